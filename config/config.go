@@ -44,6 +44,32 @@ type Config struct {
 
 	// User-Agent 配置
 	UserAgent string
+
+	// 请求日志保留配置
+	RequestLogRetentionDays int
+
+	// 主动健康探测配置
+	HealthProbeInterval int
+	HealthProbeModels   bool
+
+	// 多实例健康状态同步配置
+	HealthSyncInterval int
+
+	// 健康状态变化历史保留配置
+	HealthHistoryRetentionDays int
+
+	// 告警投递记录保留配置
+	AlertDeliveryRetentionDays int
+
+	// 声明式平台配置文件
+	ProvidersFile string
+
+	// 上游模型列表自动同步配置
+	ModelSyncInterval int
+
+	// 异步任务配置
+	TaskConcurrency   int
+	TaskRetentionDays int
 }
 
 // LoadConfig 加载配置
@@ -73,6 +99,24 @@ func LoadConfig() *Config {
 		ModelMapping:         env.ModelMapping,
 		LogLevel:             env.LogLevel,
 		UserAgent:            env.UserAgent,
+
+		RequestLogRetentionDays: env.RequestLogRetentionDays,
+
+		HealthProbeInterval: env.HealthProbeInterval,
+		HealthProbeModels:   env.HealthProbeModels,
+
+		HealthSyncInterval: env.HealthSyncInterval,
+
+		HealthHistoryRetentionDays: env.HealthHistoryRetentionDays,
+
+		AlertDeliveryRetentionDays: env.AlertDeliveryRetentionDays,
+
+		ProvidersFile: env.ProvidersFile,
+
+		ModelSyncInterval: env.ModelSyncInterval,
+
+		TaskConcurrency:   env.TaskConcurrency,
+		TaskRetentionDays: env.TaskRetentionDays,
 	}
 
 	// 从命令行参数加载配置
@@ -121,5 +165,31 @@ func (c *Config) loadFlags() {
 	// User-Agent 参数
 	flag.StringVar(&c.UserAgent, "user-agent", c.UserAgent, "User-Agent 配置，空则透传客户端 UA，\"default\" 使用 Go net/http 默认值，其他字符串则复写")
 
+	// 请求日志保留参数
+	flag.IntVar(&c.RequestLogRetentionDays, "request-log-retention-days", c.RequestLogRetentionDays, "原始请求日志保留天数，超期日志汇总为小时统计后清理，0 表示永久保留")
+
+	// 主动健康探测参数
+	flag.IntVar(&c.HealthProbeInterval, "health-probe-interval", c.HealthProbeInterval, "主动健康探测周期（秒），0 表示不自动探测")
+	flag.BoolVar(&c.HealthProbeModels, "health-probe-models", c.HealthProbeModels, "主动探测时对模型发送 1 token 补全请求（会产生少量费用）")
+
+	// 多实例健康状态同步参数
+	flag.IntVar(&c.HealthSyncInterval, "health-sync-interval", c.HealthSyncInterval, "多实例健康状态同步周期（秒），0 表示不同步，多副本部署时所有实例需开启")
+
+	// 健康状态变化历史保留参数
+	flag.IntVar(&c.HealthHistoryRetentionDays, "health-history-retention-days", c.HealthHistoryRetentionDays, "健康状态变化历史保留天数，0 表示永久保留")
+
+	// 告警投递记录保留参数
+	flag.IntVar(&c.AlertDeliveryRetentionDays, "alert-delivery-retention-days", c.AlertDeliveryRetentionDays, "告警投递记录保留天数，0 表示永久保留")
+
+	// 声明式平台配置文件参数
+	flag.StringVar(&c.ProvidersFile, "providers-file", c.ProvidersFile, "声明式平台配置文件路径（YAML），启动时及文件变更时同步到数据库")
+
+	// 上游模型列表自动同步参数
+	flag.IntVar(&c.ModelSyncInterval, "model-sync-interval", c.ModelSyncInterval, "上游模型列表自动同步周期（秒），仅同步开启自动同步的平台，0 表示不同步")
+
+	// 异步任务参数
+	flag.IntVar(&c.TaskConcurrency, "task-concurrency", c.TaskConcurrency, "同时执行的异步任务数量（批量操作、模型同步、日志清理等）")
+	flag.IntVar(&c.TaskRetentionDays, "task-retention-days", c.TaskRetentionDays, "已结束异步任务的保留天数，0 表示永久保留")
+
 	flag.Parse()
 }
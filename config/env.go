@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
 
 // Env 环境变量配置
 type Env struct {
@@ -25,6 +30,23 @@ type Env struct {
 	ModelMapping         string // 模型映射规则，格式：key1:value1,key2:value2
 	LogLevel             string // 日志输出等级
 	UserAgent            string // User-Agent 配置
+
+	RequestLogRetentionDays int // 原始请求日志保留天数，0 表示永久保留
+
+	HealthProbeInterval int  // 主动健康探测周期（秒），0 表示不探测
+	HealthProbeModels   bool // 主动探测是否包含模型补全请求
+	HealthSyncInterval  int  // 多实例健康状态同步周期（秒），0 表示不同步
+
+	HealthHistoryRetentionDays int // 健康状态变化历史保留天数，0 表示永久保留
+
+	AlertDeliveryRetentionDays int // 告警投递记录保留天数，0 表示永久保留
+
+	ProvidersFile string // 声明式平台配置文件路径，为空表示不启用
+
+	ModelSyncInterval int // 上游模型列表自动同步周期（秒），0 表示不同步
+
+	TaskConcurrency   int // 同时执行的异步任务数量
+	TaskRetentionDays int // 已结束异步任务的保留天数，0 表示永久保留
 }
 
 // LoadEnv 从环境变量加载配置
@@ -51,6 +73,23 @@ func LoadEnv() *Env {
 		ModelMapping:         getEnvOrDefault("MODEL_MAPPING", ""),
 		LogLevel:             getEnvOrDefault("LOG_LEVEL", "INFO"),
 		UserAgent:            getEnvOrDefault("USER_AGENT", ""),
+
+		RequestLogRetentionDays: getEnvIntOrDefault("REQUEST_LOG_RETENTION_DAYS", 0),
+
+		HealthProbeInterval: getEnvIntOrDefault("HEALTH_PROBE_INTERVAL", 0),
+		HealthProbeModels:   getEnvOrDefault("HEALTH_PROBE_MODELS", "") == "true",
+		HealthSyncInterval:  getEnvIntOrDefault("HEALTH_SYNC_INTERVAL", 0),
+
+		HealthHistoryRetentionDays: getEnvIntOrDefault("HEALTH_HISTORY_RETENTION_DAYS", 90),
+
+		AlertDeliveryRetentionDays: getEnvIntOrDefault("ALERT_DELIVERY_RETENTION_DAYS", 0),
+
+		ProvidersFile: getEnvOrDefault("PROVIDERS_FILE", ""),
+
+		ModelSyncInterval: getEnvIntOrDefault("MODEL_SYNC_INTERVAL", 0),
+
+		TaskConcurrency:   getEnvIntOrDefault("TASK_CONCURRENCY", 1),
+		TaskRetentionDays: getEnvIntOrDefault("TASK_RETENTION_DAYS", 0),
 	}
 }
 
@@ -61,3 +100,18 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvIntOrDefault 获取整数类型的环境变量，不存在时返回默认值，格式无效时记录警告并返回默认值
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		slog.Warn("环境变量不是有效的整数，使用默认值", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return n
+}
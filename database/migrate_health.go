@@ -141,6 +141,7 @@ func cleanOrphanedHealthRecords(db *gorm.DB) error {
 	platformIDs := make(map[uint]bool)
 	apiKeyIDs := make(map[uint]bool)
 	modelIDs := make(map[uint]bool)
+	keyModelIDs := make(map[uint]bool)
 
 	for _, record := range healthRecords {
 		switch record.ResourceType {
@@ -150,6 +151,8 @@ func cleanOrphanedHealthRecords(db *gorm.DB) error {
 			apiKeyIDs[record.ResourceID] = true
 		case types.ResourceTypeModel:
 			modelIDs[record.ResourceID] = true
+		case types.ResourceTypeKeyModel:
+			keyModelIDs[record.ResourceID] = true
 		}
 	}
 
@@ -229,6 +232,39 @@ func cleanOrphanedHealthRecords(db *gorm.DB) error {
 		}
 	}
 
+	// 检查密钥-模型组合资源，密钥或模型任一不存在即视为孤立
+	if len(keyModelIDs) > 0 {
+		var existingAPIKeys []types.APIKey
+		if err := db.Select("id").Find(&existingAPIKeys).Error; err != nil {
+			return fmt.Errorf("查询密钥表失败：%w", err)
+		}
+		var existingModels []types.Model
+		if err := db.Select("id").Find(&existingModels).Error; err != nil {
+			return fmt.Errorf("查询模型表失败：%w", err)
+		}
+
+		keyMap := make(map[uint]bool)
+		for _, apiKey := range existingAPIKeys {
+			keyMap[apiKey.ID] = true
+		}
+		modelMap := make(map[uint]bool)
+		for _, model := range existingModels {
+			modelMap[model.ID] = true
+		}
+
+		for id := range keyModelIDs {
+			keyID, modelID := types.SplitKeyModelResourceID(id)
+			if !keyMap[keyID] || !modelMap[modelID] {
+				toDelete = append(toDelete, types.Health{
+					ResourceType: types.ResourceTypeKeyModel,
+					ResourceID:   id,
+				})
+				deletedCount++
+				slog.Debug("发现孤立的密钥-模型组合健康记录", "api_key_id", keyID, "model_id", modelID)
+			}
+		}
+	}
+
 	// 批量删除孤立记录
 	if len(toDelete) > 0 {
 		for _, record := range toDelete {
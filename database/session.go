@@ -0,0 +1,25 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// CleanSession 基于 db 创建不携带表名、模型等语句状态的新会话
+//
+// gorm-gen 的 UnderlyingDB 会带上所属表的作用域，直接用于其他表的原生查询会串表；
+// 该函数清除这些语句状态，db 位于事务中时新会话仍在同一事务内执行。
+func CleanSession(ctx context.Context, db *gorm.DB) *gorm.DB {
+	session := db.Session(&gorm.Session{NewDB: true}).WithContext(ctx)
+
+	if session.Statement != nil {
+		session.Statement.Table = ""
+		session.Statement.TableExpr = nil
+		session.Statement.Model = nil
+		session.Statement.Schema = nil
+		session.Statement.Dest = nil
+	}
+
+	return session
+}
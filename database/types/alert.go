@@ -0,0 +1,78 @@
+package types
+
+import "time"
+
+// 告警规则类型。
+const (
+	AlertRuleTypeHealthUnavailable = "health_unavailable" // 资源进入不可用状态
+	AlertRuleTypeKeyUnauthorized   = "key_unauthorized"   // 密钥返回 401
+	AlertRuleTypeErrorRate         = "error_rate"         // 模型错误率超过阈值
+	AlertRuleTypeQuotaExhausted    = "quota_exhausted"    // 上游额度耗尽
+	AlertRuleTypeKeyDisabled       = "key_disabled"       // 密钥因永久失败被自动禁用
+)
+
+// 告警 Webhook 载荷格式。
+const (
+	AlertWebhookFormatJSON     = "json"     // 通用 JSON
+	AlertWebhookFormatSlack    = "slack"    // Slack Incoming Webhook
+	AlertWebhookFormatFeishu   = "feishu"   // 飞书自定义机器人
+	AlertWebhookFormatDingTalk = "dingtalk" // 钉钉自定义机器人
+)
+
+// 告警投递状态。
+const (
+	AlertDeliveryStatusSucceeded = "succeeded"
+	AlertDeliveryStatusFailed    = "failed"
+)
+
+// AlertRule 表示告警规则。
+//
+// 不同类型的规则使用不同的条件字段：错误率规则使用 Threshold、WindowMinutes 与 MinRequests；
+// ResourceType、ResourceID 与 ModelName 为可选的范围过滤，零值表示不过滤。
+type AlertRule struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`               // 规则 ID
+	Name    string `gorm:"size:255;not null" json:"name"`      // 规则名称
+	Type    string `gorm:"size:64;not null;index" json:"type"` // 规则类型
+	Enabled bool   `gorm:"not null" json:"enabled"`            // 是否启用
+
+	// 范围过滤
+	ResourceType ResourceType `gorm:"default:0" json:"resource_type,omitempty"` // 资源类型（健康规则）
+	ResourceID   uint         `gorm:"default:0" json:"resource_id,omitempty"`   // 资源 ID（健康规则与密钥规则）
+	ModelName    string       `gorm:"size:255" json:"model_name,omitempty"`     // 原始模型名称（错误率与额度规则）
+
+	// 错误率条件
+	Threshold     float64 `json:"threshold,omitempty"`      // 错误率阈值（百分比）
+	WindowMinutes int     `json:"window_minutes,omitempty"` // 统计窗口（分钟）
+	MinRequests   int     `json:"min_requests,omitempty"`   // 窗口内最少请求数，避免低流量误报
+
+	// 投递配置
+	CooldownSeconds int    `gorm:"not null" json:"cooldown_seconds"`       // 同一对象重复告警的冷却时间（秒）
+	WebhookURL      string `gorm:"type:text;not null" json:"webhook_url"`  // Webhook 地址
+	WebhookFormat   string `gorm:"size:32;not null" json:"webhook_format"` // 载荷格式
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AlertDelivery 表示一次告警投递记录。
+type AlertDelivery struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`                     // 记录 ID
+	RuleID       uint      `gorm:"index;not null" json:"rule_id"`            // 规则 ID
+	RuleName     string    `gorm:"size:255" json:"rule_name"`                // 投递时的规则名称
+	EventType    string    `gorm:"size:64;not null" json:"event_type"`       // 事件类型（同规则类型，测试投递为 test）
+	Subject      string    `gorm:"size:255;not null" json:"subject"`         // 告警对象（用于去重）
+	Message      string    `gorm:"type:text" json:"message"`                 // 告警消息
+	Status       string    `gorm:"size:32;not null;index" json:"status"`     // 投递状态
+	HTTPStatus   *int      `json:"http_status,omitempty"`                    // Webhook 响应状态码
+	ErrorMessage string    `gorm:"type:text" json:"error_message,omitempty"` // 投递失败原因
+	Payload      string    `gorm:"type:text" json:"payload"`                 // 实际发送的载荷
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`                  // 投递时间
+}
+
+// AlertFiring 记录规则对同一告警对象的最近触发时间，用于跨实例共享冷却期。
+type AlertFiring struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`                                                                   // 记录 ID
+	RuleID      uint      `gorm:"uniqueIndex:idx_alert_firings_rule_subject,priority:1;not null" json:"rule_id"`          // 规则 ID
+	Subject     string    `gorm:"uniqueIndex:idx_alert_firings_rule_subject,priority:2;size:255;not null" json:"subject"` // 告警对象
+	LastFiredAt time.Time `gorm:"index;not null" json:"last_fired_at"`                                                    // 最近触发时间
+}
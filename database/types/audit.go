@@ -0,0 +1,28 @@
+package types
+
+import "time"
+
+// 审计结果。
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailed  = "failed"
+)
+
+// AuditLog 表示一条控制面审计记录。
+//
+// Before、After 与 Diff 为 JSON 文本，敏感字段（如密钥值、令牌）在落库前已脱敏。
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`              // 记录 ID
+	Actor      string    `gorm:"size:128;index" json:"actor"`       // 操作者标识（管理令牌指纹）
+	ClientIP   string    `gorm:"size:64" json:"client_ip"`          // 客户端 IP
+	RequestID  string    `gorm:"size:128" json:"request_id"`        // 请求 ID
+	Action     string    `gorm:"size:64;index" json:"action"`       // 操作，如 platform.update
+	Resource   string    `gorm:"size:64;index" json:"resource"`     // 资源类型，如 platform
+	ResourceID string    `gorm:"size:255;index" json:"resource_id"` // 资源标识
+	Result     string    `gorm:"size:16;index" json:"result"`       // 结果：success/failed
+	Detail     string    `gorm:"type:text" json:"detail"`           // 详细说明
+	Before     string    `gorm:"type:text" json:"-"`                // 变更前快照
+	After      string    `gorm:"type:text" json:"-"`                // 变更后快照
+	Diff       string    `gorm:"type:text" json:"-"`                // 字段级差异
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`           // 记录时间
+}
@@ -24,6 +24,7 @@ const (
 	ResourceTypePlatform ResourceType = iota + 1 // 平台级
 	ResourceTypeAPIKey                           // 密钥级
 	ResourceTypeModel                            // 模型级
+	ResourceTypeKeyModel                         // 密钥-模型组合级
 )
 
 // DBIntType 自定义整数类型，用于根据数据库类型动态设置字段类型
@@ -47,9 +48,10 @@ type Health struct {
 	Status HealthStatus `gorm:"not null;index"` // 健康状态
 
 	// 指数退避相关
-	RetryCount      int        `gorm:"default:0"` // 重试次数
-	NextAvailableAt *time.Time `gorm:"index"`     // 下次可用时间
-	BackoffDuration int64      `gorm:"default:0"` // 当前退避时长 (秒)
+	RetryCount           int        `gorm:"default:0"` // 重试次数
+	NextAvailableAt      *time.Time `gorm:"index"`     // 下次可用时间
+	BackoffDuration      int64      `gorm:"default:0"` // 当前退避时长 (秒)
+	HalfOpenSuccessCount int        `gorm:"default:0"` // 退避结束后的连续成功次数（半开状态）
 
 	// 状态详情
 	LastError     string `gorm:"type:text"` // 最后错误信息
@@ -61,6 +63,10 @@ type Health struct {
 	LastErrorFrom           string `gorm:"type:text"` // 最后错误来源
 	LastCauseMessage        string `gorm:"type:text"` // 最后根因文本
 
+	// 永久禁用（密钥吊销、额度耗尽等不可自动恢复的失败）
+	DisabledReason string     `gorm:"size:64"` // 自动禁用原因，为空表示未被自动禁用
+	DisabledAt     *time.Time // 自动禁用时间
+
 	LastCheckAt   time.Time  `gorm:"not null"` // 最后检查时间
 	LastSuccessAt *time.Time // 最后成功时间
 
@@ -68,6 +74,9 @@ type Health struct {
 	SuccessCount int `gorm:"default:0"` // 成功次数
 	ErrorCount   int `gorm:"default:0"` // 错误次数
 
+	// 乐观锁版本号，每次写入加一；多实例写入同一资源时以版本号比较并交换，避免基于过期缓存的写入覆盖其他实例的变更
+	Version int64 `gorm:"not null;default:0"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+// HealthChange 表示健康记录或健康策略的一次变更通知，用于多实例间同步缓存。
+//
+// ResourceType 为 0 时表示健康策略变更，ResourceID 为平台 ID（0 为全局策略）。
+type HealthChange struct {
+	ID           uint         `gorm:"primaryKey" json:"id"`                // 变更序号
+	ResourceType ResourceType `gorm:"not null" json:"resource_type"`       // 资源类型
+	ResourceID   uint         `gorm:"not null" json:"resource_id"`         // 资源 ID
+	InstanceID   string       `gorm:"size:64;not null" json:"instance_id"` // 写入实例标识
+	CreatedAt    time.Time    `gorm:"index;not null" json:"created_at"`    // 变更时间
+}
@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// HealthTransition 表示资源健康状态的一次变化记录。
+type HealthTransition struct {
+	ID           uint         `gorm:"primaryKey" json:"id"`                                                           // 记录 ID
+	ResourceType ResourceType `gorm:"index:idx_health_transitions_resource,priority:1;not null" json:"resource_type"` // 资源类型
+	ResourceID   uint         `gorm:"index:idx_health_transitions_resource,priority:2;not null" json:"resource_id"`   // 资源 ID
+	FromStatus   HealthStatus `gorm:"not null" json:"from_status"`                                                    // 变化前状态
+	ToStatus     HealthStatus `gorm:"not null" json:"to_status"`                                                      // 变化后状态
+	ErrorCode    string       `gorm:"type:text" json:"error_code,omitempty"`                                          // 稳定错误码
+	HTTPStatus   *int         `json:"http_status,omitempty"`                                                          // 上游 HTTP 状态码
+	ErrorMessage string       `gorm:"type:text" json:"error_message,omitempty"`                                       // 错误展示消息
+	CreatedAt    time.Time    `gorm:"index:idx_health_transitions_resource,priority:3;not null" json:"created_at"`    // 变化时间
+}
@@ -0,0 +1,18 @@
+//go:build amd64 || arm64 || loong64 || mips64 || mips64le || ppc64 || ppc64le || riscv64 || s390x || wasm
+
+// 密钥-模型组合的资源 ID 将两个 ID 编码在同一个 uint 中，依赖 uint 为 64 位；
+// 32 位平台上编码会截断密钥 ID，因此仅在 64 位架构上编译，32 位构建会因缺少这两个函数而失败。
+
+package types
+
+// KeyModelResourceID 将密钥 ID 与模型 ID 编码为密钥-模型组合的资源 ID
+//
+// 高 32 位为密钥 ID，低 32 位为模型 ID。
+func KeyModelResourceID(keyID, modelID uint) uint {
+	return uint(uint64(keyID)<<32 | uint64(uint32(modelID)))
+}
+
+// SplitKeyModelResourceID 从密钥-模型组合的资源 ID 中解析密钥 ID 与模型 ID
+func SplitKeyModelResourceID(resourceID uint) (keyID, modelID uint) {
+	return uint(uint64(resourceID) >> 32), uint(uint32(resourceID))
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// 健康策略计入的错误类别。
+const (
+	HealthErrorClassNetwork   = "network"    // 无 HTTP 响应（连接失败、超时等）
+	HealthErrorClassAuth      = "auth"       // 401/403
+	HealthErrorClassRateLimit = "rate_limit" // 429
+	HealthErrorClassClient    = "client"     // 其他 4xx
+	HealthErrorClassServer    = "server"     // 5xx
+)
+
+// HealthPolicy 表示健康退避与熔断策略。
+//
+// PlatformID 为 0 的记录为全局策略，其余为平台级覆盖，作用于平台及其密钥、模型。
+type HealthPolicy struct {
+	ID                   uint      `gorm:"primaryKey" json:"-"`
+	PlatformID           uint      `gorm:"uniqueIndex;not null" json:"platform_id"`        // 平台 ID，0 表示全局策略
+	BaseBackoffSeconds   int64     `gorm:"not null" json:"base_backoff_seconds"`           // 初始退避时长（秒）
+	MaxBackoffSeconds    int64     `gorm:"not null" json:"max_backoff_seconds"`            // 最大退避时长（秒）
+	Multiplier           float64   `gorm:"not null" json:"multiplier"`                     // 退避倍数
+	WarningThreshold     int       `gorm:"not null" json:"warning_threshold"`              // 连续失败多少次后进入警告并开始退避
+	UnavailableThreshold int       `gorm:"not null" json:"unavailable_threshold"`          // 连续失败多少次后标记不可用，0 表示仅在退避达到上限时标记
+	HalfOpenProbes       int       `gorm:"not null" json:"half_open_probes"`               // 退避结束后需连续成功多少次才恢复为可用
+	ErrorClasses         []string  `gorm:"serializer:json;type:text" json:"error_classes"` // 计入失败的错误类别，空表示全部计入
+	CreatedAt            time.Time `json:"created_at"`                                     // 创建时间
+	UpdatedAt            time.Time `json:"updated_at"`                                     // 更新时间
+}
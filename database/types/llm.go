@@ -18,13 +18,18 @@ type Endpoint struct {
 	IsDefault       bool              `gorm:"index:idx_endpoints_platform_default,priority:2" json:"is_default"`                                                       // 是否为默认端点
 }
 
+// PlatformManagedByFile 表示平台由声明式配置文件管理
+const PlatformManagedByFile = "file"
+
 // 平台表 (platforms)
 type Platform struct {
-	ID        uint            `gorm:"primaryKey" json:"id"`              // 平台 ID
-	Name      string          `gorm:"index" json:"name"`                 // 平台名称
-	BaseURL   string          `json:"base_url"`                          // 基础 URL
-	RateLimit RateLimitConfig `gorm:"serializer:json" json:"rate_limit"` // 限流配置
-	Endpoints []Endpoint      `json:"endpoints,omitempty"`               // 平台端点列表
+	ID            uint            `gorm:"primaryKey" json:"id"`                          // 平台 ID
+	Name          string          `gorm:"index" json:"name"`                             // 平台名称
+	BaseURL       string          `json:"base_url"`                                      // 基础 URL
+	RateLimit     RateLimitConfig `gorm:"serializer:json" json:"rate_limit"`             // 限流配置
+	ManagedBy     string          `gorm:"index" json:"managed_by,omitempty"`             // 管理来源，非空时平台及其端点、模型、密钥不能通过接口修改
+	ModelAutoSync bool            `gorm:"not null;default:false" json:"model_auto_sync"` // 是否参与上游模型列表定时同步
+	Endpoints     []Endpoint      `json:"endpoints,omitempty"`                           // 平台端点列表
 }
 
 // 模型表 (models)
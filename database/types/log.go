@@ -2,6 +2,8 @@ package types
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // RequestLog 表示单个请求的统计信息
@@ -9,6 +11,8 @@ type RequestLog struct {
 	ID uint `json:"id"` // 唯一标识符
 
 	// 请求基本信息
+	RequestID         string    `gorm:"index;size:128" json:"request_id,omitempty"` // 数据面请求 ID（X-Request-ID）
+	RequestKey        uint64    `gorm:"-" json:"-"`                                 // 进程内的数据面请求标识，仅供实时统计关联，不落库
 	Timestamp         time.Time `gorm:"index" json:"timestamp"`                     // 请求时间
 	ModelName         string    `gorm:"index" json:"model_name"`                    // 模型名称
 	OriginalModelName string    `gorm:"index" json:"original_model_name,omitempty"` // 原始模型名称（用户请求中的模型名称）
@@ -50,4 +54,106 @@ type RequestLog struct {
 	PromptTokens     *int `json:"prompt_tokens"`     // 提示 Token 数
 	CompletionTokens *int `json:"completion_tokens"` // 完成 Token 数
 	TotalTokens      *int `json:"total_tokens"`      // 总 Token 数
+
+	// 费用（按请求时生效的模型单价计算，无匹配单价或无 Token 统计时为空）
+	Cost         *float64 `json:"cost,omitempty"`                        // 请求费用
+	CostCurrency *string  `gorm:"size:8" json:"cost_currency,omitempty"` // 费用币种
+}
+
+// BeforeSave 将请求时间统一为 UTC，使按时间范围查询与小时汇总不受服务器时区影响
+func (l *RequestLog) BeforeSave(*gorm.DB) error {
+	l.Timestamp = l.Timestamp.UTC()
+	return nil
+}
+
+// RequestLogHourlyStat 表示按小时、模型与平台汇总的请求统计。
+//
+// 每条请求日志写入时增量累加到该表；原始日志超过保留期被清理前也会重算该表，保证历史统计不丢失。
+type RequestLogHourlyStat struct {
+	ID uint `json:"id"` // 唯一标识符
+
+	// 汇总维度
+	BucketStart time.Time `gorm:"uniqueIndex:idx_request_log_hourly_stats_bucket,priority:1;not null" json:"bucket_start"`                   // 小时桶起始时间
+	ModelName   string    `gorm:"uniqueIndex:idx_request_log_hourly_stats_bucket,priority:2;size:255;not null;default:''" json:"model_name"` // 原始模型名称（用户请求中的模型名称）
+	PlatformID  uint      `gorm:"uniqueIndex:idx_request_log_hourly_stats_bucket,priority:3;index;not null;default:0" json:"platform_id"`    // 平台 ID
+
+	// 请求计数
+	RequestCount int64 `json:"request_count"` // 请求数
+	SuccessCount int64 `json:"success_count"` // 成功数
+	StreamCount  int64 `json:"stream_count"`  // 流式请求数
+
+	// 耗时汇总（微秒）
+	DurationSum    int64 `json:"duration_sum"`     // 总用时之和
+	FirstByteCount int64 `json:"first_byte_count"` // 有首字用时的请求数
+	FirstByteSum   int64 `json:"first_byte_sum"`   // 首字用时之和
+
+	// Token 汇总
+	UsageCount       int64 `json:"usage_count"`       // 带 Token 统计的请求数
+	PromptTokens     int64 `json:"prompt_tokens"`     // 提示 Token 数
+	CompletionTokens int64 `json:"completion_tokens"` // 完成 Token 数
+	TotalTokens      int64 `json:"total_tokens"`      // 总 Token 数
+}
+
+// RequestLogHourlyUsage 表示按小时、模型、平台、密钥与币种汇总的用量与费用。
+//
+// 与 RequestLogHourlyStat 同步维护，用于费用统计与按密钥的用量报表；Currency 为空表示未计费的请求。
+type RequestLogHourlyUsage struct {
+	ID uint `json:"id"` // 唯一标识符
+
+	// 汇总维度
+	BucketStart time.Time `gorm:"uniqueIndex:idx_request_log_hourly_usages_bucket,priority:1;not null" json:"bucket_start"`                   // 小时桶起始时间
+	ModelName   string    `gorm:"uniqueIndex:idx_request_log_hourly_usages_bucket,priority:2;size:255;not null;default:''" json:"model_name"` // 原始模型名称
+	PlatformID  uint      `gorm:"uniqueIndex:idx_request_log_hourly_usages_bucket,priority:3;not null;default:0" json:"platform_id"`          // 平台 ID
+	APIKeyID    uint      `gorm:"uniqueIndex:idx_request_log_hourly_usages_bucket,priority:4;index;not null;default:0" json:"api_key_id"`     // 密钥 ID
+	Currency    string    `gorm:"uniqueIndex:idx_request_log_hourly_usages_bucket,priority:5;size:8;not null;default:''" json:"currency"`     // 费用币种
+
+	// 用量与费用
+	RequestCount     int64   `json:"request_count"`     // 请求数
+	UsageCount       int64   `json:"usage_count"`       // 带 Token 统计的请求数
+	PromptTokens     int64   `json:"prompt_tokens"`     // 提示 Token 数
+	CompletionTokens int64   `json:"completion_tokens"` // 完成 Token 数
+	TotalTokens      int64   `json:"total_tokens"`      // 总 Token 数
+	Cost             float64 `json:"cost"`              // 费用
+}
+
+// RequestLogHourlyError 表示按小时、模型、平台、密钥与结构化错误字段汇总的失败次数。
+//
+// 与 RequestLogHourlyStat 同步维护，用于错误分析；原始日志被清理后错误计数与趋势仍可查询。
+type RequestLogHourlyError struct {
+	ID uint `json:"id"` // 唯一标识符
+
+	// 汇总维度
+	BucketStart       time.Time `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:1;not null" json:"bucket_start"`                            // 小时桶起始时间
+	ModelName         string    `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:2;size:255;not null;default:''" json:"model_name"`          // 原始模型名称
+	PlatformID        uint      `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:3;not null;default:0" json:"platform_id"`                   // 平台 ID
+	APIKeyID          uint      `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:4;not null;default:0" json:"api_key_id"`                    // 密钥 ID
+	ErrorCode         string    `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:5;size:64;not null;default:''" json:"error_code"`           // 结构化错误码
+	ErrorFrom         string    `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:6;size:32;not null;default:''" json:"error_from"`           // 错误来源
+	HTTPStatus        int       `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:7;not null;default:0" json:"http_status"`                   // 上游 HTTP 状态码，0 表示无
+	UpstreamErrorType string    `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:8;size:128;not null;default:''" json:"upstream_error_type"` // 上游错误类型
+	UpstreamErrorCode string    `gorm:"uniqueIndex:idx_request_log_hourly_errors_bucket,priority:9;size:128;not null;default:''" json:"upstream_error_code"` // 上游错误码
+
+	RequestCount int64 `json:"request_count"` // 失败次数
+}
+
+// 延迟分布指标
+const (
+	LatencyMetricFirstByte = "first_byte" // 首字用时
+	LatencyMetricDuration  = "duration"   // 总用时
+)
+
+// RequestLogLatencyBin 表示按小时、模型与平台汇总的延迟分布桶。
+//
+// 桶按对数刻度划分，用于在不读取原始日志的情况下近似计算延迟分位数。
+type RequestLogLatencyBin struct {
+	ID uint `json:"id"` // 唯一标识符
+
+	// 汇总维度
+	BucketStart time.Time `gorm:"uniqueIndex:idx_request_log_latency_bins_bucket,priority:1;not null" json:"bucket_start"`                   // 小时桶起始时间
+	ModelName   string    `gorm:"uniqueIndex:idx_request_log_latency_bins_bucket,priority:2;size:255;not null;default:''" json:"model_name"` // 原始模型名称
+	PlatformID  uint      `gorm:"uniqueIndex:idx_request_log_latency_bins_bucket,priority:3;not null;default:0" json:"platform_id"`          // 平台 ID
+	Metric      string    `gorm:"uniqueIndex:idx_request_log_latency_bins_bucket,priority:4;size:32;not null" json:"metric"`                 // 延迟指标
+	Bin         int       `gorm:"uniqueIndex:idx_request_log_latency_bins_bucket,priority:5;not null" json:"bin"`                            // 对数刻度桶序号
+
+	RequestCount int64 `json:"request_count"` // 落入该桶的请求数
 }
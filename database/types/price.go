@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+// DefaultPriceCurrency 为未指定币种时使用的默认币种
+const DefaultPriceCurrency = "USD"
+
+// ModelPrice 表示模型的 Token 单价，单价均以每百万 Token 计。
+//
+// PlatformID 为 0 时对所有平台生效；同一平台与模型可按生效时间保留多条单价，
+// 请求按其发生时已生效的最新单价计费，平台专属单价优先于全局单价。
+type ModelPrice struct {
+	ID uint `gorm:"primaryKey" json:"id"` // 唯一标识符
+
+	// 适用范围
+	PlatformID    uint      `gorm:"uniqueIndex:idx_model_prices_scope,priority:1;not null;default:0" json:"platform_id"` // 平台 ID，0 表示全部平台
+	ModelName     string    `gorm:"uniqueIndex:idx_model_prices_scope,priority:2;size:255;not null" json:"model_name"`   // 模型名称，匹配上游模型名称或原始模型名称
+	EffectiveFrom time.Time `gorm:"uniqueIndex:idx_model_prices_scope,priority:3;not null" json:"effective_from"`        // 生效时间
+
+	// 单价
+	Currency    string  `gorm:"size:8;not null" json:"currency"` // 币种，如 USD、CNY
+	InputPrice  float64 `json:"input_price"`                     // 输入单价
+	OutputPrice float64 `json:"output_price"`                    // 输出单价
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
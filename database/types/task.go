@@ -7,6 +7,25 @@ const (
 	ModelBatchTaskTypeAdd    = "model.batch_add"
 	ModelBatchTaskTypeUpdate = "model.batch_update"
 	ModelBatchTaskTypeDelete = "model.batch_delete"
+	ModelBatchTaskTypeSync   = "model.sync"
+)
+
+// 密钥批量任务类型，复用模型批量任务运行时。
+const (
+	KeyBatchTaskTypeAdd          = "key.batch_add"
+	KeyBatchTaskTypeDelete       = "key.batch_delete"
+	KeyBatchTaskTypeUpdateModels = "key.batch_update_models"
+)
+
+// 复用任务运行时的其他任务类型。
+const (
+	TaskTypeRequestLogRetention      = "request_log.retention"
+	TaskTypeRequestLogRollupBackfill = "request_log.rollup_backfill"
+	TaskTypeRequestLogCostRecompute  = "request_log.cost_recompute"
+	TaskTypeHealthProbe              = "health.probe"
+	TaskTypeHealthHistoryRetention   = "health.history_retention"
+	TaskTypeAlertDeliveryRetention   = "alert.delivery_retention"
+	TaskTypeTaskRetention            = "task.retention"
 )
 
 // 模型批量任务状态。
@@ -15,19 +34,28 @@ const (
 	ModelBatchTaskStatusRunning   = "running"
 	ModelBatchTaskStatusSucceeded = "succeeded"
 	ModelBatchTaskStatusFailed    = "failed"
+	ModelBatchTaskStatusCanceled  = "canceled"
 )
 
 // ModelBatchTask 表示模型批量异步任务。
 type ModelBatchTask struct {
-	ID           uint       `gorm:"primaryKey" json:"id"`
-	Type         string     `gorm:"index:idx_model_batch_tasks_status_type,priority:2;size:64;not null" json:"type"`
-	Status       string     `gorm:"index:idx_model_batch_tasks_status_type,priority:1;size:32;not null" json:"status"`
-	PlatformID   uint       `gorm:"index;not null" json:"platform_id"`
-	Payload      string     `gorm:"type:text;not null" json:"payload"`
-	Result       string     `gorm:"type:text" json:"result,omitempty"`
-	ErrorMessage string     `gorm:"type:text" json:"error_message,omitempty"`
-	StartedAt    *time.Time `json:"started_at,omitempty"`
-	FinishedAt   *time.Time `json:"finished_at,omitempty"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Type            string     `gorm:"index:idx_model_batch_tasks_status_type,priority:2;size:64;not null" json:"type"`
+	Status          string     `gorm:"index:idx_model_batch_tasks_status_type,priority:1;size:32;not null" json:"status"`
+	PlatformID      uint       `gorm:"index;not null" json:"platform_id"`
+	Payload         string     `gorm:"type:text;not null" json:"payload"`
+	Result          string     `gorm:"type:text" json:"result,omitempty"`
+	ErrorMessage    string     `gorm:"type:text" json:"error_message,omitempty"`
+	Progress        int        `gorm:"not null;default:0" json:"progress"`                       // 执行进度百分比（0-100）
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`                       // 已执行次数
+	MaxAttempts     int        `gorm:"not null;default:1" json:"max_attempts"`                   // 最大执行次数，失败后按退避间隔重试
+	NextRunAt       *time.Time `json:"next_run_at,omitempty"`                                    // 重试任务的最早执行时间
+	CancelRequested bool       `gorm:"not null;default:false" json:"cancel_requested"`           // 运行中的任务是否已被请求取消
+	LeaseOwner      string     `gorm:"size:64;not null;default:''" json:"lease_owner,omitempty"` // 持有执行租约的实例标识
+	LeaseExpiresAt  *time.Time `json:"lease_expires_at,omitempty"`                               // 执行租约到期时间，到期未续约的任务可被其他实例接管
+	PeriodKey       *string    `gorm:"size:128;uniqueIndex" json:"period_key,omitempty"`         // 周期任务所属周期的唯一标识，保证多实例下每个周期只提交一次
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
@@ -4,6 +4,9 @@ package types
 var Types = []interface{}{
 	// Health
 	Health{},
+	HealthPolicy{},
+	HealthTransition{},
+	HealthChange{},
 
 	// Platform
 	Platform{},
@@ -13,6 +16,19 @@ var Types = []interface{}{
 
 	// Stats
 	RequestLog{},
+	RequestLogHourlyStat{},
+	RequestLogLatencyBin{},
+	RequestLogHourlyUsage{},
+	RequestLogHourlyError{},
+	ModelPrice{},
+
+	// Alerts
+	AlertRule{},
+	AlertDelivery{},
+	AlertFiring{},
+
+	// Audit
+	AuditLog{},
 
 	// Async Tasks
 	ModelBatchTask{},
@@ -3,6 +3,7 @@ package query
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -72,3 +73,23 @@ func OptionalUint(c *gin.Context, key string) (*uint, error) {
 	u := uint(v)
 	return &u, nil
 }
+
+// OptionalTime 解析可选时间参数，支持 RFC3339 格式与 Unix 毫秒时间戳。缺失返回 nil，非法返回 error。
+func OptionalTime(c *gin.Context, key string) (*time.Time, error) {
+	valStr := c.Query(key)
+	if valStr == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, valStr); err == nil {
+		return &t, nil
+	}
+
+	ms, err := strconv.ParseInt(valStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s 参数无效，应为 RFC3339 时间或 Unix 毫秒时间戳", key)
+	}
+
+	t := time.UnixMilli(ms)
+	return &t, nil
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -163,3 +164,30 @@ func TestOptionalUint_Invalid_ReturnError(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionalTime_Valid_ParseOK(t *testing.T) {
+	cases := map[string]time.Time{
+		"start_time=2024-05-01T08:00:00Z": time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		"start_time=1714550400000":        time.UnixMilli(1714550400000),
+	}
+
+	for rawQuery, want := range cases {
+		c := newTestContext(t, rawQuery)
+		v, err := OptionalTime(c, "start_time")
+		if err != nil {
+			t.Fatalf("%s: expected nil error, got %v", rawQuery, err)
+		}
+		if v == nil || !v.Equal(want) {
+			t.Fatalf("%s: expected %v, got %v", rawQuery, want, v)
+		}
+	}
+}
+
+func TestOptionalTime_MissingOrInvalid(t *testing.T) {
+	if v, err := OptionalTime(newTestContext(t, ""), "start_time"); err != nil || v != nil {
+		t.Fatalf("expected nil, got %v, %v", v, err)
+	}
+	if _, err := OptionalTime(newTestContext(t, "start_time=yesterday"), "start_time"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,107 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// modelErrorRateRow 为模型维度的请求与失败计数
+type modelErrorRateRow struct {
+	ModelName    string
+	RequestCount int64
+	FailureCount int64
+}
+
+// loadErrorRates 统计 (since, now] 内各模型的请求与失败计数；modelName 非空时仅统计该模型
+//
+// 窗口内的整小时读取按小时预聚合的统计表，首尾不足一小时的部分读取原始请求日志，
+// 两者均为各实例共享的数据库记录，多实例部署时每个实例评估的都是全部流量。
+func loadErrorRates(ctx context.Context, modelName string, since, now time.Time) ([]modelErrorRateRow, error) {
+	since, now = since.UTC(), now.UTC()
+	hoursFrom := since.Truncate(time.Hour)
+	if hoursFrom.Before(since) {
+		hoursFrom = hoursFrom.Add(time.Hour)
+	}
+	hoursTo := now.Truncate(time.Hour)
+
+	totals := make(map[string]*modelErrorRateRow)
+	add := func(rows []modelErrorRateRow) {
+		for _, row := range rows {
+			total, ok := totals[row.ModelName]
+			if !ok {
+				total = &modelErrorRateRow{ModelName: row.ModelName}
+				totals[row.ModelName] = total
+			}
+			total.RequestCount += row.RequestCount
+			total.FailureCount += row.FailureCount
+		}
+	}
+
+	if hoursFrom.Before(hoursTo) {
+		rows, err := loadHourlyErrorRates(ctx, modelName, hoursFrom, hoursTo)
+		if err != nil {
+			return nil, err
+		}
+		add(rows)
+
+		rows, err = loadLogErrorRates(ctx, modelName, since, hoursFrom)
+		if err != nil {
+			return nil, err
+		}
+		add(rows)
+		since = hoursTo
+	}
+
+	rows, err := loadLogErrorRates(ctx, modelName, since, now)
+	if err != nil {
+		return nil, err
+	}
+	add(rows)
+
+	result := make([]modelErrorRateRow, 0, len(totals))
+	for _, total := range totals {
+		if total.RequestCount > 0 {
+			result = append(result, *total)
+		}
+	}
+	return result, nil
+}
+
+// loadHourlyErrorRates 从小时统计表读取 [from, to) 内各模型的请求与失败计数
+func loadHourlyErrorRates(ctx context.Context, modelName string, from, to time.Time) ([]modelErrorRateRow, error) {
+	db := baseDB(ctx).Model(&types.RequestLogHourlyStat{}).
+		Select("model_name, SUM(request_count) AS request_count, SUM(request_count - success_count) AS failure_count").
+		Where("bucket_start >= ? AND bucket_start < ?", from, to)
+	if modelName != "" {
+		db = db.Where("model_name = ?", modelName)
+	}
+
+	var rows []modelErrorRateRow
+	if err := db.Group("model_name").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询小时统计失败：%w", err)
+	}
+	return rows, nil
+}
+
+// loadLogErrorRates 从原始请求日志读取 (from, to] 内各模型的请求与失败计数
+func loadLogErrorRates(ctx context.Context, modelName string, from, to time.Time) ([]modelErrorRateRow, error) {
+	if !from.Before(to) {
+		return nil, nil
+	}
+
+	db := baseDB(ctx).Model(&types.RequestLog{}).
+		Select("original_model_name AS model_name, COUNT(*) AS request_count, SUM(CASE WHEN success THEN 0 ELSE 1 END) AS failure_count").
+		Where("timestamp > ? AND timestamp <= ?", from, to)
+	if modelName != "" {
+		db = db.Where("original_model_name = ?", modelName)
+	}
+
+	var rows []modelErrorRateRow
+	if err := db.Group("original_model_name").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询请求日志失败：%w", err)
+	}
+	return rows, nil
+}
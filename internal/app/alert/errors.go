@@ -0,0 +1,8 @@
+package alert
+
+import "errors"
+
+var (
+	ErrRuleNotFound    = errors.New("告警规则未找到")
+	ErrInvalidArgument = errors.New("请求参数不合法")
+)
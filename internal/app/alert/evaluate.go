@@ -0,0 +1,289 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/health"
+)
+
+// quotaErrorKeywords 为上游错误类型或错误码中表示额度耗尽的关键字
+var quotaErrorKeywords = []string{"quota", "billing", "insufficient_balance", "credit"}
+
+// ObserveHealthTransition 实现 Service 接口
+func (s *service) ObserveHealthTransition(transition health.Transition) {
+	if transition.To != types.HealthStatusUnavailable {
+		return
+	}
+
+	for _, rule := range s.rulesOfType(types.AlertRuleTypeHealthUnavailable) {
+		if rule.ResourceType != 0 && rule.ResourceType != transition.ResourceType {
+			continue
+		}
+		if rule.ResourceID != 0 && rule.ResourceID != transition.ResourceID {
+			continue
+		}
+
+		label := resourceLabel(transition.ResourceType, transition.ResourceID)
+		labels := map[string]any{
+			"resource_type": transition.ResourceType,
+			"resource_id":   transition.ResourceID,
+			"from_status":   transition.From,
+		}
+		message := fmt.Sprintf("%s 进入不可用状态", label)
+		if h := transition.Health; h != nil {
+			if reason := firstNonEmpty(h.LastErrorMessage, h.LastError); reason != "" {
+				message += "：" + reason
+			}
+			if h.LastHTTPStatus != nil {
+				labels["http_status"] = *h.LastHTTPStatus
+			}
+			if h.LastStructuredErrorCode != "" {
+				labels["error_code"] = h.LastStructuredErrorCode
+			}
+		}
+
+		s.fire(rule, Event{
+			Subject: fmt.Sprintf("resource:%d:%d", transition.ResourceType, transition.ResourceID),
+			Title:   label + " 不可用",
+			Message: message,
+			Labels:  labels,
+			FiredAt: transition.At,
+		})
+	}
+}
+
+// ObserveKeyDisabled 实现 Service 接口
+func (s *service) ObserveKeyDisabled(event health.KeyDisabledEvent) {
+	labels := map[string]any{
+		"api_key_id":      event.KeyID,
+		"disabled_reason": event.Reason,
+	}
+	reason := event.Reason
+	if h := event.Health; h != nil {
+		if message := firstNonEmpty(h.LastErrorMessage, h.LastError); message != "" {
+			reason += "，" + message
+		}
+		if h.LastHTTPStatus != nil {
+			labels["http_status"] = *h.LastHTTPStatus
+		}
+	}
+
+	for _, rule := range s.rulesOfType(types.AlertRuleTypeKeyDisabled) {
+		if rule.ResourceID != 0 && rule.ResourceID != event.KeyID {
+			continue
+		}
+
+		label := resourceLabel(types.ResourceTypeAPIKey, event.KeyID)
+		s.fire(rule, Event{
+			Subject: fmt.Sprintf("key_disabled:%d", event.KeyID),
+			Title:   label + " 已自动禁用",
+			Message: fmt.Sprintf("%s 因永久失败被自动禁用（%s），需管理员处理后手动启用", label, reason),
+			Labels:  labels,
+			FiredAt: event.At,
+		})
+	}
+}
+
+// ObserveRequestLog 实现 Service 接口
+func (s *service) ObserveRequestLog(log *types.RequestLog) {
+	if log == nil {
+		return
+	}
+
+	if log.Success {
+		return
+	}
+
+	now := time.Now()
+
+	labels := map[string]any{
+		"log_id":      log.ID,
+		"model_name":  log.OriginalModelName,
+		"platform_id": log.PlatformID,
+		"api_key_id":  log.APIKeyID,
+	}
+	if log.HTTPStatus != nil {
+		labels["http_status"] = *log.HTTPStatus
+	}
+	reason := firstNonEmpty(derefString(log.UpstreamErrorMessage), derefString(log.ErrorMsg))
+
+	if log.HTTPStatus != nil && *log.HTTPStatus == http.StatusUnauthorized && log.APIKeyID != 0 {
+		for _, rule := range s.rulesOfType(types.AlertRuleTypeKeyUnauthorized) {
+			if rule.ResourceID != 0 && rule.ResourceID != log.APIKeyID {
+				continue
+			}
+
+			label := resourceLabel(types.ResourceTypeAPIKey, log.APIKeyID)
+			s.fire(rule, Event{
+				Subject: fmt.Sprintf("api_key:%d", log.APIKeyID),
+				Title:   label + " 认证失败",
+				Message: fmt.Sprintf("%s（%s）上游返回 401：%s", label, resourceLabel(types.ResourceTypePlatform, log.PlatformID), reason),
+				Labels:  labels,
+				FiredAt: now,
+			})
+		}
+	}
+
+	if isQuotaExhausted(log) {
+		for _, rule := range s.rulesOfType(types.AlertRuleTypeQuotaExhausted) {
+			if rule.ModelName != "" && rule.ModelName != log.OriginalModelName {
+				continue
+			}
+
+			label := resourceLabel(types.ResourceTypePlatform, log.PlatformID)
+			s.fire(rule, Event{
+				Subject: fmt.Sprintf("quota:%d:%d", log.PlatformID, log.APIKeyID),
+				Title:   label + " 额度耗尽",
+				Message: fmt.Sprintf("%s 的%s额度耗尽：%s", label, resourceLabel(types.ResourceTypeAPIKey, log.APIKeyID), reason),
+				Labels:  labels,
+				FiredAt: now,
+			})
+		}
+	}
+}
+
+// isQuotaExhausted 判断失败请求是否由上游额度耗尽导致
+func isQuotaExhausted(log *types.RequestLog) bool {
+	if log.HTTPStatus != nil && *log.HTTPStatus == http.StatusPaymentRequired {
+		return true
+	}
+
+	for _, value := range []string{derefString(log.UpstreamErrorCode), derefString(log.UpstreamErrorType), derefString(log.ErrorCode)} {
+		lower := strings.ToLower(value)
+		for _, keyword := range quotaErrorKeywords {
+			if strings.Contains(lower, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluateLoop 周期性评估错误率规则，并清理过期的冷却记录
+func (s *service) evaluateLoop(ctx context.Context) {
+	ticker := time.NewTicker(errorRateEvalInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			s.evaluateErrorRates(ctx, now)
+
+			// 每小时清理一次
+			if now.Minute() == 0 {
+				s.pruneFirings(ctx, now)
+			}
+		}
+	}
+}
+
+// evaluateErrorRates 按模型统计各规则窗口内的错误率，超过阈值时触发告警
+//
+// 计数来自数据库中的小时统计与请求日志，多实例部署时按全部流量评估，冷却记录在实例间共享。
+func (s *service) evaluateErrorRates(ctx context.Context, now time.Time) {
+	for _, rule := range s.rulesOfType(types.AlertRuleTypeErrorRate) {
+		since := now.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+		rows, err := loadErrorRates(ctx, rule.ModelName, since, now)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "统计模型错误率失败",
+				"rule_id", rule.ID,
+				"error", err,
+				"error_type", "database_error",
+			)
+			continue
+		}
+
+		minRequests := int64(rule.MinRequests)
+		if minRequests < 1 {
+			minRequests = 1
+		}
+
+		for _, row := range rows {
+			if row.RequestCount < minRequests {
+				continue
+			}
+			rate := float64(row.FailureCount) / float64(row.RequestCount) * 100
+			if rate <= rule.Threshold {
+				continue
+			}
+
+			modelName := row.ModelName
+			if modelName == "" {
+				modelName = "unknown"
+			}
+			s.fire(rule, Event{
+				Subject: "model:" + row.ModelName,
+				Title:   fmt.Sprintf("模型 %s 错误率过高", modelName),
+				Message: fmt.Sprintf("模型 %s 最近 %d 分钟错误率 %.1f%%（%d/%d），超过阈值 %.1f%%",
+					modelName, rule.WindowMinutes, rate, row.FailureCount, row.RequestCount, rule.Threshold),
+				Labels: map[string]any{
+					"model_name":     row.ModelName,
+					"error_rate":     rate,
+					"request_count":  row.RequestCount,
+					"failure_count":  row.FailureCount,
+					"window_minutes": rule.WindowMinutes,
+				},
+				FiredAt: now,
+			})
+		}
+	}
+}
+
+// fire 在冷却期外将告警放入投递队列；队列已满时丢弃，不阻塞调用方
+func (s *service) fire(rule types.AlertRule, event Event) {
+	if !s.allowFire(rule, event.Subject, event.FiredAt) {
+		s.logger.Debug("告警处于冷却期，跳过", "rule_id", rule.ID, "subject", event.Subject)
+		return
+	}
+
+	event.RuleID = rule.ID
+	event.RuleName = rule.Name
+	event.Type = rule.Type
+
+	select {
+	case s.queue <- pendingAlert{rule: rule, event: event}:
+	default:
+		s.logger.Warn("告警投递队列已满，丢弃告警", "rule_id", rule.ID, "subject", event.Subject)
+	}
+}
+
+// resourceLabel 生成资源的展示名称
+func resourceLabel(resourceType types.ResourceType, resourceID uint) string {
+	switch resourceType {
+	case types.ResourceTypePlatform:
+		return fmt.Sprintf("平台#%d", resourceID)
+	case types.ResourceTypeAPIKey:
+		return fmt.Sprintf("密钥#%d", resourceID)
+	case types.ResourceTypeModel:
+		return fmt.Sprintf("模型#%d", resourceID)
+	case types.ResourceTypeKeyModel:
+		keyID, modelID := types.SplitKeyModelResourceID(resourceID)
+		return fmt.Sprintf("密钥#%d/模型#%d", keyID, modelID)
+	default:
+		return fmt.Sprintf("资源#%d", resourceID)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func derefString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
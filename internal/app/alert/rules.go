@@ -0,0 +1,214 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
+	"gorm.io/gorm"
+)
+
+// ListRules 获取全部告警规则
+func (s *service) ListRules(ctx context.Context) ([]types.AlertRule, error) {
+	var rules []types.AlertRule
+	if err := s.ruleDB(ctx).Order("id ASC").Find(&rules).Error; err != nil {
+		s.logger.ErrorContext(ctx, "查询告警规则失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询告警规则失败：%w", err)
+	}
+	return rules, nil
+}
+
+// GetRule 获取指定告警规则
+func (s *service) GetRule(ctx context.Context, id uint) (*types.AlertRule, error) {
+	var rule types.AlertRule
+	if err := s.ruleDB(ctx).Where("id = ?", id).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("未找到 ID 为 %d 的告警规则：%w", id, ErrRuleNotFound)
+		}
+		s.logger.ErrorContext(ctx, "查询告警规则失败", "rule_id", id, "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询告警规则失败：%w", err)
+	}
+	return &rule, nil
+}
+
+// CreateRule 创建告警规则
+func (s *service) CreateRule(ctx context.Context, req RuleRequest) (*types.AlertRule, error) {
+	rule, err := buildRule(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleDB(ctx).Create(rule).Error; err != nil {
+		s.logger.ErrorContext(ctx, "创建告警规则失败", "error", err, "error_type", "database_error")
+		s.recordRuleAudit(ctx, "alert_rule.create", 0, err, nil, rule)
+		return nil, fmt.Errorf("创建告警规则失败：%w", err)
+	}
+
+	s.recordRuleAudit(ctx, "alert_rule.create", rule.ID, nil, nil, rule)
+	s.afterRuleChanged(ctx, rule.ID)
+	s.logger.InfoContext(ctx, "告警规则已创建", "rule_id", rule.ID, "rule_type", rule.Type)
+	return rule, nil
+}
+
+// UpdateRule 更新告警规则
+func (s *service) UpdateRule(ctx context.Context, id uint, req RuleRequest) (*types.AlertRule, error) {
+	existing, err := s.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := buildRule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+
+	if err := baseDB(ctx).Save(rule).Error; err != nil {
+		s.logger.ErrorContext(ctx, "更新告警规则失败", "rule_id", id, "error", err, "error_type", "database_error")
+		s.recordRuleAudit(ctx, "alert_rule.update", id, err, existing, rule)
+		return nil, fmt.Errorf("更新告警规则失败：%w", err)
+	}
+
+	s.recordRuleAudit(ctx, "alert_rule.update", id, nil, existing, rule)
+	s.afterRuleChanged(ctx, id)
+	s.logger.InfoContext(ctx, "告警规则已更新", "rule_id", id, "rule_type", rule.Type)
+	return rule, nil
+}
+
+// DeleteRule 删除告警规则，投递记录保留
+func (s *service) DeleteRule(ctx context.Context, id uint) error {
+	existing, err := s.GetRule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	result := s.ruleDB(ctx).Where("id = ?", id).Delete(&types.AlertRule{})
+	if result.Error != nil {
+		s.logger.ErrorContext(ctx, "删除告警规则失败", "rule_id", id, "error", result.Error, "error_type", "database_error")
+		s.recordRuleAudit(ctx, "alert_rule.delete", id, result.Error, existing, nil)
+		return fmt.Errorf("删除告警规则失败：%w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("未找到 ID 为 %d 的告警规则：%w", id, ErrRuleNotFound)
+	}
+
+	s.recordRuleAudit(ctx, "alert_rule.delete", id, nil, existing, nil)
+	s.afterRuleChanged(ctx, id)
+	s.logger.InfoContext(ctx, "告警规则已删除", "rule_id", id)
+	return nil
+}
+
+// afterRuleChanged 刷新内存规则并清除该规则的冷却记录
+func (s *service) afterRuleChanged(ctx context.Context, ruleID uint) {
+	s.forgetRule(ctx, ruleID)
+	if err := s.reloadRules(ctx); err != nil {
+		s.logger.ErrorContext(ctx, "刷新告警规则失败，变更将在下次刷新后生效", "rule_id", ruleID, "error", err)
+	}
+}
+
+// recordRuleAudit 记录规则变更审计，err 非空时记为失败；审计写入失败仅记录日志
+func (s *service) recordRuleAudit(ctx context.Context, action string, ruleID uint, err error, before, after *types.AlertRule) {
+	if s.auditRecorder == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:   action,
+		Resource: "alert_rule",
+		Result:   "success",
+		Detail:   "告警规则变更",
+		Before:   before,
+		After:    after,
+	}
+	if ruleID != 0 {
+		entry.ResourceID = strconv.FormatUint(uint64(ruleID), 10)
+	}
+	if err != nil {
+		entry.Result = "failed"
+		entry.Detail = fmt.Sprintf("告警规则变更失败：%v", err)
+	}
+
+	if recordErr := s.auditRecorder.Record(ctx, entry); recordErr != nil {
+		s.logger.WarnContext(ctx, "写入告警规则审计记录失败", "action", action, "rule_id", ruleID, "error", recordErr)
+	}
+}
+
+// buildRule 校验请求参数并构建规则实体
+func buildRule(req RuleRequest) (*types.AlertRule, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("规则名称不能为空：%w", ErrInvalidArgument)
+	}
+
+	switch req.Type {
+	case types.AlertRuleTypeHealthUnavailable, types.AlertRuleTypeKeyUnauthorized, types.AlertRuleTypeQuotaExhausted, types.AlertRuleTypeKeyDisabled:
+	case types.AlertRuleTypeErrorRate:
+		if req.Threshold <= 0 || req.Threshold > 100 {
+			return nil, fmt.Errorf("错误率阈值必须在 (0, 100] 之间：%w", ErrInvalidArgument)
+		}
+		if req.WindowMinutes <= 0 || req.WindowMinutes > maxErrorRateWindowMins {
+			return nil, fmt.Errorf("统计窗口必须在 1 到 %d 分钟之间：%w", maxErrorRateWindowMins, ErrInvalidArgument)
+		}
+		if req.MinRequests < 0 {
+			return nil, fmt.Errorf("最少请求数不能为负数：%w", ErrInvalidArgument)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的规则类型 %q：%w", req.Type, ErrInvalidArgument)
+	}
+
+	switch req.ResourceType {
+	case 0, types.ResourceTypePlatform, types.ResourceTypeAPIKey, types.ResourceTypeModel, types.ResourceTypeKeyModel:
+	default:
+		return nil, fmt.Errorf("不支持的资源类型 %d：%w", req.ResourceType, ErrInvalidArgument)
+	}
+
+	webhookURL := strings.TrimSpace(req.WebhookURL)
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("Webhook 地址必须为有效的 http/https URL：%w", ErrInvalidArgument)
+	}
+
+	format := req.WebhookFormat
+	if format == "" {
+		format = types.AlertWebhookFormatJSON
+	}
+	switch format {
+	case types.AlertWebhookFormatJSON, types.AlertWebhookFormatSlack, types.AlertWebhookFormatFeishu, types.AlertWebhookFormatDingTalk:
+	default:
+		return nil, fmt.Errorf("不支持的载荷格式 %q：%w", format, ErrInvalidArgument)
+	}
+
+	cooldown := defaultCooldownSeconds
+	if req.CooldownSeconds != nil {
+		if *req.CooldownSeconds < 0 {
+			return nil, fmt.Errorf("冷却时间不能为负数：%w", ErrInvalidArgument)
+		}
+		cooldown = *req.CooldownSeconds
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &types.AlertRule{
+		Name:            name,
+		Type:            req.Type,
+		Enabled:         enabled,
+		ResourceType:    req.ResourceType,
+		ResourceID:      req.ResourceID,
+		ModelName:       strings.TrimSpace(req.ModelName),
+		Threshold:       req.Threshold,
+		WindowMinutes:   req.WindowMinutes,
+		MinRequests:     req.MinRequests,
+		CooldownSeconds: cooldown,
+		WebhookURL:      webhookURL,
+		WebhookFormat:   format,
+	}, nil
+}
@@ -0,0 +1,252 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/pinai/database"
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
+	"github.com/MeowSalty/pinai/internal/app/health"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultCooldownSeconds  = 300                // 默认冷却时间（秒）
+	dispatchQueueSize       = 256                // 待投递告警队列大小
+	errorRateEvalInterval   = time.Minute        // 错误率规则评估周期
+	ruleReloadInterval      = 30 * time.Second   // 规则重新加载周期，使其他实例的规则变更生效
+	firingRetention         = 7 * 24 * time.Hour // 冷却记录超过该时长且已过冷却期后清理
+	webhookTimeout          = 10 * time.Second   // 单次 Webhook 请求超时
+	maxErrorRateWindowMins  = 24 * 60            // 错误率统计窗口上限（分钟）
+	deliveryErrorMaxLength  = 1000               // 投递失败原因最大长度
+	deliveryPruneBatchSize  = 5000               // 清理投递记录时单批删除的记录数
+	webhookResponseReadSize = 4096               // 读取 Webhook 响应体的最大字节数
+)
+
+// Service 定义告警服务接口
+type Service interface {
+	ListRules(ctx context.Context) ([]types.AlertRule, error)
+	GetRule(ctx context.Context, id uint) (*types.AlertRule, error)
+	CreateRule(ctx context.Context, req RuleRequest) (*types.AlertRule, error)
+	UpdateRule(ctx context.Context, id uint, req RuleRequest) (*types.AlertRule, error)
+	DeleteRule(ctx context.Context, id uint) error
+
+	// TestRule 立即向规则的 Webhook 发送一条测试告警，不受冷却时间限制
+	TestRule(ctx context.Context, id uint) (*types.AlertDelivery, error)
+
+	// ListDeliveries 分页查询投递记录，按时间倒序
+	ListDeliveries(ctx context.Context, opts ListDeliveriesOptions) (*DeliveryListResponse, error)
+
+	// PruneDeliveries 分批删除 before 之前的投递记录
+	PruneDeliveries(ctx context.Context, before time.Time) (*DeliveryPruneResult, error)
+
+	// ObserveHealthTransition 在资源健康状态变化时评估健康规则
+	ObserveHealthTransition(transition health.Transition)
+
+	// ObserveKeyDisabled 在密钥因永久失败被自动禁用时评估密钥禁用规则
+	ObserveKeyDisabled(event health.KeyDisabledEvent)
+
+	// ObserveRequestLog 在请求日志落库后评估密钥 401 与额度耗尽规则
+	ObserveRequestLog(log *types.RequestLog)
+
+	// Start 加载规则并启动投递、规则刷新与错误率评估协程，ctx 结束时协程退出
+	Start(ctx context.Context) error
+}
+
+// service 告警服务实现
+type service struct {
+	logger *slog.Logger
+	client *http.Client
+
+	auditRecorder audit.Recorder // 规则变更审计记录器，为空时不记录
+
+	rulesMu sync.RWMutex
+	rules   []types.AlertRule // 已启用规则的内存副本，周期性从数据库刷新
+
+	queue chan pendingAlert // 待投递告警
+}
+
+// pendingAlert 为等待投递的告警及其规则快照
+type pendingAlert struct {
+	rule  types.AlertRule
+	event Event
+}
+
+// Option 定义告警服务的可选配置函数
+type Option func(*service)
+
+// WithAuditRecorder 设置规则变更的审计记录器
+func WithAuditRecorder(recorder audit.Recorder) Option {
+	return func(s *service) {
+		s.auditRecorder = recorder
+	}
+}
+
+// New 创建告警服务实例
+func New(logger *slog.Logger, opts ...Option) Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &service{
+		logger: logger.With("component", "alert_service"),
+		client: &http.Client{Timeout: webhookTimeout},
+		queue:  make(chan pendingAlert, dispatchQueueSize),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Start 实现 Service 接口
+func (s *service) Start(ctx context.Context) error {
+	if err := s.reloadRules(ctx); err != nil {
+		return fmt.Errorf("启动告警服务失败：%w", err)
+	}
+
+	go s.dispatchLoop(ctx)
+	go s.reloadLoop(ctx)
+	go s.evaluateLoop(ctx)
+
+	s.logger.Info("告警服务已启动")
+	return nil
+}
+
+// reloadRules 从数据库重新加载已启用的规则
+func (s *service) reloadRules(ctx context.Context) error {
+	var rules []types.AlertRule
+	if err := s.ruleDB(ctx).Where("enabled = ?", true).Order("id ASC").Find(&rules).Error; err != nil {
+		return fmt.Errorf("加载告警规则失败：%w", err)
+	}
+
+	s.rulesMu.Lock()
+	s.rules = rules
+	s.rulesMu.Unlock()
+
+	s.logger.Debug("告警规则已加载", "count", len(rules))
+	return nil
+}
+
+// reloadLoop 周期性重新加载规则，使其他实例上的规则变更在本实例生效
+func (s *service) reloadLoop(ctx context.Context) {
+	ticker := time.NewTicker(ruleReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.reloadRules(ctx); err != nil {
+				s.logger.ErrorContext(ctx, "周期刷新告警规则失败", "error", err)
+			}
+		}
+	}
+}
+
+// rulesOfType 返回指定类型的已启用规则
+func (s *service) rulesOfType(ruleType string) []types.AlertRule {
+	s.rulesMu.RLock()
+	defer s.rulesMu.RUnlock()
+
+	var matched []types.AlertRule
+	for _, rule := range s.rules {
+		if rule.Type == ruleType {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+// allowFire 判断规则对指定对象是否已过冷却期，允许时记录本次触发时间
+//
+// 触发时间保存在数据库中，通过条件更新抢占，多个实例对同一对象在冷却期内只会有一个触发成功。
+// 数据库不可用时放行告警，避免因存储故障漏报。
+func (s *service) allowFire(rule types.AlertRule, subject string, now time.Time) bool {
+	ctx := context.Background()
+	now = now.UTC()
+	cutoff := now.Add(-time.Duration(rule.CooldownSeconds) * time.Second)
+
+	result := s.firingDB(ctx).
+		Where("rule_id = ? AND subject = ? AND last_fired_at <= ?", rule.ID, subject, cutoff).
+		Update("last_fired_at", now)
+	if result.Error != nil {
+		s.logger.Error("抢占告警冷却记录失败，放行本次告警", "rule_id", rule.ID, "subject", subject, "error", result.Error)
+		return true
+	}
+	if result.RowsAffected > 0 {
+		return true
+	}
+
+	// 首次触发时插入记录；并发插入或记录仍在冷却期内时冲突被忽略
+	result = baseDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&types.AlertFiring{
+		RuleID:      rule.ID,
+		Subject:     subject,
+		LastFiredAt: now,
+	})
+	if result.Error != nil {
+		s.logger.Error("写入告警冷却记录失败，放行本次告警", "rule_id", rule.ID, "subject", subject, "error", result.Error)
+		return true
+	}
+	return result.RowsAffected > 0
+}
+
+// forgetRule 清除规则的冷却记录，规则被修改或删除后调用
+func (s *service) forgetRule(ctx context.Context, ruleID uint) {
+	if err := s.firingDB(ctx).Where("rule_id = ?", ruleID).Delete(&types.AlertFiring{}).Error; err != nil {
+		s.logger.ErrorContext(ctx, "清除告警冷却记录失败", "rule_id", ruleID, "error", err)
+	}
+}
+
+// pruneFirings 清理长期未再触发的冷却记录
+func (s *service) pruneFirings(ctx context.Context, now time.Time) {
+	cutoff := now.UTC().Add(-firingRetention)
+
+	var ruleIDs []uint
+	s.rulesMu.RLock()
+	for _, rule := range s.rules {
+		if time.Duration(rule.CooldownSeconds)*time.Second > firingRetention {
+			ruleIDs = append(ruleIDs, rule.ID)
+		}
+	}
+	s.rulesMu.RUnlock()
+
+	db := s.firingDB(ctx).Where("last_fired_at < ?", cutoff)
+	if len(ruleIDs) > 0 {
+		db = db.Where("rule_id NOT IN ?", ruleIDs)
+	}
+	result := db.Delete(&types.AlertFiring{})
+	if result.Error != nil {
+		s.logger.ErrorContext(ctx, "清理告警冷却记录失败", "error", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		s.logger.DebugContext(ctx, "已清理过期告警冷却记录", "deleted", result.RowsAffected)
+	}
+}
+
+// baseDB 返回不携带语句状态的数据库会话
+func baseDB(ctx context.Context) *gorm.DB {
+	return database.CleanSession(ctx, query.Q.Health.WithContext(ctx).UnderlyingDB())
+}
+
+func (s *service) ruleDB(ctx context.Context) *gorm.DB {
+	return baseDB(ctx).Model(&types.AlertRule{})
+}
+
+func (s *service) deliveryDB(ctx context.Context) *gorm.DB {
+	return baseDB(ctx).Model(&types.AlertDelivery{})
+}
+
+func (s *service) firingDB(ctx context.Context) *gorm.DB {
+	return baseDB(ctx).Model(&types.AlertFiring{})
+}
@@ -0,0 +1,394 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
+	"github.com/MeowSalty/pinai/internal/app/health"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) (*service, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&types.Health{}, &types.RequestLog{}, &types.RequestLogHourlyStat{}, &types.AlertRule{}, &types.AlertDelivery{}, &types.AlertFiring{}); err != nil {
+		t.Fatalf("迁移告警表失败: %v", err)
+	}
+	query.SetDefault(db)
+
+	return New(slog.Default()).(*service), db
+}
+
+// webhookRecorder 记录收到的 Webhook 请求体
+type webhookRecorder struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (r *webhookRecorder) handler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.bodies = append(r.bodies, string(body))
+		r.mu.Unlock()
+		w.WriteHeader(status)
+	}
+}
+
+// drain 同步投递队列中的全部告警
+func drain(t *testing.T, svc *service) int {
+	t.Helper()
+
+	count := 0
+	for {
+		select {
+		case pending := <-svc.queue:
+			svc.deliver(context.Background(), pending.rule, pending.event)
+			count++
+		default:
+			return count
+		}
+	}
+}
+
+func TestObserveHealthTransition_资源不可用时投递并冷却去重(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder.handler(http.StatusOK))
+	defer server.Close()
+
+	rule, err := svc.CreateRule(ctx, RuleRequest{
+		Name:          "平台不可用",
+		Type:          types.AlertRuleTypeHealthUnavailable,
+		ResourceType:  types.ResourceTypePlatform,
+		WebhookURL:    server.URL,
+		WebhookFormat: types.AlertWebhookFormatFeishu,
+	})
+	if err != nil {
+		t.Fatalf("创建告警规则失败: %v", err)
+	}
+
+	transition := health.Transition{
+		ResourceType: types.ResourceTypePlatform,
+		ResourceID:   3,
+		From:         types.HealthStatusWarning,
+		To:           types.HealthStatusUnavailable,
+		Health:       &types.Health{LastErrorMessage: "upstream down"},
+		At:           time.Now(),
+	}
+	svc.ObserveHealthTransition(transition)
+	svc.ObserveHealthTransition(transition)
+	svc.ObserveHealthTransition(health.Transition{ResourceType: types.ResourceTypeAPIKey, ResourceID: 1, To: types.HealthStatusUnavailable, At: time.Now()})
+
+	if delivered := drain(t, svc); delivered != 1 {
+		t.Fatalf("投递次数 = %d, 期望 1（冷却期内去重，资源类型不匹配不触发）", delivered)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(recorder.bodies[0]), &payload); err != nil {
+		t.Fatalf("解析飞书载荷失败: %v", err)
+	}
+	if payload["msg_type"] != "text" {
+		t.Fatalf("飞书载荷格式不符: %s", recorder.bodies[0])
+	}
+
+	var deliveries []types.AlertDelivery
+	db.Find(&deliveries)
+	if len(deliveries) != 1 || deliveries[0].RuleID != rule.ID || deliveries[0].Status != types.AlertDeliveryStatusSucceeded {
+		t.Fatalf("投递记录不符: %+v", deliveries)
+	}
+}
+
+func TestObserveRequestLog_密钥401与额度耗尽(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder.handler(http.StatusInternalServerError))
+	defer server.Close()
+
+	for _, req := range []RuleRequest{
+		{Name: "密钥失效", Type: types.AlertRuleTypeKeyUnauthorized, WebhookURL: server.URL},
+		{Name: "额度耗尽", Type: types.AlertRuleTypeQuotaExhausted, WebhookURL: server.URL},
+	} {
+		if _, err := svc.CreateRule(ctx, req); err != nil {
+			t.Fatalf("创建告警规则失败: %v", err)
+		}
+	}
+
+	status401 := 401
+	quotaCode := "insufficient_quota"
+	svc.ObserveRequestLog(&types.RequestLog{ID: 1, APIKeyID: 7, PlatformID: 1, HTTPStatus: &status401})
+	svc.ObserveRequestLog(&types.RequestLog{ID: 2, APIKeyID: 8, PlatformID: 1, UpstreamErrorCode: &quotaCode})
+	svc.ObserveRequestLog(&types.RequestLog{ID: 3, APIKeyID: 9, PlatformID: 1, Success: true, HTTPStatus: &status401})
+
+	if delivered := drain(t, svc); delivered != 2 {
+		t.Fatalf("投递次数 = %d, 期望 2", delivered)
+	}
+
+	result, err := svc.ListDeliveries(ctx, ListDeliveriesOptions{Status: types.AlertDeliveryStatusFailed, Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("查询投递记录失败: %v", err)
+	}
+	if result.Total != 2 || result.Items[0].HTTPStatus == nil || *result.Items[0].HTTPStatus != http.StatusInternalServerError {
+		t.Fatalf("失败投递记录不符: %+v", result)
+	}
+}
+
+func TestObserveKeyDisabled_密钥自动禁用时投递(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder.handler(http.StatusOK))
+	defer server.Close()
+
+	if _, err := svc.CreateRule(ctx, RuleRequest{Name: "密钥禁用", Type: types.AlertRuleTypeKeyDisabled, ResourceID: 5, WebhookURL: server.URL}); err != nil {
+		t.Fatalf("创建告警规则失败: %v", err)
+	}
+
+	svc.ObserveKeyDisabled(health.KeyDisabledEvent{KeyID: 5, Reason: health.DisabledReasonQuotaExhausted, At: time.Now()})
+	svc.ObserveKeyDisabled(health.KeyDisabledEvent{KeyID: 6, Reason: health.DisabledReasonInvalidKey, At: time.Now()})
+
+	if delivered := drain(t, svc); delivered != 1 {
+		t.Fatalf("投递次数 = %d, 期望 1（密钥 ID 不匹配不触发）", delivered)
+	}
+
+	var payload Event
+	if err := json.Unmarshal([]byte(recorder.bodies[0]), &payload); err != nil {
+		t.Fatalf("解析告警载荷失败: %v", err)
+	}
+	if payload.Labels["disabled_reason"] != health.DisabledReasonQuotaExhausted {
+		t.Fatalf("告警载荷应包含禁用原因: %s", recorder.bodies[0])
+	}
+}
+
+func TestEvaluateErrorRates_超过阈值触发(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder.handler(http.StatusOK))
+	defer server.Close()
+
+	if _, err := svc.CreateRule(ctx, RuleRequest{
+		Name:          "错误率",
+		Type:          types.AlertRuleTypeErrorRate,
+		Threshold:     50,
+		WindowMinutes: 5,
+		MinRequests:   3,
+		WebhookURL:    server.URL,
+	}); err != nil {
+		t.Fatalf("创建告警规则失败: %v", err)
+	}
+
+	now := time.Now()
+	logs := []*types.RequestLog{
+		{Timestamp: now, OriginalModelName: "gpt-4o", Success: false},
+		{Timestamp: now, OriginalModelName: "gpt-4o", Success: false},
+		{Timestamp: now, OriginalModelName: "gpt-4o", Success: true},
+		{Timestamp: now, OriginalModelName: "claude", Success: false},
+		{Timestamp: now.Add(-time.Hour), OriginalModelName: "claude", Success: false},
+		{Timestamp: now.Add(-time.Hour), OriginalModelName: "claude", Success: false},
+	}
+	if err := db.Create(logs).Error; err != nil {
+		t.Fatalf("写入请求日志失败: %v", err)
+	}
+
+	svc.evaluateErrorRates(ctx, now)
+	if delivered := drain(t, svc); delivered != 1 {
+		t.Fatalf("投递次数 = %d, 期望 1（claude 窗口内请求数不足）", delivered)
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(recorder.bodies[0]), &event); err != nil {
+		t.Fatalf("解析 JSON 载荷失败: %v", err)
+	}
+	if event.Subject != "model:gpt-4o" || event.Type != types.AlertRuleTypeErrorRate {
+		t.Fatalf("告警事件不符: %+v", event)
+	}
+}
+
+func TestLoadErrorRates_合并小时统计与窗口首尾的请求日志(t *testing.T) {
+	_, db := newTestService(t)
+	ctx := context.Background()
+
+	now := time.Date(2026, 5, 1, 10, 20, 0, 0, time.UTC)
+	since := now.Add(-3 * time.Hour) // 07:20
+	stats := []types.RequestLogHourlyStat{
+		{BucketStart: time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC), ModelName: "gpt-4o", PlatformID: 1, RequestCount: 10, SuccessCount: 8},
+		{BucketStart: time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC), ModelName: "gpt-4o", PlatformID: 2, RequestCount: 5, SuccessCount: 5},
+		// 窗口起点所在小时由请求日志统计，不读取小时统计
+		{BucketStart: time.Date(2026, 5, 1, 7, 0, 0, 0, time.UTC), ModelName: "gpt-4o", PlatformID: 1, RequestCount: 100, SuccessCount: 0},
+	}
+	if err := db.Create(&stats).Error; err != nil {
+		t.Fatalf("写入小时统计失败: %v", err)
+	}
+	logs := []*types.RequestLog{
+		{Timestamp: time.Date(2026, 5, 1, 7, 10, 0, 0, time.UTC), OriginalModelName: "gpt-4o", Success: false},
+		{Timestamp: time.Date(2026, 5, 1, 7, 30, 0, 0, time.UTC), OriginalModelName: "gpt-4o", Success: false},
+		{Timestamp: time.Date(2026, 5, 1, 10, 5, 0, 0, time.UTC), OriginalModelName: "gpt-4o", Success: true},
+		{Timestamp: time.Date(2026, 5, 1, 10, 6, 0, 0, time.UTC), OriginalModelName: "claude", Success: false},
+	}
+	if err := db.Create(logs).Error; err != nil {
+		t.Fatalf("写入请求日志失败: %v", err)
+	}
+
+	rows, err := loadErrorRates(ctx, "gpt-4o", since, now)
+	if err != nil {
+		t.Fatalf("统计错误率失败: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("应仅返回指定模型: %+v", rows)
+	}
+	if rows[0].RequestCount != 17 || rows[0].FailureCount != 3 {
+		t.Fatalf("计数不符: got=%+v, want request=17 failure=3", rows[0])
+	}
+}
+
+func TestPruneDeliveries_删除过期投递记录(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	deliveries := []types.AlertDelivery{
+		{RuleID: 1, EventType: EventTypeTest, Subject: "old", Status: types.AlertDeliveryStatusSucceeded, CreatedAt: now.AddDate(0, 0, -40)},
+		{RuleID: 1, EventType: EventTypeTest, Subject: "new", Status: types.AlertDeliveryStatusSucceeded, CreatedAt: now},
+	}
+	if err := db.Create(&deliveries).Error; err != nil {
+		t.Fatalf("写入投递记录失败: %v", err)
+	}
+
+	result, err := svc.PruneDeliveries(ctx, now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("清理投递记录失败: %v", err)
+	}
+	if result.DeletedRows != 1 {
+		t.Fatalf("删除数量 = %d, 期望 1", result.DeletedRows)
+	}
+	var remaining []types.AlertDelivery
+	db.Find(&remaining)
+	if len(remaining) != 1 || remaining[0].Subject != "new" {
+		t.Fatalf("应仅保留未过期的投递记录: %+v", remaining)
+	}
+}
+
+func TestAllowFire_冷却记录在实例间共享(t *testing.T) {
+	first, db := newTestService(t)
+	second := New(slog.Default()).(*service)
+	ctx := context.Background()
+
+	rule := types.AlertRule{ID: 1, CooldownSeconds: 60}
+	now := time.Now()
+
+	if !first.allowFire(rule, "model:gpt-4o", now) {
+		t.Fatal("首次触发应被放行")
+	}
+	if second.allowFire(rule, "model:gpt-4o", now.Add(time.Second)) {
+		t.Fatal("其他实例在冷却期内不应再次触发")
+	}
+	if !second.allowFire(rule, "model:claude", now.Add(time.Second)) {
+		t.Fatal("不同告警对象应独立冷却")
+	}
+	if !second.allowFire(rule, "model:gpt-4o", now.Add(time.Minute)) {
+		t.Fatal("冷却期结束后应允许再次触发")
+	}
+
+	first.forgetRule(ctx, rule.ID)
+	var count int64
+	db.Model(&types.AlertFiring{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("规则变更后冷却记录数 = %d, 期望 0", count)
+	}
+}
+
+func TestCreateRule_参数校验(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	for name, req := range map[string]RuleRequest{
+		"未知类型":    {Name: "x", Type: "unknown", WebhookURL: "https://example.com"},
+		"无效地址":    {Name: "x", Type: types.AlertRuleTypeKeyUnauthorized, WebhookURL: "ftp://example.com"},
+		"错误率缺少阈值": {Name: "x", Type: types.AlertRuleTypeErrorRate, WindowMinutes: 5, WebhookURL: "https://example.com"},
+		"未知载荷格式":  {Name: "x", Type: types.AlertRuleTypeKeyUnauthorized, WebhookURL: "https://example.com", WebhookFormat: "teams"},
+	} {
+		if _, err := svc.CreateRule(ctx, req); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("%s: 期望参数错误，实际 %v", name, err)
+		}
+	}
+
+	disabled := false
+	rule, err := svc.CreateRule(ctx, RuleRequest{Name: "停用", Type: types.AlertRuleTypeKeyUnauthorized, Enabled: &disabled, WebhookURL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("创建告警规则失败: %v", err)
+	}
+	if rule.Enabled || rule.CooldownSeconds != defaultCooldownSeconds {
+		t.Fatalf("规则默认值不符: %+v", rule)
+	}
+	if got := len(svc.rulesOfType(types.AlertRuleTypeKeyUnauthorized)); got != 0 {
+		t.Fatalf("停用规则不应加载，实际 %d 条", got)
+	}
+}
+
+// recordingAuditRecorder 记录写入的审计事件
+type recordingAuditRecorder struct {
+	entries []audit.Entry
+}
+
+func (r *recordingAuditRecorder) Record(_ context.Context, entry audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func TestRuleCRUD_变更写入审计(t *testing.T) {
+	svc, _ := newTestService(t)
+	recorder := &recordingAuditRecorder{}
+	svc.auditRecorder = recorder
+	ctx := context.Background()
+
+	req := RuleRequest{Name: "密钥禁用", Type: types.AlertRuleTypeKeyDisabled, WebhookURL: "https://example.com/hook"}
+	rule, err := svc.CreateRule(ctx, req)
+	if err != nil {
+		t.Fatalf("创建告警规则失败: %v", err)
+	}
+	req.Name = "密钥自动禁用"
+	if _, err := svc.UpdateRule(ctx, rule.ID, req); err != nil {
+		t.Fatalf("更新告警规则失败: %v", err)
+	}
+	if err := svc.DeleteRule(ctx, rule.ID); err != nil {
+		t.Fatalf("删除告警规则失败: %v", err)
+	}
+	if _, err := svc.CreateRule(ctx, RuleRequest{Name: "无效"}); err == nil {
+		t.Fatal("无效规则应创建失败")
+	}
+
+	var actions []string
+	for _, entry := range recorder.entries {
+		actions = append(actions, entry.Action)
+	}
+	if want := []string{"alert_rule.create", "alert_rule.update", "alert_rule.delete"}; !slices.Equal(actions, want) {
+		t.Fatalf("审计操作 = %v, 期望 %v（参数校验失败不记录）", actions, want)
+	}
+	if before := recorder.entries[1].Before.(*types.AlertRule); before.Name != "密钥禁用" {
+		t.Fatalf("更新的审计记录应包含变更前规则: %+v", recorder.entries[1])
+	}
+}
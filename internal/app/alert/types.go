@@ -0,0 +1,65 @@
+package alert
+
+import (
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// EventTypeTest 为测试投递的事件类型
+const EventTypeTest = "test"
+
+// RuleRequest 定义创建或更新告警规则的请求参数
+//
+// 更新时整体替换规则内容；Enabled 与 CooldownSeconds 为空时分别默认为启用与 300 秒。
+type RuleRequest struct {
+	Name    string `json:"name"`    // 规则名称
+	Type    string `json:"type"`    // 规则类型：health_unavailable/key_unauthorized/error_rate/quota_exhausted
+	Enabled *bool  `json:"enabled"` // 是否启用
+
+	ResourceType types.ResourceType `json:"resource_type"` // 资源类型过滤（健康规则）
+	ResourceID   uint               `json:"resource_id"`   // 资源 ID 过滤（健康规则与密钥规则）
+	ModelName    string             `json:"model_name"`    // 原始模型名称过滤（错误率与额度规则）
+
+	Threshold     float64 `json:"threshold"`      // 错误率阈值（百分比，错误率规则必填）
+	WindowMinutes int     `json:"window_minutes"` // 统计窗口（分钟，错误率规则必填）
+	MinRequests   int     `json:"min_requests"`   // 窗口内最少请求数
+
+	CooldownSeconds *int   `json:"cooldown_seconds"` // 冷却时间（秒）
+	WebhookURL      string `json:"webhook_url"`      // Webhook 地址
+	WebhookFormat   string `json:"webhook_format"`   // 载荷格式：json/slack/feishu/dingtalk，默认 json
+}
+
+// Event 定义一次告警事件，通用 JSON 格式下作为 Webhook 载荷发送
+type Event struct {
+	RuleID   uint           `json:"rule_id"`          // 规则 ID
+	RuleName string         `json:"rule_name"`        // 规则名称
+	Type     string         `json:"type"`             // 事件类型
+	Subject  string         `json:"subject"`          // 告警对象
+	Title    string         `json:"title"`            // 标题
+	Message  string         `json:"message"`          // 详细消息
+	Labels   map[string]any `json:"labels,omitempty"` // 附加信息
+	FiredAt  time.Time      `json:"fired_at"`         // 触发时间
+}
+
+// ListDeliveriesOptions 定义投递记录的筛选选项
+type ListDeliveriesOptions struct {
+	RuleID   *uint  // 规则 ID
+	Status   string // 投递状态
+	Page     int    // 页码
+	PageSize int    // 每页大小
+}
+
+// DeliveryListResponse 定义投递记录列表响应
+type DeliveryListResponse struct {
+	Items    []types.AlertDelivery `json:"items"`     // 投递记录
+	Total    int64                 `json:"total"`     // 总数
+	Page     int                   `json:"page"`      // 当前页码
+	PageSize int                   `json:"page_size"` // 每页大小
+}
+
+// DeliveryPruneResult 定义投递记录清理结果
+type DeliveryPruneResult struct {
+	Before      time.Time `json:"before"`       // 清理该时间之前的记录
+	DeletedRows int64     `json:"deleted_rows"` // 删除的记录数
+}
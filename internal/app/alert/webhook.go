@@ -0,0 +1,222 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+// dispatchLoop 逐条投递队列中的告警
+func (s *service) dispatchLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pending := <-s.queue:
+			s.deliver(ctx, pending.rule, pending.event)
+		}
+	}
+}
+
+// TestRule 实现 Service 接口
+func (s *service) TestRule(ctx context.Context, id uint) (*types.AlertDelivery, error) {
+	rule, err := s.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	event := Event{
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		Type:     EventTypeTest,
+		Subject:  EventTypeTest,
+		Title:    "告警测试",
+		Message:  fmt.Sprintf("这是来自告警规则「%s」的测试消息", rule.Name),
+		FiredAt:  time.Now(),
+	}
+
+	delivery := s.deliver(ctx, *rule, event)
+	if delivery.ID == 0 {
+		return nil, fmt.Errorf("保存投递记录失败")
+	}
+	return delivery, nil
+}
+
+// deliver 发送 Webhook 并记录投递结果
+func (s *service) deliver(ctx context.Context, rule types.AlertRule, event Event) *types.AlertDelivery {
+	logger := s.logger.With("operation", "deliver_alert", "rule_id", rule.ID, "subject", event.Subject)
+
+	delivery := &types.AlertDelivery{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		EventType: event.Type,
+		Subject:   event.Subject,
+		Message:   event.Message,
+		Status:    types.AlertDeliveryStatusSucceeded,
+	}
+
+	payload, err := buildPayload(rule.WebhookFormat, event)
+	if err != nil {
+		delivery.Status = types.AlertDeliveryStatusFailed
+		delivery.ErrorMessage = err.Error()
+	} else {
+		delivery.Payload = string(payload)
+		httpStatus, sendErr := s.send(ctx, rule.WebhookURL, payload)
+		if httpStatus != 0 {
+			delivery.HTTPStatus = &httpStatus
+		}
+		if sendErr != nil {
+			delivery.Status = types.AlertDeliveryStatusFailed
+			delivery.ErrorMessage = truncate(sendErr.Error(), deliveryErrorMaxLength)
+		}
+	}
+
+	if delivery.Status == types.AlertDeliveryStatusFailed {
+		logger.Warn("告警投递失败", "error", delivery.ErrorMessage)
+	} else {
+		logger.Info("告警投递成功", "event_type", event.Type)
+	}
+
+	if err := s.deliveryDB(ctx).Create(delivery).Error; err != nil {
+		logger.Error("保存告警投递记录失败", "error", err, "error_type", "database_error")
+	}
+
+	return delivery
+}
+
+// send 发送 Webhook 请求，返回响应状态码；非 2xx 视为失败
+func (s *service) send(ctx context.Context, webhookURL string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("构建 Webhook 请求失败：%w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("发送 Webhook 请求失败：%w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseReadSize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("Webhook 返回状态码 %d：%s", resp.StatusCode, string(body))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// buildPayload 按载荷格式构建 Webhook 请求体
+func buildPayload(format string, event Event) ([]byte, error) {
+	text := fmt.Sprintf("[%s] %s\n%s", event.RuleName, event.Title, event.Message)
+
+	var payload any
+	switch format {
+	case types.AlertWebhookFormatJSON, "":
+		payload = event
+	case types.AlertWebhookFormatSlack:
+		payload = map[string]any{"text": text}
+	case types.AlertWebhookFormatFeishu:
+		payload = map[string]any{
+			"msg_type": "text",
+			"content":  map[string]string{"text": text},
+		}
+	case types.AlertWebhookFormatDingTalk:
+		payload = map[string]any{
+			"msgtype": "text",
+			"text":    map[string]string{"content": text},
+		}
+	default:
+		return nil, fmt.Errorf("不支持的载荷格式 %q", format)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化告警载荷失败：%w", err)
+	}
+	return data, nil
+}
+
+// ListDeliveries 实现 Service 接口
+func (s *service) ListDeliveries(ctx context.Context, opts ListDeliveriesOptions) (*DeliveryListResponse, error) {
+	filter := func() *gorm.DB {
+		db := s.deliveryDB(ctx)
+		if opts.RuleID != nil {
+			db = db.Where("rule_id = ?", *opts.RuleID)
+		}
+		if opts.Status != "" {
+			db = db.Where("status = ?", opts.Status)
+		}
+		return db
+	}
+
+	var total int64
+	if err := filter().Count(&total).Error; err != nil {
+		s.logger.ErrorContext(ctx, "统计告警投递记录失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询告警投递记录失败：%w", err)
+	}
+
+	items := make([]types.AlertDelivery, 0)
+	if err := filter().Order("id DESC").Offset((opts.Page - 1) * opts.PageSize).Limit(opts.PageSize).Find(&items).Error; err != nil {
+		s.logger.ErrorContext(ctx, "查询告警投递记录失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询告警投递记录失败：%w", err)
+	}
+
+	return &DeliveryListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     opts.Page,
+		PageSize: opts.PageSize,
+	}, nil
+}
+
+// PruneDeliveries 实现 Service 接口
+func (s *service) PruneDeliveries(ctx context.Context, before time.Time) (*DeliveryPruneResult, error) {
+	result := &DeliveryPruneResult{Before: before}
+	for {
+		var ids []uint
+		if err := s.deliveryDB(ctx).Where("created_at < ?", before).
+			Order("id ASC").
+			Limit(deliveryPruneBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return result, fmt.Errorf("查询过期告警投递记录失败：%w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		deleted := s.deliveryDB(ctx).Where("id IN ?", ids).Delete(&types.AlertDelivery{})
+		if deleted.Error != nil {
+			return result, fmt.Errorf("删除过期告警投递记录失败：%w", deleted.Error)
+		}
+		result.DeletedRows += deleted.RowsAffected
+
+		if len(ids) < deliveryPruneBatchSize {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+	}
+
+	if result.DeletedRows > 0 {
+		s.logger.InfoContext(ctx, "已清理过期告警投递记录", "before", before, "deleted_rows", result.DeletedRows)
+	}
+	return result, nil
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// AnonymousActor 为未配置管理令牌时的操作者标识
+const AnonymousActor = "anonymous"
+
+// Actor 描述发起控制面操作的调用方
+type Actor struct {
+	Identity  string // 操作者标识
+	ClientIP  string // 客户端 IP
+	RequestID string // 请求 ID
+}
+
+type actorContextKey struct{}
+
+// WithActor 将调用方信息写入上下文，供审计记录读取
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext 读取上下文中的调用方信息，不存在时返回匿名操作者
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx != nil {
+		if actor, ok := ctx.Value(actorContextKey{}).(Actor); ok {
+			return actor
+		}
+	}
+	return Actor{Identity: AnonymousActor}
+}
+
+// TokenIdentity 返回管理令牌的操作者标识。
+//
+// 标识为 "admin:" 加令牌 SHA-256 摘要的前 8 位十六进制字符，可区分轮换前后的令牌且不泄露令牌本身；
+// 令牌为空时返回匿名操作者。
+func TokenIdentity(token string) string {
+	if token == "" {
+		return AnonymousActor
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return "admin:" + hex.EncodeToString(sum[:])[:8]
+}
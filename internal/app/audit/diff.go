@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// redactedValue 为脱敏后的占位值
+const redactedValue = "****"
+
+// sensitiveFieldNames 为需要脱敏的字段名（小写并去除 - 与 _）
+var sensitiveFieldNames = map[string]struct{}{
+	"value":              {}, // 上游密钥值
+	"apikey":             {},
+	"xapikey":            {},
+	"authorization":      {},
+	"proxyauthorization": {},
+	"webhookurl":         {}, // 告警 Webhook 地址，路径或查询参数中通常含令牌
+}
+
+// sensitiveFieldKeywords 为字段名包含即需脱敏的关键字
+var sensitiveFieldKeywords = []string{"token", "secret", "password"}
+
+// isSensitiveField 判断字段名是否需要脱敏
+func isSensitiveField(name string) bool {
+	normalized := strings.ToLower(name)
+	normalized = strings.NewReplacer("-", "", "_", "").Replace(normalized)
+	if _, ok := sensitiveFieldNames[normalized]; ok {
+		return true
+	}
+	for _, keyword := range sensitiveFieldKeywords {
+		if strings.Contains(normalized, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecret 脱敏敏感值，长度足够的字符串保留末 4 位便于辨认
+func maskSecret(v any) any {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		if v == nil {
+			return nil
+		}
+		return redactedValue
+	}
+	if len(s) <= 8 {
+		return redactedValue
+	}
+	return redactedValue + s[len(s)-4:]
+}
+
+// snapshot 将资源序列化为通用 JSON 值，nil 返回 nil
+func snapshot(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// redact 递归脱敏快照中的敏感字段
+func redact(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, field := range value {
+			if isSensitiveField(key) {
+				out[key] = maskSecret(field)
+				continue
+			}
+			out[key] = redact(field)
+		}
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, item := range value {
+			out[i] = redact(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// diffSnapshots 比较快照的顶层字段，返回发生变化的字段。
+//
+// 比较基于原始值，差异中的敏感字段随后脱敏；非对象快照整体记为字段 "$"。
+func diffSnapshots(before, after any) map[string]FieldChange {
+	beforeMap, beforeIsMap := before.(map[string]any)
+	afterMap, afterIsMap := after.(map[string]any)
+	if (before != nil && !beforeIsMap) || (after != nil && !afterIsMap) {
+		if reflect.DeepEqual(before, after) {
+			return nil
+		}
+		return map[string]FieldChange{"$": {Before: redact(before), After: redact(after)}}
+	}
+
+	changes := make(map[string]FieldChange)
+	for key, beforeValue := range beforeMap {
+		afterValue, exists := afterMap[key]
+		if exists && reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes[key] = redactChange(key, beforeValue, afterValue)
+	}
+	for key, afterValue := range afterMap {
+		if _, exists := beforeMap[key]; exists {
+			continue
+		}
+		changes[key] = redactChange(key, nil, afterValue)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// redactChange 构造单个字段的脱敏变更
+func redactChange(key string, before, after any) FieldChange {
+	if isSensitiveField(key) {
+		return FieldChange{Before: maskSecret(before), After: maskSecret(after)}
+	}
+	return FieldChange{Before: redact(before), After: redact(after)}
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/MeowSalty/pinai/database"
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+// detailMaxLength 为审计说明的最大长度
+const detailMaxLength = 2000
+
+// Recorder 定义记录审计事件的能力，供需要写入审计记录的服务依赖
+type Recorder interface {
+	// Record 记录一次审计事件，操作者、客户端 IP 与请求 ID 取自上下文
+	Record(ctx context.Context, entry Entry) error
+}
+
+// Service 定义控制面审计服务接口
+type Service interface {
+	Recorder
+
+	// List 分页查询审计记录，按时间倒序
+	List(ctx context.Context, opts ListOptions) (*ListResponse, error)
+}
+
+// service 审计服务实现
+type service struct {
+	logger *slog.Logger
+}
+
+// New 创建审计服务实例
+func New(logger *slog.Logger) Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &service{
+		logger: logger.With("component", "audit_service"),
+	}
+}
+
+// baseDB 返回不携带语句状态的数据库会话
+func baseDB(ctx context.Context) *gorm.DB {
+	return database.CleanSession(ctx, query.Q.Health.WithContext(ctx).UnderlyingDB())
+}
+
+func auditDB(ctx context.Context) *gorm.DB {
+	return baseDB(ctx).Model(&types.AuditLog{})
+}
+
+// Record 实现 Service 接口
+func (s *service) Record(ctx context.Context, entry Entry) error {
+	actor := ActorFromContext(ctx)
+	record := types.AuditLog{
+		Actor:      actor.Identity,
+		ClientIP:   actor.ClientIP,
+		RequestID:  actor.RequestID,
+		Action:     entry.Action,
+		Resource:   entry.Resource,
+		ResourceID: entry.ResourceID,
+		Result:     entry.Result,
+		Detail:     truncate(entry.Detail, detailMaxLength),
+	}
+
+	// 快照序列化失败时仍保留审计记录本身
+	if err := fillSnapshots(&record, entry.Before, entry.After); err != nil {
+		s.logger.Warn("序列化审计快照失败",
+			"action", entry.Action,
+			"resource", entry.Resource,
+			"resource_id", entry.ResourceID,
+			"error", err,
+		)
+	}
+
+	if err := baseDB(ctx).Create(&record).Error; err != nil {
+		s.logger.Error("写入审计记录失败",
+			"action", entry.Action,
+			"resource", entry.Resource,
+			"resource_id", entry.ResourceID,
+			"error", err,
+		)
+		return fmt.Errorf("写入审计记录失败：%w", err)
+	}
+
+	return nil
+}
+
+// fillSnapshots 序列化变更前后快照并计算差异，敏感字段脱敏后写入记录
+func fillSnapshots(record *types.AuditLog, before, after any) error {
+	beforeSnapshot, err := snapshot(before)
+	if err != nil {
+		return fmt.Errorf("序列化变更前快照失败：%w", err)
+	}
+	afterSnapshot, err := snapshot(after)
+	if err != nil {
+		return fmt.Errorf("序列化变更后快照失败：%w", err)
+	}
+
+	if beforeSnapshot != nil {
+		data, err := json.Marshal(redact(beforeSnapshot))
+		if err != nil {
+			return fmt.Errorf("序列化变更前快照失败：%w", err)
+		}
+		record.Before = string(data)
+	}
+	if afterSnapshot != nil {
+		data, err := json.Marshal(redact(afterSnapshot))
+		if err != nil {
+			return fmt.Errorf("序列化变更后快照失败：%w", err)
+		}
+		record.After = string(data)
+	}
+	if changes := diffSnapshots(beforeSnapshot, afterSnapshot); changes != nil {
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("序列化变更差异失败：%w", err)
+		}
+		record.Diff = string(data)
+	}
+
+	return nil
+}
+
+// List 实现 Service 接口
+func (s *service) List(ctx context.Context, opts ListOptions) (*ListResponse, error) {
+	page := opts.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := opts.PageSize
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	filter := func() *gorm.DB {
+		db := auditDB(ctx)
+		if opts.Actor != "" {
+			db = db.Where("actor = ?", opts.Actor)
+		}
+		if opts.Action != "" {
+			db = db.Where("action = ?", opts.Action)
+		}
+		if opts.Resource != "" {
+			db = db.Where("resource = ?", opts.Resource)
+		}
+		if opts.ResourceID != "" {
+			db = db.Where("resource_id = ?", opts.ResourceID)
+		}
+		if opts.Result != "" {
+			db = db.Where("result = ?", opts.Result)
+		}
+		if opts.StartTime != nil {
+			db = db.Where("created_at >= ?", *opts.StartTime)
+		}
+		if opts.EndTime != nil {
+			db = db.Where("created_at < ?", *opts.EndTime)
+		}
+		return db
+	}
+
+	var total int64
+	if err := filter().Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("统计审计记录失败：%w", err)
+	}
+
+	var records []types.AuditLog
+	if err := filter().
+		Order("created_at DESC").Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询审计记录失败：%w", err)
+	}
+
+	items := make([]LogItem, 0, len(records))
+	for _, record := range records {
+		items = append(items, LogItem{
+			AuditLog: record,
+			Before:   rawJSON(record.Before),
+			After:    rawJSON(record.After),
+			Diff:     rawJSON(record.Diff),
+		})
+	}
+
+	return &ListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// rawJSON 将存储的 JSON 文本转换为原始 JSON，空串返回 nil
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) *service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&types.Health{}, &types.AuditLog{}); err != nil {
+		t.Fatalf("迁移审计表失败: %v", err)
+	}
+	query.SetDefault(db)
+
+	return New(slog.Default()).(*service)
+}
+
+func TestRecord_记录操作者与脱敏差异(t *testing.T) {
+	svc := newTestService(t)
+	ctx := WithActor(context.Background(), Actor{
+		Identity:  TokenIdentity("admin-token"),
+		ClientIP:  "10.0.0.1",
+		RequestID: "req-1",
+	})
+
+	before := &types.APIKey{ID: 7, PlatformID: 1, Value: "sk-old-secret-1111"}
+	after := &types.APIKey{ID: 7, PlatformID: 1, Value: "sk-new-secret-2222"}
+	if err := svc.Record(ctx, Entry{
+		Action:     "key.update",
+		Resource:   "key",
+		ResourceID: "7",
+		Result:     types.AuditResultSuccess,
+		Detail:     "更新 API 密钥成功",
+		Before:     before,
+		After:      after,
+	}); err != nil {
+		t.Fatalf("记录审计失败: %v", err)
+	}
+
+	resp, err := svc.List(context.Background(), ListOptions{Resource: "key", ResourceID: "7"})
+	if err != nil {
+		t.Fatalf("查询审计失败: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Items) != 1 {
+		t.Fatalf("审计记录数量不符: total=%d items=%d", resp.Total, len(resp.Items))
+	}
+
+	item := resp.Items[0]
+	if !strings.HasPrefix(item.Actor, "admin:") || item.ClientIP != "10.0.0.1" || item.RequestID != "req-1" {
+		t.Fatalf("操作者信息不符: %+v", item.AuditLog)
+	}
+	for _, raw := range []json.RawMessage{item.Before, item.After, item.Diff} {
+		if strings.Contains(string(raw), "sk-old-secret") || strings.Contains(string(raw), "sk-new-secret") {
+			t.Fatalf("快照中出现未脱敏的密钥: %s", raw)
+		}
+	}
+
+	var diff map[string]FieldChange
+	if err := json.Unmarshal(item.Diff, &diff); err != nil {
+		t.Fatalf("解析差异失败: %v", err)
+	}
+	if len(diff) != 1 {
+		t.Fatalf("期望仅 value 字段变化，实际: %v", diff)
+	}
+	change, ok := diff["value"]
+	if !ok || change.Before != "****1111" || change.After != "****2222" {
+		t.Fatalf("value 字段差异不符: %+v", change)
+	}
+}
+
+func TestList_按条件过滤并分页(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	entries := []Entry{
+		{Action: "platform.create", Resource: "platform", ResourceID: "1", Result: types.AuditResultSuccess},
+		{Action: "platform.update", Resource: "platform", ResourceID: "1", Result: types.AuditResultFailed},
+		{Action: "platform.update", Resource: "platform", ResourceID: "1", Result: types.AuditResultSuccess},
+		{Action: "proxy.request", Resource: "proxy", ResourceID: "https://example.com", Result: types.AuditResultSuccess},
+	}
+	for _, entry := range entries {
+		if err := svc.Record(ctx, entry); err != nil {
+			t.Fatalf("记录审计失败: %v", err)
+		}
+	}
+
+	resp, err := svc.List(ctx, ListOptions{Resource: "platform", Result: types.AuditResultSuccess, Page: 1, PageSize: 1})
+	if err != nil {
+		t.Fatalf("查询审计失败: %v", err)
+	}
+	if resp.Total != 2 || len(resp.Items) != 1 {
+		t.Fatalf("过滤结果不符: total=%d items=%d", resp.Total, len(resp.Items))
+	}
+	if resp.Items[0].Action != "platform.update" {
+		t.Fatalf("期望按时间倒序返回最新记录，实际: %s", resp.Items[0].Action)
+	}
+	if resp.Items[0].Actor != AnonymousActor {
+		t.Fatalf("无操作者上下文时应记为匿名，实际: %s", resp.Items[0].Actor)
+	}
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// Entry 定义一次待记录的审计事件
+//
+// Before 与 After 为变更前后的资源快照，可为空；记录时序列化为 JSON 并脱敏。
+type Entry struct {
+	Action     string // 操作，如 platform.update
+	Resource   string // 资源类型，如 platform
+	ResourceID string // 资源标识
+	Result     string // 结果：success/failed
+	Detail     string // 详细说明
+	Before     any    // 变更前快照
+	After      any    // 变更后快照
+}
+
+// ListOptions 定义审计记录的筛选选项
+type ListOptions struct {
+	Actor      string     // 操作者标识
+	Action     string     // 操作
+	Resource   string     // 资源类型
+	ResourceID string     // 资源标识
+	Result     string     // 结果
+	StartTime  *time.Time // 开始时间（含）
+	EndTime    *time.Time // 结束时间（不含）
+	Page       int        // 页码
+	PageSize   int        // 每页大小
+}
+
+// FieldChange 定义单个字段的变更前后值
+type FieldChange struct {
+	Before any `json:"before"` // 变更前的值
+	After  any `json:"after"`  // 变更后的值
+}
+
+// LogItem 定义审计记录响应项，快照与差异以 JSON 对象返回
+type LogItem struct {
+	types.AuditLog
+	Before json.RawMessage `json:"before,omitempty"` // 变更前快照
+	After  json.RawMessage `json:"after,omitempty"`  // 变更后快照
+	Diff   json.RawMessage `json:"diff,omitempty"`   // 字段级差异
+}
+
+// ListResponse 定义审计记录列表响应
+type ListResponse struct {
+	Items    []LogItem `json:"items"`     // 审计记录
+	Total    int64     `json:"total"`     // 总数
+	Page     int       `json:"page"`      // 当前页码
+	PageSize int       `json:"page_size"` // 每页大小
+}
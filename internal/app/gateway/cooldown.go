@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	portalErrors "github.com/MeowSalty/portal/errors"
+)
+
+type cooldownTrackerKey struct{}
+
+// cooldownTracker 记录单次请求路由时因退避被排除的通道中最早恢复的时间。
+type cooldownTracker struct {
+	mu          sync.Mutex
+	availableAt time.Time
+}
+
+// withCooldownTracker 为请求上下文挂载通道冷却记录器。
+func withCooldownTracker(ctx context.Context) (context.Context, *cooldownTracker) {
+	tracker := &cooldownTracker{}
+	return context.WithValue(ctx, cooldownTrackerKey{}, tracker), tracker
+}
+
+// ObserveChannelCooldown 记录通道最早恢复可用的时间，供所有通道冷却时返回 Retry-After。
+//
+// 上下文未挂载记录器时忽略；多次调用保留最早的时间。
+func ObserveChannelCooldown(ctx context.Context, availableAt time.Time) {
+	tracker, ok := ctx.Value(cooldownTrackerKey{}).(*cooldownTracker)
+	if !ok || availableAt.IsZero() {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if tracker.availableAt.IsZero() || availableAt.Before(tracker.availableAt) {
+		tracker.availableAt = availableAt
+	}
+}
+
+// wrap 在没有可用通道的错误上附加最早恢复时间。
+func (t *cooldownTracker) wrap(err error) error {
+	if err == nil || !portalErrors.IsCode(err, portalErrors.ErrCodeResourceExhausted) {
+		return err
+	}
+	t.mu.Lock()
+	availableAt := t.availableAt
+	t.mu.Unlock()
+
+	wait := time.Until(availableAt)
+	if availableAt.IsZero() || wait <= 0 {
+		return err
+	}
+	return &cooldownError{err: err, retryAfter: wait}
+}
+
+// cooldownError 表示所有通道均在冷却中的错误。
+type cooldownError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *cooldownError) Error() string { return e.err.Error() }
+
+func (e *cooldownError) Unwrap() error { return e.err }
+
+// RetryAfter 返回建议客户端等待的时长。
+func (e *cooldownError) RetryAfter() time.Duration { return e.retryAfter }
+
+// applyCooldown 将所有通道冷却中的错误映射为带 Retry-After 的 429。
+func applyCooldown(err error, out *DataPlaneError) {
+	var ce *cooldownError
+	if !errors.As(err, &ce) {
+		return
+	}
+	out.StatusCode = http.StatusTooManyRequests
+	out.ErrorType = "rate_limit_error"
+	out.ErrorCode = "all_channels_cooling_down"
+	out.Message = "所有可用通道均处于冷却中，请稍后重试"
+	out.Retryable = true
+	out.RetryAfter = ce.retryAfter
+}
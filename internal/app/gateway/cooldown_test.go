@@ -0,0 +1,46 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	portalErrors "github.com/MeowSalty/portal/errors"
+)
+
+func TestCooldownTracker_所有通道冷却时映射为带RetryAfter的429(t *testing.T) {
+	ctx, tracker := withCooldownTracker(context.Background())
+	now := time.Now()
+	ObserveChannelCooldown(ctx, now.Add(time.Minute))
+	ObserveChannelCooldown(ctx, now.Add(10*time.Second))
+
+	noChannel := portalErrors.New(portalErrors.ErrCodeResourceExhausted, "没有可用的通道").WithHTTPStatus(http.StatusServiceUnavailable)
+	err := fmt.Errorf("处理请求失败：%w", tracker.wrap(noChannel))
+
+	mapped := (&service{}).MapDataPlaneError(err, "请求失败")
+	if mapped.StatusCode != http.StatusTooManyRequests || mapped.ErrorCode != "all_channels_cooling_down" {
+		t.Fatalf("应映射为 429: %+v", mapped)
+	}
+	if mapped.RetryAfter <= 0 || mapped.RetryAfter > 10*time.Second {
+		t.Fatalf("RetryAfter 应取最早恢复的通道: %v", mapped.RetryAfter)
+	}
+}
+
+func TestCooldownTracker_存在可用通道时不附加RetryAfter(t *testing.T) {
+	ctx, tracker := withCooldownTracker(context.Background())
+	ObserveChannelCooldown(ctx, time.Now().Add(time.Minute))
+	ObserveChannelCooldown(ctx, time.Now())
+
+	noChannel := portalErrors.New(portalErrors.ErrCodeResourceExhausted, "没有可用的通道")
+	if wrapped := tracker.wrap(noChannel); wrapped != noChannel {
+		t.Fatalf("存在已恢复通道时不应包装错误: %v", wrapped)
+	}
+
+	other := fmt.Errorf("upstream error")
+	ObserveChannelCooldown(ctx, time.Now().Add(-time.Second))
+	if wrapped := tracker.wrap(other); wrapped != other {
+		t.Fatalf("非通道耗尽错误不应包装: %v", wrapped)
+	}
+}
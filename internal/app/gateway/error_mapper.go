@@ -26,6 +26,7 @@ func (s *service) MapDataPlaneError(err error, fallbackAction string) DataPlaneE
 	}
 
 	extractStructuredDataPlaneError(err, &mapped)
+	applyCooldown(err, &mapped)
 	applyHeuristicDataPlaneError(err, fallbackAction, &mapped)
 
 	if mapped.Raw == nil {
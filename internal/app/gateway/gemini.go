@@ -29,10 +29,12 @@ func (s *service) executeGeminiGenerateContent(ctx context.Context, req *geminiT
 
 	logger.Info("开始执行非流式请求", "request_name", requestName, "model", modelName)
 
+	ctx, cooldown := withCooldownTracker(ctx)
 	startTime := time.Now()
 	resp, err := invoker(ctx, req)
 	duration := time.Since(startTime)
 	if err != nil {
+		err = cooldown.wrap(err)
 		s.logNonStreamError(logger, requestName, err, duration, modelName)
 		return nil, fmt.Errorf("处理 %s 请求失败：%w", requestName, err)
 	}
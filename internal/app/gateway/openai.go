@@ -407,10 +407,12 @@ func (s *service) executeOpenAIChatCompletion(ctx context.Context, req *openaiCh
 	}
 	logger.Info("开始执行非流式请求", "request_name", requestName, "model", modelName)
 
+	ctx, cooldown := withCooldownTracker(ctx)
 	startTime := time.Now()
 	resp, err := invoker(ctx, req)
 	duration := time.Since(startTime)
 	if err != nil {
+		err = cooldown.wrap(err)
 		s.logNonStreamError(logger, requestName, err, duration, modelName)
 		return nil, fmt.Errorf("处理 %s 请求失败：%w", requestName, err)
 	}
@@ -427,10 +429,12 @@ func (s *service) executeOpenAIResponses(ctx context.Context, req *openaiRespons
 	}
 	logger.Info("开始执行非流式请求", "request_name", requestName, "model", modelName)
 
+	ctx, cooldown := withCooldownTracker(ctx)
 	startTime := time.Now()
 	resp, err := invoker(ctx, req)
 	duration := time.Since(startTime)
 	if err != nil {
+		err = cooldown.wrap(err)
 		s.logNonStreamError(logger, requestName, err, duration, modelName)
 		return nil, fmt.Errorf("处理 %s 请求失败：%w", requestName, err)
 	}
@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"net/http"
+	"time"
 
 	anthropicTypes "github.com/MeowSalty/portal/request/adapter/anthropic/types"
 	geminiTypes "github.com/MeowSalty/portal/request/adapter/gemini/types"
@@ -63,6 +64,7 @@ type DataPlaneError struct {
 	Raw                    any
 	Retryable              bool
 	ShouldProxyAsHTTPError bool
+	RetryAfter             time.Duration
 }
 
 func defaultDataPlaneError(action string) DataPlaneError {
@@ -0,0 +1,36 @@
+package health
+
+import (
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// ChannelAvailableAt 返回平台、模型、密钥组成的通道最早可参与路由的时间
+//
+// 取平台、模型、密钥以及密钥-模型组合中最晚的退避到期时间；当前可用时返回 now。
+// 任一资源被手动或永久禁用时通道不会自动恢复，返回 false。
+func (s *Storage) ChannelAvailableAt(platformID, modelID, keyID uint, now time.Time) (time.Time, bool) {
+	availableAt := now
+	for _, resource := range []struct {
+		resourceType types.ResourceType
+		resourceID   uint
+	}{
+		{types.ResourceTypePlatform, platformID},
+		{types.ResourceTypeModel, modelID},
+		{types.ResourceTypeAPIKey, keyID},
+		{types.ResourceTypeKeyModel, types.KeyModelResourceID(keyID, modelID)},
+	} {
+		h := s.cached(s.makeKey(resource.resourceType, resource.resourceID))
+		switch {
+		case h == nil:
+		case h.NextAvailableAt != nil:
+			if h.NextAvailableAt.After(availableAt) {
+				availableAt = *h.NextAvailableAt
+			}
+		case h.Status == types.HealthStatusUnavailable:
+			return time.Time{}, false
+		}
+	}
+	return availableAt, true
+}
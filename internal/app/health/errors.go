@@ -0,0 +1,11 @@
+package health
+
+import "errors"
+
+var (
+	ErrPolicyNotFound       = errors.New("健康策略未找到")
+	ErrInvalidPolicy        = errors.New("健康策略参数不合法")
+	ErrPolicyPlatformAbsent = errors.New("平台不存在")
+	ErrInvalidArgument      = errors.New("请求参数不合法")
+	ErrHealthConflict       = errors.New("健康状态已被其他实例修改")
+)
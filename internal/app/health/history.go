@@ -0,0 +1,258 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+// historyRanges 为变化历史支持的统计时间范围
+var historyRanges = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// defaultHistoryRange 为默认统计时间范围
+const defaultHistoryRange = "7d"
+
+// customHistoryRange 为指定起止时间时响应中的时间范围标识
+const customHistoryRange = "custom"
+
+// historyPruneBatchSize 为清理变化历史时单批删除的记录数
+const historyPruneBatchSize = 5000
+
+// historyDB 返回健康状态变化历史表的数据库会话
+func historyDB(ctx context.Context) *gorm.DB {
+	return policyDB(ctx).Model(&types.HealthTransition{})
+}
+
+// recordTransition 将状态变化写入历史表，写入失败仅记录日志，不影响状态更新
+func (s *Storage) recordTransition(transition Transition) {
+	record := &types.HealthTransition{
+		ResourceType: transition.ResourceType,
+		ResourceID:   transition.ResourceID,
+		FromStatus:   transition.From,
+		ToStatus:     transition.To,
+		CreatedAt:    transition.At,
+	}
+	if h := transition.Health; h != nil && transition.To != types.HealthStatusAvailable {
+		record.ErrorCode = h.LastStructuredErrorCode
+		record.HTTPStatus = h.LastHTTPStatus
+		record.ErrorMessage = h.LastErrorMessage
+	}
+
+	if err := historyDB(context.Background()).Create(record).Error; err != nil {
+		s.logger.Warn("写入健康状态变化历史失败",
+			"resource_type", transition.ResourceType,
+			"resource_id", transition.ResourceID,
+			"error", err)
+	}
+}
+
+// GetHistory 获取资源健康状态变化历史与时间范围内的可用率
+func (s *service) GetHistory(ctx context.Context, resourceType types.ResourceType, resourceID uint, opts HistoryOptions) (*HistoryResponse, error) {
+	rangeName, start, end, err := resolveHistoryWindow(opts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	logger := s.logger.With(
+		"operation", "get_history",
+		"resource_type", resourceType,
+		"resource_id", resourceID,
+	)
+
+	filter := func() *gorm.DB {
+		return historyDB(ctx).Where("resource_type = ? AND resource_id = ? AND created_at >= ? AND created_at < ?", resourceType, resourceID, start, end)
+	}
+
+	var total int64
+	if err := filter().Count(&total).Error; err != nil {
+		logger.ErrorContext(ctx, "统计健康状态变化历史失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询健康状态变化历史失败：%w", err)
+	}
+
+	items := make([]types.HealthTransition, 0)
+	if err := filter().Order("created_at DESC, id DESC").
+		Offset((opts.Page - 1) * opts.PageSize).
+		Limit(opts.PageSize).
+		Find(&items).Error; err != nil {
+		logger.ErrorContext(ctx, "查询健康状态变化历史失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询健康状态变化历史失败：%w", err)
+	}
+
+	// 可用率按窗口内全部变化计算，与分页无关
+	var transitions []types.HealthTransition
+	if err := filter().Select("from_status, to_status, created_at").
+		Order("created_at ASC, id ASC").
+		Find(&transitions).Error; err != nil {
+		logger.ErrorContext(ctx, "查询健康状态变化历史失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询健康状态变化历史失败：%w", err)
+	}
+
+	initial, err := s.statusAt(ctx, resourceType, resourceID, start, end, transitions)
+	if err != nil {
+		logger.ErrorContext(ctx, "查询窗口起始状态失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询健康状态变化历史失败：%w", err)
+	}
+
+	uptime := computeUptime(initial, transitions, start, end)
+	return &HistoryResponse{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Range:        rangeName,
+		StartTime:    start,
+		EndTime:      end,
+		Uptime:       uptime,
+		Items:        items,
+		Total:        total,
+		Page:         opts.Page,
+		PageSize:     opts.PageSize,
+	}, nil
+}
+
+// resolveHistoryWindow 解析统计时间范围，返回范围标识与起止时间
+//
+// 指定了起止时间之一时按起止时间统计，缺省的开始时间取结束时间前的默认范围；否则按预设范围统计。
+func resolveHistoryWindow(opts HistoryOptions, now time.Time) (string, time.Time, time.Time, error) {
+	if opts.StartTime == nil && opts.EndTime == nil {
+		rangeName := opts.Range
+		if rangeName == "" {
+			rangeName = defaultHistoryRange
+		}
+		window, ok := historyRanges[rangeName]
+		if !ok {
+			return "", time.Time{}, time.Time{}, fmt.Errorf("无效的时间范围参数，可选值：24h, 7d, 30d：%w", ErrInvalidArgument)
+		}
+		return rangeName, now.Add(-window), now, nil
+	}
+
+	end := now
+	if opts.EndTime != nil && opts.EndTime.Before(now) {
+		end = *opts.EndTime
+	}
+	start := end.Add(-historyRanges[defaultHistoryRange])
+	if opts.StartTime != nil {
+		start = *opts.StartTime
+	}
+	if !end.After(start) {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("结束时间必须晚于开始时间：%w", ErrInvalidArgument)
+	}
+	return customHistoryRange, start, end, nil
+}
+
+// statusAt 推断窗口起始时刻的状态
+//
+// 优先使用窗口前最后一次变化的目标状态；窗口前无记录时使用窗口内首次变化的起始状态；
+// 窗口内也无变化时使用窗口后首次变化的起始状态，仍无记录时使用当前状态。
+func (s *service) statusAt(ctx context.Context, resourceType types.ResourceType, resourceID uint, start, end time.Time, transitions []types.HealthTransition) (types.HealthStatus, error) {
+	var before []types.HealthTransition
+	if err := historyDB(ctx).Select("to_status").
+		Where("resource_type = ? AND resource_id = ? AND created_at < ?", resourceType, resourceID, start).
+		Order("created_at DESC, id DESC").
+		Limit(1).
+		Find(&before).Error; err != nil {
+		return types.HealthStatusUnknown, err
+	}
+
+	switch {
+	case len(before) > 0:
+		return before[0].ToStatus, nil
+	case len(transitions) > 0:
+		return transitions[0].FromStatus, nil
+	}
+
+	var after []types.HealthTransition
+	if err := historyDB(ctx).Select("from_status").
+		Where("resource_type = ? AND resource_id = ? AND created_at >= ?", resourceType, resourceID, end).
+		Order("created_at ASC, id ASC").
+		Limit(1).
+		Find(&after).Error; err != nil {
+		return types.HealthStatusUnknown, err
+	}
+	if len(after) > 0 {
+		return after[0].FromStatus, nil
+	}
+
+	current, err := s.storage.Get(resourceType, resourceID)
+	if err != nil || current == nil {
+		return types.HealthStatusUnknown, err
+	}
+	return current.Status, nil
+}
+
+// PruneHistory 分批删除 before 之前的健康状态变化历史
+func (s *service) PruneHistory(ctx context.Context, before time.Time) (*HistoryPruneResult, error) {
+	result := &HistoryPruneResult{Before: before}
+	for {
+		var ids []uint
+		if err := historyDB(ctx).Where("created_at < ?", before).
+			Order("id ASC").
+			Limit(historyPruneBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return result, fmt.Errorf("查询过期健康状态变化历史失败：%w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		deleted := historyDB(ctx).Where("id IN ?", ids).Delete(&types.HealthTransition{})
+		if deleted.Error != nil {
+			return result, fmt.Errorf("删除过期健康状态变化历史失败：%w", deleted.Error)
+		}
+		result.DeletedRows += deleted.RowsAffected
+
+		if len(ids) < historyPruneBatchSize {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+	}
+
+	if result.DeletedRows > 0 {
+		s.logger.InfoContext(ctx, "已清理过期健康状态变化历史", "before", before, "deleted_rows", result.DeletedRows)
+	}
+	return result, nil
+}
+
+// computeUptime 按状态累计窗口内各状态的持续时间并计算可用率
+//
+// 可用率 = 可用时长 / (可用 + 警告 + 不可用时长)，未知状态的时长不计入分母。
+func computeUptime(initial types.HealthStatus, transitions []types.HealthTransition, start, end time.Time) Uptime {
+	durations := make(map[types.HealthStatus]time.Duration, 4)
+	status := initial
+	cursor := start
+	for _, transition := range transitions {
+		at := transition.CreatedAt
+		if at.Before(cursor) {
+			at = cursor
+		}
+		if at.After(end) {
+			at = end
+		}
+		durations[status] += at.Sub(cursor)
+		cursor = at
+		status = transition.ToStatus
+	}
+	durations[status] += end.Sub(cursor)
+
+	uptime := Uptime{
+		AvailableSeconds:   int64(durations[types.HealthStatusAvailable].Seconds()),
+		WarningSeconds:     int64(durations[types.HealthStatusWarning].Seconds()),
+		UnavailableSeconds: int64(durations[types.HealthStatusUnavailable].Seconds()),
+		UnknownSeconds:     int64(durations[types.HealthStatusUnknown].Seconds()),
+		Transitions:        len(transitions),
+	}
+
+	known := durations[types.HealthStatusAvailable] + durations[types.HealthStatusWarning] + durations[types.HealthStatusUnavailable]
+	if known > 0 {
+		percent := float64(durations[types.HealthStatusAvailable]) / float64(known) * 100
+		uptime.Percent = &percent
+	}
+	return uptime
+}
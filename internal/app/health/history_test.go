@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestStorage_仅在状态变化时写入历史(t *testing.T) {
+	svc, db := newTestService(t)
+
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, Outcome{Success: true}); err != nil {
+		t.Fatalf("记录成功结果失败: %v", err)
+	}
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, Outcome{Success: true}); err != nil {
+		t.Fatalf("记录成功结果失败: %v", err)
+	}
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, failure(http.StatusForbidden)); err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+	if err := svc.storage.Delete(types.ResourceTypeAPIKey, 1); err != nil {
+		t.Fatalf("删除健康状态失败: %v", err)
+	}
+
+	var transitions []types.HealthTransition
+	if err := db.Order("id ASC").Find(&transitions).Error; err != nil {
+		t.Fatalf("查询变化历史失败: %v", err)
+	}
+	if len(transitions) != 3 {
+		t.Fatalf("应写入 3 条变化记录，实际 %d", len(transitions))
+	}
+
+	want := [][2]types.HealthStatus{
+		{types.HealthStatusUnknown, types.HealthStatusAvailable},
+		{types.HealthStatusAvailable, types.HealthStatusWarning},
+		{types.HealthStatusWarning, types.HealthStatusUnknown},
+	}
+	for i, w := range want {
+		if transitions[i].FromStatus != w[0] || transitions[i].ToStatus != w[1] {
+			t.Fatalf("第 %d 条变化记录不符: %+v", i, transitions[i])
+		}
+	}
+	if transitions[1].HTTPStatus == nil || *transitions[1].HTTPStatus != http.StatusForbidden {
+		t.Fatalf("失败变化应记录 HTTP 状态码: %+v", transitions[1])
+	}
+}
+
+func TestComputeUptime_按状态持续时间计算可用率(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	transitions := []types.HealthTransition{
+		{FromStatus: types.HealthStatusAvailable, ToStatus: types.HealthStatusUnavailable, CreatedAt: start.Add(6 * time.Hour)},
+		{FromStatus: types.HealthStatusUnavailable, ToStatus: types.HealthStatusAvailable, CreatedAt: start.Add(8 * time.Hour)},
+	}
+
+	uptime := computeUptime(types.HealthStatusAvailable, transitions, start, end)
+	if uptime.Percent == nil || *uptime.Percent != 80 {
+		t.Fatalf("可用率应为 80%%: %+v", uptime.Percent)
+	}
+	if uptime.UnavailableSeconds != 2*3600 || uptime.Transitions != 2 {
+		t.Fatalf("统计不符: %+v", uptime)
+	}
+
+	unknown := computeUptime(types.HealthStatusUnknown, nil, start, end)
+	if unknown.Percent != nil || unknown.UnknownSeconds != 10*3600 {
+		t.Fatalf("无已知状态时可用率应为空: %+v", unknown)
+	}
+}
+
+func TestGetHistory_返回窗口内记录与可用率(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	records := []types.HealthTransition{
+		{ResourceType: types.ResourceTypeModel, ResourceID: 7, FromStatus: types.HealthStatusUnknown, ToStatus: types.HealthStatusAvailable, CreatedAt: now.Add(-48 * time.Hour)},
+		{ResourceType: types.ResourceTypeModel, ResourceID: 7, FromStatus: types.HealthStatusAvailable, ToStatus: types.HealthStatusWarning, CreatedAt: now.Add(-6 * time.Hour)},
+		{ResourceType: types.ResourceTypeModel, ResourceID: 8, FromStatus: types.HealthStatusUnknown, ToStatus: types.HealthStatusWarning, CreatedAt: now.Add(-time.Hour)},
+	}
+	if err := db.Create(&records).Error; err != nil {
+		t.Fatalf("写入变化历史失败: %v", err)
+	}
+
+	resp, err := svc.GetHistory(ctx, types.ResourceTypeModel, 7, HistoryOptions{Range: "24h", Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("获取变化历史失败: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Items) != 1 || resp.Items[0].ToStatus != types.HealthStatusWarning {
+		t.Fatalf("应仅返回窗口内该资源的记录: %+v", resp.Items)
+	}
+	if resp.Uptime.Percent == nil || *resp.Uptime.Percent < 74 || *resp.Uptime.Percent > 76 {
+		t.Fatalf("可用率应约为 75%%: %+v", resp.Uptime.Percent)
+	}
+
+	if _, err := svc.GetHistory(ctx, types.ResourceTypeModel, 7, HistoryOptions{Range: "1y", Page: 1, PageSize: 10}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("无效时间范围应返回参数错误: %v", err)
+	}
+}
+
+func TestGetHistory_按起止时间查询(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	records := []types.HealthTransition{
+		{ResourceType: types.ResourceTypeModel, ResourceID: 7, FromStatus: types.HealthStatusAvailable, ToStatus: types.HealthStatusUnavailable, CreatedAt: now.Add(-10 * 24 * time.Hour)},
+		{ResourceType: types.ResourceTypeModel, ResourceID: 7, FromStatus: types.HealthStatusUnavailable, ToStatus: types.HealthStatusAvailable, CreatedAt: now.Add(-2 * 24 * time.Hour)},
+	}
+	if err := db.Create(&records).Error; err != nil {
+		t.Fatalf("写入变化历史失败: %v", err)
+	}
+
+	// 窗口内无变化时，起始状态取窗口后首次变化的起始状态
+	start, end := now.Add(-6*24*time.Hour), now.Add(-4*24*time.Hour)
+	resp, err := svc.GetHistory(ctx, types.ResourceTypeModel, 7, HistoryOptions{StartTime: &start, EndTime: &end, Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("获取变化历史失败: %v", err)
+	}
+	if resp.Range != customHistoryRange || resp.Total != 0 || !resp.EndTime.Equal(end) {
+		t.Fatalf("自定义时间范围结果不符: %+v", resp)
+	}
+	if resp.Uptime.Percent == nil || *resp.Uptime.Percent != 0 {
+		t.Fatalf("窗口内应全部不可用: %+v", resp.Uptime.Percent)
+	}
+
+	if _, err := svc.GetHistory(ctx, types.ResourceTypeModel, 7, HistoryOptions{StartTime: &end, EndTime: &start, Page: 1, PageSize: 10}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("结束时间早于开始时间应返回参数错误: %v", err)
+	}
+}
+
+func TestPruneHistory_删除过期记录(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	records := []types.HealthTransition{
+		{ResourceType: types.ResourceTypeModel, ResourceID: 7, FromStatus: types.HealthStatusUnknown, ToStatus: types.HealthStatusAvailable, CreatedAt: now.Add(-100 * 24 * time.Hour)},
+		{ResourceType: types.ResourceTypeModel, ResourceID: 7, FromStatus: types.HealthStatusAvailable, ToStatus: types.HealthStatusWarning, CreatedAt: now.Add(-time.Hour)},
+	}
+	if err := db.Create(&records).Error; err != nil {
+		t.Fatalf("写入变化历史失败: %v", err)
+	}
+
+	result, err := svc.PruneHistory(ctx, now.AddDate(0, 0, -90))
+	if err != nil {
+		t.Fatalf("清理变化历史失败: %v", err)
+	}
+	if result.DeletedRows != 1 {
+		t.Fatalf("删除记录数 = %d, 期望 1", result.DeletedRows)
+	}
+
+	var remaining []types.HealthTransition
+	db.Find(&remaining)
+	if len(remaining) != 1 || remaining[0].ToStatus != types.HealthStatusWarning {
+		t.Fatalf("应保留保留期内的记录: %+v", remaining)
+	}
+}
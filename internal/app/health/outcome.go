@@ -0,0 +1,140 @@
+package health
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// Outcome 描述一次主动探测对资源健康状态的影响
+type Outcome struct {
+	Success    bool   // 是否成功
+	Message    string // 失败原因
+	ErrorCode  string // 稳定错误码
+	HTTPStatus *int   // 上游 HTTP 状态码（无响应时为空）
+	ErrorFrom  string // 错误来源
+
+	RetryAt *time.Time // 上游要求的最早重试时间（如 Retry-After），策略计入该错误时替代指数退避
+}
+
+// IsManuallyDisabled 判断健康记录是否为手动禁用
+//
+// 手动禁用与永久禁用的资源均为不可用且没有自动恢复时间，主动探测不得覆盖。
+func IsManuallyDisabled(h *types.Health) bool {
+	return h != nil && h.Status == types.HealthStatusUnavailable && h.NextAvailableAt == nil
+}
+
+// RecordOutcome 按资源生效的健康策略更新资源健康状态，并通过 Set 写入存储
+//
+// 成功时累加成功计数并按半开规则恢复；失败时累加错误计数并按退避策略计算下次可用时间，
+// 上游给出重试时间时以其为准。其他实例已修改该记录时基于最新记录重新计算。
+func (s *Storage) RecordOutcome(resourceType types.ResourceType, resourceID uint, outcome Outcome) (*types.Health, error) {
+	for attempt := 1; ; attempt++ {
+		next, err := s.recordOutcome(resourceType, resourceID, outcome)
+		if errors.Is(err, ErrHealthConflict) && attempt < conflictRetries {
+			continue
+		}
+		return next, err
+	}
+}
+
+// conflictRetries 为写入健康状态遇到版本冲突时基于最新记录重新计算的最大次数
+const conflictRetries = 3
+
+// recordOutcome 基于缓存中的记录计算并写入一次结果
+func (s *Storage) recordOutcome(resourceType types.ResourceType, resourceID uint, outcome Outcome) (*types.Health, error) {
+	current, err := s.Get(resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 复制缓存中的记录，避免在写入前修改共享对象
+	now := time.Now()
+	var next types.Health
+	if current != nil {
+		next = *current
+	} else {
+		next = types.Health{
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Status:       types.HealthStatusUnknown,
+			CreatedAt:    now,
+		}
+	}
+	next.LastCheckAt = now
+	next.UpdatedAt = now
+
+	if outcome.Success {
+		next.SuccessCount++
+		next.LastSuccessAt = &now
+		next.ErrorCount = 0
+		next.LastError = ""
+		next.LastErrorCode = 0
+		next.LastErrorMessage = ""
+		next.LastStructuredErrorCode = ""
+		next.LastHTTPStatus = nil
+		next.LastErrorFrom = ""
+		next.LastCauseMessage = ""
+	} else {
+		next.ErrorCount++
+		next.LastErrorMessage = outcome.Message
+		next.LastStructuredErrorCode = outcome.ErrorCode
+		next.LastHTTPStatus = outcome.HTTPStatus
+		next.LastErrorFrom = outcome.ErrorFrom
+		next.LastCauseMessage = ""
+		next.LastError = outcome.Message
+		next.LastErrorCode = 0
+		if outcome.HTTPStatus != nil {
+			next.LastErrorCode = *outcome.HTTPStatus
+		}
+	}
+
+	if outcome.Success {
+		s.policies.resolve(resourceType, resourceID).apply(current, &next, outcomeSuccess, now)
+	} else if reason, ok := classifyHealth(&next); ok {
+		markPermanentlyDisabled(&next, reason, now)
+	} else {
+		policy := s.policies.resolve(resourceType, resourceID)
+		policy.apply(current, &next, outcomeFailure, now)
+		if outcome.RetryAt != nil && policy.counts(errorClass(&next)) {
+			applyRetryHint(&next, *outcome.RetryAt, now)
+		}
+	}
+
+	if err := s.Set(&next); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// ApplyPolicy 按资源生效的健康策略修正 portal 库即将写入的健康记录
+//
+// previous 为写入前的记录（可为空），next 会被原地修改；非请求结果的写入仅保留半开计数。
+// 已永久禁用的记录保持不变，避免禁用前发出的请求在完成后将其恢复；
+// 权限与模型不存在类失败仅计入密钥-模型组合，不改变密钥与模型自身的状态。
+func (s *Storage) ApplyPolicy(previous, next *types.Health) {
+	if IsPermanentlyDisabled(previous) {
+		*next = *previous
+		return
+	}
+
+	kind := detectOutcome(previous, next)
+	if kind == outcomeFailure {
+		if reason, ok := classifyHealth(next); ok {
+			markPermanentlyDisabled(next, reason, time.Now())
+			return
+		}
+		if next.ResourceType != types.ResourceTypePlatform && isPairScoped(next.LastHTTPStatus) {
+			keepScopeOnPairFailure(previous, next)
+			return
+		}
+	}
+	if kind == outcomeNone {
+		if previous != nil {
+			next.HalfOpenSuccessCount = previous.HalfOpenSuccessCount
+		}
+		return
+	}
+	s.policies.resolve(next.ResourceType, next.ResourceID).apply(previous, next, kind, time.Now())
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// pairScopedStatuses 为仅影响单个密钥-模型组合的上游 HTTP 状态码
+//
+// 403 多为密钥无权使用该模型，404 多为该密钥所属账户不提供该模型，同一密钥访问其他模型通常不受影响。
+var pairScopedStatuses = map[int]struct{}{
+	http.StatusForbidden: {},
+	http.StatusNotFound:  {},
+}
+
+// isPairScoped 判断失败是否仅应计入密钥-模型组合
+func isPairScoped(status *int) bool {
+	if status == nil {
+		return false
+	}
+	_, ok := pairScopedStatuses[*status]
+	return ok
+}
+
+// IsKeyModelAvailable 判断密钥-模型组合当前是否可参与路由
+//
+// 没有记录、退避已到期或未处于不可用状态的组合视为可用；密钥与模型自身的健康状态由 portal 库另行判断。
+func (s *Storage) IsKeyModelAvailable(keyID, modelID uint) bool {
+	h := s.cached(s.makeKey(types.ResourceTypeKeyModel, types.KeyModelResourceID(keyID, modelID)))
+	if h == nil {
+		return true
+	}
+	if h.NextAvailableAt != nil {
+		return !time.Now().Before(*h.NextAvailableAt)
+	}
+	return h.Status != types.HealthStatusUnavailable
+}
+
+// observeKeyModel 根据请求日志更新密钥-模型组合的健康状态
+//
+// 仅权限与模型不存在类失败会创建组合记录；已有记录的组合在请求成功时按半开规则恢复。
+func (s *Storage) observeKeyModel(log *types.RequestLog) {
+	if log.ModelID == 0 {
+		return
+	}
+	resourceID := types.KeyModelResourceID(log.APIKeyID, log.ModelID)
+
+	var outcome Outcome
+	switch {
+	case log.Success:
+		if s.cached(s.makeKey(types.ResourceTypeKeyModel, resourceID)) == nil {
+			return
+		}
+		outcome = Outcome{Success: true}
+	case isPairScoped(log.HTTPStatus) && !isPermanentLog(log):
+		outcome = Outcome{
+			Message:    firstNonEmpty(derefString(log.UpstreamErrorMessage), derefString(log.ErrorMsg)),
+			ErrorCode:  firstNonEmpty(derefString(log.UpstreamErrorCode), derefString(log.ErrorCode)),
+			HTTPStatus: log.HTTPStatus,
+			ErrorFrom:  derefString(log.ErrorFrom),
+		}
+	default:
+		return
+	}
+
+	if _, err := s.RecordOutcome(types.ResourceTypeKeyModel, resourceID, outcome); err != nil {
+		s.logger.Error("更新密钥-模型组合健康状态失败",
+			"key_id", log.APIKeyID,
+			"model_id", log.ModelID,
+			"error", err)
+	}
+}
+
+// isPermanentLog 判断失败请求是否属于密钥永久失败，此类失败由密钥禁用处理
+func isPermanentLog(log *types.RequestLog) bool {
+	_, ok := ClassifyPermanentFailure(FailureSignal{
+		HTTPStatus: log.HTTPStatus,
+		ErrorType:  derefString(log.UpstreamErrorType),
+		ErrorCode:  derefString(log.UpstreamErrorCode),
+	})
+	return ok
+}
+
+// keepScopeOnPairFailure 撤销仅影响单个组合的失败对密钥与模型健康状态的影响
+//
+// 保留错误详情便于排查，状态、退避与错误计数恢复为写入前的值，由组合记录承担退避。
+func keepScopeOnPairFailure(previous, next *types.Health) {
+	prev := types.Health{Status: types.HealthStatusUnknown}
+	if previous != nil {
+		prev = *previous
+	}
+	next.ErrorCount = prev.ErrorCount
+	next.HalfOpenSuccessCount = prev.HalfOpenSuccessCount
+	restoreBackoff(next, &prev)
+}
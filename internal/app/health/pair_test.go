@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestKeyModelResourceID_编码可还原(t *testing.T) {
+	id := types.KeyModelResourceID(7, 42)
+	keyID, modelID := types.SplitKeyModelResourceID(id)
+	if keyID != 7 || modelID != 42 {
+		t.Fatalf("解析结果不符: key=%d model=%d", keyID, modelID)
+	}
+}
+
+func TestObserveRequestLog_模型权限错误仅退避密钥模型组合(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	platform := types.Platform{Name: "p"}
+	if err := db.Create(&platform).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+	key := types.APIKey{PlatformID: platform.ID, Value: "k"}
+	if err := db.Create(&key).Error; err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	models := []types.Model{{PlatformID: platform.ID, Name: "gpt-4o"}, {PlatformID: platform.ID, Name: "o1"}}
+	if err := db.Create(&models).Error; err != nil {
+		t.Fatalf("创建模型失败: %v", err)
+	}
+	restricted := models[1]
+
+	// portal 库对密钥写入 403 失败：不改变密钥状态，仅保留错误详情
+	forbidden := http.StatusForbidden
+	backoffUntil := time.Now().Add(time.Minute)
+	incoming := &types.Health{
+		ResourceType:     types.ResourceTypeAPIKey,
+		ResourceID:       key.ID,
+		Status:           types.HealthStatusWarning,
+		RetryCount:       1,
+		NextAvailableAt:  &backoffUntil,
+		ErrorCount:       1,
+		LastErrorMessage: "model not allowed",
+		LastHTTPStatus:   &forbidden,
+	}
+	svc.storage.ApplyPolicy(nil, incoming)
+	if incoming.Status != types.HealthStatusUnknown || incoming.ErrorCount != 0 || incoming.NextAvailableAt != nil {
+		t.Fatalf("组合级失败不应改变密钥状态: %+v", incoming)
+	}
+	if incoming.LastErrorMessage != "model not allowed" {
+		t.Fatalf("应保留错误详情: %+v", incoming)
+	}
+
+	// 请求日志驱动组合记录退避，其他模型不受影响
+	svc.storage.ObserveRequestLog(&types.RequestLog{
+		APIKeyID:             key.ID,
+		ModelID:              restricted.ID,
+		HTTPStatus:           intPtr(http.StatusForbidden),
+		UpstreamErrorMessage: strPtr("model not allowed"),
+	})
+	if svc.storage.IsKeyModelAvailable(key.ID, restricted.ID) {
+		t.Fatalf("无权限的组合应进入退避")
+	}
+	if !svc.storage.IsKeyModelAvailable(key.ID, models[0].ID) {
+		t.Fatalf("其他模型不应受影响")
+	}
+
+	list, err := svc.GetKeyModelHealthList(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("获取组合健康列表失败: %v", err)
+	}
+	if list.Total != 1 || len(list.Items) != 1 {
+		t.Fatalf("应返回 1 条组合记录: %+v", list)
+	}
+	item := list.Items[0]
+	if item.KeyValue != "k" || item.ModelName != "o1" || item.PlatformID != platform.ID || item.Status != types.HealthStatusWarning {
+		t.Fatalf("组合记录内容不符: %+v", item)
+	}
+
+	// 退避到期后请求成功，组合恢复可用
+	h, _ := svc.storage.Get(types.ResourceTypeKeyModel, item.ResourceID)
+	expired := *h
+	past := time.Now().Add(-time.Second)
+	expired.NextAvailableAt = &past
+	if err := svc.storage.Set(&expired); err != nil {
+		t.Fatalf("写入健康状态失败: %v", err)
+	}
+	if !svc.storage.IsKeyModelAvailable(key.ID, restricted.ID) {
+		t.Fatalf("退避到期后应放行请求")
+	}
+	svc.storage.ObserveRequestLog(&types.RequestLog{APIKeyID: key.ID, ModelID: restricted.ID, Success: true})
+	if h, _ := svc.storage.Get(types.ResourceTypeKeyModel, item.ResourceID); h.Status != types.HealthStatusAvailable {
+		t.Fatalf("成功后组合应恢复可用: %+v", h)
+	}
+}
@@ -0,0 +1,215 @@
+package health
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// 密钥永久禁用原因
+const (
+	DisabledReasonInvalidKey         = "invalid_api_key"     // 密钥无效或已被吊销
+	DisabledReasonQuotaExhausted     = "quota_exhausted"     // 账户额度或余额耗尽
+	DisabledReasonAccountDeactivated = "account_deactivated" // 上游账户或组织被停用
+)
+
+// quotaExhaustedCodes 为上游表示额度耗尽的错误类型或错误码
+//
+// 仅使用精确匹配：限流类错误（如 rate_limit_exceeded、RESOURCE_EXHAUSTED）同样带有 quota 字样，但可自动恢复。
+var quotaExhaustedCodes = map[string]struct{}{
+	"insufficient_quota":         {},
+	"billing_hard_limit_reached": {},
+	"billing_not_active":         {},
+	"insufficient_balance":       {},
+	"credit_balance_too_low":     {},
+}
+
+// deactivatedCodes 为上游表示账户被停用的错误类型或错误码
+var deactivatedCodes = map[string]struct{}{
+	"account_deactivated":      {},
+	"organization_deactivated": {},
+	"account_disabled":         {},
+}
+
+// invalidKeyCodes 为上游表示密钥无效的错误类型或错误码
+var invalidKeyCodes = map[string]struct{}{
+	"invalid_api_key":      {},
+	"api_key_invalid":      {},
+	"authentication_error": {},
+}
+
+// FailureSignal 描述一次失败请求中可用于分类的上游错误信息
+//
+// 字段与数据面 DataPlaneError 以及请求日志中的上游错误字段一一对应。
+type FailureSignal struct {
+	HTTPStatus *int   // 上游 HTTP 状态码（无响应时为空）
+	ErrorType  string // 上游错误类型
+	ErrorCode  string // 上游错误码
+}
+
+// ClassifyPermanentFailure 判断失败是否属于不可自动恢复的密钥失败
+//
+// 返回禁用原因与是否为永久失败；额度与停用类错误码优先于 HTTP 401 判定。
+func ClassifyPermanentFailure(signal FailureSignal) (string, bool) {
+	values := []string{
+		strings.ToLower(strings.TrimSpace(signal.ErrorCode)),
+		strings.ToLower(strings.TrimSpace(signal.ErrorType)),
+	}
+
+	switch {
+	case matchAny(values, quotaExhaustedCodes):
+		return DisabledReasonQuotaExhausted, true
+	case matchAny(values, deactivatedCodes):
+		return DisabledReasonAccountDeactivated, true
+	case signal.HTTPStatus != nil && *signal.HTTPStatus == http.StatusPaymentRequired:
+		return DisabledReasonQuotaExhausted, true
+	case signal.HTTPStatus != nil && *signal.HTTPStatus == http.StatusUnauthorized:
+		return DisabledReasonInvalidKey, true
+	case matchAny(values, invalidKeyCodes):
+		return DisabledReasonInvalidKey, true
+	}
+	return "", false
+}
+
+// IsPermanentlyDisabled 判断健康记录是否已因永久失败被自动禁用
+func IsPermanentlyDisabled(h *types.Health) bool {
+	return h != nil && h.DisabledReason != ""
+}
+
+// classifyHealth 根据健康记录中的最近错误判断密钥是否应永久禁用
+//
+// 仅对密钥生效：平台与模型的 401 通常由密钥引起，不应连带禁用。
+func classifyHealth(h *types.Health) (string, bool) {
+	if h.ResourceType != types.ResourceTypeAPIKey {
+		return "", false
+	}
+	return ClassifyPermanentFailure(FailureSignal{
+		HTTPStatus: h.LastHTTPStatus,
+		ErrorCode:  h.LastStructuredErrorCode,
+	})
+}
+
+// markPermanentlyDisabled 将健康记录标记为永久禁用
+//
+// 与手动禁用一致不设置下次可用时间，直至管理员通过启用操作清除记录。
+func markPermanentlyDisabled(next *types.Health, reason string, now time.Time) {
+	next.Status = types.HealthStatusUnavailable
+	next.NextAvailableAt = nil
+	next.RetryCount = 0
+	next.BackoffDuration = 0
+	next.HalfOpenSuccessCount = 0
+	next.DisabledReason = reason
+	next.DisabledAt = &now
+}
+
+// ObserveRequestLog 根据请求日志更新密钥-模型组合的健康状态，并按失败请求的上游错误类型与错误码永久禁用密钥
+//
+// portal 写入的健康记录仅包含 HTTP 状态码与网关错误码，额度耗尽等需依赖请求日志中的上游错误信息识别。
+func (s *Storage) ObserveRequestLog(log *types.RequestLog) {
+	if log == nil || log.APIKeyID == 0 {
+		return
+	}
+	s.observeKeyModel(log)
+	if log.Success {
+		return
+	}
+
+	reason, ok := ClassifyPermanentFailure(FailureSignal{
+		HTTPStatus: log.HTTPStatus,
+		ErrorType:  derefString(log.UpstreamErrorType),
+		ErrorCode:  derefString(log.UpstreamErrorCode),
+	})
+	if !ok {
+		s.observeRateLimitMessage(log)
+		return
+	}
+
+	current, err := s.Get(types.ResourceTypeAPIKey, log.APIKeyID)
+	if err != nil || IsPermanentlyDisabled(current) {
+		return
+	}
+
+	now := time.Now()
+	next := types.Health{
+		ResourceType: types.ResourceTypeAPIKey,
+		ResourceID:   log.APIKeyID,
+		CreatedAt:    now,
+	}
+	if current != nil {
+		next = *current
+	}
+	next.LastCheckAt = now
+	next.UpdatedAt = now
+	if message := firstNonEmpty(derefString(log.UpstreamErrorMessage), derefString(log.ErrorMsg)); message != "" {
+		next.LastError = message
+		next.LastErrorMessage = message
+	}
+	if log.HTTPStatus != nil {
+		next.LastHTTPStatus = log.HTTPStatus
+		next.LastErrorCode = *log.HTTPStatus
+	}
+	if code := derefString(log.UpstreamErrorCode); code != "" {
+		next.LastStructuredErrorCode = code
+	}
+	markPermanentlyDisabled(&next, reason, now)
+
+	if err := s.Set(&next); err != nil {
+		s.logger.Error("永久禁用密钥失败", "key_id", log.APIKeyID, "reason", reason, "error", err)
+		return
+	}
+	s.logger.Warn("密钥已因永久失败被自动禁用", "key_id", log.APIKeyID, "reason", reason)
+}
+
+// notifyKeyDisabled 通知观察者密钥已被永久禁用
+func (s *Storage) notifyKeyDisabled(h *types.Health) {
+	if h.ResourceType != types.ResourceTypeAPIKey {
+		return
+	}
+
+	s.observerMu.RLock()
+	observers := s.disabledObservers
+	s.observerMu.RUnlock()
+
+	event := KeyDisabledEvent{
+		KeyID:  h.ResourceID,
+		Reason: h.DisabledReason,
+		Health: h,
+		At:     time.Now(),
+	}
+	if h.DisabledAt != nil {
+		event.At = *h.DisabledAt
+	}
+	for _, observer := range observers {
+		observer.ObserveKeyDisabled(event)
+	}
+}
+
+// matchAny 判断任一取值是否命中集合
+func matchAny(values []string, set map[string]struct{}) bool {
+	for _, value := range values {
+		if _, ok := set[value]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// derefString 返回字符串指针的值，空指针返回空字符串
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// disabledRecorder 记录收到的密钥永久禁用事件
+type disabledRecorder struct {
+	events []KeyDisabledEvent
+}
+
+func (r *disabledRecorder) ObserveKeyDisabled(event KeyDisabledEvent) {
+	r.events = append(r.events, event)
+}
+
+func strPtr(v string) *string { return &v }
+
+func TestClassifyPermanentFailure_区分永久失败与可恢复失败(t *testing.T) {
+	cases := []struct {
+		name   string
+		signal FailureSignal
+		reason string
+		ok     bool
+	}{
+		{"401 视为密钥吊销", FailureSignal{HTTPStatus: intPtr(http.StatusUnauthorized)}, DisabledReasonInvalidKey, true},
+		{"429 额度耗尽错误码", FailureSignal{HTTPStatus: intPtr(http.StatusTooManyRequests), ErrorCode: "insufficient_quota"}, DisabledReasonQuotaExhausted, true},
+		{"402 视为额度耗尽", FailureSignal{HTTPStatus: intPtr(http.StatusPaymentRequired)}, DisabledReasonQuotaExhausted, true},
+		{"账户停用", FailureSignal{HTTPStatus: intPtr(http.StatusForbidden), ErrorType: "account_deactivated"}, DisabledReasonAccountDeactivated, true},
+		{"限流可恢复", FailureSignal{HTTPStatus: intPtr(http.StatusTooManyRequests), ErrorCode: "rate_limit_exceeded"}, "", false},
+		{"403 可恢复", FailureSignal{HTTPStatus: intPtr(http.StatusForbidden)}, "", false},
+		{"无响应可恢复", FailureSignal{}, "", false},
+	}
+
+	for _, c := range cases {
+		reason, ok := ClassifyPermanentFailure(c.signal)
+		if reason != c.reason || ok != c.ok {
+			t.Fatalf("%s: 得到 (%q, %v)，期望 (%q, %v)", c.name, reason, ok, c.reason, c.ok)
+		}
+	}
+}
+
+func TestObserveRequestLog_额度耗尽永久禁用且不被请求结果恢复(t *testing.T) {
+	svc, _ := newTestService(t)
+	recorder := &disabledRecorder{}
+	svc.storage.AddKeyDisabledObserver(recorder)
+
+	// 限流错误仍走退避，不触发永久禁用
+	svc.storage.ObserveRequestLog(&types.RequestLog{APIKeyID: 1, HTTPStatus: intPtr(http.StatusTooManyRequests), UpstreamErrorCode: strPtr("rate_limit_exceeded")})
+	if h, _ := svc.storage.Get(types.ResourceTypeAPIKey, 1); h != nil {
+		t.Fatalf("可恢复失败不应写入健康记录: %+v", h)
+	}
+
+	log := &types.RequestLog{
+		APIKeyID:             1,
+		HTTPStatus:           intPtr(http.StatusTooManyRequests),
+		UpstreamErrorType:    strPtr("insufficient_quota"),
+		UpstreamErrorCode:    strPtr("insufficient_quota"),
+		UpstreamErrorMessage: strPtr("You exceeded your current quota"),
+	}
+	svc.storage.ObserveRequestLog(log)
+	svc.storage.ObserveRequestLog(log)
+
+	h, _ := svc.storage.Get(types.ResourceTypeAPIKey, 1)
+	if h == nil || h.Status != types.HealthStatusUnavailable || h.NextAvailableAt != nil || h.DisabledReason != DisabledReasonQuotaExhausted {
+		t.Fatalf("额度耗尽应永久禁用密钥: %+v", h)
+	}
+	if len(recorder.events) != 1 || recorder.events[0].KeyID != 1 || recorder.events[0].Reason != DisabledReasonQuotaExhausted {
+		t.Fatalf("应仅通知一次禁用事件: %+v", recorder.events)
+	}
+
+	// 禁用前发出的请求随后成功，portal 写入不应恢复密钥
+	success := *h
+	success.SuccessCount++
+	success.Status = types.HealthStatusAvailable
+	success.DisabledReason = ""
+	svc.storage.ApplyPolicy(h, &success)
+	if success.Status != types.HealthStatusUnavailable || success.DisabledReason != DisabledReasonQuotaExhausted {
+		t.Fatalf("永久禁用不应被请求结果恢复: %+v", success)
+	}
+
+	// 管理员启用后清除禁用记录
+	if err := svc.EnableHealth(types.ResourceTypeAPIKey, 1); err != nil {
+		t.Fatalf("启用密钥失败: %v", err)
+	}
+	if h, _ := svc.storage.Get(types.ResourceTypeAPIKey, 1); h != nil {
+		t.Fatalf("启用后应清除健康记录: %+v", h)
+	}
+}
+
+func TestGetIssues_按异常类别与禁用原因过滤(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	platform := types.Platform{Name: "p"}
+	if err := db.Create(&platform).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+	keys := []types.APIKey{{PlatformID: platform.ID, Value: "k1"}, {PlatformID: platform.ID, Value: "k2"}, {PlatformID: platform.ID, Value: "k3"}}
+	if err := db.Create(&keys).Error; err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+
+	// 401 永久禁用、手动禁用、退避达到上限三类异常
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, keys[0].ID, failure(http.StatusUnauthorized)); err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+	if err := svc.DisableHealth(types.ResourceTypeAPIKey, keys[1].ID); err != nil {
+		t.Fatalf("禁用密钥失败: %v", err)
+	}
+	next := time.Now().Add(time.Hour)
+	if err := svc.storage.Set(&types.Health{
+		ResourceType:    types.ResourceTypeAPIKey,
+		ResourceID:      keys[2].ID,
+		Status:          types.HealthStatusUnavailable,
+		NextAvailableAt: &next,
+		LastCheckAt:     time.Now(),
+	}); err != nil {
+		t.Fatalf("写入健康状态失败: %v", err)
+	}
+
+	all, err := svc.GetIssues(ctx, IssuesOptions{})
+	if err != nil || len(all.Items) != 3 {
+		t.Fatalf("应返回全部 3 个异常资源: %+v, %v", all, err)
+	}
+
+	permanent, err := svc.GetIssues(ctx, IssuesOptions{Kind: IssueKindPermanent, Reason: DisabledReasonInvalidKey})
+	if err != nil {
+		t.Fatalf("查询永久禁用资源失败: %v", err)
+	}
+	if len(permanent.Items) != 1 || permanent.Items[0].ResourceID != keys[0].ID || permanent.Items[0].DisabledAt == nil {
+		t.Fatalf("永久禁用过滤结果不符: %+v", permanent.Items)
+	}
+
+	manual, _ := svc.GetIssues(ctx, IssuesOptions{Kind: IssueKindManual})
+	if len(manual.Items) != 1 || manual.Items[0].ResourceID != keys[1].ID {
+		t.Fatalf("手动禁用过滤结果不符: %+v", manual.Items)
+	}
+
+	if _, err := svc.GetIssues(ctx, IssuesOptions{Kind: "unknown"}); err == nil {
+		t.Fatalf("不支持的异常类别应返回错误")
+	}
+}
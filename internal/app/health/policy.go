@@ -0,0 +1,361 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/pinai/database"
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+// 默认策略参数，与 portal 库默认退避策略保持一致
+const (
+	defaultBackoffInitial    = 30 * time.Second
+	defaultBackoffMax        = 24 * time.Hour
+	defaultBackoffMultiplier = 2.0
+)
+
+// knownErrorClasses 为可配置的错误类别
+var knownErrorClasses = map[string]struct{}{
+	types.HealthErrorClassNetwork:   {},
+	types.HealthErrorClassAuth:      {},
+	types.HealthErrorClassRateLimit: {},
+	types.HealthErrorClassClient:    {},
+	types.HealthErrorClassServer:    {},
+}
+
+// Policy 定义生效中的退避与熔断策略
+type Policy struct {
+	BaseBackoff          time.Duration
+	MaxBackoff           time.Duration
+	Multiplier           float64
+	WarningThreshold     int
+	UnavailableThreshold int
+	HalfOpenProbes       int
+	ErrorClasses         []string
+}
+
+// DefaultPolicy 返回默认策略：首次失败即开始指数退避，退避达到上限时标记不可用，
+// 退避结束后一次成功即恢复，全部错误类别计入
+func DefaultPolicy() Policy {
+	return Policy{
+		BaseBackoff:      defaultBackoffInitial,
+		MaxBackoff:       defaultBackoffMax,
+		Multiplier:       defaultBackoffMultiplier,
+		WarningThreshold: 1,
+		HalfOpenProbes:   1,
+	}
+}
+
+// policyFromRecord 将策略记录转换为生效策略
+func policyFromRecord(record *types.HealthPolicy) Policy {
+	return Policy{
+		BaseBackoff:          time.Duration(record.BaseBackoffSeconds) * time.Second,
+		MaxBackoff:           time.Duration(record.MaxBackoffSeconds) * time.Second,
+		Multiplier:           record.Multiplier,
+		WarningThreshold:     record.WarningThreshold,
+		UnavailableThreshold: record.UnavailableThreshold,
+		HalfOpenProbes:       record.HalfOpenProbes,
+		ErrorClasses:         record.ErrorClasses,
+	}
+}
+
+// record 将生效策略转换为策略记录
+func (p Policy) record(platformID uint) *types.HealthPolicy {
+	classes := p.ErrorClasses
+	if classes == nil {
+		classes = []string{}
+	}
+	return &types.HealthPolicy{
+		PlatformID:           platformID,
+		BaseBackoffSeconds:   int64(p.BaseBackoff / time.Second),
+		MaxBackoffSeconds:    int64(p.MaxBackoff / time.Second),
+		Multiplier:           p.Multiplier,
+		WarningThreshold:     p.WarningThreshold,
+		UnavailableThreshold: p.UnavailableThreshold,
+		HalfOpenProbes:       p.HalfOpenProbes,
+		ErrorClasses:         classes,
+	}
+}
+
+// counts 判断错误类别是否计入失败
+func (p Policy) counts(class string) bool {
+	if len(p.ErrorClasses) == 0 {
+		return true
+	}
+	for _, c := range p.ErrorClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// errorClass 根据健康记录中的最后错误推断错误类别
+func errorClass(h *types.Health) string {
+	if h.LastHTTPStatus == nil {
+		return types.HealthErrorClassNetwork
+	}
+	switch status := *h.LastHTTPStatus; {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return types.HealthErrorClassAuth
+	case status == http.StatusTooManyRequests:
+		return types.HealthErrorClassRateLimit
+	case status >= http.StatusInternalServerError:
+		return types.HealthErrorClassServer
+	default:
+		return types.HealthErrorClassClient
+	}
+}
+
+// outcomeKind 为一次健康记录写入所对应的请求结果
+type outcomeKind int
+
+const (
+	outcomeNone        outcomeKind = iota // 非请求结果（如更新最近尝试时间、手动启停）
+	outcomeSuccess                        // 请求成功
+	outcomeFailure                        // 计入错误计数的失败
+	outcomeRecoverable                    // 不计入错误计数的可恢复失败
+)
+
+// detectOutcome 比较写入前后的记录推断请求结果
+//
+// portal 库成功时累加 SuccessCount，失败时累加 ErrorCount，可恢复失败仅更新错误详情并标记警告。
+func detectOutcome(previous, next *types.Health) outcomeKind {
+	prevStatus := types.HealthStatusUnknown
+	var prevSuccess, prevError int
+	if previous != nil {
+		prevStatus = previous.Status
+		prevSuccess, prevError = previous.SuccessCount, previous.ErrorCount
+	}
+
+	switch {
+	case next.SuccessCount > prevSuccess:
+		return outcomeSuccess
+	case next.ErrorCount > prevError:
+		return outcomeFailure
+	case next.Status == types.HealthStatusWarning && next.LastErrorMessage != "" &&
+		prevStatus != types.HealthStatusWarning && prevStatus != types.HealthStatusUnavailable:
+		return outcomeRecoverable
+	default:
+		return outcomeNone
+	}
+}
+
+// apply 按策略修正一次请求结果写入的状态、退避与半开计数
+func (p Policy) apply(previous, next *types.Health, kind outcomeKind, now time.Time) {
+	var prev types.Health
+	if previous != nil {
+		prev = *previous
+	} else {
+		prev.Status = types.HealthStatusUnknown
+	}
+	next.HalfOpenSuccessCount = prev.HalfOpenSuccessCount
+
+	switch kind {
+	case outcomeSuccess:
+		inBackoff := prev.RetryCount > 0 &&
+			(prev.Status == types.HealthStatusWarning || prev.Status == types.HealthStatusUnavailable)
+		if inBackoff && p.HalfOpenProbes > 1 {
+			next.HalfOpenSuccessCount++
+			if next.HalfOpenSuccessCount < p.HalfOpenProbes {
+				// 半开状态：保持警告且退避已到期，继续放行请求直到连续成功次数达标
+				next.Status = types.HealthStatusWarning
+				next.RetryCount = prev.RetryCount
+				next.BackoffDuration = prev.BackoffDuration
+				next.NextAvailableAt = prev.NextAvailableAt
+				if next.NextAvailableAt == nil || next.NextAvailableAt.After(now) {
+					next.NextAvailableAt = &now
+				}
+				return
+			}
+		}
+		next.HalfOpenSuccessCount = 0
+		next.RetryCount = 0
+		next.NextAvailableAt = nil
+		next.BackoffDuration = 0
+		next.Status = types.HealthStatusAvailable
+
+	case outcomeFailure:
+		next.HalfOpenSuccessCount = 0
+		if !p.counts(errorClass(next)) {
+			// 不计入的错误类别仅记录错误详情，不改变健康状态与错误计数
+			next.ErrorCount = prev.ErrorCount
+			restoreBackoff(next, &prev)
+			return
+		}
+		if next.ErrorCount < p.WarningThreshold {
+			restoreBackoff(next, &prev)
+			return
+		}
+		p.backoff(next, prev.RetryCount+1, now)
+
+	case outcomeRecoverable:
+		if !p.counts(errorClass(next)) {
+			restoreBackoff(next, &prev)
+		}
+	}
+}
+
+// backoff 按指数退避更新重试次数、退避时长与状态
+func (p Policy) backoff(h *types.Health, retryCount int, now time.Time) {
+	h.RetryCount = retryCount
+
+	delay := time.Duration(float64(p.BaseBackoff) * math.Pow(p.Multiplier, float64(retryCount-1)))
+	if delay <= 0 || delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	h.BackoffDuration = int64(delay.Seconds())
+	nextAvailable := now.Add(delay)
+	h.NextAvailableAt = &nextAvailable
+
+	if delay >= p.MaxBackoff || (p.UnavailableThreshold > 0 && h.ErrorCount >= p.UnavailableThreshold) {
+		h.Status = types.HealthStatusUnavailable
+	} else {
+		h.Status = types.HealthStatusWarning
+	}
+}
+
+// restoreBackoff 将状态与退避字段恢复为写入前的值
+func restoreBackoff(next, prev *types.Health) {
+	next.Status = prev.Status
+	next.RetryCount = prev.RetryCount
+	next.NextAvailableAt = prev.NextAvailableAt
+	next.BackoffDuration = prev.BackoffDuration
+}
+
+// policyStore 缓存全局与平台级策略，并解析资源所属平台
+type policyStore struct {
+	mu        sync.RWMutex
+	global    Policy
+	platforms map[uint]Policy
+
+	resourcePlatforms sync.Map // key 格式："resourceType:resourceID"，值为平台 ID
+	logger            *slog.Logger
+}
+
+// newPolicyStore 创建使用默认全局策略的策略缓存
+func newPolicyStore(logger *slog.Logger) *policyStore {
+	return &policyStore{
+		global:    DefaultPolicy(),
+		platforms: make(map[uint]Policy),
+		logger:    logger,
+	}
+}
+
+// policyDB 返回不带 gorm-gen 作用域的数据库会话
+func policyDB(ctx context.Context) *gorm.DB {
+	return database.CleanSession(ctx, query.Q.Health.WithContext(ctx).UnderlyingDB()).
+		Model(&types.HealthPolicy{})
+}
+
+// load 从数据库加载全部策略
+func (p *policyStore) load(ctx context.Context) error {
+	var records []types.HealthPolicy
+	if err := policyDB(ctx).Find(&records).Error; err != nil {
+		return fmt.Errorf("查询健康策略失败：%w", err)
+	}
+
+	global := DefaultPolicy()
+	platforms := make(map[uint]Policy, len(records))
+	for i := range records {
+		if records[i].PlatformID == 0 {
+			global = policyFromRecord(&records[i])
+			continue
+		}
+		platforms[records[i].PlatformID] = policyFromRecord(&records[i])
+	}
+
+	p.mu.Lock()
+	p.global = global
+	p.platforms = platforms
+	p.mu.Unlock()
+
+	p.logger.Debug("健康策略加载完成", "platform_overrides", len(platforms))
+	return nil
+}
+
+// resolve 返回资源生效的策略：平台级覆盖优先，否则使用全局策略
+func (p *policyStore) resolve(resourceType types.ResourceType, resourceID uint) Policy {
+	p.mu.RLock()
+	global := p.global
+	hasOverrides := len(p.platforms) > 0
+	p.mu.RUnlock()
+
+	if !hasOverrides {
+		return global
+	}
+
+	platformID, ok := p.platformOf(resourceType, resourceID)
+	if !ok {
+		return global
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if policy, exists := p.platforms[platformID]; exists {
+		return policy
+	}
+	return global
+}
+
+// platformOf 解析资源所属平台，密钥与模型的平台关系会被缓存，密钥-模型组合按密钥解析
+func (p *policyStore) platformOf(resourceType types.ResourceType, resourceID uint) (uint, bool) {
+	if resourceType == types.ResourceTypePlatform {
+		return resourceID, true
+	}
+	if resourceType == types.ResourceTypeKeyModel {
+		// 密钥-模型组合使用密钥所属平台的策略
+		keyID, _ := types.SplitKeyModelResourceID(resourceID)
+		return p.platformOf(types.ResourceTypeAPIKey, keyID)
+	}
+
+	key := fmt.Sprintf("%d:%d", resourceType, resourceID)
+	if value, ok := p.resourcePlatforms.Load(key); ok {
+		return value.(uint), true
+	}
+
+	q := query.Q
+	var platformID uint
+	switch resourceType {
+	case types.ResourceTypeAPIKey:
+		key, err := q.APIKey.Select(q.APIKey.PlatformID).Where(q.APIKey.ID.Eq(resourceID)).First()
+		if err != nil {
+			p.logger.Debug("解析密钥所属平台失败", "resource_id", resourceID, "error", err)
+			return 0, false
+		}
+		platformID = key.PlatformID
+	case types.ResourceTypeModel:
+		model, err := q.Model.Select(q.Model.PlatformID).Where(q.Model.ID.Eq(resourceID)).First()
+		if err != nil {
+			p.logger.Debug("解析模型所属平台失败", "resource_id", resourceID, "error", err)
+			return 0, false
+		}
+		platformID = model.PlatformID
+	default:
+		return 0, false
+	}
+
+	p.resourcePlatforms.Store(key, platformID)
+	return platformID, true
+}
+
+// snapshot 返回全局策略与平台级覆盖的副本
+func (p *policyStore) snapshot() (Policy, map[uint]Policy) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	platforms := make(map[uint]Policy, len(p.platforms))
+	for id, policy := range p.platforms {
+		platforms[id] = policy
+	}
+	return p.global, platforms
+}
@@ -0,0 +1,236 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) (*service, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&types.Platform{}, &types.APIKey{}, &types.Model{}, &types.Health{}, &types.HealthPolicy{}, &types.HealthTransition{}); err != nil {
+		t.Fatalf("迁移测试表失败: %v", err)
+	}
+	query.SetDefault(db)
+
+	storage, err := NewStorage(context.Background(), slog.Default())
+	if err != nil {
+		t.Fatalf("创建健康存储失败: %v", err)
+	}
+	svc, err := NewService(storage, slog.Default())
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+	return svc.(*service), db
+}
+
+func intPtr(v int) *int { return &v }
+
+func failure(status int) Outcome {
+	return Outcome{Message: "upstream error", HTTPStatus: &status}
+}
+
+func TestRecordOutcome_默认策略首次失败即退避(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	h, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, failure(http.StatusInternalServerError))
+	if err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+	if h.Status != types.HealthStatusWarning || h.RetryCount != 1 || h.BackoffDuration != 30 {
+		t.Fatalf("默认策略应进入 30 秒退避: %+v", h)
+	}
+}
+
+func TestRecordOutcome_按阈值进入警告与不可用(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.UpdateGlobalPolicy(ctx, PolicyRequest{
+		WarningThreshold:     intPtr(2),
+		UnavailableThreshold: intPtr(3),
+	}); err != nil {
+		t.Fatalf("更新全局策略失败: %v", err)
+	}
+
+	h, _ := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, failure(http.StatusInternalServerError))
+	if h.Status != types.HealthStatusUnknown || h.NextAvailableAt != nil || h.ErrorCount != 1 {
+		t.Fatalf("未达到警告阈值时不应退避: %+v", h)
+	}
+	h, _ = svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, failure(http.StatusInternalServerError))
+	if h.Status != types.HealthStatusWarning || h.RetryCount != 1 {
+		t.Fatalf("达到警告阈值时应进入退避: %+v", h)
+	}
+	h, _ = svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, failure(http.StatusInternalServerError))
+	if h.Status != types.HealthStatusUnavailable || h.RetryCount != 2 || h.BackoffDuration != 60 {
+		t.Fatalf("达到不可用阈值时应标记不可用: %+v", h)
+	}
+}
+
+func TestRecordOutcome_半开状态需连续成功才恢复(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	if _, err := svc.UpdateGlobalPolicy(context.Background(), PolicyRequest{HalfOpenProbes: intPtr(2)}); err != nil {
+		t.Fatalf("更新全局策略失败: %v", err)
+	}
+
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeModel, 1, failure(http.StatusBadGateway)); err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+
+	h, _ := svc.storage.RecordOutcome(types.ResourceTypeModel, 1, Outcome{Success: true})
+	if h.Status != types.HealthStatusWarning || h.HalfOpenSuccessCount != 1 || h.RetryCount != 1 {
+		t.Fatalf("首次成功应保持半开状态: %+v", h)
+	}
+	if h.NextAvailableAt == nil || h.NextAvailableAt.After(time.Now()) {
+		t.Fatalf("半开状态应继续放行请求: %+v", h.NextAvailableAt)
+	}
+
+	h, _ = svc.storage.RecordOutcome(types.ResourceTypeModel, 1, Outcome{Success: true})
+	if h.Status != types.HealthStatusAvailable || h.HalfOpenSuccessCount != 0 || h.RetryCount != 0 {
+		t.Fatalf("连续成功达标后应恢复可用: %+v", h)
+	}
+}
+
+func TestApplyPolicy_平台覆盖与错误类别过滤(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	platform := types.Platform{Name: "p"}
+	if err := db.Create(&platform).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+	key := types.APIKey{PlatformID: platform.ID, Value: "k"}
+	if err := db.Create(&key).Error; err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+
+	if _, err := svc.UpdatePlatformPolicy(ctx, platform.ID, PolicyRequest{
+		ErrorClasses: []string{types.HealthErrorClassServer},
+	}); err != nil {
+		t.Fatalf("设置平台策略失败: %v", err)
+	}
+
+	// 模拟 portal 库写入 400 失败：错误计数累加并应用了自身的退避
+	badRequest := http.StatusBadRequest
+	next := time.Now().Add(30 * time.Second)
+	incoming := &types.Health{
+		ResourceType:     types.ResourceTypeAPIKey,
+		ResourceID:       key.ID,
+		Status:           types.HealthStatusWarning,
+		RetryCount:       1,
+		NextAvailableAt:  &next,
+		BackoffDuration:  30,
+		ErrorCount:       1,
+		LastErrorMessage: "bad request",
+		LastHTTPStatus:   &badRequest,
+	}
+	svc.storage.ApplyPolicy(nil, incoming)
+	if incoming.Status != types.HealthStatusUnknown || incoming.ErrorCount != 0 || incoming.NextAvailableAt != nil {
+		t.Fatalf("未计入的错误类别不应改变健康状态: %+v", incoming)
+	}
+	if incoming.LastErrorMessage != "bad request" {
+		t.Fatalf("应保留错误详情: %+v", incoming)
+	}
+
+	// 其他平台的资源仍使用全局策略
+	h, _ := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, key.ID+100, failure(http.StatusForbidden))
+	if h.Status != types.HealthStatusWarning {
+		t.Fatalf("全局策略应计入全部错误类别: %+v", h)
+	}
+
+	if err := svc.DeletePlatformPolicy(ctx, platform.ID); err != nil {
+		t.Fatalf("删除平台策略失败: %v", err)
+	}
+	if err := svc.DeletePlatformPolicy(ctx, platform.ID); !errors.Is(err, ErrPolicyNotFound) {
+		t.Fatalf("重复删除应返回未找到: %v", err)
+	}
+}
+
+func TestUpdatePlatformPolicy_校验参数(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	platform := types.Platform{Name: "p"}
+	if err := db.Create(&platform).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+
+	if _, err := svc.UpdatePlatformPolicy(ctx, platform.ID+1, PolicyRequest{}); !errors.Is(err, ErrPolicyPlatformAbsent) {
+		t.Fatalf("平台不存在时应返回错误: %v", err)
+	}
+	if _, err := svc.UpdatePlatformPolicy(ctx, platform.ID, PolicyRequest{ErrorClasses: []string{"unknown"}}); !errors.Is(err, ErrInvalidPolicy) {
+		t.Fatalf("未知错误类别应返回参数错误: %v", err)
+	}
+	if _, err := svc.UpdatePlatformPolicy(ctx, platform.ID, PolicyRequest{WarningThreshold: intPtr(3), UnavailableThreshold: intPtr(2)}); !errors.Is(err, ErrInvalidPolicy) {
+		t.Fatalf("不可用阈值小于警告阈值应返回参数错误: %v", err)
+	}
+
+	list, err := svc.ListPolicies(ctx)
+	if err != nil {
+		t.Fatalf("获取策略失败: %v", err)
+	}
+	if list.Global.BaseBackoffSeconds != 30 || len(list.Platforms) != 0 {
+		t.Fatalf("校验失败不应写入策略: %+v", list)
+	}
+}
+
+// recordingAuditRecorder 记录写入的审计事件
+type recordingAuditRecorder struct {
+	entries []audit.Entry
+}
+
+func (r *recordingAuditRecorder) Record(_ context.Context, entry audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func TestPlatformPolicy_变更写入审计(t *testing.T) {
+	svc, db := newTestService(t)
+	recorder := &recordingAuditRecorder{}
+	svc.auditRecorder = recorder
+	ctx := context.Background()
+
+	platform := types.Platform{Name: "p"}
+	if err := db.Create(&platform).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+
+	if _, err := svc.UpdatePlatformPolicy(ctx, platform.ID, PolicyRequest{WarningThreshold: intPtr(2)}); err != nil {
+		t.Fatalf("设置平台策略失败: %v", err)
+	}
+	if _, err := svc.UpdatePlatformPolicy(ctx, platform.ID, PolicyRequest{WarningThreshold: intPtr(3)}); err != nil {
+		t.Fatalf("更新平台策略失败: %v", err)
+	}
+	if err := svc.DeletePlatformPolicy(ctx, platform.ID); err != nil {
+		t.Fatalf("删除平台策略失败: %v", err)
+	}
+
+	if len(recorder.entries) != 3 {
+		t.Fatalf("审计记录数 = %d, 期望 3", len(recorder.entries))
+	}
+	created, updated, deleted := recorder.entries[0], recorder.entries[1], recorder.entries[2]
+	if created.Action != "health_policy.update" || created.Before.(*types.HealthPolicy) != nil || created.After == nil {
+		t.Fatalf("首次设置的审计记录不符: %+v", created)
+	}
+	if before := updated.Before.(*types.HealthPolicy); before == nil || before.WarningThreshold != 2 {
+		t.Fatalf("更新的审计记录应包含变更前策略: %+v", updated)
+	}
+	if deleted.Action != "health_policy.delete" || deleted.Result != "success" || deleted.Before.(*types.HealthPolicy).WarningThreshold != 3 {
+		t.Fatalf("删除的审计记录不符: %+v", deleted)
+	}
+}
@@ -0,0 +1,322 @@
+package health
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// lowQuotaRatio 剩余额度低于上限的该比例时视为即将耗尽
+const lowQuotaRatio = 0.05
+
+// RateLimitInfo 为上游给出的限流信息
+//
+// 主动探测由 ParseRateLimitHeaders 从响应头解析，支持 Retry-After、retry-after-ms、
+// OpenAI 风格的 x-ratelimit-* 以及 Anthropic 风格的 anthropic-ratelimit-* 响应头；
+// 网关请求拿不到上游响应头，仅由 ParseRateLimitMessage 从限流错误信息中解析。
+type RateLimitInfo struct {
+	RetryAt           *time.Time `json:"retry_at,omitempty"`           // 上游要求的最早重试时间
+	RemainingRequests *int64     `json:"remaining_requests,omitempty"` // 剩余请求数
+	LimitRequests     *int64     `json:"limit_requests,omitempty"`     // 请求数上限
+	RequestsResetAt   *time.Time `json:"requests_reset_at,omitempty"`  // 请求数额度重置时间
+	RemainingTokens   *int64     `json:"remaining_tokens,omitempty"`   // 剩余 token 数
+	LimitTokens       *int64     `json:"limit_tokens,omitempty"`       // token 数上限
+	TokensResetAt     *time.Time `json:"tokens_reset_at,omitempty"`    // token 额度重置时间
+	UpdatedAt         time.Time  `json:"updated_at"`                   // 记录时间
+}
+
+// ParseRateLimitHeaders 解析上游响应头中的限流信息
+func ParseRateLimitHeaders(header http.Header, now time.Time) RateLimitInfo {
+	info := RateLimitInfo{UpdatedAt: now}
+
+	if value := strings.TrimSpace(header.Get("Retry-After-Ms")); value != "" {
+		if ms, err := strconv.ParseFloat(value, 64); err == nil && ms >= 0 {
+			info.RetryAt = timePtr(now.Add(time.Duration(ms * float64(time.Millisecond))))
+		}
+	}
+	if info.RetryAt == nil {
+		info.RetryAt = parseRetryAfter(header.Get("Retry-After"), now)
+	}
+
+	info.RemainingRequests = parseHeaderInt(header, "X-Ratelimit-Remaining-Requests", "Anthropic-Ratelimit-Requests-Remaining")
+	info.LimitRequests = parseHeaderInt(header, "X-Ratelimit-Limit-Requests", "Anthropic-Ratelimit-Requests-Limit")
+	info.RequestsResetAt = parseHeaderReset(header, now, "X-Ratelimit-Reset-Requests", "Anthropic-Ratelimit-Requests-Reset")
+	info.RemainingTokens = parseHeaderInt(header, "X-Ratelimit-Remaining-Tokens", "Anthropic-Ratelimit-Tokens-Remaining")
+	info.LimitTokens = parseHeaderInt(header, "X-Ratelimit-Limit-Tokens", "Anthropic-Ratelimit-Tokens-Limit")
+	info.TokensResetAt = parseHeaderReset(header, now, "X-Ratelimit-Reset-Tokens", "Anthropic-Ratelimit-Tokens-Reset")
+	return info
+}
+
+// Empty 判断是否未解析到任何限流信息
+func (i RateLimitInfo) Empty() bool {
+	return i.RetryAt == nil && !i.hasQuota()
+}
+
+// hasQuota 判断是否包含剩余额度信息
+func (i RateLimitInfo) hasQuota() bool {
+	return i.RemainingRequests != nil || i.RemainingTokens != nil
+}
+
+// WaitUntil 返回限流失败后应等待到的时间
+//
+// 优先使用 Retry-After；缺失时取已耗尽维度的额度重置时间，无法判断时返回空。
+func (i RateLimitInfo) WaitUntil() *time.Time {
+	if i.RetryAt != nil {
+		return i.RetryAt
+	}
+
+	var until *time.Time
+	for _, dim := range []struct {
+		remaining *int64
+		resetAt   *time.Time
+	}{
+		{i.RemainingRequests, i.RequestsResetAt},
+		{i.RemainingTokens, i.TokensResetAt},
+	} {
+		if dim.remaining != nil && *dim.remaining <= 0 && dim.resetAt != nil {
+			if until == nil || dim.resetAt.After(*until) {
+				until = dim.resetAt
+			}
+		}
+	}
+	return until
+}
+
+// nearlyExhausted 判断额度是否即将耗尽，已过重置时间的维度视为已恢复
+func (i RateLimitInfo) nearlyExhausted(now time.Time) bool {
+	low := func(remaining, limit *int64, resetAt *time.Time) bool {
+		if remaining == nil || (resetAt != nil && !resetAt.After(now)) {
+			return false
+		}
+		if limit != nil && *limit > 0 {
+			return float64(*remaining) < float64(*limit)*lowQuotaRatio
+		}
+		return *remaining <= 0
+	}
+	return low(i.RemainingRequests, i.LimitRequests, i.RequestsResetAt) ||
+		low(i.RemainingTokens, i.LimitTokens, i.TokensResetAt)
+}
+
+// RecordRateLimit 记录密钥最近一次上报的剩余额度
+//
+// 额度来自主动探测的响应头与网关限流错误信息，仅保存在本实例内存中，不随多实例同步，重启后丢失；
+// 不含额度信息的响应不会覆盖已有记录。
+func (s *Storage) RecordRateLimit(keyID uint, info RateLimitInfo) {
+	if keyID == 0 || !info.hasQuota() {
+		return
+	}
+	s.rateLimits.Store(keyID, info)
+}
+
+// KeyRateLimit 返回密钥最近一次上报的剩余额度
+func (s *Storage) KeyRateLimit(keyID uint) (RateLimitInfo, bool) {
+	value, ok := s.rateLimits.Load(keyID)
+	if !ok {
+		return RateLimitInfo{}, false
+	}
+	return value.(RateLimitInfo), true
+}
+
+// IsKeyNearlyExhausted 判断密钥剩余额度是否即将耗尽，供路由优先选择其他密钥
+func (s *Storage) IsKeyNearlyExhausted(keyID uint) bool {
+	info, ok := s.KeyRateLimit(keyID)
+	return ok && info.nearlyExhausted(time.Now())
+}
+
+// ApplyRetryHint 按上游要求的重试时间更新限流失败的密钥退避
+//
+// 仅在策略计入限流错误时生效；手动禁用与永久禁用的密钥保持不变。
+func (s *Storage) ApplyRetryHint(resourceType types.ResourceType, resourceID uint, retryAt time.Time) error {
+	for attempt := 1; ; attempt++ {
+		err := s.applyRetryHintOnce(resourceType, resourceID, retryAt)
+		if errors.Is(err, ErrHealthConflict) && attempt < conflictRetries {
+			continue
+		}
+		return err
+	}
+}
+
+// applyRetryHintOnce 基于缓存中的记录写入一次重试时间
+func (s *Storage) applyRetryHintOnce(resourceType types.ResourceType, resourceID uint, retryAt time.Time) error {
+	current, err := s.Get(resourceType, resourceID)
+	if err != nil || current == nil || IsManuallyDisabled(current) {
+		return err
+	}
+	if !s.policies.resolve(resourceType, resourceID).counts(types.HealthErrorClassRateLimit) {
+		return nil
+	}
+
+	now := time.Now()
+	next := *current
+	if !applyRetryHint(&next, retryAt, now) {
+		return nil
+	}
+	next.UpdatedAt = now
+	return s.Set(&next)
+}
+
+// applyRetryHint 以上游给出的重试时间替换指数退避计算的下次可用时间
+func applyRetryHint(h *types.Health, retryAt time.Time, now time.Time) bool {
+	if !retryAt.After(now) {
+		return false
+	}
+	h.NextAvailableAt = &retryAt
+	h.BackoffDuration = int64(math.Ceil(retryAt.Sub(now).Seconds()))
+	if h.RetryCount == 0 {
+		h.RetryCount = 1
+	}
+	if h.Status != types.HealthStatusUnavailable {
+		h.Status = types.HealthStatusWarning
+	}
+	return true
+}
+
+// retryHintPatterns 匹配上游错误信息中的重试提示，如 "Please try again in 20s" 与 Gemini RetryInfo 的 "retryDelay": "20s"
+var retryHintPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(?:try again|retry) (?:in|after) ([0-9]+(?:\.[0-9]+)?)\s*(ms|milliseconds?|s|secs?|seconds?|m|mins?|minutes?)\b`),
+	regexp.MustCompile(`(?i)"retryDelay"\s*:\s*"([0-9]+(?:\.[0-9]+)?)(s)"`),
+}
+
+// ParseRetryHint 从上游错误信息中解析重试等待时长
+func ParseRetryHint(message string) (time.Duration, bool) {
+	for _, pattern := range retryHintPatterns {
+		match := pattern.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+
+		unit := time.Second
+		switch strings.ToLower(match[2]) {
+		case "ms", "millisecond", "milliseconds":
+			unit = time.Millisecond
+		case "m", "min", "mins", "minute", "minutes":
+			unit = time.Minute
+		}
+		return time.Duration(value * float64(unit)), true
+	}
+	return 0, false
+}
+
+// quotaHintPattern 匹配 OpenAI 限流错误信息中的额度说明，如 "on tokens per min (TPM): Limit 30000, Used 29500, Requested 800"
+var quotaHintPattern = regexp.MustCompile(`(?i)\b(requests|tokens) per [a-z]+(?: \([a-z]+\))?:\s*limit ([0-9,]+),\s*used ([0-9,]+)`)
+
+// ParseRateLimitMessage 从上游限流错误信息中解析重试时间与剩余额度
+//
+// 额度仅在同时给出重试提示时记录，并以重试时间作为重置时间，避免过期的额度信息长期影响路由。
+func ParseRateLimitMessage(message string, now time.Time) RateLimitInfo {
+	info := RateLimitInfo{UpdatedAt: now}
+	wait, ok := ParseRetryHint(message)
+	if !ok {
+		return info
+	}
+	info.RetryAt = timePtr(now.Add(wait))
+
+	match := quotaHintPattern.FindStringSubmatch(message)
+	if match == nil {
+		return info
+	}
+	limit, err := strconv.ParseInt(strings.ReplaceAll(match[2], ",", ""), 10, 64)
+	if err != nil {
+		return info
+	}
+	used, err := strconv.ParseInt(strings.ReplaceAll(match[3], ",", ""), 10, 64)
+	if err != nil {
+		return info
+	}
+	remaining := max(limit-used, 0)
+
+	if strings.EqualFold(match[1], "requests") {
+		info.RemainingRequests, info.LimitRequests, info.RequestsResetAt = &remaining, &limit, info.RetryAt
+	} else {
+		info.RemainingTokens, info.LimitTokens, info.TokensResetAt = &remaining, &limit, info.RetryAt
+	}
+	return info
+}
+
+// parseRetryAfter 解析 Retry-After，支持秒数与 HTTP 日期两种格式
+func parseRetryAfter(value string, now time.Time) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds < 0 {
+			return nil
+		}
+		return timePtr(now.Add(time.Duration(seconds * float64(time.Second))))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return &at
+	}
+	return nil
+}
+
+// parseHeaderInt 返回第一个可解析为整数的响应头
+func parseHeaderInt(header http.Header, names ...string) *int64 {
+	for _, name := range names {
+		if value, err := strconv.ParseInt(strings.TrimSpace(header.Get(name)), 10, 64); err == nil {
+			return &value
+		}
+	}
+	return nil
+}
+
+// parseHeaderReset 解析额度重置时间
+//
+// 支持 OpenAI 的时长格式（如 "6m0s"、"20ms"）、RFC 3339 时间、Unix 时间戳与秒数。
+func parseHeaderReset(header http.Header, now time.Time, names ...string) *time.Time {
+	for _, name := range names {
+		value := strings.TrimSpace(header.Get(name))
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err == nil {
+			return timePtr(now.Add(d))
+		}
+		if at, err := time.Parse(time.RFC3339, value); err == nil {
+			return &at
+		}
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+			// 超过 10 亿秒视为 Unix 时间戳
+			if seconds > 1e9 {
+				return timePtr(time.Unix(int64(seconds), 0))
+			}
+			return timePtr(now.Add(time.Duration(seconds * float64(time.Second))))
+		}
+	}
+	return nil
+}
+
+// timePtr 返回时间指针
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// observeRateLimitMessage 按网关限流失败的上游错误信息更新密钥退避与剩余额度
+//
+// 网关请求由 portal 库转发，portal 不向调用方暴露上游响应头，因此网关流量不读取 Retry-After 与 x-ratelimit-*，
+// 此处只解析错误信息文本中的提示（如 "Please try again in 20s"、"Limit 30000, Used 29500"），
+// 错误信息不含提示时按退避策略处理。
+func (s *Storage) observeRateLimitMessage(log *types.RequestLog) {
+	if log.HTTPStatus == nil || *log.HTTPStatus != http.StatusTooManyRequests {
+		return
+	}
+	info := ParseRateLimitMessage(firstNonEmpty(derefString(log.UpstreamErrorMessage), derefString(log.ErrorMsg)), time.Now())
+	s.RecordRateLimit(log.APIKeyID, info)
+	if info.RetryAt == nil {
+		return
+	}
+	if err := s.ApplyRetryHint(types.ResourceTypeAPIKey, log.APIKeyID, *info.RetryAt); err != nil {
+		s.logger.Error("按限流错误信息更新密钥退避失败", "key_id", log.APIKeyID, "error", err)
+	}
+}
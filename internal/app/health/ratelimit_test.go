@@ -0,0 +1,152 @@
+package health
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestParseRateLimitHeaders_解析重试时间与剩余额度(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("Retry-After", "20")
+	header.Set("X-Ratelimit-Remaining-Requests", "0")
+	header.Set("X-Ratelimit-Limit-Requests", "500")
+	header.Set("X-Ratelimit-Reset-Requests", "6m0s")
+	header.Set("X-Ratelimit-Remaining-Tokens", "1000")
+	header.Set("X-Ratelimit-Limit-Tokens", "100000")
+	header.Set("X-Ratelimit-Reset-Tokens", "120ms")
+
+	info := ParseRateLimitHeaders(header, now)
+	if info.RetryAt == nil || !info.RetryAt.Equal(now.Add(20*time.Second)) {
+		t.Fatalf("Retry-After 解析不符: %v", info.RetryAt)
+	}
+	if info.RemainingRequests == nil || *info.RemainingRequests != 0 || info.LimitTokens == nil || *info.LimitTokens != 100000 {
+		t.Fatalf("剩余额度解析不符: %+v", info)
+	}
+	if info.RequestsResetAt == nil || !info.RequestsResetAt.Equal(now.Add(6*time.Minute)) {
+		t.Fatalf("额度重置时间解析不符: %v", info.RequestsResetAt)
+	}
+	if !info.nearlyExhausted(now) {
+		t.Fatalf("剩余请求数为 0 时应视为即将耗尽")
+	}
+	if info.nearlyExhausted(now.Add(7 * time.Minute)) {
+		t.Fatalf("已过重置时间的额度应视为已恢复")
+	}
+
+	// 缺少 Retry-After 时使用已耗尽维度的重置时间
+	info.RetryAt = nil
+	if until := info.WaitUntil(); until == nil || !until.Equal(now.Add(6*time.Minute)) {
+		t.Fatalf("等待时间应取耗尽维度的重置时间: %v", until)
+	}
+}
+
+func TestParseRetryHint_解析错误信息中的重试提示(t *testing.T) {
+	cases := map[string]time.Duration{
+		"Rate limit reached. Please try again in 20s.":            20 * time.Second,
+		"Please try again in 1.5 seconds":                         1500 * time.Millisecond,
+		"retry after 250ms":                                       250 * time.Millisecond,
+		`{"@type": "RetryInfo", "retryDelay": "42s"}`:             42 * time.Second,
+		"You exceeded your current quota, please try again later": 0,
+	}
+	for message, want := range cases {
+		got, ok := ParseRetryHint(message)
+		if ok != (want > 0) || got != want {
+			t.Fatalf("%q 解析结果不符: got=%v ok=%v want=%v", message, got, ok, want)
+		}
+	}
+}
+
+func TestRecordOutcome_按上游重试时间替代指数退避(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	retryAt := time.Now().Add(90 * time.Second)
+	outcome := failure(http.StatusTooManyRequests)
+	outcome.RetryAt = &retryAt
+	h, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, outcome)
+	if err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+	if h.NextAvailableAt == nil || !h.NextAvailableAt.Equal(retryAt) || h.BackoffDuration != 90 {
+		t.Fatalf("应按上游重试时间退避: %+v", h)
+	}
+
+	at, ok := svc.storage.ChannelAvailableAt(0, 0, 1, time.Now())
+	if !ok || !at.Equal(retryAt) {
+		t.Fatalf("通道恢复时间应为密钥退避到期时间: %v %v", at, ok)
+	}
+}
+
+func TestObserveRequestLog_限流错误信息中的重试提示更新密钥退避(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 1, failure(http.StatusTooManyRequests)); err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+	before := time.Now()
+	svc.storage.ObserveRequestLog(&types.RequestLog{
+		APIKeyID:             1,
+		HTTPStatus:           intPtr(http.StatusTooManyRequests),
+		UpstreamErrorMessage: strPtr("Rate limit reached. Please try again in 5m."),
+	})
+
+	h, _ := svc.storage.Get(types.ResourceTypeAPIKey, 1)
+	if h.NextAvailableAt == nil || h.NextAvailableAt.Before(before.Add(5*time.Minute)) {
+		t.Fatalf("应按错误信息中的重试提示退避: %+v", h)
+	}
+}
+
+func TestIsKeyNearlyExhausted_仅含额度信息的记录生效(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	remaining, limit := int64(3), int64(100)
+	svc.storage.RecordRateLimit(1, RateLimitInfo{RemainingRequests: &remaining, LimitRequests: &limit, UpdatedAt: time.Now()})
+	if !svc.storage.IsKeyNearlyExhausted(1) {
+		t.Fatalf("剩余不足 5%% 时应视为即将耗尽")
+	}
+
+	// 不含额度信息的响应不覆盖已有记录
+	svc.storage.RecordRateLimit(1, RateLimitInfo{UpdatedAt: time.Now()})
+	if !svc.storage.IsKeyNearlyExhausted(1) {
+		t.Fatalf("空记录不应覆盖已有额度信息")
+	}
+	if svc.storage.IsKeyNearlyExhausted(2) {
+		t.Fatalf("无记录的密钥不应视为即将耗尽")
+	}
+}
+
+func TestParseRateLimitMessage_解析错误信息中的剩余额度(t *testing.T) {
+	now := time.Now()
+
+	info := ParseRateLimitMessage("Rate limit reached for gpt-4o in organization org-x on tokens per min (TPM): Limit 30,000, Used 29,500, Requested 800. Please try again in 1.6s.", now)
+	if info.RetryAt == nil || !info.RetryAt.Equal(now.Add(1600*time.Millisecond)) {
+		t.Fatalf("重试时间解析不符: %v", info.RetryAt)
+	}
+	if info.RemainingTokens == nil || *info.RemainingTokens != 500 || info.LimitTokens == nil || *info.LimitTokens != 30000 {
+		t.Fatalf("token 额度解析不符: %+v", info)
+	}
+	if info.TokensResetAt == nil || !info.TokensResetAt.Equal(*info.RetryAt) || info.RemainingRequests != nil {
+		t.Fatalf("重置时间应取重试时间: %+v", info)
+	}
+
+	// 缺少重试提示时不记录额度
+	info = ParseRateLimitMessage("on requests per day (RPD): Limit 200, Used 200, Requested 1.", now)
+	if !info.Empty() {
+		t.Fatalf("缺少重试提示时不应解析额度: %+v", info)
+	}
+}
+
+func TestObserveRequestLog_限流错误信息中的额度更新密钥剩余额度(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	svc.storage.ObserveRequestLog(&types.RequestLog{
+		APIKeyID:             1,
+		HTTPStatus:           intPtr(http.StatusTooManyRequests),
+		UpstreamErrorMessage: strPtr("Rate limit reached on requests per min (RPM): Limit 60, Used 60, Requested 1. Please try again in 1s."),
+	})
+	if !svc.storage.IsKeyNearlyExhausted(1) {
+		t.Fatalf("额度耗尽的密钥应视为即将耗尽")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
 )
 
 // Service 定义健康服务接口
@@ -17,13 +18,35 @@ type Service interface {
 	GetPlatformHealthList(ctx context.Context, page, pageSize int) (*PlatformHealthListResponse, error)
 	GetAPIKeyHealthList(ctx context.Context, page, pageSize int) (*APIKeyHealthListResponse, error)
 	GetModelHealthList(ctx context.Context, page, pageSize int) (*ModelHealthListResponse, error)
-	GetIssues(ctx context.Context) (*IssuesListResponse, error)
+	GetKeyModelHealthList(ctx context.Context, page, pageSize int) (*KeyModelHealthListResponse, error)
+	GetIssues(ctx context.Context, opts IssuesOptions) (*IssuesListResponse, error)
+	GetHistory(ctx context.Context, resourceType types.ResourceType, resourceID uint, opts HistoryOptions) (*HistoryResponse, error)
+
+	// PruneHistory 分批删除 before 之前的健康状态变化历史
+	PruneHistory(ctx context.Context, before time.Time) (*HistoryPruneResult, error)
+
+	// 健康策略
+	ListPolicies(ctx context.Context) (*PolicyListResponse, error)
+	UpdateGlobalPolicy(ctx context.Context, req PolicyRequest) (*types.HealthPolicy, error)
+	UpdatePlatformPolicy(ctx context.Context, platformID uint, req PolicyRequest) (*types.HealthPolicy, error)
+	DeletePlatformPolicy(ctx context.Context, platformID uint) error
 }
 
 // service 健康服务实现
 type service struct {
-	storage *Storage     // 健康状态存储，用于缓存和持久化
-	logger  *slog.Logger // 日志记录器
+	storage       *Storage       // 健康状态存储，用于缓存和持久化
+	logger        *slog.Logger   // 日志记录器
+	auditRecorder audit.Recorder // 健康策略变更审计记录器，为空时不记录
+}
+
+// ServiceOption 定义健康服务的可选配置函数
+type ServiceOption func(*service)
+
+// WithAuditRecorder 设置健康策略变更的审计记录器
+func WithAuditRecorder(recorder audit.Recorder) ServiceOption {
+	return func(s *service) {
+		s.auditRecorder = recorder
+	}
 }
 
 // NewService 创建健康服务实例
@@ -32,12 +55,13 @@ type service struct {
 //
 //	storage - 健康状态存储实例，由组装层创建后注入
 //	logger - 日志记录器
+//	opts - 可选配置，如审计记录器
 //
 // 返回值：
 //
 //	Service - 健康服务实例
 //	error - 初始化错误
-func NewService(storage *Storage, logger *slog.Logger) (Service, error) {
+func NewService(storage *Storage, logger *slog.Logger, opts ...ServiceOption) (Service, error) {
 	logger.Debug("开始初始化健康服务")
 
 	if storage == nil {
@@ -46,11 +70,16 @@ func NewService(storage *Storage, logger *slog.Logger) (Service, error) {
 	}
 
 	serviceLogger := logger.WithGroup("health_service").With("component", "health_service")
-	serviceLogger.Info("健康服务初始化完成")
-	return &service{
+	s := &service{
 		storage: storage,
 		logger:  serviceLogger,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	serviceLogger.Info("健康服务初始化完成")
+	return s, nil
 }
 
 // EnableHealth 启用/恢复资源健康状态
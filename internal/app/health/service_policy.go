@@ -0,0 +1,239 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MeowSalty/pinai/database"
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
+	"gorm.io/gorm"
+)
+
+// ListPolicies 获取全局策略与平台级覆盖
+func (s *service) ListPolicies(ctx context.Context) (*PolicyListResponse, error) {
+	var records []types.HealthPolicy
+	if err := policyDB(ctx).Order("platform_id ASC").Find(&records).Error; err != nil {
+		s.logger.ErrorContext(ctx, "查询健康策略失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询健康策略失败：%w", err)
+	}
+
+	resp := &PolicyListResponse{
+		Global:    *DefaultPolicy().record(0),
+		Platforms: make([]PlatformPolicyItem, 0, len(records)),
+	}
+	platformIDs := make([]uint, 0, len(records))
+	for _, record := range records {
+		if record.PlatformID == 0 {
+			resp.Global = record
+			continue
+		}
+		platformIDs = append(platformIDs, record.PlatformID)
+		resp.Platforms = append(resp.Platforms, PlatformPolicyItem{HealthPolicy: record})
+	}
+
+	if len(platformIDs) > 0 {
+		q := query.Q
+		platforms, err := q.Platform.WithContext(ctx).
+			Select(q.Platform.ID, q.Platform.Name).
+			Where(q.Platform.ID.In(platformIDs...)).
+			Find()
+		if err != nil {
+			s.logger.ErrorContext(ctx, "查询平台名称失败", "error", err, "error_type", "database_error")
+			return nil, fmt.Errorf("查询平台名称失败：%w", err)
+		}
+		names := make(map[uint]string, len(platforms))
+		for _, platform := range platforms {
+			names[platform.ID] = platform.Name
+		}
+		for i := range resp.Platforms {
+			resp.Platforms[i].PlatformName = names[resp.Platforms[i].PlatformID]
+		}
+	}
+
+	return resp, nil
+}
+
+// UpdateGlobalPolicy 更新全局策略，未提供的字段沿用当前全局策略
+func (s *service) UpdateGlobalPolicy(ctx context.Context, req PolicyRequest) (*types.HealthPolicy, error) {
+	global, _ := s.storage.policies.snapshot()
+	return s.savePolicy(ctx, 0, global, req)
+}
+
+// UpdatePlatformPolicy 设置平台级策略覆盖，未提供的字段沿用该平台当前策略或全局策略
+func (s *service) UpdatePlatformPolicy(ctx context.Context, platformID uint, req PolicyRequest) (*types.HealthPolicy, error) {
+	q := query.Q
+	count, err := q.Platform.WithContext(ctx).Where(q.Platform.ID.Eq(platformID)).Count()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "查询平台失败", "platform_id", platformID, "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询平台失败：%w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("未找到 ID 为 %d 的平台：%w", platformID, ErrPolicyPlatformAbsent)
+	}
+
+	global, platforms := s.storage.policies.snapshot()
+	base, exists := platforms[platformID]
+	if !exists {
+		base = global
+	}
+	return s.savePolicy(ctx, platformID, base, req)
+}
+
+// DeletePlatformPolicy 删除平台级策略覆盖，平台恢复使用全局策略
+func (s *service) DeletePlatformPolicy(ctx context.Context, platformID uint) error {
+	var existing types.HealthPolicy
+	if err := policyDB(ctx).Where("platform_id = ?", platformID).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("平台 %d 未设置健康策略：%w", platformID, ErrPolicyNotFound)
+		}
+		s.logger.ErrorContext(ctx, "查询健康策略失败", "platform_id", platformID, "error", err, "error_type", "database_error")
+		return fmt.Errorf("查询健康策略失败：%w", err)
+	}
+
+	result := policyDB(ctx).Where("platform_id = ?", platformID).Delete(&types.HealthPolicy{})
+	if result.Error != nil {
+		s.logger.ErrorContext(ctx, "删除健康策略失败", "platform_id", platformID, "error", result.Error, "error_type", "database_error")
+		s.recordPolicyAudit(ctx, "health_policy.delete", platformID, result.Error, &existing, nil)
+		return fmt.Errorf("删除健康策略失败：%w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("平台 %d 未设置健康策略：%w", platformID, ErrPolicyNotFound)
+	}
+
+	s.recordPolicyAudit(ctx, "health_policy.delete", platformID, nil, &existing, nil)
+	s.afterPolicyChanged(ctx, platformID)
+	s.logger.InfoContext(ctx, "平台健康策略已删除", "platform_id", platformID)
+	return nil
+}
+
+// savePolicy 合并请求与基础策略，校验后写入数据库并刷新缓存
+func (s *service) savePolicy(ctx context.Context, platformID uint, base Policy, req PolicyRequest) (*types.HealthPolicy, error) {
+	policy, err := mergePolicy(base, req)
+	if err != nil {
+		return nil, err
+	}
+	record := policy.record(platformID)
+
+	var before *types.HealthPolicy
+	err = policyDB(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.HealthPolicy
+		err := tx.Where("platform_id = ?", platformID).First(&existing).Error
+		switch {
+		case err == nil:
+			before = &existing
+			record.ID = existing.ID
+			record.CreatedAt = existing.CreatedAt
+			// tx 携带策略表的空模型，按主键更新须使用不带模型的会话
+			return database.CleanSession(ctx, tx).Save(record).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(record).Error
+		default:
+			return err
+		}
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "保存健康策略失败", "platform_id", platformID, "error", err, "error_type", "database_error")
+		s.recordPolicyAudit(ctx, "health_policy.update", platformID, err, before, record)
+		return nil, fmt.Errorf("保存健康策略失败：%w", err)
+	}
+
+	s.recordPolicyAudit(ctx, "health_policy.update", platformID, nil, before, record)
+	s.afterPolicyChanged(ctx, platformID)
+	s.logger.InfoContext(ctx, "健康策略已更新", "platform_id", platformID)
+	return record, nil
+}
+
+// recordPolicyAudit 记录健康策略变更审计，资源标识为平台 ID（0 表示全局策略）；审计写入失败仅记录日志
+func (s *service) recordPolicyAudit(ctx context.Context, action string, platformID uint, err error, before, after *types.HealthPolicy) {
+	if s.auditRecorder == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:     action,
+		Resource:   "health_policy",
+		ResourceID: strconv.FormatUint(uint64(platformID), 10),
+		Result:     "success",
+		Detail:     "健康策略变更",
+		Before:     before,
+		After:      after,
+	}
+	if err != nil {
+		entry.Result = "failed"
+		entry.Detail = fmt.Sprintf("健康策略变更失败：%v", err)
+	}
+
+	if recordErr := s.auditRecorder.Record(ctx, entry); recordErr != nil {
+		s.logger.WarnContext(ctx, "写入健康策略审计记录失败", "action", action, "platform_id", platformID, "error", recordErr)
+	}
+}
+
+// afterPolicyChanged 重新加载策略缓存并通知其他实例
+func (s *service) afterPolicyChanged(ctx context.Context, platformID uint) {
+	if err := s.storage.policies.load(ctx); err != nil {
+		s.logger.ErrorContext(ctx, "刷新健康策略失败，变更将在重启后生效", "error", err)
+	}
+	s.storage.publishChange(policyChangeType, platformID)
+}
+
+// mergePolicy 以基础策略为准合并请求字段并校验
+func mergePolicy(base Policy, req PolicyRequest) (Policy, error) {
+	policy := base
+	if req.BaseBackoffSeconds != nil {
+		policy.BaseBackoff = time.Duration(*req.BaseBackoffSeconds) * time.Second
+	}
+	if req.MaxBackoffSeconds != nil {
+		policy.MaxBackoff = time.Duration(*req.MaxBackoffSeconds) * time.Second
+	}
+	if req.Multiplier != nil {
+		policy.Multiplier = *req.Multiplier
+	}
+	if req.WarningThreshold != nil {
+		policy.WarningThreshold = *req.WarningThreshold
+	}
+	if req.UnavailableThreshold != nil {
+		policy.UnavailableThreshold = *req.UnavailableThreshold
+	}
+	if req.HalfOpenProbes != nil {
+		policy.HalfOpenProbes = *req.HalfOpenProbes
+	}
+	if req.ErrorClasses != nil {
+		classes := make([]string, 0, len(req.ErrorClasses))
+		seen := make(map[string]struct{}, len(req.ErrorClasses))
+		for _, class := range req.ErrorClasses {
+			if _, ok := knownErrorClasses[class]; !ok {
+				return Policy{}, fmt.Errorf("不支持的错误类别 %q，可选值：network, auth, rate_limit, client, server：%w", class, ErrInvalidPolicy)
+			}
+			if _, dup := seen[class]; dup {
+				continue
+			}
+			seen[class] = struct{}{}
+			classes = append(classes, class)
+		}
+		policy.ErrorClasses = classes
+	}
+
+	switch {
+	case policy.BaseBackoff < time.Second:
+		return Policy{}, fmt.Errorf("初始退避时长不能小于 1 秒：%w", ErrInvalidPolicy)
+	case policy.MaxBackoff < policy.BaseBackoff:
+		return Policy{}, fmt.Errorf("最大退避时长不能小于初始退避时长：%w", ErrInvalidPolicy)
+	case policy.Multiplier < 1:
+		return Policy{}, fmt.Errorf("退避倍数不能小于 1：%w", ErrInvalidPolicy)
+	case policy.WarningThreshold < 1:
+		return Policy{}, fmt.Errorf("警告阈值不能小于 1：%w", ErrInvalidPolicy)
+	case policy.UnavailableThreshold < 0:
+		return Policy{}, fmt.Errorf("不可用阈值不能为负数：%w", ErrInvalidPolicy)
+	case policy.UnavailableThreshold != 0 && policy.UnavailableThreshold < policy.WarningThreshold:
+		return Policy{}, fmt.Errorf("不可用阈值须为 0 或不小于警告阈值：%w", ErrInvalidPolicy)
+	case policy.HalfOpenProbes < 1:
+		return Policy{}, fmt.Errorf("半开探测次数不能小于 1：%w", ErrInvalidPolicy)
+	}
+
+	return policy, nil
+}
@@ -461,6 +461,104 @@ func (s *service) GetModelHealthList(ctx context.Context, page, pageSize int) (*
 	}, nil
 }
 
+// GetKeyModelHealthList 获取密钥-模型组合健康列表
+//
+// 组合记录仅在权限或模型不存在类失败时创建，密钥或模型已被删除的组合不会返回。
+func (s *service) GetKeyModelHealthList(ctx context.Context, page, pageSize int) (*KeyModelHealthListResponse, error) {
+	logger := s.logger.With(
+		"operation", "list_key_models",
+		"page", page,
+		"page_size", pageSize,
+	)
+
+	pairHealths := s.storage.GetByResourceType(types.ResourceTypeKeyModel)
+	sort.Slice(pairHealths, func(i, j int) bool {
+		return pairHealths[i].LastCheckAt.After(pairHealths[j].LastCheckAt)
+	})
+
+	total := len(pairHealths)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+	pagedHealths := pairHealths[start:end]
+
+	keyIDs := make([]uint, 0, len(pagedHealths))
+	modelIDs := make([]uint, 0, len(pagedHealths))
+	for _, health := range pagedHealths {
+		keyID, modelID := types.SplitKeyModelResourceID(health.ResourceID)
+		keyIDs = append(keyIDs, keyID)
+		modelIDs = append(modelIDs, modelID)
+	}
+
+	q := query.Q
+	keyMap := make(map[uint]*types.APIKey)
+	modelMap := make(map[uint]*types.Model)
+	if len(pagedHealths) > 0 {
+		keys, err := q.APIKey.WithContext(ctx).
+			Select(q.APIKey.ID, q.APIKey.Value, q.APIKey.PlatformID).
+			Where(q.APIKey.ID.In(keyIDs...)).
+			Find()
+		if err != nil {
+			logger.Error("查询密钥信息失败", "error", err)
+			return nil, fmt.Errorf("查询密钥信息失败：%w", err)
+		}
+		for _, key := range keys {
+			keyMap[key.ID] = key
+		}
+
+		models, err := q.Model.WithContext(ctx).
+			Select(q.Model.ID, q.Model.Name).
+			Where(q.Model.ID.In(modelIDs...)).
+			Find()
+		if err != nil {
+			logger.Error("查询模型信息失败", "error", err)
+			return nil, fmt.Errorf("查询模型信息失败：%w", err)
+		}
+		for _, model := range models {
+			modelMap[model.ID] = model
+		}
+	}
+
+	items := make([]KeyModelHealthItem, 0, len(pagedHealths))
+	for _, health := range pagedHealths {
+		keyID, modelID := types.SplitKeyModelResourceID(health.ResourceID)
+		key, model := keyMap[keyID], modelMap[modelID]
+		if key == nil || model == nil {
+			continue
+		}
+		items = append(items, KeyModelHealthItem{
+			ResourceID:              health.ResourceID,
+			KeyID:                   key.ID,
+			KeyValue:                key.Value,
+			ModelID:                 model.ID,
+			ModelName:               model.Name,
+			PlatformID:              key.PlatformID,
+			Status:                  health.Status,
+			RetryCount:              health.RetryCount,
+			NextAvailableAt:         health.NextAvailableAt,
+			BackoffDuration:         health.BackoffDuration,
+			LastError:               health.LastError,
+			LastErrorCode:           health.LastErrorCode,
+			LastErrorMessage:        health.LastErrorMessage,
+			LastStructuredErrorCode: health.LastStructuredErrorCode,
+			LastHTTPStatus:          health.LastHTTPStatus,
+			LastErrorFrom:           health.LastErrorFrom,
+			LastCheckAt:             health.LastCheckAt,
+			LastSuccessAt:           health.LastSuccessAt,
+			SuccessCount:            health.SuccessCount,
+			ErrorCount:              health.ErrorCount,
+		})
+	}
+
+	logger.Debug("成功获取密钥-模型组合健康列表", "total", total, "item_count", len(items))
+
+	return &KeyModelHealthListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
 // GetIssues 获取所有异常资源列表（状态为 unavailable）
 //
 // 该方法返回所有状态为 Unavailable 的资源列表，包括资源类型、ID、名称、状态、最后检查时间和最后错误信息。
@@ -468,17 +566,33 @@ func (s *service) GetModelHealthList(ctx context.Context, page, pageSize int) (*
 // 参数：
 //
 //	ctx - 上下文
+//	opts - 过滤条件，可按异常类别与永久禁用原因过滤
 //
 // 返回值：
 //
 //	*IssuesListResponse - 异常资源列表响应
 //	error - 操作错误
-func (s *service) GetIssues(ctx context.Context) (*IssuesListResponse, error) {
+func (s *service) GetIssues(ctx context.Context, opts IssuesOptions) (*IssuesListResponse, error) {
 	logger := s.logger.With("operation", "list_issues")
-	logger.Debug("开始获取异常资源列表")
+	logger.Debug("开始获取异常资源列表", "kind", opts.Kind, "reason", opts.Reason)
 
-	// 从存储中获取所有 Unavailable 状态的健康记录
-	unavailableHealths := s.storage.GetByStatus(types.HealthStatusUnavailable)
+	switch opts.Kind {
+	case "", IssueKindBackoff, IssueKindManual, IssueKindPermanent:
+	default:
+		return nil, fmt.Errorf("不支持的异常类别 %q：%w", opts.Kind, ErrInvalidArgument)
+	}
+
+	// 从存储中获取所有 Unavailable 状态的健康记录，并按类别与禁用原因过滤
+	unavailableHealths := make([]*types.Health, 0)
+	for _, health := range s.storage.GetByStatus(types.HealthStatusUnavailable) {
+		if opts.Kind != "" && issueKind(health) != opts.Kind {
+			continue
+		}
+		if opts.Reason != "" && health.DisabledReason != opts.Reason {
+			continue
+		}
+		unavailableHealths = append(unavailableHealths, health)
+	}
 
 	// 如果没有异常记录，返回空列表
 	if len(unavailableHealths) == 0 {
@@ -632,6 +746,9 @@ func (s *service) GetIssues(ctx context.Context) (*IssuesListResponse, error) {
 				LastSuccessAt:           health.LastSuccessAt,
 				SuccessCount:            health.SuccessCount,
 				ErrorCount:              health.ErrorCount,
+				Kind:                    issueKind(health),
+				DisabledReason:          health.DisabledReason,
+				DisabledAt:              health.DisabledAt,
 			})
 		}
 	}
@@ -642,3 +759,15 @@ func (s *service) GetIssues(ctx context.Context) (*IssuesListResponse, error) {
 		Items: items,
 	}, nil
 }
+
+// issueKind 判断不可用资源的异常类别
+func issueKind(h *types.Health) string {
+	switch {
+	case IsPermanentlyDisabled(h):
+		return IssueKindPermanent
+	case h.NextAvailableAt == nil:
+		return IssueKindManual
+	default:
+		return IssueKindBackoff
+	}
+}
@@ -2,12 +2,17 @@ package health
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
+	"github.com/MeowSalty/pinai/database"
 	"github.com/MeowSalty/pinai/database/query"
 	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Storage 健康状态存储实现
@@ -17,8 +22,50 @@ import (
 //   - 写操作同时更新缓存和数据库，保证数据一致性
 //   - 使用 sync.Map 保证线程安全
 type Storage struct {
-	cache  sync.Map     // 内存缓存，key 格式："resourceType:resourceID"
-	logger *slog.Logger // 日志记录器
+	cache      sync.Map     // 内存缓存，key 格式："resourceType:resourceID"
+	rateLimits sync.Map     // 密钥最近一次上报的剩余额度，key 为密钥 ID
+	policies   *policyStore // 退避与熔断策略缓存
+	logger     *slog.Logger // 日志记录器
+
+	syncMu     sync.Mutex
+	instanceID string // 多实例同步的本实例标识，为空表示未启用同步
+	syncCursor uint   // 已应用的最大变更序号
+
+	observerMu        sync.RWMutex
+	observers         []TransitionObserver  // 健康状态变化观察者
+	disabledObservers []KeyDisabledObserver // 密钥永久禁用观察者
+}
+
+// Transition 描述资源健康状态的一次变化
+type Transition struct {
+	ResourceType types.ResourceType // 资源类型
+	ResourceID   uint               // 资源 ID
+	From         types.HealthStatus // 变化前状态，无记录时为 Unknown
+	To           types.HealthStatus // 变化后状态，记录被删除时为 Unknown
+	Health       *types.Health      // 变化后的健康记录，记录被删除时为空
+	At           time.Time          // 变化时间
+}
+
+// TransitionObserver 定义健康状态变化观察者
+//
+// 观察者在状态持久化成功后被同步调用，实现方不得阻塞。
+type TransitionObserver interface {
+	ObserveHealthTransition(transition Transition)
+}
+
+// KeyDisabledEvent 描述密钥因永久失败被自动禁用
+type KeyDisabledEvent struct {
+	KeyID  uint          // 密钥 ID
+	Reason string        // 禁用原因
+	Health *types.Health // 禁用后的健康记录
+	At     time.Time     // 禁用时间
+}
+
+// KeyDisabledObserver 定义密钥永久禁用观察者
+//
+// 观察者在禁用记录持久化成功后被同步调用，实现方不得阻塞。
+type KeyDisabledObserver interface {
+	ObserveKeyDisabled(event KeyDisabledEvent)
 }
 
 // NewStorage 创建新的健康状态存储实例
@@ -35,7 +82,8 @@ func NewStorage(ctx context.Context, logger *slog.Logger) (*Storage, error) {
 	storageLogger.Info("初始化健康状态存储")
 
 	storage := &Storage{
-		logger: storageLogger,
+		policies: newPolicyStore(storageLogger),
+		logger:   storageLogger,
 	}
 
 	// 从数据库加载所有健康状态到缓存
@@ -44,10 +92,82 @@ func NewStorage(ctx context.Context, logger *slog.Logger) (*Storage, error) {
 		return nil, fmt.Errorf("初始化健康状态存储失败：%w", err)
 	}
 
+	// 加载退避与熔断策略
+	if err := storage.policies.load(ctx); err != nil {
+		storageLogger.Error("加载健康策略失败", "error", err)
+		return nil, fmt.Errorf("初始化健康状态存储失败：%w", err)
+	}
+
 	storageLogger.Info("健康状态存储初始化完成")
 	return storage, nil
 }
 
+// AddObserver 注册健康状态变化观察者
+func (s *Storage) AddObserver(observer TransitionObserver) {
+	if observer == nil {
+		return
+	}
+
+	s.observerMu.Lock()
+	defer s.observerMu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// AddKeyDisabledObserver 注册密钥永久禁用观察者
+func (s *Storage) AddKeyDisabledObserver(observer KeyDisabledObserver) {
+	if observer == nil {
+		return
+	}
+
+	s.observerMu.Lock()
+	defer s.observerMu.Unlock()
+	s.disabledObservers = append(s.disabledObservers, observer)
+}
+
+// notifyTransition 在状态发生变化时写入变化历史并通知观察者
+func (s *Storage) notifyTransition(resourceType types.ResourceType, resourceID uint, from types.HealthStatus, next *types.Health) {
+	to := types.HealthStatusUnknown
+	if next != nil {
+		to = next.Status
+	}
+	if from == to {
+		return
+	}
+
+	s.observerMu.RLock()
+	observers := s.observers
+	s.observerMu.RUnlock()
+
+	transition := Transition{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		From:         from,
+		To:           to,
+		Health:       next,
+		At:           time.Now(),
+	}
+	s.recordTransition(transition)
+	for _, observer := range observers {
+		observer.ObserveHealthTransition(transition)
+	}
+}
+
+// cachedStatus 返回缓存中的健康状态，无记录时为 Unknown
+func (s *Storage) cachedStatus(key string) types.HealthStatus {
+	if h := s.cached(key); h != nil {
+		return h.Status
+	}
+	return types.HealthStatusUnknown
+}
+
+// cached 返回缓存中的健康记录，无记录时为空
+func (s *Storage) cached(key string) *types.Health {
+	if value, ok := s.cache.Load(key); ok {
+		return value.(*types.Health)
+	}
+	return nil
+}
+
 // Get 获取指定资源的健康状态
 //
 // 实现 health.Storage 接口
@@ -77,7 +197,9 @@ func (s *Storage) Get(resourceType types.ResourceType, resourceID uint) (*types.
 // Set 设置指定资源的健康状态
 //
 // 实现 health.Storage 接口
-// 同时更新内存缓存和数据库
+// 以缓存记录的版本号比较并交换写入数据库，成功后更新内存缓存。缓存已过期（其他实例已修改该记录）时
+// 以数据库为准刷新缓存并返回 ErrHealthConflict，由调用方基于最新记录重新计算；手动禁用与永久禁用
+// 不依赖原有状态，冲突时直接基于最新版本重试。
 func (s *Storage) Set(status *types.Health) error {
 	key := s.makeKey(status.ResourceType, status.ResourceID)
 
@@ -87,11 +209,19 @@ func (s *Storage) Set(status *types.Health) error {
 		"status", status.Status,
 		"key", key)
 
-	// 更新内存缓存
-	s.cache.Store(key, status)
-
-	// 持久化到数据库
-	if err := s.saveToDatabase(context.Background(), status); err != nil {
+	ctx := context.Background()
+	previousRecord := s.cached(key)
+	err := s.saveToDatabase(ctx, previousRecord, status)
+	if errors.Is(err, ErrHealthConflict) {
+		if refreshErr := s.refresh(ctx, status.ResourceType, status.ResourceID); refreshErr != nil {
+			return fmt.Errorf("刷新健康状态失败：%w", refreshErr)
+		}
+		if IsManuallyDisabled(status) {
+			previousRecord = s.cached(key)
+			err = s.saveToDatabase(ctx, previousRecord, status)
+		}
+	}
+	if err != nil {
 		s.logger.Debug("保存健康状态到数据库失败",
 			"error", err,
 			"resource_type", status.ResourceType,
@@ -99,9 +229,27 @@ func (s *Storage) Set(status *types.Health) error {
 		return fmt.Errorf("保存健康状态失败：%w", err)
 	}
 
+	previous := types.HealthStatusUnknown
+	if previousRecord != nil {
+		previous = previousRecord.Status
+	}
+	newlyDisabled := IsPermanentlyDisabled(status) && !IsPermanentlyDisabled(previousRecord)
+
+	// 更新内存缓存
+	s.cache.Store(key, status)
+
 	s.logger.Debug("健康状态设置成功",
 		"resource_type", status.ResourceType,
 		"resource_id", status.ResourceID)
+
+	// 写入成功说明 previousRecord 与写入前的数据库记录版本一致，据此判断是否需要通知其他实例
+	if routingChanged(previousRecord, status) {
+		s.publishChange(status.ResourceType, status.ResourceID)
+	}
+	s.notifyTransition(status.ResourceType, status.ResourceID, previous, status)
+	if newlyDisabled {
+		s.notifyKeyDisabled(status)
+	}
 	return nil
 }
 
@@ -117,6 +265,8 @@ func (s *Storage) Delete(resourceType types.ResourceType, resourceID uint) error
 		"resource_id", resourceID,
 		"key", key)
 
+	previous := s.cachedStatus(key)
+
 	// 从内存缓存删除
 	s.cache.Delete(key)
 
@@ -132,6 +282,9 @@ func (s *Storage) Delete(resourceType types.ResourceType, resourceID uint) error
 	s.logger.Debug("健康状态删除成功",
 		"resource_type", resourceType,
 		"resource_id", resourceID)
+
+	s.publishChange(resourceType, resourceID)
+	s.notifyTransition(resourceType, resourceID, previous, nil)
 	return nil
 }
 
@@ -164,21 +317,43 @@ func (s *Storage) loadFromDatabase(ctx context.Context) error {
 	return nil
 }
 
-// saveToDatabase 保存健康状态到数据库
-func (s *Storage) saveToDatabase(ctx context.Context, status *types.Health) error {
+// healthDB 返回不带 gorm-gen 作用域的健康状态表会话
+func healthDB(ctx context.Context) *gorm.DB {
+	return database.CleanSession(ctx, query.Q.Health.WithContext(ctx).UnderlyingDB())
+}
+
+// saveToDatabase 以 previous 的版本号比较并交换，将健康状态写入数据库
+//
+// previous 为空时仅在记录不存在时插入；数据库中的记录已不是 previous 的版本时返回 ErrHealthConflict。
+func (s *Storage) saveToDatabase(ctx context.Context, previous, status *types.Health) error {
 	s.logger.Debug("保存健康状态到数据库",
 		"resource_type", status.ResourceType,
 		"resource_id", status.ResourceID)
 
-	q := query.Q
-
-	// 使用 Save 进行 upsert 操作
-	if err := q.WithContext(ctx).Health.Save(status); err != nil {
+	var expected int64
+	if previous != nil {
+		expected = previous.Version
+	}
+	status.Version = expected + 1
+
+	var result *gorm.DB
+	if previous == nil {
+		result = healthDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(status)
+	} else {
+		result = healthDB(ctx).Model(&types.Health{}).
+			Where("resource_type = ? AND resource_id = ? AND version = ?", status.ResourceType, status.ResourceID, expected).
+			Select("*").
+			Updates(status)
+	}
+	if result.Error != nil {
 		s.logger.Debug("保存到数据库失败",
-			"error", err,
+			"error", result.Error,
 			"resource_type", status.ResourceType,
 			"resource_id", status.ResourceID)
-		return fmt.Errorf("保存到数据库失败：%w", err)
+		return fmt.Errorf("保存到数据库失败：%w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("资源 %d:%d 版本 %d：%w", status.ResourceType, status.ResourceID, expected, ErrHealthConflict)
 	}
 
 	s.logger.Debug("保存到数据库成功",
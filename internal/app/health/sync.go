@@ -0,0 +1,243 @@
+package health
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+// 多实例同步参数
+const (
+	syncBatchSize       = 500       // 单次拉取的变更数量上限
+	syncReconcileEvery  = 60        // 每隔多少次轮询全量对账一次，兜底自增序号乱序提交导致的遗漏
+	syncChangeRetention = time.Hour // 变更通知保留时长
+)
+
+// policyChangeType 为健康策略变更通知使用的资源类型
+const policyChangeType types.ResourceType = 0
+
+// changeDB 返回健康变更通知表的干净会话
+func changeDB(ctx context.Context) *gorm.DB {
+	return policyDB(ctx).Model(&types.HealthChange{})
+}
+
+// StartSync 启用多实例缓存同步
+//
+// 启用后本实例改变路由相关字段的健康记录写入与策略写入都会追加一条变更通知，并按 interval 轮询其他实例写入的通知，
+// 从数据库重新读取对应记录刷新本地缓存。所有实例需使用相同配置启用同步。
+// 远端变更不会再次写入历史或通知观察者，这些已由写入实例完成。
+func (s *Storage) StartSync(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return nil
+	}
+
+	instanceID, err := newInstanceID()
+	if err != nil {
+		return fmt.Errorf("生成实例标识失败：%w", err)
+	}
+
+	// 先记录游标再全量加载，游标之后的变更会在首次轮询时重复应用，保证不遗漏
+	var cursor uint
+	if err := changeDB(ctx).Select("COALESCE(MAX(id), 0)").Scan(&cursor).Error; err != nil {
+		return fmt.Errorf("读取健康变更游标失败：%w", err)
+	}
+	if err := s.reconcile(ctx); err != nil {
+		return err
+	}
+
+	s.syncMu.Lock()
+	s.instanceID = instanceID
+	s.syncCursor = cursor
+	s.syncMu.Unlock()
+
+	s.logger.Info("已启用多实例健康状态同步", "instance_id", instanceID, "interval", interval.String())
+	go s.syncLoop(ctx, interval)
+	return nil
+}
+
+// syncLoop 周期拉取变更通知，ctx 结束时退出
+func (s *Storage) syncLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for polls := 1; ; polls++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.pollChanges(ctx); err != nil {
+			s.logger.Error("拉取健康变更失败", "error", err)
+		}
+		if polls%syncReconcileEvery == 0 {
+			if err := s.reconcile(ctx); err != nil {
+				s.logger.Error("健康状态全量对账失败", "error", err)
+			}
+			s.pruneChanges(ctx)
+		}
+	}
+}
+
+// pollChanges 拉取游标之后的变更通知并刷新本地缓存
+func (s *Storage) pollChanges(ctx context.Context) error {
+	s.syncMu.Lock()
+	cursor, instanceID := s.syncCursor, s.instanceID
+	s.syncMu.Unlock()
+
+	for {
+		var changes []types.HealthChange
+		if err := changeDB(ctx).Where("id > ?", cursor).Order("id ASC").Limit(syncBatchSize).Find(&changes).Error; err != nil {
+			return fmt.Errorf("查询健康变更失败：%w", err)
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		// 同一批次内同一资源只刷新一次
+		refresh := make(map[[2]uint]struct{})
+		reloadPolicies := false
+		for _, change := range changes {
+			cursor = change.ID
+			if change.InstanceID == instanceID {
+				continue
+			}
+			if change.ResourceType == policyChangeType {
+				reloadPolicies = true
+				continue
+			}
+			refresh[[2]uint{uint(change.ResourceType), change.ResourceID}] = struct{}{}
+		}
+
+		for key := range refresh {
+			if err := s.refresh(ctx, types.ResourceType(key[0]), key[1]); err != nil {
+				return err
+			}
+		}
+		if reloadPolicies {
+			if err := s.policies.load(ctx); err != nil {
+				return fmt.Errorf("重新加载健康策略失败：%w", err)
+			}
+		}
+
+		s.syncMu.Lock()
+		s.syncCursor = cursor
+		s.syncMu.Unlock()
+
+		s.logger.Debug("已应用远端健康变更", "count", len(refresh), "reload_policies", reloadPolicies, "cursor", cursor)
+		if len(changes) < syncBatchSize {
+			return nil
+		}
+	}
+}
+
+// refresh 从数据库重新读取单个资源的健康记录，记录不存在时从缓存移除
+func (s *Storage) refresh(ctx context.Context, resourceType types.ResourceType, resourceID uint) error {
+	var records []types.Health
+	if err := policyDB(ctx).Model(&types.Health{}).
+		Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+		Limit(1).
+		Find(&records).Error; err != nil {
+		return fmt.Errorf("查询健康状态失败：%w", err)
+	}
+
+	key := s.makeKey(resourceType, resourceID)
+	if len(records) == 0 {
+		s.cache.Delete(key)
+		return nil
+	}
+	s.cache.Store(key, &records[0])
+	return nil
+}
+
+// reconcile 以数据库为准全量刷新缓存，并移除数据库中已不存在的记录
+func (s *Storage) reconcile(ctx context.Context) error {
+	var records []*types.Health
+	if err := policyDB(ctx).Model(&types.Health{}).Find(&records).Error; err != nil {
+		return fmt.Errorf("查询健康状态失败：%w", err)
+	}
+
+	present := make(map[string]struct{}, len(records))
+	for _, h := range records {
+		key := s.makeKey(h.ResourceType, h.ResourceID)
+		present[key] = struct{}{}
+		s.cache.Store(key, h)
+	}
+	s.cache.Range(func(key, _ any) bool {
+		if _, ok := present[key.(string)]; !ok {
+			s.cache.Delete(key)
+		}
+		return true
+	})
+
+	if err := s.policies.load(ctx); err != nil {
+		return fmt.Errorf("重新加载健康策略失败：%w", err)
+	}
+	return nil
+}
+
+// publishChange 在启用同步时追加一条变更通知，写入失败仅记录日志
+func (s *Storage) publishChange(resourceType types.ResourceType, resourceID uint) {
+	s.syncMu.Lock()
+	instanceID := s.instanceID
+	s.syncMu.Unlock()
+	if instanceID == "" {
+		return
+	}
+
+	change := &types.HealthChange{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		InstanceID:   instanceID,
+	}
+	if err := changeDB(context.Background()).Create(change).Error; err != nil {
+		s.logger.Error("写入健康变更通知失败，其他实例将在全量对账时同步",
+			"resource_type", resourceType,
+			"resource_id", resourceID,
+			"error", err)
+	}
+}
+
+// routingChanged 判断写入是否改变了影响路由的字段
+//
+// 仅计数与时间戳变化的写入（如连续成功）无需通知其他实例，避免每个请求都追加变更通知。
+func routingChanged(previous, next *types.Health) bool {
+	if previous == nil {
+		return true
+	}
+	return previous.Status != next.Status ||
+		previous.RetryCount != next.RetryCount ||
+		previous.HalfOpenSuccessCount != next.HalfOpenSuccessCount ||
+		previous.DisabledReason != next.DisabledReason ||
+		!sameTime(previous.NextAvailableAt, next.NextAvailableAt)
+}
+
+// sameTime 判断两个可空时间是否相同
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// pruneChanges 清理超过保留时长的变更通知
+func (s *Storage) pruneChanges(ctx context.Context) {
+	cutoff := time.Now().Add(-syncChangeRetention)
+	if err := changeDB(ctx).Where("created_at < ?", cutoff).Delete(&types.HealthChange{}).Error; err != nil {
+		s.logger.Error("清理健康变更通知失败", "error", err)
+	}
+}
+
+// newInstanceID 生成随机实例标识
+func newInstanceID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
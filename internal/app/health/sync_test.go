@@ -0,0 +1,146 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestStartSync_其他实例的启停与策略变更同步到本地缓存(t *testing.T) {
+	svc, db := newTestService(t)
+	if err := db.AutoMigrate(&types.HealthChange{}); err != nil {
+		t.Fatalf("迁移变更通知表失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	replica, err := NewStorage(ctx, slog.Default())
+	if err != nil {
+		t.Fatalf("创建副本健康存储失败: %v", err)
+	}
+	// 轮询周期足够长，测试中手动触发拉取
+	for _, storage := range []*Storage{svc.storage, replica} {
+		if err := storage.StartSync(ctx, time.Hour); err != nil {
+			t.Fatalf("启用同步失败: %v", err)
+		}
+	}
+
+	// 手动禁用在副本上可见
+	if err := svc.DisableHealth(types.ResourceTypeAPIKey, 1); err != nil {
+		t.Fatalf("禁用密钥失败: %v", err)
+	}
+	if err := replica.pollChanges(ctx); err != nil {
+		t.Fatalf("拉取变更失败: %v", err)
+	}
+	if h, _ := replica.Get(types.ResourceTypeAPIKey, 1); h == nil || h.Status != types.HealthStatusUnavailable {
+		t.Fatalf("副本应看到密钥被禁用: %+v", h)
+	}
+
+	// 手动启用（删除记录）在副本上可见
+	if err := svc.EnableHealth(types.ResourceTypeAPIKey, 1); err != nil {
+		t.Fatalf("启用密钥失败: %v", err)
+	}
+	if err := replica.pollChanges(ctx); err != nil {
+		t.Fatalf("拉取变更失败: %v", err)
+	}
+	if h, _ := replica.Get(types.ResourceTypeAPIKey, 1); h != nil {
+		t.Fatalf("副本应看到密钥被启用: %+v", h)
+	}
+
+	// 策略变更在副本上可见
+	if _, err := svc.UpdateGlobalPolicy(ctx, PolicyRequest{WarningThreshold: intPtr(3)}); err != nil {
+		t.Fatalf("更新全局策略失败: %v", err)
+	}
+	if err := replica.pollChanges(ctx); err != nil {
+		t.Fatalf("拉取变更失败: %v", err)
+	}
+	if got := replica.policies.resolve(types.ResourceTypeAPIKey, 1).WarningThreshold; got != 3 {
+		t.Fatalf("副本策略未刷新: warning_threshold = %d", got)
+	}
+
+	// 本实例写入的变更不会重复应用到自身，仅计数变化的写入不追加通知
+	var before int64
+	db.Model(&types.HealthChange{}).Count(&before)
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 2, failure(http.StatusInternalServerError)); err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+	h, _ := svc.storage.Get(types.ResourceTypeAPIKey, 2)
+	counted := *h
+	counted.ErrorCount++
+	counted.LastCheckAt = time.Now()
+	if err := svc.storage.Set(&counted); err != nil {
+		t.Fatalf("写入健康状态失败: %v", err)
+	}
+	var after int64
+	db.Model(&types.HealthChange{}).Count(&after)
+	if after-before != 1 {
+		t.Fatalf("应仅为状态变化追加 1 条通知，实际 %d", after-before)
+	}
+}
+
+func TestStorageSet_过期缓存的写入不覆盖其他实例的变更(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	replica, err := NewStorage(ctx, slog.Default())
+	if err != nil {
+		t.Fatalf("创建副本健康存储失败: %v", err)
+	}
+
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 3, Outcome{Success: true}); err != nil {
+		t.Fatalf("记录成功结果失败: %v", err)
+	}
+	if err := replica.refresh(ctx, types.ResourceTypeAPIKey, 3); err != nil {
+		t.Fatalf("刷新副本缓存失败: %v", err)
+	}
+	stale, _ := replica.Get(types.ResourceTypeAPIKey, 3)
+	staleCopy := *stale
+
+	// 本实例写入失败后，副本缓存仍停留在旧版本
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 3, failure(http.StatusInternalServerError)); err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+
+	// 基于旧版本的整行写入被拒绝，副本缓存刷新为数据库中的记录
+	if err := replica.Set(&staleCopy); !errors.Is(err, ErrHealthConflict) {
+		t.Fatalf("过期缓存的写入应返回版本冲突: %v", err)
+	}
+	if h, _ := replica.Get(types.ResourceTypeAPIKey, 3); h == nil || h.ErrorCount != 1 {
+		t.Fatalf("冲突后副本缓存应刷新为最新记录: %+v", h)
+	}
+
+	// 请求结果基于最新记录重新计算，不丢失其他实例累计的错误
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 3, failure(http.StatusInternalServerError)); err != nil {
+		t.Fatalf("记录失败结果失败: %v", err)
+	}
+	if _, err := replica.RecordOutcome(types.ResourceTypeAPIKey, 3, failure(http.StatusInternalServerError)); err != nil {
+		t.Fatalf("副本记录失败结果失败: %v", err)
+	}
+	var stored types.Health
+	if err := db.Where("resource_type = ? AND resource_id = ?", types.ResourceTypeAPIKey, 3).First(&stored).Error; err != nil {
+		t.Fatalf("查询健康记录失败: %v", err)
+	}
+	if stored.ErrorCount != 3 {
+		t.Fatalf("副本应基于最新记录累计错误: error_count = %d", stored.ErrorCount)
+	}
+
+	// 手动禁用不依赖原有状态，缓存过期时仍写入成功
+	if _, err := svc.storage.RecordOutcome(types.ResourceTypeAPIKey, 3, Outcome{Success: true}); err != nil {
+		t.Fatalf("记录成功结果失败: %v", err)
+	}
+	disabled := &types.Health{ResourceType: types.ResourceTypeAPIKey, ResourceID: 3, Status: types.HealthStatusUnavailable, LastCheckAt: time.Now()}
+	if err := replica.Set(disabled); err != nil {
+		t.Fatalf("缓存过期时手动禁用失败: %v", err)
+	}
+	if err := svc.storage.refresh(ctx, types.ResourceTypeAPIKey, 3); err != nil {
+		t.Fatalf("刷新缓存失败: %v", err)
+	}
+	if h, _ := svc.storage.Get(types.ResourceTypeAPIKey, 3); !IsManuallyDisabled(h) {
+		t.Fatalf("手动禁用应写入数据库: %+v", h)
+	}
+}
@@ -110,6 +110,38 @@ type ModelHealthListResponse struct {
 	PageSize int               `json:"page_size"` // 每页大小
 }
 
+// KeyModelHealthItem 单个密钥-模型组合健康状态项
+type KeyModelHealthItem struct {
+	ResourceID              uint               `json:"resource_id"`                // 组合资源 ID
+	KeyID                   uint               `json:"key_id"`                     // 密钥 ID
+	KeyValue                string             `json:"key_value"`                  // 密钥值
+	ModelID                 uint               `json:"model_id"`                   // 模型 ID
+	ModelName               string             `json:"model_name"`                 // 模型名称
+	PlatformID              uint               `json:"platform_id"`                // 所属平台 ID
+	Status                  types.HealthStatus `json:"status"`                     // 健康状态
+	RetryCount              int                `json:"retry_count"`                // 重试次数
+	NextAvailableAt         *time.Time         `json:"next_available_at"`          // 下次可用时间
+	BackoffDuration         int64              `json:"backoff_duration"`           // 当前退避时长（秒）
+	LastError               string             `json:"last_error"`                 // 最后错误信息
+	LastErrorCode           int                `json:"last_error_code"`            // 最后错误码
+	LastErrorMessage        string             `json:"last_error_message"`         // 最后错误展示消息
+	LastStructuredErrorCode string             `json:"last_structured_error_code"` // 最后稳定错误码
+	LastHTTPStatus          *int               `json:"last_http_status"`           // 最后 HTTP 状态码
+	LastErrorFrom           string             `json:"last_error_from"`            // 最后错误来源
+	LastCheckAt             time.Time          `json:"last_check_at"`              // 最后检查时间
+	LastSuccessAt           *time.Time         `json:"last_success_at"`            // 最后成功时间
+	SuccessCount            int                `json:"success_count"`              // 成功次数
+	ErrorCount              int                `json:"error_count"`                // 错误次数
+}
+
+// KeyModelHealthListResponse 密钥-模型组合健康列表响应
+type KeyModelHealthListResponse struct {
+	Items    []KeyModelHealthItem `json:"items"`     // 组合健康列表
+	Total    int                  `json:"total"`     // 总数
+	Page     int                  `json:"page"`      // 当前页码
+	PageSize int                  `json:"page_size"` // 每页大小
+}
+
 // IssueItem 单个异常资源项
 type IssueItem struct {
 	ResourceType            types.ResourceType `json:"resource_type"`              // 资源类型
@@ -131,9 +163,89 @@ type IssueItem struct {
 	LastSuccessAt           *time.Time         `json:"last_success_at"`            // 最后成功时间
 	SuccessCount            int                `json:"success_count"`              // 成功次数
 	ErrorCount              int                `json:"error_count"`                // 错误次数
+	Kind                    string             `json:"kind"`                       // 异常类别
+	DisabledReason          string             `json:"disabled_reason,omitempty"`  // 永久禁用原因
+	DisabledAt              *time.Time         `json:"disabled_at,omitempty"`      // 永久禁用时间
+}
+
+// 异常资源类别
+const (
+	IssueKindBackoff   = "backoff"   // 退避中，到期后自动恢复
+	IssueKindManual    = "manual"    // 管理员手动禁用
+	IssueKindPermanent = "permanent" // 因永久失败被自动禁用
+)
+
+// IssuesOptions 异常资源列表过滤条件
+type IssuesOptions struct {
+	Kind   string // 异常类别，为空表示全部
+	Reason string // 永久禁用原因，为空表示全部
 }
 
 // IssuesListResponse 异常资源列表响应
 type IssuesListResponse struct {
 	Items []IssueItem `json:"items"` // 异常资源列表
 }
+
+// PolicyRequest 健康策略写入请求
+//
+// 未提供的字段沿用当前值：全局策略沿用默认值，平台策略沿用全局策略。
+type PolicyRequest struct {
+	BaseBackoffSeconds   *int64   `json:"base_backoff_seconds"`  // 初始退避时长（秒）
+	MaxBackoffSeconds    *int64   `json:"max_backoff_seconds"`   // 最大退避时长（秒）
+	Multiplier           *float64 `json:"multiplier"`            // 退避倍数
+	WarningThreshold     *int     `json:"warning_threshold"`     // 连续失败多少次后进入警告并开始退避
+	UnavailableThreshold *int     `json:"unavailable_threshold"` // 连续失败多少次后标记不可用，0 表示仅在退避达到上限时标记
+	HalfOpenProbes       *int     `json:"half_open_probes"`      // 退避结束后需连续成功多少次才恢复为可用
+	ErrorClasses         []string `json:"error_classes"`         // 计入失败的错误类别，空数组表示全部计入
+}
+
+// PlatformPolicyItem 平台级健康策略项
+type PlatformPolicyItem struct {
+	types.HealthPolicy
+	PlatformName string `json:"platform_name"` // 平台名称
+}
+
+// PolicyListResponse 健康策略列表响应
+type PolicyListResponse struct {
+	Global    types.HealthPolicy   `json:"global"`    // 全局策略
+	Platforms []PlatformPolicyItem `json:"platforms"` // 平台级覆盖
+}
+
+// HistoryOptions 健康状态变化历史查询参数
+type HistoryOptions struct {
+	Range     string     // 时间范围：24h、7d、30d，默认 7d；指定起止时间时忽略
+	StartTime *time.Time // 开始时间（含），未指定时为结束时间前的默认范围
+	EndTime   *time.Time // 结束时间，未指定时为当前时间，晚于当前时间时截断为当前时间
+	Page      int        // 页码
+	PageSize  int        // 每页大小
+}
+
+// HistoryPruneResult 健康状态变化历史清理结果
+type HistoryPruneResult struct {
+	Before      time.Time `json:"before"`       // 清理该时间之前的记录
+	DeletedRows int64     `json:"deleted_rows"` // 删除的记录数
+}
+
+// Uptime 时间范围内的可用率统计
+type Uptime struct {
+	Percent            *float64 `json:"percent"`             // 可用率（百分比），无已知状态时为空
+	AvailableSeconds   int64    `json:"available_seconds"`   // 可用时长（秒）
+	WarningSeconds     int64    `json:"warning_seconds"`     // 警告时长（秒）
+	UnavailableSeconds int64    `json:"unavailable_seconds"` // 不可用时长（秒）
+	UnknownSeconds     int64    `json:"unknown_seconds"`     // 未知时长（秒）
+	Transitions        int      `json:"transitions"`         // 状态变化次数
+}
+
+// HistoryResponse 健康状态变化历史响应
+type HistoryResponse struct {
+	ResourceType types.ResourceType       `json:"resource_type"` // 资源类型
+	ResourceID   uint                     `json:"resource_id"`   // 资源 ID
+	Range        string                   `json:"range"`         // 时间范围，指定起止时间时为 custom
+	StartTime    time.Time                `json:"start_time"`    // 统计开始时间
+	EndTime      time.Time                `json:"end_time"`      // 统计结束时间
+	Uptime       Uptime                   `json:"uptime"`        // 可用率统计
+	Items        []types.HealthTransition `json:"items"`         // 状态变化记录，按时间倒序
+	Total        int64                    `json:"total"`         // 时间范围内的变化总数
+	Page         int                      `json:"page"`          // 当前页码
+	PageSize     int                      `json:"page_size"`     // 每页大小
+}
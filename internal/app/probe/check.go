@@ -0,0 +1,94 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// 密钥校验结果的稳定错误码
+const (
+	CheckErrorUnsupportedEndpoint = "unsupported_endpoint" // 平台没有支持校验的端点
+	CheckErrorNetwork             = "network_error"        // 无法连接上游
+	CheckErrorInvalidKey          = "invalid_api_key"      // 401
+	CheckErrorPermissionDenied    = "permission_denied"    // 403
+	CheckErrorNotFound            = "endpoint_not_found"   // 404
+	CheckErrorRateLimited         = "rate_limited"         // 429
+	CheckErrorUpstreamUnavailable = "upstream_unavailable" // 5xx
+	CheckErrorUpstream            = "upstream_error"       // 其他非 2xx
+)
+
+// KeyCheckResult 定义一次密钥校验的结果
+type KeyCheckResult struct {
+	Success      bool   // 上游是否接受该密钥
+	LatencyMs    int64  // 请求耗时（毫秒）
+	EndpointType string // 校验使用的端点类型
+	HTTPStatus   *int   // 上游 HTTP 状态码（无响应时为空）
+	ErrorCode    string // 稳定错误码，成功时为空
+	ErrorMessage string // 错误说明，成功时为空
+}
+
+// CheckKey 以列出模型请求校验密钥，不写入健康状态
+//
+// platform 须包含端点列表；优先使用默认端点。
+func (s *service) CheckKey(ctx context.Context, platform *types.Platform, keyValue string) KeyCheckResult {
+	endpoint := probeEndpoint(platform.Endpoints)
+	if endpoint == nil || !supportsEndpoint(endpoint.EndpointType) {
+		return KeyCheckResult{
+			ErrorCode:    CheckErrorUnsupportedEndpoint,
+			ErrorMessage: "平台没有支持校验的端点（openai、anthropic、google）",
+		}
+	}
+
+	result := KeyCheckResult{EndpointType: normalizeEndpointType(endpoint.EndpointType)}
+	req, err := buildListModelsRequest(ctx, platform, endpoint, keyValue)
+	if err != nil {
+		result.ErrorCode = CheckErrorUnsupportedEndpoint
+		result.ErrorMessage = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.ErrorCode = CheckErrorNetwork
+		result.ErrorMessage = fmt.Sprintf("请求上游失败：%v", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseDrainSize))
+	status := resp.StatusCode
+	result.HTTPStatus = &status
+	if status >= 200 && status < 300 {
+		result.Success = true
+		return result
+	}
+
+	result.ErrorCode = checkErrorCode(status)
+	result.ErrorMessage = fmt.Sprintf("上游返回 HTTP %d：%s", status, snippet(body))
+	return result
+}
+
+// checkErrorCode 将上游 HTTP 状态码映射为稳定错误码
+func checkErrorCode(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return CheckErrorInvalidKey
+	case status == http.StatusForbidden:
+		return CheckErrorPermissionDenied
+	case status == http.StatusNotFound:
+		return CheckErrorNotFound
+	case status == http.StatusTooManyRequests:
+		return CheckErrorRateLimited
+	case status >= http.StatusInternalServerError:
+		return CheckErrorUpstreamUnavailable
+	default:
+		return CheckErrorUpstream
+	}
+}
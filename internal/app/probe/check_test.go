@@ -0,0 +1,100 @@
+package probe
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestCheckKey_按端点类型发送鉴权请求(t *testing.T) {
+	var gotPath, gotAuth, gotAnthropicKey, gotGoogleKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotAnthropicKey = r.Header.Get("x-api-key")
+		gotGoogleKey = r.Header.Get("x-goog-api-key")
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	svc := New(Config{}, nil, slog.Default())
+	cases := []struct {
+		endpointType string
+		wantPath     string
+		check        func() bool
+	}{
+		{"openai", "/v1/models", func() bool { return gotAuth == "Bearer sk-test" }},
+		{"anthropic", "/v1/models", func() bool { return gotAnthropicKey == "sk-test" }},
+		{"gemini", "/v1beta/models", func() bool { return gotGoogleKey == "sk-test" }},
+	}
+	for _, tc := range cases {
+		platform := &types.Platform{
+			BaseURL:   server.URL,
+			Endpoints: []types.Endpoint{{EndpointType: tc.endpointType, IsDefault: true}},
+		}
+		result := svc.CheckKey(context.Background(), platform, "sk-test")
+		if !result.Success || result.HTTPStatus == nil || *result.HTTPStatus != http.StatusOK {
+			t.Fatalf("%s 校验应成功: %+v", tc.endpointType, result)
+		}
+		if gotPath != tc.wantPath || !tc.check() {
+			t.Fatalf("%s 请求不符: path=%s", tc.endpointType, gotPath)
+		}
+	}
+}
+
+func TestCheckKey_映射上游错误(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"error":{"message":"Incorrect API key provided"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	svc := New(Config{}, nil, slog.Default())
+	platform := &types.Platform{
+		BaseURL:   server.URL,
+		Endpoints: []types.Endpoint{{EndpointType: "openai", IsDefault: true}},
+	}
+
+	want := map[int]string{
+		http.StatusUnauthorized:       CheckErrorInvalidKey,
+		http.StatusForbidden:          CheckErrorPermissionDenied,
+		http.StatusTooManyRequests:    CheckErrorRateLimited,
+		http.StatusServiceUnavailable: CheckErrorUpstreamUnavailable,
+		http.StatusBadRequest:         CheckErrorUpstream,
+	}
+	for code, errorCode := range want {
+		status = code
+		result := svc.CheckKey(context.Background(), platform, "sk-bad")
+		if result.Success || result.ErrorCode != errorCode {
+			t.Fatalf("HTTP %d 应映射为 %s: %+v", code, errorCode, result)
+		}
+		if result.ErrorMessage == "" {
+			t.Fatalf("失败结果应包含错误说明: %+v", result)
+		}
+	}
+}
+
+func TestCheckKey_无可用端点或上游不可达(t *testing.T) {
+	svc := New(Config{}, nil, slog.Default())
+
+	result := svc.CheckKey(context.Background(), &types.Platform{}, "sk-test")
+	if result.Success || result.ErrorCode != CheckErrorUnsupportedEndpoint {
+		t.Fatalf("无端点时应返回不支持: %+v", result)
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	baseURL := server.URL
+	server.Close()
+	result = svc.CheckKey(context.Background(), &types.Platform{
+		BaseURL:   baseURL,
+		Endpoints: []types.Endpoint{{EndpointType: "openai"}},
+	}, "sk-test")
+	if result.Success || result.ErrorCode != CheckErrorNetwork || result.HTTPStatus != nil {
+		t.Fatalf("上游不可达时应返回网络错误: %+v", result)
+	}
+}
@@ -0,0 +1,134 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+const (
+	listModelsPageSize = 1000    // 单页请求的模型数量
+	listModelsMaxPages = 20      // 分页上限，防止上游分页异常时无限请求
+	listModelsBodySize = 8 << 20 // 模型列表响应体上限
+)
+
+// listModelsPage 为各端点类型模型列表响应的公共字段
+type listModelsPage struct {
+	// OpenAI / Anthropic
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+
+	// Google
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListModels 使用密钥请求平台的模型列表，返回去重后的模型名称
+//
+// platform 须包含端点列表；优先使用默认端点。Google 模型名称去除 "models/" 前缀，与请求转发保持一致。
+func (s *service) ListModels(ctx context.Context, platform *types.Platform, keyValue string) ([]string, error) {
+	endpoint := probeEndpoint(platform.Endpoints)
+	if endpoint == nil || !supportsEndpoint(endpoint.EndpointType) {
+		return nil, fmt.Errorf("平台没有支持列出模型的端点（openai、anthropic、google）")
+	}
+	endpointType := normalizeEndpointType(endpoint.EndpointType)
+
+	seen := make(map[string]struct{})
+	names := make([]string, 0)
+	cursor := ""
+	for range listModelsMaxPages {
+		req, err := buildListModelsRequest(ctx, platform, endpoint, keyValue)
+		if err != nil {
+			return nil, err
+		}
+		applyListModelsPaging(req, endpointType, cursor)
+
+		page, err := s.fetchModelsPage(req)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Data {
+			names = appendModelName(names, seen, item.ID)
+		}
+		for _, item := range page.Models {
+			names = appendModelName(names, seen, strings.TrimPrefix(item.Name, "models/"))
+		}
+
+		switch {
+		case endpointType == endpointTypeAnthropic && page.HasMore && page.LastID != "":
+			cursor = page.LastID
+		case endpointType == endpointTypeGoogle && page.NextPageToken != "":
+			cursor = page.NextPageToken
+		default:
+			return names, nil
+		}
+	}
+	return nil, fmt.Errorf("模型列表分页超过 %d 页", listModelsMaxPages)
+}
+
+// applyListModelsPaging 设置分页参数，cursor 为空时请求首页
+func applyListModelsPaging(req *http.Request, endpointType, cursor string) {
+	query := req.URL.Query()
+	switch endpointType {
+	case endpointTypeAnthropic:
+		query.Set("limit", fmt.Sprint(listModelsPageSize))
+		if cursor != "" {
+			query.Set("after_id", cursor)
+		}
+	case endpointTypeGoogle:
+		query.Set("pageSize", fmt.Sprint(listModelsPageSize))
+		if cursor != "" {
+			query.Set("pageToken", cursor)
+		}
+	default:
+		return
+	}
+	req.URL.RawQuery = query.Encode()
+}
+
+// fetchModelsPage 发送请求并解析一页模型列表
+func (s *service) fetchModelsPage(req *http.Request) (*listModelsPage, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求上游失败：%w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, listModelsBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("读取模型列表失败：%w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("上游返回 HTTP %d（%s）：%s", resp.StatusCode, checkErrorCode(resp.StatusCode), snippet(body))
+	}
+
+	var page listModelsPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("解析模型列表失败：%w", err)
+	}
+	return &page, nil
+}
+
+// appendModelName 追加非空且未出现过的模型名称
+func appendModelName(names []string, seen map[string]struct{}, name string) []string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return names
+	}
+	if _, ok := seen[name]; ok {
+		return names
+	}
+	seen[name] = struct{}{}
+	return append(names, name)
+}
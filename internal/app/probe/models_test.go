@@ -0,0 +1,67 @@
+package probe
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestListModels_按端点类型解析并分页(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") != "":
+			_, _ = w.Write([]byte(`{"data":[{"id":"gpt-4o"},{"id":"o1"},{"id":"gpt-4o"}]}`))
+		case r.Header.Get("x-api-key") != "":
+			if r.URL.Query().Get("after_id") == "" {
+				_, _ = w.Write([]byte(`{"data":[{"id":"claude-a"}],"has_more":true,"last_id":"claude-a"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[{"id":"claude-b"}],"has_more":false}`))
+		default:
+			if r.URL.Query().Get("pageToken") == "" {
+				_, _ = w.Write([]byte(`{"models":[{"name":"models/gemini-a"}],"nextPageToken":"p2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"models":[{"name":"models/gemini-b"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	svc := New(Config{}, nil, slog.Default())
+	cases := map[string][]string{
+		"openai":    {"gpt-4o", "o1"},
+		"anthropic": {"claude-a", "claude-b"},
+		"gemini":    {"gemini-a", "gemini-b"},
+	}
+	for endpointType, want := range cases {
+		platform := &types.Platform{
+			BaseURL:   server.URL,
+			Endpoints: []types.Endpoint{{EndpointType: endpointType, IsDefault: true}},
+		}
+		got, err := svc.ListModels(context.Background(), platform, "sk-test")
+		if err != nil {
+			t.Fatalf("%s 列出模型失败: %v", endpointType, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("%s 模型列表不符: %v", endpointType, got)
+		}
+	}
+}
+
+func TestListModels_上游错误返回错误(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	svc := New(Config{}, nil, slog.Default())
+	platform := &types.Platform{BaseURL: server.URL, Endpoints: []types.Endpoint{{EndpointType: "openai"}}}
+	if _, err := svc.ListModels(context.Background(), platform, "sk-test"); err == nil {
+		t.Fatalf("上游返回 401 时应返回错误")
+	}
+}
@@ -0,0 +1,169 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// 支持主动探测的端点类型
+const (
+	endpointTypeOpenAI    = "openai"
+	endpointTypeAnthropic = "anthropic"
+	endpointTypeGoogle    = "google"
+	endpointTypeGemini    = "gemini"
+)
+
+// anthropicVersion 为 Anthropic 接口要求的版本头
+const anthropicVersion = "2023-06-01"
+
+// probePrompt 为模型探测发送的最小输入
+const probePrompt = "ping"
+
+// supportsEndpoint 判断端点类型是否支持主动探测
+func supportsEndpoint(endpointType string) bool {
+	switch normalizeEndpointType(endpointType) {
+	case endpointTypeOpenAI, endpointTypeAnthropic, endpointTypeGoogle:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeEndpointType 归一化端点类型，gemini 视为 google
+func normalizeEndpointType(endpointType string) string {
+	t := strings.ToLower(strings.TrimSpace(endpointType))
+	if t == endpointTypeGemini {
+		return endpointTypeGoogle
+	}
+	return t
+}
+
+// buildListModelsRequest 构建列出模型的探测请求，用于校验密钥与平台连通性
+func buildListModelsRequest(ctx context.Context, platform *types.Platform, endpoint *types.Endpoint, key string) (*http.Request, error) {
+	var defaultPath string
+	switch normalizeEndpointType(endpoint.EndpointType) {
+	case endpointTypeOpenAI, endpointTypeAnthropic:
+		defaultPath = "/v1/models"
+	case endpointTypeGoogle:
+		defaultPath = "/v1beta/models"
+	default:
+		return nil, fmt.Errorf("不支持探测的端点类型：%s", endpoint.EndpointType)
+	}
+
+	// 自定义路径为完整补全地址时无法推导模型列表地址，使用默认路径
+	path := defaultPath
+	if strings.HasSuffix(endpoint.Path, "/") {
+		path = endpoint.Path + defaultPath
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinBaseURL(platform.BaseURL, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	applyHeaders(req, endpoint, key)
+	return req, nil
+}
+
+// buildCompletionRequest 构建只生成极少 token 的补全探测请求，用于校验模型可用性
+func buildCompletionRequest(ctx context.Context, platform *types.Platform, endpoint *types.Endpoint, key, model string) (*http.Request, error) {
+	var (
+		defaultPath string
+		body        any
+	)
+	switch normalizeEndpointType(endpoint.EndpointType) {
+	case endpointTypeOpenAI:
+		if strings.EqualFold(strings.TrimSpace(endpoint.EndpointVariant), "responses") {
+			defaultPath = "/v1/responses"
+			body = map[string]any{
+				"model":             model,
+				"input":             probePrompt,
+				"max_output_tokens": 16, // Responses 接口允许的最小值
+			}
+		} else {
+			defaultPath = "/v1/chat/completions"
+			body = map[string]any{
+				"model":      model,
+				"messages":   []map[string]string{{"role": "user", "content": probePrompt}},
+				"max_tokens": 1,
+			}
+		}
+	case endpointTypeAnthropic:
+		defaultPath = "/v1/messages"
+		body = map[string]any{
+			"model":      model,
+			"messages":   []map[string]string{{"role": "user", "content": probePrompt}},
+			"max_tokens": 1,
+		}
+	case endpointTypeGoogle:
+		defaultPath = "/v1beta/models/" + strings.TrimPrefix(model, "models/") + ":generateContent"
+		body = map[string]any{
+			"contents":         []map[string]any{{"parts": []map[string]string{{"text": probePrompt}}}},
+			"generationConfig": map[string]any{"maxOutputTokens": 1},
+		}
+	default:
+		return nil, fmt.Errorf("不支持探测的端点类型：%s", endpoint.EndpointType)
+	}
+
+	// 自定义路径以 / 结尾时视为前缀，否则视为完整路径，与请求转发保持一致
+	path := defaultPath
+	if endpoint.Path != "" {
+		if strings.HasSuffix(endpoint.Path, "/") {
+			path = endpoint.Path + defaultPath
+		} else {
+			path = endpoint.Path
+		}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, joinBaseURL(platform.BaseURL, path), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	applyHeaders(req, endpoint, key)
+	return req, nil
+}
+
+// applyHeaders 设置鉴权头与端点自定义请求头
+func applyHeaders(req *http.Request, endpoint *types.Endpoint, key string) {
+	switch normalizeEndpointType(endpoint.EndpointType) {
+	case endpointTypeOpenAI:
+		req.Header.Set("Authorization", "Bearer "+key)
+	case endpointTypeAnthropic:
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", anthropicVersion)
+	case endpointTypeGoogle:
+		req.Header.Set("x-goog-api-key", key)
+	}
+
+	for name, value := range endpoint.CustomHeaders {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+}
+
+// joinBaseURL 拼接平台基础地址与路径，路径为完整地址时直接返回
+func joinBaseURL(baseURL, path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+
+	base := strings.TrimRight(baseURL, "/")
+	normalized := strings.TrimLeft(path, "/")
+	for strings.Contains(normalized, "//") {
+		normalized = strings.ReplaceAll(normalized, "//", "/")
+	}
+	return base + "/" + normalized
+}
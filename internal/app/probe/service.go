@@ -0,0 +1,478 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/health"
+)
+
+const (
+	defaultProbeTimeout = 15 * time.Second // 单次探测请求超时
+	probeConcurrency    = 4                // 同时进行的探测请求数
+	responseSnippetSize = 256              // 失败原因中保留的响应体长度
+	responseDrainSize   = 64 << 10         // 读取并丢弃的响应体上限
+)
+
+// Config 定义主动探测配置
+type Config struct {
+	Interval    time.Duration // 探测周期，0 表示不自动探测
+	ProbeModels bool          // 是否对模型发送 1 token 补全请求（会产生少量费用）
+	Timeout     time.Duration // 单次探测请求超时，默认 15 秒
+}
+
+// HealthRecorder 定义主动探测写入健康状态所需的最小能力
+type HealthRecorder interface {
+	Get(resourceType types.ResourceType, resourceID uint) (*types.Health, error)
+	RecordOutcome(resourceType types.ResourceType, resourceID uint, outcome health.Outcome) (*types.Health, error)
+	RecordRateLimit(keyID uint, info health.RateLimitInfo)
+	IsKeyModelAvailable(keyID, modelID uint) bool
+}
+
+// Service 定义主动健康探测服务接口
+type Service interface {
+	// RunOnce 对全部平台、密钥与（按配置）模型执行一轮探测
+	//
+	// 探测请求直接发往上游，不经过网关转发链路，不会写入请求日志与用户统计。
+	RunOnce(ctx context.Context) (*Result, error)
+
+	// CheckKey 以一次列出模型请求校验密钥是否被上游接受，结果不写入健康状态
+	CheckKey(ctx context.Context, platform *types.Platform, keyValue string) KeyCheckResult
+
+	// ListModels 使用密钥请求平台的模型列表，返回上游提供的模型名称
+	ListModels(ctx context.Context, platform *types.Platform, keyValue string) ([]string, error)
+}
+
+// service 主动健康探测服务实现
+type service struct {
+	cfg      Config
+	recorder HealthRecorder
+	client   *http.Client
+	logger   *slog.Logger
+}
+
+// New 创建主动健康探测服务实例
+func New(cfg Config, recorder HealthRecorder, logger *slog.Logger) Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultProbeTimeout
+	}
+
+	return &service{
+		cfg:      cfg,
+		recorder: recorder,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		logger:   logger.With("component", "health_probe"),
+	}
+}
+
+// verdict 为探测结论
+type verdict int
+
+const (
+	verdictSkip    verdict = iota // 结果不能说明资源健康状况，不写入
+	verdictSuccess                // 探测成功
+	verdictFailure                // 探测失败
+)
+
+// probeResult 为单次探测的结论
+type probeResult struct {
+	verdict         verdict
+	outcome         health.Outcome
+	platformFailure bool                 // 连接失败或上游 5xx，视为平台级故障
+	rateLimited     bool                 // 上游返回 429 且给出了重试时间，归因于密钥
+	rateLimit       health.RateLimitInfo // 响应头中的限流信息
+}
+
+// platformTarget 为一个平台的探测目标
+type platformTarget struct {
+	platform *types.Platform
+	endpoint *types.Endpoint
+	keys     []*types.APIKey
+	models   []*types.Model
+}
+
+// RunOnce 实现 Service 接口
+func (s *service) RunOnce(ctx context.Context) (*Result, error) {
+	start := time.Now()
+
+	targets, err := s.loadTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, probeConcurrency)
+
+	for _, target := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(target platformTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			platformResult := s.probePlatform(ctx, target)
+			mu.Lock()
+			result.merge(platformResult)
+			mu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("健康探测被中断：%w", err)
+	}
+
+	s.logger.Info("健康探测完成",
+		"platforms", result.Platforms,
+		"keys", result.Keys,
+		"models", result.Models,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+	return result, nil
+}
+
+// loadTargets 加载全部平台、端点、密钥与模型，并按平台分组
+func (s *service) loadTargets(ctx context.Context) ([]platformTarget, error) {
+	q := query.Q
+
+	platforms, err := q.Platform.WithContext(ctx).Preload(q.Platform.Endpoints).Find()
+	if err != nil {
+		return nil, fmt.Errorf("查询平台失败：%w", err)
+	}
+	keys, err := q.APIKey.WithContext(ctx).Find()
+	if err != nil {
+		return nil, fmt.Errorf("查询密钥失败：%w", err)
+	}
+
+	var models []*types.Model
+	if s.cfg.ProbeModels {
+		models, err = q.Model.WithContext(ctx).Preload(q.Model.APIKeys).Find()
+		if err != nil {
+			return nil, fmt.Errorf("查询模型失败：%w", err)
+		}
+	}
+
+	byPlatform := make(map[uint]*platformTarget, len(platforms))
+	targets := make([]*platformTarget, 0, len(platforms))
+	for _, platform := range platforms {
+		target := &platformTarget{platform: platform, endpoint: probeEndpoint(platform.Endpoints)}
+		byPlatform[platform.ID] = target
+		targets = append(targets, target)
+	}
+	for _, key := range keys {
+		if target, ok := byPlatform[key.PlatformID]; ok {
+			target.keys = append(target.keys, key)
+		}
+	}
+	for _, model := range models {
+		if target, ok := byPlatform[model.PlatformID]; ok {
+			target.models = append(target.models, model)
+		}
+	}
+
+	result := make([]platformTarget, 0, len(targets))
+	for _, target := range targets {
+		result = append(result, *target)
+	}
+	return result, nil
+}
+
+// probeEndpoint 选择用于探测的端点：优先默认端点，否则取第一个
+func probeEndpoint(endpoints []types.Endpoint) *types.Endpoint {
+	for i := range endpoints {
+		if endpoints[i].IsDefault {
+			return &endpoints[i]
+		}
+	}
+	if len(endpoints) > 0 {
+		return &endpoints[0]
+	}
+	return nil
+}
+
+// probePlatform 探测一个平台下的密钥与模型，并根据密钥探测结果推断平台健康状况
+func (s *service) probePlatform(ctx context.Context, target platformTarget) Result {
+	var result Result
+	platform := target.platform
+	logger := s.logger.With("platform_id", platform.ID)
+
+	if target.endpoint == nil || !supportsEndpoint(target.endpoint.EndpointType) {
+		logger.Debug("平台没有支持探测的端点，跳过")
+		result.Platforms.Skipped++
+		return result
+	}
+	if s.manuallyDisabled(types.ResourceTypePlatform, platform.ID) {
+		logger.Debug("平台已手动禁用，跳过")
+		result.Platforms.Skipped++
+		return result
+	}
+
+	var anySuccess bool
+	var platformFailure *probeResult
+	for _, key := range target.keys {
+		if ctx.Err() != nil {
+			return result
+		}
+		if s.manuallyDisabled(types.ResourceTypeAPIKey, key.ID) {
+			result.Keys.Skipped++
+			continue
+		}
+
+		probed := s.probeKey(ctx, platform, target.endpoint, key)
+		s.recorder.RecordRateLimit(key.ID, probed.rateLimit)
+		s.record(types.ResourceTypeAPIKey, key.ID, probed, &result.Keys)
+
+		switch probed.verdict {
+		case verdictSuccess:
+			anySuccess = true
+		case verdictFailure:
+			if probed.platformFailure && platformFailure == nil {
+				platformFailure = &probed
+			}
+		}
+	}
+
+	// 任一密钥成功说明平台可达；所有失败均为平台级故障时标记平台失败
+	switch {
+	case anySuccess:
+		s.record(types.ResourceTypePlatform, platform.ID, probeResult{verdict: verdictSuccess}, &result.Platforms)
+	case platformFailure != nil && s.allPlatformFailures(target.keys):
+		s.record(types.ResourceTypePlatform, platform.ID, *platformFailure, &result.Platforms)
+	default:
+		result.Platforms.Skipped++
+	}
+
+	for _, model := range target.models {
+		if ctx.Err() != nil {
+			return result
+		}
+		s.probeModel(ctx, platform, target.endpoint, model, &result.Models)
+	}
+
+	return result
+}
+
+// allPlatformFailures 判断平台的最近密钥探测是否全部为平台级故障
+//
+// 仅在存在平台级失败时调用，依据刚写入的健康记录判断，避免单个密钥网络抖动拖垮整个平台。
+func (s *service) allPlatformFailures(keys []*types.APIKey) bool {
+	for _, key := range keys {
+		h, err := s.recorder.Get(types.ResourceTypeAPIKey, key.ID)
+		if err != nil || h == nil || health.IsManuallyDisabled(h) {
+			continue
+		}
+		if h.Status == types.HealthStatusAvailable {
+			return false
+		}
+		if h.LastHTTPStatus != nil && *h.LastHTTPStatus < http.StatusInternalServerError {
+			return false
+		}
+	}
+	return true
+}
+
+// probeKey 以列出模型请求探测密钥
+func (s *service) probeKey(ctx context.Context, platform *types.Platform, endpoint *types.Endpoint, key *types.APIKey) probeResult {
+	req, err := buildListModelsRequest(ctx, platform, endpoint, key.Value)
+	if err != nil {
+		return probeResult{verdict: verdictSkip}
+	}
+	return s.do(req, classifyKeyResponse)
+}
+
+// probeModel 以 1 token 补全请求探测模型，使用模型关联的第一个非不可用且组合未在退避中的密钥
+//
+// 403 与 404 仅说明所用密钥无权使用或无法访问该模型，失败写入密钥-模型组合；模型状态仅由 5xx 等模型级故障改变。
+func (s *service) probeModel(ctx context.Context, platform *types.Platform, endpoint *types.Endpoint, model *types.Model, counts *Counts) {
+	if s.manuallyDisabled(types.ResourceTypeModel, model.ID) {
+		counts.Skipped++
+		return
+	}
+
+	var key *types.APIKey
+	for i := range model.APIKeys {
+		h, err := s.recorder.Get(types.ResourceTypeAPIKey, model.APIKeys[i].ID)
+		if err != nil || (h != nil && h.Status == types.HealthStatusUnavailable) {
+			continue
+		}
+		if !s.recorder.IsKeyModelAvailable(model.APIKeys[i].ID, model.ID) {
+			continue
+		}
+		key = &model.APIKeys[i]
+		break
+	}
+	if key == nil {
+		counts.Skipped++
+		return
+	}
+
+	req, err := buildCompletionRequest(ctx, platform, endpoint, key.Value, model.Name)
+	if err != nil {
+		counts.Skipped++
+		return
+	}
+	probed := s.do(req, classifyModelResponse)
+	s.recorder.RecordRateLimit(key.ID, probed.rateLimit)
+	if probed.rateLimited {
+		// 限流归因于密钥：按上游给出的重试时间退避密钥，模型状态不变
+		var keyCounts Counts
+		s.record(types.ResourceTypeAPIKey, key.ID, probed, &keyCounts)
+		counts.Skipped++
+		return
+	}
+
+	pairID := types.KeyModelResourceID(key.ID, model.ID)
+	if probed.verdict == verdictFailure && !probed.platformFailure {
+		s.record(types.ResourceTypeKeyModel, pairID, probed, counts)
+		return
+	}
+	if probed.verdict == verdictSuccess {
+		// 组合曾因 403/404 退避时，本次成功使其按半开规则恢复
+		if h, err := s.recorder.Get(types.ResourceTypeKeyModel, pairID); err == nil && h != nil {
+			var pairCounts Counts
+			s.record(types.ResourceTypeKeyModel, pairID, probed, &pairCounts)
+		}
+	}
+	s.record(types.ResourceTypeModel, model.ID, probed, counts)
+}
+
+// do 发送探测请求并按分类函数得出结论
+func (s *service) do(req *http.Request, classify func(status int) verdict) probeResult {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if req.Context().Err() != nil {
+			return probeResult{verdict: verdictSkip}
+		}
+		return probeResult{
+			verdict: verdictFailure,
+			outcome: health.Outcome{
+				Message:   fmt.Sprintf("主动探测请求失败：%v", err),
+				ErrorCode: "probe_network_error",
+				ErrorFrom: "probe",
+			},
+			platformFailure: true,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseDrainSize))
+	rateLimit := health.ParseRateLimitHeaders(resp.Header, time.Now())
+
+	status := resp.StatusCode
+	var retryAt *time.Time
+	v := classify(status)
+	if status == http.StatusTooManyRequests {
+		// 限流本身不能说明资源状况，仅在上游给出重试时间时按其退避
+		if retryAt = rateLimit.WaitUntil(); retryAt != nil {
+			v = verdictFailure
+		}
+	}
+	if v != verdictFailure {
+		return probeResult{verdict: v, rateLimit: rateLimit}
+	}
+
+	return probeResult{
+		verdict: verdictFailure,
+		outcome: health.Outcome{
+			Message:    fmt.Sprintf("主动探测返回 HTTP %d：%s", status, snippet(body)),
+			ErrorCode:  fmt.Sprintf("probe_http_%d", status),
+			HTTPStatus: &status,
+			ErrorFrom:  "probe",
+			RetryAt:    retryAt,
+		},
+		platformFailure: status >= http.StatusInternalServerError,
+		rateLimited:     retryAt != nil,
+		rateLimit:       rateLimit,
+	}
+}
+
+// classifyKeyResponse 判断列出模型请求的结论
+//
+// 401/403 说明密钥无效，5xx 说明平台故障；其余 4xx（如上游不支持列出模型、限流）不能说明密钥状况。
+func classifyKeyResponse(status int) verdict {
+	switch {
+	case status >= 200 && status < 300:
+		return verdictSuccess
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return verdictFailure
+	case status >= http.StatusInternalServerError:
+		return verdictFailure
+	default:
+		return verdictSkip
+	}
+}
+
+// classifyModelResponse 判断补全探测请求的结论
+//
+// 403/404 说明所用密钥无法访问该模型，5xx 说明模型不可用；401 与限流归因于密钥，不写入模型状态。
+func classifyModelResponse(status int) verdict {
+	switch {
+	case status >= 200 && status < 300:
+		return verdictSuccess
+	case status == http.StatusForbidden, status == http.StatusNotFound:
+		return verdictFailure
+	case status >= http.StatusInternalServerError:
+		return verdictFailure
+	default:
+		return verdictSkip
+	}
+}
+
+// record 将探测结论写入健康存储并累加计数
+func (s *service) record(resourceType types.ResourceType, resourceID uint, probed probeResult, counts *Counts) {
+	if probed.verdict == verdictSkip {
+		counts.Skipped++
+		return
+	}
+
+	outcome := probed.outcome
+	outcome.Success = probed.verdict == verdictSuccess
+	if _, err := s.recorder.RecordOutcome(resourceType, resourceID, outcome); err != nil {
+		s.logger.Warn("写入探测结果失败",
+			"resource_type", resourceType,
+			"resource_id", resourceID,
+			"error", err,
+		)
+		counts.Skipped++
+		return
+	}
+
+	if outcome.Success {
+		counts.Succeeded++
+	} else {
+		counts.Failed++
+	}
+}
+
+// manuallyDisabled 判断资源是否已手动禁用
+func (s *service) manuallyDisabled(resourceType types.ResourceType, resourceID uint) bool {
+	h, err := s.recorder.Get(resourceType, resourceID)
+	return err == nil && health.IsManuallyDisabled(h)
+}
+
+// snippet 截取响应体开头作为失败原因
+func snippet(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	runes := []rune(text)
+	if len(runes) > responseSnippetSize {
+		return string(runes[:responseSnippetSize]) + "..."
+	}
+	return text
+}
@@ -0,0 +1,178 @@
+package probe
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/health"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestStorage(t *testing.T) (*health.Storage, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&types.Platform{}, &types.Endpoint{}, &types.Model{}, &types.APIKey{}, &types.Health{}, &types.HealthPolicy{}, &types.HealthTransition{}); err != nil {
+		t.Fatalf("迁移测试表失败: %v", err)
+	}
+	query.SetDefault(db)
+
+	storage, err := health.NewStorage(context.Background(), slog.Default())
+	if err != nil {
+		t.Fatalf("创建健康存储失败: %v", err)
+	}
+	return storage, db
+}
+
+// newUpstream 创建按 Authorization 头返回状态码的模拟上游
+func newUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			if r.URL.Path == "/v1/chat/completions" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"model not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid api key"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func seedPlatform(t *testing.T, db *gorm.DB, baseURL string) (types.Platform, types.APIKey, types.APIKey) {
+	t.Helper()
+
+	platform := types.Platform{
+		Name:      "test",
+		BaseURL:   baseURL,
+		Endpoints: []types.Endpoint{{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true}},
+	}
+	if err := db.Create(&platform).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+	good := types.APIKey{PlatformID: platform.ID, Value: "good"}
+	bad := types.APIKey{PlatformID: platform.ID, Value: "bad"}
+	if err := db.Create(&good).Error; err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	if err := db.Create(&bad).Error; err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	return platform, good, bad
+}
+
+func TestRunOnce_按密钥探测结果写入健康状态(t *testing.T) {
+	storage, db := newTestStorage(t)
+	platform, good, bad := seedPlatform(t, db, newUpstream(t).URL)
+
+	model := types.Model{PlatformID: platform.ID, Name: "missing-model", APIKeys: []types.APIKey{good}}
+	if err := db.Create(&model).Error; err != nil {
+		t.Fatalf("创建模型失败: %v", err)
+	}
+
+	svc := New(Config{ProbeModels: true, Timeout: 5 * time.Second}, storage, slog.Default())
+	result, err := svc.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("执行探测失败: %v", err)
+	}
+
+	if result.Keys.Succeeded != 1 || result.Keys.Failed != 1 {
+		t.Fatalf("密钥探测计数不符: %+v", result.Keys)
+	}
+	if result.Platforms.Succeeded != 1 {
+		t.Fatalf("平台探测计数不符: %+v", result.Platforms)
+	}
+	if result.Models.Failed != 1 {
+		t.Fatalf("模型探测计数不符: %+v", result.Models)
+	}
+
+	goodHealth, _ := storage.Get(types.ResourceTypeAPIKey, good.ID)
+	if goodHealth == nil || goodHealth.Status != types.HealthStatusAvailable {
+		t.Fatalf("有效密钥应为可用: %+v", goodHealth)
+	}
+	badHealth, _ := storage.Get(types.ResourceTypeAPIKey, bad.ID)
+	if badHealth == nil || badHealth.Status != types.HealthStatusUnavailable || badHealth.DisabledReason != health.DisabledReasonInvalidKey {
+		t.Fatalf("无效密钥应被永久禁用: %+v", badHealth)
+	}
+	if badHealth.LastHTTPStatus == nil || *badHealth.LastHTTPStatus != http.StatusUnauthorized {
+		t.Fatalf("无效密钥应记录 401: %+v", badHealth.LastHTTPStatus)
+	}
+	modelHealth, _ := storage.Get(types.ResourceTypeModel, model.ID)
+	if modelHealth != nil && modelHealth.Status != types.HealthStatusAvailable && modelHealth.Status != types.HealthStatusUnknown {
+		t.Fatalf("单个密钥返回 404 不应改变模型状态: %+v", modelHealth)
+	}
+	pairHealth, _ := storage.Get(types.ResourceTypeKeyModel, types.KeyModelResourceID(good.ID, model.ID))
+	if pairHealth == nil || pairHealth.Status != types.HealthStatusWarning {
+		t.Fatalf("返回 404 的密钥-模型组合应进入退避: %+v", pairHealth)
+	}
+	if storage.IsKeyModelAvailable(good.ID, model.ID) {
+		t.Fatalf("退避中的密钥-模型组合不应参与路由")
+	}
+}
+
+func TestRunOnce_跳过手动禁用的资源(t *testing.T) {
+	storage, db := newTestStorage(t)
+	_, _, bad := seedPlatform(t, db, newUpstream(t).URL)
+
+	disabled := &types.Health{
+		ResourceType: types.ResourceTypeAPIKey,
+		ResourceID:   bad.ID,
+		Status:       types.HealthStatusUnavailable,
+	}
+	if err := storage.Set(disabled); err != nil {
+		t.Fatalf("写入手动禁用状态失败: %v", err)
+	}
+
+	svc := New(Config{}, storage, slog.Default())
+	result, err := svc.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("执行探测失败: %v", err)
+	}
+	if result.Keys.Skipped != 1 || result.Keys.Succeeded != 1 || result.Keys.Failed != 0 {
+		t.Fatalf("密钥探测计数不符: %+v", result.Keys)
+	}
+
+	badHealth, _ := storage.Get(types.ResourceTypeAPIKey, bad.ID)
+	if !health.IsManuallyDisabled(badHealth) || badHealth.ErrorCount != 0 {
+		t.Fatalf("手动禁用状态不应被探测覆盖: %+v", badHealth)
+	}
+}
+
+func TestRunOnce_上游不可达时标记平台失败(t *testing.T) {
+	storage, db := newTestStorage(t)
+	server := newUpstream(t)
+	baseURL := server.URL
+	server.Close()
+	platform, _, _ := seedPlatform(t, db, baseURL)
+
+	svc := New(Config{Timeout: time.Second}, storage, slog.Default())
+	result, err := svc.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("执行探测失败: %v", err)
+	}
+	if result.Platforms.Failed != 1 || result.Keys.Failed != 2 {
+		t.Fatalf("探测计数不符: %+v", result)
+	}
+
+	platformHealth, _ := storage.Get(types.ResourceTypePlatform, platform.ID)
+	if platformHealth == nil || platformHealth.LastStructuredErrorCode != "probe_network_error" {
+		t.Fatalf("平台应记录网络错误: %+v", platformHealth)
+	}
+}
@@ -0,0 +1,28 @@
+package probe
+
+// Counts 定义一类资源的探测计数
+type Counts struct {
+	Succeeded int `json:"succeeded"` // 探测成功并写入的数量
+	Failed    int `json:"failed"`    // 探测失败并写入的数量
+	Skipped   int `json:"skipped"`   // 跳过或结果不确定的数量
+}
+
+// Result 定义一轮探测的结果汇总
+type Result struct {
+	Platforms Counts `json:"platforms"` // 平台
+	Keys      Counts `json:"keys"`      // 密钥
+	Models    Counts `json:"models"`    // 模型
+}
+
+// merge 累加另一份结果
+func (r *Result) merge(other Result) {
+	r.Platforms.add(other.Platforms)
+	r.Keys.add(other.Keys)
+	r.Models.add(other.Models)
+}
+
+func (c *Counts) add(other Counts) {
+	c.Succeeded += other.Succeeded
+	c.Failed += other.Failed
+	c.Skipped += other.Skipped
+}
@@ -0,0 +1,727 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/MeowSalty/pinai/database"
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	configActionCreate = "create"
+	configActionUpdate = "update"
+	configActionDelete = "delete"
+)
+
+// ExportConfig 导出全部平台及其端点、模型、密钥与关联关系
+func (s *service) ExportConfig(ctx context.Context, includeSecrets bool) (*ConfigDocument, error) {
+	logger := s.logger.With(slog.String("operation", "config_export"))
+	logger.Debug("开始导出配置", slog.Bool("include_secrets", includeSecrets))
+
+	state, err := loadConfigState(ctx)
+	if err != nil {
+		logger.Error("导出配置失败", slog.Any("error", err))
+		return nil, fmt.Errorf("导出配置失败：%w", err)
+	}
+
+	doc := &ConfigDocument{
+		Version:        ConfigDocumentVersion,
+		IncludeSecrets: includeSecrets,
+		Platforms:      make([]PlatformConfig, 0, len(state.platforms)),
+	}
+	for _, platform := range state.platforms {
+		pc := PlatformConfig{
+			Name:      platform.Name,
+			BaseURL:   platform.BaseURL,
+			RateLimit: platform.RateLimit,
+			Endpoints: make([]EndpointConfig, 0, len(platform.Endpoints)),
+			Models:    make([]ModelConfig, 0, len(state.models[platform.ID])),
+		}
+		for _, endpoint := range platform.Endpoints {
+			pc.Endpoints = append(pc.Endpoints, EndpointConfig{
+				EndpointType:    endpoint.EndpointType,
+				EndpointVariant: endpoint.EndpointVariant,
+				Path:            endpoint.Path,
+				CustomHeaders:   endpoint.CustomHeaders,
+				IsDefault:       endpoint.IsDefault,
+			})
+		}
+
+		refs := make(map[uint]string)
+		if includeSecrets {
+			pc.Keys = make([]KeyConfig, 0, len(state.keys[platform.ID]))
+			for i, key := range state.keys[platform.ID] {
+				ref := fmt.Sprintf("key-%d", i+1)
+				refs[key.ID] = ref
+				pc.Keys = append(pc.Keys, KeyConfig{Ref: ref, Value: key.Value})
+			}
+		}
+
+		for _, model := range state.models[platform.ID] {
+			mc := ModelConfig{Name: model.Name, Alias: model.Alias}
+			for _, key := range model.APIKeys {
+				if ref, ok := refs[key.ID]; ok {
+					mc.Keys = append(mc.Keys, ref)
+				}
+			}
+			pc.Models = append(pc.Models, mc)
+		}
+		doc.Platforms = append(doc.Platforms, pc)
+	}
+
+	logger.Info("成功导出配置", slog.Int("platform_count", len(doc.Platforms)))
+	return doc, nil
+}
+
+// ImportConfig 按配置文档同步平台配置
+//
+// 文档视为完整配置：文档中不存在的平台、端点、模型（以及包含密钥时的密钥）会被删除。
+// dryRun 为 true 时仅返回变更列表，否则在控制面事务中一次性应用；涉及声明式配置文件管理的平台变更时返回错误。
+func (s *service) ImportConfig(ctx context.Context, doc ConfigDocument, dryRun bool) (*ConfigImportResult, error) {
+	logger := s.logger.With(slog.String("operation", "config_import"), slog.Bool("dry_run", dryRun))
+	logger.Debug("开始导入配置", slog.Int("platform_count", len(doc.Platforms)))
+
+	if err := validateConfigDocument(doc); err != nil {
+		logger.Warn("配置文档校验失败", slog.Any("error", err))
+		return nil, err
+	}
+
+	result := &ConfigImportResult{DryRun: dryRun}
+	if dryRun {
+		r := newConfigReconciler(doc, false, "", nil)
+		if err := r.reconcile(ctx, doc); err != nil {
+			logger.Error("计算配置变更失败", slog.Any("error", err))
+			return nil, fmt.Errorf("计算配置变更失败：%w", err)
+		}
+		result.setChanges(r.changes)
+		return result, nil
+	}
+
+	changes, err := s.applyConfig(ctx, doc, "")
+	if err != nil {
+		logger.Error("导入配置失败", slog.Any("error", err))
+		_ = s.logConfigImportAudit(ctx, "failed", fmt.Sprintf("导入配置失败：%v", err))
+		return nil, fmt.Errorf("导入配置失败：%w", err)
+	}
+
+	result.Applied = true
+	result.setChanges(changes)
+	logger.Info("成功导入配置",
+		slog.Int("created", result.Created),
+		slog.Int("updated", result.Updated),
+		slog.Int("deleted", result.Deleted))
+	_ = s.logConfigImportAudit(ctx, "success", fmt.Sprintf("导入配置成功：新增 %d 项，更新 %d 项，删除 %d 项", result.Created, result.Updated, result.Deleted))
+	return result, nil
+}
+
+// applyConfig 在控制面事务中应用配置文档，并为删除的平台写入与 DeletePlatform 相同的审计记录
+func (s *service) applyConfig(ctx context.Context, doc ConfigDocument, managedBy string) ([]ConfigChange, error) {
+	if s.controlTx == nil {
+		return nil, fmt.Errorf("事务执行器未初始化")
+	}
+	if s.platformControlRepo == nil {
+		return nil, fmt.Errorf("平台控制仓储未初始化")
+	}
+
+	r := newConfigReconciler(doc, true, managedBy, s.platformControlRepo)
+	err := s.controlTx.WithinTx(ctx, func(txCtx context.Context) error {
+		return r.reconcile(txCtx, doc)
+	})
+	if err != nil {
+		if r.failedPlatform != nil {
+			_ = s.logPlatformControlAudit(ctx, r.failedPlatform.ID, "platform.delete", "failed", fmt.Sprintf("删除平台失败：%v", err), nil, nil)
+		}
+		return nil, err
+	}
+
+	for i := range r.deletedPlatforms {
+		platform := &r.deletedPlatforms[i]
+		_ = s.logPlatformControlAudit(ctx, platform.ID, "platform.delete", "success", "删除平台成功", platform, nil)
+	}
+	return r.changes, nil
+}
+
+func (s *service) logConfigImportAudit(ctx context.Context, result, detail string) error {
+	if s.controlAudit == nil {
+		return nil
+	}
+
+	return s.controlAudit.Log(ctx, ControlAuditEvent{
+		Action:   "config.import",
+		Resource: "config",
+		Result:   result,
+		Detail:   detail,
+	})
+}
+
+// setChanges 写入变更列表并按动作统计
+func (r *ConfigImportResult) setChanges(changes []ConfigChange) {
+	r.Changes = changes
+	if r.Changes == nil {
+		r.Changes = []ConfigChange{}
+	}
+	for _, change := range changes {
+		switch change.Action {
+		case configActionCreate:
+			r.Created++
+		case configActionUpdate:
+			r.Updated++
+		case configActionDelete:
+			r.Deleted++
+		}
+	}
+}
+
+// validateConfigDocument 校验配置文档
+func validateConfigDocument(doc ConfigDocument) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%s：%w", fmt.Sprintf(format, args...), ErrInvalidArgument)
+	}
+
+	if doc.Version != ConfigDocumentVersion {
+		return invalid("不支持的配置文档版本 %d", doc.Version)
+	}
+
+	platformNames := make(map[string]struct{}, len(doc.Platforms))
+	for _, pc := range doc.Platforms {
+		if strings.TrimSpace(pc.Name) == "" {
+			return invalid("平台名称不能为空")
+		}
+		if _, ok := platformNames[pc.Name]; ok {
+			return invalid("平台名称 %q 重复", pc.Name)
+		}
+		platformNames[pc.Name] = struct{}{}
+
+		endpoints := make(map[string]struct{}, len(pc.Endpoints))
+		defaults := 0
+		for _, ec := range pc.Endpoints {
+			if strings.TrimSpace(ec.EndpointType) == "" {
+				return invalid("平台 %q 的端点类型不能为空", pc.Name)
+			}
+			name := endpointConfigName(ec)
+			if _, ok := endpoints[name]; ok {
+				return invalid("平台 %q 的端点 %q 重复", pc.Name, name)
+			}
+			endpoints[name] = struct{}{}
+			if ec.IsDefault {
+				defaults++
+			}
+		}
+		if defaults > 1 {
+			return invalid("平台 %q 存在多个默认端点", pc.Name)
+		}
+
+		if !doc.IncludeSecrets {
+			if len(pc.Keys) > 0 {
+				return invalid("平台 %q 包含密钥，但文档未声明 include_secrets", pc.Name)
+			}
+		}
+		refs := make(map[string]struct{}, len(pc.Keys))
+		values := make(map[string]struct{}, len(pc.Keys))
+		for _, kc := range pc.Keys {
+			if kc.ValueEnv != "" {
+				return invalid("平台 %q 的密钥 %q 使用了 value_env，仅声明式配置文件支持从环境变量读取密钥", pc.Name, kc.Ref)
+			}
+			if kc.Ref == "" || kc.Value == "" {
+				return invalid("平台 %q 的密钥引用名与密钥值不能为空", pc.Name)
+			}
+			if _, ok := refs[kc.Ref]; ok {
+				return invalid("平台 %q 的密钥引用名 %q 重复", pc.Name, kc.Ref)
+			}
+			if _, ok := values[kc.Value]; ok {
+				return invalid("平台 %q 的密钥 %q 与其他密钥重复", pc.Name, kc.Ref)
+			}
+			refs[kc.Ref] = struct{}{}
+			values[kc.Value] = struct{}{}
+		}
+
+		for _, mc := range pc.Models {
+			if strings.TrimSpace(mc.Name) == "" {
+				return invalid("平台 %q 的模型名称不能为空", pc.Name)
+			}
+			for _, ref := range mc.Keys {
+				if _, ok := refs[ref]; !ok {
+					return invalid("平台 %q 的模型 %q 引用了不存在的密钥 %q", pc.Name, mc.Name, ref)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// configState 为导出与导入时读取的当前配置
+type configState struct {
+	platforms []types.Platform
+	keys      map[uint][]types.APIKey // 平台 ID -> 密钥
+	models    map[uint][]types.Model  // 平台 ID -> 模型（含关联密钥）
+}
+
+// configDB 返回不带 gorm-gen 作用域的数据库会话，位于控制面事务中时使用事务会话
+func configDB(ctx context.Context) *gorm.DB {
+	return database.CleanSession(ctx, queryFromContextOrDefault(ctx).Platform.WithContext(ctx).UnderlyingDB())
+}
+
+// loadConfigState 读取全部平台、端点、密钥与模型
+func loadConfigState(ctx context.Context) (*configState, error) {
+	db := configDB(ctx)
+	state := &configState{
+		keys:   make(map[uint][]types.APIKey),
+		models: make(map[uint][]types.Model),
+	}
+
+	orderByID := func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }
+	if err := db.Preload("Endpoints", orderByID).Order("id").Find(&state.platforms).Error; err != nil {
+		return nil, fmt.Errorf("查询平台失败：%w", err)
+	}
+
+	var keys []types.APIKey
+	if err := db.Order("id").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("查询密钥失败：%w", err)
+	}
+	for _, key := range keys {
+		state.keys[key.PlatformID] = append(state.keys[key.PlatformID], key)
+	}
+
+	var models []types.Model
+	if err := db.Preload("APIKeys", orderByID).Order("id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("查询模型失败：%w", err)
+	}
+	for _, model := range models {
+		state.models[model.PlatformID] = append(state.models[model.PlatformID], model)
+	}
+
+	return state, nil
+}
+
+// configReconciler 对比配置文档与当前配置，apply 为 true 时同时写入数据库
+//
+// managedBy 为空时为接口导入，不得改动任何受管平台；非空时仅删除同一来源管理的平台，并接管文档中的同名平台。
+type configReconciler struct {
+	db             *gorm.DB
+	platforms      PlatformControlRepository
+	apply          bool
+	includeSecrets bool
+	managedBy      string
+	changes        []ConfigChange
+
+	deletedPlatforms []types.Platform // 已删除的平台，事务提交后写入审计
+	failedPlatform   *types.Platform  // 删除失败的平台
+}
+
+// newConfigReconciler 创建配置对比器，apply 为 true 时 platforms 不能为空
+func newConfigReconciler(doc ConfigDocument, apply bool, managedBy string, platforms PlatformControlRepository) *configReconciler {
+	return &configReconciler{platforms: platforms, apply: apply, includeSecrets: doc.IncludeSecrets, managedBy: managedBy}
+}
+
+// reconcile 计算并（可选）应用配置文档与当前配置的差异
+func (r *configReconciler) reconcile(ctx context.Context, doc ConfigDocument) error {
+	state, err := loadConfigState(ctx)
+	if err != nil {
+		return err
+	}
+	r.db = configDB(ctx)
+
+	byName := make(map[string][]*types.Platform, len(state.platforms))
+	for i := range state.platforms {
+		platform := &state.platforms[i]
+		byName[platform.Name] = append(byName[platform.Name], platform)
+	}
+
+	kept := make(map[uint]struct{}, len(doc.Platforms))
+	for _, pc := range doc.Platforms {
+		matches := byName[pc.Name]
+		if len(matches) > 1 {
+			return fmt.Errorf("现有配置中存在多个名为 %q 的平台：%w", pc.Name, ErrInvalidArgument)
+		}
+
+		var current *types.Platform
+		if len(matches) == 1 {
+			current = matches[0]
+			kept[current.ID] = struct{}{}
+		}
+		before := len(r.changes)
+		if err := r.reconcilePlatform(pc, current, state); err != nil {
+			return err
+		}
+		if r.managedBy == "" && current != nil && current.ManagedBy != "" && len(r.changes) > before {
+			return fmt.Errorf("平台 %q 由 %s 管理：%w", current.Name, current.ManagedBy, ErrManagedResource)
+		}
+	}
+
+	for _, platform := range state.platforms {
+		if _, ok := kept[platform.ID]; ok {
+			continue
+		}
+		if platform.ManagedBy != r.managedBy {
+			if r.managedBy == "" {
+				return fmt.Errorf("文档缺少平台 %q，该平台由 %s 管理：%w", platform.Name, platform.ManagedBy, ErrManagedResource)
+			}
+			continue
+		}
+		if err := r.deletePlatform(ctx, platform); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *configReconciler) record(action, resource, platform, name string, fields []string) {
+	r.changes = append(r.changes, ConfigChange{
+		Action:   action,
+		Resource: resource,
+		Platform: platform,
+		Name:     name,
+		Fields:   fields,
+	})
+}
+
+func (r *configReconciler) reconcilePlatform(pc PlatformConfig, current *types.Platform, state *configState) error {
+	var platformID uint
+	if current == nil {
+		r.record(configActionCreate, "platform", pc.Name, pc.Name, nil)
+		if r.apply {
+			platform := types.Platform{Name: pc.Name, BaseURL: pc.BaseURL, RateLimit: pc.RateLimit, ManagedBy: r.managedBy}
+			if err := r.db.Omit(clause.Associations).Create(&platform).Error; err != nil {
+				return fmt.Errorf("创建平台 %q 失败：%w", pc.Name, err)
+			}
+			platformID = platform.ID
+		}
+	} else {
+		platformID = current.ID
+		var fields []string
+		if current.BaseURL != pc.BaseURL {
+			fields = append(fields, "base_url")
+		}
+		if current.RateLimit != pc.RateLimit {
+			fields = append(fields, "rate_limit")
+		}
+		if r.managedBy != "" && current.ManagedBy != r.managedBy {
+			fields = append(fields, "managed_by")
+		}
+		if len(fields) > 0 {
+			r.record(configActionUpdate, "platform", pc.Name, pc.Name, fields)
+			if r.apply {
+				updates := types.Platform{BaseURL: pc.BaseURL, RateLimit: pc.RateLimit, ManagedBy: r.managedBy}
+				if err := r.db.Model(&types.Platform{ID: platformID}).Select(fields).Updates(&updates).Error; err != nil {
+					return fmt.Errorf("更新平台 %q 失败：%w", pc.Name, err)
+				}
+			}
+		}
+	}
+
+	var (
+		endpoints []types.Endpoint
+		keys      []types.APIKey
+		models    []types.Model
+	)
+	if current != nil {
+		endpoints = current.Endpoints
+		keys = state.keys[current.ID]
+		models = state.models[current.ID]
+	}
+
+	if err := r.reconcileEndpoints(pc, platformID, endpoints); err != nil {
+		return err
+	}
+	keyIDs, err := r.reconcileKeys(pc, platformID, keys)
+	if err != nil {
+		return err
+	}
+	return r.reconcileModels(pc, platformID, models, keyIDs)
+}
+
+// endpointConfigName 返回端点在文档中的标识
+func endpointConfigName(ec EndpointConfig) string {
+	if ec.EndpointVariant == "" {
+		return ec.EndpointType
+	}
+	return ec.EndpointType + "/" + ec.EndpointVariant
+}
+
+func (r *configReconciler) reconcileEndpoints(pc PlatformConfig, platformID uint, current []types.Endpoint) error {
+	byName := make(map[string]types.Endpoint, len(current))
+	for _, endpoint := range current {
+		name := endpointConfigName(EndpointConfig{EndpointType: endpoint.EndpointType, EndpointVariant: endpoint.EndpointVariant})
+		if _, ok := byName[name]; !ok {
+			byName[name] = endpoint
+		}
+	}
+
+	kept := make(map[uint]struct{}, len(pc.Endpoints))
+	for _, ec := range pc.Endpoints {
+		name := endpointConfigName(ec)
+		existing, ok := byName[name]
+		if !ok {
+			r.record(configActionCreate, "endpoint", pc.Name, name, nil)
+			if r.apply {
+				endpoint := types.Endpoint{
+					PlatformID:      platformID,
+					EndpointType:    ec.EndpointType,
+					EndpointVariant: ec.EndpointVariant,
+					Path:            ec.Path,
+					CustomHeaders:   ec.CustomHeaders,
+					IsDefault:       ec.IsDefault,
+				}
+				if err := r.db.Create(&endpoint).Error; err != nil {
+					return fmt.Errorf("创建平台 %q 的端点 %q 失败：%w", pc.Name, name, err)
+				}
+			}
+			continue
+		}
+
+		kept[existing.ID] = struct{}{}
+		var fields []string
+		if existing.Path != ec.Path {
+			fields = append(fields, "path")
+		}
+		if !maps.Equal(existing.CustomHeaders, ec.CustomHeaders) {
+			fields = append(fields, "custom_headers")
+		}
+		if existing.IsDefault != ec.IsDefault {
+			fields = append(fields, "is_default")
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		r.record(configActionUpdate, "endpoint", pc.Name, name, fields)
+		if r.apply {
+			updates := types.Endpoint{Path: ec.Path, CustomHeaders: ec.CustomHeaders, IsDefault: ec.IsDefault}
+			if err := r.db.Model(&types.Endpoint{ID: existing.ID}).Select(fields).Updates(&updates).Error; err != nil {
+				return fmt.Errorf("更新平台 %q 的端点 %q 失败：%w", pc.Name, name, err)
+			}
+		}
+	}
+
+	for _, endpoint := range current {
+		if _, ok := kept[endpoint.ID]; ok {
+			continue
+		}
+		name := endpointConfigName(EndpointConfig{EndpointType: endpoint.EndpointType, EndpointVariant: endpoint.EndpointVariant})
+		r.record(configActionDelete, "endpoint", pc.Name, name, nil)
+		if r.apply {
+			if err := r.db.Delete(&types.Endpoint{}, endpoint.ID).Error; err != nil {
+				return fmt.Errorf("删除平台 %q 的端点 %q 失败：%w", pc.Name, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// reconcileKeys 同步平台密钥，返回文档引用名到密钥 ID 的映射；文档不含密钥时返回 nil
+func (r *configReconciler) reconcileKeys(pc PlatformConfig, platformID uint, current []types.APIKey) (map[string]uint, error) {
+	if !r.includeSecrets {
+		return nil, nil
+	}
+
+	byValue := make(map[string]types.APIKey, len(current))
+	for _, key := range current {
+		if _, ok := byValue[key.Value]; !ok {
+			byValue[key.Value] = key
+		}
+	}
+
+	refs := make(map[string]uint, len(pc.Keys))
+	kept := make(map[uint]struct{}, len(pc.Keys))
+	for _, kc := range pc.Keys {
+		if existing, ok := byValue[kc.Value]; ok {
+			refs[kc.Ref] = existing.ID
+			kept[existing.ID] = struct{}{}
+			continue
+		}
+
+		r.record(configActionCreate, "key", pc.Name, kc.Ref, nil)
+		refs[kc.Ref] = 0
+		if r.apply {
+			key := types.APIKey{PlatformID: platformID, Value: kc.Value}
+			if err := r.db.Omit(clause.Associations).Create(&key).Error; err != nil {
+				return nil, fmt.Errorf("创建平台 %q 的密钥 %q 失败：%w", pc.Name, kc.Ref, err)
+			}
+			refs[kc.Ref] = key.ID
+		}
+	}
+
+	for _, key := range current {
+		if _, ok := kept[key.ID]; ok {
+			continue
+		}
+		r.record(configActionDelete, "key", pc.Name, maskKeyValue(key.Value), nil)
+		if r.apply {
+			if err := r.db.Exec("DELETE FROM api_key_models WHERE api_key_id = ?", key.ID).Error; err != nil {
+				return nil, fmt.Errorf("清理密钥 ID 为 %d 与模型的关联关系失败：%w", key.ID, err)
+			}
+			if err := r.db.Delete(&types.APIKey{}, key.ID).Error; err != nil {
+				return nil, fmt.Errorf("删除密钥 ID 为 %d 失败：%w", key.ID, err)
+			}
+		}
+	}
+	return refs, nil
+}
+
+func (r *configReconciler) reconcileModels(pc PlatformConfig, platformID uint, current []types.Model, keyIDs map[string]uint) error {
+	byName := make(map[string][]types.Model, len(current))
+	for _, model := range current {
+		byName[model.Name] = append(byName[model.Name], model)
+	}
+
+	kept := make(map[uint]struct{}, len(pc.Models))
+	for _, mc := range pc.Models {
+		desired := make([]uint, 0, len(mc.Keys))
+		for _, ref := range mc.Keys {
+			desired = append(desired, keyIDs[ref])
+		}
+		slices.Sort(desired)
+		desired = slices.Compact(desired)
+
+		candidates := byName[mc.Name]
+		if len(candidates) == 0 {
+			r.record(configActionCreate, "model", pc.Name, mc.Name, nil)
+			if r.apply {
+				model := types.Model{PlatformID: platformID, Name: mc.Name, Alias: mc.Alias}
+				if err := r.db.Omit(clause.Associations).Create(&model).Error; err != nil {
+					return fmt.Errorf("创建平台 %q 的模型 %q 失败：%w", pc.Name, mc.Name, err)
+				}
+				if err := r.setModelKeys(model.ID, desired); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		existing := candidates[0]
+		byName[mc.Name] = candidates[1:]
+		kept[existing.ID] = struct{}{}
+
+		var fields []string
+		if existing.Alias != mc.Alias {
+			fields = append(fields, "alias")
+		}
+		keysChanged := false
+		if r.includeSecrets {
+			currentKeys := make([]uint, 0, len(existing.APIKeys))
+			for _, key := range existing.APIKeys {
+				currentKeys = append(currentKeys, key.ID)
+			}
+			slices.Sort(currentKeys)
+			if keysChanged = !slices.Equal(currentKeys, desired); keysChanged {
+				fields = append(fields, "keys")
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		r.record(configActionUpdate, "model", pc.Name, mc.Name, fields)
+		if !r.apply {
+			continue
+		}
+		if existing.Alias != mc.Alias {
+			if err := r.db.Model(&types.Model{ID: existing.ID}).Update("alias", mc.Alias).Error; err != nil {
+				return fmt.Errorf("更新平台 %q 的模型 %q 失败：%w", pc.Name, mc.Name, err)
+			}
+		}
+		if keysChanged {
+			if err := r.setModelKeys(existing.ID, desired); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, model := range current {
+		if _, ok := kept[model.ID]; ok {
+			continue
+		}
+		r.record(configActionDelete, "model", pc.Name, model.Name, nil)
+		if r.apply {
+			if err := r.db.Exec("DELETE FROM api_key_models WHERE model_id = ?", model.ID).Error; err != nil {
+				return fmt.Errorf("清理模型 ID 为 %d 与密钥的关联关系失败：%w", model.ID, err)
+			}
+			if err := r.db.Delete(&types.Model{}, model.ID).Error; err != nil {
+				return fmt.Errorf("删除模型 ID 为 %d 失败：%w", model.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setModelKeys 将模型关联的密钥替换为 keyIDs
+func (r *configReconciler) setModelKeys(modelID uint, keyIDs []uint) error {
+	if !r.includeSecrets {
+		return nil
+	}
+	if err := r.db.Exec("DELETE FROM api_key_models WHERE model_id = ?", modelID).Error; err != nil {
+		return fmt.Errorf("清理模型 ID 为 %d 与密钥的关联关系失败：%w", modelID, err)
+	}
+	if len(keyIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]map[string]any, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		rows = append(rows, map[string]any{"api_key_id": keyID, "model_id": modelID})
+	}
+	if err := r.db.Table("api_key_models").Create(&rows).Error; err != nil {
+		return fmt.Errorf("写入模型 ID 为 %d 与密钥的关联关系失败：%w", modelID, err)
+	}
+	return nil
+}
+
+// deletePlatform 删除平台及其端点、模型、密钥与关联关系
+//
+// 模型、密钥与平台本身通过平台控制仓储删除，与 DeletePlatform 一致；
+// 密钥与模型的关联关系直接清理，ClearAPIKeyModelRelations 在事务内无法正常完成（见 DeletePlatform）。
+func (r *configReconciler) deletePlatform(ctx context.Context, platform types.Platform) error {
+	r.record(configActionDelete, "platform", platform.Name, platform.Name, nil)
+	if !r.apply {
+		return nil
+	}
+
+	if err := r.removePlatform(ctx, platform); err != nil {
+		r.failedPlatform = &platform
+		return err
+	}
+	r.deletedPlatforms = append(r.deletedPlatforms, platform)
+	return nil
+}
+
+func (r *configReconciler) removePlatform(ctx context.Context, platform types.Platform) error {
+	if err := r.db.Exec(
+		"DELETE FROM api_key_models WHERE model_id IN (SELECT id FROM models WHERE platform_id = ?) OR api_key_id IN (SELECT id FROM api_keys WHERE platform_id = ?)",
+		platform.ID, platform.ID,
+	).Error; err != nil {
+		return fmt.Errorf("清理平台 %q 的密钥与模型关联关系失败：%w", platform.Name, err)
+	}
+	if _, err := r.platforms.DeleteModelsByPlatform(ctx, platform.ID); err != nil {
+		return fmt.Errorf("删除平台 %q 的模型失败：%w", platform.Name, err)
+	}
+	if _, err := r.platforms.DeleteAPIKeysByPlatform(ctx, platform.ID); err != nil {
+		return fmt.Errorf("删除平台 %q 的密钥失败：%w", platform.Name, err)
+	}
+	if err := r.db.Where("platform_id = ?", platform.ID).Delete(&types.Endpoint{}).Error; err != nil {
+		return fmt.Errorf("删除平台 %q 的端点失败：%w", platform.Name, err)
+	}
+
+	deleted, err := r.platforms.DeletePlatform(ctx, platform.ID)
+	if err != nil {
+		return fmt.Errorf("删除平台 %q 失败：%w", platform.Name, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("平台 %q 已被删除", platform.Name)
+	}
+	return nil
+}
+
+// maskKeyValue 脱敏密钥值，仅保留末 4 位便于辨认
+func maskKeyValue(value string) string {
+	if len(value) <= 8 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
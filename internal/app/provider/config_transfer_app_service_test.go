@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newConfigTransferTestService(t *testing.T) (*service, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&types.Platform{}, &types.Endpoint{}, &types.APIKey{}, &types.Model{}); err != nil {
+		t.Fatalf("迁移表结构失败: %v", err)
+	}
+	query.SetDefault(db)
+
+	return &service{
+		logger:              slog.Default(),
+		controlTx:           NewQueryControlTx(),
+		platformControlRepo: NewPlatformControlQueryRepository(nil, slog.Default()),
+		controlAudit:        &recordingControlAudit{},
+	}, db
+}
+
+// recordingControlAudit 记录写入的控制面审计事件
+type recordingControlAudit struct {
+	events []ControlAuditEvent
+}
+
+func (r *recordingControlAudit) Log(_ context.Context, event ControlAuditEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func seedConfigTransferData(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	platforms := []types.Platform{
+		{Name: "openai", BaseURL: "https://api.openai.com", Endpoints: []types.Endpoint{{EndpointType: "openai", Path: "/v1", IsDefault: true}}},
+		{Name: "legacy", BaseURL: "https://legacy.example.com"},
+	}
+	if err := db.Create(&platforms).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+	key := types.APIKey{PlatformID: platforms[0].ID, Value: "sk-staging-0001"}
+	if err := db.Create(&key).Error; err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	model := types.Model{PlatformID: platforms[0].ID, Name: "gpt-4o", Alias: "gpt4", APIKeys: []types.APIKey{key}}
+	if err := db.Create(&model).Error; err != nil {
+		t.Fatalf("创建模型失败: %v", err)
+	}
+}
+
+func TestImportConfig_预览变更后事务应用且再次导入无变更(t *testing.T) {
+	svc, db := newConfigTransferTestService(t)
+	seedConfigTransferData(t, db)
+	ctx := context.Background()
+
+	doc, err := svc.ExportConfig(ctx, true)
+	if err != nil {
+		t.Fatalf("导出配置失败: %v", err)
+	}
+	if len(doc.Platforms) != 2 || len(doc.Platforms[0].Keys) != 1 || len(doc.Platforms[0].Models[0].Keys) != 1 {
+		t.Fatalf("导出内容不符: %+v", doc)
+	}
+
+	// 修改基础 URL、新增密钥与模型、移除 legacy 平台
+	target := doc.Platforms[0]
+	target.BaseURL = "https://proxy.example.com"
+	target.Keys = append(target.Keys, KeyConfig{Ref: "prod", Value: "sk-prod-0002"})
+	target.Models = append(target.Models, ModelConfig{Name: "o1", Keys: []string{"key-1", "prod"}})
+	doc.Platforms = []PlatformConfig{target}
+
+	preview, err := svc.ImportConfig(ctx, *doc, true)
+	if err != nil {
+		t.Fatalf("预览导入失败: %v", err)
+	}
+	if preview.Applied || preview.Created != 2 || preview.Updated != 1 || preview.Deleted != 1 {
+		t.Fatalf("预览变更统计不符: %+v", preview)
+	}
+	var platformCount int64
+	db.Model(&types.Platform{}).Count(&platformCount)
+	if platformCount != 2 {
+		t.Fatalf("预览不应写入数据库")
+	}
+
+	applied, err := svc.ImportConfig(ctx, *doc, false)
+	if err != nil {
+		t.Fatalf("导入配置失败: %v", err)
+	}
+	if !applied.Applied || len(applied.Changes) != len(preview.Changes) {
+		t.Fatalf("应用结果应与预览一致: %+v", applied)
+	}
+
+	var o1 types.Model
+	if err := db.Preload("APIKeys").Where("name = ?", "o1").First(&o1).Error; err != nil {
+		t.Fatalf("查询新模型失败: %v", err)
+	}
+	if len(o1.APIKeys) != 2 {
+		t.Fatalf("新模型应关联 2 个密钥: %+v", o1.APIKeys)
+	}
+	db.Model(&types.Platform{}).Count(&platformCount)
+	if platformCount != 1 {
+		t.Fatalf("legacy 平台应被删除")
+	}
+
+	var deleteEvents []ControlAuditEvent
+	for _, event := range svc.controlAudit.(*recordingControlAudit).events {
+		if event.Action == "platform.delete" {
+			deleteEvents = append(deleteEvents, event)
+		}
+	}
+	if len(deleteEvents) != 1 || deleteEvents[0].Result != "success" || deleteEvents[0].Before.(*types.Platform).Name != "legacy" {
+		t.Fatalf("删除平台应写入与 DeletePlatform 相同的审计记录: %+v", deleteEvents)
+	}
+
+	again, err := svc.ImportConfig(ctx, *doc, true)
+	if err != nil {
+		t.Fatalf("再次预览失败: %v", err)
+	}
+	if len(again.Changes) != 0 {
+		t.Fatalf("再次导入不应产生变更: %+v", again.Changes)
+	}
+}
+
+func TestImportConfig_不含密钥时保留现有密钥与关联(t *testing.T) {
+	svc, db := newConfigTransferTestService(t)
+	seedConfigTransferData(t, db)
+	ctx := context.Background()
+
+	doc, err := svc.ExportConfig(ctx, false)
+	if err != nil {
+		t.Fatalf("导出配置失败: %v", err)
+	}
+	if doc.Platforms[0].Keys != nil || doc.Platforms[0].Models[0].Keys != nil {
+		t.Fatalf("未包含密钥时不应导出密钥与关联: %+v", doc.Platforms[0])
+	}
+
+	doc.Platforms[0].Models[0].Alias = "gpt-4o-latest"
+	result, err := svc.ImportConfig(ctx, *doc, false)
+	if err != nil {
+		t.Fatalf("导入配置失败: %v", err)
+	}
+	if result.Updated != 1 || result.Changes[0].Fields[0] != "alias" {
+		t.Fatalf("仅应更新模型别名: %+v", result.Changes)
+	}
+
+	var model types.Model
+	if err := db.Preload("APIKeys").Where("name = ?", "gpt-4o").First(&model).Error; err != nil {
+		t.Fatalf("查询模型失败: %v", err)
+	}
+	if model.Alias != "gpt-4o-latest" || len(model.APIKeys) != 1 {
+		t.Fatalf("别名应更新且密钥关联保持不变: %+v", model)
+	}
+}
+
+func TestImportConfig_校验文档(t *testing.T) {
+	svc, _ := newConfigTransferTestService(t)
+
+	cases := []ConfigDocument{
+		{Version: 2},
+		{Version: 1, Platforms: []PlatformConfig{{Name: "a"}, {Name: "a"}}},
+		{Version: 1, Platforms: []PlatformConfig{{Name: "a", Keys: []KeyConfig{{Ref: "k", Value: "v"}}}}},
+		{Version: 1, IncludeSecrets: true, Platforms: []PlatformConfig{{Name: "a", Models: []ModelConfig{{Name: "m", Keys: []string{"missing"}}}}}},
+	}
+	for i, doc := range cases {
+		if _, err := svc.ImportConfig(context.Background(), doc, true); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("用例 %d 应返回参数错误: %v", i, err)
+		}
+	}
+}
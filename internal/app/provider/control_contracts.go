@@ -23,12 +23,16 @@ type ControlTx interface {
 }
 
 // ControlAuditEvent 表示控制面审计事件。
+//
+// Before 与 After 为变更前后的资源快照，失败事件或无法获取时为 nil。
 type ControlAuditEvent struct {
 	Action     string
 	Resource   string
 	ResourceID uint
 	Result     string
 	Detail     string
+	Before     any
+	After      any
 }
 
 // ControlAuditLogger 定义控制面审计记录接口。
@@ -102,10 +106,19 @@ type EndpointControlRepository interface {
 // ModelBatchTaskRepository 定义模型批量异步任务仓储能力。
 type ModelBatchTaskRepository interface {
 	CreateModelBatchTask(ctx context.Context, task *types.ModelBatchTask) error
+	CreatePeriodicModelBatchTask(ctx context.Context, task *types.ModelBatchTask) (bool, error)
 	GetModelBatchTaskByID(ctx context.Context, taskID uint) (*types.ModelBatchTask, error)
 	ListUnfinishedModelBatchTasks(ctx context.Context) ([]*types.ModelBatchTask, error)
-	MarkModelBatchTaskRunning(ctx context.Context, taskID uint, startedAt time.Time) error
-	FinishModelBatchTask(ctx context.Context, taskID uint, status, result, errorMessage string) error
+	ListModelBatchTasks(ctx context.Context, opts ListTasksOptions) ([]*types.ModelBatchTask, int64, error)
+	ClaimModelBatchTask(ctx context.Context, owner string, leaseExpiresAt time.Time, taskTypes []string) (*types.ModelBatchTask, error)
+	RenewModelBatchTaskLease(ctx context.Context, taskID uint, owner string, leaseExpiresAt time.Time) (bool, error)
+	ReleaseModelBatchTask(ctx context.Context, taskID uint, owner string) error
+	UpdateModelBatchTaskProgress(ctx context.Context, taskID uint, owner string, progress int) error
+	FinishModelBatchTask(ctx context.Context, taskID uint, owner, status, result, errorMessage string) error
+	ScheduleModelBatchTaskRetry(ctx context.Context, taskID uint, owner string, nextRunAt time.Time, errorMessage string) error
+	CancelModelBatchTask(ctx context.Context, taskID uint) (string, error)
+	RetryModelBatchTask(ctx context.Context, taskID uint) error
+	DeleteFinishedModelBatchTasks(ctx context.Context, before time.Time, limit int) (int64, error)
 }
 
 type controlTxQueryKey struct{}
@@ -162,6 +175,27 @@ func (noOpControlTx) WithinTx(ctx context.Context, fn func(ctx context.Context)
 	return fn(ctx)
 }
 
+// healthAuditSnapshot 返回资源当前健康状态的审计快照，无法读取时返回 nil。
+func (s *service) healthAuditSnapshot(resourceType types.ResourceType, resourceID uint) any {
+	if s.healthReader == nil {
+		return nil
+	}
+
+	current, err := s.healthReader.Get(resourceType, resourceID)
+	if err != nil {
+		return nil
+	}
+	if current == nil {
+		return healthAuditState(types.HealthStatusUnknown)
+	}
+	return healthAuditState(current.Status)
+}
+
+// healthAuditState 构造健康状态审计快照。
+func healthAuditState(status types.HealthStatus) map[string]any {
+	return map[string]any{"status": status}
+}
+
 // noOpControlAuditLogger 为默认 no-op 审计实现。
 type noOpControlAuditLogger struct {
 	logger *slog.Logger
@@ -15,11 +15,19 @@ import (
 
 // AddEndpointToPlatform 实现为指定平台添加新端点
 func (s *service) AddEndpointToPlatform(ctx context.Context, platformId uint, endpoint types.Endpoint) (*types.Endpoint, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	return s.addEndpointToPlatformApp(ctx, platformId, endpoint)
 }
 
 // BatchAddEndpointsToPlatform 实现批量为指定平台添加端点（原子性操作）
 func (s *service) BatchAddEndpointsToPlatform(ctx context.Context, platformId uint, endpoints []types.Endpoint) ([]*types.Endpoint, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	logger := s.logger.With(
 		slog.Uint64("platform_id", uint64(platformId)),
 		slog.Int("endpoint_count", len(endpoints)),
@@ -117,11 +125,19 @@ func (s *service) GetEndpoint(ctx context.Context, endpointId uint) (*types.Endp
 
 // UpdateEndpoint 实现更新指定端点
 func (s *service) UpdateEndpoint(ctx context.Context, endpointId uint, endpoint types.Endpoint) (*types.Endpoint, error) {
+	if err := s.ensureResourceWritable(ctx, &types.Endpoint{}, endpointId); err != nil {
+		return nil, err
+	}
+
 	return s.updateEndpointApp(ctx, endpointId, endpoint)
 }
 
 // BatchUpdateEndpoints 实现批量更新指定平台的端点（原子性操作）
 func (s *service) BatchUpdateEndpoints(ctx context.Context, platformId uint, updateItems []EndpointUpdateItem) ([]*types.Endpoint, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	logger := s.logger.With(
 		slog.Uint64("platform_id", uint64(platformId)),
 		slog.Int("endpoint_count", len(updateItems)),
@@ -348,5 +364,9 @@ func buildEndpointUpdateValueSignature(value interface{}) (string, error) {
 
 // DeleteEndpoint 实现删除指定端点
 func (s *service) DeleteEndpoint(ctx context.Context, endpointId uint) error {
+	if err := s.ensureResourceWritable(ctx, &types.Endpoint{}, endpointId); err != nil {
+		return err
+	}
+
 	return s.deleteEndpointApp(ctx, endpointId)
 }
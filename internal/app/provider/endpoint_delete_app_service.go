@@ -25,7 +25,7 @@ func (s *service) deleteEndpointApp(ctx context.Context, endpointID uint) error
 	endpoint, err := s.endpointControlRepo.GetEndpoint(ctx, endpointID)
 	if err != nil {
 		logger.Warn("查询端点失败", slog.Any("error", err))
-		_ = s.logEndpointDeleteAudit(ctx, endpointID, "failed", fmt.Sprintf("查询端点失败：%v", err))
+		_ = s.logEndpointDeleteAudit(ctx, endpointID, "failed", fmt.Sprintf("查询端点失败：%v", err), nil, nil)
 		return err
 	}
 
@@ -64,16 +64,16 @@ func (s *service) deleteEndpointApp(ctx context.Context, endpointID uint) error
 	})
 	if err != nil {
 		logger.Error("删除端点失败", slog.Any("error", err))
-		_ = s.logEndpointDeleteAudit(ctx, endpointID, "failed", fmt.Sprintf("删除端点失败：%v", err))
+		_ = s.logEndpointDeleteAudit(ctx, endpointID, "failed", fmt.Sprintf("删除端点失败：%v", err), nil, nil)
 		return fmt.Errorf("删除端点失败：%w", err)
 	}
 
 	logger.Info("成功删除端点", slog.Uint64("platform_id", uint64(endpoint.PlatformID)))
-	_ = s.logEndpointDeleteAudit(ctx, endpointID, "success", fmt.Sprintf("删除端点成功，platform_id=%d", endpoint.PlatformID))
+	_ = s.logEndpointDeleteAudit(ctx, endpointID, "success", fmt.Sprintf("删除端点成功，platform_id=%d", endpoint.PlatformID), endpoint, nil)
 	return nil
 }
 
-func (s *service) logEndpointDeleteAudit(ctx context.Context, endpointID uint, result, detail string) error {
+func (s *service) logEndpointDeleteAudit(ctx context.Context, endpointID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -84,5 +84,7 @@ func (s *service) logEndpointDeleteAudit(ctx context.Context, endpointID uint, r
 		ResourceID: endpointID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -32,7 +32,7 @@ func (s *service) updateEndpointApp(ctx context.Context, endpointID uint, endpoi
 		} else {
 			logger.Error("查询端点失败", slog.Any("error", err))
 		}
-		_ = s.logEndpointUpdateAudit(ctx, endpointID, "failed", fmt.Sprintf("查询端点失败：%v", err))
+		_ = s.logEndpointUpdateAudit(ctx, endpointID, "failed", fmt.Sprintf("查询端点失败：%v", err), nil, nil)
 		return nil, err
 	}
 
@@ -94,16 +94,16 @@ func (s *service) updateEndpointApp(ctx context.Context, endpointID uint, endpoi
 		} else {
 			logger.Error("更新端点失败", slog.Any("error", err))
 		}
-		_ = s.logEndpointUpdateAudit(ctx, endpointID, "failed", fmt.Sprintf("更新端点失败：%v", err))
+		_ = s.logEndpointUpdateAudit(ctx, endpointID, "failed", fmt.Sprintf("更新端点失败：%v", err), nil, nil)
 		return nil, err
 	}
 
 	logger.Info("成功更新端点", slog.Uint64("platform_id", uint64(updatedEndpoint.PlatformID)))
-	_ = s.logEndpointUpdateAudit(ctx, endpointID, "success", fmt.Sprintf("更新端点成功，platform_id=%d", updatedEndpoint.PlatformID))
+	_ = s.logEndpointUpdateAudit(ctx, endpointID, "success", fmt.Sprintf("更新端点成功，platform_id=%d", updatedEndpoint.PlatformID), existing, updatedEndpoint)
 	return updatedEndpoint, nil
 }
 
-func (s *service) logEndpointUpdateAudit(ctx context.Context, endpointID uint, result, detail string) error {
+func (s *service) logEndpointUpdateAudit(ctx context.Context, endpointID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -114,5 +114,7 @@ func (s *service) logEndpointUpdateAudit(ctx context.Context, endpointID uint, r
 		ResourceID: endpointID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -8,4 +8,7 @@ var (
 	ErrInvalidArgument   = errors.New("请求参数不合法")
 	ErrDefaultConflict   = errors.New("默认端点冲突")
 	ErrTaskNotFound      = errors.New("任务未找到")
+	ErrTaskStateConflict = errors.New("任务当前状态不支持该操作")
+	ErrTaskLeaseLost     = errors.New("任务租约已失效")
+	ErrManagedResource   = errors.New("资源由声明式配置文件管理，不能通过接口修改")
 )
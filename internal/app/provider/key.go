@@ -11,6 +11,10 @@ import (
 
 // AddKeyToPlatform 实现为指定供应方添加新密钥
 func (s *service) AddKeyToPlatform(ctx context.Context, providerId uint, key types.APIKey) (*types.APIKey, error) {
+	if err := s.ensurePlatformWritable(ctx, providerId); err != nil {
+		return nil, err
+	}
+
 	return s.addKeyToPlatformApp(ctx, providerId, key)
 }
 
@@ -54,10 +58,18 @@ func (s *service) GetKey(ctx context.Context, keyId uint) (*types.APIKey, error)
 
 // UpdateKey 实现更新指定密钥
 func (s *service) UpdateKey(ctx context.Context, keyId uint, key types.APIKey) (*types.APIKey, error) {
+	if err := s.ensureResourceWritable(ctx, &types.APIKey{}, keyId); err != nil {
+		return nil, err
+	}
+
 	return s.updateKeyApp(ctx, keyId, key)
 }
 
 // DeleteKey 实现删除指定密钥
 func (s *service) DeleteKey(ctx context.Context, keyId uint) error {
+	if err := s.ensureResourceWritable(ctx, &types.APIKey{}, keyId); err != nil {
+		return err
+	}
+
 	return s.deleteKeyApp(ctx, keyId)
 }
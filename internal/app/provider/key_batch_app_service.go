@@ -0,0 +1,416 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+type keyBatchAddTaskPayload struct {
+	PlatformID uint     `json:"platform_id"`
+	Values     []string `json:"values"`
+	ModelIDs   []uint   `json:"model_ids,omitempty"`
+}
+
+type keyBatchDeleteTaskPayload struct {
+	PlatformID uint   `json:"platform_id"`
+	KeyIDs     []uint `json:"key_ids"`
+}
+
+type keyBatchUpdateModelsTaskPayload struct {
+	PlatformID uint   `json:"platform_id"`
+	KeyIDs     []uint `json:"key_ids"`
+	ModelIDs   []uint `json:"model_ids"`
+	Mode       string `json:"mode"`
+}
+
+// csvKeyHeaders 为 CSV 首行可能出现的表头，出现时跳过
+var csvKeyHeaders = []string{"key", "value", "api_key", "apikey"}
+
+// parseKeyList 合并密钥列表与逐行/CSV 文本，按出现顺序去重
+//
+// 文本每行取第一列，忽略空行、# 开头的注释行与 CSV 表头。
+func parseKeyList(keys []string, text string) ([]string, error) {
+	seen := make(map[string]struct{}, len(keys))
+	values := make([]string, 0, len(keys))
+	appendValue := func(value string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		if _, ok := seen[value]; ok {
+			return
+		}
+		seen[value] = struct{}{}
+		values = append(values, value)
+	}
+
+	for _, key := range keys {
+		appendValue(key)
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	reader.LazyQuotes = true
+	for line := 0; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析密钥文本失败：%v：%w", err, ErrInvalidArgument)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		first := strings.TrimSpace(record[0])
+		if line == 0 && slices.Contains(csvKeyHeaders, strings.ToLower(first)) {
+			continue
+		}
+		appendValue(first)
+	}
+
+	return values, nil
+}
+
+// EnqueueBatchAddKeysTask 提交批量导入密钥异步任务
+func (s *service) EnqueueBatchAddKeysTask(ctx context.Context, platformID uint, req BatchCreateKeysRequest) (*BatchTaskAcceptedResponse, error) {
+	if err := s.ensurePlatformWritable(ctx, platformID); err != nil {
+		return nil, err
+	}
+
+	values, err := parseKeyList(req.Keys, req.Text)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("必须至少提供一个密钥：%w", ErrInvalidArgument)
+	}
+
+	return s.enqueuePlatformTask(ctx, types.KeyBatchTaskTypeAdd, platformID, keyBatchAddTaskPayload{
+		PlatformID: platformID,
+		Values:     values,
+		ModelIDs:   req.ModelIDs,
+	})
+}
+
+// EnqueueBatchDeleteKeysTask 提交批量删除密钥异步任务
+func (s *service) EnqueueBatchDeleteKeysTask(ctx context.Context, platformID uint, keyIDs []uint) (*BatchTaskAcceptedResponse, error) {
+	if err := s.ensurePlatformWritable(ctx, platformID); err != nil {
+		return nil, err
+	}
+
+	if len(keyIDs) == 0 {
+		return nil, fmt.Errorf("必须至少提供一个密钥 ID：%w", ErrInvalidArgument)
+	}
+
+	return s.enqueuePlatformTask(ctx, types.KeyBatchTaskTypeDelete, platformID, keyBatchDeleteTaskPayload{
+		PlatformID: platformID,
+		KeyIDs:     keyIDs,
+	})
+}
+
+// EnqueueBatchUpdateKeyModelsTask 提交批量更新密钥与模型关联异步任务
+func (s *service) EnqueueBatchUpdateKeyModelsTask(ctx context.Context, platformID uint, req BatchUpdateKeyModelsRequest) (*BatchTaskAcceptedResponse, error) {
+	if err := s.ensurePlatformWritable(ctx, platformID); err != nil {
+		return nil, err
+	}
+
+	if len(req.KeyIDs) == 0 {
+		return nil, fmt.Errorf("必须至少提供一个密钥 ID：%w", ErrInvalidArgument)
+	}
+	switch req.Mode {
+	case KeyModelsModeAdd, KeyModelsModeRemove:
+		if len(req.ModelIDs) == 0 {
+			return nil, fmt.Errorf("必须至少提供一个模型 ID：%w", ErrInvalidArgument)
+		}
+	case KeyModelsModeReplace:
+	default:
+		return nil, fmt.Errorf("不支持的关联更新方式 %q：%w", req.Mode, ErrInvalidArgument)
+	}
+
+	return s.enqueuePlatformTask(ctx, types.KeyBatchTaskTypeUpdateModels, platformID, keyBatchUpdateModelsTaskPayload{
+		PlatformID: platformID,
+		KeyIDs:     req.KeyIDs,
+		ModelIDs:   req.ModelIDs,
+		Mode:       req.Mode,
+	})
+}
+
+// enqueuePlatformTask 校验平台存在后创建平台维度的异步任务并入队
+func (s *service) enqueuePlatformTask(ctx context.Context, taskType string, platformID uint, payload any) (*BatchTaskAcceptedResponse, error) {
+	exists, err := s.keyControlRepo.ExistsPlatform(ctx, platformID)
+	if err != nil {
+		return nil, fmt.Errorf("检查平台是否存在失败：%w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("未找到 ID 为 %d 的平台：%w", platformID, ErrResourceNotFound)
+	}
+
+	taskPayload, err := marshalTaskPayload(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("构建任务载荷失败：%w", err)
+	}
+
+	task := &types.ModelBatchTask{
+		Type:       taskType,
+		Status:     types.ModelBatchTaskStatusPending,
+		PlatformID: platformID,
+		Payload:    taskPayload,
+	}
+	if err := s.modelBatchTaskRepo.CreateModelBatchTask(ctx, task); err != nil {
+		return nil, err
+	}
+
+	s.ensureTaskRuntimeInitialized()
+	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
+	s.notifyTaskWorker(task.ID)
+
+	return &BatchTaskAcceptedResponse{TaskID: task.ID, Type: task.Type, Status: task.Status}, nil
+}
+
+// batchAddKeysApp 批量创建密钥，跳过平台中已存在的密钥值，并按需关联模型
+func (s *service) batchAddKeysApp(ctx context.Context, payload keyBatchAddTaskPayload) (*BatchTaskResult, error) {
+	logger := s.logger.With(
+		slog.String("operation", "batch_add_keys"),
+		slog.Uint64("platform_id", uint64(payload.PlatformID)),
+		slog.Int("key_count", len(payload.Values)),
+	)
+
+	if s.controlTx == nil {
+		return nil, fmt.Errorf("批量导入密钥失败：事务执行器未初始化")
+	}
+
+	result := &BatchTaskResult{TotalCount: len(payload.Values)}
+	err := s.controlTx.WithinTx(ctx, func(txCtx context.Context) error {
+		db := configDB(txCtx)
+		if err := ensureModelsInPlatform(db, payload.PlatformID, payload.ModelIDs); err != nil {
+			return err
+		}
+
+		var existingValues []string
+		if err := db.Model(&types.APIKey{}).Where("platform_id = ?", payload.PlatformID).Pluck("value", &existingValues).Error; err != nil {
+			return fmt.Errorf("查询平台现有密钥失败：%w", err)
+		}
+		existing := make(map[string]struct{}, len(existingValues))
+		for _, value := range existingValues {
+			existing[value] = struct{}{}
+		}
+
+		keys := make([]types.APIKey, 0, len(payload.Values))
+		for _, value := range payload.Values {
+			if _, ok := existing[value]; ok {
+				result.SkippedCount++
+				continue
+			}
+			keys = append(keys, types.APIKey{PlatformID: payload.PlatformID, Value: value})
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		if err := db.CreateInBatches(&keys, 100).Error; err != nil {
+			return fmt.Errorf("创建 API 密钥失败：%w", err)
+		}
+		result.CreatedCount = len(keys)
+
+		keyIDs := make([]uint, 0, len(keys))
+		for _, key := range keys {
+			keyIDs = append(keyIDs, key.ID)
+		}
+		return insertKeyModelRelations(db, keyIDs, payload.ModelIDs)
+	})
+	if err != nil {
+		logger.Error("批量导入密钥失败", slog.Any("error", err))
+		_ = s.logKeyBatchAudit(ctx, "key.batch_add", payload.PlatformID, "failed", fmt.Sprintf("批量导入密钥失败：%v", err))
+		return nil, fmt.Errorf("批量导入密钥失败：%w", err)
+	}
+
+	logger.Info("批量导入密钥完成", slog.Int("created", result.CreatedCount), slog.Int("skipped", result.SkippedCount))
+	_ = s.logKeyBatchAudit(ctx, "key.batch_add", payload.PlatformID, "success",
+		fmt.Sprintf("批量导入密钥成功，新增 %d 个，跳过重复 %d 个", result.CreatedCount, result.SkippedCount))
+	return result, nil
+}
+
+// batchDeleteKeysApp 批量删除密钥及其模型关联，并清理原先关联这些密钥且因此失去全部密钥的模型
+func (s *service) batchDeleteKeysApp(ctx context.Context, payload keyBatchDeleteTaskPayload) (*BatchTaskResult, error) {
+	logger := s.logger.With(
+		slog.String("operation", "batch_delete_keys"),
+		slog.Uint64("platform_id", uint64(payload.PlatformID)),
+		slog.Int("key_count", len(payload.KeyIDs)),
+	)
+
+	if s.controlTx == nil {
+		return nil, fmt.Errorf("批量删除密钥失败：事务执行器未初始化")
+	}
+
+	result := &BatchTaskResult{TotalCount: len(payload.KeyIDs)}
+	var affectedModelIDs []uint
+	err := s.controlTx.WithinTx(ctx, func(txCtx context.Context) error {
+		db := configDB(txCtx)
+		if err := ensureKeysInPlatform(db, payload.PlatformID, payload.KeyIDs); err != nil {
+			return err
+		}
+		if err := db.Table("api_key_models").Where("api_key_id IN ?", payload.KeyIDs).Distinct().Pluck("model_id", &affectedModelIDs).Error; err != nil {
+			return fmt.Errorf("查询密钥关联模型失败：%w", err)
+		}
+		if err := db.Exec("DELETE FROM api_key_models WHERE api_key_id IN ?", payload.KeyIDs).Error; err != nil {
+			return fmt.Errorf("清理密钥与模型关联关系失败：%w", err)
+		}
+		deleted := db.Where("id IN ?", payload.KeyIDs).Delete(&types.APIKey{})
+		if deleted.Error != nil {
+			return fmt.Errorf("删除 API 密钥失败：%w", deleted.Error)
+		}
+		result.DeletedCount = int(deleted.RowsAffected)
+		return nil
+	})
+	if err != nil {
+		logger.Error("批量删除密钥失败", slog.Any("error", err))
+		_ = s.logKeyBatchAudit(ctx, "key.batch_delete", payload.PlatformID, "failed", fmt.Sprintf("批量删除密钥失败：%v", err))
+		return nil, fmt.Errorf("批量删除密钥失败：%w", err)
+	}
+
+	orphanedCount, err := s.removeOrphanedModelsAmong(ctx, payload.PlatformID, affectedModelIDs, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("批量删除密钥完成", slog.Int("deleted", result.DeletedCount), slog.Int64("orphaned_model_deleted_count", orphanedCount))
+	_ = s.logKeyBatchAudit(ctx, "key.batch_delete", payload.PlatformID, "success",
+		fmt.Sprintf("批量删除密钥成功，删除 %d 个，删除孤立模型数=%d", result.DeletedCount, orphanedCount))
+	return result, nil
+}
+
+// batchUpdateKeyModelsApp 批量追加、移除或替换密钥与模型的关联
+//
+// 失去全部密钥的模型予以保留，由用户重新关联密钥或手动删除
+func (s *service) batchUpdateKeyModelsApp(ctx context.Context, payload keyBatchUpdateModelsTaskPayload) (*BatchTaskResult, error) {
+	logger := s.logger.With(
+		slog.String("operation", "batch_update_key_models"),
+		slog.Uint64("platform_id", uint64(payload.PlatformID)),
+		slog.String("mode", payload.Mode),
+		slog.Int("key_count", len(payload.KeyIDs)),
+		slog.Int("model_count", len(payload.ModelIDs)),
+	)
+
+	if s.controlTx == nil {
+		return nil, fmt.Errorf("批量更新密钥关联失败：事务执行器未初始化")
+	}
+
+	err := s.controlTx.WithinTx(ctx, func(txCtx context.Context) error {
+		db := configDB(txCtx)
+		if err := ensureKeysInPlatform(db, payload.PlatformID, payload.KeyIDs); err != nil {
+			return err
+		}
+		if err := ensureModelsInPlatform(db, payload.PlatformID, payload.ModelIDs); err != nil {
+			return err
+		}
+
+		switch payload.Mode {
+		case KeyModelsModeReplace:
+			if err := db.Exec("DELETE FROM api_key_models WHERE api_key_id IN ?", payload.KeyIDs).Error; err != nil {
+				return fmt.Errorf("清理密钥与模型关联关系失败：%w", err)
+			}
+			return insertKeyModelRelations(db, payload.KeyIDs, payload.ModelIDs)
+		case KeyModelsModeRemove:
+			if err := db.Exec("DELETE FROM api_key_models WHERE api_key_id IN ? AND model_id IN ?", payload.KeyIDs, payload.ModelIDs).Error; err != nil {
+				return fmt.Errorf("移除密钥与模型关联关系失败：%w", err)
+			}
+			return nil
+		case KeyModelsModeAdd:
+			// 先移除已存在的关联再写入，避免主键冲突
+			if err := db.Exec("DELETE FROM api_key_models WHERE api_key_id IN ? AND model_id IN ?", payload.KeyIDs, payload.ModelIDs).Error; err != nil {
+				return fmt.Errorf("追加密钥与模型关联关系失败：%w", err)
+			}
+			return insertKeyModelRelations(db, payload.KeyIDs, payload.ModelIDs)
+		default:
+			return fmt.Errorf("不支持的关联更新方式 %q：%w", payload.Mode, ErrInvalidArgument)
+		}
+	})
+	if err != nil {
+		logger.Error("批量更新密钥关联失败", slog.Any("error", err))
+		_ = s.logKeyBatchAudit(ctx, "key.batch_update_models", payload.PlatformID, "failed", fmt.Sprintf("批量更新密钥关联失败：%v", err))
+		return nil, fmt.Errorf("批量更新密钥关联失败：%w", err)
+	}
+
+	logger.Info("批量更新密钥关联完成")
+	_ = s.logKeyBatchAudit(ctx, "key.batch_update_models", payload.PlatformID, "success",
+		fmt.Sprintf("批量更新密钥关联成功，方式=%s，密钥数=%d，模型数=%d", payload.Mode, len(payload.KeyIDs), len(payload.ModelIDs)))
+	return &BatchTaskResult{TotalCount: len(payload.KeyIDs), UpdatedCount: len(payload.KeyIDs)}, nil
+}
+
+// ensureKeysInPlatform 校验密钥均存在且属于平台
+func ensureKeysInPlatform(db *gorm.DB, platformID uint, keyIDs []uint) error {
+	var count int64
+	if err := db.Model(&types.APIKey{}).Where("platform_id = ? AND id IN ?", platformID, keyIDs).Count(&count).Error; err != nil {
+		return fmt.Errorf("校验密钥失败：%w", err)
+	}
+	if int(count) != len(uniqueIDs(keyIDs)) {
+		return fmt.Errorf("部分 API 密钥不存在或不属于平台 ID %d：%w", platformID, ErrResourceNotBelong)
+	}
+	return nil
+}
+
+// ensureModelsInPlatform 校验模型均存在且属于平台
+func ensureModelsInPlatform(db *gorm.DB, platformID uint, modelIDs []uint) error {
+	if len(modelIDs) == 0 {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&types.Model{}).Where("platform_id = ? AND id IN ?", platformID, modelIDs).Count(&count).Error; err != nil {
+		return fmt.Errorf("校验模型失败：%w", err)
+	}
+	if int(count) != len(uniqueIDs(modelIDs)) {
+		return fmt.Errorf("部分模型不存在或不属于平台 ID %d：%w", platformID, ErrResourceNotBelong)
+	}
+	return nil
+}
+
+// insertKeyModelRelations 写入密钥与模型的全部组合关联
+func insertKeyModelRelations(db *gorm.DB, keyIDs, modelIDs []uint) error {
+	keyIDs, modelIDs = uniqueIDs(keyIDs), uniqueIDs(modelIDs)
+	if len(keyIDs) == 0 || len(modelIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]map[string]any, 0, len(keyIDs)*len(modelIDs))
+	for _, keyID := range keyIDs {
+		for _, modelID := range modelIDs {
+			rows = append(rows, map[string]any{"api_key_id": keyID, "model_id": modelID})
+		}
+	}
+	if err := db.Table("api_key_models").CreateInBatches(&rows, 200).Error; err != nil {
+		return fmt.Errorf("写入密钥与模型关联关系失败：%w", err)
+	}
+	return nil
+}
+
+// uniqueIDs 返回去重后的 ID 列表
+func uniqueIDs(ids []uint) []uint {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+func (s *service) logKeyBatchAudit(ctx context.Context, action string, platformID uint, result, detail string) error {
+	if s.controlAudit == nil {
+		return nil
+	}
+
+	return s.controlAudit.Log(ctx, ControlAuditEvent{
+		Action:     action,
+		Resource:   "platform",
+		ResourceID: platformID,
+		Result:     result,
+		Detail:     detail,
+	})
+}
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestParseKeyList_合并文本与列表并去重(t *testing.T) {
+	text := "key,note\nsk-a,主账号\n# 注释\n\nsk-b\n  sk-a  \nsk-c,\"含,逗号\"\n"
+	got, err := parseKeyList([]string{"sk-c", " sk-d "}, text)
+	if err != nil {
+		t.Fatalf("解析密钥失败: %v", err)
+	}
+	if want := []string{"sk-c", "sk-d", "sk-a", "sk-b"}; !slices.Equal(got, want) {
+		t.Fatalf("解析结果不符: got=%v want=%v", got, want)
+	}
+}
+
+func TestBatchKeys_导入去重并更新关联后删除(t *testing.T) {
+	svc, db := newConfigTransferTestService(t)
+	seedConfigTransferData(t, db)
+	ctx := context.Background()
+
+	var platform types.Platform
+	db.Where("name = ?", "openai").First(&platform)
+	var model types.Model
+	db.Where("name = ?", "gpt-4o").First(&model)
+	// 未关联任何密钥的模型（如按预设创建的模型）不受密钥批量操作影响
+	keyless := types.Model{PlatformID: platform.ID, Name: "preset-model"}
+	if err := db.Create(&keyless).Error; err != nil {
+		t.Fatalf("创建无密钥模型失败: %v", err)
+	}
+
+	added, err := svc.batchAddKeysApp(ctx, keyBatchAddTaskPayload{
+		PlatformID: platform.ID,
+		Values:     []string{"sk-staging-0001", "sk-new-1", "sk-new-2"},
+		ModelIDs:   []uint{model.ID},
+	})
+	if err != nil {
+		t.Fatalf("批量导入密钥失败: %v", err)
+	}
+	if added.CreatedCount != 2 || added.SkippedCount != 1 {
+		t.Fatalf("应跳过已存在的密钥: %+v", added)
+	}
+	db.Preload("APIKeys").First(&model, model.ID)
+	if len(model.APIKeys) != 3 {
+		t.Fatalf("新密钥应关联到模型: %+v", model.APIKeys)
+	}
+
+	var newKeyIDs []uint
+	db.Model(&types.APIKey{}).Where("value LIKE ?", "sk-new-%").Order("id").Pluck("id", &newKeyIDs)
+	var oldKey types.APIKey
+	db.Where("value = ?", "sk-staging-0001").First(&oldKey)
+
+	// 旧密钥移除全部关联后模型仍由新密钥支撑，不应被删除
+	if _, err := svc.batchUpdateKeyModelsApp(ctx, keyBatchUpdateModelsTaskPayload{
+		PlatformID: platform.ID, KeyIDs: []uint{oldKey.ID}, Mode: KeyModelsModeReplace,
+	}); err != nil {
+		t.Fatalf("替换密钥关联失败: %v", err)
+	}
+	db.Preload("APIKeys").First(&model, model.ID)
+	if len(model.APIKeys) != 2 {
+		t.Fatalf("旧密钥关联应被移除: %+v", model.APIKeys)
+	}
+
+	// 删除全部新密钥后模型失去所有密钥，随之删除
+	deleted, err := svc.batchDeleteKeysApp(ctx, keyBatchDeleteTaskPayload{PlatformID: platform.ID, KeyIDs: newKeyIDs})
+	if err != nil {
+		t.Fatalf("批量删除密钥失败: %v", err)
+	}
+	if deleted.DeletedCount != 2 {
+		t.Fatalf("删除数量不符: %+v", deleted)
+	}
+	var modelCount int64
+	db.Model(&types.Model{}).Where("id = ?", model.ID).Count(&modelCount)
+	if modelCount != 0 {
+		t.Fatalf("失去全部密钥的模型应被删除")
+	}
+	db.Model(&types.Model{}).Where("id = ?", keyless.ID).Count(&modelCount)
+	if modelCount != 1 {
+		t.Fatalf("原本未关联密钥的模型不应被删除")
+	}
+}
+
+func TestBatchKeys_更新关联不删除失去密钥的模型(t *testing.T) {
+	svc, db := newConfigTransferTestService(t)
+	seedConfigTransferData(t, db)
+	ctx := context.Background()
+
+	var platform types.Platform
+	db.Where("name = ?", "openai").First(&platform)
+	var model types.Model
+	db.Preload("APIKeys").Where("name = ?", "gpt-4o").First(&model)
+	keyIDs := make([]uint, 0, len(model.APIKeys))
+	for _, key := range model.APIKeys {
+		keyIDs = append(keyIDs, key.ID)
+	}
+
+	result, err := svc.batchUpdateKeyModelsApp(ctx, keyBatchUpdateModelsTaskPayload{
+		PlatformID: platform.ID, KeyIDs: keyIDs, ModelIDs: []uint{model.ID}, Mode: KeyModelsModeRemove,
+	})
+	if err != nil {
+		t.Fatalf("移除密钥关联失败: %v", err)
+	}
+	if result.DeletedCount != 0 {
+		t.Fatalf("更新关联不应删除模型: %+v", result)
+	}
+	db.Preload("APIKeys").First(&model, model.ID)
+	if model.ID == 0 || len(model.APIKeys) != 0 {
+		t.Fatalf("模型应保留且不再关联密钥: %+v", model)
+	}
+}
+
+func TestBatchKeys_拒绝其他平台的密钥(t *testing.T) {
+	svc, db := newConfigTransferTestService(t)
+	seedConfigTransferData(t, db)
+
+	var legacy types.Platform
+	db.Where("name = ?", "legacy").First(&legacy)
+	var key types.APIKey
+	db.First(&key)
+
+	_, err := svc.batchDeleteKeysApp(context.Background(), keyBatchDeleteTaskPayload{PlatformID: legacy.ID, KeyIDs: []uint{key.ID}})
+	if !errors.Is(err, ErrResourceNotBelong) {
+		t.Fatalf("删除其他平台的密钥应返回不属于错误: %v", err)
+	}
+}
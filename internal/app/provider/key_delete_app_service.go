@@ -25,14 +25,14 @@ func (s *service) deleteKeyApp(ctx context.Context, keyID uint) error {
 	apiKey, err := s.keyControlRepo.GetAPIKey(ctx, keyID)
 	if err != nil {
 		logger.Warn("查询 API 密钥失败", slog.Any("error", err))
-		_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("查询 API 密钥失败：%v", err))
+		_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("查询 API 密钥失败：%v", err), nil, nil)
 		return err
 	}
 
 	backupModels, err := s.keyControlRepo.ListModelsByAPIKey(ctx, keyID)
 	if err != nil {
 		logger.Error("查询密钥关联模型失败", slog.Any("error", err))
-		_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("查询密钥关联模型失败：%v", err))
+		_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("查询密钥关联模型失败：%v", err), nil, nil)
 		return fmt.Errorf("查询密钥关联模型失败：%w", err)
 	}
 
@@ -41,7 +41,7 @@ func (s *service) deleteKeyApp(ctx context.Context, keyID uint) error {
 		logger.Debug("开始清理密钥与模型关联关系", slog.Int("model_count", relationCount))
 		if err = s.keyControlRepo.ClearAPIKeyModelRelations(ctx, keyID); err != nil {
 			logger.Error("清理密钥与模型关联关系失败", slog.Any("error", err))
-			_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("清理密钥与模型关联关系失败：%v", err))
+			_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("清理密钥与模型关联关系失败：%v", err), nil, nil)
 			return fmt.Errorf("清理密钥与模型关联关系失败：%w", err)
 		}
 	}
@@ -63,14 +63,14 @@ func (s *service) deleteKeyApp(ctx context.Context, keyID uint) error {
 				logger.Error("恢复密钥与模型关联关系失败", slog.Any("error", restoreErr))
 			}
 		}
-		_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("删除 API 密钥失败：%v", err))
+		_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("删除 API 密钥失败：%v", err), nil, nil)
 		return fmt.Errorf("删除 API 密钥失败：%w", err)
 	}
 
 	orphanedCount, err := s.removeOrphanedModels(ctx, apiKey.PlatformID, logger)
 	if err != nil {
 		logger.Error("删除孤立模型失败", slog.Any("error", err))
-		_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("删除孤立模型失败：%v", err))
+		_ = s.logKeyDeleteAudit(ctx, keyID, "failed", fmt.Sprintf("删除孤立模型失败：%v", err), nil, nil)
 		return err
 	}
 
@@ -79,12 +79,12 @@ func (s *service) deleteKeyApp(ctx context.Context, keyID uint) error {
 		slog.Int("model_relation_count", relationCount),
 		slog.Int64("orphaned_model_deleted_count", orphanedCount),
 	)
-	_ = s.logKeyDeleteAudit(ctx, keyID, "success", fmt.Sprintf("删除 API 密钥成功，platform_id=%d，关联模型数=%d，删除孤立模型数=%d", apiKey.PlatformID, relationCount, orphanedCount))
+	_ = s.logKeyDeleteAudit(ctx, keyID, "success", fmt.Sprintf("删除 API 密钥成功，platform_id=%d，关联模型数=%d，删除孤立模型数=%d", apiKey.PlatformID, relationCount, orphanedCount), apiKey, nil)
 
 	return nil
 }
 
-func (s *service) logKeyDeleteAudit(ctx context.Context, keyID uint, result, detail string) error {
+func (s *service) logKeyDeleteAudit(ctx context.Context, keyID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -95,5 +95,7 @@ func (s *service) logKeyDeleteAudit(ctx context.Context, keyID uint, result, det
 		ResourceID: keyID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -28,6 +28,7 @@ func (s *service) UpdateKeyHealthEnabled(ctx context.Context, keyID uint, enable
 		return types.HealthStatusUnknown, fmt.Errorf("查询密钥失败：%w", err)
 	}
 
+	beforeHealth := s.healthAuditSnapshot(types.ResourceTypeAPIKey, keyID)
 	status := types.HealthStatusUnavailable
 	auditResult := "success"
 	auditDetail := "禁用密钥健康状态"
@@ -51,16 +52,16 @@ func (s *service) UpdateKeyHealthEnabled(ctx context.Context, keyID uint, enable
 		auditResult = "failed"
 		auditDetail = fmt.Sprintf("更新密钥健康状态失败：%v", err)
 		logger.Error("更新密钥健康状态失败", slog.Any("error", err))
-		_ = s.logKeyControlAudit(ctx, keyID, auditResult, auditDetail)
+		_ = s.logKeyControlAudit(ctx, keyID, auditResult, auditDetail, nil, nil)
 		return types.HealthStatusUnknown, fmt.Errorf("更新密钥健康状态失败：%w", err)
 	}
 
 	logger.Info("更新密钥健康状态成功", slog.Int("status", int(status)))
-	_ = s.logKeyControlAudit(ctx, keyID, auditResult, auditDetail)
+	_ = s.logKeyControlAudit(ctx, keyID, auditResult, auditDetail, beforeHealth, healthAuditState(status))
 	return status, nil
 }
 
-func (s *service) logKeyControlAudit(ctx context.Context, keyID uint, result, detail string) error {
+func (s *service) logKeyControlAudit(ctx context.Context, keyID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -71,5 +72,7 @@ func (s *service) logKeyControlAudit(ctx context.Context, keyID uint, result, de
 		ResourceID: keyID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -24,10 +24,10 @@ func (s *service) updateKeyApp(ctx context.Context, keyID uint, key types.APIKey
 		return nil, fmt.Errorf("更新 API 密钥失败：事务执行器未初始化")
 	}
 
-	_, err := s.keyControlRepo.GetAPIKey(ctx, keyID)
+	existingKey, err := s.keyControlRepo.GetAPIKey(ctx, keyID)
 	if err != nil {
 		logger.Warn("查询 API 密钥失败", slog.Any("error", err))
-		_ = s.logKeyUpdateAudit(ctx, keyID, "failed", fmt.Sprintf("查询 API 密钥失败：%v", err))
+		_ = s.logKeyUpdateAudit(ctx, keyID, "failed", fmt.Sprintf("查询 API 密钥失败：%v", err), nil, nil)
 		return nil, err
 	}
 
@@ -43,24 +43,24 @@ func (s *service) updateKeyApp(ctx context.Context, keyID uint, key types.APIKey
 	})
 	if err != nil {
 		logger.Error("更新 API 密钥事务失败", slog.Any("error", err))
-		_ = s.logKeyUpdateAudit(ctx, keyID, "failed", fmt.Sprintf("更新 API 密钥失败：%v", err))
+		_ = s.logKeyUpdateAudit(ctx, keyID, "failed", fmt.Sprintf("更新 API 密钥失败：%v", err), nil, nil)
 		return nil, fmt.Errorf("更新 API 密钥失败：%w", err)
 	}
 
 	updatedKey, err := s.keyControlRepo.GetAPIKey(ctx, keyID)
 	if err != nil {
 		logger.Error("获取更新后的 API 密钥失败", slog.Any("error", err))
-		_ = s.logKeyUpdateAudit(ctx, keyID, "failed", fmt.Sprintf("获取更新后的 API 密钥失败：%v", err))
+		_ = s.logKeyUpdateAudit(ctx, keyID, "failed", fmt.Sprintf("获取更新后的 API 密钥失败：%v", err), nil, nil)
 		return nil, err
 	}
 
 	logger.Info("成功更新 API 密钥", slog.Uint64("platform_id", uint64(updatedKey.PlatformID)))
-	_ = s.logKeyUpdateAudit(ctx, keyID, "success", fmt.Sprintf("更新 API 密钥成功，platform_id=%d", updatedKey.PlatformID))
+	_ = s.logKeyUpdateAudit(ctx, keyID, "success", fmt.Sprintf("更新 API 密钥成功，platform_id=%d", updatedKey.PlatformID), existingKey, updatedKey)
 
 	return updatedKey, nil
 }
 
-func (s *service) logKeyUpdateAudit(ctx context.Context, keyID uint, result, detail string) error {
+func (s *service) logKeyUpdateAudit(ctx context.Context, keyID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -71,5 +71,7 @@ func (s *service) logKeyUpdateAudit(ctx context.Context, keyID uint, result, det
 		ResourceID: keyID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
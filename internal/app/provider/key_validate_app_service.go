@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// KeyValidator 定义向上游校验密钥的能力
+type KeyValidator interface {
+	// ValidateKey 使用平台的默认端点发送一次低成本鉴权请求，platform 须包含端点列表
+	ValidateKey(ctx context.Context, platform *types.Platform, keyValue string) KeyValidationResult
+}
+
+// KeyValidationResult 表示密钥校验结果
+type KeyValidationResult struct {
+	Success      bool   `json:"success"`                 // 上游是否接受该密钥
+	LatencyMs    int64  `json:"latency_ms"`              // 请求耗时（毫秒）
+	EndpointType string `json:"endpoint_type,omitempty"` // 校验使用的端点类型
+	HTTPStatus   *int   `json:"http_status,omitempty"`   // 上游 HTTP 状态码
+	ErrorCode    string `json:"error_code,omitempty"`    // 稳定错误码
+	ErrorMessage string `json:"error_message,omitempty"` // 错误说明
+}
+
+// ValidateKey 校验尚未保存的密钥值能否通过平台鉴权
+func (s *service) ValidateKey(ctx context.Context, platformID uint, keyValue string) (*KeyValidationResult, error) {
+	if keyValue == "" {
+		return nil, fmt.Errorf("密钥值不能为空：%w", ErrInvalidArgument)
+	}
+
+	platform, err := s.getPlatformByID(ctx, platformID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.validateKeyValue(ctx, platform, keyValue, 0)
+}
+
+// TestKey 校验已保存的密钥能否通过平台鉴权
+func (s *service) TestKey(ctx context.Context, keyID uint) (*KeyValidationResult, error) {
+	key, err := s.getAPIKeyByID(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	platform, err := s.getPlatformByID(ctx, key.PlatformID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.validateKeyValue(ctx, platform, key.Value, keyID)
+}
+
+// validateKeyValue 调用校验器并记录结果
+func (s *service) validateKeyValue(ctx context.Context, platform *types.Platform, keyValue string, keyID uint) (*KeyValidationResult, error) {
+	if s.keyValidator == nil {
+		return nil, fmt.Errorf("校验密钥失败：密钥校验器未初始化")
+	}
+
+	result := s.keyValidator.ValidateKey(ctx, platform, keyValue)
+	s.logger.Info("密钥校验完成",
+		slog.String("operation", "key_validate"),
+		slog.Uint64("platform_id", uint64(platform.ID)),
+		slog.Uint64("key_id", uint64(keyID)),
+		slog.Bool("success", result.Success),
+		slog.Int64("latency_ms", result.LatencyMs),
+		slog.String("error_code", result.ErrorCode),
+	)
+	return &result, nil
+}
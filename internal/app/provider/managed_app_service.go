@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// ensurePlatformWritable 校验平台未由声明式配置文件管理
+//
+// 平台不存在时不返回错误，由后续业务逻辑给出未找到错误。
+func (s *service) ensurePlatformWritable(ctx context.Context, platformID uint) error {
+	var platforms []types.Platform
+	if err := configDB(ctx).Select("id", "name", "managed_by").Where("id = ?", platformID).Limit(1).Find(&platforms).Error; err != nil {
+		return fmt.Errorf("查询平台管理来源失败：%w", err)
+	}
+	if len(platforms) == 0 || platforms[0].ManagedBy == "" {
+		return nil
+	}
+	return fmt.Errorf("平台 %q 由 %s 管理：%w", platforms[0].Name, platforms[0].ManagedBy, ErrManagedResource)
+}
+
+// ensureResourceWritable 校验模型、密钥或端点所属平台未由声明式配置文件管理
+func (s *service) ensureResourceWritable(ctx context.Context, resource any, resourceID uint) error {
+	var platformIDs []uint
+	if err := configDB(ctx).Model(resource).Where("id = ?", resourceID).Limit(1).Pluck("platform_id", &platformIDs).Error; err != nil {
+		return fmt.Errorf("查询资源所属平台失败：%w", err)
+	}
+	if len(platformIDs) == 0 {
+		return nil
+	}
+	return s.ensurePlatformWritable(ctx, platformIDs[0])
+}
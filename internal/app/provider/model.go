@@ -11,6 +11,10 @@ import (
 
 // AddModelToPlatform 实现为指定平台添加新模型
 func (s *service) AddModelToPlatform(ctx context.Context, platformId uint, model types.Model) (*types.Model, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	logger := s.logger.With(slog.Uint64("platform_id", uint64(platformId)))
 	logger.Debug("开始为平台添加模型")
 
@@ -51,6 +55,10 @@ func (s *service) AddModelToPlatform(ctx context.Context, platformId uint, model
 
 // BatchAddModelsToPlatform 实现批量为指定平台添加模型（原子性操作）
 func (s *service) BatchAddModelsToPlatform(ctx context.Context, platformId uint, models []types.Model) ([]*types.Model, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	return s.batchAddModelsToPlatformApp(ctx, platformId, models)
 }
 
@@ -97,20 +105,36 @@ func (s *service) GetModel(ctx context.Context, modelId uint) (*types.Model, err
 
 // UpdateModel 实现更新指定模型信息
 func (s *service) UpdateModel(ctx context.Context, modelId uint, model types.Model) (*types.Model, error) {
+	if err := s.ensureResourceWritable(ctx, &types.Model{}, modelId); err != nil {
+		return nil, err
+	}
+
 	return s.updateModelApp(ctx, modelId, model)
 }
 
 // DeleteModel 实现删除指定模型
 func (s *service) DeleteModel(ctx context.Context, modelId uint) error {
+	if err := s.ensureResourceWritable(ctx, &types.Model{}, modelId); err != nil {
+		return err
+	}
+
 	return s.deleteModelApp(ctx, modelId)
 }
 
 // BatchDeleteModels 实现批量删除指定平台的模型（原子性操作）
 func (s *service) BatchDeleteModels(ctx context.Context, platformId uint, modelIds []uint) (int, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return 0, err
+	}
+
 	return s.batchDeleteModelsApp(ctx, platformId, modelIds)
 }
 
 // BatchUpdateModels 实现批量更新指定平台的模型（原子性操作）
 func (s *service) BatchUpdateModels(ctx context.Context, platformId uint, updateItems []ModelUpdateItem) ([]*types.Model, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	return s.batchUpdateModelsApp(ctx, platformId, updateItems)
 }
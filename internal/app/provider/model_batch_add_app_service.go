@@ -98,16 +98,16 @@ func (s *service) batchAddModelsToPlatformApp(ctx context.Context, platformID ui
 	})
 	if err != nil {
 		logger.Error("批量创建模型事务失败", slog.Any("error", err))
-		_ = s.logModelBatchCreateAudit(ctx, platformID, "failed", fmt.Sprintf("批量创建模型失败：%v", err))
+		_ = s.logModelBatchCreateAudit(ctx, platformID, "failed", fmt.Sprintf("批量创建模型失败：%v", err), nil, nil)
 		return nil, fmt.Errorf("批量创建模型失败：%w", err)
 	}
 
 	logger.Info("成功批量为平台添加模型", slog.Int("created_count", len(createdModels)))
-	_ = s.logModelBatchCreateAudit(ctx, platformID, "success", fmt.Sprintf("批量创建模型成功，创建数量 %d", len(createdModels)))
+	_ = s.logModelBatchCreateAudit(ctx, platformID, "success", fmt.Sprintf("批量创建模型成功，创建数量 %d", len(createdModels)), nil, createdModels)
 	return createdModels, nil
 }
 
-func (s *service) logModelBatchCreateAudit(ctx context.Context, platformID uint, result, detail string) error {
+func (s *service) logModelBatchCreateAudit(ctx context.Context, platformID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -118,5 +118,7 @@ func (s *service) logModelBatchCreateAudit(ctx context.Context, platformID uint,
 		ResourceID: platformID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
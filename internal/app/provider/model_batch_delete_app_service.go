@@ -39,20 +39,20 @@ func (s *service) batchDeleteModelsApp(ctx context.Context, platformID uint, mod
 	exists, err := s.modelControlRepo.ExistsPlatform(ctx, platformID)
 	if err != nil {
 		logger.Error("检查平台是否存在失败", slog.Any("error", err))
-		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", fmt.Sprintf("检查平台是否存在失败：%v", err))
+		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", fmt.Sprintf("检查平台是否存在失败：%v", err), nil, nil)
 		return 0, fmt.Errorf("检查平台是否存在失败：%w", err)
 	}
 	if !exists {
 		err = fmt.Errorf("未找到 ID 为 %d 的平台：%w", platformID, ErrResourceNotFound)
 		logger.Warn("平台不存在")
-		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error())
+		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error(), nil, nil)
 		return 0, err
 	}
 
 	models, err := s.modelControlRepo.ListModelsByIDs(ctx, modelIDs)
 	if err != nil {
 		logger.Error("批量查询模型失败", slog.Any("error", err))
-		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", fmt.Sprintf("批量查询模型失败：%v", err))
+		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", fmt.Sprintf("批量查询模型失败：%v", err), nil, nil)
 		return 0, err
 	}
 
@@ -74,7 +74,7 @@ func (s *service) batchDeleteModelsApp(ctx context.Context, platformID uint, mod
 			slog.Int("requested_count", len(modelIDs)),
 			slog.Int("found_count", len(models)),
 		)
-		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error())
+		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error(), nil, nil)
 		return 0, err
 	}
 
@@ -87,7 +87,7 @@ func (s *service) batchDeleteModelsApp(ctx context.Context, platformID uint, mod
 				slog.Uint64("model_platform_id", uint64(model.PlatformID)),
 				slog.Uint64("expected_platform_id", uint64(platformID)),
 			)
-			_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error())
+			_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error(), nil, nil)
 			return 0, err
 		}
 
@@ -98,7 +98,7 @@ func (s *service) batchDeleteModelsApp(ctx context.Context, platformID uint, mod
 				slog.Uint64("model_id", uint64(model.ID)),
 				slog.Any("error", innerErr),
 			)
-			_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error())
+			_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error(), nil, nil)
 			return 0, err
 		}
 
@@ -115,7 +115,7 @@ func (s *service) batchDeleteModelsApp(ctx context.Context, platformID uint, mod
 				slog.Uint64("model_id", uint64(model.ID)),
 				slog.Any("error", clearErr),
 			)
-			_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error())
+			_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", err.Error(), nil, nil)
 			return 0, err
 		}
 	}
@@ -141,16 +141,16 @@ func (s *service) batchDeleteModelsApp(ctx context.Context, platformID uint, mod
 				)
 			}
 		}
-		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", fmt.Sprintf("批量删除模型失败：%v", err))
+		_ = s.logModelBatchDeleteAudit(ctx, platformID, "failed", fmt.Sprintf("批量删除模型失败：%v", err), nil, nil)
 		return 0, fmt.Errorf("批量删除模型失败：%w", err)
 	}
 
 	logger.Info("成功批量删除模型", slog.Int("deleted_count", deletedCount))
-	_ = s.logModelBatchDeleteAudit(ctx, platformID, "success", fmt.Sprintf("批量删除模型成功，删除数量 %d", deletedCount))
+	_ = s.logModelBatchDeleteAudit(ctx, platformID, "success", fmt.Sprintf("批量删除模型成功，删除数量 %d", deletedCount), models, nil)
 	return deletedCount, nil
 }
 
-func (s *service) logModelBatchDeleteAudit(ctx context.Context, platformID uint, result, detail string) error {
+func (s *service) logModelBatchDeleteAudit(ctx context.Context, platformID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -161,5 +161,7 @@ func (s *service) logModelBatchDeleteAudit(ctx context.Context, platformID uint,
 		ResourceID: platformID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MeowSalty/pinai/internal/app/audit"
+)
+
+// taskActorPayloadKey 为任务载荷中保存发起方信息的字段名
+const taskActorPayloadKey = "_actor"
+
+// taskActor 为异步任务发起方信息，入队时写入载荷，执行时恢复到审计上下文
+type taskActor struct {
+	Identity  string `json:"identity"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// marshalTaskPayload 序列化任务载荷，并写入 ctx 中的审计操作者
+//
+// 仅对象类型的载荷会写入操作者；上下文中没有操作者时原样序列化。错误原样返回，由调用方包装。
+func marshalTaskPayload(ctx context.Context, payload any) (string, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	actor := audit.ActorFromContext(ctx)
+	if actor.Identity == audit.AnonymousActor && actor.ClientIP == "" {
+		return string(payloadBytes), nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payloadBytes, &fields); err != nil || fields == nil {
+		return string(payloadBytes), nil
+	}
+
+	actorBytes, err := json.Marshal(taskActor{Identity: actor.Identity, ClientIP: actor.ClientIP, RequestID: actor.RequestID})
+	if err != nil {
+		return "", err
+	}
+	fields[taskActorPayloadKey] = actorBytes
+
+	payloadBytes, err = json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(payloadBytes), nil
+}
+
+// withTaskActor 将任务载荷中保存的发起方恢复到 ctx，使异步执行产生的审计记录归属于发起方
+func withTaskActor(ctx context.Context, payload string) context.Context {
+	var envelope struct {
+		Actor *taskActor `json:"_actor"`
+	}
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil || envelope.Actor == nil || envelope.Actor.Identity == "" {
+		return ctx
+	}
+
+	return audit.WithActor(ctx, audit.Actor{
+		Identity:  envelope.Actor.Identity,
+		ClientIP:  envelope.Actor.ClientIP,
+		RequestID: envelope.Actor.RequestID,
+	})
+}
@@ -0,0 +1,281 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// TaskHandler 定义由任务运行时执行的任务处理函数，内置任务与其他应用服务注册的任务均通过它分发。
+//
+// 各类型任务统一以 ModelBatchTask 记录存储在 model_batch_tasks 表中。返回值会被序列化为 JSON 写入任务结果；返回错误时任务标记为失败。
+type TaskHandler func(ctx context.Context, task *types.ModelBatchTask) (any, error)
+
+// TaskHandlerOption 定义注册任务处理函数时的可选配置。
+type TaskHandlerOption func(*taskHandlerConfig)
+
+type taskHandlerConfig struct {
+	maxAttempts int
+	timeout     time.Duration
+}
+
+// WithTaskMaxAttempts 设置任务最大执行次数，失败后按指数退避重试；默认为 1，即不自动重试。
+func WithTaskMaxAttempts(n int) TaskHandlerOption {
+	return func(c *taskHandlerConfig) {
+		if n > 0 {
+			c.maxAttempts = n
+		}
+	}
+}
+
+// WithTaskTimeout 设置单次执行的超时时间，超时后任务按执行失败处理；默认为 10 分钟。
+func WithTaskTimeout(d time.Duration) TaskHandlerOption {
+	return func(c *taskHandlerConfig) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// builtinTaskTypes 为创建服务时注册的内置任务类型，不可通过 RegisterTaskHandler 覆盖。
+var builtinTaskTypes = []string{
+	types.ModelBatchTaskTypeAdd, types.ModelBatchTaskTypeUpdate, types.ModelBatchTaskTypeDelete, types.ModelBatchTaskTypeSync,
+	types.KeyBatchTaskTypeAdd, types.KeyBatchTaskTypeDelete, types.KeyBatchTaskTypeUpdateModels,
+}
+
+// periodicTask 描述由 worker 周期性提交的任务。
+type periodicTask struct {
+	taskType string
+	interval time.Duration
+	payload  any
+}
+
+// RegisterTaskHandler 注册指定类型任务的处理函数。
+func (s *service) RegisterTaskHandler(taskType string, handler TaskHandler, opts ...TaskHandlerOption) error {
+	if taskType == "" || handler == nil {
+		return fmt.Errorf("注册任务处理函数失败：任务类型与处理函数不能为空：%w", ErrInvalidArgument)
+	}
+
+	if slices.Contains(builtinTaskTypes, taskType) {
+		return fmt.Errorf("注册任务处理函数失败：任务类型 %s 为内置类型：%w", taskType, ErrInvalidArgument)
+	}
+
+	s.registerTaskHandler(taskType, handler, opts...)
+	return nil
+}
+
+// registerBuiltinTaskHandlers 注册内置任务类型的处理函数
+func (s *service) registerBuiltinTaskHandlers() {
+	s.registerTaskHandler(types.ModelBatchTaskTypeAdd, s.runModelBatchAddTask)
+	s.registerTaskHandler(types.ModelBatchTaskTypeUpdate, s.runModelBatchUpdateTask)
+	s.registerTaskHandler(types.ModelBatchTaskTypeDelete, s.runModelBatchDeleteTask)
+	// 模型同步任务失败后自动重试：定时同步重新请求上游模型列表，手动同步跳过已应用的差异后继续执行
+	s.registerTaskHandler(types.ModelBatchTaskTypeSync, s.runModelSyncTask, WithTaskMaxAttempts(3))
+	s.registerTaskHandler(types.KeyBatchTaskTypeAdd, s.runKeyBatchAddTask)
+	s.registerTaskHandler(types.KeyBatchTaskTypeDelete, s.runKeyBatchDeleteTask)
+	s.registerTaskHandler(types.KeyBatchTaskTypeUpdateModels, s.runKeyBatchUpdateModelsTask)
+}
+
+// registerTaskHandler 写入任务类型的处理函数与执行配置
+func (s *service) registerTaskHandler(taskType string, handler TaskHandler, opts ...TaskHandlerOption) {
+	s.workerMu.Lock()
+	defer s.workerMu.Unlock()
+
+	if s.taskHandlers == nil {
+		s.taskHandlers = make(map[string]TaskHandler)
+	}
+	s.taskHandlers[taskType] = handler
+
+	cfg := taskHandlerConfig{maxAttempts: 1, timeout: defaultTaskTimeout}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if s.taskMaxAttempts == nil {
+		s.taskMaxAttempts = make(map[string]int)
+	}
+	s.taskMaxAttempts[taskType] = cfg.maxAttempts
+	if s.taskTimeouts == nil {
+		s.taskTimeouts = make(map[string]time.Duration)
+	}
+	s.taskTimeouts[taskType] = cfg.timeout
+}
+
+// SchedulePeriodicTask 注册由 worker 周期性提交的任务。
+//
+// worker 启动时立即提交一次，之后每隔 interval 提交一次；
+// 同类型任务仍处于 pending/running 或其他实例已在本周期提交时跳过本次提交，避免任务堆积。
+// 须在 StartModelBatchTaskWorker 之前调用。
+func (s *service) SchedulePeriodicTask(taskType string, interval time.Duration, payload any) error {
+	if interval <= 0 {
+		return fmt.Errorf("注册周期任务失败：周期必须大于 0：%w", ErrInvalidArgument)
+	}
+
+	s.workerMu.Lock()
+	defer s.workerMu.Unlock()
+
+	if _, ok := s.taskHandlers[taskType]; !ok {
+		return fmt.Errorf("注册周期任务失败：任务类型 %s 未注册处理函数：%w", taskType, ErrInvalidArgument)
+	}
+	if s.workerRunning {
+		return fmt.Errorf("注册周期任务失败：worker 已启动")
+	}
+
+	s.periodicTasks = append(s.periodicTasks, periodicTask{
+		taskType: taskType,
+		interval: interval,
+		payload:  payload,
+	})
+
+	return nil
+}
+
+// EnqueueTask 提交已注册类型的异步任务。
+func (s *service) EnqueueTask(ctx context.Context, taskType string, payload any) (*BatchTaskAcceptedResponse, error) {
+	task, err := s.newRegisteredTask(ctx, taskType, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.modelBatchTaskRepo.CreateModelBatchTask(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return s.acceptTask(task), nil
+}
+
+// newRegisteredTask 构建已注册类型的待执行任务
+func (s *service) newRegisteredTask(ctx context.Context, taskType string, payload any) (*types.ModelBatchTask, error) {
+	if _, ok := s.getTaskHandler(taskType); !ok {
+		return nil, fmt.Errorf("任务类型 %s 未注册处理函数：%w", taskType, ErrInvalidArgument)
+	}
+
+	taskPayload, err := marshalTaskPayload(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("构建任务载荷失败：%w", err)
+	}
+
+	return &types.ModelBatchTask{
+		Type:        taskType,
+		Status:      types.ModelBatchTaskStatusPending,
+		Payload:     taskPayload,
+		MaxAttempts: s.taskMaxAttemptsFor(taskType),
+	}, nil
+}
+
+// acceptTask 缓存已写入的任务并通知 worker
+func (s *service) acceptTask(task *types.ModelBatchTask) *BatchTaskAcceptedResponse {
+	s.ensureTaskRuntimeInitialized()
+	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
+	s.notifyTaskWorker(task.ID)
+
+	return &BatchTaskAcceptedResponse{TaskID: task.ID, Type: task.Type, Status: task.Status}
+}
+
+func (s *service) getTaskHandler(taskType string) (TaskHandler, bool) {
+	s.workerMu.Lock()
+	defer s.workerMu.Unlock()
+
+	handler, ok := s.taskHandlers[taskType]
+	return handler, ok
+}
+
+// taskMaxAttemptsFor 返回任务类型的最大执行次数，未配置时为 1。
+func (s *service) taskMaxAttemptsFor(taskType string) int {
+	s.workerMu.Lock()
+	defer s.workerMu.Unlock()
+
+	if n := s.taskMaxAttempts[taskType]; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// taskTimeoutFor 返回任务类型单次执行的超时时间，未配置时为 defaultTaskTimeout。
+func (s *service) taskTimeoutFor(taskType string) time.Duration {
+	s.workerMu.Lock()
+	defer s.workerMu.Unlock()
+
+	if d := s.taskTimeouts[taskType]; d > 0 {
+		return d
+	}
+	return defaultTaskTimeout
+}
+
+// claimableTaskTypes 返回本实例已注册处理函数的任务类型。
+//
+// 多实例部署时各实例注册的处理函数可能不同，认领时据此过滤，避免认领本实例无法执行的任务。
+func (s *service) claimableTaskTypes() []string {
+	s.workerMu.Lock()
+	defer s.workerMu.Unlock()
+
+	taskTypes := make([]string, 0, len(s.taskHandlers))
+	for taskType := range s.taskHandlers {
+		taskTypes = append(taskTypes, taskType)
+	}
+	slices.Sort(taskTypes)
+	return taskTypes
+}
+
+// periodKey 返回周期任务在 now 所属周期的唯一标识，周期按 interval 对齐，各实例计算结果一致。
+func (pt periodicTask) periodKey(now time.Time) string {
+	return fmt.Sprintf("%s@%d", pt.taskType, now.Truncate(pt.interval).Unix())
+}
+
+// enqueuePeriodicTask 提交本周期的周期任务，本次未提交时返回 nil。
+//
+// 同类型任务仍处于 pending/running 时跳过，避免任务堆积；
+// 多实例部署时各实例均会调度周期任务，任务以所属周期为唯一标识写入，同一周期只有一个实例写入成功。
+func (s *service) enqueuePeriodicTask(ctx context.Context, pt periodicTask, now time.Time) (*BatchTaskAcceptedResponse, error) {
+	unfinished, err := s.modelBatchTaskRepo.ListUnfinishedModelBatchTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range unfinished {
+		if task.Type == pt.taskType {
+			return nil, nil
+		}
+	}
+
+	task, err := s.newRegisteredTask(ctx, pt.taskType, pt.payload)
+	if err != nil {
+		return nil, err
+	}
+	periodKey := pt.periodKey(now)
+	task.PeriodKey = &periodKey
+
+	created, err := s.modelBatchTaskRepo.CreatePeriodicModelBatchTask(ctx, task)
+	if err != nil || !created {
+		return nil, err
+	}
+	return s.acceptTask(task), nil
+}
+
+func (s *service) runPeriodicTask(ctx context.Context, pt periodicTask) {
+	logger := s.logger.With(
+		slog.String("operation", "periodic_task"),
+		slog.String("task_type", pt.taskType),
+		slog.Duration("interval", pt.interval),
+	)
+
+	ticker := time.NewTicker(pt.interval)
+	defer ticker.Stop()
+
+	for {
+		if accepted, err := s.enqueuePeriodicTask(ctx, pt, time.Now()); err != nil {
+			logger.Error("提交周期任务失败", slog.Any("error", err))
+		} else if accepted == nil {
+			logger.Debug("同类型任务尚未完成或已由其他实例提交，跳过本次提交")
+		} else {
+			logger.Debug("周期任务已提交", slog.Uint64("task_id", uint64(accepted.TaskID)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
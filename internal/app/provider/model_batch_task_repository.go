@@ -5,12 +5,28 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
+	"github.com/MeowSalty/pinai/database"
 	"github.com/MeowSalty/pinai/database/types"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// secretPayloadTaskTypes 为载荷中含有密钥明文的任务类型，任务结束时清空载荷
+var secretPayloadTaskTypes = []string{types.KeyBatchTaskTypeAdd}
+
+// clearSecretPayload 返回结束任务时清空密钥类任务载荷的更新表达式
+func clearSecretPayload() clause.Expr {
+	return gorm.Expr("CASE WHEN type IN ? THEN '' ELSE payload END", secretPayloadTaskTypes)
+}
+
+// hasClearedPayload 判断任务载荷是否已在结束时清空
+func hasClearedPayload(task *types.ModelBatchTask) bool {
+	return task.Payload == "" && slices.Contains(secretPayloadTaskTypes, task.Type)
+}
+
 // modelBatchTaskGormRepository 是基于 GORM 的模型批量任务仓储实现。
 type modelBatchTaskGormRepository struct {
 	logger *slog.Logger
@@ -31,19 +47,7 @@ func (r *modelBatchTaskGormRepository) taskDB(ctx context.Context) *gorm.DB {
 }
 
 func (r *modelBatchTaskGormRepository) taskModelDB(ctx context.Context) *gorm.DB {
-	db := r.taskDB(ctx).
-		Session(&gorm.Session{NewDB: true}).
-		WithContext(ctx)
-
-	if db.Statement != nil {
-		db.Statement.Table = ""
-		db.Statement.TableExpr = nil
-		db.Statement.Model = nil
-		db.Statement.Schema = nil
-		db.Statement.Dest = nil
-	}
-
-	return db.Model(&types.ModelBatchTask{})
+	return database.CleanSession(ctx, r.taskDB(ctx)).Model(&types.ModelBatchTask{})
 }
 
 // CreateModelBatchTask 创建模型批量任务。
@@ -51,6 +55,9 @@ func (r *modelBatchTaskGormRepository) CreateModelBatchTask(ctx context.Context,
 	if task == nil {
 		return fmt.Errorf("创建模型批量任务失败：任务参数不能为空")
 	}
+	if task.MaxAttempts <= 0 {
+		task.MaxAttempts = 1
+	}
 
 	if err := r.taskModelDB(ctx).Create(task).Error; err != nil {
 		r.logger.Error("创建模型批量任务失败", slog.Any("error", err))
@@ -60,6 +67,26 @@ func (r *modelBatchTaskGormRepository) CreateModelBatchTask(ctx context.Context,
 	return nil
 }
 
+// CreatePeriodicModelBatchTask 创建周期任务，同一 PeriodKey 的任务已存在时不创建并返回 false。
+//
+// PeriodKey 上的唯一索引保证多实例同时提交同一周期的任务时只有一个实例写入成功。
+func (r *modelBatchTaskGormRepository) CreatePeriodicModelBatchTask(ctx context.Context, task *types.ModelBatchTask) (bool, error) {
+	if task == nil || task.PeriodKey == nil {
+		return false, fmt.Errorf("创建周期任务失败：任务参数与周期标识不能为空")
+	}
+	if task.MaxAttempts <= 0 {
+		task.MaxAttempts = 1
+	}
+
+	resultDB := r.taskModelDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(task)
+	if resultDB.Error != nil {
+		r.logger.Error("创建周期任务失败", slog.String("period_key", *task.PeriodKey), slog.Any("error", resultDB.Error))
+		return false, fmt.Errorf("创建周期任务失败：%w", resultDB.Error)
+	}
+
+	return resultDB.RowsAffected > 0, nil
+}
+
 // GetModelBatchTaskByID 根据 ID 查询模型批量任务。
 func (r *modelBatchTaskGormRepository) GetModelBatchTaskByID(ctx context.Context, taskID uint) (*types.ModelBatchTask, error) {
 	var task types.ModelBatchTask
@@ -90,51 +117,345 @@ func (r *modelBatchTaskGormRepository) ListUnfinishedModelBatchTasks(ctx context
 	return tasks, nil
 }
 
-// MarkModelBatchTaskRunning 将任务标记为运行中。
-func (r *modelBatchTaskGormRepository) MarkModelBatchTaskRunning(ctx context.Context, taskID uint, startedAt time.Time) error {
+// ListModelBatchTasks 按条件分页查询任务，按 ID 倒序返回。
+func (r *modelBatchTaskGormRepository) ListModelBatchTasks(ctx context.Context, opts ListTasksOptions) ([]*types.ModelBatchTask, int64, error) {
+	filter := func() *gorm.DB {
+		db := r.taskModelDB(ctx)
+		if opts.Type != "" {
+			db = db.Where("type = ?", opts.Type)
+		}
+		if opts.Status != "" {
+			db = db.Where("status = ?", opts.Status)
+		}
+		if opts.PlatformID != nil {
+			db = db.Where("platform_id = ?", *opts.PlatformID)
+		}
+		return db
+	}
+
+	var total int64
+	if err := filter().Count(&total).Error; err != nil {
+		r.logger.Error("统计任务数量失败", slog.Any("error", err))
+		return nil, 0, fmt.Errorf("查询任务列表失败：%w", err)
+	}
+
+	tasks := make([]*types.ModelBatchTask, 0)
+	if err := filter().Order("id DESC").Offset((opts.Page - 1) * opts.PageSize).Limit(opts.PageSize).Find(&tasks).Error; err != nil {
+		r.logger.Error("查询任务列表失败", slog.Any("error", err))
+		return nil, 0, fmt.Errorf("查询任务列表失败：%w", err)
+	}
+
+	return tasks, total, nil
+}
+
+// claimableTaskCondition 为可被认领任务的条件：到达执行时间的 pending 任务，或租约已过期的 running 任务。
+//
+// 租约为空的 running 任务来自未启用租约的旧版本实例，同样视为已过期。
+const claimableTaskCondition = "((status = ? AND (next_run_at IS NULL OR next_run_at <= ?)) OR (status = ? AND (lease_expires_at IS NULL OR lease_expires_at < ?)))"
+
+// exhaustedLeaseCondition 为租约已过期且执行次数已达上限的 running 任务条件，此类任务不再重新执行。
+const exhaustedLeaseCondition = "status = ? AND (lease_expires_at IS NULL OR lease_expires_at < ?) AND attempts >= max_attempts"
+
+// taskClaimCandidates 为单次认领时读取的候选任务数量
+const taskClaimCandidates = 8
+
+// ClaimModelBatchTask 为 owner 认领一个类型属于 taskTypes 的可执行任务并持有租约至 leaseExpiresAt，没有可执行任务时返回 nil。
+//
+// 仅认领本实例已注册处理函数的任务类型，未注册的任务留给其他实例执行。先读取候选任务，再以带原条件的 UPDATE 逐个抢占，仅影响行数为 1 的实例认领成功；
+// 该方式不依赖行锁语法，SQLite、MySQL 与 PostgreSQL 下均保证同一任务只被一个实例认领。
+// 认领后任务状态为 running，执行次数加一。租约过期但执行次数已达上限的任务直接标记为 failed，不再重新执行。
+func (r *modelBatchTaskGormRepository) ClaimModelBatchTask(ctx context.Context, owner string, leaseExpiresAt time.Time, taskTypes []string) (*types.ModelBatchTask, error) {
+	if len(taskTypes) == 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	if err := r.failExhaustedLeaseTasks(ctx, now, taskTypes); err != nil {
+		return nil, err
+	}
+	conditionArgs := []any{types.ModelBatchTaskStatusPending, now, types.ModelBatchTaskStatusRunning, now}
+
+	var candidates []uint
+	if err := r.taskModelDB(ctx).
+		Where("type IN ?", taskTypes).
+		Where(claimableTaskCondition, conditionArgs...).
+		Where("attempts < max_attempts OR status = ?", types.ModelBatchTaskStatusPending).
+		Order("id ASC").
+		Limit(taskClaimCandidates).
+		Pluck("id", &candidates).Error; err != nil {
+		r.logger.Error("查询可执行任务失败", slog.Any("error", err))
+		return nil, fmt.Errorf("查询可执行任务失败：%w", err)
+	}
+
+	for _, taskID := range candidates {
+		resultDB := r.taskModelDB(ctx).
+			Where("id = ?", taskID).
+			Where(claimableTaskCondition, conditionArgs...).
+			Where("attempts < max_attempts OR status = ?", types.ModelBatchTaskStatusPending).
+			Updates(map[string]any{
+				"status":           types.ModelBatchTaskStatusRunning,
+				"lease_owner":      owner,
+				"lease_expires_at": leaseExpiresAt,
+				"started_at":       now,
+				"error_message":    "",
+				"result":           "",
+				"progress":         0,
+				"attempts":         gorm.Expr("attempts + 1"),
+				"next_run_at":      nil,
+			})
+		if resultDB.Error != nil {
+			r.logger.Error("认领任务失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
+			return nil, fmt.Errorf("认领任务失败：%w", resultDB.Error)
+		}
+		if resultDB.RowsAffected == 0 {
+			// 已被其他实例认领或已取消
+			continue
+		}
+		return r.GetModelBatchTaskByID(ctx, taskID)
+	}
+
+	return nil, nil
+}
+
+// failExhaustedLeaseTasks 将租约已过期且执行次数已达上限的 running 任务标记为 failed。
+//
+// 此类任务的最后一次执行随实例崩溃或失联中断，再次认领将超出 max_attempts 限制。
+func (r *modelBatchTaskGormRepository) failExhaustedLeaseTasks(ctx context.Context, now time.Time, taskTypes []string) error {
 	resultDB := r.taskModelDB(ctx).
-		Where("id = ?", taskID).
+		Where("type IN ?", taskTypes).
+		Where(exhaustedLeaseCondition, types.ModelBatchTaskStatusRunning, now).
 		Updates(map[string]any{
-			"status":        types.ModelBatchTaskStatusRunning,
-			"started_at":    startedAt,
-			"error_message": "",
-			"result":        "",
+			"status":           types.ModelBatchTaskStatusFailed,
+			"error_message":    "任务租约已过期且执行次数已达上限",
+			"finished_at":      now,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+			"payload":          clearSecretPayload(),
 		})
 	if resultDB.Error != nil {
-		r.logger.Error("标记模型批量任务为运行中失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
-		return fmt.Errorf("标记模型批量任务为运行中失败：%w", resultDB.Error)
+		r.logger.Error("标记租约过期任务失败", slog.Any("error", resultDB.Error))
+		return fmt.Errorf("标记租约过期任务失败：%w", resultDB.Error)
+	}
+	if resultDB.RowsAffected > 0 {
+		r.logger.Warn("租约过期且执行次数已达上限的任务已标记为失败", slog.Int64("count", resultDB.RowsAffected))
+	}
+
+	return nil
+}
+
+// RenewModelBatchTaskLease 续约 owner 持有的任务租约，并返回任务是否已被请求取消。
+//
+// 任务已不在运行中或租约已被其他实例接管时返回 ErrTaskLeaseLost。
+func (r *modelBatchTaskGormRepository) RenewModelBatchTaskLease(ctx context.Context, taskID uint, owner string, leaseExpiresAt time.Time) (bool, error) {
+	resultDB := r.taskModelDB(ctx).
+		Where("id = ? AND status = ? AND lease_owner = ?", taskID, types.ModelBatchTaskStatusRunning, owner).
+		Update("lease_expires_at", leaseExpiresAt)
+	if resultDB.Error != nil {
+		r.logger.Error("续约任务租约失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
+		return false, fmt.Errorf("续约任务租约失败：%w", resultDB.Error)
 	}
 	if resultDB.RowsAffected == 0 {
-		return fmt.Errorf("标记模型批量任务为运行中失败：未找到 ID 为 %d 的任务：%w", taskID, ErrTaskNotFound)
+		return false, fmt.Errorf("续约任务 %d 租约失败：%w", taskID, ErrTaskLeaseLost)
+	}
+
+	var cancelRequested bool
+	if err := r.taskModelDB(ctx).Where("id = ?", taskID).Pluck("cancel_requested", &cancelRequested).Error; err != nil {
+		return false, fmt.Errorf("查询任务取消请求失败：%w", err)
+	}
+	return cancelRequested, nil
+}
+
+// ReleaseModelBatchTask 释放 owner 持有的任务租约并将任务重置为 pending，本次执行不计入执行次数。
+//
+// 用于实例停止时让出未完成的任务，使其他实例无需等待租约过期即可接管。
+func (r *modelBatchTaskGormRepository) ReleaseModelBatchTask(ctx context.Context, taskID uint, owner string) error {
+	resultDB := r.taskModelDB(ctx).
+		Where("id = ? AND status = ? AND lease_owner = ?", taskID, types.ModelBatchTaskStatusRunning, owner).
+		Updates(map[string]any{
+			"status":           types.ModelBatchTaskStatusPending,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+			"started_at":       nil,
+			"progress":         0,
+			"attempts":         gorm.Expr("CASE WHEN attempts > 0 THEN attempts - 1 ELSE 0 END"),
+		})
+	if resultDB.Error != nil {
+		r.logger.Error("释放任务租约失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
+		return fmt.Errorf("释放任务租约失败：%w", resultDB.Error)
+	}
+	if resultDB.RowsAffected == 0 {
+		return fmt.Errorf("释放任务 %d 租约失败：%w", taskID, ErrTaskLeaseLost)
 	}
 
 	return nil
 }
 
-// FinishModelBatchTask 完成模型批量任务。
-func (r *modelBatchTaskGormRepository) FinishModelBatchTask(ctx context.Context, taskID uint, status, result, errorMessage string) error {
-	if status != types.ModelBatchTaskStatusSucceeded && status != types.ModelBatchTaskStatusFailed {
+// UpdateModelBatchTaskProgress 更新 owner 执行中任务的进度百分比。
+func (r *modelBatchTaskGormRepository) UpdateModelBatchTaskProgress(ctx context.Context, taskID uint, owner string, progress int) error {
+	err := r.taskModelDB(ctx).
+		Where("id = ? AND status = ? AND lease_owner = ?", taskID, types.ModelBatchTaskStatusRunning, owner).
+		Update("progress", progress).Error
+	if err != nil {
+		r.logger.Error("更新任务进度失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", err))
+		return fmt.Errorf("更新任务进度失败：%w", err)
+	}
+
+	return nil
+}
+
+// FinishModelBatchTask 完成 owner 持有租约的模型批量任务并释放租约。
+func (r *modelBatchTaskGormRepository) FinishModelBatchTask(ctx context.Context, taskID uint, owner, status, result, errorMessage string) error {
+	if !isTaskFinished(status) {
 		return fmt.Errorf("完成模型批量任务失败：不支持的任务状态 %s", status)
 	}
 
 	now := time.Now()
 	updateMap := map[string]any{
-		"status":        status,
-		"result":        result,
-		"error_message": errorMessage,
-		"finished_at":   now,
+		"status":           status,
+		"result":           result,
+		"error_message":    errorMessage,
+		"finished_at":      now,
+		"lease_owner":      "",
+		"lease_expires_at": nil,
+		"payload":          clearSecretPayload(),
+	}
+	if status == types.ModelBatchTaskStatusSucceeded {
+		updateMap["progress"] = 100
 	}
 
 	resultDB := r.taskModelDB(ctx).
-		Where("id = ?", taskID).
+		Where("id = ? AND lease_owner = ?", taskID, owner).
 		Updates(updateMap)
 	if resultDB.Error != nil {
 		r.logger.Error("更新模型批量任务状态失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
 		return fmt.Errorf("更新模型批量任务状态失败：%w", resultDB.Error)
 	}
 	if resultDB.RowsAffected == 0 {
-		return fmt.Errorf("更新模型批量任务状态失败：未找到 ID 为 %d 的任务：%w", taskID, ErrTaskNotFound)
+		return fmt.Errorf("更新模型批量任务状态失败：任务 %d 不存在或租约已失效：%w", taskID, ErrTaskLeaseLost)
+	}
+
+	return nil
+}
+
+// ScheduleModelBatchTaskRetry 将 owner 执行失败的任务重置为 pending 并释放租约，等待 nextRunAt 后重试。
+func (r *modelBatchTaskGormRepository) ScheduleModelBatchTaskRetry(ctx context.Context, taskID uint, owner string, nextRunAt time.Time, errorMessage string) error {
+	resultDB := r.taskModelDB(ctx).
+		Where("id = ? AND status = ? AND lease_owner = ?", taskID, types.ModelBatchTaskStatusRunning, owner).
+		Updates(map[string]any{
+			"status":           types.ModelBatchTaskStatusPending,
+			"error_message":    errorMessage,
+			"next_run_at":      nextRunAt,
+			"progress":         0,
+			"started_at":       nil,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+		})
+	if resultDB.Error != nil {
+		r.logger.Error("安排任务重试失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
+		return fmt.Errorf("安排任务重试失败：%w", resultDB.Error)
+	}
+	if resultDB.RowsAffected == 0 {
+		return fmt.Errorf("安排任务重试失败：任务 %d 不在运行中或租约已失效：%w", taskID, ErrTaskLeaseLost)
 	}
 
 	return nil
 }
+
+// CancelModelBatchTask 取消任务并返回取消后的状态。
+//
+// pending 任务直接标记为 canceled；running 任务仅记录取消请求，由执行实例中断后标记为 canceled。
+func (r *modelBatchTaskGormRepository) CancelModelBatchTask(ctx context.Context, taskID uint) (string, error) {
+	now := time.Now()
+	resultDB := r.taskModelDB(ctx).
+		Where("id = ? AND status = ?", taskID, types.ModelBatchTaskStatusPending).
+		Updates(map[string]any{
+			"status":           types.ModelBatchTaskStatusCanceled,
+			"cancel_requested": true,
+			"next_run_at":      nil,
+			"finished_at":      now,
+			"payload":          clearSecretPayload(),
+		})
+	if resultDB.Error != nil {
+		r.logger.Error("取消任务失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
+		return "", fmt.Errorf("取消任务失败：%w", resultDB.Error)
+	}
+	if resultDB.RowsAffected > 0 {
+		return types.ModelBatchTaskStatusCanceled, nil
+	}
+
+	resultDB = r.taskModelDB(ctx).
+		Where("id = ? AND status = ?", taskID, types.ModelBatchTaskStatusRunning).
+		Update("cancel_requested", true)
+	if resultDB.Error != nil {
+		r.logger.Error("取消任务失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
+		return "", fmt.Errorf("取消任务失败：%w", resultDB.Error)
+	}
+	if resultDB.RowsAffected > 0 {
+		return types.ModelBatchTaskStatusRunning, nil
+	}
+
+	task, err := r.GetModelBatchTaskByID(ctx, taskID)
+	if err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("取消任务失败：任务已处于 %s 状态：%w", task.Status, ErrTaskStateConflict)
+}
+
+// RetryModelBatchTask 将失败或已取消的任务重置为 pending，执行次数从零开始计算。
+//
+// 载荷已在结束时清空的密钥类任务无法重试。
+func (r *modelBatchTaskGormRepository) RetryModelBatchTask(ctx context.Context, taskID uint) error {
+	resultDB := r.taskModelDB(ctx).
+		Where("id = ? AND status IN ?", taskID, []string{types.ModelBatchTaskStatusFailed, types.ModelBatchTaskStatusCanceled}).
+		Where("NOT (type IN ? AND payload = '')", secretPayloadTaskTypes).
+		Updates(map[string]any{
+			"status":           types.ModelBatchTaskStatusPending,
+			"result":           "",
+			"error_message":    "",
+			"progress":         0,
+			"attempts":         0,
+			"cancel_requested": false,
+			"next_run_at":      nil,
+			"started_at":       nil,
+			"finished_at":      nil,
+		})
+	if resultDB.Error != nil {
+		r.logger.Error("重试任务失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", resultDB.Error))
+		return fmt.Errorf("重试任务失败：%w", resultDB.Error)
+	}
+	if resultDB.RowsAffected > 0 {
+		return nil
+	}
+
+	task, err := r.GetModelBatchTaskByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if hasClearedPayload(task) {
+		return fmt.Errorf("重试任务失败：任务载荷含有密钥，已在任务结束时清除，请重新提交：%w", ErrTaskStateConflict)
+	}
+	return fmt.Errorf("重试任务失败：任务处于 %s 状态，仅失败或已取消的任务可以重试：%w", task.Status, ErrTaskStateConflict)
+}
+
+// DeleteFinishedModelBatchTasks 删除 before 之前结束的任务，单次最多删除 limit 条，返回删除数量。
+func (r *modelBatchTaskGormRepository) DeleteFinishedModelBatchTasks(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var ids []uint
+	if err := r.taskModelDB(ctx).
+		Where("status IN ? AND finished_at < ?", []string{types.ModelBatchTaskStatusSucceeded, types.ModelBatchTaskStatusFailed, types.ModelBatchTaskStatusCanceled}, before).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		r.logger.Error("查询过期任务失败", slog.Any("error", err))
+		return 0, fmt.Errorf("查询过期任务失败：%w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	resultDB := database.CleanSession(ctx, r.taskDB(ctx)).Where("id IN ?", ids).Delete(&types.ModelBatchTask{})
+	if resultDB.Error != nil {
+		r.logger.Error("删除过期任务失败", slog.Any("error", resultDB.Error))
+		return 0, fmt.Errorf("删除过期任务失败：%w", resultDB.Error)
+	}
+
+	return resultDB.RowsAffected, nil
+}
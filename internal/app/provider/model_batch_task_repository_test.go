@@ -2,7 +2,9 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
@@ -108,7 +110,7 @@ func TestModelBatchTaskRepository_ListUnfinishedTasks(t *testing.T) {
 	}
 }
 
-func TestModelBatchTaskRepository_MarkTaskRunning(t *testing.T) {
+func TestModelBatchTaskRepository_ClaimTask(t *testing.T) {
 	ctx, db := newModelBatchTaskRepoTestContext(t)
 	repo := NewModelBatchTaskGormRepository(slog.Default())
 
@@ -124,35 +126,218 @@ func TestModelBatchTaskRepository_MarkTaskRunning(t *testing.T) {
 		t.Fatalf("准备测试任务数据失败: %v", err)
 	}
 
-	if err := repo.MarkModelBatchTaskRunning(ctx, seed.ID, seed.CreatedAt); err != nil {
-		t.Fatalf("标记任务为 running 失败: %v", err)
+	leaseExpiresAt := time.Now().Add(time.Minute)
+	claimed, err := repo.ClaimModelBatchTask(ctx, "node-a", leaseExpiresAt, builtinTaskTypes)
+	if err != nil {
+		t.Fatalf("认领任务失败: %v", err)
+	}
+	if claimed == nil || claimed.ID != seed.ID {
+		t.Fatalf("应认领到 pending 任务: got=%v", claimed)
+	}
+	if claimed.Status != types.ModelBatchTaskStatusRunning {
+		t.Fatalf("任务状态应为 running: got=%s", claimed.Status)
+	}
+	if claimed.LeaseOwner != "node-a" || claimed.LeaseExpiresAt == nil {
+		t.Fatalf("任务应记录租约持有者与到期时间: owner=%q", claimed.LeaseOwner)
+	}
+	if claimed.Attempts != 1 || claimed.StartedAt == nil {
+		t.Fatalf("认领后执行次数应为 1 且记录开始时间: attempts=%d", claimed.Attempts)
+	}
+	if claimed.Result != "" || claimed.ErrorMessage != "" {
+		t.Fatalf("认领后 result 与 error_message 应被清空: result=%q, error=%q", claimed.Result, claimed.ErrorMessage)
 	}
 
-	var stored types.ModelBatchTask
-	if err := db.WithContext(ctx).Where("id = ?", seed.ID).First(&stored).Error; err != nil {
-		t.Fatalf("查询更新后的任务记录失败: %v", err)
+	again, err := repo.ClaimModelBatchTask(ctx, "node-b", leaseExpiresAt, builtinTaskTypes)
+	if err != nil {
+		t.Fatalf("再次认领任务失败: %v", err)
 	}
-	if stored.Status != types.ModelBatchTaskStatusRunning {
-		t.Fatalf("任务状态应为 running: got=%s", stored.Status)
+	if again != nil {
+		t.Fatalf("租约有效的任务不应被其他实例认领: got=%d", again.ID)
 	}
-	if stored.StartedAt == nil {
-		t.Fatalf("任务 StartedAt 不应为 nil")
+}
+
+func TestModelBatchTaskRepository_ClaimTask_SkipsDelayedRetry(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	nextRunAt := time.Now().Add(time.Hour)
+	seed := &types.ModelBatchTask{
+		Type:      types.ModelBatchTaskTypeAdd,
+		Status:    types.ModelBatchTaskStatusPending,
+		Payload:   "{}",
+		NextRunAt: &nextRunAt,
+	}
+	if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+		t.Fatalf("准备测试任务数据失败: %v", err)
 	}
-	if stored.Result != "" {
-		t.Fatalf("任务 result 应被清空: got=%q", stored.Result)
+
+	claimed, err := repo.ClaimModelBatchTask(ctx, "node-a", time.Now().Add(time.Minute), builtinTaskTypes)
+	if err != nil {
+		t.Fatalf("认领任务失败: %v", err)
 	}
-	if stored.ErrorMessage != "" {
-		t.Fatalf("任务 error_message 应被清空: got=%q", stored.ErrorMessage)
+	if claimed != nil {
+		t.Fatalf("未到重试时间的任务不应被认领")
 	}
 }
 
-func TestModelBatchTaskRepository_MarkTaskRunning_NotFound(t *testing.T) {
-	ctx, _ := newModelBatchTaskRepoTestContext(t)
+func TestModelBatchTaskRepository_ClaimTask_RecoversExpiredLease(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	expiredAt := time.Now().Add(-time.Second)
+	seed := &types.ModelBatchTask{
+		Type:           types.ModelBatchTaskTypeDelete,
+		Status:         types.ModelBatchTaskStatusRunning,
+		Payload:        "{}",
+		Attempts:       1,
+		MaxAttempts:    2,
+		LeaseOwner:     "node-a",
+		LeaseExpiresAt: &expiredAt,
+	}
+	if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+		t.Fatalf("准备测试任务数据失败: %v", err)
+	}
+
+	claimed, err := repo.ClaimModelBatchTask(ctx, "node-b", time.Now().Add(time.Minute), builtinTaskTypes)
+	if err != nil {
+		t.Fatalf("认领任务失败: %v", err)
+	}
+	if claimed == nil || claimed.LeaseOwner != "node-b" || claimed.Attempts != 2 {
+		t.Fatalf("租约过期的任务应由其他实例接管: got=%+v", claimed)
+	}
+
+	if _, err := repo.RenewModelBatchTaskLease(ctx, seed.ID, "node-a", time.Now().Add(time.Minute)); !errors.Is(err, ErrTaskLeaseLost) {
+		t.Fatalf("原持有者续约应返回租约失效: %v", err)
+	}
+	if err := repo.FinishModelBatchTask(ctx, seed.ID, "node-a", types.ModelBatchTaskStatusSucceeded, "{}", ""); !errors.Is(err, ErrTaskLeaseLost) {
+		t.Fatalf("原持有者完成任务应返回租约失效: %v", err)
+	}
+	if err := repo.FinishModelBatchTask(ctx, seed.ID, "node-b", types.ModelBatchTaskStatusSucceeded, "{}", ""); err != nil {
+		t.Fatalf("当前持有者完成任务失败: %v", err)
+	}
+}
+
+func TestModelBatchTaskRepository_ClaimTask_FailsExhaustedExpiredLease(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	expiredAt := time.Now().Add(-time.Second)
+	seed := &types.ModelBatchTask{
+		Type:           types.KeyBatchTaskTypeAdd,
+		Status:         types.ModelBatchTaskStatusRunning,
+		Payload:        `{"keys":["sk-secret"]}`,
+		Attempts:       3,
+		MaxAttempts:    3,
+		LeaseOwner:     "node-a",
+		LeaseExpiresAt: &expiredAt,
+	}
+	if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+		t.Fatalf("准备测试任务数据失败: %v", err)
+	}
+
+	claimed, err := repo.ClaimModelBatchTask(ctx, "node-b", time.Now().Add(time.Minute), builtinTaskTypes)
+	if err != nil {
+		t.Fatalf("认领任务失败: %v", err)
+	}
+	if claimed != nil {
+		t.Fatalf("执行次数已达上限的任务不应被重新认领: got=%+v", claimed)
+	}
+
+	got, err := repo.GetModelBatchTaskByID(ctx, seed.ID)
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	if got.Status != types.ModelBatchTaskStatusFailed || got.Attempts != 3 || got.FinishedAt == nil {
+		t.Fatalf("任务应标记为失败且不增加执行次数: %+v", got)
+	}
+	if got.LeaseOwner != "" || got.Payload != "" {
+		t.Fatalf("失败任务应释放租约并清空密钥载荷: %+v", got)
+	}
+}
+
+func TestModelBatchTaskRepository_ClaimTask_Concurrent(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
 	repo := NewModelBatchTaskGormRepository(slog.Default())
 
-	err := repo.MarkModelBatchTaskRunning(ctx, 9999, seedTime())
-	if err == nil {
-		t.Fatalf("标记不存在任务应返回错误")
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	const taskCount = 20
+	for range taskCount {
+		if err := db.WithContext(ctx).Create(&types.ModelBatchTask{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusPending, Payload: "{}"}).Error; err != nil {
+			t.Fatalf("准备测试任务数据失败: %v", err)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		claimed = make(map[uint]string)
+		wg      sync.WaitGroup
+	)
+	for _, owner := range []string{"node-a", "node-b", "node-c", "node-d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				task, err := repo.ClaimModelBatchTask(ctx, owner, time.Now().Add(time.Minute), builtinTaskTypes)
+				if err != nil {
+					t.Errorf("认领任务失败: %v", err)
+					return
+				}
+				if task == nil {
+					return
+				}
+				mu.Lock()
+				if previous, ok := claimed[task.ID]; ok {
+					t.Errorf("任务 %d 被 %s 与 %s 重复认领", task.ID, previous, owner)
+				}
+				claimed[task.ID] = owner
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(claimed) != taskCount {
+		t.Fatalf("全部任务应被认领一次: got=%d, want=%d", len(claimed), taskCount)
+	}
+}
+
+func TestModelBatchTaskRepository_RenewAndReleaseLease(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	if err := db.WithContext(ctx).Create(&types.ModelBatchTask{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusPending, Payload: "{}"}).Error; err != nil {
+		t.Fatalf("准备测试任务数据失败: %v", err)
+	}
+	task, err := repo.ClaimModelBatchTask(ctx, "node-a", time.Now().Add(time.Minute), builtinTaskTypes)
+	if err != nil || task == nil {
+		t.Fatalf("认领任务失败: %v", err)
+	}
+
+	if _, err := repo.CancelModelBatchTask(ctx, task.ID); err != nil {
+		t.Fatalf("请求取消任务失败: %v", err)
+	}
+	cancelRequested, err := repo.RenewModelBatchTaskLease(ctx, task.ID, "node-a", time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("续约任务租约失败: %v", err)
+	}
+	if !cancelRequested {
+		t.Fatalf("续约时应返回取消请求")
+	}
+
+	if err := repo.ReleaseModelBatchTask(ctx, task.ID, "node-a"); err != nil {
+		t.Fatalf("释放任务租约失败: %v", err)
+	}
+	stored, err := repo.GetModelBatchTaskByID(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	if stored.Status != types.ModelBatchTaskStatusPending || stored.LeaseOwner != "" || stored.Attempts != 0 {
+		t.Fatalf("释放后任务应恢复为 pending 且不计执行次数: status=%s, owner=%q, attempts=%d", stored.Status, stored.LeaseOwner, stored.Attempts)
 	}
 }
 
@@ -175,7 +360,7 @@ func TestModelBatchTaskRepository_FinishTask(t *testing.T) {
 	}
 
 	resultJSON := `{"success_count":1,"failed_count":0}`
-	if err := repo.FinishModelBatchTask(ctx, seed.ID, types.ModelBatchTaskStatusSucceeded, resultJSON, ""); err != nil {
+	if err := repo.FinishModelBatchTask(ctx, seed.ID, "", types.ModelBatchTaskStatusSucceeded, resultJSON, ""); err != nil {
 		t.Fatalf("完成模型批量任务失败: %v", err)
 	}
 
@@ -193,3 +378,215 @@ func TestModelBatchTaskRepository_FinishTask(t *testing.T) {
 		t.Fatalf("完成后 FinishedAt 不应为 nil")
 	}
 }
+
+func TestModelBatchTaskRepository_ListTasks(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	seeds := []*types.ModelBatchTask{
+		{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusSucceeded, PlatformID: 1, Payload: "{}"},
+		{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusFailed, PlatformID: 2, Payload: "{}"},
+		{Type: types.KeyBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusFailed, PlatformID: 1, Payload: "{}"},
+		{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusFailed, PlatformID: 1, Payload: "{}"},
+	}
+	for _, seed := range seeds {
+		if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+			t.Fatalf("准备测试任务数据失败: %v", err)
+		}
+	}
+
+	platformID := uint(1)
+	tasks, total, err := repo.ListModelBatchTasks(ctx, ListTasksOptions{
+		Type:       types.ModelBatchTaskTypeAdd,
+		PlatformID: &platformID,
+		Page:       1,
+		PageSize:   10,
+	})
+	if err != nil {
+		t.Fatalf("查询任务列表失败: %v", err)
+	}
+	if total != 2 || len(tasks) != 2 {
+		t.Fatalf("按类型与平台筛选的任务数量不匹配: total=%d, len=%d", total, len(tasks))
+	}
+	if tasks[0].ID != seeds[3].ID {
+		t.Fatalf("任务列表应按 id 倒序: first=%d, want=%d", tasks[0].ID, seeds[3].ID)
+	}
+
+	tasks, total, err = repo.ListModelBatchTasks(ctx, ListTasksOptions{
+		Status:   types.ModelBatchTaskStatusFailed,
+		Page:     2,
+		PageSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("查询任务列表失败: %v", err)
+	}
+	if total != 3 || len(tasks) != 1 || tasks[0].ID != seeds[1].ID {
+		t.Fatalf("按状态分页查询结果不匹配: total=%d, len=%d", total, len(tasks))
+	}
+}
+
+func TestModelBatchTaskRepository_CancelTask(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	pending := &types.ModelBatchTask{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusPending, Payload: "{}"}
+	running := &types.ModelBatchTask{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusRunning, Payload: "{}"}
+	succeeded := &types.ModelBatchTask{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusSucceeded, Payload: "{}"}
+	for _, seed := range []*types.ModelBatchTask{pending, running, succeeded} {
+		if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+			t.Fatalf("准备测试任务数据失败: %v", err)
+		}
+	}
+
+	status, err := repo.CancelModelBatchTask(ctx, pending.ID)
+	if err != nil || status != types.ModelBatchTaskStatusCanceled {
+		t.Fatalf("pending 任务应直接取消: status=%s, err=%v", status, err)
+	}
+
+	status, err = repo.CancelModelBatchTask(ctx, running.ID)
+	if err != nil || status != types.ModelBatchTaskStatusRunning {
+		t.Fatalf("running 任务应记录取消请求: status=%s, err=%v", status, err)
+	}
+	stored, err := repo.GetModelBatchTaskByID(ctx, running.ID)
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	if !stored.CancelRequested || stored.Status != types.ModelBatchTaskStatusRunning {
+		t.Fatalf("running 任务应保持运行中并标记取消请求: status=%s, cancel_requested=%v", stored.Status, stored.CancelRequested)
+	}
+
+	if _, err := repo.CancelModelBatchTask(ctx, succeeded.ID); !errors.Is(err, ErrTaskStateConflict) {
+		t.Fatalf("已结束任务取消应返回状态冲突: %v", err)
+	}
+	if _, err := repo.CancelModelBatchTask(ctx, 9999); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("不存在任务取消应返回未找到: %v", err)
+	}
+}
+
+func TestModelBatchTaskRepository_RetryTask(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	finishedAt := seedTime()
+	failed := &types.ModelBatchTask{
+		Type:         types.ModelBatchTaskTypeAdd,
+		Status:       types.ModelBatchTaskStatusFailed,
+		Payload:      "{}",
+		ErrorMessage: "upstream error",
+		Attempts:     3,
+		MaxAttempts:  3,
+		FinishedAt:   &finishedAt,
+	}
+	running := &types.ModelBatchTask{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusRunning, Payload: "{}"}
+	for _, seed := range []*types.ModelBatchTask{failed, running} {
+		if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+			t.Fatalf("准备测试任务数据失败: %v", err)
+		}
+	}
+
+	if err := repo.RetryModelBatchTask(ctx, failed.ID); err != nil {
+		t.Fatalf("重试失败任务失败: %v", err)
+	}
+	stored, err := repo.GetModelBatchTaskByID(ctx, failed.ID)
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	if stored.Status != types.ModelBatchTaskStatusPending || stored.Attempts != 0 || stored.ErrorMessage != "" || stored.FinishedAt != nil {
+		t.Fatalf("重试后任务应重置为 pending: status=%s, attempts=%d, error=%q", stored.Status, stored.Attempts, stored.ErrorMessage)
+	}
+
+	if err := repo.RetryModelBatchTask(ctx, running.ID); !errors.Is(err, ErrTaskStateConflict) {
+		t.Fatalf("运行中任务重试应返回状态冲突: %v", err)
+	}
+}
+
+func TestModelBatchTaskRepository_FinishTask_ClearsSecretPayload(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	keyImport := &types.ModelBatchTask{Type: types.KeyBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusRunning, LeaseOwner: "node-a", Payload: `{"values":["sk-secret"]}`}
+	modelAdd := &types.ModelBatchTask{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusRunning, LeaseOwner: "node-a", Payload: `{"platform_id":1}`}
+	for _, seed := range []*types.ModelBatchTask{keyImport, modelAdd} {
+		if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+			t.Fatalf("准备测试任务数据失败: %v", err)
+		}
+		if err := repo.FinishModelBatchTask(ctx, seed.ID, "node-a", types.ModelBatchTaskStatusFailed, "", "upstream error"); err != nil {
+			t.Fatalf("完成任务失败: %v", err)
+		}
+	}
+
+	stored, err := repo.GetModelBatchTaskByID(ctx, keyImport.ID)
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	if stored.Payload != "" {
+		t.Fatalf("密钥导入任务结束后应清空载荷: %q", stored.Payload)
+	}
+	if err := repo.RetryModelBatchTask(ctx, keyImport.ID); !errors.Is(err, ErrTaskStateConflict) {
+		t.Fatalf("载荷已清空的任务重试应返回状态冲突: %v", err)
+	}
+
+	stored, err = repo.GetModelBatchTaskByID(ctx, modelAdd.ID)
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	if stored.Payload != modelAdd.Payload {
+		t.Fatalf("其他任务的载荷应保留: %q", stored.Payload)
+	}
+}
+
+func TestModelBatchTaskRepository_DeleteFinishedTasks(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	now := time.Now()
+	old, recent := now.Add(-48*time.Hour), now.Add(-time.Hour)
+	seeds := []*types.ModelBatchTask{
+		{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusSucceeded, Payload: "{}", FinishedAt: &old},
+		{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusFailed, Payload: "{}", FinishedAt: &old},
+		{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusSucceeded, Payload: "{}", FinishedAt: &recent},
+		{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusPending, Payload: "{}"},
+	}
+	for _, seed := range seeds {
+		if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+			t.Fatalf("准备测试任务数据失败: %v", err)
+		}
+	}
+
+	deleted, err := repo.DeleteFinishedModelBatchTasks(ctx, now.Add(-24*time.Hour), 1)
+	if err != nil || deleted != 1 {
+		t.Fatalf("单次删除数量应受上限约束: deleted=%d, err=%v", deleted, err)
+	}
+	deleted, err = repo.DeleteFinishedModelBatchTasks(ctx, now.Add(-24*time.Hour), 10)
+	if err != nil || deleted != 1 {
+		t.Fatalf("应删除剩余的过期任务: deleted=%d, err=%v", deleted, err)
+	}
+
+	var remaining int64
+	db.Model(&types.ModelBatchTask{}).Count(&remaining)
+	if remaining != 2 {
+		t.Fatalf("未过期与未结束的任务应保留: remaining=%d", remaining)
+	}
+}
+
+func TestModelBatchTaskRepository_ClaimTask_FiltersRegisteredTypes(t *testing.T) {
+	ctx, db := newModelBatchTaskRepoTestContext(t)
+	repo := NewModelBatchTaskGormRepository(slog.Default())
+
+	foreign := &types.ModelBatchTask{Type: "other.only", Status: types.ModelBatchTaskStatusPending, Payload: "{}"}
+	local := &types.ModelBatchTask{Type: types.ModelBatchTaskTypeAdd, Status: types.ModelBatchTaskStatusPending, Payload: "{}"}
+	for _, seed := range []*types.ModelBatchTask{foreign, local} {
+		if err := db.WithContext(ctx).Create(seed).Error; err != nil {
+			t.Fatalf("准备测试任务数据失败: %v", err)
+		}
+	}
+
+	claimed, err := repo.ClaimModelBatchTask(ctx, "node-a", time.Now().Add(time.Minute), builtinTaskTypes)
+	if err != nil || claimed == nil || claimed.ID != local.ID {
+		t.Fatalf("应跳过未注册类型的任务: claimed=%+v, err=%v", claimed, err)
+	}
+	again, err := repo.ClaimModelBatchTask(ctx, "node-a", time.Now().Add(time.Minute), builtinTaskTypes)
+	if err != nil || again != nil {
+		t.Fatalf("未注册类型的任务不应被认领: again=%+v, err=%v", again, err)
+	}
+}
@@ -2,12 +2,42 @@ package provider
 
 import (
 	"context"
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/MeowSalty/pinai/database/types"
 )
 
+const (
+	defaultTaskRetryBaseDelay = 10 * time.Second // 任务首次重试的退避间隔
+	taskRetryMaxDelay         = 10 * time.Minute // 任务重试退避间隔上限
+	defaultTaskLeaseDuration  = 30 * time.Second // 任务执行租约时长，执行期间每隔三分之一租约续约一次
+	defaultTaskTimeout        = 10 * time.Minute // 单次任务执行的默认超时时间
+)
+
+// 任务执行中断的原因
+var (
+	errTaskCanceledByUser = errors.New("任务已取消")
+	errWorkerStopped      = errors.New("worker 已停止")
+)
+
+type taskProgressKey struct{}
+
+// ReportTaskProgress 上报当前任务的执行进度百分比，超出 0-100 的值会被截断。
+//
+// 仅在任务处理函数收到的 ctx 中生效，其他场景调用不产生任何效果。
+func ReportTaskProgress(ctx context.Context, percent int) {
+	report, ok := ctx.Value(taskProgressKey{}).(func(int))
+	if !ok {
+		return
+	}
+	report(min(max(percent, 0), 100))
+}
+
 func (s *service) ensureTaskRuntimeInitialized() {
 	s.workerMu.Lock()
 	defer s.workerMu.Unlock()
@@ -31,8 +61,17 @@ func (s *service) ensureTaskRuntimeInitializedLocked() {
 	if s.taskEnqueued == nil {
 		s.taskEnqueued = make(map[uint]struct{})
 	}
+	if s.taskRunning == nil {
+		s.taskRunning = make(map[uint]context.CancelCauseFunc)
+	}
+	if s.taskOwner == "" {
+		s.taskOwner = newTaskOwnerID()
+	}
 }
 
+// cacheTaskSummary 缓存未完成任务的摘要，已结束的任务从缓存中移除
+//
+// 缓存仅用于记录本实例执行中任务的进度与取消请求，已结束任务的状态以数据库为准。
 func (s *service) cacheTaskSummary(summary *ModelBatchTaskSummary) {
 	if summary == nil {
 		return
@@ -41,10 +80,23 @@ func (s *service) cacheTaskSummary(summary *ModelBatchTaskSummary) {
 	s.taskStateMu.Lock()
 	defer s.taskStateMu.Unlock()
 
+	if isTaskFinished(summary.Status) {
+		delete(s.taskStateCache, summary.ID)
+		return
+	}
 	cloned := *summary
 	s.taskStateCache[summary.ID] = &cloned
 }
 
+// isTaskFinished 判断任务是否已处于最终状态
+func isTaskFinished(status string) bool {
+	switch status {
+	case types.ModelBatchTaskStatusSucceeded, types.ModelBatchTaskStatusFailed, types.ModelBatchTaskStatusCanceled:
+		return true
+	}
+	return false
+}
+
 func (s *service) getCachedTaskSummary(taskID uint) (*ModelBatchTaskSummary, bool) {
 	s.taskStateMu.RLock()
 	defer s.taskStateMu.RUnlock()
@@ -64,21 +116,87 @@ func (s *service) removeQueuedMark(taskID uint) {
 	delete(s.taskEnqueued, taskID)
 }
 
-func (s *service) enqueueTaskInMemory(taskID uint) error {
+// notifyTaskWorker 通知本实例空闲的 worker 认领任务
+//
+// 任务已持久化，通知仅用于缩短等待；队列已满或任务已在队列中时忽略，由 worker 轮询认领。
+func (s *service) notifyTaskWorker(taskID uint) {
 	s.taskStateMu.Lock()
 	if _, exists := s.taskEnqueued[taskID]; exists {
 		s.taskStateMu.Unlock()
-		return nil
+		return
 	}
 	s.taskEnqueued[taskID] = struct{}{}
 	s.taskStateMu.Unlock()
 
 	select {
 	case s.taskQueue <- taskID:
-		return nil
 	default:
 		s.removeQueuedMark(taskID)
-		return fmt.Errorf("内存任务队列已满")
+	}
+}
+
+// notifyTaskWorkerAfter 在 delay 后通知 worker，用于重试退避
+func (s *service) notifyTaskWorkerAfter(taskID uint, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		s.notifyTaskWorker(taskID)
+	})
+}
+
+// taskRetryDelay 返回第 attempts 次执行失败后的退避间隔，按指数增长并设有上限
+func (s *service) taskRetryDelay(attempts int) time.Duration {
+	delay := s.taskRetryBaseDelay
+	if delay <= 0 {
+		delay = defaultTaskRetryBaseDelay
+	}
+	for i := 1; i < attempts && delay < taskRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, taskRetryMaxDelay)
+}
+
+// trackRunningTask 记录运行中任务的取消函数
+func (s *service) trackRunningTask(taskID uint, cancel context.CancelCauseFunc) {
+	s.taskStateMu.Lock()
+	defer s.taskStateMu.Unlock()
+	s.taskRunning[taskID] = cancel
+}
+
+func (s *service) untrackRunningTask(taskID uint) {
+	s.taskStateMu.Lock()
+	defer s.taskStateMu.Unlock()
+	delete(s.taskRunning, taskID)
+}
+
+// cancelRunningTask 中断本实例正在执行的任务，任务不在本实例执行时返回 false
+func (s *service) cancelRunningTask(taskID uint) bool {
+	s.taskStateMu.RLock()
+	cancel, ok := s.taskRunning[taskID]
+	s.taskStateMu.RUnlock()
+	if ok {
+		cancel(errTaskCanceledByUser)
+	}
+	return ok
+}
+
+// markTaskCancelRequested 在缓存中标记运行中任务已被请求取消
+func (s *service) markTaskCancelRequested(taskID uint) {
+	s.taskStateMu.Lock()
+	defer s.taskStateMu.Unlock()
+	if summary, ok := s.taskStateCache[taskID]; ok && summary != nil && summary.Status == types.ModelBatchTaskStatusRunning {
+		summary.CancelRequested = true
+	}
+}
+
+// updateTaskProgress 更新任务缓存与持久化的进度
+func (s *service) updateTaskProgress(taskID uint, progress int) {
+	s.taskStateMu.Lock()
+	if summary, ok := s.taskStateCache[taskID]; ok && summary != nil {
+		summary.Progress = progress
+	}
+	s.taskStateMu.Unlock()
+
+	if err := s.modelBatchTaskRepo.UpdateModelBatchTaskProgress(context.Background(), taskID, s.taskOwner, progress); err != nil {
+		s.logger.Warn("持久化任务进度失败", slog.Uint64("task_id", uint64(taskID)), slog.Any("error", err))
 	}
 }
 
@@ -96,40 +214,34 @@ func (s *service) loadTaskSummaryFromEntity(task *types.ModelBatchTask) *ModelBa
 	}
 
 	return &ModelBatchTaskSummary{
-		ID:           task.ID,
-		Type:         task.Type,
-		Status:       task.Status,
-		PlatformID:   task.PlatformID,
-		Result:       []byte(task.Result),
-		ErrorMessage: task.ErrorMessage,
-		StartedAt:    toTime(task.StartedAt),
-		FinishedAt:   toTime(task.FinishedAt),
-		CreatedAt:    task.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    task.UpdatedAt.Format(time.RFC3339),
+		ID:              task.ID,
+		Type:            task.Type,
+		Status:          task.Status,
+		PlatformID:      task.PlatformID,
+		Result:          []byte(task.Result),
+		ErrorMessage:    task.ErrorMessage,
+		Progress:        task.Progress,
+		Attempts:        task.Attempts,
+		MaxAttempts:     task.MaxAttempts,
+		NextRunAt:       toTime(task.NextRunAt),
+		CancelRequested: task.CancelRequested,
+		StartedAt:       toTime(task.StartedAt),
+		FinishedAt:      toTime(task.FinishedAt),
+		CreatedAt:       task.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       task.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
-func (s *service) recoverModelBatchTasks(ctx context.Context) error {
-	tasks, err := s.modelBatchTaskRepo.ListUnfinishedModelBatchTasks(ctx)
-	if err != nil {
-		return fmt.Errorf("恢复模型批量任务失败：%w", err)
+// newTaskOwnerID 生成任务租约使用的实例标识，由主机名与随机后缀组成
+func newTaskOwnerID() string {
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "pinai"
 	}
-
-	for _, task := range tasks {
-		summary := s.loadTaskSummaryFromEntity(task)
-		if summary == nil {
-			continue
-		}
-		if summary.Status == types.ModelBatchTaskStatusRunning {
-			summary.Status = types.ModelBatchTaskStatusPending
-			summary.StartedAt = nil
-		}
-		s.cacheTaskSummary(summary)
-
-		if err := s.enqueueTaskInMemory(task.ID); err != nil {
-			return fmt.Errorf("恢复任务 %d 入队失败：%w", task.ID, err)
-		}
+	if len(host) > 48 {
+		host = host[:48]
 	}
-
-	return nil
+	return host + "-" + hex.EncodeToString(buf)
 }
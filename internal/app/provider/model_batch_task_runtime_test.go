@@ -1,9 +1,18 @@
 package provider
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/MeowSalty/pinai/database/query"
 	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestModelBatchTaskRuntime_CacheClone(t *testing.T) {
@@ -38,14 +47,317 @@ func TestModelBatchTaskRuntime_EnqueueDeduplicate(t *testing.T) {
 		taskQueue:      make(chan uint, 2),
 	}
 
-	if err := s.enqueueTaskInMemory(10); err != nil {
-		t.Fatalf("首次入队失败: %v", err)
-	}
-	if err := s.enqueueTaskInMemory(10); err != nil {
-		t.Fatalf("重复入队不应报错: %v", err)
-	}
+	s.notifyTaskWorker(10)
+	s.notifyTaskWorker(10)
 
 	if len(s.taskQueue) != 1 {
 		t.Fatalf("重复入队应被去重: got=%d, want=1", len(s.taskQueue))
 	}
 }
+
+func newTaskRuntimeTestService(t *testing.T) *service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&types.ModelBatchTask{}); err != nil {
+		t.Fatalf("迁移模型批量任务表失败: %v", err)
+	}
+	query.SetDefault(db)
+
+	s := New(slog.Default(), nil, WithTaskConcurrency(2)).(*service)
+	s.taskRetryBaseDelay = 10 * time.Millisecond
+	return s
+}
+
+func waitTaskStatus(t *testing.T, s *service, taskID uint, status string) *ModelBatchTaskSummary {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		summary, err := s.GetModelBatchTask(context.Background(), taskID)
+		if err != nil {
+			t.Fatalf("查询任务失败: %v", err)
+		}
+		if summary.Status == status {
+			return summary
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("等待任务 %d 进入 %s 状态超时", taskID, status)
+	return nil
+}
+
+func TestModelBatchTaskRuntime_RetryWithBackoff(t *testing.T) {
+	s := newTaskRuntimeTestService(t)
+
+	var calls atomic.Int32
+	err := s.RegisterTaskHandler("test.flaky", func(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("upstream unavailable")
+		}
+		return map[string]int{"calls": int(calls.Load())}, nil
+	}, WithTaskMaxAttempts(2))
+	if err != nil {
+		t.Fatalf("注册任务处理函数失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.StartModelBatchTaskWorker(ctx); err != nil {
+		t.Fatalf("启动 worker 失败: %v", err)
+	}
+	defer s.StopModelBatchTaskWorker(context.Background())
+
+	accepted, err := s.EnqueueTask(ctx, "test.flaky", nil)
+	if err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+
+	summary := waitTaskStatus(t, s, accepted.TaskID, types.ModelBatchTaskStatusSucceeded)
+	if summary.Attempts != 2 || summary.MaxAttempts != 2 {
+		t.Fatalf("任务应在第二次执行时成功: attempts=%d, max_attempts=%d", summary.Attempts, summary.MaxAttempts)
+	}
+	if summary.Progress != 100 {
+		t.Fatalf("成功任务进度应为 100: got=%d", summary.Progress)
+	}
+}
+
+func TestModelBatchTaskRuntime_CancelAndRetry(t *testing.T) {
+	s := newTaskRuntimeTestService(t)
+
+	started := make(chan struct{}, 1)
+	var block atomic.Bool
+	block.Store(true)
+	err := s.RegisterTaskHandler("test.blocking", func(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+		if !block.Load() {
+			return nil, nil
+		}
+		ReportTaskProgress(ctx, 40)
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("注册任务处理函数失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.StartModelBatchTaskWorker(ctx); err != nil {
+		t.Fatalf("启动 worker 失败: %v", err)
+	}
+	defer s.StopModelBatchTaskWorker(context.Background())
+
+	accepted, err := s.EnqueueTask(ctx, "test.blocking", nil)
+	if err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("等待任务开始执行超时")
+	}
+
+	if _, err := s.RetryTask(ctx, accepted.TaskID); !errors.Is(err, ErrTaskStateConflict) {
+		t.Fatalf("运行中任务重试应返回状态冲突: %v", err)
+	}
+
+	summary, err := s.CancelTask(ctx, accepted.TaskID)
+	if err != nil {
+		t.Fatalf("取消任务失败: %v", err)
+	}
+	if !summary.CancelRequested {
+		t.Fatalf("运行中任务取消后应标记取消请求")
+	}
+
+	canceled := waitTaskStatus(t, s, accepted.TaskID, types.ModelBatchTaskStatusCanceled)
+	if canceled.Progress != 40 {
+		t.Fatalf("取消后应保留已上报的进度: got=%d", canceled.Progress)
+	}
+
+	block.Store(false)
+	if _, err := s.RetryTask(ctx, accepted.TaskID); err != nil {
+		t.Fatalf("重试已取消任务失败: %v", err)
+	}
+	waitTaskStatus(t, s, accepted.TaskID, types.ModelBatchTaskStatusSucceeded)
+
+	list, err := s.ListTasks(ctx, ListTasksOptions{Type: "test.blocking"})
+	if err != nil {
+		t.Fatalf("查询任务列表失败: %v", err)
+	}
+	if list.Total != 1 || list.Items[0].Attempts != 1 {
+		t.Fatalf("任务列表结果不匹配: total=%d", list.Total)
+	}
+}
+
+func TestModelBatchTaskRuntime_TaskRetryDelay(t *testing.T) {
+	s := &service{taskRetryBaseDelay: time.Second}
+
+	cases := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		20: taskRetryMaxDelay,
+	}
+	for attempts, want := range cases {
+		if got := s.taskRetryDelay(attempts); got != want {
+			t.Fatalf("第 %d 次失败的退避间隔不匹配: got=%s, want=%s", attempts, got, want)
+		}
+	}
+}
+
+func TestModelBatchTaskRuntime_CancelFromOtherInstance(t *testing.T) {
+	worker := newTaskRuntimeTestService(t)
+	worker.taskLeaseDuration = 300 * time.Millisecond
+	other := New(slog.Default(), nil).(*service)
+
+	started := make(chan struct{}, 1)
+	err := worker.RegisterTaskHandler("test.blocking", func(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("注册任务处理函数失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := worker.StartModelBatchTaskWorker(ctx); err != nil {
+		t.Fatalf("启动 worker 失败: %v", err)
+	}
+	defer worker.StopModelBatchTaskWorker(context.Background())
+
+	accepted, err := worker.EnqueueTask(ctx, "test.blocking", nil)
+	if err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("等待任务开始执行超时")
+	}
+
+	running, err := other.GetModelBatchTask(ctx, accepted.TaskID)
+	if err != nil {
+		t.Fatalf("查询任务失败: %v", err)
+	}
+	if running.Status != types.ModelBatchTaskStatusRunning {
+		t.Fatalf("任务应处于 running 状态: got=%s", running.Status)
+	}
+
+	if _, err := other.CancelTask(ctx, accepted.TaskID); err != nil {
+		t.Fatalf("从其他实例取消任务失败: %v", err)
+	}
+	waitTaskStatus(t, worker, accepted.TaskID, types.ModelBatchTaskStatusCanceled)
+}
+
+func TestModelBatchTaskRuntime_RestoreActor(t *testing.T) {
+	s := newTaskRuntimeTestService(t)
+
+	actors := make(chan audit.Actor, 1)
+	err := s.RegisterTaskHandler("test.actor", func(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+		actors <- audit.ActorFromContext(ctx)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("注册任务处理函数失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.StartModelBatchTaskWorker(ctx); err != nil {
+		t.Fatalf("启动 worker 失败: %v", err)
+	}
+	defer s.StopModelBatchTaskWorker(context.Background())
+
+	actor := audit.Actor{Identity: "admin:1a2b3c4d", ClientIP: "10.0.0.8", RequestID: "req-1"}
+	accepted, err := s.EnqueueTask(audit.WithActor(ctx, actor), "test.actor", map[string]uint{"platform_id": 1})
+	if err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+	waitTaskStatus(t, s, accepted.TaskID, types.ModelBatchTaskStatusSucceeded)
+
+	if got := <-actors; got != actor {
+		t.Fatalf("异步执行时的操作者不符: got=%+v want=%+v", got, actor)
+	}
+}
+
+func TestModelBatchTaskRuntime_TaskTimeoutAndCacheEviction(t *testing.T) {
+	s := newTaskRuntimeTestService(t)
+
+	err := s.RegisterTaskHandler("test.slow", func(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, WithTaskTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("注册任务处理函数失败: %v", err)
+	}
+	if got := s.taskTimeoutFor(types.ModelBatchTaskTypeAdd); got != defaultTaskTimeout {
+		t.Fatalf("未配置的任务类型应使用默认超时: got=%v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.StartModelBatchTaskWorker(ctx); err != nil {
+		t.Fatalf("启动 worker 失败: %v", err)
+	}
+	defer s.StopModelBatchTaskWorker(context.Background())
+
+	accepted, err := s.EnqueueTask(ctx, "test.slow", nil)
+	if err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+
+	summary := waitTaskStatus(t, s, accepted.TaskID, types.ModelBatchTaskStatusFailed)
+	if summary.ErrorMessage != context.DeadlineExceeded.Error() {
+		t.Fatalf("任务应因超时失败: %q", summary.ErrorMessage)
+	}
+	if _, ok := s.getCachedTaskSummary(accepted.TaskID); ok {
+		t.Fatalf("已结束的任务应从缓存中移除")
+	}
+}
+
+func TestModelBatchTaskRuntime_PeriodicTaskOncePerPeriod(t *testing.T) {
+	s := newTaskRuntimeTestService(t)
+	other := New(slog.Default(), nil).(*service)
+
+	handler := func(ctx context.Context, task *types.ModelBatchTask) (any, error) { return nil, nil }
+	for _, svc := range []*service{s, other} {
+		if err := svc.RegisterTaskHandler("test.periodic", handler); err != nil {
+			t.Fatalf("注册任务处理函数失败: %v", err)
+		}
+	}
+
+	pt := periodicTask{taskType: "test.periodic", interval: time.Hour}
+	now := time.Now()
+	ctx := context.Background()
+
+	accepted, err := s.enqueuePeriodicTask(ctx, pt, now)
+	if err != nil || accepted == nil {
+		t.Fatalf("首次提交周期任务失败: accepted=%+v, err=%v", accepted, err)
+	}
+	if err := s.modelBatchTaskRepo.FinishModelBatchTask(ctx, accepted.TaskID, "", types.ModelBatchTaskStatusSucceeded, "", ""); err != nil {
+		t.Fatalf("完成任务失败: %v", err)
+	}
+
+	// 其他实例在同一周期内提交时不会重复写入
+	again, err := other.enqueuePeriodicTask(ctx, pt, now)
+	if err != nil || again != nil {
+		t.Fatalf("同一周期不应重复提交: again=%+v, err=%v", again, err)
+	}
+
+	next, err := other.enqueuePeriodicTask(ctx, pt, now.Add(time.Hour))
+	if err != nil || next == nil {
+		t.Fatalf("下一周期应正常提交: next=%+v, err=%v", next, err)
+	}
+}
@@ -3,8 +3,10 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/MeowSalty/pinai/database/types"
@@ -26,6 +28,10 @@ type modelBatchDeleteTaskPayload struct {
 }
 
 func (s *service) EnqueueBatchAddModelsTask(ctx context.Context, platformId uint, models []types.Model) (*BatchTaskAcceptedResponse, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	if len(models) == 0 {
 		return nil, fmt.Errorf("必须至少提供一个模型")
 	}
@@ -38,7 +44,7 @@ func (s *service) EnqueueBatchAddModelsTask(ctx context.Context, platformId uint
 		return nil, fmt.Errorf("未找到 ID 为 %d 的平台：%w", platformId, ErrResourceNotFound)
 	}
 
-	payloadBytes, err := json.Marshal(modelBatchAddTaskPayload{PlatformID: platformId, Models: models})
+	taskPayload, err := marshalTaskPayload(ctx, modelBatchAddTaskPayload{PlatformID: platformId, Models: models})
 	if err != nil {
 		return nil, fmt.Errorf("构建批量新增任务失败：%w", err)
 	}
@@ -47,7 +53,7 @@ func (s *service) EnqueueBatchAddModelsTask(ctx context.Context, platformId uint
 		Type:       types.ModelBatchTaskTypeAdd,
 		Status:     types.ModelBatchTaskStatusPending,
 		PlatformID: platformId,
-		Payload:    string(payloadBytes),
+		Payload:    taskPayload,
 	}
 	if err := s.modelBatchTaskRepo.CreateModelBatchTask(ctx, task); err != nil {
 		return nil, err
@@ -55,15 +61,16 @@ func (s *service) EnqueueBatchAddModelsTask(ctx context.Context, platformId uint
 
 	s.ensureTaskRuntimeInitialized()
 	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
-	if err := s.enqueueTaskInMemory(task.ID); err != nil {
-		s.logger.Error("模型批量新增任务入队失败", slog.Uint64("task_id", uint64(task.ID)), slog.Any("error", err))
-		return nil, fmt.Errorf("模型批量新增任务入队失败：%w", err)
-	}
+	s.notifyTaskWorker(task.ID)
 
 	return &BatchTaskAcceptedResponse{TaskID: task.ID, Type: task.Type, Status: task.Status}, nil
 }
 
 func (s *service) EnqueueBatchUpdateModelsTask(ctx context.Context, platformId uint, updateItems []ModelUpdateItem) (*BatchTaskAcceptedResponse, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	if len(updateItems) == 0 {
 		return nil, fmt.Errorf("必须至少提供一个模型更新项")
 	}
@@ -82,7 +89,7 @@ func (s *service) EnqueueBatchUpdateModelsTask(ctx context.Context, platformId u
 		return nil, fmt.Errorf("未找到 ID 为 %d 的平台：%w", platformId, ErrResourceNotFound)
 	}
 
-	payloadBytes, err := json.Marshal(modelBatchUpdateTaskPayload{PlatformID: platformId, Models: updateItems})
+	taskPayload, err := marshalTaskPayload(ctx, modelBatchUpdateTaskPayload{PlatformID: platformId, Models: updateItems})
 	if err != nil {
 		return nil, fmt.Errorf("构建批量更新任务失败：%w", err)
 	}
@@ -91,7 +98,7 @@ func (s *service) EnqueueBatchUpdateModelsTask(ctx context.Context, platformId u
 		Type:       types.ModelBatchTaskTypeUpdate,
 		Status:     types.ModelBatchTaskStatusPending,
 		PlatformID: platformId,
-		Payload:    string(payloadBytes),
+		Payload:    taskPayload,
 	}
 	if err := s.modelBatchTaskRepo.CreateModelBatchTask(ctx, task); err != nil {
 		return nil, err
@@ -99,15 +106,16 @@ func (s *service) EnqueueBatchUpdateModelsTask(ctx context.Context, platformId u
 
 	s.ensureTaskRuntimeInitialized()
 	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
-	if err := s.enqueueTaskInMemory(task.ID); err != nil {
-		s.logger.Error("模型批量更新任务入队失败", slog.Uint64("task_id", uint64(task.ID)), slog.Any("error", err))
-		return nil, fmt.Errorf("模型批量更新任务入队失败：%w", err)
-	}
+	s.notifyTaskWorker(task.ID)
 
 	return &BatchTaskAcceptedResponse{TaskID: task.ID, Type: task.Type, Status: task.Status}, nil
 }
 
 func (s *service) EnqueueBatchDeleteModelsTask(ctx context.Context, platformId uint, modelIds []uint) (*BatchTaskAcceptedResponse, error) {
+	if err := s.ensurePlatformWritable(ctx, platformId); err != nil {
+		return nil, err
+	}
+
 	if len(modelIds) == 0 {
 		return nil, fmt.Errorf("必须至少提供一个模型 ID")
 	}
@@ -120,7 +128,7 @@ func (s *service) EnqueueBatchDeleteModelsTask(ctx context.Context, platformId u
 		return nil, fmt.Errorf("未找到 ID 为 %d 的平台：%w", platformId, ErrResourceNotFound)
 	}
 
-	payloadBytes, err := json.Marshal(modelBatchDeleteTaskPayload{PlatformID: platformId, ModelIDs: modelIds})
+	taskPayload, err := marshalTaskPayload(ctx, modelBatchDeleteTaskPayload{PlatformID: platformId, ModelIDs: modelIds})
 	if err != nil {
 		return nil, fmt.Errorf("构建批量删除任务失败：%w", err)
 	}
@@ -129,7 +137,7 @@ func (s *service) EnqueueBatchDeleteModelsTask(ctx context.Context, platformId u
 		Type:       types.ModelBatchTaskTypeDelete,
 		Status:     types.ModelBatchTaskStatusPending,
 		PlatformID: platformId,
-		Payload:    string(payloadBytes),
+		Payload:    taskPayload,
 	}
 	if err := s.modelBatchTaskRepo.CreateModelBatchTask(ctx, task); err != nil {
 		return nil, err
@@ -137,19 +145,13 @@ func (s *service) EnqueueBatchDeleteModelsTask(ctx context.Context, platformId u
 
 	s.ensureTaskRuntimeInitialized()
 	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
-	if err := s.enqueueTaskInMemory(task.ID); err != nil {
-		s.logger.Error("模型批量删除任务入队失败", slog.Uint64("task_id", uint64(task.ID)), slog.Any("error", err))
-		return nil, fmt.Errorf("模型批量删除任务入队失败：%w", err)
-	}
+	s.notifyTaskWorker(task.ID)
 
 	return &BatchTaskAcceptedResponse{TaskID: task.ID, Type: task.Type, Status: task.Status}, nil
 }
 
+// GetModelBatchTask 查询任务状态，以数据库为准，多实例部署时可查询其他实例执行的任务。
 func (s *service) GetModelBatchTask(ctx context.Context, taskID uint) (*ModelBatchTaskSummary, error) {
-	if cached, ok := s.getCachedTaskSummary(taskID); ok {
-		return cached, nil
-	}
-
 	task, err := s.modelBatchTaskRepo.GetModelBatchTaskByID(ctx, taskID)
 	if err != nil {
 		return nil, err
@@ -163,6 +165,120 @@ func (s *service) GetModelBatchTask(ctx context.Context, taskID uint) (*ModelBat
 	return summary, nil
 }
 
+// ListTasks 按类型、状态与平台分页查询异步任务。
+func (s *service) ListTasks(ctx context.Context, opts ListTasksOptions) (*TaskListResponse, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = 10
+	}
+
+	tasks, total, err := s.modelBatchTaskRepo.ListModelBatchTasks(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ModelBatchTaskSummary, 0, len(tasks))
+	for _, task := range tasks {
+		items = append(items, *s.loadTaskSummaryFromEntity(task))
+	}
+
+	return &TaskListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     opts.Page,
+		PageSize: opts.PageSize,
+	}, nil
+}
+
+// CancelTask 取消异步任务。
+//
+// pending 任务立即标记为 canceled；running 任务记录取消请求并中断执行，执行结束后标记为 canceled。
+func (s *service) CancelTask(ctx context.Context, taskID uint) (*ModelBatchTaskSummary, error) {
+	status, err := s.modelBatchTaskRepo.CancelModelBatchTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ensureTaskRuntimeInitialized()
+	if status == types.ModelBatchTaskStatusRunning {
+		s.markTaskCancelRequested(taskID)
+		s.cancelRunningTask(taskID)
+	}
+
+	task, err := s.modelBatchTaskRepo.GetModelBatchTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	summary := s.loadTaskSummaryFromEntity(task)
+	// running 任务的最终状态由执行方写入缓存，此处仅缓存已直接取消的任务
+	if status == types.ModelBatchTaskStatusCanceled {
+		s.cacheTaskSummary(summary)
+	}
+
+	s.logger.Info("已取消任务",
+		slog.Uint64("task_id", uint64(taskID)),
+		slog.String("task_type", task.Type),
+		slog.String("status", status))
+	return summary, nil
+}
+
+// RetryTask 将失败或已取消的任务重置为 pending 并重新入队，执行次数重新计算。
+func (s *service) RetryTask(ctx context.Context, taskID uint) (*ModelBatchTaskSummary, error) {
+	task, err := s.modelBatchTaskRepo.GetModelBatchTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.PlatformID != 0 {
+		if err := s.ensurePlatformWritable(ctx, task.PlatformID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.modelBatchTaskRepo.RetryModelBatchTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+	task, err = s.modelBatchTaskRepo.GetModelBatchTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ensureTaskRuntimeInitialized()
+	summary := s.loadTaskSummaryFromEntity(task)
+	s.cacheTaskSummary(summary)
+	s.notifyTaskWorker(task.ID)
+
+	s.logger.Info("已重新提交任务", slog.Uint64("task_id", uint64(taskID)), slog.String("task_type", task.Type))
+	return summary, nil
+}
+
+// taskPruneBatchSize 为单次清理删除的任务数量上限
+const taskPruneBatchSize = 1000
+
+// PruneTasks 分批删除 before 之前已结束的任务，pending 与 running 任务不受影响。
+func (s *service) PruneTasks(ctx context.Context, before time.Time) (*TaskPruneResult, error) {
+	result := &TaskPruneResult{Before: before}
+	for {
+		deleted, err := s.modelBatchTaskRepo.DeleteFinishedModelBatchTasks(ctx, before, taskPruneBatchSize)
+		if err != nil {
+			return result, err
+		}
+		result.DeletedRows += deleted
+		if deleted < taskPruneBatchSize {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+	}
+
+	if result.DeletedRows > 0 {
+		s.logger.Info("已清理过期任务", slog.Time("before", before), slog.Int64("deleted_rows", result.DeletedRows))
+	}
+	return result, nil
+}
+
 func (s *service) StartModelBatchTaskWorker(ctx context.Context) error {
 	s.workerMu.Lock()
 	if s.workerRunning {
@@ -189,33 +305,47 @@ func (s *service) StartModelBatchTaskWorker(ctx context.Context) error {
 
 	logger := s.logger.With(
 		slog.String("operation", "model_batch_task_worker"),
+		slog.String("owner", s.taskOwner),
 		slog.Int("queue_size", s.taskQueueSize),
 	)
 
-	if err := s.recoverModelBatchTasks(workerCtx); err != nil {
-		cancel()
-		s.workerMu.Lock()
-		s.workerCancel = nil
-		s.workerDone = nil
-		s.workerRunning = false
-		s.workerMu.Unlock()
-		return fmt.Errorf("启动模型批量任务 worker 失败：恢复任务失败：%w", err)
-	}
+	s.workerMu.Lock()
+	periodicTasks := append([]periodicTask(nil), s.periodicTasks...)
+	s.workerMu.Unlock()
 
-	go func() {
-		defer close(done)
-		for {
-			if err := s.processOneModelBatchTask(workerCtx); err != nil {
-				if err == context.Canceled {
-					logger.Info("模型批量任务 worker 已停止")
-					return
+	concurrency := max(s.taskConcurrency, 1)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if err := s.processOneModelBatchTask(workerCtx); err != nil {
+					if err == context.Canceled {
+						logger.Info("模型批量任务 worker 已停止")
+						return
+					}
+					logger.Error("处理模型批量任务失败", slog.Any("error", err))
 				}
-				logger.Error("处理模型批量任务失败", slog.Any("error", err))
 			}
-		}
+		}()
+	}
+
+	for _, pt := range periodicTasks {
+		wg.Add(1)
+		go func(pt periodicTask) {
+			defer wg.Done()
+			s.runPeriodicTask(workerCtx, pt)
+		}(pt)
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
 	}()
 
-	logger.Info("模型批量任务 worker 已启动")
+	logger.Info("模型批量任务 worker 已启动", slog.Int("concurrency", concurrency))
 	return nil
 }
 
@@ -253,127 +383,244 @@ func (s *service) StopModelBatchTaskWorker(ctx context.Context) error {
 	}
 }
 
+// processOneModelBatchTask 认领并执行一个任务，没有可执行任务时等待本实例的入队通知或轮询周期。
+//
+// 任务通过数据库租约认领，多实例部署时同一任务只由一个实例执行；执行实例失联后租约过期，任务由其他实例接管。
 func (s *service) processOneModelBatchTask(ctx context.Context) error {
-	select {
-	case <-ctx.Done():
-		return context.Canceled
-	case taskID := <-s.taskQueue:
-		defer s.removeQueuedMark(taskID)
-
-		task, err := s.modelBatchTaskRepo.GetModelBatchTaskByID(ctx, taskID)
-		if err != nil {
-			return err
+	task, err := s.modelBatchTaskRepo.ClaimModelBatchTask(ctx, s.taskOwner, time.Now().Add(s.taskLeaseDuration), s.claimableTaskTypes())
+	if err != nil || task == nil {
+		pollInterval := time.Duration(max(s.workerPollSecond, 1)) * time.Second
+		select {
+		case <-ctx.Done():
+			return context.Canceled
+		case taskID := <-s.taskQueue:
+			s.removeQueuedMark(taskID)
+		case <-time.After(pollInterval):
 		}
+		return err
+	}
 
-		startedAt := time.Now()
-		if err := s.modelBatchTaskRepo.MarkModelBatchTaskRunning(ctx, task.ID, startedAt); err != nil {
-			s.logger.Error("标记模型批量任务运行中失败", slog.Uint64("task_id", uint64(task.ID)), slog.Any("error", err))
-			return nil
-		}
+	logger := s.logger.With(
+		slog.String("operation", "model_batch_task"),
+		slog.Uint64("task_id", uint64(task.ID)),
+		slog.String("task_type", task.Type),
+		slog.Uint64("platform_id", uint64(task.PlatformID)),
+		slog.Int("attempts", task.Attempts),
+	)
 
-		task.Status = types.ModelBatchTaskStatusRunning
-		task.StartedAt = &startedAt
-		task.ErrorMessage = ""
-		task.Result = ""
-		s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
-
-		logger := s.logger.With(
-			slog.String("operation", "model_batch_task"),
-			slog.Uint64("task_id", uint64(task.ID)),
-			slog.String("task_type", task.Type),
-			slog.Uint64("platform_id", uint64(task.PlatformID)),
-		)
-
-		result, runErr := s.executeModelBatchTask(ctx, task)
-		if runErr != nil {
-			finishedAt := time.Now()
-			task.Status = types.ModelBatchTaskStatusFailed
-			task.FinishedAt = &finishedAt
-			task.ErrorMessage = runErr.Error()
-			s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
-
-			if err := s.modelBatchTaskRepo.FinishModelBatchTask(context.Background(), task.ID, types.ModelBatchTaskStatusFailed, "", runErr.Error()); err != nil {
-				logger.Error("更新模型批量任务失败状态失败", slog.Any("error", err))
+	if task.CancelRequested {
+		s.finishModelBatchTask(task, types.ModelBatchTaskStatusCanceled, "", errTaskCanceledByUser.Error(), logger)
+		logger.Info("任务已取消")
+		return nil
+	}
+	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	runCtx = context.WithValue(runCtx, taskProgressKey{}, func(progress int) {
+		s.updateTaskProgress(task.ID, progress)
+	})
+	s.trackRunningTask(task.ID, cancel)
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		s.renewTaskLease(runCtx, task.ID, cancel, logger)
+	}()
+
+	result, runErr := s.executeModelBatchTask(runCtx, task)
+	cause := context.Cause(runCtx)
+	if ctx.Err() != nil {
+		cause = errWorkerStopped
+	}
+	cancel(nil)
+	<-heartbeatDone
+	s.untrackRunningTask(task.ID)
+
+	if runErr != nil {
+		switch {
+		case errors.Is(cause, errWorkerStopped):
+			// worker 停止导致的中断不计为失败，释放租约使任务可立即被其他实例或重启后的本实例接管
+			if err := s.modelBatchTaskRepo.ReleaseModelBatchTask(context.Background(), task.ID, s.taskOwner); err != nil {
+				logger.Warn("释放任务租约失败，任务将在租约过期后被接管", slog.Any("error", err))
 			}
+			logger.Warn("worker 已停止，任务将重新执行", slog.Any("error", runErr))
+		case errors.Is(cause, ErrTaskLeaseLost):
+			logger.Warn("任务租约已失效，已由其他实例接管，放弃本次执行结果", slog.Any("error", runErr))
+		case errors.Is(cause, errTaskCanceledByUser):
+			s.finishModelBatchTask(task, types.ModelBatchTaskStatusCanceled, "", errTaskCanceledByUser.Error(), logger)
+			logger.Info("任务已取消")
+		case task.Attempts < task.MaxAttempts:
+			s.scheduleModelBatchTaskRetry(task, runErr, logger)
+		default:
+			s.finishModelBatchTask(task, types.ModelBatchTaskStatusFailed, "", runErr.Error(), logger)
 			logger.Error("模型批量任务执行失败", slog.Any("error", runErr))
-			return nil
 		}
+		return nil
+	}
 
-		resultBytes, err := json.Marshal(result)
-		if err != nil {
-			finishedAt := time.Now()
-			task.Status = types.ModelBatchTaskStatusFailed
-			task.FinishedAt = &finishedAt
-			task.ErrorMessage = fmt.Sprintf("序列化任务结果失败：%v", err)
-			s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
-
-			if finishErr := s.modelBatchTaskRepo.FinishModelBatchTask(context.Background(), task.ID, types.ModelBatchTaskStatusFailed, "", task.ErrorMessage); finishErr != nil {
-				logger.Error("更新模型批量任务失败状态失败", slog.Any("error", finishErr))
-			}
-			logger.Error("序列化模型批量任务结果失败", slog.Any("error", err))
-			return nil
-		}
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		s.finishModelBatchTask(task, types.ModelBatchTaskStatusFailed, "", fmt.Sprintf("序列化任务结果失败：%v", err), logger)
+		logger.Error("序列化模型批量任务结果失败", slog.Any("error", err))
+		return nil
+	}
 
-		finishedAt := time.Now()
-		task.Status = types.ModelBatchTaskStatusSucceeded
-		task.FinishedAt = &finishedAt
-		task.ErrorMessage = ""
-		task.Result = string(resultBytes)
-		s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
+	s.finishModelBatchTask(task, types.ModelBatchTaskStatusSucceeded, string(resultBytes), "", logger)
+	logger.Info("模型批量任务执行成功")
+	return nil
+}
+
+// renewTaskLease 在任务执行期间周期续约租约，租约失效或任务被请求取消时中断执行
+func (s *service) renewTaskLease(ctx context.Context, taskID uint, cancel context.CancelCauseFunc, logger *slog.Logger) {
+	ticker := time.NewTicker(max(s.taskLeaseDuration/3, 10*time.Millisecond))
+	defer ticker.Stop()
 
-		if err := s.modelBatchTaskRepo.FinishModelBatchTask(context.Background(), task.ID, types.ModelBatchTaskStatusSucceeded, string(resultBytes), ""); err != nil {
-			logger.Error("更新模型批量任务成功状态失败", slog.Any("error", err))
-			return nil
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 
-		logger.Info("模型批量任务执行成功")
-		return nil
+		cancelRequested, err := s.modelBatchTaskRepo.RenewModelBatchTaskLease(ctx, taskID, s.taskOwner, time.Now().Add(s.taskLeaseDuration))
+		switch {
+		case errors.Is(err, ErrTaskLeaseLost):
+			cancel(ErrTaskLeaseLost)
+			return
+		case err != nil:
+			if ctx.Err() == nil {
+				logger.Warn("续约任务租约失败，将在下个周期重试", slog.Any("error", err))
+			}
+		case cancelRequested:
+			// 取消请求可能来自其他实例
+			cancel(errTaskCanceledByUser)
+			return
+		}
+	}
+}
+
+// finishModelBatchTask 更新任务缓存并持久化最终状态，失败或取消时保留已上报的进度
+func (s *service) finishModelBatchTask(task *types.ModelBatchTask, status, result, errorMessage string, logger *slog.Logger) {
+	finishedAt := time.Now()
+	task.Status = status
+	task.FinishedAt = &finishedAt
+	task.Result = result
+	task.ErrorMessage = errorMessage
+	if status == types.ModelBatchTaskStatusSucceeded {
+		task.Progress = 100
+	} else if cached, ok := s.getCachedTaskSummary(task.ID); ok {
+		task.Progress = cached.Progress
+	}
+	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
+
+	if err := s.modelBatchTaskRepo.FinishModelBatchTask(context.Background(), task.ID, s.taskOwner, status, result, errorMessage); err != nil {
+		logger.Error("更新模型批量任务最终状态失败", slog.String("status", status), slog.Any("error", err))
 	}
 }
 
-func (s *service) executeModelBatchTask(ctx context.Context, task *types.ModelBatchTask) (*BatchTaskResult, error) {
+// scheduleModelBatchTaskRetry 将执行失败的任务重置为 pending，并在退避间隔后重新入队
+func (s *service) scheduleModelBatchTaskRetry(task *types.ModelBatchTask, runErr error, logger *slog.Logger) {
+	delay := s.taskRetryDelay(task.Attempts)
+	nextRunAt := time.Now().Add(delay)
+	if err := s.modelBatchTaskRepo.ScheduleModelBatchTaskRetry(context.Background(), task.ID, s.taskOwner, nextRunAt, runErr.Error()); err != nil {
+		logger.Error("安排任务重试失败", slog.Any("error", err))
+		s.finishModelBatchTask(task, types.ModelBatchTaskStatusFailed, "", runErr.Error(), logger)
+		return
+	}
+
+	task.Status = types.ModelBatchTaskStatusPending
+	task.ErrorMessage = runErr.Error()
+	task.NextRunAt = &nextRunAt
+	task.StartedAt = nil
+	task.Progress = 0
+	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
+	s.notifyTaskWorkerAfter(task.ID, delay)
+
+	logger.Warn("任务执行失败，将在退避后重试",
+		slog.Int("attempts", task.Attempts),
+		slog.Int("max_attempts", task.MaxAttempts),
+		slog.Duration("delay", delay),
+		slog.Any("error", runErr))
+}
+
+func (s *service) executeModelBatchTask(ctx context.Context, task *types.ModelBatchTask) (any, error) {
 	if task == nil {
 		return nil, fmt.Errorf("执行模型批量任务失败：任务为空")
 	}
 
-	taskCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	handler, ok := s.getTaskHandler(task.Type)
+	if !ok {
+		return nil, fmt.Errorf("不支持的模型批量任务类型：%s", task.Type)
+	}
+
+	// 恢复入队时的发起方，异步执行产生的审计记录归属于发起方而非匿名操作者
+	taskCtx, cancel := context.WithTimeout(withTaskActor(ctx, task.Payload), s.taskTimeoutFor(task.Type))
 	defer cancel()
 
-	switch task.Type {
-	case types.ModelBatchTaskTypeAdd:
-		var payload modelBatchAddTaskPayload
-		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
-			return nil, fmt.Errorf("解析批量新增任务载荷失败：%w", err)
-		}
-		created, err := s.batchAddModelsToPlatformApp(taskCtx, payload.PlatformID, payload.Models)
-		if err != nil {
-			return nil, err
-		}
-		return &BatchTaskResult{TotalCount: len(payload.Models), CreatedCount: len(created)}, nil
+	return handler(taskCtx, task)
+}
 
-	case types.ModelBatchTaskTypeUpdate:
-		var payload modelBatchUpdateTaskPayload
-		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
-			return nil, fmt.Errorf("解析批量更新任务载荷失败：%w", err)
-		}
-		updated, err := s.batchUpdateModelsApp(taskCtx, payload.PlatformID, payload.Models)
-		if err != nil {
-			return nil, err
-		}
-		return &BatchTaskResult{TotalCount: len(payload.Models), UpdatedCount: len(updated)}, nil
+// runModelBatchAddTask 执行模型批量新增任务
+func (s *service) runModelBatchAddTask(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+	var payload modelBatchAddTaskPayload
+	if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("解析批量新增任务载荷失败：%w", err)
+	}
+	created, err := s.batchAddModelsToPlatformApp(ctx, payload.PlatformID, payload.Models)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchTaskResult{TotalCount: len(payload.Models), CreatedCount: len(created)}, nil
+}
 
-	case types.ModelBatchTaskTypeDelete:
-		var payload modelBatchDeleteTaskPayload
-		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
-			return nil, fmt.Errorf("解析批量删除任务载荷失败：%w", err)
-		}
-		deleted, err := s.batchDeleteModelsApp(taskCtx, payload.PlatformID, payload.ModelIDs)
-		if err != nil {
-			return nil, err
-		}
-		return &BatchTaskResult{TotalCount: len(payload.ModelIDs), DeletedCount: deleted}, nil
+// runModelBatchUpdateTask 执行模型批量更新任务
+func (s *service) runModelBatchUpdateTask(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+	var payload modelBatchUpdateTaskPayload
+	if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("解析批量更新任务载荷失败：%w", err)
+	}
+	updated, err := s.batchUpdateModelsApp(ctx, payload.PlatformID, payload.Models)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchTaskResult{TotalCount: len(payload.Models), UpdatedCount: len(updated)}, nil
+}
 
-	default:
-		return nil, fmt.Errorf("不支持的模型批量任务类型：%s", task.Type)
+// runModelBatchDeleteTask 执行模型批量删除任务
+func (s *service) runModelBatchDeleteTask(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+	var payload modelBatchDeleteTaskPayload
+	if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("解析批量删除任务载荷失败：%w", err)
+	}
+	deleted, err := s.batchDeleteModelsApp(ctx, payload.PlatformID, payload.ModelIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchTaskResult{TotalCount: len(payload.ModelIDs), DeletedCount: deleted}, nil
+}
+
+// runKeyBatchAddTask 执行密钥批量导入任务
+func (s *service) runKeyBatchAddTask(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+	var payload keyBatchAddTaskPayload
+	if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("解析密钥批量导入任务载荷失败：%w", err)
+	}
+	return s.batchAddKeysApp(ctx, payload)
+}
+
+// runKeyBatchDeleteTask 执行密钥批量删除任务
+func (s *service) runKeyBatchDeleteTask(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+	var payload keyBatchDeleteTaskPayload
+	if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("解析密钥批量删除任务载荷失败：%w", err)
+	}
+	return s.batchDeleteKeysApp(ctx, payload)
+}
+
+// runKeyBatchUpdateModelsTask 执行密钥关联批量更新任务
+func (s *service) runKeyBatchUpdateModelsTask(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+	var payload keyBatchUpdateModelsTaskPayload
+	if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("解析密钥关联批量更新任务载荷失败：%w", err)
 	}
+	return s.batchUpdateKeyModelsApp(ctx, payload)
 }
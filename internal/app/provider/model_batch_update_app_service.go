@@ -140,16 +140,16 @@ func (s *service) batchUpdateModelsApp(ctx context.Context, platformID uint, upd
 	})
 	if err != nil {
 		logger.Error("批量更新模型事务失败", slog.Any("error", err))
-		_ = s.logModelBatchUpdateAudit(ctx, platformID, "failed", fmt.Sprintf("批量更新模型失败：%v", err))
+		_ = s.logModelBatchUpdateAudit(ctx, platformID, "failed", fmt.Sprintf("批量更新模型失败：%v", err), nil, nil)
 		return nil, fmt.Errorf("批量更新模型失败：%w", err)
 	}
 
 	logger.Info("成功批量更新模型", slog.Int("updated_count", len(updatedModels)))
-	_ = s.logModelBatchUpdateAudit(ctx, platformID, "success", fmt.Sprintf("批量更新模型成功，更新数量 %d", len(updatedModels)))
+	_ = s.logModelBatchUpdateAudit(ctx, platformID, "success", fmt.Sprintf("批量更新模型成功，更新数量 %d", len(updatedModels)), models, updatedModels)
 	return updatedModels, nil
 }
 
-func (s *service) logModelBatchUpdateAudit(ctx context.Context, platformID uint, result, detail string) error {
+func (s *service) logModelBatchUpdateAudit(ctx context.Context, platformID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -160,5 +160,7 @@ func (s *service) logModelBatchUpdateAudit(ctx context.Context, platformID uint,
 		ResourceID: platformID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -25,7 +25,7 @@ func (s *service) deleteModelApp(ctx context.Context, modelID uint) error {
 	model, err := s.modelControlRepo.GetModel(ctx, modelID)
 	if err != nil {
 		logger.Warn("查询模型失败", slog.Any("error", err))
-		_ = s.logModelDeleteAudit(ctx, modelID, "failed", fmt.Sprintf("查询模型失败：%v", err))
+		_ = s.logModelDeleteAudit(ctx, modelID, "failed", fmt.Sprintf("查询模型失败：%v", err), nil, nil)
 		return err
 	}
 
@@ -55,7 +55,7 @@ func (s *service) deleteModelApp(ctx context.Context, modelID uint) error {
 	})
 	if err != nil {
 		logger.Error("删除模型事务失败", slog.Any("error", err))
-		_ = s.logModelDeleteAudit(ctx, modelID, "failed", fmt.Sprintf("删除模型失败：%v", err))
+		_ = s.logModelDeleteAudit(ctx, modelID, "failed", fmt.Sprintf("删除模型失败：%v", err), nil, nil)
 		return fmt.Errorf("删除模型失败：%w", err)
 	}
 
@@ -64,11 +64,11 @@ func (s *service) deleteModelApp(ctx context.Context, modelID uint) error {
 		slog.Uint64("platform_id", uint64(model.PlatformID)),
 		slog.Int("api_key_relation_count", relationCount),
 	)
-	_ = s.logModelDeleteAudit(ctx, modelID, "success", fmt.Sprintf("删除模型成功，platform_id=%d，关联密钥数=%d", model.PlatformID, relationCount))
+	_ = s.logModelDeleteAudit(ctx, modelID, "success", fmt.Sprintf("删除模型成功，platform_id=%d，关联密钥数=%d", model.PlatformID, relationCount), model, nil)
 	return nil
 }
 
-func (s *service) logModelDeleteAudit(ctx context.Context, modelID uint, result, detail string) error {
+func (s *service) logModelDeleteAudit(ctx context.Context, modelID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -79,5 +79,7 @@ func (s *service) logModelDeleteAudit(ctx context.Context, modelID uint, result,
 		ResourceID: modelID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -28,6 +28,7 @@ func (s *service) UpdateModelHealthEnabled(ctx context.Context, modelID uint, en
 		return types.HealthStatusUnknown, fmt.Errorf("查询模型失败：%w", err)
 	}
 
+	beforeHealth := s.healthAuditSnapshot(types.ResourceTypeModel, modelID)
 	status := types.HealthStatusUnavailable
 	auditResult := "success"
 	auditDetail := "禁用模型健康状态"
@@ -51,16 +52,16 @@ func (s *service) UpdateModelHealthEnabled(ctx context.Context, modelID uint, en
 		auditResult = "failed"
 		auditDetail = fmt.Sprintf("更新模型健康状态失败：%v", err)
 		logger.Error("更新模型健康状态失败", slog.Any("error", err))
-		_ = s.logModelControlAudit(ctx, modelID, auditResult, auditDetail)
+		_ = s.logModelControlAudit(ctx, modelID, auditResult, auditDetail, nil, nil)
 		return types.HealthStatusUnknown, fmt.Errorf("更新模型健康状态失败：%w", err)
 	}
 
 	logger.Info("更新模型健康状态成功", slog.Int("status", int(status)))
-	_ = s.logModelControlAudit(ctx, modelID, auditResult, auditDetail)
+	_ = s.logModelControlAudit(ctx, modelID, auditResult, auditDetail, beforeHealth, healthAuditState(status))
 	return status, nil
 }
 
-func (s *service) logModelControlAudit(ctx context.Context, modelID uint, result, detail string) error {
+func (s *service) logModelControlAudit(ctx context.Context, modelID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -71,5 +72,7 @@ func (s *service) logModelControlAudit(ctx context.Context, modelID uint, result
 		ResourceID: modelID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -0,0 +1,330 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// ModelLister 定义向上游请求平台模型列表的能力
+type ModelLister interface {
+	// ListModels 使用平台的默认端点请求模型列表，platform 须包含端点列表
+	ListModels(ctx context.Context, platform *types.Platform, keyValue string) ([]string, error)
+}
+
+// WithModelLister 设置上游模型列表读取器，未设置时模型同步接口返回错误
+func WithModelLister(lister ModelLister) Option {
+	return func(s *service) {
+		if lister != nil {
+			s.modelLister = lister
+		}
+	}
+}
+
+// modelSyncTaskPayload 为模型同步任务载荷
+//
+// PlatformID 为 0 时同步全部开启自动同步的平台，否则按载荷中的差异应用到指定平台。
+type modelSyncTaskPayload struct {
+	PlatformID uint     `json:"platform_id,omitempty"`
+	KeyID      uint     `json:"key_id,omitempty"`
+	Added      []string `json:"added,omitempty"`
+	RemovedIDs []uint   `json:"removed_ids,omitempty"`
+}
+
+// SyncPlatformModels 对比上游模型列表与平台现有模型，dryRun 为 false 且存在差异时提交同步任务
+func (s *service) SyncPlatformModels(ctx context.Context, platformID uint, dryRun bool) (*ModelSyncResponse, error) {
+	if err := s.ensurePlatformWritable(ctx, platformID); err != nil {
+		return nil, err
+	}
+
+	logger := s.logger.With(
+		slog.String("operation", "model_sync"),
+		slog.Uint64("platform_id", uint64(platformID)),
+		slog.Bool("dry_run", dryRun),
+	)
+
+	preview, err := s.previewModelSync(ctx, platformID)
+	if err != nil {
+		logger.Warn("计算模型同步差异失败", slog.Any("error", err))
+		return nil, err
+	}
+
+	resp := &ModelSyncResponse{Preview: preview}
+	if dryRun || (len(preview.Added) == 0 && len(preview.Removed) == 0) {
+		return resp, nil
+	}
+
+	payload := modelSyncTaskPayload{PlatformID: platformID, KeyID: preview.KeyID, Added: preview.Added}
+	for _, removed := range preview.Removed {
+		payload.RemovedIDs = append(payload.RemovedIDs, removed.ID)
+	}
+	taskPayload, err := marshalTaskPayload(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("构建模型同步任务失败：%w", err)
+	}
+
+	task := &types.ModelBatchTask{
+		Type:        types.ModelBatchTaskTypeSync,
+		Status:      types.ModelBatchTaskStatusPending,
+		PlatformID:  platformID,
+		Payload:     taskPayload,
+		MaxAttempts: s.taskMaxAttemptsFor(types.ModelBatchTaskTypeSync),
+	}
+	if err := s.modelBatchTaskRepo.CreateModelBatchTask(ctx, task); err != nil {
+		return nil, err
+	}
+
+	s.ensureTaskRuntimeInitialized()
+	s.cacheTaskSummary(s.loadTaskSummaryFromEntity(task))
+	s.notifyTaskWorker(task.ID)
+
+	logger.Info("已提交模型同步任务",
+		slog.Uint64("task_id", uint64(task.ID)),
+		slog.Int("added", len(preview.Added)),
+		slog.Int("removed", len(preview.Removed)))
+	resp.Task = &BatchTaskAcceptedResponse{TaskID: task.ID, Type: task.Type, Status: task.Status}
+	return resp, nil
+}
+
+// UpdatePlatformModelAutoSync 设置平台是否参与上游模型列表定时同步
+func (s *service) UpdatePlatformModelAutoSync(ctx context.Context, platformID uint, enabled bool) (*types.Platform, error) {
+	if err := s.ensurePlatformWritable(ctx, platformID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.getPlatformByID(ctx, platformID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := configDB(ctx).Model(&types.Platform{}).Where("id = ?", platformID).Update("model_auto_sync", enabled).Error; err != nil {
+		_ = s.logPlatformControlAudit(ctx, platformID, "platform.model_auto_sync", "failed", fmt.Sprintf("更新模型自动同步失败：%v", err), nil, nil)
+		return nil, fmt.Errorf("更新模型自动同步失败：%w", err)
+	}
+
+	updated, err := s.getPlatformByID(ctx, platformID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("已更新平台模型自动同步",
+		slog.Uint64("platform_id", uint64(platformID)),
+		slog.Bool("enabled", enabled))
+	_ = s.logPlatformControlAudit(ctx, platformID, "platform.model_auto_sync", "success", "更新模型自动同步成功", existing, updated)
+	return updated, nil
+}
+
+// previewModelSync 请求上游模型列表并与平台现有模型对比
+//
+// 依次尝试平台的密钥，跳过健康状态为不可用的密钥，使用第一个成功返回的列表。
+func (s *service) previewModelSync(ctx context.Context, platformID uint) (*ModelSyncPreview, error) {
+	if s.modelLister == nil {
+		return nil, fmt.Errorf("同步模型失败：模型列表读取器未初始化")
+	}
+
+	platform, err := s.getPlatformByID(ctx, platformID)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []types.APIKey
+	if err := configDB(ctx).Where("platform_id = ?", platformID).Order("id").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("查询平台密钥失败：%w", err)
+	}
+
+	var (
+		upstream []string
+		keyID    uint
+		listErr  error
+	)
+	for _, key := range keys {
+		if s.keyUnavailable(key.ID) {
+			continue
+		}
+		names, err := s.modelLister.ListModels(ctx, platform, key.Value)
+		if err != nil {
+			listErr = err
+			continue
+		}
+		upstream, keyID = names, key.ID
+		break
+	}
+	if keyID == 0 {
+		if listErr != nil {
+			return nil, fmt.Errorf("请求上游模型列表失败：%w", listErr)
+		}
+		return nil, fmt.Errorf("平台没有可用于请求模型列表的密钥：%w", ErrInvalidArgument)
+	}
+	// 上游返回空列表多为异常响应，拒绝同步以免删除全部模型
+	if len(upstream) == 0 {
+		return nil, fmt.Errorf("上游未返回任何模型")
+	}
+
+	var models []types.Model
+	if err := configDB(ctx).Where("platform_id = ?", platformID).Order("id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("查询平台模型失败：%w", err)
+	}
+
+	upstreamSet := make(map[string]struct{}, len(upstream))
+	for _, name := range upstream {
+		upstreamSet[name] = struct{}{}
+	}
+	existing := make(map[string]struct{}, len(models))
+
+	preview := &ModelSyncPreview{
+		PlatformID:    platformID,
+		KeyID:         keyID,
+		UpstreamCount: len(upstream),
+		Added:         []string{},
+		Removed:       []ModelSyncRemove{},
+	}
+	for _, model := range models {
+		existing[model.Name] = struct{}{}
+		if _, ok := upstreamSet[model.Name]; ok {
+			preview.UnchangedCount++
+			continue
+		}
+		preview.Removed = append(preview.Removed, ModelSyncRemove{ID: model.ID, Name: model.Name, Alias: model.Alias})
+	}
+	for _, name := range upstream {
+		if _, ok := existing[name]; !ok {
+			preview.Added = append(preview.Added, name)
+		}
+	}
+	return preview, nil
+}
+
+// keyUnavailable 判断密钥健康状态是否为不可用
+func (s *service) keyUnavailable(keyID uint) bool {
+	if s.healthReader == nil {
+		return false
+	}
+	current, err := s.healthReader.Get(types.ResourceTypeAPIKey, keyID)
+	return err == nil && current != nil && current.Status == types.HealthStatusUnavailable
+}
+
+// runModelSyncTask 执行模型同步任务
+func (s *service) runModelSyncTask(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+	var payload modelSyncTaskPayload
+	if task.Payload != "" {
+		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+			return nil, fmt.Errorf("解析模型同步任务载荷失败：%w", err)
+		}
+	}
+
+	if payload.PlatformID != 0 {
+		if err := s.ensurePlatformWritable(ctx, payload.PlatformID); err != nil {
+			return nil, err
+		}
+		result, err := s.applyModelSync(ctx, payload.PlatformID, payload.KeyID, payload.Added, payload.RemovedIDs)
+		if err != nil {
+			return nil, err
+		}
+		return &ModelSyncResult{Platforms: []ModelSyncPlatformResult{result}}, nil
+	}
+
+	return s.runModelAutoSync(ctx)
+}
+
+// runModelAutoSync 同步全部开启自动同步且未受管的平台，单个平台失败不影响其他平台
+func (s *service) runModelAutoSync(ctx context.Context) (*ModelSyncResult, error) {
+	var platformIDs []uint
+	if err := configDB(ctx).Model(&types.Platform{}).
+		Where("model_auto_sync = ? AND (managed_by = '' OR managed_by IS NULL)", true).
+		Order("id").
+		Pluck("id", &platformIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询自动同步平台失败：%w", err)
+	}
+
+	result := &ModelSyncResult{Platforms: make([]ModelSyncPlatformResult, 0, len(platformIDs))}
+	for i, platformID := range platformIDs {
+		item := ModelSyncPlatformResult{PlatformID: platformID}
+		preview, err := s.previewModelSync(ctx, platformID)
+		if err == nil {
+			removedIDs := make([]uint, 0, len(preview.Removed))
+			for _, removed := range preview.Removed {
+				removedIDs = append(removedIDs, removed.ID)
+			}
+			item, err = s.applyModelSync(ctx, platformID, preview.KeyID, preview.Added, removedIDs)
+		}
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, err
+			}
+			s.logger.Warn("平台模型自动同步失败", slog.Uint64("platform_id", uint64(platformID)), slog.Any("error", err))
+			item.Error = err.Error()
+		}
+		result.Platforms = append(result.Platforms, item)
+		ReportTaskProgress(ctx, (i+1)*100/len(platformIDs))
+	}
+	return result, nil
+}
+
+// applyModelSync 新增上游提供的模型并删除上游已下线的模型，新增模型关联请求列表所用的密钥
+func (s *service) applyModelSync(ctx context.Context, platformID, keyID uint, added []string, removedIDs []uint) (ModelSyncPlatformResult, error) {
+	result := ModelSyncPlatformResult{PlatformID: platformID}
+
+	// 任务失败重试时跳过上次已应用的部分：已存在的模型不再新增，已删除的模型不再删除
+	added, removedIDs, err := pendingModelSyncChanges(ctx, platformID, added, removedIDs)
+	if err != nil {
+		return result, err
+	}
+
+	if len(added) > 0 {
+		models := make([]types.Model, 0, len(added))
+		for _, name := range added {
+			models = append(models, types.Model{Name: name, APIKeys: []types.APIKey{{ID: keyID}}})
+		}
+		created, err := s.batchAddModelsToPlatformApp(ctx, platformID, models)
+		if err != nil {
+			return result, fmt.Errorf("新增同步模型失败：%w", err)
+		}
+		result.CreatedCount = len(created)
+	}
+
+	if len(removedIDs) > 0 {
+		deleted, err := s.batchDeleteModelsApp(ctx, platformID, removedIDs)
+		if err != nil {
+			return result, fmt.Errorf("删除下线模型失败：%w", err)
+		}
+		result.DeletedCount = deleted
+	}
+
+	s.logger.Info("平台模型同步完成",
+		slog.Uint64("platform_id", uint64(platformID)),
+		slog.Int("created", result.CreatedCount),
+		slog.Int("deleted", result.DeletedCount))
+	return result, nil
+}
+
+// pendingModelSyncChanges 过滤出平台上尚未应用的新增模型名称与待删除模型 ID
+func pendingModelSyncChanges(ctx context.Context, platformID uint, added []string, removedIDs []uint) ([]string, []uint, error) {
+	var models []types.Model
+	if err := configDB(ctx).Select("id", "name").Where("platform_id = ?", platformID).Find(&models).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询平台现有模型失败：%w", err)
+	}
+	names := make(map[string]struct{}, len(models))
+	ids := make(map[uint]struct{}, len(models))
+	for _, model := range models {
+		names[model.Name] = struct{}{}
+		ids[model.ID] = struct{}{}
+	}
+
+	pendingAdded := make([]string, 0, len(added))
+	for _, name := range added {
+		if _, ok := names[name]; !ok {
+			pendingAdded = append(pendingAdded, name)
+		}
+	}
+	pendingRemoved := make([]uint, 0, len(removedIDs))
+	for _, id := range removedIDs {
+		if _, ok := ids[id]; ok {
+			pendingRemoved = append(pendingRemoved, id)
+		}
+	}
+	return pendingAdded, pendingRemoved, nil
+}
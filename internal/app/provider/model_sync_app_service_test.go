@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+// stubModelLister 按密钥值返回固定模型列表
+type stubModelLister map[string][]string
+
+func (l stubModelLister) ListModels(_ context.Context, _ *types.Platform, keyValue string) ([]string, error) {
+	names, ok := l[keyValue]
+	if !ok {
+		return nil, errors.New("invalid api key")
+	}
+	return names, nil
+}
+
+func newModelSyncTestService(t *testing.T, lister ModelLister) (*service, *gorm.DB, types.Platform) {
+	t.Helper()
+
+	svc, db := newConfigTransferTestService(t)
+	svc.modelLister = lister
+	svc.modelControlRepo = NewModelControlQueryRepository(nil, slog.Default())
+
+	platform := types.Platform{Name: "openai", BaseURL: "https://api.openai.com", ModelAutoSync: true}
+	if err := db.Create(&platform).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+	keys := []types.APIKey{{PlatformID: platform.ID, Value: "sk-revoked"}, {PlatformID: platform.ID, Value: "sk-valid"}}
+	if err := db.Create(&keys).Error; err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	models := []types.Model{
+		{PlatformID: platform.ID, Name: "gpt-4o", APIKeys: keys[1:]},
+		{PlatformID: platform.ID, Name: "gpt-3.5-turbo", APIKeys: keys[1:]},
+	}
+	if err := db.Create(&models).Error; err != nil {
+		t.Fatalf("创建模型失败: %v", err)
+	}
+	return svc, db, platform
+}
+
+func TestSyncPlatformModels_预览差异并按任务应用(t *testing.T) {
+	svc, db, platform := newModelSyncTestService(t, stubModelLister{"sk-valid": {"gpt-4o", "o1"}})
+	ctx := context.Background()
+
+	resp, err := svc.SyncPlatformModels(ctx, platform.ID, true)
+	if err != nil {
+		t.Fatalf("预览模型同步失败: %v", err)
+	}
+	preview := resp.Preview
+	if resp.Task != nil || !slices.Equal(preview.Added, []string{"o1"}) || len(preview.Removed) != 1 || preview.Removed[0].Name != "gpt-3.5-turbo" || preview.UnchangedCount != 1 {
+		t.Fatalf("预览差异不符: %+v", preview)
+	}
+
+	payload := modelSyncTaskPayload{PlatformID: platform.ID, KeyID: preview.KeyID, Added: preview.Added, RemovedIDs: []uint{preview.Removed[0].ID}}
+	if _, err := svc.runModelSyncTask(ctx, &types.ModelBatchTask{Payload: mustJSON(t, payload)}); err != nil {
+		t.Fatalf("执行模型同步任务失败: %v", err)
+	}
+
+	var models []types.Model
+	db.Preload("APIKeys").Where("platform_id = ?", platform.ID).Order("name").Find(&models)
+	if len(models) != 2 || models[0].Name != "gpt-4o" || models[1].Name != "o1" {
+		t.Fatalf("同步后模型不符: %+v", models)
+	}
+	if len(models[1].APIKeys) != 1 || models[1].APIKeys[0].Value != "sk-valid" {
+		t.Fatalf("新增模型应关联请求列表所用的密钥: %+v", models[1].APIKeys)
+	}
+}
+
+func TestRunModelSyncTask_自动同步跳过受管与未开启的平台(t *testing.T) {
+	svc, db, platform := newModelSyncTestService(t, stubModelLister{"sk-valid": {"gpt-4o", "gpt-3.5-turbo", "o1"}})
+	ctx := context.Background()
+
+	others := []types.Platform{{Name: "manual"}, {Name: "managed", ModelAutoSync: true, ManagedBy: types.PlatformManagedByFile}}
+	if err := db.Create(&others).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+
+	result, err := svc.runModelSyncTask(ctx, &types.ModelBatchTask{Payload: "null"})
+	if err != nil {
+		t.Fatalf("执行自动同步失败: %v", err)
+	}
+	synced := result.(*ModelSyncResult).Platforms
+	if len(synced) != 1 || synced[0].PlatformID != platform.ID || synced[0].CreatedCount != 1 || synced[0].DeletedCount != 0 {
+		t.Fatalf("仅应同步开启自动同步的未受管平台: %+v", synced)
+	}
+}
+
+func TestSyncPlatformModels_上游返回空列表时拒绝同步(t *testing.T) {
+	svc, _, platform := newModelSyncTestService(t, stubModelLister{"sk-valid": {}})
+
+	if _, err := svc.SyncPlatformModels(context.Background(), platform.ID, true); err == nil {
+		t.Fatalf("上游返回空列表时应返回错误")
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	return string(data)
+}
+
+func TestSyncPlatformModels_手动同步任务可重试且重复执行不重复应用(t *testing.T) {
+	svc, db, platform := newModelSyncTestService(t, stubModelLister{"sk-valid": {"gpt-4o", "o1"}})
+	if err := db.AutoMigrate(&types.ModelBatchTask{}); err != nil {
+		t.Fatalf("迁移任务表失败: %v", err)
+	}
+	svc.modelBatchTaskRepo = NewModelBatchTaskGormRepository(slog.Default())
+	svc.taskStateCache = make(map[uint]*ModelBatchTaskSummary)
+	svc.taskEnqueued = make(map[uint]struct{})
+	svc.taskMaxAttempts = map[string]int{types.ModelBatchTaskTypeSync: 3}
+	ctx := context.Background()
+
+	resp, err := svc.SyncPlatformModels(ctx, platform.ID, false)
+	if err != nil {
+		t.Fatalf("提交模型同步任务失败: %v", err)
+	}
+
+	var task types.ModelBatchTask
+	if err := db.First(&task, resp.Task.TaskID).Error; err != nil {
+		t.Fatalf("查询模型同步任务失败: %v", err)
+	}
+	if task.MaxAttempts != 3 || task.PlatformID != platform.ID {
+		t.Fatalf("手动同步任务应使用同步任务类型的最大执行次数: %+v", task)
+	}
+
+	for range 2 {
+		if _, err := svc.runModelSyncTask(ctx, &task); err != nil {
+			t.Fatalf("执行模型同步任务失败: %v", err)
+		}
+	}
+	var names []string
+	db.Model(&types.Model{}).Where("platform_id = ?", platform.ID).Order("name").Pluck("name", &names)
+	if !slices.Equal(names, []string{"gpt-4o", "o1"}) {
+		t.Fatalf("重复执行不应重复新增或删除模型: %v", names)
+	}
+}
@@ -24,10 +24,10 @@ func (s *service) updateModelApp(ctx context.Context, modelID uint, model types.
 		return nil, fmt.Errorf("更新模型失败：事务执行器未初始化")
 	}
 
-	existingModel, err := s.modelControlRepo.GetModel(ctx, modelID)
+	existingModel, err := s.modelControlRepo.GetModelWithAPIKeys(ctx, modelID)
 	if err != nil {
 		logger.Warn("查询模型失败", slog.Any("error", err))
-		_ = s.logModelUpdateAudit(ctx, modelID, "failed", fmt.Sprintf("查询模型失败：%v", err))
+		_ = s.logModelUpdateAudit(ctx, modelID, "failed", fmt.Sprintf("查询模型失败：%v", err), nil, nil)
 		return nil, err
 	}
 
@@ -37,13 +37,13 @@ func (s *service) updateModelApp(ctx context.Context, modelID uint, model types.
 		validKeys, err = s.modelControlRepo.ListAPIKeysByPlatformAndIDs(ctx, existingModel.PlatformID, apiKeyIDs)
 		if err != nil {
 			logger.Error("校验模型关联密钥失败", slog.Any("error", err))
-			_ = s.logModelUpdateAudit(ctx, modelID, "failed", fmt.Sprintf("校验模型关联密钥失败：%v", err))
+			_ = s.logModelUpdateAudit(ctx, modelID, "failed", fmt.Sprintf("校验模型关联密钥失败：%v", err), nil, nil)
 			return nil, fmt.Errorf("校验模型关联密钥失败：%w", err)
 		}
 		if len(validKeys) != len(apiKeyIDs) {
 			logger.Warn("部分 API 密钥不存在或不属于指定平台", slog.Uint64("platform_id", uint64(existingModel.PlatformID)))
 			err = fmt.Errorf("部分 API 密钥不存在或不属于平台 ID %d：%w", existingModel.PlatformID, ErrResourceNotBelong)
-			_ = s.logModelUpdateAudit(ctx, modelID, "failed", err.Error())
+			_ = s.logModelUpdateAudit(ctx, modelID, "failed", err.Error(), nil, nil)
 			return nil, err
 		}
 	}
@@ -77,14 +77,14 @@ func (s *service) updateModelApp(ctx context.Context, modelID uint, model types.
 	})
 	if err != nil {
 		logger.Error("更新模型事务失败", slog.Any("error", err))
-		_ = s.logModelUpdateAudit(ctx, modelID, "failed", fmt.Sprintf("更新模型失败：%v", err))
+		_ = s.logModelUpdateAudit(ctx, modelID, "failed", fmt.Sprintf("更新模型失败：%v", err), nil, nil)
 		return nil, fmt.Errorf("更新模型失败：%w", err)
 	}
 
 	updatedModel, err := s.modelControlRepo.GetModelWithAPIKeys(ctx, modelID)
 	if err != nil {
 		logger.Error("获取更新后的模型失败", slog.Any("error", err))
-		_ = s.logModelUpdateAudit(ctx, modelID, "failed", fmt.Sprintf("获取更新后的模型失败：%v", err))
+		_ = s.logModelUpdateAudit(ctx, modelID, "failed", fmt.Sprintf("获取更新后的模型失败：%v", err), nil, nil)
 		return nil, err
 	}
 
@@ -94,12 +94,12 @@ func (s *service) updateModelApp(ctx context.Context, modelID uint, model types.
 		slog.Bool("api_keys_updated", len(validKeys) > 0),
 		slog.Int("updated_field_count", len(updates)),
 	)
-	_ = s.logModelUpdateAudit(ctx, modelID, "success", fmt.Sprintf("更新模型成功，platform_id=%d，更新字段数=%d，是否更新密钥=%t", updatedModel.PlatformID, len(updates), len(validKeys) > 0))
+	_ = s.logModelUpdateAudit(ctx, modelID, "success", fmt.Sprintf("更新模型成功，platform_id=%d，更新字段数=%d，是否更新密钥=%t", updatedModel.PlatformID, len(updates), len(validKeys) > 0), existingModel, updatedModel)
 
 	return updatedModel, nil
 }
 
-func (s *service) logModelUpdateAudit(ctx context.Context, modelID uint, result, detail string) error {
+func (s *service) logModelUpdateAudit(ctx context.Context, modelID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -110,5 +110,7 @@ func (s *service) logModelUpdateAudit(ctx context.Context, modelID uint, result,
 		ResourceID: modelID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
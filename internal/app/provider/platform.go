@@ -26,6 +26,7 @@ func (s *service) CreatePlatform(ctx context.Context, platform types.Platform) (
 	}
 
 	platform.ID = 0
+	platform.ManagedBy = ""
 	err := s.controlTx.WithinTx(ctx, func(txCtx context.Context) error {
 		if innerErr := s.platformControlRepo.CreatePlatform(txCtx, &platform); innerErr != nil {
 			return innerErr
@@ -34,12 +35,12 @@ func (s *service) CreatePlatform(ctx context.Context, platform types.Platform) (
 	})
 	if err != nil {
 		logger.Error("创建平台失败", slog.Any("error", err))
-		_ = s.logPlatformControlAudit(ctx, platform.ID, "platform.create", "failed", fmt.Sprintf("创建平台失败：%v", err))
+		_ = s.logPlatformControlAudit(ctx, platform.ID, "platform.create", "failed", fmt.Sprintf("创建平台失败：%v", err), nil, nil)
 		return nil, fmt.Errorf("创建平台失败：%w", err)
 	}
 
 	logger.Info("成功创建平台", slog.Uint64("platform_id", uint64(platform.ID)))
-	_ = s.logPlatformControlAudit(ctx, platform.ID, "platform.create", "success", "创建平台成功")
+	_ = s.logPlatformControlAudit(ctx, platform.ID, "platform.create", "success", "创建平台成功", nil, &platform)
 	return &platform, nil
 }
 
@@ -129,6 +130,10 @@ func (s *service) GetPlatform(ctx context.Context, id uint) (*types.Platform, er
 
 // UpdatePlatform 实现更新平台信息
 func (s *service) UpdatePlatform(ctx context.Context, id uint, platform types.Platform) (*types.Platform, error) {
+	if err := s.ensurePlatformWritable(ctx, id); err != nil {
+		return nil, err
+	}
+
 	logger := s.logger.With(slog.Uint64("platform_id", uint64(id)))
 	logger.Debug("开始更新平台")
 
@@ -139,8 +144,18 @@ func (s *service) UpdatePlatform(ctx context.Context, id uint, platform types.Pl
 		return nil, fmt.Errorf("更新平台失败：事务执行器未初始化")
 	}
 
-	var updatedPlatform *types.Platform
+	// 管理来源仅由声明式配置文件同步写入，模型自动同步通过专用接口设置
+	platform.ManagedBy = ""
+	platform.ModelAutoSync = false
+
+	var existingPlatform, updatedPlatform *types.Platform
 	err := s.controlTx.WithinTx(ctx, func(txCtx context.Context) error {
+		var innerErr error
+		existingPlatform, innerErr = s.platformControlRepo.GetPlatform(txCtx, id)
+		if innerErr != nil {
+			return innerErr
+		}
+
 		rowsAffected, innerErr := s.platformControlRepo.UpdatePlatform(txCtx, id, platform)
 		if innerErr != nil {
 			return innerErr
@@ -158,16 +173,16 @@ func (s *service) UpdatePlatform(ctx context.Context, id uint, platform types.Pl
 	})
 	if err != nil {
 		logger.Error("更新平台失败", slog.Any("error", err))
-		_ = s.logPlatformControlAudit(ctx, id, "platform.update", "failed", fmt.Sprintf("更新平台失败：%v", err))
+		_ = s.logPlatformControlAudit(ctx, id, "platform.update", "failed", fmt.Sprintf("更新平台失败：%v", err), nil, nil)
 		return nil, fmt.Errorf("更新 ID 为 %d 的平台失败：%w", id, err)
 	}
 
 	logger.Info("成功更新平台", slog.String("platform_name", updatedPlatform.Name))
-	_ = s.logPlatformControlAudit(ctx, id, "platform.update", "success", "更新平台成功")
+	_ = s.logPlatformControlAudit(ctx, id, "platform.update", "success", "更新平台成功", existingPlatform, updatedPlatform)
 	return updatedPlatform, nil
 }
 
-func (s *service) logPlatformControlAudit(ctx context.Context, platformID uint, action, result, detail string) error {
+func (s *service) logPlatformControlAudit(ctx context.Context, platformID uint, action, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -178,11 +193,17 @@ func (s *service) logPlatformControlAudit(ctx context.Context, platformID uint,
 		ResourceID: platformID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
 
 // DeletePlatform 实现删除平台（包括其关联的模型、密钥及关联关系）
 func (s *service) DeletePlatform(ctx context.Context, id uint) error {
+	if err := s.ensurePlatformWritable(ctx, id); err != nil {
+		return err
+	}
+
 	// apiKeyModelsBackup 关联关系备份结构
 	type apiKeyModelsBackup struct {
 		apiKeyID uint
@@ -198,23 +219,23 @@ func (s *service) DeletePlatform(ctx context.Context, id uint) error {
 		return fmt.Errorf("删除平台失败：事务执行器未初始化")
 	}
 
-	// 检查平台是否存在
-	exists, err := s.platformControlRepo.ExistsPlatform(ctx, id)
+	// 检查平台是否存在，同时留存删除前快照用于审计
+	existingPlatform, err := s.platformControlRepo.GetPlatform(ctx, id)
 	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			logger.Warn("平台不存在")
+			return err
+		}
 		logger.Error("检查平台是否存在失败", slog.Any("error", err))
-		_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("检查平台是否存在失败：%v", err))
+		_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("检查平台是否存在失败：%v", err), nil, nil)
 		return fmt.Errorf("检查平台是否存在失败：%w", err)
 	}
-	if !exists {
-		logger.Warn("平台不存在")
-		return fmt.Errorf("未找到 ID 为 %d 的平台：%w", id, ErrResourceNotFound)
-	}
 
 	// 查询平台下的所有密钥
 	apiKeys, err := s.platformControlRepo.ListAPIKeysByPlatform(ctx, id)
 	if err != nil {
 		logger.Error("查询平台关联的密钥失败", slog.Any("error", err))
-		_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("查询平台关联的密钥失败：%v", err))
+		_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("查询平台关联的密钥失败：%v", err), nil, nil)
 		return fmt.Errorf("查询平台关联的密钥失败：%w", err)
 	}
 	logger.Debug("查询到关联密钥", slog.Int("apikey_count", len(apiKeys)))
@@ -229,7 +250,7 @@ func (s *service) DeletePlatform(ctx context.Context, id uint) error {
 			logger.Error("统计密钥关联的模型数量失败",
 				slog.Uint64("apikey_id", uint64(key.ID)),
 				slog.Any("error", err))
-			_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("统计密钥 ID 为 %d 关联模型数量失败：%v", key.ID, err))
+			_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("统计密钥 ID 为 %d 关联模型数量失败：%v", key.ID, err), nil, nil)
 			return fmt.Errorf("统计密钥 ID 为 %d 关联模型数量失败：%w", key.ID, err)
 		}
 		if count == 0 {
@@ -242,7 +263,7 @@ func (s *service) DeletePlatform(ctx context.Context, id uint) error {
 			logger.Error("查询密钥关联的模型失败",
 				slog.Uint64("apikey_id", uint64(key.ID)),
 				slog.Any("error", err))
-			_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("查询密钥 ID 为 %d 关联模型失败：%v", key.ID, err))
+			_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("查询密钥 ID 为 %d 关联模型失败：%v", key.ID, err), nil, nil)
 			return fmt.Errorf("查询密钥 ID 为 %d 关联的模型失败：%w", key.ID, err)
 		}
 
@@ -270,7 +291,7 @@ func (s *service) DeletePlatform(ctx context.Context, id uint) error {
 			logger.Error("清理密钥与模型的关联关系失败",
 				slog.Uint64("apikey_id", uint64(backup.apiKeyID)),
 				slog.Any("error", err))
-			_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("清理密钥 ID 为 %d 与模型关联关系失败：%v", backup.apiKeyID, err))
+			_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("清理密钥 ID 为 %d 与模型关联关系失败：%v", backup.apiKeyID, err), nil, nil)
 			return fmt.Errorf("清理密钥 ID 为 %d 与模型的关联关系失败：%w", backup.apiKeyID, err)
 		}
 		logger.Debug("成功清理密钥与模型的关联关系", slog.Uint64("apikey_id", uint64(backup.apiKeyID)), slog.Int("model_count", len(backup.models)))
@@ -321,12 +342,12 @@ func (s *service) DeletePlatform(ctx context.Context, id uint) error {
 			}
 		}
 		logger.Debug("完成关联关系恢复")
-		_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("删除平台失败：%v", err))
+		_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "failed", fmt.Sprintf("删除平台失败：%v", err), nil, nil)
 		return fmt.Errorf("删除平台失败：%w", err)
 	}
 
 	logger.Info("成功删除平台及其所有关联数据")
-	_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "success", "删除平台成功")
+	_ = s.logPlatformControlAudit(ctx, id, "platform.delete", "success", "删除平台成功", existingPlatform, nil)
 	return nil
 }
 
@@ -33,6 +33,7 @@ func (s *service) UpdatePlatformHealthEnabled(ctx context.Context, platformID ui
 		return types.HealthStatusUnknown, fmt.Errorf("平台不存在：%w", ErrResourceNotFound)
 	}
 
+	beforeHealth := s.healthAuditSnapshot(types.ResourceTypePlatform, platformID)
 	status := types.HealthStatusUnavailable
 	auditResult := "success"
 	auditDetail := "禁用平台健康状态"
@@ -56,16 +57,16 @@ func (s *service) UpdatePlatformHealthEnabled(ctx context.Context, platformID ui
 		auditResult = "failed"
 		auditDetail = fmt.Sprintf("更新平台健康状态失败：%v", err)
 		logger.Error("更新平台健康状态失败", slog.Any("error", err))
-		_ = s.logControlAudit(ctx, platformID, auditResult, auditDetail)
+		_ = s.logControlAudit(ctx, platformID, auditResult, auditDetail, nil, nil)
 		return types.HealthStatusUnknown, fmt.Errorf("更新平台健康状态失败：%w", err)
 	}
 
 	logger.Info("更新平台健康状态成功", slog.Int("status", int(status)))
-	_ = s.logControlAudit(ctx, platformID, auditResult, auditDetail)
+	_ = s.logControlAudit(ctx, platformID, auditResult, auditDetail, beforeHealth, healthAuditState(status))
 	return status, nil
 }
 
-func (s *service) logControlAudit(ctx context.Context, platformID uint, result, detail string) error {
+func (s *service) logControlAudit(ctx context.Context, platformID uint, result, detail string, before, after any) error {
 	if s.controlAudit == nil {
 		return nil
 	}
@@ -76,5 +77,7 @@ func (s *service) logControlAudit(ctx context.Context, platformID uint, result,
 		ResourceID: platformID,
 		Result:     result,
 		Detail:     detail,
+		Before:     before,
+		After:      after,
 	})
 }
@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// platformPresets 为内置的常用供应方预设，端点类型与变体需与请求转发使用的取值一致：
+// openai（chat_completions、responses）、anthropic（messages）、google（generate）。
+var platformPresets = []PlatformPreset{
+	{
+		ID:          "openai",
+		Name:        "OpenAI",
+		Description: "OpenAI 官方接口，包含 Chat Completions 与 Responses 端点",
+		BaseURL:     "https://api.openai.com",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true},
+			{EndpointType: "openai", EndpointVariant: "responses"},
+		},
+		Models: []string{"gpt-4.1", "gpt-4.1-mini", "gpt-4o", "gpt-4o-mini", "o3", "o4-mini"},
+	},
+	{
+		ID:              "azure-openai",
+		Name:            "Azure OpenAI",
+		Description:     "Azure OpenAI v1 接口，需将基础 URL 中的 {resource} 替换为资源名称，模型名称需与部署名称一致",
+		BaseURL:         "https://{resource}.openai.azure.com",
+		BaseURLRequired: true,
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", Path: "/openai/v1/chat/completions", IsDefault: true},
+			{EndpointType: "openai", EndpointVariant: "responses", Path: "/openai/v1/responses"},
+		},
+		Models: []string{"gpt-4.1", "gpt-4.1-mini", "gpt-4o", "gpt-4o-mini"},
+	},
+	{
+		ID:          "anthropic",
+		Name:        "Anthropic",
+		Description: "Anthropic 官方 Messages 接口",
+		BaseURL:     "https://api.anthropic.com",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "anthropic", EndpointVariant: "messages", IsDefault: true},
+		},
+		Models: []string{"claude-opus-4-1", "claude-sonnet-4-5", "claude-haiku-4-5"},
+	},
+	{
+		ID:          "gemini",
+		Name:        "Gemini",
+		Description: "Google Gemini API，包含原生 generateContent 与 OpenAI 兼容端点",
+		BaseURL:     "https://generativelanguage.googleapis.com",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "google", EndpointVariant: "generate", IsDefault: true},
+			{EndpointType: "openai", EndpointVariant: "chat_completions", Path: "/v1beta/openai/chat/completions"},
+		},
+		Models: []string{"gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.5-flash-lite"},
+	},
+	{
+		ID:          "deepseek",
+		Name:        "DeepSeek",
+		Description: "DeepSeek 开放平台，包含 OpenAI 兼容与 Anthropic 兼容端点",
+		BaseURL:     "https://api.deepseek.com",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true},
+			{EndpointType: "anthropic", EndpointVariant: "messages", Path: "/anthropic/v1/messages"},
+		},
+		Models: []string{"deepseek-chat", "deepseek-reasoner"},
+	},
+	{
+		ID:          "openrouter",
+		Name:        "OpenRouter",
+		Description: "OpenRouter 聚合接口，模型名称带供应方前缀",
+		BaseURL:     "https://openrouter.ai/api",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true},
+		},
+		Models: []string{"openai/gpt-4o", "anthropic/claude-sonnet-4.5", "google/gemini-2.5-pro", "deepseek/deepseek-chat"},
+	},
+	{
+		ID:          "xai",
+		Name:        "xAI",
+		Description: "xAI Grok 接口（OpenAI 兼容）",
+		BaseURL:     "https://api.x.ai",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true},
+			{EndpointType: "openai", EndpointVariant: "responses"},
+		},
+		Models: []string{"grok-4", "grok-3", "grok-3-mini"},
+	},
+	{
+		ID:          "mistral",
+		Name:        "Mistral AI",
+		Description: "Mistral AI 接口（OpenAI 兼容）",
+		BaseURL:     "https://api.mistral.ai",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true},
+		},
+		Models: []string{"mistral-large-latest", "mistral-medium-latest", "mistral-small-latest", "codestral-latest"},
+	},
+	{
+		ID:          "groq",
+		Name:        "Groq",
+		Description: "Groq 接口（OpenAI 兼容）",
+		BaseURL:     "https://api.groq.com/openai",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true},
+		},
+		Models: []string{"llama-3.3-70b-versatile", "llama-3.1-8b-instant"},
+	},
+	{
+		ID:          "ollama",
+		Name:        "Ollama",
+		Description: "本地 Ollama 服务（OpenAI 兼容接口），模型需预先拉取，可通过模型同步获取实际列表",
+		BaseURL:     "http://localhost:11434",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true},
+		},
+		Models: []string{"llama3.1", "qwen3", "gemma3"},
+	},
+	{
+		ID:          "vllm",
+		Name:        "vLLM",
+		Description: "自部署 vLLM 服务（OpenAI 兼容接口），模型取决于部署，可通过模型同步获取实际列表",
+		BaseURL:     "http://localhost:8000",
+		Endpoints: []EndpointConfig{
+			{EndpointType: "openai", EndpointVariant: "chat_completions", IsDefault: true},
+			{EndpointType: "openai", EndpointVariant: "responses"},
+		},
+		Models: []string{},
+	},
+}
+
+// ListPlatformPresets 返回内置的平台预设列表
+func (s *service) ListPlatformPresets() []PlatformPreset {
+	presets := make([]PlatformPreset, len(platformPresets))
+	for i, preset := range platformPresets {
+		presets[i] = clonePlatformPreset(preset)
+	}
+	return presets
+}
+
+// CreatePlatformFromPreset 按预设在一个事务中创建平台及其端点与模型
+func (s *service) CreatePlatformFromPreset(ctx context.Context, presetID string, req CreatePlatformFromPresetRequest) (*PlatformFromPresetResponse, error) {
+	logger := s.logger.With(
+		slog.String("operation", "create_platform_from_preset"),
+		slog.String("preset", presetID),
+	)
+	logger.Debug("开始从预设创建平台")
+
+	preset, ok := findPlatformPreset(presetID)
+	if !ok {
+		logger.Warn("平台预设不存在")
+		return nil, fmt.Errorf("未找到平台预设 %q：%w", presetID, ErrResourceNotFound)
+	}
+	if s.platformControlRepo == nil || s.endpointControlRepo == nil || s.modelControlRepo == nil {
+		return nil, fmt.Errorf("从预设创建平台失败：控制仓储未初始化")
+	}
+	if s.controlTx == nil {
+		return nil, fmt.Errorf("从预设创建平台失败：事务执行器未初始化")
+	}
+
+	platform := types.Platform{
+		Name:    strings.TrimSpace(req.Name),
+		BaseURL: strings.TrimSpace(req.BaseURL),
+	}
+	if platform.Name == "" {
+		platform.Name = preset.Name
+	}
+	if platform.BaseURL == "" {
+		if preset.BaseURLRequired {
+			return nil, fmt.Errorf("预设 %s 需要提供 base_url：%w", preset.ID, ErrInvalidArgument)
+		}
+		platform.BaseURL = preset.BaseURL
+	}
+	if strings.ContainsAny(platform.BaseURL, "{}") {
+		return nil, fmt.Errorf("base_url %q 仍包含未替换的占位符：%w", platform.BaseURL, ErrInvalidArgument)
+	}
+
+	modelNames := preset.Models
+	if req.Models != nil {
+		modelNames = req.Models
+	}
+
+	var models []*types.Model
+	err := s.controlTx.WithinTx(ctx, func(txCtx context.Context) error {
+		if innerErr := s.platformControlRepo.CreatePlatform(txCtx, &platform); innerErr != nil {
+			return innerErr
+		}
+
+		for _, ec := range preset.Endpoints {
+			endpoint := types.Endpoint{
+				PlatformID:      platform.ID,
+				EndpointType:    ec.EndpointType,
+				EndpointVariant: ec.EndpointVariant,
+				Path:            ec.Path,
+				CustomHeaders:   ec.CustomHeaders,
+				IsDefault:       ec.IsDefault,
+			}
+			if innerErr := s.endpointControlRepo.CreateEndpoint(txCtx, &endpoint); innerErr != nil {
+				return innerErr
+			}
+			platform.Endpoints = append(platform.Endpoints, endpoint)
+		}
+
+		seen := make(map[string]struct{}, len(modelNames))
+		for _, name := range modelNames {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, dup := seen[name]; dup {
+				continue
+			}
+			seen[name] = struct{}{}
+
+			model := &types.Model{PlatformID: platform.ID, Name: name}
+			if innerErr := s.modelControlRepo.CreateModel(txCtx, model); innerErr != nil {
+				return innerErr
+			}
+			models = append(models, model)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("从预设创建平台失败", slog.Any("error", err))
+		_ = s.logPlatformControlAudit(ctx, 0, "platform.create", "failed", fmt.Sprintf("从预设 %s 创建平台失败：%v", preset.ID, err), nil, nil)
+		return nil, fmt.Errorf("从预设创建平台失败：%w", err)
+	}
+
+	logger.Info("成功从预设创建平台",
+		slog.Uint64("platform_id", uint64(platform.ID)),
+		slog.Int("endpoints", len(platform.Endpoints)),
+		slog.Int("models", len(models)))
+	_ = s.logPlatformControlAudit(ctx, platform.ID, "platform.create", "success", fmt.Sprintf("从预设 %s 创建平台成功", preset.ID), nil, &platform)
+	return &PlatformFromPresetResponse{Platform: &platform, Models: models}, nil
+}
+
+// findPlatformPreset 按 ID 查找平台预设，ID 不区分大小写
+func findPlatformPreset(presetID string) (PlatformPreset, bool) {
+	presetID = strings.ToLower(strings.TrimSpace(presetID))
+	for _, preset := range platformPresets {
+		if preset.ID == presetID {
+			return preset, true
+		}
+	}
+	return PlatformPreset{}, false
+}
+
+// clonePlatformPreset 复制预设，避免调用方修改内置预设
+func clonePlatformPreset(preset PlatformPreset) PlatformPreset {
+	preset.Endpoints = slices.Clone(preset.Endpoints)
+	preset.Models = slices.Clone(preset.Models)
+	return preset
+}
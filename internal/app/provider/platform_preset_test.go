@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+func newPlatformPresetTestService(t *testing.T) (*service, *gorm.DB) {
+	t.Helper()
+
+	svc, db := newConfigTransferTestService(t)
+	svc.platformControlRepo = NewPlatformControlQueryRepository(nil, slog.Default())
+	svc.endpointControlRepo = NewEndpointControlQueryRepository(slog.Default())
+	svc.modelControlRepo = NewModelControlQueryRepository(nil, slog.Default())
+	return svc, db
+}
+
+func TestPlatformPresets_端点类型与变体有效(t *testing.T) {
+	validVariants := map[string][]string{
+		"openai":    {"chat_completions", "responses"},
+		"anthropic": {"messages"},
+		"google":    {"generate"},
+	}
+
+	seenIDs := make(map[string]bool)
+	for _, preset := range platformPresets {
+		if preset.ID == "" || seenIDs[preset.ID] {
+			t.Fatalf("预设 ID 为空或重复: %q", preset.ID)
+		}
+		seenIDs[preset.ID] = true
+
+		defaults := 0
+		seenEndpoints := make(map[string]bool)
+		for _, ec := range preset.Endpoints {
+			variants, ok := validVariants[ec.EndpointType]
+			if !ok {
+				t.Fatalf("预设 %s 端点类型不受支持: %s", preset.ID, ec.EndpointType)
+			}
+			valid := false
+			for _, variant := range variants {
+				valid = valid || variant == ec.EndpointVariant
+			}
+			if !valid {
+				t.Fatalf("预设 %s 端点变体不受支持: %s/%s", preset.ID, ec.EndpointType, ec.EndpointVariant)
+			}
+			name := endpointConfigName(ec)
+			if seenEndpoints[name] {
+				t.Fatalf("预设 %s 端点重复: %s", preset.ID, name)
+			}
+			seenEndpoints[name] = true
+			if ec.IsDefault {
+				defaults++
+			}
+		}
+		if defaults != 1 {
+			t.Fatalf("预设 %s 应有且仅有一个默认端点: got=%d", preset.ID, defaults)
+		}
+	}
+}
+
+func TestCreatePlatformFromPreset_创建平台端点与建议模型(t *testing.T) {
+	svc, db := newPlatformPresetTestService(t)
+	ctx := context.Background()
+
+	result, err := svc.CreatePlatformFromPreset(ctx, "OpenAI", CreatePlatformFromPresetRequest{})
+	if err != nil {
+		t.Fatalf("从预设创建平台失败: %v", err)
+	}
+	preset, _ := findPlatformPreset("openai")
+	if result.Name != preset.Name || result.BaseURL != preset.BaseURL {
+		t.Fatalf("平台应使用预设名称与地址: name=%q, base_url=%q", result.Name, result.BaseURL)
+	}
+	if len(result.Endpoints) != len(preset.Endpoints) || len(result.Models) != len(preset.Models) {
+		t.Fatalf("响应中的端点或模型数量不符: endpoints=%d, models=%d", len(result.Endpoints), len(result.Models))
+	}
+
+	var endpoints []types.Endpoint
+	if err := db.Where("platform_id = ?", result.ID).Find(&endpoints).Error; err != nil {
+		t.Fatalf("查询端点失败: %v", err)
+	}
+	if len(endpoints) != len(preset.Endpoints) {
+		t.Fatalf("端点数量不符: got=%d, want=%d", len(endpoints), len(preset.Endpoints))
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.IsDefault != (endpoint.EndpointVariant == "chat_completions") {
+			t.Fatalf("默认端点应为 chat_completions: %+v", endpoint)
+		}
+	}
+
+	var modelCount int64
+	if err := db.Model(&types.Model{}).Where("platform_id = ?", result.ID).Count(&modelCount).Error; err != nil {
+		t.Fatalf("统计模型失败: %v", err)
+	}
+	if modelCount != int64(len(preset.Models)) {
+		t.Fatalf("模型数量不符: got=%d, want=%d", modelCount, len(preset.Models))
+	}
+}
+
+func TestCreatePlatformFromPreset_自定义地址与模型列表(t *testing.T) {
+	svc, db := newPlatformPresetTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.CreatePlatformFromPreset(ctx, "azure-openai", CreatePlatformFromPresetRequest{}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("缺少必填基础 URL 应返回参数错误: %v", err)
+	}
+	if _, err := svc.CreatePlatformFromPreset(ctx, "azure-openai", CreatePlatformFromPresetRequest{
+		BaseURL: "https://{resource}.openai.azure.com",
+	}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("包含占位符的基础 URL 应返回参数错误: %v", err)
+	}
+	if _, err := svc.CreatePlatformFromPreset(ctx, "unknown", CreatePlatformFromPresetRequest{}); !errors.Is(err, ErrResourceNotFound) {
+		t.Fatalf("未知预设应返回未找到: %v", err)
+	}
+
+	result, err := svc.CreatePlatformFromPreset(ctx, "azure-openai", CreatePlatformFromPresetRequest{
+		Name:    "azure-prod",
+		BaseURL: "https://contoso.openai.azure.com",
+		Models:  []string{" gpt-4o ", "gpt-4o", ""},
+	})
+	if err != nil {
+		t.Fatalf("从预设创建平台失败: %v", err)
+	}
+	if result.Name != "azure-prod" || result.BaseURL != "https://contoso.openai.azure.com" {
+		t.Fatalf("平台应使用请求中的名称与地址: name=%q, base_url=%q", result.Name, result.BaseURL)
+	}
+	if len(result.Models) != 1 || result.Models[0].Name != "gpt-4o" {
+		t.Fatalf("模型列表应去除空白与重复项: %+v", result.Models)
+	}
+
+	var platformCount int64
+	if err := db.Model(&types.Platform{}).Count(&platformCount).Error; err != nil {
+		t.Fatalf("统计平台失败: %v", err)
+	}
+	if platformCount != 1 {
+		t.Fatalf("失败的请求不应创建平台: got=%d", platformCount)
+	}
+
+	empty, err := svc.CreatePlatformFromPreset(ctx, "deepseek", CreatePlatformFromPresetRequest{Models: []string{}})
+	if err != nil {
+		t.Fatalf("从预设创建平台失败: %v", err)
+	}
+	if len(empty.Models) != 0 {
+		t.Fatalf("传入空模型列表时不应创建模型: got=%d", len(empty.Models))
+	}
+}
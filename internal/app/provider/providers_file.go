@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/goccy/go-yaml"
+)
+
+// providersFilePollInterval 为声明式配置文件变更检查周期
+const providersFilePollInterval = 5 * time.Second
+
+// SyncProvidersFile 读取声明式配置文件并同步到数据库
+//
+// 文件中的平台标记为由配置文件管理；此前由配置文件管理、但已从文件中移除的平台会被删除，
+// 未受管的平台保持不变。密钥可通过 value_env 从环境变量读取。
+func (s *service) SyncProvidersFile(ctx context.Context, path string) (*ConfigImportResult, error) {
+	logger := s.logger.With(slog.String("operation", "providers_file_sync"), slog.String("path", path))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取声明式配置文件失败：%w", err)
+	}
+	doc, err := parseProvidersFile(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfigDocument(doc); err != nil {
+		return nil, fmt.Errorf("声明式配置文件校验失败：%w", err)
+	}
+
+	changes, err := s.applyConfig(ctx, doc, types.PlatformManagedByFile)
+	if err != nil {
+		return nil, fmt.Errorf("同步声明式配置文件失败：%w", err)
+	}
+
+	result := &ConfigImportResult{Applied: true}
+	result.setChanges(changes)
+	if len(changes) > 0 {
+		logger.Info("已同步声明式配置文件",
+			slog.Int("created", result.Created),
+			slog.Int("updated", result.Updated),
+			slog.Int("deleted", result.Deleted))
+	}
+	return result, nil
+}
+
+// StartProvidersFileSync 启动时同步一次声明式配置文件，并在文件内容变更时重新同步
+//
+// 首次同步失败时返回错误；后续同步失败仅记录日志并保留数据库中的现有配置。ctx 结束时停止检查。
+func (s *service) StartProvidersFileSync(ctx context.Context, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	if _, err := s.SyncProvidersFile(ctx, path); err != nil {
+		return err
+	}
+	digest, _ := providersFileDigest(path)
+
+	s.logger.Info("已启用声明式配置文件同步", "path", path, "interval", providersFilePollInterval.String())
+	go s.providersFileLoop(ctx, path, digest)
+	return nil
+}
+
+// providersFileLoop 周期检查文件摘要，变更时重新同步
+func (s *service) providersFileLoop(ctx context.Context, path string, digest [sha256.Size]byte) {
+	ticker := time.NewTicker(providersFilePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := providersFileDigest(path)
+		if err != nil {
+			s.logger.Warn("读取声明式配置文件失败", "path", path, "error", err)
+			continue
+		}
+		if current == digest {
+			continue
+		}
+		if _, err := s.SyncProvidersFile(ctx, path); err != nil {
+			s.logger.Error("同步声明式配置文件失败，保留现有配置", "path", path, "error", err)
+			continue
+		}
+		digest = current
+	}
+}
+
+// providersFileDigest 计算文件内容摘要
+func providersFileDigest(path string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// parseProvidersFile 解析声明式配置文件，并从环境变量解析 value_env 引用的密钥
+func parseProvidersFile(data []byte) (ConfigDocument, error) {
+	var doc ConfigDocument
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return doc, fmt.Errorf("解析声明式配置文件失败：%w", err)
+	}
+	if doc.Version == 0 {
+		doc.Version = ConfigDocumentVersion
+	}
+	// 配置文件是密钥的唯一来源，文件中未列出的密钥会被删除
+	doc.IncludeSecrets = true
+
+	for i := range doc.Platforms {
+		pc := &doc.Platforms[i]
+		for j := range pc.Keys {
+			kc := &pc.Keys[j]
+			if kc.ValueEnv == "" {
+				continue
+			}
+			if kc.Value != "" {
+				return doc, fmt.Errorf("平台 %q 的密钥 %q 不能同时设置 value 与 value_env：%w", pc.Name, kc.Ref, ErrInvalidArgument)
+			}
+			value, ok := os.LookupEnv(kc.ValueEnv)
+			if !ok || value == "" {
+				return doc, fmt.Errorf("平台 %q 的密钥 %q 引用的环境变量 %s 未设置：%w", pc.Name, kc.Ref, kc.ValueEnv, ErrInvalidArgument)
+			}
+			kc.Value = value
+			kc.ValueEnv = ""
+		}
+	}
+	return doc, nil
+}
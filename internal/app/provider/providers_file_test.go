@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+const testProvidersFile = `
+platforms:
+  - name: openai
+    base_url: https://api.openai.com
+    endpoints:
+      - endpoint_type: openai
+        path: /v1
+        is_default: true
+    keys:
+      - ref: main
+        value_env: PINAI_TEST_OPENAI_KEY
+    models:
+      - name: gpt-4o
+        keys: [main]
+`
+
+func writeProvidersFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "providers.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	return path
+}
+
+func TestSyncProvidersFile_同步受管平台且不影响未受管平台(t *testing.T) {
+	svc, db := newConfigTransferTestService(t)
+	ctx := context.Background()
+	t.Setenv("PINAI_TEST_OPENAI_KEY", "sk-from-env")
+
+	manual := types.Platform{Name: "manual", BaseURL: "https://manual.example.com"}
+	if err := db.Create(&manual).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+
+	path := writeProvidersFile(t, testProvidersFile)
+	if _, err := svc.SyncProvidersFile(ctx, path); err != nil {
+		t.Fatalf("同步配置文件失败: %v", err)
+	}
+
+	var managed types.Platform
+	if err := db.Where("name = ?", "openai").First(&managed).Error; err != nil {
+		t.Fatalf("查询受管平台失败: %v", err)
+	}
+	if managed.ManagedBy != types.PlatformManagedByFile {
+		t.Fatalf("平台应标记为由配置文件管理: %+v", managed)
+	}
+	var model types.Model
+	if err := db.Preload("APIKeys").Where("name = ?", "gpt-4o").First(&model).Error; err != nil {
+		t.Fatalf("查询模型失败: %v", err)
+	}
+	if len(model.APIKeys) != 1 || model.APIKeys[0].Value != "sk-from-env" {
+		t.Fatalf("密钥应从环境变量读取: %+v", model.APIKeys)
+	}
+
+	// 再次同步无变更；从文件移除后受管平台被删除，未受管平台保留
+	again, err := svc.SyncProvidersFile(ctx, path)
+	if err != nil || len(again.Changes) != 0 {
+		t.Fatalf("再次同步不应产生变更: %+v %v", again, err)
+	}
+	if _, err := svc.SyncProvidersFile(ctx, writeProvidersFile(t, "platforms: []\n")); err != nil {
+		t.Fatalf("同步空配置文件失败: %v", err)
+	}
+	var names []string
+	db.Model(&types.Platform{}).Pluck("name", &names)
+	if len(names) != 1 || names[0] != "manual" {
+		t.Fatalf("仅应保留未受管平台: %v", names)
+	}
+}
+
+func TestSyncProvidersFile_环境变量缺失时拒绝同步(t *testing.T) {
+	svc, _ := newConfigTransferTestService(t)
+
+	path := writeProvidersFile(t, testProvidersFile)
+	if _, err := svc.SyncProvidersFile(context.Background(), path); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("环境变量缺失时应返回参数错误: %v", err)
+	}
+}
+
+func TestManagedPlatform_接口修改返回受管错误(t *testing.T) {
+	svc, db := newConfigTransferTestService(t)
+	ctx := context.Background()
+	t.Setenv("PINAI_TEST_OPENAI_KEY", "sk-from-env")
+
+	if _, err := svc.SyncProvidersFile(ctx, writeProvidersFile(t, testProvidersFile)); err != nil {
+		t.Fatalf("同步配置文件失败: %v", err)
+	}
+	var platform types.Platform
+	db.Where("name = ?", "openai").First(&platform)
+	var model types.Model
+	db.Where("name = ?", "gpt-4o").First(&model)
+
+	if _, err := svc.UpdatePlatform(ctx, platform.ID, types.Platform{Name: "renamed"}); !errors.Is(err, ErrManagedResource) {
+		t.Fatalf("修改受管平台应返回受管错误: %v", err)
+	}
+	if err := svc.DeleteModel(ctx, model.ID); !errors.Is(err, ErrManagedResource) {
+		t.Fatalf("删除受管平台的模型应返回受管错误: %v", err)
+	}
+
+	// 接口导入不得改动受管平台
+	doc, err := svc.ExportConfig(ctx, false)
+	if err != nil {
+		t.Fatalf("导出配置失败: %v", err)
+	}
+	doc.Platforms[0].BaseURL = "https://proxy.example.com"
+	if _, err := svc.ImportConfig(ctx, *doc, true); !errors.Is(err, ErrManagedResource) {
+		t.Fatalf("接口导入修改受管平台应返回受管错误: %v", err)
+	}
+	doc.Platforms = nil
+	if _, err := svc.ImportConfig(ctx, *doc, true); !errors.Is(err, ErrManagedResource) {
+		t.Fatalf("接口导入删除受管平台应返回受管错误: %v", err)
+	}
+}
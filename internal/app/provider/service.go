@@ -3,20 +3,51 @@ package provider
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/MeowSalty/pinai/database/types"
 	"github.com/MeowSalty/pinai/internal/app/health"
 )
 
+// Option 定义供应商服务的可选配置
+type Option func(*service)
+
+// WithControlAuditLogger 设置控制面审计记录器，默认仅输出调试日志
+func WithControlAuditLogger(auditLogger ControlAuditLogger) Option {
+	return func(s *service) {
+		if auditLogger != nil {
+			s.controlAudit = auditLogger
+		}
+	}
+}
+
+// WithKeyValidator 设置密钥校验器，未设置时密钥校验接口返回错误
+func WithKeyValidator(validator KeyValidator) Option {
+	return func(s *service) {
+		if validator != nil {
+			s.keyValidator = validator
+		}
+	}
+}
+
+// WithTaskConcurrency 设置同时执行的异步任务数量，默认为 1
+func WithTaskConcurrency(n int) Option {
+	return func(s *service) {
+		if n > 0 {
+			s.taskConcurrency = n
+		}
+	}
+}
+
 // New 创建一个新的 Service 实例
-func New(logger *slog.Logger, healthStorage *health.Storage) Service {
+func New(logger *slog.Logger, healthStorage *health.Storage, opts ...Option) Service {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
 	taskRepo := NewModelBatchTaskGormRepository(logger.WithGroup("model_batch_task_repo"))
 
-	return &service{
+	s := &service{
 		logger:              logger,
 		healthReader:        healthStorage,
 		platformControlRepo: NewPlatformControlQueryRepository(healthStorage, logger.WithGroup("platform_control_repo")),
@@ -30,7 +61,18 @@ func New(logger *slog.Logger, healthStorage *health.Storage) Service {
 		taskQueueSize:       256,
 		taskStateCache:      make(map[uint]*ModelBatchTaskSummary),
 		taskEnqueued:        make(map[uint]struct{}),
+		taskConcurrency:     1,
+		taskRetryBaseDelay:  defaultTaskRetryBaseDelay,
+		taskLeaseDuration:   defaultTaskLeaseDuration,
+		taskOwner:           newTaskOwnerID(),
+		taskRunning:         make(map[uint]context.CancelCauseFunc),
 	}
+	s.registerBuiltinTaskHandlers()
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Service 定义了 LLM 供应商管理的服务接口
@@ -38,6 +80,12 @@ type Service interface {
 	// CreatePlatform 创建一个新的平台
 	CreatePlatform(ctx context.Context, platform types.Platform) (*types.Platform, error)
 
+	// ListPlatformPresets 获取内置的平台预设列表
+	ListPlatformPresets() []PlatformPreset
+
+	// CreatePlatformFromPreset 按预设在一个事务中创建平台及其端点与建议模型
+	CreatePlatformFromPreset(ctx context.Context, presetID string, req CreatePlatformFromPresetRequest) (*PlatformFromPresetResponse, error)
+
 	// GetPlatforms 获取所有平台列表
 	GetPlatforms(ctx context.Context) ([]*types.Platform, error)
 
@@ -83,15 +131,51 @@ type Service interface {
 	// EnqueueBatchDeleteModelsTask 提交批量删除模型异步任务。
 	EnqueueBatchDeleteModelsTask(ctx context.Context, platformId uint, modelIds []uint) (*BatchTaskAcceptedResponse, error)
 
+	// EnqueueBatchAddKeysTask 提交批量导入密钥异步任务，密钥列表与文本合并去重。
+	EnqueueBatchAddKeysTask(ctx context.Context, platformID uint, req BatchCreateKeysRequest) (*BatchTaskAcceptedResponse, error)
+
+	// EnqueueBatchDeleteKeysTask 提交批量删除密钥异步任务。
+	EnqueueBatchDeleteKeysTask(ctx context.Context, platformID uint, keyIDs []uint) (*BatchTaskAcceptedResponse, error)
+
+	// EnqueueBatchUpdateKeyModelsTask 提交批量更新密钥与模型关联异步任务。
+	EnqueueBatchUpdateKeyModelsTask(ctx context.Context, platformID uint, req BatchUpdateKeyModelsRequest) (*BatchTaskAcceptedResponse, error)
+
+	// SyncPlatformModels 对比上游模型列表与平台现有模型，dryRun 为 false 且存在差异时提交模型同步任务。
+	SyncPlatformModels(ctx context.Context, platformID uint, dryRun bool) (*ModelSyncResponse, error)
+
+	// UpdatePlatformModelAutoSync 设置平台是否参与上游模型列表定时同步。
+	UpdatePlatformModelAutoSync(ctx context.Context, platformID uint, enabled bool) (*types.Platform, error)
+
 	// GetModelBatchTask 查询模型批量任务状态。
 	GetModelBatchTask(ctx context.Context, taskID uint) (*ModelBatchTaskSummary, error)
 
+	// ListTasks 按类型、状态与平台分页查询异步任务。
+	ListTasks(ctx context.Context, opts ListTasksOptions) (*TaskListResponse, error)
+
+	// CancelTask 取消 pending 或 running 状态的异步任务。
+	CancelTask(ctx context.Context, taskID uint) (*ModelBatchTaskSummary, error)
+
+	// RetryTask 重新执行失败或已取消的异步任务。
+	RetryTask(ctx context.Context, taskID uint) (*ModelBatchTaskSummary, error)
+
+	// PruneTasks 删除 before 之前已结束的异步任务。
+	PruneTasks(ctx context.Context, before time.Time) (*TaskPruneResult, error)
+
 	// StartModelBatchTaskWorker 启动模型批量任务后台 worker。
 	StartModelBatchTaskWorker(ctx context.Context) error
 
 	// StopModelBatchTaskWorker 停止模型批量任务后台 worker。
 	StopModelBatchTaskWorker(ctx context.Context) error
 
+	// RegisterTaskHandler 注册其他应用服务复用任务运行时执行的任务处理函数。
+	RegisterTaskHandler(taskType string, handler TaskHandler, opts ...TaskHandlerOption) error
+
+	// SchedulePeriodicTask 注册由 worker 周期性提交的任务，须在启动 worker 前调用。
+	SchedulePeriodicTask(taskType string, interval time.Duration, payload any) error
+
+	// EnqueueTask 提交已注册类型的异步任务。
+	EnqueueTask(ctx context.Context, taskType string, payload any) (*BatchTaskAcceptedResponse, error)
+
 	// UpdateModelHealthEnabled 更新模型健康状态（enabled=true 启用，false 禁用）
 	UpdateModelHealthEnabled(ctx context.Context, modelID uint, enabled bool) (types.HealthStatus, error)
 
@@ -113,6 +197,12 @@ type Service interface {
 	// UpdateKeyHealthEnabled 更新密钥健康状态（enabled=true 启用，false 禁用）
 	UpdateKeyHealthEnabled(ctx context.Context, keyID uint, enabled bool) (types.HealthStatus, error)
 
+	// ValidateKey 校验尚未保存的密钥值能否通过指定平台的鉴权
+	ValidateKey(ctx context.Context, platformID uint, keyValue string) (*KeyValidationResult, error)
+
+	// TestKey 校验已保存的密钥能否通过所属平台的鉴权
+	TestKey(ctx context.Context, keyID uint) (*KeyValidationResult, error)
+
 	// AddEndpointToPlatform 为指定平台添加新端点
 	AddEndpointToPlatform(ctx context.Context, platformId uint, endpoint types.Endpoint) (*types.Endpoint, error)
 
@@ -148,4 +238,16 @@ type Service interface {
 
 	// CountResourceHealthByPlatform 获取密钥和模型按平台分组的健康计数。
 	CountResourceHealthByPlatform(ctx context.Context) (keyCounts, modelCounts map[uint]PlatformStatusCount, err error)
+
+	// ExportConfig 导出全部平台配置，includeSecrets 为 true 时包含密钥及其与模型的关联
+	ExportConfig(ctx context.Context, includeSecrets bool) (*ConfigDocument, error)
+
+	// ImportConfig 按配置文档同步平台配置，dryRun 为 true 时仅返回变更而不写入
+	ImportConfig(ctx context.Context, doc ConfigDocument, dryRun bool) (*ConfigImportResult, error)
+
+	// SyncProvidersFile 将声明式配置文件同步到数据库，文件中的平台标记为受管
+	SyncProvidersFile(ctx context.Context, path string) (*ConfigImportResult, error)
+
+	// StartProvidersFileSync 启动时同步声明式配置文件，并在文件变更时重新同步；path 为空时不启用
+	StartProvidersFileSync(ctx context.Context, path string) error
 }
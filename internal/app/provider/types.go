@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/MeowSalty/pinai/database/types"
 	"github.com/MeowSalty/pinai/internal/app/health"
@@ -21,6 +22,8 @@ type service struct {
 	modelBatchTaskRepo  ModelBatchTaskRepository
 	controlTx           ControlTx
 	controlAudit        ControlAuditLogger
+	keyValidator        KeyValidator
+	modelLister         ModelLister
 
 	workerMu         sync.Mutex
 	workerCancel     context.CancelFunc
@@ -34,6 +37,17 @@ type service struct {
 	taskStateCache    map[uint]*ModelBatchTaskSummary
 	taskEnqueued      map[uint]struct{}
 	workerRecoverOnce sync.Once
+
+	taskConcurrency    int
+	taskRetryBaseDelay time.Duration
+	taskLeaseDuration  time.Duration
+	taskOwner          string
+	taskRunning        map[uint]context.CancelCauseFunc
+
+	taskHandlers    map[string]TaskHandler
+	taskMaxAttempts map[string]int
+	taskTimeouts    map[string]time.Duration
+	periodicTasks   []periodicTask
 }
 
 // PlatformStatusCount 平台维度健康状态统计。
@@ -89,6 +103,34 @@ type BatchDeleteModelsResponse struct {
 	DeletedCount int `json:"deleted_count"` // 实际删除的模型数
 }
 
+// BatchCreateKeysRequest 批量导入密钥的请求体
+//
+// Keys 与 Text 可同时提供；Text 为逐行粘贴的密钥列表或 CSV（取每行第一列），重复密钥自动去除。
+type BatchCreateKeysRequest struct {
+	Keys     []string `json:"keys,omitempty"`      // 密钥值列表
+	Text     string   `json:"text,omitempty"`      // 逐行或 CSV 格式的密钥文本
+	ModelIDs []uint   `json:"model_ids,omitempty"` // 可选：新密钥关联的模型 ID 列表
+}
+
+// BatchDeleteKeysRequest 批量删除密钥的请求体
+type BatchDeleteKeysRequest struct {
+	KeyIDs []uint `json:"key_ids" binding:"required,min=1"` // 要删除的密钥 ID 列表
+}
+
+// 密钥与模型关联的批量更新方式。
+const (
+	KeyModelsModeAdd     = "add"     // 追加关联
+	KeyModelsModeRemove  = "remove"  // 移除关联
+	KeyModelsModeReplace = "replace" // 以 ModelIDs 替换密钥现有关联
+)
+
+// BatchUpdateKeyModelsRequest 批量更新密钥与模型关联的请求体
+type BatchUpdateKeyModelsRequest struct {
+	KeyIDs   []uint `json:"key_ids" binding:"required,min=1"`                 // 要更新的密钥 ID 列表
+	ModelIDs []uint `json:"model_ids"`                                        // 模型 ID 列表，replace 时可为空
+	Mode     string `json:"mode" binding:"required,oneof=add remove replace"` // 更新方式
+}
+
 // BatchTaskAcceptedResponse 表示异步任务已接受响应。
 type BatchTaskAcceptedResponse struct {
 	TaskID uint   `json:"task_id"`
@@ -102,20 +144,85 @@ type BatchTaskResult struct {
 	CreatedCount int `json:"created_count,omitempty"`
 	UpdatedCount int `json:"updated_count,omitempty"`
 	DeletedCount int `json:"deleted_count,omitempty"`
+	SkippedCount int `json:"skipped_count,omitempty"`
+}
+
+// ModelSyncPreview 表示平台模型与上游模型列表的差异。
+type ModelSyncPreview struct {
+	PlatformID     uint              `json:"platform_id"`
+	KeyID          uint              `json:"key_id"`          // 请求模型列表所用的密钥 ID，新增模型关联该密钥
+	UpstreamCount  int               `json:"upstream_count"`  // 上游返回的模型数量
+	UnchangedCount int               `json:"unchanged_count"` // 已存在且仍由上游提供的模型数量
+	Added          []string          `json:"added"`           // 将新增的模型名称
+	Removed        []ModelSyncRemove `json:"removed"`         // 将删除的模型
+}
+
+// ModelSyncRemove 表示同步时将删除的模型。
+type ModelSyncRemove struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+}
+
+// ModelSyncResponse 表示模型同步请求结果，存在变更且非预览时附带已提交的任务。
+type ModelSyncResponse struct {
+	Preview *ModelSyncPreview          `json:"preview"`
+	Task    *BatchTaskAcceptedResponse `json:"task,omitempty"`
+}
+
+// ModelSyncResult 表示模型同步任务的执行结果。
+type ModelSyncResult struct {
+	Platforms []ModelSyncPlatformResult `json:"platforms"`
+}
+
+// ModelSyncPlatformResult 表示单个平台的模型同步结果，失败时 Error 非空。
+type ModelSyncPlatformResult struct {
+	PlatformID   uint   `json:"platform_id"`
+	CreatedCount int    `json:"created_count"`
+	DeletedCount int    `json:"deleted_count"`
+	Error        string `json:"error,omitempty"`
 }
 
 // ModelBatchTaskSummary 表示模型批量任务查询结果。
 type ModelBatchTaskSummary struct {
-	ID           uint            `json:"id"`
-	Type         string          `json:"type"`
-	Status       string          `json:"status"`
-	PlatformID   uint            `json:"platform_id"`
-	Result       json.RawMessage `json:"result,omitempty"`
-	ErrorMessage string          `json:"error_message,omitempty"`
-	StartedAt    *string         `json:"started_at,omitempty"`
-	FinishedAt   *string         `json:"finished_at,omitempty"`
-	CreatedAt    string          `json:"created_at"`
-	UpdatedAt    string          `json:"updated_at"`
+	ID              uint            `json:"id"`
+	Type            string          `json:"type"`
+	Status          string          `json:"status"`
+	PlatformID      uint            `json:"platform_id"`
+	Result          json.RawMessage `json:"result,omitempty"`
+	ErrorMessage    string          `json:"error_message,omitempty"`
+	Progress        int             `json:"progress"`
+	Attempts        int             `json:"attempts"`
+	MaxAttempts     int             `json:"max_attempts"`
+	NextRunAt       *string         `json:"next_run_at,omitempty"`
+	CancelRequested bool            `json:"cancel_requested"`
+	StartedAt       *string         `json:"started_at,omitempty"`
+	FinishedAt      *string         `json:"finished_at,omitempty"`
+	CreatedAt       string          `json:"created_at"`
+	UpdatedAt       string          `json:"updated_at"`
+}
+
+// ListTasksOptions 定义任务列表的筛选选项
+type ListTasksOptions struct {
+	Type       string // 任务类型
+	Status     string // 任务状态
+	PlatformID *uint  // 平台 ID
+	Page       int    // 页码
+	PageSize   int    // 每页大小
+}
+
+// TaskListResponse 定义任务列表响应
+type TaskListResponse struct {
+	Items    []ModelBatchTaskSummary `json:"items"`     // 任务摘要
+	Total    int64                   `json:"total"`     // 总数
+	Page     int                     `json:"page"`      // 当前页码
+	PageSize int                     `json:"page_size"` // 每页大小
+}
+
+// TaskPruneResult 定义已结束任务清理结果
+type TaskPruneResult struct {
+	Before      time.Time `json:"before"`       // 清理截止时间
+	DeletedRows int64     `json:"deleted_rows"` // 删除的任务数量
 }
 
 // BatchCreateEndpointsRequest 批量创建端点的请求体
@@ -151,3 +258,98 @@ type BatchUpdateEndpointsResponse struct {
 	TotalCount   int               `json:"total_count"`
 	UpdatedCount int               `json:"updated_count"`
 }
+
+// ConfigDocumentVersion 为当前配置文档格式版本
+const ConfigDocumentVersion = 1
+
+// ConfigDocument 配置导出/导入文档
+//
+// 平台按名称匹配，端点按类型与变体匹配，模型按名称匹配，密钥按密钥值匹配。
+// IncludeSecrets 为 false 时文档不含密钥，导入时不改动现有密钥及其与模型的关联。
+type ConfigDocument struct {
+	Version        int              `json:"version"`
+	IncludeSecrets bool             `json:"include_secrets"`
+	Platforms      []PlatformConfig `json:"platforms"`
+}
+
+// PlatformConfig 平台配置
+type PlatformConfig struct {
+	Name      string                `json:"name"`
+	BaseURL   string                `json:"base_url"`
+	RateLimit types.RateLimitConfig `json:"rate_limit"`
+	Endpoints []EndpointConfig      `json:"endpoints"`
+	Keys      []KeyConfig           `json:"keys,omitempty"`
+	Models    []ModelConfig         `json:"models"`
+}
+
+// EndpointConfig 端点配置
+type EndpointConfig struct {
+	EndpointType    string            `json:"endpoint_type"`
+	EndpointVariant string            `json:"endpoint_variant"`
+	Path            string            `json:"path"`
+	CustomHeaders   map[string]string `json:"custom_headers,omitempty"`
+	IsDefault       bool              `json:"is_default"`
+}
+
+// KeyConfig 密钥配置，Ref 为文档内引用名，供模型关联使用
+//
+// ValueEnv 仅用于声明式配置文件，表示从该环境变量读取密钥值。
+type KeyConfig struct {
+	Ref      string `json:"ref"`
+	Value    string `json:"value,omitempty"`
+	ValueEnv string `json:"value_env,omitempty"`
+}
+
+// ModelConfig 模型配置，Keys 为关联密钥的引用名
+type ModelConfig struct {
+	Name  string   `json:"name"`
+	Alias string   `json:"alias,omitempty"`
+	Keys  []string `json:"keys,omitempty"`
+}
+
+// ConfigChange 配置导入产生的单项变更
+type ConfigChange struct {
+	Action   string   `json:"action"`           // create、update 或 delete
+	Resource string   `json:"resource"`         // platform、endpoint、key 或 model
+	Platform string   `json:"platform"`         // 所属平台名称
+	Name     string   `json:"name,omitempty"`   // 资源名称；密钥为文档引用名或脱敏值
+	Fields   []string `json:"fields,omitempty"` // 更新的字段
+}
+
+// ConfigImportResult 配置导入结果
+type ConfigImportResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Applied bool           `json:"applied"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Deleted int            `json:"deleted"`
+	Changes []ConfigChange `json:"changes"`
+}
+
+// PlatformPreset 常用供应方的平台预设
+//
+// BaseURLRequired 为 true 时 BaseURL 仅为示例（如包含资源名占位符），创建时必须提供实际地址。
+type PlatformPreset struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	BaseURL         string           `json:"base_url"`
+	BaseURLRequired bool             `json:"base_url_required"`
+	Endpoints       []EndpointConfig `json:"endpoints"`
+	Models          []string         `json:"models"`
+}
+
+// CreatePlatformFromPresetRequest 从预设创建平台的请求体，字段均可省略
+//
+// Models 省略时使用预设的建议模型列表，传入空数组时不创建模型。
+type CreatePlatformFromPresetRequest struct {
+	Name    string   `json:"name"`     // 平台名称，默认为预设名称
+	BaseURL string   `json:"base_url"` // 基础 URL，默认为预设地址
+	Models  []string `json:"models"`   // 创建的模型名称列表
+}
+
+// PlatformFromPresetResponse 从预设创建平台的结果，包含创建的端点与模型
+type PlatformFromPresetResponse struct {
+	*types.Platform
+	Models []*types.Model `json:"models"`
+}
@@ -141,6 +141,32 @@ func (s *service) removeOrphanedModels(ctx context.Context, platformId uint, log
 		return 0, fmt.Errorf("查询平台模型失败：%w", err)
 	}
 
+	return s.deleteKeylessModels(ctx, models, logger)
+}
+
+// removeOrphanedModelsAmong 仅在 modelIDs 范围内检测并移除指定平台的孤立模型
+//
+// 用于删除密钥后只清理原先由这些密钥关联的模型，平台上本就没有密钥的模型（如按预设创建的模型）不受影响
+func (s *service) removeOrphanedModelsAmong(ctx context.Context, platformId uint, modelIDs []uint, logger *slog.Logger) (int64, error) {
+	if len(modelIDs) == 0 {
+		return 0, nil
+	}
+	logger.Debug("开始检测孤立模型", slog.Int("candidate_count", len(modelIDs)))
+
+	models, err := query.Q.Model.WithContext(ctx).
+		Preload(query.Q.Model.APIKeys).
+		Where(query.Q.Model.PlatformID.Eq(platformId), query.Q.Model.ID.In(modelIDs...)).
+		Find()
+	if err != nil {
+		logger.Error("查询平台模型失败", slog.Any("error", err))
+		return 0, fmt.Errorf("查询平台模型失败：%w", err)
+	}
+
+	return s.deleteKeylessModels(ctx, models, logger)
+}
+
+// deleteKeylessModels 删除 models 中没有关联任何密钥的模型，返回被删除的模型数量
+func (s *service) deleteKeylessModels(ctx context.Context, models []*types.Model, logger *slog.Logger) (int64, error) {
 	// 找出孤立模型（没有关联任何密钥的模型）
 	var orphanedModelIDs []uint
 	for _, model := range models {
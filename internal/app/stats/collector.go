@@ -19,6 +19,12 @@ type Collector struct {
 	// 活动连接计数器
 	activeConnections int64
 
+	// 按模型、平台与客户端拆分的实时计数
+	dimensions *realtimeDimensions
+
+	// 实时事件订阅者
+	live *liveHub
+
 	// 日志记录器
 	logger *slog.Logger
 }
@@ -30,6 +36,8 @@ func NewCollector(logger *slog.Logger) *Collector {
 	collector := &Collector{
 		requestCounts: make([]int64, 60), // 保存过去 60 秒的数据
 		currentSecond: time.Now().Unix(),
+		dimensions:    newRealtimeDimensions(),
+		live:          newLiveHub(),
 		logger:        logger,
 	}
 
@@ -64,14 +72,19 @@ func (c *Collector) RecordRequest() {
 	c.requestCounts[index]++
 }
 
-// IncrementConnection 增加活动连接数
-func (c *Collector) IncrementConnection() {
+// IncrementConnection 增加活动连接数，并向实时事件订阅者推送请求开始事件
+func (c *Collector) IncrementConnection(info RequestInfo) {
 	newCount := atomic.AddInt64(&c.activeConnections, 1)
 	c.logger.Debug("增加活动连接", "active_connections", newCount)
+
+	c.dimensions.start(info, time.Now().Unix())
+	c.publishRequestStart(info)
 }
 
-// DecrementConnection 减少活动连接数
-func (c *Collector) DecrementConnection() {
+// DecrementConnection 减少活动连接数；info 须与 IncrementConnection 传入的一致
+func (c *Collector) DecrementConnection(info RequestInfo) {
+	c.dimensions.end(info, time.Now().Unix())
+
 	after := atomic.AddInt64(&c.activeConnections, -1)
 	before := after + 1
 
@@ -138,5 +151,12 @@ func (c *Collector) cleanup() {
 			}
 		}
 		c.mu.Unlock()
+
+		// 每个窗口周期清理一次已无数据的维度取值
+		if now%realtimeWindowSeconds == 0 {
+			if removed := c.dimensions.prune(now); removed > 0 {
+				c.logger.Debug("清理过期维度计数", "removed", removed)
+			}
+		}
 	}
 }
@@ -0,0 +1,288 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// realtimeWindowSeconds 为实时分维度统计的滑动窗口长度（秒）
+const realtimeWindowSeconds = 60
+
+// realtimeTopN 为实时分维度统计每个维度返回的最大条目数
+const realtimeTopN = 20
+
+// slidingWindow 记录过去 60 秒的逐秒计数
+//
+// 每个槽位记录其对应的秒时间戳，读写时惰性判定过期，无需后台清理。
+type slidingWindow struct {
+	counts  [realtimeWindowSeconds]int64
+	seconds [realtimeWindowSeconds]int64
+}
+
+func (w *slidingWindow) add(now, n int64) {
+	i := now % realtimeWindowSeconds
+	if w.seconds[i] != now {
+		w.seconds[i] = now
+		w.counts[i] = 0
+	}
+	w.counts[i] += n
+}
+
+func (w *slidingWindow) sum(now int64) int64 {
+	var total int64
+	for i := range w.counts {
+		if age := now - w.seconds[i]; age >= 0 && age < realtimeWindowSeconds {
+			total += w.counts[i]
+		}
+	}
+	return total
+}
+
+// dimensionCounters 为单个维度取值（某个模型、平台或客户端）的实时计数
+type dimensionCounters struct {
+	requests slidingWindow // 请求数
+	tokens   slidingWindow // Token 数
+	finished slidingWindow // 完成数
+	failed   slidingWindow // 失败数
+	inFlight int64         // 进行中的请求数
+	lastSeen int64         // 最近一次更新的秒时间戳
+}
+
+// dimensionSet 为一个维度下所有取值的实时计数
+type dimensionSet[K comparable] map[K]*dimensionCounters
+
+func (d dimensionSet[K]) get(key K, now int64) *dimensionCounters {
+	counters, ok := d[key]
+	if !ok {
+		counters = &dimensionCounters{}
+		d[key] = counters
+	}
+	counters.lastSeen = now
+	return counters
+}
+
+// prune 清理窗口内无数据且无进行中请求的取值，返回清理数量
+func (d dimensionSet[K]) prune(now int64) int {
+	removed := 0
+	for key, counters := range d {
+		if counters.inFlight <= 0 && now-counters.lastSeen >= realtimeWindowSeconds {
+			delete(d, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (d dimensionSet[K]) snapshot(now int64, name func(K) (string, uint)) []RealtimeBreakdownItem {
+	items := make([]RealtimeBreakdownItem, 0, len(d))
+	for key, counters := range d {
+		item := RealtimeBreakdownItem{
+			RPM:      counters.requests.sum(now),
+			TPM:      counters.tokens.sum(now),
+			InFlight: counters.inFlight,
+			Finished: counters.finished.sum(now),
+		}
+		if item.Finished > 0 {
+			item.ErrorRate = float64(counters.failed.sum(now)) / float64(item.Finished)
+		}
+		if item.RPM == 0 && item.Finished == 0 && item.InFlight == 0 {
+			continue
+		}
+		item.Name, item.PlatformID = name(key)
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].RPM == items[j].RPM {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].RPM > items[j].RPM
+	})
+	if len(items) > realtimeTopN {
+		items = items[:realtimeTopN]
+	}
+
+	return items
+}
+
+// realtimeDimensions 为按模型、平台与客户端拆分的实时计数
+//
+// 三个维度统一统计四项指标：RPM 为过去 1 分钟开始的请求数，进行中请求数为已开始未结束的请求数，
+// TPM 与错误率按过去 1 分钟写入的请求日志（每次上游尝试一条）计算。
+// 模型与客户端在数据面请求开始时计数；平台以上游尝试计数，候选通道均属于同一平台时在路由阶段开始计数，
+// 候选通道分属多个平台时由 portal 选择通道，该尝试在请求日志写入、得知实际通道后计入所属平台。
+// 进行中的请求按进程内请求标识（RequestInfo.Key）登记，请求日志经该标识关联数据面请求，
+// 从而取得客户端 IP 与客户端请求的模型名称；客户端指定的请求 ID 可能重复，不用于关联。
+type realtimeDimensions struct {
+	mu        sync.Mutex
+	models    dimensionSet[string]
+	platforms dimensionSet[uint]
+	clients   dimensionSet[string]
+	requests  map[uint64]*requestState
+}
+
+// requestState 为进行中的数据面请求的维度归属
+type requestState struct {
+	info     RequestInfo
+	platform uint // 当前上游尝试已计入的平台，0 表示未计入
+}
+
+func newRealtimeDimensions() *realtimeDimensions {
+	return &realtimeDimensions{
+		models:    make(dimensionSet[string]),
+		platforms: make(dimensionSet[uint]),
+		clients:   make(dimensionSet[string]),
+		requests:  make(map[uint64]*requestState),
+	}
+}
+
+func (r *realtimeDimensions) start(info RequestInfo, now int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	model := r.models.get(modelKey(info.Model), now)
+	model.requests.add(now, 1)
+	model.inFlight++
+
+	client := r.clients.get(info.ClientIP, now)
+	client.requests.add(now, 1)
+	client.inFlight++
+
+	if info.Key != 0 {
+		r.requests[info.Key] = &requestState{info: info}
+	}
+}
+
+// route 在路由确定平台后将本次上游尝试计入平台
+func (r *realtimeDimensions) route(info RequestInfo, now int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.requests[info.Key]
+	if !ok || info.PlatformID == 0 {
+		return
+	}
+	r.releasePlatform(state, now)
+
+	platform := r.platforms.get(info.PlatformID, now)
+	platform.requests.add(now, 1)
+	platform.inFlight++
+	state.platform = info.PlatformID
+}
+
+func (r *realtimeDimensions) end(info RequestInfo, now int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state, ok := r.requests[info.Key]; ok {
+		r.releasePlatform(state, now)
+		delete(r.requests, info.Key)
+	}
+
+	if model := r.models.get(modelKey(info.Model), now); model.inFlight > 0 {
+		model.inFlight--
+	}
+	if client := r.clients.get(info.ClientIP, now); client.inFlight > 0 {
+		client.inFlight--
+	}
+}
+
+// finish 记录一次上游尝试的结果，返回关联到的数据面请求信息
+func (r *realtimeDimensions) finish(log *types.RequestLog, now int64) (RequestInfo, bool) {
+	tokens := ptrIntToInt64(log.TotalTokens)
+	if log.TotalTokens == nil {
+		tokens = ptrIntToInt64(log.PromptTokens) + ptrIntToInt64(log.CompletionTokens)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modelName := log.OriginalModelName
+	counters := make([]*dimensionCounters, 0, 3)
+
+	state, matched := r.requests[log.RequestKey]
+	if matched {
+		modelName = state.info.Model
+		counters = append(counters, r.clients.get(state.info.ClientIP, now))
+	}
+	counters = append(counters, r.models.get(modelKey(modelName), now))
+
+	platform := r.platforms.get(log.PlatformID, now)
+	if !matched || state.platform != log.PlatformID {
+		// 路由阶段未能确定平台的尝试在完成时按实际通道计入请求数
+		platform.requests.add(now, 1)
+	}
+	if matched {
+		r.releasePlatform(state, now)
+	}
+	counters = append(counters, platform)
+
+	for _, c := range counters {
+		c.tokens.add(now, tokens)
+		c.finished.add(now, 1)
+		if !log.Success {
+			c.failed.add(now, 1)
+		}
+	}
+
+	if !matched {
+		return RequestInfo{}, false
+	}
+	return state.info, true
+}
+
+// releasePlatform 结束请求当前尝试在平台上的进行中计数
+func (r *realtimeDimensions) releasePlatform(state *requestState, now int64) {
+	if state.platform == 0 {
+		return
+	}
+	if platform := r.platforms.get(state.platform, now); platform.inFlight > 0 {
+		platform.inFlight--
+	}
+	state.platform = 0
+}
+
+func (r *realtimeDimensions) prune(now int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.models.prune(now) + r.platforms.prune(now) + r.clients.prune(now)
+}
+
+// RealtimeBreakdown 返回按模型、平台与客户端拆分的过去 1 分钟实时数据
+//
+// platformName 用于解析平台名称，可为 nil。
+func (c *Collector) RealtimeBreakdown(platformName func(uint) string) RealtimeBreakdown {
+	now := time.Now().Unix()
+
+	c.dimensions.mu.Lock()
+	defer c.dimensions.mu.Unlock()
+
+	return RealtimeBreakdown{
+		Models: c.dimensions.models.snapshot(now, func(model string) (string, uint) {
+			return model, 0
+		}),
+		Platforms: c.dimensions.platforms.snapshot(now, func(id uint) (string, uint) {
+			if platformName != nil {
+				if name := platformName(id); name != "" {
+					return name, id
+				}
+			}
+			return "", id
+		}),
+		Clients: c.dimensions.clients.snapshot(now, func(clientIP string) (string, uint) {
+			return clientIP, 0
+		}),
+	}
+}
+
+// modelKey 将空模型名归一为 unknown，与仪表盘保持一致
+func modelKey(model string) string {
+	if model == "" {
+		return "unknown"
+	}
+	return model
+}
@@ -0,0 +1,145 @@
+package stats
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestCollectorRealtimeBreakdown_按维度统计(t *testing.T) {
+	collector := NewCollector(slog.Default())
+
+	// 前两个请求携带相同的客户端请求 ID，按进程内标识区分
+	first := RequestInfo{Key: 1, RequestID: "req-dup", Model: "gpt-4o", ClientIP: "10.0.0.1"}
+	second := RequestInfo{Key: 2, RequestID: "req-dup", Model: "gpt-4o", ClientIP: "10.0.0.2"}
+	third := RequestInfo{Key: 3, RequestID: "req-3", Model: "claude", ClientIP: "10.0.0.1"}
+	collector.IncrementConnection(first)
+	collector.IncrementConnection(second)
+	collector.IncrementConnection(third)
+
+	// 首个请求路由到平台 1 后完成
+	routed := first
+	routed.PlatformID = 1
+	collector.ObserveRequestRoute(routed)
+	collector.ObserveRequestLog(&types.RequestLog{RequestID: "req-dup", RequestKey: 1, OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, PromptTokens: intPtr(30), CompletionTokens: intPtr(10)})
+	collector.DecrementConnection(first)
+
+	// 第二个请求已路由到平台 1，尚未完成
+	routed = second
+	routed.PlatformID = 1
+	collector.ObserveRequestRoute(routed)
+
+	// 第三个请求的候选通道分属多个平台，在平台 2 失败后仍在重试，失败的尝试按实际通道计入平台 2
+	collector.ObserveRequestLog(&types.RequestLog{RequestID: "req-3", RequestKey: 3, OriginalModelName: "claude", PlatformID: 2, Success: false})
+
+	// 未关联数据面请求的日志仅计入模型与平台
+	collector.ObserveRequestLog(&types.RequestLog{OriginalModelName: "claude", PlatformID: 2, Success: true, TotalTokens: intPtr(5)})
+
+	breakdown := collector.RealtimeBreakdown(func(id uint) string {
+		if id == 1 {
+			return "openai"
+		}
+		return ""
+	})
+
+	models := make(map[string]RealtimeBreakdownItem)
+	for _, item := range breakdown.Models {
+		models[item.Name] = item
+	}
+	if model := models["gpt-4o"]; model.RPM != 2 || model.InFlight != 1 || model.TPM != 40 || model.Finished != 1 || model.ErrorRate != 0 {
+		t.Fatalf("模型 gpt-4o 统计不符: %+v", model)
+	}
+	if model := models["claude"]; model.RPM != 1 || model.InFlight != 1 || model.TPM != 5 || model.Finished != 2 || model.ErrorRate != 0.5 {
+		t.Fatalf("模型 claude 统计不符: %+v", model)
+	}
+
+	platforms := make(map[uint]RealtimeBreakdownItem)
+	for _, item := range breakdown.Platforms {
+		platforms[item.PlatformID] = item
+	}
+	if len(platforms) != 2 {
+		t.Fatalf("平台维度数量 = %d, 期望 2", len(platforms))
+	}
+	if platform := platforms[1]; platform.Name != "openai" || platform.RPM != 2 || platform.InFlight != 1 || platform.TPM != 40 || platform.Finished != 1 || platform.ErrorRate != 0 {
+		t.Fatalf("平台 1 统计不符: %+v", platform)
+	}
+	if platform := platforms[2]; platform.RPM != 2 || platform.InFlight != 0 || platform.TPM != 5 || platform.Finished != 2 || platform.ErrorRate != 0.5 {
+		t.Fatalf("平台 2 统计不符: %+v", platform)
+	}
+
+	clients := make(map[string]RealtimeBreakdownItem)
+	for _, item := range breakdown.Clients {
+		clients[item.Name] = item
+	}
+	if len(clients) != 2 {
+		t.Fatalf("客户端维度数量 = %d, 期望 2", len(clients))
+	}
+	if client := clients["10.0.0.1"]; client.RPM != 2 || client.InFlight != 1 || client.TPM != 40 || client.Finished != 2 || client.ErrorRate != 0.5 {
+		t.Fatalf("客户端 10.0.0.1 统计不符: %+v", client)
+	}
+	if client := clients["10.0.0.2"]; client.RPM != 1 || client.InFlight != 1 || client.Finished != 0 {
+		t.Fatalf("客户端 10.0.0.2 统计不符: %+v", client)
+	}
+
+	// 请求结束后释放平台上的进行中计数
+	collector.DecrementConnection(second)
+	collector.DecrementConnection(third)
+	for _, platform := range collector.RealtimeBreakdown(nil).Platforms {
+		if platform.InFlight != 0 {
+			t.Fatalf("请求结束后平台 %d 进行中请求数 = %d, 期望 0", platform.PlatformID, platform.InFlight)
+		}
+	}
+}
+
+func TestCollectorRealtimeBreakdown_重试切换平台(t *testing.T) {
+	collector := NewCollector(slog.Default())
+
+	info := RequestInfo{Key: 1, RequestID: "req-retry", Model: "gpt-4o", ClientIP: "10.0.0.1"}
+	collector.IncrementConnection(info)
+
+	routed := info
+	routed.PlatformID = 1
+	collector.ObserveRequestRoute(routed)
+	routed.PlatformID = 2
+	collector.ObserveRequestRoute(routed)
+
+	platforms := make(map[uint]RealtimeBreakdownItem)
+	for _, item := range collector.RealtimeBreakdown(nil).Platforms {
+		platforms[item.PlatformID] = item
+	}
+	if platforms[1].RPM != 1 || platforms[1].InFlight != 0 {
+		t.Fatalf("平台 1 统计不符: %+v", platforms[1])
+	}
+	if platforms[2].RPM != 1 || platforms[2].InFlight != 1 {
+		t.Fatalf("平台 2 统计不符: %+v", platforms[2])
+	}
+
+	collector.ObserveRequestLog(&types.RequestLog{RequestID: "req-retry", RequestKey: 1, OriginalModelName: "gpt-4o", PlatformID: 2, Success: true})
+	collector.DecrementConnection(info)
+
+	for _, item := range collector.RealtimeBreakdown(nil).Platforms {
+		if item.PlatformID == 2 && (item.RPM != 1 || item.InFlight != 0 || item.Finished != 1) {
+			t.Fatalf("完成后平台 2 统计不符: %+v", item)
+		}
+	}
+}
+
+func TestSlidingWindow_过期数据不计入(t *testing.T) {
+	var w slidingWindow
+	w.add(100, 3)
+	w.add(130, 2)
+
+	if got := w.sum(130); got != 5 {
+		t.Fatalf("窗口内计数 = %d, 期望 5", got)
+	}
+	if got := w.sum(165); got != 2 {
+		t.Fatalf("部分过期后计数 = %d, 期望 2", got)
+	}
+
+	// 同一槽位进入新的秒时覆盖旧计数
+	w.add(160, 1)
+	if got := w.sum(160); got != 3 {
+		t.Fatalf("覆盖槽位后计数 = %d, 期望 3", got)
+	}
+}
@@ -7,22 +7,11 @@ import (
 	"time"
 
 	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
 )
 
 const dashboardTopN = 5
 
-// dashboardLogRow 定义仪表盘聚合查询的原始行结构
-type dashboardLogRow struct {
-	Timestamp         time.Time `gorm:"column:timestamp"`
-	Success           bool      `gorm:"column:success"`
-	FirstByteTime     *int64    `gorm:"column:first_byte_time"`
-	OriginalModelName string    `gorm:"column:original_model_name"`
-	PlatformID        uint      `gorm:"column:platform_id"`
-	PromptTokens      *int      `gorm:"column:prompt_tokens"`
-	CompletionTokens  *int      `gorm:"column:completion_tokens"`
-	TotalTokens       *int      `gorm:"column:total_tokens"`
-}
-
 type dashboardCallAgg struct {
 	RequestCount int64
 	SuccessCount int64
@@ -34,7 +23,9 @@ type dashboardUsageAgg struct {
 	TotalTokens      int64
 }
 
-// GetDashboard 获取仪表盘所有数据（单次查询优化版本）
+// GetDashboard 获取仪表盘所有数据
+//
+// 数据读取自小时汇总与延迟分布表，不扫描原始请求日志。
 func (s *service) GetDashboard(ctx context.Context, trendRange TrendRange) (*DashboardResponse, error) {
 	start := time.Now()
 	logger := s.logger.With("operation", "get_dashboard")
@@ -59,22 +50,36 @@ func (s *service) GetDashboard(ctx context.Context, trendRange TrendRange) (*Das
 		"bucket_end", bucketEnd,
 	)
 
-	var rows []dashboardLogRow
-	err := query.Q.RequestLog.WithContext(ctx).
-		UnderlyingDB().
-		Table("request_logs").
-		Select("timestamp, success, first_byte_time, original_model_name, platform_id, prompt_tokens, completion_tokens, total_tokens").
-		Where("timestamp >= ? AND timestamp < ?", bucketStart, bucketEnd).
-		Order("timestamp ASC").
-		Scan(&rows).Error
+	stats, err := loadHourlyStats(ctx, bucketStart, bucketEnd, nil)
+	if err != nil {
+		logger.ErrorContext(ctx, "查询仪表盘小时汇总失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("查询仪表盘数据失败：%w", err)
+	}
+
+	firstByteSketch, err := loadLatencySketch(ctx, types.LatencyMetricFirstByte, bucketStart, bucketEnd)
+	if err != nil {
+		logger.ErrorContext(ctx, "查询首字用时分布失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("查询仪表盘数据失败：%w", err)
+	}
+
+	costRows, err := loadUsageCosts(ctx, bucketStart, bucketEnd)
 	if err != nil {
-		logger.ErrorContext(ctx, "查询仪表盘原始数据失败",
+		logger.ErrorContext(ctx, "查询费用汇总失败",
 			"error", err,
 			"error_type", "database_error",
 			"latency_ms", time.Since(start).Milliseconds(),
 		)
 		return nil, fmt.Errorf("查询仪表盘数据失败：%w", err)
 	}
+	costs := indexUsageCosts(costRows)
 
 	platformNameMap, err := s.loadPlatformNameMap(ctx)
 	if err != nil {
@@ -97,37 +102,27 @@ func (s *service) GetDashboard(ctx context.Context, trendRange TrendRange) (*Das
 	platformCallAgg := make(map[uint]*dashboardCallAgg)
 	modelUsageAgg := make(map[string]*dashboardUsageAgg)
 	platformUsageAgg := make(map[uint]*dashboardUsageAgg)
-	firstByteDurations := make([]uint64, 0, len(rows))
 
 	var (
-		overview      DashboardOverview
-		successCount  int64
-		trendSummary  TrendSummary
-		totalUsageTok int64
+		overview       DashboardOverview
+		successCount   int64
+		firstByteCount int64
+		firstByteSum   int64
+		trendSummary   TrendSummary
+		totalUsageTok  int64
 	)
 
-	for _, row := range rows {
-		overview.TotalRequests++
-		if row.Success {
-			successCount++
-		}
-
-		if row.FirstByteTime != nil && *row.FirstByteTime > 0 {
-			firstByteDurations = append(firstByteDurations, uint64(*row.FirstByteTime))
-		}
-
-		promptTokens := ptrIntToInt64(row.PromptTokens)
-		completionTokens := ptrIntToInt64(row.CompletionTokens)
-		totalTokens := ptrIntToInt64(row.TotalTokens)
-		if row.TotalTokens == nil && (promptTokens > 0 || completionTokens > 0) {
-			totalTokens = promptTokens + completionTokens
-		}
+	for _, stat := range stats {
+		overview.TotalRequests += stat.RequestCount
+		successCount += stat.SuccessCount
+		firstByteCount += stat.FirstByteCount
+		firstByteSum += stat.FirstByteSum
 
-		overview.TotalPromptTokens += promptTokens
-		overview.TotalCompletionTokens += completionTokens
-		overview.TotalTokens += totalTokens
+		overview.TotalPromptTokens += stat.PromptTokens
+		overview.TotalCompletionTokens += stat.CompletionTokens
+		overview.TotalTokens += stat.TotalTokens
 
-		modelName := row.OriginalModelName
+		modelName := stat.ModelName
 		if modelName == "" {
 			modelName = "unknown"
 		}
@@ -135,48 +130,50 @@ func (s *service) GetDashboard(ctx context.Context, trendRange TrendRange) (*Das
 		if _, exists := modelCallAgg[modelName]; !exists {
 			modelCallAgg[modelName] = &dashboardCallAgg{}
 		}
-		modelCallAgg[modelName].RequestCount++
-		if row.Success {
-			modelCallAgg[modelName].SuccessCount++
-		}
+		modelCallAgg[modelName].RequestCount += stat.RequestCount
+		modelCallAgg[modelName].SuccessCount += stat.SuccessCount
 
-		if _, exists := platformCallAgg[row.PlatformID]; !exists {
-			platformCallAgg[row.PlatformID] = &dashboardCallAgg{}
-		}
-		platformCallAgg[row.PlatformID].RequestCount++
-		if row.Success {
-			platformCallAgg[row.PlatformID].SuccessCount++
+		if _, exists := platformCallAgg[stat.PlatformID]; !exists {
+			platformCallAgg[stat.PlatformID] = &dashboardCallAgg{}
 		}
+		platformCallAgg[stat.PlatformID].RequestCount += stat.RequestCount
+		platformCallAgg[stat.PlatformID].SuccessCount += stat.SuccessCount
 
-		if row.TotalTokens != nil || row.PromptTokens != nil || row.CompletionTokens != nil {
+		if stat.UsageCount > 0 {
 			if _, exists := modelUsageAgg[modelName]; !exists {
 				modelUsageAgg[modelName] = &dashboardUsageAgg{}
 			}
-			modelUsageAgg[modelName].PromptTokens += promptTokens
-			modelUsageAgg[modelName].CompletionTokens += completionTokens
-			modelUsageAgg[modelName].TotalTokens += totalTokens
+			modelUsageAgg[modelName].PromptTokens += stat.PromptTokens
+			modelUsageAgg[modelName].CompletionTokens += stat.CompletionTokens
+			modelUsageAgg[modelName].TotalTokens += stat.TotalTokens
 
-			if _, exists := platformUsageAgg[row.PlatformID]; !exists {
-				platformUsageAgg[row.PlatformID] = &dashboardUsageAgg{}
+			if _, exists := platformUsageAgg[stat.PlatformID]; !exists {
+				platformUsageAgg[stat.PlatformID] = &dashboardUsageAgg{}
 			}
-			platformUsageAgg[row.PlatformID].PromptTokens += promptTokens
-			platformUsageAgg[row.PlatformID].CompletionTokens += completionTokens
-			platformUsageAgg[row.PlatformID].TotalTokens += totalTokens
+			platformUsageAgg[stat.PlatformID].PromptTokens += stat.PromptTokens
+			platformUsageAgg[stat.PlatformID].CompletionTokens += stat.CompletionTokens
+			platformUsageAgg[stat.PlatformID].TotalTokens += stat.TotalTokens
 
-			totalUsageTok += totalTokens
+			totalUsageTok += stat.TotalTokens
 		}
 
-		idx := int(row.Timestamp.Sub(bucketStart) / cfg.Granularity)
+		idx := int(stat.BucketStart.Sub(bucketStart) / cfg.Granularity)
 		if idx >= 0 && idx < len(points) {
-			points[idx].RequestCount++
-			points[idx].TotalTokens += totalTokens
+			points[idx].RequestCount += stat.RequestCount
+			points[idx].TotalTokens += stat.TotalTokens
 		}
 	}
 
 	overview.SuccessRate = s.calculateSuccessRate(overview.TotalRequests, successCount)
-	overview.AvgFirstByteTime = s.calculateAverageWithPercentile(firstByteDurations, percentileLower, percentileUpper)
+	// 样本不足时与原始算法一致使用算术平均，否则基于延迟分布近似百分位过滤平均值
+	if firstByteCount > 0 && firstByteCount < 10 {
+		overview.AvgFirstByteTime = float64(firstByteSum) / float64(firstByteCount)
+	} else {
+		overview.AvgFirstByteTime = firstByteSketch.trimmedMean(percentileLower, percentileUpper)
+	}
 	overview.ActiveModels = len(modelCallAgg)
 	overview.ActivePlatforms = len(platformCallAgg)
+	overview.TotalCosts = costsFromMap(costs.total)
 
 	for _, p := range points {
 		trendSummary.TotalRequests += p.RequestCount
@@ -190,8 +187,8 @@ func (s *service) GetDashboard(ctx context.Context, trendRange TrendRange) (*Das
 	ranks := DashboardRanks{
 		ModelCall:     buildModelCallRankItems(modelCallAgg, overview.TotalRequests),
 		PlatformCall:  buildPlatformCallRankItems(platformCallAgg, platformNameMap, overview.TotalRequests),
-		ModelUsage:    buildModelUsageRankItems(modelUsageAgg, totalUsageTok),
-		PlatformUsage: buildPlatformUsageRankItems(platformUsageAgg, platformNameMap, totalUsageTok),
+		ModelUsage:    buildModelUsageRankItems(modelUsageAgg, totalUsageTok, costs.models),
+		PlatformUsage: buildPlatformUsageRankItems(platformUsageAgg, platformNameMap, totalUsageTok, costs.platforms),
 	}
 
 	resp := &DashboardResponse{
@@ -208,7 +205,7 @@ func (s *service) GetDashboard(ctx context.Context, trendRange TrendRange) (*Das
 
 	logger.DebugContext(ctx, "成功聚合仪表盘数据",
 		"range", trendRange,
-		"rows", len(rows),
+		"hourly_stat_rows", len(stats),
 		"total_requests", resp.Overview.TotalRequests,
 		"total_tokens", resp.Overview.TotalTokens,
 		"latency_ms", time.Since(start).Milliseconds(),
@@ -234,44 +231,6 @@ func (s *service) loadPlatformNameMap(ctx context.Context) (map[uint]string, err
 	return nameMap, nil
 }
 
-// calculateAverageWithPercentile 使用百分位数过滤法计算平均值
-func (s *service) calculateAverageWithPercentile(values []uint64, lowerPercentile, upperPercentile float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-
-	if len(values) < 10 {
-		return calculateAverage(values)
-	}
-
-	sort.Slice(values, func(i, j int) bool {
-		return values[i] < values[j]
-	})
-
-	lowerIndex := int(float64(len(values)) * lowerPercentile)
-	upperIndex := int(float64(len(values)) * upperPercentile)
-
-	if lowerIndex < 0 {
-		lowerIndex = 0
-	}
-	if upperIndex > len(values) {
-		upperIndex = len(values)
-	}
-	if lowerIndex >= upperIndex {
-		upperIndex = lowerIndex + 1
-	}
-	if upperIndex > len(values) {
-		upperIndex = len(values)
-	}
-
-	filteredValues := values[lowerIndex:upperIndex]
-	if len(filteredValues) == 0 {
-		return 0
-	}
-
-	return calculateAverage(filteredValues)
-}
-
 // ptrIntToInt64 将 *int 安全转换为 int64
 func ptrIntToInt64(v *int) int64 {
 	if v == nil {
@@ -355,7 +314,7 @@ func buildPlatformCallRankItems(agg map[uint]*dashboardCallAgg, platformNameMap
 	return items
 }
 
-func buildModelUsageRankItems(agg map[string]*dashboardUsageAgg, totalTokens int64) []ModelUsageRankItem {
+func buildModelUsageRankItems(agg map[string]*dashboardUsageAgg, totalTokens int64, costs map[string]map[string]float64) []ModelUsageRankItem {
 	items := make([]ModelUsageRankItem, 0, len(agg))
 	for modelName, stat := range agg {
 		var percentage float64
@@ -369,6 +328,7 @@ func buildModelUsageRankItems(agg map[string]*dashboardUsageAgg, totalTokens int
 			PromptTokens:     stat.PromptTokens,
 			CompletionTokens: stat.CompletionTokens,
 			Percentage:       percentage,
+			Costs:            costsFromMap(costs[modelName]),
 		})
 	}
 
@@ -386,7 +346,7 @@ func buildModelUsageRankItems(agg map[string]*dashboardUsageAgg, totalTokens int
 	return items
 }
 
-func buildPlatformUsageRankItems(agg map[uint]*dashboardUsageAgg, platformNameMap map[uint]string, totalTokens int64) []PlatformUsageRankItem {
+func buildPlatformUsageRankItems(agg map[uint]*dashboardUsageAgg, platformNameMap map[uint]string, totalTokens int64, costs map[uint]map[string]float64) []PlatformUsageRankItem {
 	items := make([]PlatformUsageRankItem, 0, len(agg))
 	for platformID, stat := range agg {
 		platformName := platformNameMap[platformID]
@@ -405,6 +365,7 @@ func buildPlatformUsageRankItems(agg map[uint]*dashboardUsageAgg, platformNameMa
 			PromptTokens:     stat.PromptTokens,
 			CompletionTokens: stat.CompletionTokens,
 			Percentage:       percentage,
+			Costs:            costsFromMap(costs[platformID]),
 		})
 	}
 
@@ -0,0 +1,373 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+const (
+	errorBreakdownLimit  = 10  // 每个错误分组内各维度最多返回的条目数
+	errorSampleLimit     = 3   // 每个错误分组最多返回的示例消息数
+	errorSampleScanLimit = 200 // 每个错误分组读取的最近失败日志数上限，用于挑选示例消息
+	errorSampleMaxLength = 500 // 示例消息最大长度（字符）
+)
+
+// errorSampleRow 为挑选示例消息所需的失败请求日志字段
+type errorSampleRow struct {
+	ID                   uint
+	Timestamp            time.Time
+	ErrorMsg             *string
+	UpstreamErrorMessage *string
+}
+
+// errorGroupKey 为错误分组维度
+type errorGroupKey struct {
+	ErrorCode         string
+	ErrorFrom         string
+	HTTPStatus        int
+	UpstreamErrorType string
+	UpstreamErrorCode string
+}
+
+// errorGroupAgg 为单个错误分组的聚合中间结果
+type errorGroupAgg struct {
+	item      *ErrorGroupItem
+	models    map[string]int64
+	platforms map[uint]int64
+	apiKeys   map[uint]int64
+}
+
+// GetErrorAnalytics 获取错误分析数据。
+//
+// 失败请求按结构化错误字段分组，每组给出按模型、平台与密钥的拆分、时间趋势与出现最多的错误消息。
+// 计数、拆分与趋势读取自小时错误汇总，不受原始日志保留期影响；示例消息取自每组最近的失败日志（至多 errorSampleScanLimit 条），
+// 原始日志已清理的分组没有示例消息。
+func (s *service) GetErrorAnalytics(ctx context.Context, opts ErrorAnalyticsOptions) (*ErrorAnalyticsResponse, error) {
+	start := time.Now()
+	logger := s.logger.With("operation", "get_error_analytics")
+
+	window, err := resolveStatusWindow(ctx, StatusOptions{Range: opts.Range})
+	if err != nil {
+		return nil, err
+	}
+	bucketStart, bucketEnd := window.Start, window.End
+
+	logger.DebugContext(ctx, "开始聚合错误分析数据",
+		"range", window.Range,
+		"granularity", window.Label,
+		"bucket_start", bucketStart,
+		"bucket_end", bucketEnd,
+	)
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("bucket_start >= ? AND bucket_start < ?", bucketStart, bucketEnd)
+		if opts.ModelName != nil {
+			db = db.Where("model_name = ?", *opts.ModelName)
+		}
+		if opts.PlatformID != nil {
+			db = db.Where("platform_id = ?", *opts.PlatformID)
+		}
+		if opts.APIKeyID != nil {
+			db = db.Where("api_key_id = ?", *opts.APIKeyID)
+		}
+		return db
+	}
+
+	var totalRequests int64
+	if err := filter(requestLogDB(ctx).Model(&types.RequestLogHourlyUsage{})).
+		Select("COALESCE(SUM(request_count), 0)").
+		Scan(&totalRequests).Error; err != nil {
+		logger.ErrorContext(ctx, "统计请求总数失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("查询错误分析数据失败：%w", err)
+	}
+
+	var rows []types.RequestLogHourlyError
+	if err := filter(requestLogDB(ctx).Model(&types.RequestLogHourlyError{})).
+		Order("bucket_start ASC").
+		Find(&rows).Error; err != nil {
+		logger.ErrorContext(ctx, "查询错误汇总失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("查询错误分析数据失败：%w", err)
+	}
+
+	platformNameMap, err := s.loadPlatformNameMap(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "加载平台名称映射失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("加载平台名称失败：%w", err)
+	}
+
+	var totalErrors int64
+	aggMap := make(map[errorGroupKey]*errorGroupAgg)
+	for _, row := range rows {
+		key := errorGroupKey{
+			ErrorCode:         row.ErrorCode,
+			ErrorFrom:         row.ErrorFrom,
+			HTTPStatus:        row.HTTPStatus,
+			UpstreamErrorType: row.UpstreamErrorType,
+			UpstreamErrorCode: row.UpstreamErrorCode,
+		}
+		bucket := row.BucketStart.UTC()
+
+		agg, exists := aggMap[key]
+		if !exists {
+			points := make([]ErrorTrendPoint, window.Points)
+			for i := range points {
+				points[i] = ErrorTrendPoint{
+					Timestamp: bucketStart.Add(window.Granularity * time.Duration(i+1)),
+				}
+			}
+
+			item := &ErrorGroupItem{
+				ErrorCode:         key.ErrorCode,
+				ErrorFrom:         key.ErrorFrom,
+				UpstreamErrorType: key.UpstreamErrorType,
+				UpstreamErrorCode: key.UpstreamErrorCode,
+				FirstSeen:         bucket,
+				LastSeen:          bucket,
+				Points:            points,
+			}
+			if key.HTTPStatus != 0 {
+				status := key.HTTPStatus
+				item.HTTPStatus = &status
+			}
+
+			agg = &errorGroupAgg{
+				item:      item,
+				models:    make(map[string]int64),
+				platforms: make(map[uint]int64),
+				apiKeys:   make(map[uint]int64),
+			}
+			aggMap[key] = agg
+		}
+
+		item := agg.item
+		item.Count += row.RequestCount
+		totalErrors += row.RequestCount
+		if bucket.Before(item.FirstSeen) {
+			item.FirstSeen = bucket
+		}
+		if bucket.After(item.LastSeen) {
+			item.LastSeen = bucket
+		}
+
+		if idx := window.pointIndex(bucket); idx >= 0 {
+			item.Points[idx].Count += row.RequestCount
+		}
+
+		agg.models[row.ModelName] += row.RequestCount
+		agg.platforms[row.PlatformID] += row.RequestCount
+		agg.apiKeys[row.APIKeyID] += row.RequestCount
+	}
+
+	groups := make([]ErrorGroupItem, 0, len(aggMap))
+	for key, agg := range aggMap {
+		item := agg.item
+		if totalErrors > 0 {
+			item.Percentage = float64(item.Count) / float64(totalErrors) * 100
+		}
+		item.Models = buildErrorModelItems(agg.models)
+		item.Platforms = buildErrorPlatformItems(agg.platforms, platformNameMap)
+		item.APIKeys = buildErrorAPIKeyItems(agg.apiKeys)
+
+		samples, err := loadErrorSamples(ctx, bucketStart, bucketEnd, opts, key)
+		if err != nil {
+			logger.ErrorContext(ctx, "查询错误示例失败",
+				"error", err,
+				"error_type", "database_error",
+				"latency_ms", time.Since(start).Milliseconds(),
+			)
+			return nil, fmt.Errorf("查询错误分析数据失败：%w", err)
+		}
+		// 小时汇总只能定位到小时，原始日志仍保留时以最新失败日志的时间作为最近出现时间
+		if len(samples) > 0 && samples[0].Timestamp.After(item.LastSeen) {
+			item.LastSeen = samples[0].Timestamp
+		}
+		item.Samples = buildErrorSamples(samples)
+		groups = append(groups, *item)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count == groups[j].Count {
+			return groups[i].LastSeen.After(groups[j].LastSeen)
+		}
+		return groups[i].Count > groups[j].Count
+	})
+
+	resp := &ErrorAnalyticsResponse{
+		Range:         window.Range,
+		Granularity:   window.Label,
+		WindowStart:   bucketStart,
+		WindowEnd:     bucketEnd,
+		TotalRequests: totalRequests,
+		TotalErrors:   totalErrors,
+		Groups:        groups,
+	}
+	if totalRequests > 0 {
+		resp.ErrorRate = float64(totalErrors) / float64(totalRequests)
+	}
+
+	logger.DebugContext(ctx, "成功聚合错误分析数据",
+		"total_requests", totalRequests,
+		"total_errors", totalErrors,
+		"groups", len(groups),
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	return resp, nil
+}
+
+// loadErrorSamples 读取错误分组在窗口内最近的失败日志（按 ID 倒序，至多 errorSampleScanLimit 条）
+//
+// 汇总中的空错误字段对应原始日志中的 NULL 或空值。
+func loadErrorSamples(ctx context.Context, from, to time.Time, opts ErrorAnalyticsOptions, key errorGroupKey) ([]errorSampleRow, error) {
+	db := requestLogDB(ctx).Table("request_logs").
+		Select("id, timestamp, error_msg, upstream_error_message").
+		Where("timestamp >= ? AND timestamp < ? AND success = ?", from, to, false)
+	if opts.ModelName != nil {
+		db = db.Where("original_model_name = ?", *opts.ModelName)
+	}
+	if opts.PlatformID != nil {
+		db = db.Where("platform_id = ?", *opts.PlatformID)
+	}
+	if opts.APIKeyID != nil {
+		db = db.Where("api_key_id = ?", *opts.APIKeyID)
+	}
+
+	for _, field := range []struct {
+		column string
+		value  any
+		empty  bool
+	}{
+		{"error_code", key.ErrorCode, key.ErrorCode == ""},
+		{"error_from", key.ErrorFrom, key.ErrorFrom == ""},
+		{"http_status", key.HTTPStatus, key.HTTPStatus == 0},
+		{"upstream_error_type", key.UpstreamErrorType, key.UpstreamErrorType == ""},
+		{"upstream_error_code", key.UpstreamErrorCode, key.UpstreamErrorCode == ""},
+	} {
+		if field.empty {
+			db = db.Where(fmt.Sprintf("(%s IS NULL OR %s = ?)", field.column, field.column), field.value)
+		} else {
+			db = db.Where(field.column+" = ?", field.value)
+		}
+	}
+
+	var rows []errorSampleRow
+	if err := db.Order("id DESC").Limit(errorSampleScanLimit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func buildErrorModelItems(counts map[string]int64) []ErrorDimensionItem {
+	items := make([]ErrorDimensionItem, 0, len(counts))
+	for name, count := range counts {
+		if name == "" {
+			name = "unknown"
+		}
+		items = append(items, ErrorDimensionItem{Name: name, Count: count})
+	}
+	return sortErrorDimensionItems(items)
+}
+
+func buildErrorPlatformItems(counts map[uint]int64, nameMap map[uint]string) []ErrorDimensionItem {
+	items := make([]ErrorDimensionItem, 0, len(counts))
+	for id, count := range counts {
+		name := nameMap[id]
+		if name == "" {
+			name = fmt.Sprintf("平台#%d", id)
+		}
+		items = append(items, ErrorDimensionItem{ID: id, Name: name, Count: count})
+	}
+	return sortErrorDimensionItems(items)
+}
+
+func buildErrorAPIKeyItems(counts map[uint]int64) []ErrorDimensionItem {
+	items := make([]ErrorDimensionItem, 0, len(counts))
+	for id, count := range counts {
+		items = append(items, ErrorDimensionItem{ID: id, Name: fmt.Sprintf("密钥#%d", id), Count: count})
+	}
+	return sortErrorDimensionItems(items)
+}
+
+// sortErrorDimensionItems 按次数降序排列并截断到 errorBreakdownLimit 条
+func sortErrorDimensionItems(items []ErrorDimensionItem) []ErrorDimensionItem {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count == items[j].Count {
+			if items[i].ID == items[j].ID {
+				return items[i].Name < items[j].Name
+			}
+			return items[i].ID < items[j].ID
+		}
+		return items[i].Count > items[j].Count
+	})
+	if len(items) > errorBreakdownLimit {
+		items = items[:errorBreakdownLimit]
+	}
+	return items
+}
+
+// buildErrorSamples 统计示例日志中的错误消息，按出现次数返回至多 errorSampleLimit 条
+//
+// rows 按 ID 倒序，首次出现的日志即为该消息最新的一条；优先使用上游返回的错误消息。
+func buildErrorSamples(rows []errorSampleRow) []ErrorSampleMessage {
+	samples := make(map[string]*ErrorSampleMessage)
+	for _, row := range rows {
+		message := derefString(row.UpstreamErrorMessage)
+		if message == "" {
+			message = derefString(row.ErrorMsg)
+		}
+		message = truncateRunes(message, errorSampleMaxLength)
+		if sample, ok := samples[message]; ok {
+			sample.Count++
+			continue
+		}
+		samples[message] = &ErrorSampleMessage{Message: message, Count: 1, LastLogID: row.ID}
+	}
+
+	items := make([]ErrorSampleMessage, 0, len(samples))
+	for _, sample := range samples {
+		items = append(items, *sample)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count == items[j].Count {
+			return items[i].LastLogID > items[j].LastLogID
+		}
+		return items[i].Count > items[j].Count
+	})
+	if len(items) > errorSampleLimit {
+		items = items[:errorSampleLimit]
+	}
+	return items
+}
+
+func derefString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+// truncateRunes 按字符截断字符串，超出部分以省略号代替
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,151 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+// createRolledUpLogs 写入请求日志并同步累加小时汇总，与仓储写入路径一致
+func createRolledUpLogs(t *testing.T, db *gorm.DB, logs []*types.RequestLog) {
+	t.Helper()
+	ctx := context.Background()
+	for _, log := range logs {
+		if err := db.Create(log).Error; err != nil {
+			t.Fatalf("写入请求日志失败: %v", err)
+		}
+		if err := RecordRequestLogRollup(ctx, db, log); err != nil {
+			t.Fatalf("累加小时汇总失败: %v", err)
+		}
+	}
+}
+
+func TestGetErrorAnalytics_按结构化错误字段分组(t *testing.T) {
+	svc, db := newRetentionTestService(t, 0)
+	ctx := context.Background()
+
+	rateLimited, authFailed := "rate_limited", "auth_failed"
+	upstream := "upstream"
+	status429, status401 := 429, 401
+	slowDown, invalidKey := "slow down", "invalid api key"
+
+	now := time.Now()
+	createRolledUpLogs(t, db, []*types.RequestLog{
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 1, APIKeyID: 11, Success: true},
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 1, APIKeyID: 11, ErrorCode: &rateLimited, ErrorFrom: &upstream, HTTPStatus: &status429, UpstreamErrorMessage: &slowDown},
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 2, APIKeyID: 12, ErrorCode: &rateLimited, ErrorFrom: &upstream, HTTPStatus: &status429, UpstreamErrorMessage: &slowDown},
+		{Timestamp: now, OriginalModelName: "claude", PlatformID: 2, APIKeyID: 12, ErrorCode: &authFailed, ErrorFrom: &upstream, HTTPStatus: &status401, ErrorMsg: &invalidKey},
+		{Timestamp: now.Add(-48 * time.Hour), OriginalModelName: "gpt-4o", PlatformID: 1, ErrorCode: &authFailed, HTTPStatus: &status401},
+	})
+
+	result, err := svc.GetErrorAnalytics(ctx, ErrorAnalyticsOptions{Range: TrendRange24h})
+	if err != nil {
+		t.Fatalf("获取错误分析失败: %v", err)
+	}
+	if result.TotalRequests != 4 || result.TotalErrors != 3 {
+		t.Fatalf("请求总数或失败总数不符: %+v", result)
+	}
+	if len(result.Groups) != 2 {
+		t.Fatalf("错误分组数量 = %d, 期望 2", len(result.Groups))
+	}
+
+	top := result.Groups[0]
+	if top.ErrorCode != rateLimited || top.Count != 2 || *top.HTTPStatus != 429 {
+		t.Fatalf("首个分组不符: %+v", top)
+	}
+	if len(top.Platforms) != 2 || len(top.APIKeys) != 2 || len(top.Models) != 1 {
+		t.Fatalf("分组维度拆分不符: %+v", top)
+	}
+	if len(top.Samples) != 1 || top.Samples[0].Message != slowDown || top.Samples[0].Count != 2 {
+		t.Fatalf("示例消息不符: %+v", top.Samples)
+	}
+	if !top.LastSeen.Equal(now.UTC()) {
+		t.Fatalf("最近出现时间 = %v, 期望 %v", top.LastSeen, now.UTC())
+	}
+	if last := top.Points[len(top.Points)-1]; last.Count != 2 {
+		t.Fatalf("最新趋势点失败数 = %d, 期望 2", last.Count)
+	}
+
+	keyID := uint(12)
+	filtered, err := svc.GetErrorAnalytics(ctx, ErrorAnalyticsOptions{Range: TrendRange24h, APIKeyID: &keyID})
+	if err != nil {
+		t.Fatalf("按密钥获取错误分析失败: %v", err)
+	}
+	if filtered.TotalRequests != 2 || filtered.ErrorRate != 1 || len(filtered.Groups) != 2 {
+		t.Fatalf("按密钥过滤结果不符: %+v", filtered)
+	}
+}
+
+func TestGetErrorAnalytics_原始日志清理后仍统计(t *testing.T) {
+	svc, db := newRetentionTestService(t, 0)
+	ctx := context.Background()
+
+	timeout := "timeout"
+	now := time.Now()
+	createRolledUpLogs(t, db, []*types.RequestLog{
+		{Timestamp: now.Add(-5 * 24 * time.Hour), OriginalModelName: "gpt-4o", PlatformID: 1, ErrorCode: &timeout},
+		{Timestamp: now.Add(-5 * 24 * time.Hour), OriginalModelName: "gpt-4o", PlatformID: 1, Success: true},
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 1, ErrorCode: &timeout},
+	})
+
+	// 模拟保留任务清理 3 天前的原始日志
+	if err := db.Where("timestamp < ?", now.Add(-3*24*time.Hour).UTC()).Delete(&types.RequestLog{}).Error; err != nil {
+		t.Fatalf("清理原始日志失败: %v", err)
+	}
+
+	result, err := svc.GetErrorAnalytics(ctx, ErrorAnalyticsOptions{Range: TrendRange7d})
+	if err != nil {
+		t.Fatalf("获取错误分析失败: %v", err)
+	}
+	if result.TotalRequests != 3 || result.TotalErrors != 2 || len(result.Groups) != 1 {
+		t.Fatalf("清理原始日志后统计不符: %+v", result)
+	}
+	group := result.Groups[0]
+	if group.Count != 2 || len(group.Samples) != 1 || group.Samples[0].Count != 1 {
+		t.Fatalf("示例消息应仅来自仍保留的原始日志: %+v", group)
+	}
+	if !group.FirstSeen.Equal(hourBucket(now.Add(-5 * 24 * time.Hour))) {
+		t.Fatalf("首次出现时间 = %v, 期望按小时对齐的清理前时间", group.FirstSeen)
+	}
+}
+
+func TestBackfillRequestLogRollup_补建错误汇总(t *testing.T) {
+	svc, db := newRetentionTestService(t, 0)
+	ctx := context.Background()
+
+	timeout := "timeout"
+	now := time.Now()
+	createRolledUpLogs(t, db, []*types.RequestLog{
+		{Timestamp: now.Add(-2 * time.Hour), OriginalModelName: "gpt-4o", PlatformID: 1, ErrorCode: &timeout},
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 1, Success: true},
+	})
+
+	// 模拟新增错误汇总表前写入的日志：小时汇总已有失败请求，错误汇总为空
+	if err := db.Where("1 = 1").Delete(&types.RequestLogHourlyError{}).Error; err != nil {
+		t.Fatalf("清空错误汇总失败: %v", err)
+	}
+
+	if _, err := svc.BackfillRequestLogRollup(ctx); err != nil {
+		t.Fatalf("补建小时汇总失败: %v", err)
+	}
+
+	var errorRows []types.RequestLogHourlyError
+	if err := db.Find(&errorRows).Error; err != nil {
+		t.Fatalf("查询错误汇总失败: %v", err)
+	}
+	if len(errorRows) != 1 || errorRows[0].ErrorCode != timeout || errorRows[0].RequestCount != 1 {
+		t.Fatalf("补建的错误汇总不符: %+v", errorRows)
+	}
+
+	// 错误汇总已跟上时不再补建
+	from, err := svc.errorBackfillStart(ctx)
+	if err != nil {
+		t.Fatalf("查询错误汇总补建起点失败: %v", err)
+	}
+	if from != nil {
+		t.Fatalf("错误汇总已跟上时补建起点应为空: %v", from)
+	}
+}
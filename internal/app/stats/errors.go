@@ -0,0 +1,8 @@
+package stats
+
+import "errors"
+
+var (
+	ErrResourceNotFound = errors.New("资源未找到")
+	ErrInvalidArgument  = errors.New("请求参数不合法")
+)
@@ -0,0 +1,262 @@
+package stats
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// 实时事件类型
+const (
+	LiveEventRequestStart  = "request.start"  // 请求开始
+	LiveEventRequestFinish = "request.finish" // 请求完成（请求日志写入后，落库失败时同样发布）
+)
+
+// defaultLiveBufferSize 为单个订阅者的默认事件缓冲区大小
+const defaultLiveBufferSize = 256
+
+// RequestInfo 描述进入数据面的请求基本信息
+type RequestInfo struct {
+	Key       uint64 // 进程内唯一的请求标识，由 NewRequestKey 分配
+	RequestID string // 请求 ID
+	Model     string // 请求中的模型名称
+	Provider  string // 协议供应商（openai/anthropic/gemini）
+	APIStyle  string // API 风格（compat/native）
+	ClientIP  string // 客户端 IP
+	Stream    bool   // 是否为流式请求
+
+	PlatformID uint // 路由确定的平台 ID，仅在路由通知中设置
+}
+
+type requestInfoKey struct{}
+
+// requestKeySeq 为进程内请求标识的分配序列
+var requestKeySeq atomic.Uint64
+
+// NewRequestKey 分配进程内唯一的请求标识
+//
+// 请求 ID 可由客户端通过 X-Request-ID 指定，并发请求之间可能重复，
+// 因此进程内关联同一请求的开始、路由与请求日志时使用该标识。
+func NewRequestKey() uint64 {
+	return requestKeySeq.Add(1)
+}
+
+// ContextWithRequestInfo 将数据面请求信息写入 context，供请求日志关联请求 ID 与客户端信息
+func ContextWithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext 从 context 中读取数据面请求信息
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	if ctx == nil {
+		return RequestInfo{}, false
+	}
+	info, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
+
+// LiveEvent 定义实时请求事件
+//
+// 开始事件来自数据面入口；完成事件来自请求日志，携带日志 ID、平台与耗时等结果信息。
+// 两类事件均携带请求 ID，可据此关联同一请求的开始与完成；同一请求重试时每次尝试各产生一个完成事件。
+type LiveEvent struct {
+	Type       string    `json:"type"`                  // 事件类型
+	Timestamp  time.Time `json:"timestamp"`             // 事件时间
+	RequestID  string    `json:"request_id,omitempty"`  // 请求 ID
+	LogID      uint      `json:"log_id,omitempty"`      // 请求日志 ID（完成事件，落库失败时为空）
+	Model      string    `json:"model"`                 // 原始模型名称
+	PlatformID uint      `json:"platform_id,omitempty"` // 平台 ID（完成事件）
+	Provider   string    `json:"provider,omitempty"`    // 协议供应商（开始事件）
+	APIStyle   string    `json:"api_style,omitempty"`   // API 风格（开始事件）
+	ClientIP   string    `json:"client_ip,omitempty"`   // 客户端 IP（开始事件）
+	Stream     bool      `json:"stream"`                // 是否为流式请求
+
+	Success          *bool  `json:"success,omitempty"`           // 是否成功（完成事件）
+	ErrorCode        string `json:"error_code,omitempty"`        // 结构化错误码（完成事件）
+	HTTPStatus       *int   `json:"http_status,omitempty"`       // 上游 HTTP 状态码（完成事件）
+	Duration         int64  `json:"duration,omitempty"`          // 总用时（微秒，完成事件）
+	FirstByteTime    *int64 `json:"first_byte_time,omitempty"`   // 首字用时（微秒，完成事件）
+	PromptTokens     *int   `json:"prompt_tokens,omitempty"`     // 提示 Token 数（完成事件）
+	CompletionTokens *int   `json:"completion_tokens,omitempty"` // 完成 Token 数（完成事件）
+	TotalTokens      *int   `json:"total_tokens,omitempty"`      // 总 Token 数（完成事件）
+}
+
+// LiveFilter 定义实时事件订阅的过滤条件，零值字段表示不过滤
+//
+// 开始事件尚未确定平台，按平台过滤时仅推送完成事件。
+type LiveFilter struct {
+	Model      string
+	PlatformID uint
+}
+
+func (f LiveFilter) match(event *LiveEvent) bool {
+	if f.Model != "" && event.Model != f.Model {
+		return false
+	}
+	if f.PlatformID != 0 && event.PlatformID != f.PlatformID {
+		return false
+	}
+	return true
+}
+
+// LiveSubscription 表示一个实时事件订阅
+//
+// 事件通过有界缓冲区投递，订阅者消费过慢时新事件会被丢弃并计数，不会阻塞请求路径。
+type LiveSubscription struct {
+	id      uint64
+	filter  LiveFilter
+	events  chan *LiveEvent
+	dropped atomic.Int64
+	hub     *liveHub
+	once    sync.Once
+}
+
+// Events 返回事件通道；订阅关闭后通道被关闭
+func (s *LiveSubscription) Events() <-chan *LiveEvent {
+	return s.events
+}
+
+// TakeDropped 返回自上次调用以来因缓冲区已满被丢弃的事件数并清零
+func (s *LiveSubscription) TakeDropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+// Close 取消订阅，可重复调用
+func (s *LiveSubscription) Close() {
+	s.once.Do(func() {
+		s.hub.unsubscribe(s.id)
+	})
+}
+
+// liveHub 管理实时事件订阅者
+type liveHub struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	subscribers map[uint64]*LiveSubscription
+}
+
+func newLiveHub() *liveHub {
+	return &liveHub{subscribers: make(map[uint64]*LiveSubscription)}
+}
+
+func (h *liveHub) subscribe(filter LiveFilter, bufferSize int) *LiveSubscription {
+	if bufferSize <= 0 {
+		bufferSize = defaultLiveBufferSize
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	sub := &LiveSubscription{
+		id:     h.nextID,
+		filter: filter,
+		events: make(chan *LiveEvent, bufferSize),
+		hub:    h,
+	}
+	h.subscribers[sub.id] = sub
+
+	return sub
+}
+
+func (h *liveHub) unsubscribe(id uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if sub, ok := h.subscribers[id]; ok {
+		delete(h.subscribers, id)
+		close(sub.events)
+	}
+}
+
+// publish 非阻塞地向匹配的订阅者投递事件
+func (h *liveHub) publish(event *LiveEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, sub := range h.subscribers {
+		if !sub.filter.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+func (h *liveHub) subscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// SubscribeLive 订阅实时请求事件；bufferSize 小于等于 0 时使用默认缓冲区大小
+func (c *Collector) SubscribeLive(filter LiveFilter, bufferSize int) *LiveSubscription {
+	sub := c.live.subscribe(filter, bufferSize)
+	c.logger.Debug("新增实时事件订阅", "subscriber_id", sub.id, "model", filter.Model, "platform_id", filter.PlatformID)
+	return sub
+}
+
+// ObserveRequestLog 在请求日志写入后记录请求完成
+func (c *Collector) ObserveRequestLog(log *types.RequestLog) {
+	info, matched := c.dimensions.finish(log, time.Now().Unix())
+
+	if c.live.subscriberCount() == 0 {
+		return
+	}
+
+	success := log.Success
+	event := &LiveEvent{
+		Type:             LiveEventRequestFinish,
+		Timestamp:        time.Now(),
+		RequestID:        log.RequestID,
+		LogID:            log.ID,
+		Model:            log.OriginalModelName,
+		PlatformID:       log.PlatformID,
+		Stream:           log.IsStream,
+		Success:          &success,
+		HTTPStatus:       log.HTTPStatus,
+		Duration:         log.Duration,
+		FirstByteTime:    log.FirstByteTime,
+		PromptTokens:     log.PromptTokens,
+		CompletionTokens: log.CompletionTokens,
+		TotalTokens:      log.TotalTokens,
+	}
+	if log.ErrorCode != nil {
+		event.ErrorCode = *log.ErrorCode
+	}
+	if matched {
+		event.Provider = info.Provider
+		event.APIStyle = info.APIStyle
+		event.ClientIP = info.ClientIP
+	}
+
+	c.live.publish(event)
+}
+
+// ObserveRequestRoute 在路由确定上游平台后将本次上游尝试计入平台维度；info.PlatformID 为路由确定的平台
+func (c *Collector) ObserveRequestRoute(info RequestInfo) {
+	c.dimensions.route(info, time.Now().Unix())
+}
+
+func (c *Collector) publishRequestStart(info RequestInfo) {
+	if c.live.subscriberCount() == 0 {
+		return
+	}
+
+	c.live.publish(&LiveEvent{
+		Type:      LiveEventRequestStart,
+		Timestamp: time.Now(),
+		RequestID: info.RequestID,
+		Model:     info.Model,
+		Provider:  info.Provider,
+		APIStyle:  info.APIStyle,
+		ClientIP:  info.ClientIP,
+		Stream:    info.Stream,
+	})
+}
@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestCollectorLive_按模型与平台过滤事件(t *testing.T) {
+	collector := NewCollector(slog.Default())
+
+	byModel := collector.SubscribeLive(LiveFilter{Model: "gpt-4o"}, 8)
+	defer byModel.Close()
+	byPlatform := collector.SubscribeLive(LiveFilter{PlatformID: 2}, 8)
+	defer byPlatform.Close()
+
+	collector.IncrementConnection(RequestInfo{RequestID: "req-1", Model: "gpt-4o"})
+	collector.DecrementConnection(RequestInfo{RequestID: "req-1", Model: "gpt-4o"})
+
+	errorCode := "rate_limited"
+	collector.ObserveRequestLog(&types.RequestLog{ID: 7, RequestID: "req-1", OriginalModelName: "gpt-4o", PlatformID: 2, ErrorCode: &errorCode})
+	collector.ObserveRequestLog(&types.RequestLog{ID: 8, OriginalModelName: "claude", PlatformID: 3})
+
+	if got := len(byModel.Events()); got != 2 {
+		t.Fatalf("按模型过滤的事件数 = %d, 期望 2", got)
+	}
+	start := <-byModel.Events()
+	if start.Type != LiveEventRequestStart || start.RequestID != "req-1" {
+		t.Fatalf("开始事件不符: %+v", start)
+	}
+
+	if got := len(byPlatform.Events()); got != 1 {
+		t.Fatalf("按平台过滤的事件数 = %d, 期望 1", got)
+	}
+	finish := <-byPlatform.Events()
+	if finish.Type != LiveEventRequestFinish || finish.LogID != 7 || finish.RequestID != "req-1" || finish.ErrorCode != errorCode {
+		t.Fatalf("完成事件不符: %+v", finish)
+	}
+}
+
+func TestCollectorLive_慢消费者丢弃事件(t *testing.T) {
+	collector := NewCollector(slog.Default())
+
+	sub := collector.SubscribeLive(LiveFilter{}, 2)
+	for i := 0; i < 5; i++ {
+		collector.ObserveRequestLog(&types.RequestLog{ID: uint(i + 1)})
+	}
+
+	if got := len(sub.Events()); got != 2 {
+		t.Fatalf("缓冲事件数 = %d, 期望 2", got)
+	}
+	if dropped := sub.TakeDropped(); dropped != 3 {
+		t.Fatalf("丢弃事件数 = %d, 期望 3", dropped)
+	}
+	if dropped := sub.TakeDropped(); dropped != 0 {
+		t.Fatalf("再次读取丢弃数 = %d, 期望 0", dropped)
+	}
+
+	sub.Close()
+	sub.Close()
+	for range sub.Events() {
+	}
+}
@@ -2,11 +2,15 @@ package stats
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/MeowSalty/pinai/database/query"
 	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
 )
 
 // ListRequestLogs 实现获取请求状态列表的业务逻辑
@@ -27,10 +31,10 @@ func (s *service) ListRequestLogs(ctx context.Context, opts ListRequestLogsOptio
 
 	// 时间范围筛选
 	if opts.StartTime != nil {
-		queryBuilder = queryBuilder.Where(r.Timestamp.Gte(*opts.StartTime))
+		queryBuilder = queryBuilder.Where(r.Timestamp.Gte(opts.StartTime.UTC()))
 	}
 	if opts.EndTime != nil {
-		queryBuilder = queryBuilder.Where(r.Timestamp.Lte(*opts.EndTime))
+		queryBuilder = queryBuilder.Where(r.Timestamp.Lte(opts.EndTime.UTC()))
 	}
 
 	// 结果状态筛选
@@ -81,3 +85,93 @@ func (s *service) ListRequestLogs(ctx context.Context, opts ListRequestLogsOptio
 
 	return result, count, nil
 }
+
+// GetRequestLog 实现获取单条请求日志详情的业务逻辑
+//
+// ref 优先按数据面请求 ID（X-Request-ID）匹配，同一请求重试产生多条日志时返回最新一条；
+// 未匹配时按数字形式的请求日志 ID 查找。
+// 除请求日志本身外，还会补充平台、模型与密钥等路由详情，
+// 关联资源已被删除时对应字段留空，不视为错误。
+func (s *service) GetRequestLog(ctx context.Context, ref string) (*RequestLogDetail, error) {
+	start := time.Now()
+	logger := s.logger.With("operation", "get_request_log", "request_log_ref", ref)
+
+	q := query.Q
+
+	log, err := findRequestLog(ctx, ref)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("未找到请求 ID 或日志 ID 为 %s 的请求日志：%w", ref, ErrResourceNotFound)
+		}
+		logger.ErrorContext(ctx, "获取请求日志失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("获取请求日志失败：%w", err)
+	}
+
+	detail := &RequestLogDetail{RequestLog: log}
+
+	platforms, err := q.Platform.WithContext(ctx).
+		Select(q.Platform.Name, q.Platform.BaseURL).
+		Where(q.Platform.ID.Eq(log.PlatformID)).
+		Find()
+	if err != nil {
+		return nil, fmt.Errorf("获取请求日志关联平台失败：%w", err)
+	}
+	if len(platforms) > 0 {
+		detail.Routing.PlatformName = platforms[0].Name
+		detail.Routing.PlatformBaseURL = platforms[0].BaseURL
+	}
+
+	models, err := q.Model.WithContext(ctx).
+		Select(q.Model.Alias_).
+		Where(q.Model.ID.Eq(log.ModelID)).
+		Find()
+	if err != nil {
+		return nil, fmt.Errorf("获取请求日志关联模型失败：%w", err)
+	}
+	if len(models) > 0 {
+		detail.Routing.ModelAlias = models[0].Alias
+	}
+
+	keyCount, err := q.APIKey.WithContext(ctx).Where(q.APIKey.ID.Eq(log.APIKeyID)).Count()
+	if err != nil {
+		return nil, fmt.Errorf("获取请求日志关联密钥失败：%w", err)
+	}
+	detail.Routing.APIKeyExists = keyCount > 0
+
+	logger.DebugContext(ctx, "成功获取请求日志详情",
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	return detail, nil
+}
+
+// findRequestLog 按请求 ID 查找最新的请求日志，未找到时按请求日志 ID 查找
+func findRequestLog(ctx context.Context, ref string) (*types.RequestLog, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var logs []*types.RequestLog
+	if err := requestLogDB(ctx).
+		Where("request_id = ?", ref).
+		Order("id DESC").
+		Limit(1).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	if len(logs) > 0 {
+		return logs[0], nil
+	}
+
+	id, err := strconv.ParseUint(ref, 10, 64)
+	if err != nil || id == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	r := query.Q.RequestLog
+	return r.WithContext(ctx).Where(r.ID.Eq(uint(id))).First()
+}
@@ -5,59 +5,50 @@ import (
 	"fmt"
 	"sort"
 	"time"
-
-	"github.com/MeowSalty/pinai/database/query"
 )
 
-type modelStatusLogRow struct {
-	Timestamp         time.Time `gorm:"column:timestamp"`
-	Success           bool      `gorm:"column:success"`
-	OriginalModelName string    `gorm:"column:original_model_name"`
-}
-
 type modelStatusAgg struct {
 	item *ModelStatusItem
 }
 
 // GetModelStatus 获取模型状态监控数据。
-func (s *service) GetModelStatus(ctx context.Context, trendRange TrendRange, modelName *string) (*ModelStatusResponse, error) {
+//
+// 数据读取自小时汇总表，不扫描原始请求日志。
+func (s *service) GetModelStatus(ctx context.Context, opts StatusOptions) (*ModelStatusResponse, error) {
 	start := time.Now()
 	logger := s.logger.With("operation", "get_model_status")
 
-	if trendRange == "" {
-		trendRange = TrendRange24h
-	}
-
-	cfg, ok := trendRangeConfigs[trendRange]
-	if !ok {
-		return nil, fmt.Errorf("无效的时间范围参数，可选值：24h, 7d, 30d")
+	modelName := opts.ModelName
+	window, err := resolveStatusWindow(ctx, opts)
+	if err != nil {
+		return nil, err
 	}
-
-	now := time.Now()
-	bucketEnd := ceilToHour(now)
-	bucketStart := bucketEnd.Add(-cfg.Granularity * time.Duration(cfg.Points))
+	bucketStart, bucketEnd := window.Start, window.End
 
 	logger.DebugContext(ctx, "开始聚合模型状态数据",
-		"range", trendRange,
-		"granularity", cfg.Label,
+		"range", window.Range,
+		"granularity", window.Label,
 		"bucket_start", bucketStart,
 		"bucket_end", bucketEnd,
 	)
 
-	db := query.Q.RequestLog.WithContext(ctx).
-		UnderlyingDB().
-		Table("request_logs").
-		Select("timestamp, success, original_model_name").
-		Where("timestamp >= ? AND timestamp < ?", bucketStart, bucketEnd)
-
 	if modelName != nil && *modelName != "" {
-		db = db.Where("original_model_name = ?", *modelName)
 		logger = logger.With("model_name", *modelName)
 	}
 
-	var rows []modelStatusLogRow
-	if err := db.Order("timestamp ASC").Scan(&rows).Error; err != nil {
-		logger.ErrorContext(ctx, "查询模型状态原始数据失败",
+	stats, err := loadHourlyStats(ctx, bucketStart, bucketEnd, modelName)
+	if err != nil {
+		logger.ErrorContext(ctx, "查询模型状态小时汇总失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("查询模型状态数据失败：%w", err)
+	}
+
+	latencyRows, err := loadLatencyBins(ctx, bucketStart, bucketEnd, modelName)
+	if err != nil {
+		logger.ErrorContext(ctx, "查询模型延迟分布失败",
 			"error", err,
 			"error_type", "database_error",
 			"latency_ms", time.Since(start).Milliseconds(),
@@ -65,48 +56,42 @@ func (s *service) GetModelStatus(ctx context.Context, trendRange TrendRange, mod
 		return nil, fmt.Errorf("查询模型状态数据失败：%w", err)
 	}
 
+	latencyByModel := make(latencyStatsSet[string])
+	for _, row := range latencyRows {
+		latencyByModel.add(modelKey(row.ModelName), row)
+	}
+
 	aggMap := make(map[string]*modelStatusAgg)
 
-	for _, row := range rows {
-		name := row.OriginalModelName
+	for _, stat := range stats {
+		name := stat.ModelName
 		if name == "" {
 			name = "unknown"
 		}
 
 		agg, exists := aggMap[name]
 		if !exists {
-			points := make([]ModelStatusPoint, cfg.Points)
-			for i := 0; i < cfg.Points; i++ {
-				points[i] = ModelStatusPoint{
-					Timestamp: bucketStart.Add(cfg.Granularity * time.Duration(i+1)),
-				}
-			}
-
 			agg = &modelStatusAgg{
 				item: &ModelStatusItem{
 					ModelName: name,
-					Points:    points,
+					Points:    window.points(),
 				},
 			}
 			aggMap[name] = agg
 		}
 
-		agg.item.TotalRequests++
-		if row.Success {
-			agg.item.SuccessCount++
-		}
+		agg.item.TotalRequests += stat.RequestCount
+		agg.item.SuccessCount += stat.SuccessCount
 
-		idx := int(row.Timestamp.Sub(bucketStart) / cfg.Granularity)
-		if idx >= 0 && idx < len(agg.item.Points) {
-			agg.item.Points[idx].RequestCount++
-			if row.Success {
-				agg.item.Points[idx].SuccessCount++
-			}
+		if idx := window.pointIndex(stat.BucketStart); idx >= 0 {
+			agg.item.Points[idx].RequestCount += stat.RequestCount
+			agg.item.Points[idx].SuccessCount += stat.SuccessCount
 		}
 	}
 
 	models := make([]ModelStatusItem, 0, len(aggMap))
-	for _, agg := range aggMap {
+	for name, agg := range aggMap {
+		agg.item.Latency = latencyByModel.stats(name)
 		models = append(models, *agg.item)
 	}
 
@@ -118,15 +103,15 @@ func (s *service) GetModelStatus(ctx context.Context, trendRange TrendRange, mod
 	})
 
 	resp := &ModelStatusResponse{
-		Range:       string(trendRange),
-		Granularity: cfg.Label,
+		Range:       window.Range,
+		Granularity: window.Label,
 		WindowStart: bucketStart,
 		WindowEnd:   bucketEnd,
 		Models:      models,
 	}
 
 	logger.DebugContext(ctx, "成功聚合模型状态数据",
-		"rows", len(rows),
+		"hourly_stat_rows", len(stats),
 		"models", len(models),
 		"latency_ms", time.Since(start).Milliseconds(),
 	)
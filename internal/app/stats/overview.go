@@ -139,7 +139,7 @@ func (s *service) getRequestCounts(ctx context.Context, startTime time.Time) (to
 
 	// 获取总请求数
 	total, err = r.WithContext(ctx).
-		Where(r.Timestamp.Gte(startTime)).
+		Where(r.Timestamp.Gte(startTime.UTC())).
 		Count()
 	if err != nil {
 		s.logger.ErrorContext(ctx, "获取总请求数失败", "error", err, "start_time", startTime)
@@ -154,7 +154,7 @@ func (s *service) getRequestCounts(ctx context.Context, startTime time.Time) (to
 
 	// 获取成功请求数
 	success, err = r.WithContext(ctx).
-		Where(r.Timestamp.Gte(startTime)).
+		Where(r.Timestamp.Gte(startTime.UTC())).
 		Where(r.Success.Is(true)).
 		Count()
 	if err != nil {
@@ -199,7 +199,7 @@ func (s *service) getTokenStats(ctx context.Context, startTime time.Time) (promp
 			r.CompletionTokens.Sum().As("completion_tokens"),
 			r.TotalTokens.Sum().As("total_tokens"),
 		).
-		Where(r.Timestamp.Gte(startTime)).
+		Where(r.Timestamp.Gte(startTime.UTC())).
 		Scan(&result)
 
 	if err != nil {
@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// GetPlatformStatus 获取平台状态监控数据。
+//
+// 数据读取自小时汇总与延迟分布表；指定 modelName 时仅统计该模型的请求，用于对比同一模型在不同平台的表现。
+func (s *service) GetPlatformStatus(ctx context.Context, opts StatusOptions) (*PlatformStatusResponse, error) {
+	start := time.Now()
+	logger := s.logger.With("operation", "get_platform_status")
+
+	modelName := opts.ModelName
+	window, err := resolveStatusWindow(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	bucketStart, bucketEnd := window.Start, window.End
+
+	if modelName != nil && *modelName != "" {
+		logger = logger.With("model_name", *modelName)
+	}
+
+	logger.DebugContext(ctx, "开始聚合平台状态数据",
+		"range", window.Range,
+		"granularity", window.Label,
+		"bucket_start", bucketStart,
+		"bucket_end", bucketEnd,
+	)
+
+	stats, err := loadHourlyStats(ctx, bucketStart, bucketEnd, modelName)
+	if err != nil {
+		logger.ErrorContext(ctx, "查询平台状态小时汇总失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("查询平台状态数据失败：%w", err)
+	}
+
+	latencyRows, err := loadLatencyBins(ctx, bucketStart, bucketEnd, modelName)
+	if err != nil {
+		logger.ErrorContext(ctx, "查询平台延迟分布失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("查询平台状态数据失败：%w", err)
+	}
+
+	platformNameMap, err := s.loadPlatformNameMap(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "加载平台名称映射失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("加载平台名称失败：%w", err)
+	}
+
+	latencyByPlatform := make(latencyStatsSet[uint])
+	for _, row := range latencyRows {
+		latencyByPlatform.add(row.PlatformID, row)
+	}
+
+	aggMap := make(map[uint]*PlatformStatusItem)
+	for _, stat := range stats {
+		item, exists := aggMap[stat.PlatformID]
+		if !exists {
+			platformName := platformNameMap[stat.PlatformID]
+			if platformName == "" {
+				platformName = fmt.Sprintf("平台#%d", stat.PlatformID)
+			}
+
+			item = &PlatformStatusItem{
+				PlatformID:   stat.PlatformID,
+				PlatformName: platformName,
+				Points:       window.points(),
+			}
+			aggMap[stat.PlatformID] = item
+		}
+
+		item.TotalRequests += stat.RequestCount
+		item.SuccessCount += stat.SuccessCount
+
+		if idx := window.pointIndex(stat.BucketStart); idx >= 0 {
+			item.Points[idx].RequestCount += stat.RequestCount
+			item.Points[idx].SuccessCount += stat.SuccessCount
+		}
+	}
+
+	platforms := make([]PlatformStatusItem, 0, len(aggMap))
+	for platformID, item := range aggMap {
+		item.Latency = latencyByPlatform.stats(platformID)
+		platforms = append(platforms, *item)
+	}
+
+	sort.Slice(platforms, func(i, j int) bool {
+		if platforms[i].TotalRequests == platforms[j].TotalRequests {
+			return platforms[i].PlatformID < platforms[j].PlatformID
+		}
+		return platforms[i].TotalRequests > platforms[j].TotalRequests
+	})
+
+	resp := &PlatformStatusResponse{
+		Range:       window.Range,
+		Granularity: window.Label,
+		WindowStart: bucketStart,
+		WindowEnd:   bucketEnd,
+		Platforms:   platforms,
+	}
+	if modelName != nil {
+		resp.ModelName = *modelName
+	}
+
+	logger.DebugContext(ctx, "成功聚合平台状态数据",
+		"hourly_stat_rows", len(stats),
+		"platforms", len(platforms),
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	return resp, nil
+}
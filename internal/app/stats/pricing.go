@@ -0,0 +1,531 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
+	"gorm.io/gorm"
+)
+
+// priceBookTTL 为单价缓存的有效期；多实例部署时其他实例修改的单价最迟在该时间后生效
+const priceBookTTL = time.Minute
+
+// tokensPerPriceUnit 为单价对应的 Token 数（每百万 Token）
+const tokensPerPriceUnit = 1_000_000
+
+// priceBook 缓存全部模型单价，供写入请求日志时计算费用
+type priceBook struct {
+	mu       sync.Mutex
+	prices   []types.ModelPrice
+	loadedAt time.Time
+}
+
+// defaultPriceBook 为进程内共享的单价缓存
+var defaultPriceBook = &priceBook{}
+
+// snapshot 返回当前单价列表，缓存过期时从数据库重新加载
+func (b *priceBook) snapshot(ctx context.Context) ([]types.ModelPrice, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.loadedAt.IsZero() && time.Since(b.loadedAt) < priceBookTTL {
+		return b.prices, nil
+	}
+
+	var prices []types.ModelPrice
+	if err := requestLogDB(ctx).Model(&types.ModelPrice{}).Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("加载模型单价失败：%w", err)
+	}
+
+	b.prices = prices
+	b.loadedAt = time.Now()
+	return prices, nil
+}
+
+// invalidate 使缓存失效，下次计费时重新加载
+func (b *priceBook) invalidate() {
+	b.mu.Lock()
+	b.loadedAt = time.Time{}
+	b.mu.Unlock()
+}
+
+// PriceRequestLog 按请求发生时生效的模型单价计算并填充请求日志的费用。
+//
+// 无 Token 统计或无匹配单价时费用留空。须在写入请求日志的事务开始前调用。
+func PriceRequestLog(ctx context.Context, log *types.RequestLog) error {
+	log.Cost, log.CostCurrency = nil, nil
+	if log.PromptTokens == nil && log.CompletionTokens == nil {
+		return nil
+	}
+
+	prices, err := defaultPriceBook.snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Cost, log.CostCurrency = computeRequestCost(prices, log.PlatformID, log.ModelName, log.OriginalModelName,
+		log.Timestamp, log.PromptTokens, log.CompletionTokens)
+	return nil
+}
+
+// computeRequestCost 计算单条请求的费用；无 Token 统计或无匹配单价时返回 nil
+//
+// 上游未提供缓存命中 Token 数，输入 Token 全部按输入单价计费。
+func computeRequestCost(prices []types.ModelPrice, platformID uint, modelName, originalModelName string, at time.Time, promptTokens, completionTokens *int) (*float64, *string) {
+	if promptTokens == nil && completionTokens == nil {
+		return nil, nil
+	}
+
+	price := matchModelPrice(prices, platformID, modelName, originalModelName, at)
+	if price == nil {
+		return nil, nil
+	}
+
+	cost := (float64(ptrIntToInt64(promptTokens))*price.InputPrice +
+		float64(ptrIntToInt64(completionTokens))*price.OutputPrice) / tokensPerPriceUnit
+	currency := price.Currency
+	return &cost, &currency
+}
+
+// matchModelPrice 选出请求适用的单价。
+//
+// 候选单价须在请求时已生效，模型名称匹配上游模型名称或原始模型名称；
+// 平台专属单价优先于全局单价，上游模型名称优先于原始模型名称，同优先级取生效时间最新者。
+func matchModelPrice(prices []types.ModelPrice, platformID uint, modelName, originalModelName string, at time.Time) *types.ModelPrice {
+	var (
+		best      *types.ModelPrice
+		bestScore = -1
+	)
+
+	for i := range prices {
+		price := &prices[i]
+		if price.EffectiveFrom.After(at) {
+			continue
+		}
+		if price.PlatformID != 0 && price.PlatformID != platformID {
+			continue
+		}
+
+		score := 0
+		switch {
+		case modelName != "" && price.ModelName == modelName:
+			score = 1
+		case originalModelName != "" && price.ModelName == originalModelName:
+		default:
+			continue
+		}
+		if price.PlatformID != 0 {
+			score += 2
+		}
+
+		if score > bestScore || (score == bestScore && price.EffectiveFrom.After(best.EffectiveFrom)) {
+			best, bestScore = price, score
+		}
+	}
+
+	return best
+}
+
+// ListModelPrices 实现 Service 接口
+func (s *service) ListModelPrices(ctx context.Context, opts ListModelPricesOptions) ([]types.ModelPrice, error) {
+	db := requestLogDB(ctx).Model(&types.ModelPrice{})
+	if opts.PlatformID != nil {
+		db = db.Where("platform_id = ?", *opts.PlatformID)
+	}
+	if opts.ModelName != nil && *opts.ModelName != "" {
+		db = db.Where("model_name = ?", *opts.ModelName)
+	}
+
+	prices := make([]types.ModelPrice, 0)
+	if err := db.Order("model_name ASC, platform_id ASC, effective_from DESC").Find(&prices).Error; err != nil {
+		s.logger.ErrorContext(ctx, "查询模型单价失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询模型单价失败：%w", err)
+	}
+
+	return prices, nil
+}
+
+// CreateModelPrice 实现 Service 接口
+func (s *service) CreateModelPrice(ctx context.Context, req ModelPriceRequest) (*types.ModelPrice, error) {
+	price, err := buildModelPrice(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureModelPriceUnique(ctx, price, 0); err != nil {
+		return nil, err
+	}
+
+	if err := requestLogDB(ctx).Create(price).Error; err != nil {
+		s.logger.ErrorContext(ctx, "创建模型单价失败", "error", err, "error_type", "database_error")
+		s.recordPriceAudit(ctx, "model_price.create", 0, err, nil, price)
+		return nil, fmt.Errorf("创建模型单价失败：%w", err)
+	}
+
+	s.recordPriceAudit(ctx, "model_price.create", price.ID, nil, nil, price)
+	defaultPriceBook.invalidate()
+	s.logger.InfoContext(ctx, "模型单价已创建",
+		"price_id", price.ID,
+		"platform_id", price.PlatformID,
+		"model_name", price.ModelName,
+		"effective_from", price.EffectiveFrom,
+	)
+
+	return price, nil
+}
+
+// UpdateModelPrice 实现 Service 接口
+func (s *service) UpdateModelPrice(ctx context.Context, id uint, req ModelPriceRequest) (*types.ModelPrice, error) {
+	price, err := buildModelPrice(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing types.ModelPrice
+	if err := requestLogDB(ctx).First(&existing, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("未找到 ID 为 %d 的模型单价：%w", id, ErrResourceNotFound)
+		}
+		return nil, fmt.Errorf("查询模型单价失败：%w", err)
+	}
+
+	if err := ensureModelPriceUnique(ctx, price, id); err != nil {
+		return nil, err
+	}
+
+	price.ID = existing.ID
+	price.CreatedAt = existing.CreatedAt
+	if err := requestLogDB(ctx).Save(price).Error; err != nil {
+		s.logger.ErrorContext(ctx, "更新模型单价失败", "price_id", id, "error", err, "error_type", "database_error")
+		s.recordPriceAudit(ctx, "model_price.update", id, err, &existing, price)
+		return nil, fmt.Errorf("更新模型单价失败：%w", err)
+	}
+
+	s.recordPriceAudit(ctx, "model_price.update", id, nil, &existing, price)
+	defaultPriceBook.invalidate()
+	s.logger.InfoContext(ctx, "模型单价已更新", "price_id", id)
+
+	return price, nil
+}
+
+// DeleteModelPrice 实现 Service 接口
+func (s *service) DeleteModelPrice(ctx context.Context, id uint) error {
+	var existing types.ModelPrice
+	if err := requestLogDB(ctx).First(&existing, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("未找到 ID 为 %d 的模型单价：%w", id, ErrResourceNotFound)
+		}
+		return fmt.Errorf("查询模型单价失败：%w", err)
+	}
+
+	result := requestLogDB(ctx).Delete(&types.ModelPrice{}, id)
+	if result.Error != nil {
+		s.logger.ErrorContext(ctx, "删除模型单价失败", "price_id", id, "error", result.Error, "error_type", "database_error")
+		s.recordPriceAudit(ctx, "model_price.delete", id, result.Error, &existing, nil)
+		return fmt.Errorf("删除模型单价失败：%w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("未找到 ID 为 %d 的模型单价：%w", id, ErrResourceNotFound)
+	}
+
+	s.recordPriceAudit(ctx, "model_price.delete", id, nil, &existing, nil)
+	defaultPriceBook.invalidate()
+	s.logger.InfoContext(ctx, "模型单价已删除", "price_id", id)
+
+	return nil
+}
+
+// recordPriceAudit 记录模型单价变更审计，err 非空时记为失败；审计写入失败仅记录日志
+func (s *service) recordPriceAudit(ctx context.Context, action string, priceID uint, err error, before, after *types.ModelPrice) {
+	if s.auditRecorder == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:   action,
+		Resource: "model_price",
+		Result:   "success",
+		Detail:   "模型单价变更",
+		Before:   before,
+		After:    after,
+	}
+	if priceID != 0 {
+		entry.ResourceID = strconv.FormatUint(uint64(priceID), 10)
+	}
+	if err != nil {
+		entry.Result = "failed"
+		entry.Detail = fmt.Sprintf("模型单价变更失败：%v", err)
+	}
+
+	if recordErr := s.auditRecorder.Record(ctx, entry); recordErr != nil {
+		s.logger.WarnContext(ctx, "写入模型单价审计记录失败", "action", action, "price_id", priceID, "error", recordErr)
+	}
+}
+
+// buildModelPrice 校验请求并构建单价记录
+func buildModelPrice(req ModelPriceRequest) (*types.ModelPrice, error) {
+	modelName := strings.TrimSpace(req.ModelName)
+	if modelName == "" {
+		return nil, fmt.Errorf("模型名称不能为空：%w", ErrInvalidArgument)
+	}
+	for name, value := range map[string]float64{
+		"输入单价": req.InputPrice,
+		"输出单价": req.OutputPrice,
+	} {
+		if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("%s必须为非负数：%w", name, ErrInvalidArgument)
+		}
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = types.DefaultPriceCurrency
+	}
+	if len(currency) > 8 {
+		return nil, fmt.Errorf("币种代码过长：%w", ErrInvalidArgument)
+	}
+
+	effectiveFrom := time.Unix(0, 0)
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+
+	return &types.ModelPrice{
+		PlatformID:    req.PlatformID,
+		ModelName:     modelName,
+		EffectiveFrom: effectiveFrom,
+		Currency:      currency,
+		InputPrice:    req.InputPrice,
+		OutputPrice:   req.OutputPrice,
+	}, nil
+}
+
+// ensureModelPriceUnique 校验同一平台与模型在相同生效时间没有其他单价；excludeID 为更新时的自身 ID
+func ensureModelPriceUnique(ctx context.Context, price *types.ModelPrice, excludeID uint) error {
+	db := requestLogDB(ctx).Model(&types.ModelPrice{}).
+		Where("platform_id = ? AND model_name = ? AND effective_from = ?", price.PlatformID, price.ModelName, price.EffectiveFrom)
+	if excludeID != 0 {
+		db = db.Where("id <> ?", excludeID)
+	}
+
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return fmt.Errorf("查询模型单价失败：%w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("同一平台与模型在该生效时间已有单价：%w", ErrInvalidArgument)
+	}
+	return nil
+}
+
+// costsFromMap 将按币种的费用映射转换为按币种排序的列表
+func costsFromMap(costs map[string]float64) []CostAmount {
+	items := make([]CostAmount, 0, len(costs))
+	for currency, amount := range costs {
+		items = append(items, CostAmount{Currency: currency, Amount: amount})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Currency < items[j].Currency
+	})
+	return items
+}
+
+// costRecomputeRow 定义重算费用时读取的原始日志行结构
+type costRecomputeRow struct {
+	ID                uint      `gorm:"column:id"`
+	Timestamp         time.Time `gorm:"column:timestamp"`
+	ModelName         string    `gorm:"column:model_name"`
+	OriginalModelName string    `gorm:"column:original_model_name"`
+	PlatformID        uint      `gorm:"column:platform_id"`
+	APIKeyID          uint      `gorm:"column:api_key_id"`
+	PromptTokens      *int      `gorm:"column:prompt_tokens"`
+	CompletionTokens  *int      `gorm:"column:completion_tokens"`
+	TotalTokens       *int      `gorm:"column:total_tokens"`
+	Cost              *float64  `gorm:"column:cost"`
+	CostCurrency      *string   `gorm:"column:cost_currency"`
+}
+
+// RecomputeRequestLogCost 按当前单价重算时间范围内原始请求日志的费用，并同步修正用量汇总。
+//
+// 用量汇总按差值累加修正，可与请求日志的增量写入并发执行；已被保留策略清理的原始日志无法重算，
+// 对应小时的用量汇总保持原费用不变。
+func (s *service) RecomputeRequestLogCost(ctx context.Context, taskID uint, req CostRecomputeRequest) (*CostRecomputeResult, error) {
+	start := time.Now()
+	logger := s.logger.With("operation", "recompute_request_log_cost", "task_id", taskID)
+
+	if req.StartTime.IsZero() || req.EndTime.IsZero() || !req.EndTime.After(req.StartTime) {
+		return nil, fmt.Errorf("重算时间范围无效，结束时间须晚于起始时间：%w", ErrInvalidArgument)
+	}
+
+	defaultPriceBook.invalidate()
+	prices, err := defaultPriceBook.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CostRecomputeResult{
+		From: hourBucket(req.StartTime),
+		To:   ceilToHour(req.EndTime),
+	}
+
+	oldest, err := s.oldestRequestLogBefore(ctx, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("查询最早请求日志失败：%w", err)
+	}
+	if oldest == nil {
+		return result, nil
+	}
+	if oldestHour := hourBucket(*oldest); oldestHour.After(result.From) {
+		result.From = oldestHour
+	}
+
+	for chunkStart := result.From; chunkStart.Before(result.To); {
+		chunkEnd := chunkStart.Add(rollupChunk)
+		if chunkEnd.After(result.To) {
+			chunkEnd = result.To
+		}
+
+		err := requestLogDB(ctx).Transaction(func(tx *gorm.DB) error {
+			return recomputeCostRange(tx, prices, chunkStart, chunkEnd, result)
+		})
+		if err != nil {
+			logger.ErrorContext(ctx, "重算请求费用失败",
+				"error", err,
+				"error_type", "database_error",
+				"chunk_start", chunkStart,
+				"chunk_end", chunkEnd,
+			)
+			return nil, fmt.Errorf("重算 %s 至 %s 的请求费用失败：%w",
+				chunkStart.Format(time.RFC3339), chunkEnd.Format(time.RFC3339), err)
+		}
+
+		chunkStart = chunkEnd
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+	}
+
+	logger.InfoContext(ctx, "请求费用重算完成",
+		"from", result.From,
+		"to", result.To,
+		"scanned_logs", result.ScannedLogs,
+		"updated_logs", result.UpdatedLogs,
+		"priced_logs", result.PricedLogs,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	return result, nil
+}
+
+// recomputeCostRange 重算 [from, to) 内原始日志的费用，并将变化以差值累加到用量汇总
+func recomputeCostRange(tx *gorm.DB, prices []types.ModelPrice, from, to time.Time, result *CostRecomputeResult) error {
+	var rows []costRecomputeRow
+	err := tx.Table("request_logs").
+		Select("id, timestamp, model_name, original_model_name, platform_id, api_key_id, prompt_tokens, completion_tokens, total_tokens, cost, cost_currency").
+		Where("timestamp >= ? AND timestamp < ?", from, to).
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("读取原始请求日志失败：%w", err)
+	}
+
+	deltas := make(map[hourlyUsageKey]*types.RequestLogHourlyUsage)
+	var deltaKeys []hourlyUsageKey
+	addDelta := func(key hourlyUsageKey, row costRecomputeRow, sign int64, cost float64) {
+		delta, exists := deltas[key]
+		if !exists {
+			delta = &types.RequestLogHourlyUsage{
+				BucketStart: key.BucketStart,
+				ModelName:   key.ModelName,
+				PlatformID:  key.PlatformID,
+				APIKeyID:    key.APIKeyID,
+				Currency:    key.Currency,
+			}
+			deltas[key] = delta
+			deltaKeys = append(deltaKeys, key)
+		}
+		delta.Cost += cost
+		if sign == 0 {
+			return
+		}
+		delta.RequestCount += sign
+		if row.TotalTokens != nil || row.PromptTokens != nil || row.CompletionTokens != nil {
+			promptTokens := ptrIntToInt64(row.PromptTokens)
+			completionTokens := ptrIntToInt64(row.CompletionTokens)
+			totalTokens := ptrIntToInt64(row.TotalTokens)
+			if row.TotalTokens == nil {
+				totalTokens = promptTokens + completionTokens
+			}
+			delta.UsageCount += sign
+			delta.PromptTokens += sign * promptTokens
+			delta.CompletionTokens += sign * completionTokens
+			delta.TotalTokens += sign * totalTokens
+		}
+	}
+
+	for _, row := range rows {
+		result.ScannedLogs++
+
+		cost, currency := computeRequestCost(prices, row.PlatformID, row.ModelName, row.OriginalModelName,
+			row.Timestamp, row.PromptTokens, row.CompletionTokens)
+		if cost != nil {
+			result.PricedLogs++
+		}
+
+		oldCurrency, newCurrency := "", ""
+		var oldCost, newCost float64
+		if row.Cost != nil && row.CostCurrency != nil {
+			oldCurrency, oldCost = *row.CostCurrency, *row.Cost
+		}
+		if cost != nil {
+			newCurrency, newCost = *currency, *cost
+		}
+		if (row.Cost == nil) == (cost == nil) && oldCurrency == newCurrency && oldCost == newCost {
+			continue
+		}
+
+		if err := tx.Table("request_logs").Where("id = ?", row.ID).
+			Updates(map[string]any{"cost": cost, "cost_currency": currency}).Error; err != nil {
+			return fmt.Errorf("更新请求费用失败：%w", err)
+		}
+		result.UpdatedLogs++
+
+		key := hourlyStatKey{
+			BucketStart: hourBucket(row.Timestamp),
+			ModelName:   row.OriginalModelName,
+			PlatformID:  row.PlatformID,
+		}
+		oldKey := hourlyUsageKey{hourlyStatKey: key, APIKeyID: row.APIKeyID, Currency: oldCurrency}
+		newKey := hourlyUsageKey{hourlyStatKey: key, APIKeyID: row.APIKeyID, Currency: newCurrency}
+		if oldKey == newKey {
+			addDelta(newKey, row, 0, newCost-oldCost)
+			continue
+		}
+		addDelta(oldKey, row, -1, -oldCost)
+		addDelta(newKey, row, 1, newCost)
+	}
+
+	for _, key := range deltaKeys {
+		if err := upsertHourlyUsage(tx, deltas[key]); err != nil {
+			return err
+		}
+	}
+
+	if len(deltaKeys) > 0 {
+		if err := tx.Where("bucket_start >= ? AND bucket_start < ? AND request_count <= 0", from, to).
+			Delete(&types.RequestLogHourlyUsage{}).Error; err != nil {
+			return fmt.Errorf("清理空用量汇总失败：%w", err)
+		}
+	}
+
+	return nil
+}
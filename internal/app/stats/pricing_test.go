@@ -0,0 +1,132 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+func TestMatchModelPrice_平台专属优先且按生效时间选择(t *testing.T) {
+	now := time.Now()
+	prices := []types.ModelPrice{
+		{ID: 1, ModelName: "gpt-4o", EffectiveFrom: now.Add(-48 * time.Hour), Currency: "USD"},
+		{ID: 2, ModelName: "gpt-4o", EffectiveFrom: now.Add(-time.Hour), Currency: "USD"},
+		{ID: 3, ModelName: "gpt-4o", PlatformID: 2, EffectiveFrom: now.Add(-72 * time.Hour), Currency: "CNY"},
+		{ID: 4, ModelName: "alias", EffectiveFrom: now.Add(-72 * time.Hour), Currency: "USD"},
+		{ID: 5, ModelName: "gpt-4o", EffectiveFrom: now.Add(time.Hour), Currency: "USD"},
+	}
+
+	cases := []struct {
+		name       string
+		platformID uint
+		modelName  string
+		original   string
+		at         time.Time
+		want       uint
+	}{
+		{"全局单价取最新已生效", 1, "gpt-4o", "alias", now, 2},
+		{"早于最新单价生效时间", 1, "gpt-4o", "", now.Add(-2 * time.Hour), 1},
+		{"平台专属单价优先", 2, "gpt-4o", "", now, 3},
+		{"上游模型名称无单价时匹配原始模型名称", 1, "unknown-upstream", "alias", now, 4},
+		{"无匹配单价", 1, "claude", "", now, 0},
+	}
+	for _, tc := range cases {
+		got := matchModelPrice(prices, tc.platformID, tc.modelName, tc.original, tc.at)
+		var gotID uint
+		if got != nil {
+			gotID = got.ID
+		}
+		if gotID != tc.want {
+			t.Fatalf("%s: 匹配单价 = %d, 期望 %d", tc.name, gotID, tc.want)
+		}
+	}
+}
+
+func TestRecomputeRequestLogCost_单价变更后重算费用与用量汇总(t *testing.T) {
+	svc, db := newRetentionTestService(t, 0)
+	ctx := context.Background()
+
+	if _, err := svc.CreateModelPrice(ctx, ModelPriceRequest{ModelName: "gpt-4o", InputPrice: 2, OutputPrice: 8}); err != nil {
+		t.Fatalf("创建模型单价失败: %v", err)
+	}
+	if _, err := svc.CreateModelPrice(ctx, ModelPriceRequest{ModelName: "gpt-4o", InputPrice: 1, OutputPrice: 1}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("重复单价期望参数错误，实际 %v", err)
+	}
+
+	now := time.Now()
+	logs := []*types.RequestLog{
+		{Timestamp: now, ModelName: "gpt-4o", OriginalModelName: "gpt-4o", PlatformID: 1, APIKeyID: 7, Success: true, PromptTokens: intPtr(1000), CompletionTokens: intPtr(500)},
+		{Timestamp: now, ModelName: "claude", OriginalModelName: "claude", PlatformID: 1, APIKeyID: 7, Success: true, PromptTokens: intPtr(100), CompletionTokens: intPtr(100)},
+		{Timestamp: now, ModelName: "gpt-4o", OriginalModelName: "gpt-4o", PlatformID: 1, APIKeyID: 8, Success: false},
+	}
+	for _, log := range logs {
+		if err := PriceRequestLog(ctx, log); err != nil {
+			t.Fatalf("计算请求费用失败: %v", err)
+		}
+		if err := db.Create(log).Error; err != nil {
+			t.Fatalf("写入请求日志失败: %v", err)
+		}
+		if err := RecordRequestLogRollup(ctx, db, log); err != nil {
+			t.Fatalf("累加小时汇总失败: %v", err)
+		}
+	}
+	if logs[0].Cost == nil || math.Abs(*logs[0].Cost-0.006) > 1e-9 || *logs[0].CostCurrency != "USD" {
+		t.Fatalf("gpt-4o 请求费用不符: %v", logs[0].Cost)
+	}
+	if logs[1].Cost != nil || logs[2].Cost != nil {
+		t.Fatal("无单价或无 Token 统计的请求不应计费")
+	}
+
+	dashboard, err := svc.GetDashboard(ctx, TrendRange24h)
+	if err != nil {
+		t.Fatalf("获取仪表盘失败: %v", err)
+	}
+	if len(dashboard.Overview.TotalCosts) != 1 || math.Abs(dashboard.Overview.TotalCosts[0].Amount-0.006) > 1e-9 {
+		t.Fatalf("仪表盘费用不符: %+v", dashboard.Overview.TotalCosts)
+	}
+	if usage := dashboard.Ranks.ModelUsage; usage[0].ModelName != "gpt-4o" || len(usage[0].Costs) != 1 || len(usage[1].Costs) != 0 {
+		t.Fatalf("模型用量排名费用不符: %+v", usage)
+	}
+
+	if _, err := svc.CreateModelPrice(ctx, ModelPriceRequest{ModelName: "claude", Currency: "cny", InputPrice: 10, OutputPrice: 20}); err != nil {
+		t.Fatalf("创建模型单价失败: %v", err)
+	}
+	result, err := svc.RecomputeRequestLogCost(ctx, 1, CostRecomputeRequest{StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("重算请求费用失败: %v", err)
+	}
+	if result.ScannedLogs != 3 || result.UpdatedLogs != 1 || result.PricedLogs != 2 {
+		t.Fatalf("重算结果不符: %+v", result)
+	}
+
+	var claude types.RequestLog
+	db.First(&claude, logs[1].ID)
+	if claude.Cost == nil || math.Abs(*claude.Cost-0.003) > 1e-9 || *claude.CostCurrency != "CNY" {
+		t.Fatalf("重算后 claude 费用不符: %+v", claude)
+	}
+
+	report, err := svc.GetAPIKeyUsage(ctx, APIKeyUsageOptions{Range: TrendRange24h})
+	if err != nil {
+		t.Fatalf("获取密钥用量失败: %v", err)
+	}
+	if len(report.Items) != 2 || report.Items[0].APIKeyID != 7 {
+		t.Fatalf("密钥用量报表不符: %+v", report.Items)
+	}
+	key7 := report.Items[0]
+	if key7.RequestCount != 2 || key7.UnpricedRequests != 0 || key7.TotalTokens != 1700 || len(key7.Costs) != 2 {
+		t.Fatalf("密钥 7 用量不符: %+v", key7)
+	}
+	if report.Items[1].UnpricedRequests != 1 || len(report.TotalCosts) != 2 {
+		t.Fatalf("未计费请求或费用合计不符: %+v", report)
+	}
+
+	var usageRows int64
+	db.Model(&types.RequestLogHourlyUsage{}).Count(&usageRows)
+	if usageRows != 3 {
+		t.Fatalf("用量汇总行数 = %d, 期望 3（重算后清理空行）", usageRows)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
 )
 
 // modelCallRankResult 定义模型调用排名查询结果结构
@@ -17,22 +17,25 @@ type modelCallRankResult struct {
 
 // GetModelCallRank 获取模型调用排名前 5
 func (s *service) GetModelCallRank(ctx context.Context, duration time.Duration) (*ModelCallRankResponse, error) {
-	q := query.Q
-	r := q.RequestLog
-
 	// 设置默认时间范围为 24 小时
 	if duration == 0 {
 		duration = 24 * time.Hour
 	}
 
-	startTime := time.Now().Add(-duration)
+	// 汇总表以小时为粒度，起始时间向下取整到整点
+	startTime := hourBucket(time.Now().Add(-duration))
 
 	s.logger.InfoContext(ctx, "开始获取模型调用排名", "duration", duration)
 
 	// 获取总请求数
-	totalRequests, err := r.WithContext(ctx).
-		Where(r.Timestamp.Gte(startTime)).
-		Count()
+	var totalRequestSum struct {
+		Total int64 `gorm:"column:total"`
+	}
+	err := requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
+		Select("COALESCE(SUM(request_count), 0) as total").
+		Where("bucket_start >= ?", startTime).
+		Scan(&totalRequestSum).Error
+	totalRequests := totalRequestSum.Total
 	if err != nil {
 		s.logger.ErrorContext(ctx, "获取总请求数失败", "error", err)
 		return nil, fmt.Errorf("获取总请求数失败：%w", err)
@@ -40,20 +43,19 @@ func (s *service) GetModelCallRank(ctx context.Context, duration time.Duration)
 
 	// 使用数据库聚合查询一次性获取所有模型的统计数据
 	// SELECT
-	//   original_model_name,
-	//   COUNT(*) as request_count,
-	//   SUM(CASE WHEN success = true THEN 1 ELSE 0 END) as success_count
-	// FROM request_logs
-	// WHERE timestamp >= ?
-	// GROUP BY original_model_name
+	//   model_name as original_model_name,
+	//   SUM(request_count) as request_count,
+	//   SUM(success_count) as success_count
+	// FROM request_log_hourly_stats
+	// WHERE bucket_start >= ?
+	// GROUP BY model_name
 	// ORDER BY request_count DESC
-	// LIMIT 10
+	// LIMIT 5
 	var results []modelCallRankResult
-	err = r.WithContext(ctx).
-		UnderlyingDB().
-		Select("original_model_name, COUNT(*) as request_count, SUM(CASE WHEN success = ? THEN 1 ELSE 0 END) as success_count", true).
-		Where("timestamp >= ?", startTime).
-		Group("original_model_name").
+	err = requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
+		Select("model_name as original_model_name, SUM(request_count) as request_count, SUM(success_count) as success_count").
+		Where("bucket_start >= ?", startTime).
+		Group("model_name").
 		Order("request_count DESC").
 		Limit(5).
 		Scan(&results).Error
@@ -102,22 +104,25 @@ type platformCallRankResult struct {
 
 // GetPlatformCallRank 获取平台调用排名前 5
 func (s *service) GetPlatformCallRank(ctx context.Context, duration time.Duration) (*PlatformCallRankResponse, error) {
-	q := query.Q
-	r := q.RequestLog
-
 	// 设置默认时间范围为 24 小时
 	if duration == 0 {
 		duration = 24 * time.Hour
 	}
 
-	startTime := time.Now().Add(-duration)
+	// 汇总表以小时为粒度，起始时间向下取整到整点
+	startTime := hourBucket(time.Now().Add(-duration))
 
 	s.logger.InfoContext(ctx, "开始获取平台调用排名", "duration", duration)
 
 	// 获取总请求数
-	totalRequests, err := r.WithContext(ctx).
-		Where(r.Timestamp.Gte(startTime)).
-		Count()
+	var totalRequestSum struct {
+		Total int64 `gorm:"column:total"`
+	}
+	err := requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
+		Select("COALESCE(SUM(request_count), 0) as total").
+		Where("bucket_start >= ?", startTime).
+		Scan(&totalRequestSum).Error
+	totalRequests := totalRequestSum.Total
 	if err != nil {
 		s.logger.ErrorContext(ctx, "获取总请求数失败", "error", err)
 		return nil, fmt.Errorf("获取总请求数失败：%w", err)
@@ -127,21 +132,20 @@ func (s *service) GetPlatformCallRank(ctx context.Context, duration time.Duratio
 	// SELECT
 	//   r.platform_id,
 	//   p.name as platform_name,
-	//   COUNT(*) as request_count,
-	//   SUM(CASE WHEN r.success = true THEN 1 ELSE 0 END) as success_count
-	// FROM request_logs r
+	//   SUM(r.request_count) as request_count,
+	//   SUM(r.success_count) as success_count
+	// FROM request_log_hourly_stats r
 	// LEFT JOIN platforms p ON r.platform_id = p.id
-	// WHERE r.timestamp >= ?
+	// WHERE r.bucket_start >= ?
 	// GROUP BY r.platform_id, p.name
 	// ORDER BY request_count DESC
-	// LIMIT 10
+	// LIMIT 5
 	var results []platformCallRankResult
-	err = r.WithContext(ctx).
-		UnderlyingDB().
-		Table("request_logs r").
-		Select("r.platform_id, p.name as platform_name, COUNT(*) as request_count, SUM(CASE WHEN r.success = ? THEN 1 ELSE 0 END) as success_count", true).
+	err = requestLogDB(ctx).
+		Table("request_log_hourly_stats r").
+		Select("r.platform_id, p.name as platform_name, SUM(r.request_count) as request_count, SUM(r.success_count) as success_count").
 		Joins("LEFT JOIN platforms p ON r.platform_id = p.id").
-		Where("r.timestamp >= ?", startTime).
+		Where("r.bucket_start >= ?", startTime).
 		Group("r.platform_id, p.name").
 		Order("request_count DESC").
 		Limit(5).
@@ -191,15 +195,13 @@ type modelUsageRankResult struct {
 
 // GetModelUsageRank 获取模型用量排名前 5
 func (s *service) GetModelUsageRank(ctx context.Context, duration time.Duration) (*ModelUsageRankResponse, error) {
-	q := query.Q
-	r := q.RequestLog
-
 	// 设置默认时间范围为 24 小时
 	if duration == 0 {
 		duration = 24 * time.Hour
 	}
 
-	startTime := time.Now().Add(-duration)
+	// 汇总表以小时为粒度，起始时间向下取整到整点
+	startTime := hourBucket(time.Now().Add(-duration))
 
 	s.logger.InfoContext(ctx, "开始获取模型用量排名", "duration", duration)
 
@@ -207,10 +209,9 @@ func (s *service) GetModelUsageRank(ctx context.Context, duration time.Duration)
 	var totalTokensSum struct {
 		Total int64 `gorm:"column:total"`
 	}
-	err := r.WithContext(ctx).
-		UnderlyingDB().
+	err := requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
 		Select("COALESCE(SUM(total_tokens), 0) as total").
-		Where("timestamp >= ? AND total_tokens IS NOT NULL", startTime).
+		Where("bucket_start >= ? AND usage_count > 0", startTime).
 		Scan(&totalTokensSum).Error
 	if err != nil {
 		s.logger.ErrorContext(ctx, "获取总 Token 数失败", "error", err)
@@ -219,21 +220,20 @@ func (s *service) GetModelUsageRank(ctx context.Context, duration time.Duration)
 
 	// 使用数据库聚合查询获取模型用量统计
 	// SELECT
-	//   original_model_name,
+	//   model_name as original_model_name,
 	//   COALESCE(SUM(total_tokens), 0) as total_tokens,
 	//   COALESCE(SUM(prompt_tokens), 0) as prompt_tokens,
 	//   COALESCE(SUM(completion_tokens), 0) as completion_tokens
-	// FROM request_logs
-	// WHERE timestamp >= ? AND total_tokens IS NOT NULL
-	// GROUP BY original_model_name
+	// FROM request_log_hourly_stats
+	// WHERE bucket_start >= ? AND usage_count > 0
+	// GROUP BY model_name
 	// ORDER BY total_tokens DESC
 	// LIMIT 5
 	var results []modelUsageRankResult
-	err = r.WithContext(ctx).
-		UnderlyingDB().
-		Select("original_model_name, COALESCE(SUM(total_tokens), 0) as total_tokens, COALESCE(SUM(prompt_tokens), 0) as prompt_tokens, COALESCE(SUM(completion_tokens), 0) as completion_tokens").
-		Where("timestamp >= ? AND total_tokens IS NOT NULL", startTime).
-		Group("original_model_name").
+	err = requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
+		Select("model_name as original_model_name, COALESCE(SUM(total_tokens), 0) as total_tokens, COALESCE(SUM(prompt_tokens), 0) as prompt_tokens, COALESCE(SUM(completion_tokens), 0) as completion_tokens").
+		Where("bucket_start >= ? AND usage_count > 0", startTime).
+		Group("model_name").
 		Order("total_tokens DESC").
 		Limit(5).
 		Scan(&results).Error
@@ -243,6 +243,13 @@ func (s *service) GetModelUsageRank(ctx context.Context, duration time.Duration)
 		return nil, fmt.Errorf("获取模型用量排名失败：%w", err)
 	}
 
+	costRows, err := loadUsageCosts(ctx, startTime, time.Time{})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "获取模型费用失败", "error", err)
+		return nil, fmt.Errorf("获取模型费用失败：%w", err)
+	}
+	costs := indexUsageCosts(costRows)
+
 	s.logger.InfoContext(ctx, "成功获取模型用量排名",
 		"model_count", len(results),
 		"total_tokens", totalTokensSum.Total,
@@ -256,12 +263,18 @@ func (s *service) GetModelUsageRank(ctx context.Context, duration time.Duration)
 			percentage = float64(result.TotalTokens) / float64(totalTokensSum.Total)
 		}
 
+		costKey := result.ModelName
+		if costKey == "" {
+			costKey = "unknown"
+		}
+
 		modelUsageRankItems = append(modelUsageRankItems, ModelUsageRankItem{
 			ModelName:        result.ModelName,
 			TotalTokens:      result.TotalTokens,
 			PromptTokens:     result.PromptTokens,
 			CompletionTokens: result.CompletionTokens,
 			Percentage:       percentage,
+			Costs:            costsFromMap(costs.models[costKey]),
 		})
 	}
 
@@ -282,15 +295,13 @@ type platformUsageRankResult struct {
 
 // GetPlatformUsageRank 获取平台用量排名前 5
 func (s *service) GetPlatformUsageRank(ctx context.Context, duration time.Duration) (*PlatformUsageRankResponse, error) {
-	q := query.Q
-	r := q.RequestLog
-
 	// 设置默认时间范围为 24 小时
 	if duration == 0 {
 		duration = 24 * time.Hour
 	}
 
-	startTime := time.Now().Add(-duration)
+	// 汇总表以小时为粒度，起始时间向下取整到整点
+	startTime := hourBucket(time.Now().Add(-duration))
 
 	s.logger.InfoContext(ctx, "开始获取平台用量排名", "duration", duration)
 
@@ -298,10 +309,9 @@ func (s *service) GetPlatformUsageRank(ctx context.Context, duration time.Durati
 	var totalTokensSum struct {
 		Total int64 `gorm:"column:total"`
 	}
-	err := r.WithContext(ctx).
-		UnderlyingDB().
+	err := requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
 		Select("COALESCE(SUM(total_tokens), 0) as total").
-		Where("timestamp >= ? AND total_tokens IS NOT NULL", startTime).
+		Where("bucket_start >= ? AND usage_count > 0", startTime).
 		Scan(&totalTokensSum).Error
 	if err != nil {
 		s.logger.ErrorContext(ctx, "获取总 Token 数失败", "error", err)
@@ -315,19 +325,18 @@ func (s *service) GetPlatformUsageRank(ctx context.Context, duration time.Durati
 	//   COALESCE(SUM(r.total_tokens), 0) as total_tokens,
 	//   COALESCE(SUM(r.prompt_tokens), 0) as prompt_tokens,
 	//   COALESCE(SUM(r.completion_tokens), 0) as completion_tokens
-	// FROM request_logs r
+	// FROM request_log_hourly_stats r
 	// LEFT JOIN platforms p ON r.platform_id = p.id
-	// WHERE r.timestamp >= ? AND r.total_tokens IS NOT NULL
+	// WHERE r.bucket_start >= ? AND r.usage_count > 0
 	// GROUP BY r.platform_id, p.name
 	// ORDER BY total_tokens DESC
 	// LIMIT 5
 	var results []platformUsageRankResult
-	err = r.WithContext(ctx).
-		UnderlyingDB().
-		Table("request_logs r").
+	err = requestLogDB(ctx).
+		Table("request_log_hourly_stats r").
 		Select("r.platform_id, p.name as platform_name, COALESCE(SUM(r.total_tokens), 0) as total_tokens, COALESCE(SUM(r.prompt_tokens), 0) as prompt_tokens, COALESCE(SUM(r.completion_tokens), 0) as completion_tokens").
 		Joins("LEFT JOIN platforms p ON r.platform_id = p.id").
-		Where("r.timestamp >= ? AND r.total_tokens IS NOT NULL", startTime).
+		Where("r.bucket_start >= ? AND r.usage_count > 0", startTime).
 		Group("r.platform_id, p.name").
 		Order("total_tokens DESC").
 		Limit(5).
@@ -338,6 +347,13 @@ func (s *service) GetPlatformUsageRank(ctx context.Context, duration time.Durati
 		return nil, fmt.Errorf("获取平台用量排名失败：%w", err)
 	}
 
+	costRows, err := loadUsageCosts(ctx, startTime, time.Time{})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "获取平台费用失败", "error", err)
+		return nil, fmt.Errorf("获取平台费用失败：%w", err)
+	}
+	costs := indexUsageCosts(costRows)
+
 	s.logger.InfoContext(ctx, "成功获取平台用量排名",
 		"platform_count", len(results),
 		"total_tokens", totalTokensSum.Total,
@@ -357,6 +373,7 @@ func (s *service) GetPlatformUsageRank(ctx context.Context, duration time.Durati
 			PromptTokens:     result.PromptTokens,
 			CompletionTokens: result.CompletionTokens,
 			Percentage:       percentage,
+			Costs:            costsFromMap(costs.platforms[result.PlatformID]),
 		})
 	}
 
@@ -28,14 +28,41 @@ func (s *service) GetRealtime(ctx context.Context) (*StatsRealtimeResponse, erro
 	// 获取当前活动连接数
 	activeConnections := collector.GetActiveConnections()
 
+	// 平台名称仅用于展示，加载失败时只返回平台 ID
+	platformNameMap, err := s.loadPlatformNameMap(ctx)
+	if err != nil {
+		logger.WarnContext(ctx, "加载平台名称映射失败", "error", err, "error_type", "database_error")
+	}
+
+	// 获取按模型、平台与客户端拆分的实时数据
+	breakdown := collector.RealtimeBreakdown(func(id uint) string {
+		return platformNameMap[id]
+	})
+
 	logger.DebugContext(ctx, "成功获取实时数据",
 		"rpm", rpm,
 		"active_connections", activeConnections,
+		"models", len(breakdown.Models),
+		"platforms", len(breakdown.Platforms),
 		"latency_ms", time.Since(start).Milliseconds(),
 	)
 
 	return &StatsRealtimeResponse{
 		RPM:               rpm,
 		ActiveConnections: activeConnections,
+		RealtimeBreakdown: breakdown,
 	}, nil
 }
+
+// SubscribeLive 订阅实时请求事件
+//
+// 调用方须在结束消费后调用 LiveSubscription.Close 释放订阅。
+func (s *service) SubscribeLive(ctx context.Context, filter LiveFilter) (*LiveSubscription, error) {
+	if s.collector == nil {
+		err := fmt.Errorf("统计采集器未注入，无法订阅实时事件")
+		s.logger.ErrorContext(ctx, "订阅实时事件失败", "operation", "subscribe_live", "error", err)
+		return nil, err
+	}
+
+	return s.collector.SubscribeLive(filter, defaultLiveBufferSize), nil
+}
@@ -0,0 +1,185 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+// RunRequestLogRetention 将超过保留期的原始请求日志汇总为小时统计后清理。
+//
+// 按天分段处理，每段在单个事务内完成：重算该段的小时统计、写入统计表、删除原始日志。
+// 重算采用覆盖写入，任务中断后重复执行不会重复计数。
+func (s *service) RunRequestLogRetention(ctx context.Context, taskID uint) (*RequestLogRetentionResult, error) {
+	start := time.Now()
+	logger := s.logger.With("operation", "run_request_log_retention", "task_id", taskID)
+
+	if s.retention.RawLogDays <= 0 {
+		return nil, fmt.Errorf("未启用请求日志保留策略")
+	}
+
+	cutoff := s.retentionCutoff(start)
+	result := &RequestLogRetentionResult{Cutoff: cutoff}
+
+	oldest, err := s.oldestRequestLogBefore(ctx, cutoff)
+	if err != nil {
+		logger.ErrorContext(ctx, "查询最早请求日志失败", "error", err, "error_type", "database_error")
+		return nil, fmt.Errorf("查询最早请求日志失败：%w", err)
+	}
+	if oldest == nil {
+		logger.DebugContext(ctx, "无超过保留期的请求日志", "cutoff", cutoff)
+		return result, nil
+	}
+
+	for chunkStart := hourBucket(*oldest); chunkStart.Before(cutoff); {
+		chunkEnd := chunkStart.Add(rollupChunk)
+		if chunkEnd.After(cutoff) {
+			chunkEnd = cutoff
+		}
+
+		rolledUp, purged, rangeErr := s.rollupAndPurgeRange(ctx, chunkStart, chunkEnd)
+		if rangeErr != nil {
+			logger.ErrorContext(ctx, "汇总并清理请求日志失败",
+				"error", rangeErr,
+				"error_type", "database_error",
+				"chunk_start", chunkStart,
+				"chunk_end", chunkEnd,
+			)
+			return nil, fmt.Errorf("汇总并清理 %s 至 %s 的请求日志失败：%w",
+				chunkStart.Format(time.RFC3339), chunkEnd.Format(time.RFC3339), rangeErr)
+		}
+
+		result.HourlyStatRows += rolledUp
+		result.PurgedLogs += purged
+		chunkStart = chunkEnd
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+	}
+
+	logger.InfoContext(ctx, "请求日志保留任务完成",
+		"cutoff", cutoff,
+		"hourly_stat_rows", result.HourlyStatRows,
+		"purged_logs", result.PurgedLogs,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	return result, nil
+}
+
+// GetRequestLogRetention 获取请求日志保留策略的当前状态
+func (s *service) GetRequestLogRetention(ctx context.Context) (*RequestLogRetentionStatus, error) {
+	status := &RequestLogRetentionStatus{
+		Enabled:    s.retention.RawLogDays > 0,
+		RawLogDays: s.retention.RawLogDays,
+	}
+
+	if status.Enabled {
+		cutoff := s.retentionCutoff(time.Now())
+		status.Cutoff = &cutoff
+	}
+
+	oldest, err := s.oldestRequestLogBefore(ctx, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("查询最早请求日志失败：%w", err)
+	}
+	status.OldestRawLogAt = oldest
+
+	lastRun, err := lastRetentionRun(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询最近一次保留任务失败：%w", err)
+	}
+	status.LastRun = lastRun
+
+	if err := requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).Count(&status.HourlyStatRows).Error; err != nil {
+		return nil, fmt.Errorf("统计小时汇总记录失败：%w", err)
+	}
+
+	return status, nil
+}
+
+// retentionCutoff 计算原始日志保留截止时间（向下取整到整点，保证小时桶完整）
+func (s *service) retentionCutoff(now time.Time) time.Time {
+	return now.UTC().Add(-time.Duration(s.retention.RawLogDays) * 24 * time.Hour).Truncate(time.Hour)
+}
+
+// oldestRequestLogBefore 查询 before 之前最早的请求日志时间；before 为零值时不限制
+func (s *service) oldestRequestLogBefore(ctx context.Context, before time.Time) (*time.Time, error) {
+	db := requestLogDB(ctx).Table("request_logs").Select("timestamp")
+	if !before.IsZero() {
+		db = db.Where("timestamp < ?", before)
+	}
+
+	var rows []requestLogRollupRow
+	if err := db.Order("timestamp ASC").Limit(1).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	return &rows[0].Timestamp, nil
+}
+
+// rollupAndPurgeRange 在单个事务内重算 [from, to) 的小时汇总并删除对应原始日志
+func (s *service) rollupAndPurgeRange(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	var rolledUp, purged int64
+
+	err := requestLogDB(ctx).Transaction(func(tx *gorm.DB) error {
+		rows, err := rebuildRollupRange(tx, from, to)
+		if err != nil {
+			return err
+		}
+
+		deleteResult := tx.Where("timestamp >= ? AND timestamp < ?", from, to).Delete(&types.RequestLog{})
+		if deleteResult.Error != nil {
+			return fmt.Errorf("删除原始请求日志失败：%w", deleteResult.Error)
+		}
+
+		rolledUp = rows
+		purged = deleteResult.RowsAffected
+		return nil
+	})
+
+	return rolledUp, purged, err
+}
+
+// lastRetentionRun 从任务表读取最近一次结束的保留任务，多实例部署时各实例返回一致的结果
+func lastRetentionRun(ctx context.Context) (*RequestLogRetentionRun, error) {
+	var tasks []types.ModelBatchTask
+	if err := requestLogDB(ctx).
+		Where("type = ? AND status IN ?", types.TaskTypeRequestLogRetention,
+			[]string{types.ModelBatchTaskStatusSucceeded, types.ModelBatchTaskStatusFailed}).
+		Where("finished_at IS NOT NULL").
+		Order("finished_at DESC").
+		Order("id DESC").
+		Limit(1).
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+
+	task := tasks[0]
+	run := &RequestLogRetentionRun{
+		TaskID:     task.ID,
+		FinishedAt: *task.FinishedAt,
+		Error:      task.ErrorMessage,
+	}
+	if task.StartedAt != nil {
+		run.StartedAt = *task.StartedAt
+	}
+	if task.Status == types.ModelBatchTaskStatusSucceeded && task.Result != "" {
+		var result RequestLogRetentionResult
+		if err := json.Unmarshal([]byte(task.Result), &result); err == nil {
+			run.Result = &result
+		}
+	}
+	return run, nil
+}
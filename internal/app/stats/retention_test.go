@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newRetentionTestService(t *testing.T, rawLogDays int) (*service, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&types.RequestLog{}, &types.RequestLogHourlyStat{}, &types.RequestLogLatencyBin{}, &types.RequestLogHourlyUsage{}, &types.RequestLogHourlyError{}, &types.ModelPrice{}, &types.Platform{}, &types.ModelBatchTask{}); err != nil {
+		t.Fatalf("迁移请求日志表失败: %v", err)
+	}
+	query.SetDefault(db)
+	defaultPriceBook.invalidate()
+
+	svc := NewWithCollector(slog.Default(), nil, WithRetention(RetentionConfig{RawLogDays: rawLogDays})).(*service)
+	return svc, db
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestRunRequestLogRetention_汇总后清理超期日志(t *testing.T) {
+	svc, db := newRetentionTestService(t, 1)
+
+	old := time.Now().Add(-72 * time.Hour).Truncate(time.Hour)
+	firstByte := int64(1500)
+	logs := []*types.RequestLog{
+		{Timestamp: old.Add(5 * time.Minute), OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, IsStream: true, Duration: 1000, FirstByteTime: &firstByte, PromptTokens: intPtr(10), CompletionTokens: intPtr(5)},
+		{Timestamp: old.Add(30 * time.Minute), OriginalModelName: "gpt-4o", PlatformID: 1, Success: false, Duration: 3000},
+		{Timestamp: old.Add(90 * time.Minute), OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, Duration: 2000, TotalTokens: intPtr(7)},
+		{Timestamp: time.Now().Add(-time.Minute), OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, Duration: 500},
+	}
+	if err := db.Create(&logs).Error; err != nil {
+		t.Fatalf("写入请求日志失败: %v", err)
+	}
+
+	result, err := svc.RunRequestLogRetention(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("执行保留任务失败: %v", err)
+	}
+	if result.PurgedLogs != 3 {
+		t.Fatalf("清理数量 = %d, 期望 3", result.PurgedLogs)
+	}
+	if result.HourlyStatRows != 2 {
+		t.Fatalf("小时汇总数量 = %d, 期望 2", result.HourlyStatRows)
+	}
+
+	var remaining int64
+	db.Model(&types.RequestLog{}).Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("剩余原始日志 = %d, 期望 1", remaining)
+	}
+
+	var stats []types.RequestLogHourlyStat
+	if err := db.Order("bucket_start ASC").Find(&stats).Error; err != nil {
+		t.Fatalf("查询小时汇总失败: %v", err)
+	}
+	first := stats[0]
+	if first.RequestCount != 2 || first.SuccessCount != 1 || first.StreamCount != 1 {
+		t.Fatalf("首个小时计数不符: %+v", first)
+	}
+	if first.DurationSum != 4000 || first.FirstByteCount != 1 || first.FirstByteSum != 1500 {
+		t.Fatalf("首个小时耗时汇总不符: %+v", first)
+	}
+	if first.UsageCount != 1 || first.TotalTokens != 15 {
+		t.Fatalf("首个小时 Token 汇总不符: %+v", first)
+	}
+	if stats[1].TotalTokens != 7 {
+		t.Fatalf("第二个小时 Token 汇总 = %d, 期望 7", stats[1].TotalTokens)
+	}
+
+	// 重复执行不应重复计数
+	again, err := svc.RunRequestLogRetention(context.Background(), 43)
+	if err != nil {
+		t.Fatalf("重复执行保留任务失败: %v", err)
+	}
+	if again.PurgedLogs != 0 {
+		t.Fatalf("重复执行清理数量 = %d, 期望 0", again.PurgedLogs)
+	}
+
+	// 最近一次运行记录来自任务表中最近结束的保留任务
+	finishedAt := time.Now()
+	tasks := []*types.ModelBatchTask{
+		{ID: 42, Type: types.TaskTypeRequestLogRetention, Status: types.ModelBatchTaskStatusSucceeded, Result: `{"purged_logs":3}`, FinishedAt: &finishedAt},
+		{ID: 43, Type: types.TaskTypeRequestLogRetention, Status: types.ModelBatchTaskStatusRunning},
+		{ID: 44, Type: types.TaskTypeHealthProbe, Status: types.ModelBatchTaskStatusSucceeded, FinishedAt: &finishedAt},
+	}
+	if err := db.Create(&tasks).Error; err != nil {
+		t.Fatalf("写入任务失败: %v", err)
+	}
+
+	status, err := svc.GetRequestLogRetention(context.Background())
+	if err != nil {
+		t.Fatalf("获取保留策略状态失败: %v", err)
+	}
+	if status.HourlyStatRows != 2 {
+		t.Fatalf("状态中小时汇总数 = %d, 期望 2", status.HourlyStatRows)
+	}
+	if status.LastRun == nil || status.LastRun.TaskID != 42 || status.LastRun.Result == nil || status.LastRun.Result.PurgedLogs != 3 {
+		t.Fatalf("最近一次运行记录不符: %+v", status.LastRun)
+	}
+}
+
+func TestRunRequestLogRetention_未启用时返回错误(t *testing.T) {
+	svc, _ := newRetentionTestService(t, 0)
+
+	if _, err := svc.RunRequestLogRetention(context.Background(), 1); err == nil {
+		t.Fatal("未启用保留策略时应返回错误")
+	}
+}
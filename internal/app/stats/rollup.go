@@ -0,0 +1,846 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/MeowSalty/pinai/database"
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rollupChunk 为单个事务重算的原始日志时间跨度，避免大表一次性加载到内存。
+const rollupChunk = 24 * time.Hour
+
+// latencyBinsPerOctave 为延迟分布每翻倍区间划分的桶数。
+//
+// 桶边界为 2^(bin/4) 微秒，桶代表值相对真实值的误差不超过约 ±9%。
+const latencyBinsPerOctave = 4
+
+// 错误汇总维度字段的最大长度（字符），与 types.RequestLogHourlyError 的列宽一致
+const (
+	hourlyErrorCodeMaxLength     = 64
+	hourlyErrorFromMaxLength     = 32
+	hourlyUpstreamErrorMaxLength = 128
+)
+
+// requestLogRollupRow 定义汇总请求日志时读取的原始行结构
+type requestLogRollupRow struct {
+	Timestamp         time.Time `gorm:"column:timestamp"`
+	Success           bool      `gorm:"column:success"`
+	IsStream          bool      `gorm:"column:is_stream"`
+	Duration          int64     `gorm:"column:duration"`
+	FirstByteTime     *int64    `gorm:"column:first_byte_time"`
+	OriginalModelName string    `gorm:"column:original_model_name"`
+	PlatformID        uint      `gorm:"column:platform_id"`
+	APIKeyID          uint      `gorm:"column:api_key_id"`
+	PromptTokens      *int      `gorm:"column:prompt_tokens"`
+	CompletionTokens  *int      `gorm:"column:completion_tokens"`
+	TotalTokens       *int      `gorm:"column:total_tokens"`
+	Cost              *float64  `gorm:"column:cost"`
+	CostCurrency      *string   `gorm:"column:cost_currency"`
+	ErrorCode         *string   `gorm:"column:error_code"`
+	ErrorFrom         *string   `gorm:"column:error_from"`
+	HTTPStatus        *int      `gorm:"column:http_status"`
+	UpstreamErrorType *string   `gorm:"column:upstream_error_type"`
+	UpstreamErrorCode *string   `gorm:"column:upstream_error_code"`
+}
+
+// requestLogRollupColumns 为汇总请求日志时读取的原始日志列
+const requestLogRollupColumns = "timestamp, success, is_stream, duration, first_byte_time, original_model_name, platform_id, api_key_id, " +
+	"prompt_tokens, completion_tokens, total_tokens, cost, cost_currency, " +
+	"error_code, error_from, http_status, upstream_error_type, upstream_error_code"
+
+type hourlyStatKey struct {
+	BucketStart time.Time
+	ModelName   string
+	PlatformID  uint
+}
+
+type hourlyUsageKey struct {
+	hourlyStatKey
+	APIKeyID uint
+	Currency string
+}
+
+// hourlyErrorKey 为错误汇总维度，错误字段为空值时取零值
+type hourlyErrorKey struct {
+	hourlyStatKey
+	APIKeyID          uint
+	ErrorCode         string
+	ErrorFrom         string
+	HTTPStatus        int
+	UpstreamErrorType string
+	UpstreamErrorCode string
+}
+
+// newHourlyErrorKey 按汇总列宽截断错误字段并返回错误汇总维度
+func newHourlyErrorKey(key hourlyStatKey, row requestLogRollupRow) hourlyErrorKey {
+	errorKey := hourlyErrorKey{
+		hourlyStatKey:     key,
+		APIKeyID:          row.APIKeyID,
+		ErrorCode:         truncateKey(derefString(row.ErrorCode), hourlyErrorCodeMaxLength),
+		ErrorFrom:         truncateKey(derefString(row.ErrorFrom), hourlyErrorFromMaxLength),
+		UpstreamErrorType: truncateKey(derefString(row.UpstreamErrorType), hourlyUpstreamErrorMaxLength),
+		UpstreamErrorCode: truncateKey(derefString(row.UpstreamErrorCode), hourlyUpstreamErrorMaxLength),
+	}
+	if row.HTTPStatus != nil {
+		errorKey.HTTPStatus = *row.HTTPStatus
+	}
+	return errorKey
+}
+
+// truncateKey 按字符截断汇总维度值
+func truncateKey(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
+
+type latencyBinKey struct {
+	hourlyStatKey
+	Metric string
+	Bin    int
+}
+
+// requestLogRollup 为一组原始请求日志的汇总结果
+type requestLogRollup struct {
+	Stats  []*types.RequestLogHourlyStat
+	Bins   []*types.RequestLogLatencyBin
+	Usages []*types.RequestLogHourlyUsage
+	Errors []*types.RequestLogHourlyError
+}
+
+// RecordRequestLogRollup 将单条请求日志增量累加到小时汇总、延迟分布、用量费用汇总与错误汇总。
+//
+// db 通常为写入请求日志所在的事务，保证原始日志与汇总同时提交或回滚。
+func RecordRequestLogRollup(ctx context.Context, db *gorm.DB, log *types.RequestLog) error {
+	rollup := aggregateRequestLogRollup([]requestLogRollupRow{{
+		Timestamp:         log.Timestamp,
+		Success:           log.Success,
+		IsStream:          log.IsStream,
+		Duration:          log.Duration,
+		FirstByteTime:     log.FirstByteTime,
+		OriginalModelName: log.OriginalModelName,
+		PlatformID:        log.PlatformID,
+		APIKeyID:          log.APIKeyID,
+		PromptTokens:      log.PromptTokens,
+		CompletionTokens:  log.CompletionTokens,
+		TotalTokens:       log.TotalTokens,
+		Cost:              log.Cost,
+		CostCurrency:      log.CostCurrency,
+		ErrorCode:         log.ErrorCode,
+		ErrorFrom:         log.ErrorFrom,
+		HTTPStatus:        log.HTTPStatus,
+		UpstreamErrorType: log.UpstreamErrorType,
+		UpstreamErrorCode: log.UpstreamErrorCode,
+	}})
+
+	tx := database.CleanSession(ctx, db)
+	if err := lockRequestLogRollup(tx, true); err != nil {
+		return err
+	}
+
+	for _, stat := range rollup.Stats {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "bucket_start"}, {Name: "model_name"}, {Name: "platform_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"request_count":     gorm.Expr("request_count + ?", stat.RequestCount),
+				"success_count":     gorm.Expr("success_count + ?", stat.SuccessCount),
+				"stream_count":      gorm.Expr("stream_count + ?", stat.StreamCount),
+				"duration_sum":      gorm.Expr("duration_sum + ?", stat.DurationSum),
+				"first_byte_count":  gorm.Expr("first_byte_count + ?", stat.FirstByteCount),
+				"first_byte_sum":    gorm.Expr("first_byte_sum + ?", stat.FirstByteSum),
+				"usage_count":       gorm.Expr("usage_count + ?", stat.UsageCount),
+				"prompt_tokens":     gorm.Expr("prompt_tokens + ?", stat.PromptTokens),
+				"completion_tokens": gorm.Expr("completion_tokens + ?", stat.CompletionTokens),
+				"total_tokens":      gorm.Expr("total_tokens + ?", stat.TotalTokens),
+			}),
+		}).Create(stat).Error
+		if err != nil {
+			return fmt.Errorf("累加小时汇总失败：%w", err)
+		}
+	}
+
+	for _, bin := range rollup.Bins {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "bucket_start"}, {Name: "model_name"}, {Name: "platform_id"}, {Name: "metric"}, {Name: "bin"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"request_count": gorm.Expr("request_count + ?", bin.RequestCount),
+			}),
+		}).Create(bin).Error
+		if err != nil {
+			return fmt.Errorf("累加延迟分布失败：%w", err)
+		}
+	}
+
+	for _, usage := range rollup.Usages {
+		if err := upsertHourlyUsage(tx, usage); err != nil {
+			return err
+		}
+	}
+
+	for _, hourlyError := range rollup.Errors {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: hourlyErrorConflictColumns(),
+			DoUpdates: clause.Assignments(map[string]any{
+				"request_count": gorm.Expr("request_count + ?", hourlyError.RequestCount),
+			}),
+		}).Create(hourlyError).Error
+		if err != nil {
+			return fmt.Errorf("累加错误汇总失败：%w", err)
+		}
+	}
+
+	return nil
+}
+
+// hourlyErrorKeyColumns 为错误汇总的唯一键列
+var hourlyErrorKeyColumns = []string{"bucket_start", "model_name", "platform_id", "api_key_id",
+	"error_code", "error_from", "http_status", "upstream_error_type", "upstream_error_code"}
+
+func hourlyErrorConflictColumns() []clause.Column {
+	columns := make([]clause.Column, len(hourlyErrorKeyColumns))
+	for i, name := range hourlyErrorKeyColumns {
+		columns[i] = clause.Column{Name: name}
+	}
+	return columns
+}
+
+// upsertHourlyUsage 将用量与费用增量累加到对应的小时用量汇总，增量可为负值
+func upsertHourlyUsage(tx *gorm.DB, usage *types.RequestLogHourlyUsage) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "bucket_start"}, {Name: "model_name"}, {Name: "platform_id"}, {Name: "api_key_id"}, {Name: "currency"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"request_count":     gorm.Expr("request_count + ?", usage.RequestCount),
+			"usage_count":       gorm.Expr("usage_count + ?", usage.UsageCount),
+			"prompt_tokens":     gorm.Expr("prompt_tokens + ?", usage.PromptTokens),
+			"completion_tokens": gorm.Expr("completion_tokens + ?", usage.CompletionTokens),
+			"total_tokens":      gorm.Expr("total_tokens + ?", usage.TotalTokens),
+			"cost":              gorm.Expr("cost + ?", usage.Cost),
+		}),
+	}).Create(usage).Error
+	if err != nil {
+		return fmt.Errorf("累加用量汇总失败：%w", err)
+	}
+	return nil
+}
+
+// BackfillRequestLogRollup 为尚未汇总的原始请求日志补建小时汇总与延迟分布。
+//
+// 补建起点为已有汇总中最新小时桶的下一个小时；用量汇总落后时（如新增用量表后首次启动）
+// 从仍保留原始日志的最早小时起补建。补建按整小时覆盖写入，可与增量累加并发执行，
+// 由任务运行时以任务租约调度，保证多实例部署时同一时间只有一个实例执行。
+func (s *service) BackfillRequestLogRollup(ctx context.Context) (int64, error) {
+	start := time.Now()
+	logger := s.logger.With("operation", "backfill_request_log_rollup")
+
+	var latest []types.RequestLogHourlyStat
+	if err := requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
+		Select("bucket_start").
+		Order("bucket_start DESC").
+		Limit(1).
+		Find(&latest).Error; err != nil {
+		return 0, fmt.Errorf("查询最新小时汇总失败：%w", err)
+	}
+
+	var from time.Time
+	if len(latest) > 0 {
+		from = hourBucket(latest[0].BucketStart).Add(time.Hour)
+	} else {
+		oldest, err := s.oldestRequestLogBefore(ctx, time.Time{})
+		if err != nil {
+			return 0, fmt.Errorf("查询最早请求日志失败：%w", err)
+		}
+		if oldest == nil {
+			return 0, nil
+		}
+		from = hourBucket(*oldest)
+	}
+
+	usageFrom, err := s.usageBackfillStart(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if usageFrom != nil && usageFrom.Before(from) {
+		from = *usageFrom
+	}
+
+	errorFrom, err := s.errorBackfillStart(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if errorFrom != nil && errorFrom.Before(from) {
+		from = *errorFrom
+	}
+
+	to := ceilToHour(start.UTC())
+	if !from.Before(to) {
+		return 0, nil
+	}
+
+	var written int64
+	for chunkStart := from; chunkStart.Before(to); {
+		chunkEnd := chunkStart.Add(rollupChunk)
+		if chunkEnd.After(to) {
+			chunkEnd = to
+		}
+
+		err := requestLogDB(ctx).Transaction(func(tx *gorm.DB) error {
+			rows, err := rebuildRollupRange(tx, chunkStart, chunkEnd)
+			written += rows
+			return err
+		})
+		if err != nil {
+			logger.ErrorContext(ctx, "补建小时汇总失败",
+				"error", err,
+				"error_type", "database_error",
+				"chunk_start", chunkStart,
+				"chunk_end", chunkEnd,
+			)
+			return written, fmt.Errorf("补建 %s 至 %s 的小时汇总失败：%w",
+				chunkStart.Format(time.RFC3339), chunkEnd.Format(time.RFC3339), err)
+		}
+
+		chunkStart = chunkEnd
+	}
+
+	logger.InfoContext(ctx, "小时汇总补建完成",
+		"from", from,
+		"to", to,
+		"hourly_stat_rows", written,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	return written, nil
+}
+
+// usageBackfillStart 返回用量汇总需要补建的起点；用量汇总已跟上原始日志时返回 nil
+func (s *service) usageBackfillStart(ctx context.Context) (*time.Time, error) {
+	var latest []types.RequestLogHourlyUsage
+	if err := requestLogDB(ctx).Model(&types.RequestLogHourlyUsage{}).
+		Select("bucket_start").
+		Order("bucket_start DESC").
+		Limit(1).
+		Find(&latest).Error; err != nil {
+		return nil, fmt.Errorf("查询最新用量汇总失败：%w", err)
+	}
+
+	var after time.Time
+	if len(latest) > 0 {
+		after = hourBucket(latest[0].BucketStart).Add(time.Hour)
+	}
+
+	// 原始日志已清理的小时无法重算，起点不早于仍保留原始日志的最早小时
+	db := requestLogDB(ctx).Table("request_logs").Select("timestamp")
+	if !after.IsZero() {
+		db = db.Where("timestamp >= ?", after)
+	}
+	var rows []requestLogRollupRow
+	if err := db.Order("timestamp ASC").Limit(1).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询最早请求日志失败：%w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	from := hourBucket(rows[0].Timestamp)
+	return &from, nil
+}
+
+// errorBackfillStart 返回错误汇总需要补建的起点；错误汇总已跟上原始日志时返回 nil
+//
+// 错误汇总没有失败请求的小时不产生记录，因此以小时汇总中有失败请求、但晚于最新错误汇总的最早小时判断是否落后
+// （如新增错误汇总表后首次启动），起点不早于仍保留原始日志的最早小时。
+func (s *service) errorBackfillStart(ctx context.Context) (*time.Time, error) {
+	var latest []types.RequestLogHourlyError
+	if err := requestLogDB(ctx).Model(&types.RequestLogHourlyError{}).
+		Select("bucket_start").
+		Order("bucket_start DESC").
+		Limit(1).
+		Find(&latest).Error; err != nil {
+		return nil, fmt.Errorf("查询最新错误汇总失败：%w", err)
+	}
+
+	oldest, err := s.oldestRequestLogBefore(ctx, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("查询最早请求日志失败：%w", err)
+	}
+	if oldest == nil {
+		return nil, nil
+	}
+
+	after := hourBucket(*oldest)
+	if len(latest) > 0 {
+		if next := hourBucket(latest[0].BucketStart).Add(time.Hour); next.After(after) {
+			after = next
+		}
+	}
+
+	var failed []types.RequestLogHourlyStat
+	if err := requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
+		Select("bucket_start").
+		Where("bucket_start >= ? AND request_count > success_count", after).
+		Order("bucket_start ASC").
+		Limit(1).
+		Find(&failed).Error; err != nil {
+		return nil, fmt.Errorf("查询有失败请求的小时汇总失败：%w", err)
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	from := hourBucket(failed[0].BucketStart)
+	return &from, nil
+}
+
+// rollupAdvisoryLockKey 为 PostgreSQL 下协调汇总重算与增量累加的事务级咨询锁键
+const rollupAdvisoryLockKey int64 = 0x70696e6169726f6c
+
+// lockRequestLogRollup 在事务内获取汇总写入锁，锁随事务提交或回滚释放：增量累加持有共享锁，重算持有排他锁。
+//
+// 仅 PostgreSQL 需要显式加锁：其删除不会锁住尚不存在的汇总行，并发的增量累加可在重算读取原始日志后插入新行，
+// 随后被重算的覆盖写入替换而丢失。SQLite 写事务独占整个数据库，MySQL（InnoDB 默认的可重复读）
+// 按范围删除持有的间隙锁会阻塞对应小时桶的插入，均无需额外加锁。
+func lockRequestLogRollup(tx *gorm.DB, shared bool) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	statement := "SELECT pg_advisory_xact_lock(?)"
+	if shared {
+		statement = "SELECT pg_advisory_xact_lock_shared(?)"
+	}
+	if err := tx.Exec(statement, rollupAdvisoryLockKey).Error; err != nil {
+		return fmt.Errorf("获取汇总写入锁失败：%w", err)
+	}
+	return nil
+}
+
+// rebuildRollupRange 按原始日志重算 [from, to) 的小时汇总、延迟分布、用量汇总与错误汇总（覆盖写入），返回写入的小时汇总数
+//
+// 重算先获取汇总写入排他锁，并发的增量累加等待本事务提交后再累加，本事务读取时已提交的日志则计入重算结果，
+// 因此同一条日志既不会重复计入也不会丢失。
+func rebuildRollupRange(tx *gorm.DB, from, to time.Time) (int64, error) {
+	if err := lockRequestLogRollup(tx, false); err != nil {
+		return 0, err
+	}
+	if err := tx.Where("bucket_start >= ? AND bucket_start < ?", from, to).
+		Delete(&types.RequestLogHourlyStat{}).Error; err != nil {
+		return 0, fmt.Errorf("清理旧小时汇总失败：%w", err)
+	}
+	if err := tx.Where("bucket_start >= ? AND bucket_start < ?", from, to).
+		Delete(&types.RequestLogLatencyBin{}).Error; err != nil {
+		return 0, fmt.Errorf("清理旧延迟分布失败：%w", err)
+	}
+	if err := tx.Where("bucket_start >= ? AND bucket_start < ?", from, to).
+		Delete(&types.RequestLogHourlyUsage{}).Error; err != nil {
+		return 0, fmt.Errorf("清理旧用量汇总失败：%w", err)
+	}
+	if err := tx.Where("bucket_start >= ? AND bucket_start < ?", from, to).
+		Delete(&types.RequestLogHourlyError{}).Error; err != nil {
+		return 0, fmt.Errorf("清理旧错误汇总失败：%w", err)
+	}
+
+	var rows []requestLogRollupRow
+	err := tx.Table("request_logs").
+		Select(requestLogRollupColumns).
+		Where("timestamp >= ? AND timestamp < ?", from, to).
+		Scan(&rows).Error
+	if err != nil {
+		return 0, fmt.Errorf("读取原始请求日志失败：%w", err)
+	}
+
+	rollup := aggregateRequestLogRollup(rows)
+
+	if len(rollup.Stats) > 0 {
+		if err := tx.Clauses(replaceOnConflict([]string{"bucket_start", "model_name", "platform_id"},
+			"request_count", "success_count", "stream_count", "duration_sum", "first_byte_count", "first_byte_sum",
+			"usage_count", "prompt_tokens", "completion_tokens", "total_tokens")).
+			CreateInBatches(rollup.Stats, 200).Error; err != nil {
+			return 0, fmt.Errorf("写入小时汇总失败：%w", err)
+		}
+	}
+	if len(rollup.Bins) > 0 {
+		if err := tx.Clauses(replaceOnConflict([]string{"bucket_start", "model_name", "platform_id", "metric", "bin"},
+			"request_count")).
+			CreateInBatches(rollup.Bins, 200).Error; err != nil {
+			return 0, fmt.Errorf("写入延迟分布失败：%w", err)
+		}
+	}
+	if len(rollup.Usages) > 0 {
+		if err := tx.Clauses(replaceOnConflict([]string{"bucket_start", "model_name", "platform_id", "api_key_id", "currency"},
+			"request_count", "usage_count", "prompt_tokens", "completion_tokens", "total_tokens", "cost")).
+			CreateInBatches(rollup.Usages, 200).Error; err != nil {
+			return 0, fmt.Errorf("写入用量汇总失败：%w", err)
+		}
+	}
+	if len(rollup.Errors) > 0 {
+		if err := tx.Clauses(replaceOnConflict(hourlyErrorKeyColumns, "request_count")).
+			CreateInBatches(rollup.Errors, 200).Error; err != nil {
+			return 0, fmt.Errorf("写入错误汇总失败：%w", err)
+		}
+	}
+
+	return int64(len(rollup.Stats)), nil
+}
+
+// replaceOnConflict 返回按唯一键冲突时以新值覆盖指定列的子句
+func replaceOnConflict(keys []string, columns ...string) clause.OnConflict {
+	conflictColumns := make([]clause.Column, len(keys))
+	for i, key := range keys {
+		conflictColumns[i] = clause.Column{Name: key}
+	}
+	return clause.OnConflict{
+		Columns:   conflictColumns,
+		DoUpdates: clause.AssignmentColumns(columns),
+	}
+}
+
+// aggregateRequestLogRollup 将原始请求日志按小时、模型与平台汇总，用量另按密钥与币种细分，失败请求另按密钥与错误字段细分
+func aggregateRequestLogRollup(rows []requestLogRollupRow) requestLogRollup {
+	statMap := make(map[hourlyStatKey]*types.RequestLogHourlyStat)
+	binMap := make(map[latencyBinKey]*types.RequestLogLatencyBin)
+	usageMap := make(map[hourlyUsageKey]*types.RequestLogHourlyUsage)
+	errorMap := make(map[hourlyErrorKey]*types.RequestLogHourlyError)
+	var result requestLogRollup
+
+	addBin := func(key hourlyStatKey, metric string, us int64) {
+		binKey := latencyBinKey{hourlyStatKey: key, Metric: metric, Bin: latencyBinIndex(us)}
+		bin, exists := binMap[binKey]
+		if !exists {
+			bin = &types.RequestLogLatencyBin{
+				BucketStart: key.BucketStart,
+				ModelName:   key.ModelName,
+				PlatformID:  key.PlatformID,
+				Metric:      metric,
+				Bin:         binKey.Bin,
+			}
+			binMap[binKey] = bin
+			result.Bins = append(result.Bins, bin)
+		}
+		bin.RequestCount++
+	}
+
+	for _, row := range rows {
+		key := hourlyStatKey{
+			BucketStart: hourBucket(row.Timestamp),
+			ModelName:   row.OriginalModelName,
+			PlatformID:  row.PlatformID,
+		}
+
+		stat, exists := statMap[key]
+		if !exists {
+			stat = &types.RequestLogHourlyStat{
+				BucketStart: key.BucketStart,
+				ModelName:   key.ModelName,
+				PlatformID:  key.PlatformID,
+			}
+			statMap[key] = stat
+			result.Stats = append(result.Stats, stat)
+		}
+
+		usageKey := hourlyUsageKey{hourlyStatKey: key, APIKeyID: row.APIKeyID}
+		if row.Cost != nil && row.CostCurrency != nil {
+			usageKey.Currency = *row.CostCurrency
+		}
+		usage, exists := usageMap[usageKey]
+		if !exists {
+			usage = &types.RequestLogHourlyUsage{
+				BucketStart: key.BucketStart,
+				ModelName:   key.ModelName,
+				PlatformID:  key.PlatformID,
+				APIKeyID:    usageKey.APIKeyID,
+				Currency:    usageKey.Currency,
+			}
+			usageMap[usageKey] = usage
+			result.Usages = append(result.Usages, usage)
+		}
+		usage.RequestCount++
+		if usageKey.Currency != "" {
+			usage.Cost += *row.Cost
+		}
+
+		stat.RequestCount++
+		if row.Success {
+			stat.SuccessCount++
+		} else {
+			errorKey := newHourlyErrorKey(key, row)
+			hourlyError, exists := errorMap[errorKey]
+			if !exists {
+				hourlyError = &types.RequestLogHourlyError{
+					BucketStart:       key.BucketStart,
+					ModelName:         key.ModelName,
+					PlatformID:        key.PlatformID,
+					APIKeyID:          errorKey.APIKeyID,
+					ErrorCode:         errorKey.ErrorCode,
+					ErrorFrom:         errorKey.ErrorFrom,
+					HTTPStatus:        errorKey.HTTPStatus,
+					UpstreamErrorType: errorKey.UpstreamErrorType,
+					UpstreamErrorCode: errorKey.UpstreamErrorCode,
+				}
+				errorMap[errorKey] = hourlyError
+				result.Errors = append(result.Errors, hourlyError)
+			}
+			hourlyError.RequestCount++
+		}
+		if row.IsStream {
+			stat.StreamCount++
+		}
+
+		stat.DurationSum += row.Duration
+		if row.Duration > 0 {
+			addBin(key, types.LatencyMetricDuration, row.Duration)
+		}
+		if row.FirstByteTime != nil && *row.FirstByteTime > 0 {
+			stat.FirstByteCount++
+			stat.FirstByteSum += *row.FirstByteTime
+			addBin(key, types.LatencyMetricFirstByte, *row.FirstByteTime)
+		}
+
+		if row.TotalTokens != nil || row.PromptTokens != nil || row.CompletionTokens != nil {
+			promptTokens := ptrIntToInt64(row.PromptTokens)
+			completionTokens := ptrIntToInt64(row.CompletionTokens)
+			totalTokens := ptrIntToInt64(row.TotalTokens)
+			if row.TotalTokens == nil {
+				totalTokens = promptTokens + completionTokens
+			}
+
+			stat.UsageCount++
+			stat.PromptTokens += promptTokens
+			stat.CompletionTokens += completionTokens
+			stat.TotalTokens += totalTokens
+
+			usage.UsageCount++
+			usage.PromptTokens += promptTokens
+			usage.CompletionTokens += completionTokens
+			usage.TotalTokens += totalTokens
+		}
+	}
+
+	return result
+}
+
+// loadHourlyStats 读取 [from, to) 内的小时汇总；modelName 非空时按原始模型名称过滤
+func loadHourlyStats(ctx context.Context, from, to time.Time, modelName *string) ([]types.RequestLogHourlyStat, error) {
+	db := requestLogDB(ctx).Model(&types.RequestLogHourlyStat{}).
+		Where("bucket_start >= ? AND bucket_start < ?", from, to)
+	if modelName != nil && *modelName != "" {
+		db = db.Where("model_name = ?", *modelName)
+	}
+
+	var stats []types.RequestLogHourlyStat
+	if err := db.Order("bucket_start ASC").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// latencySketch 为按对数刻度分桶的延迟分布，键为桶序号，值为请求数
+type latencySketch map[int]int64
+
+// loadLatencySketch 读取 [from, to) 内指定指标的延迟分布
+func loadLatencySketch(ctx context.Context, metric string, from, to time.Time) (latencySketch, error) {
+	var rows []struct {
+		Bin          int   `gorm:"column:bin"`
+		RequestCount int64 `gorm:"column:request_count"`
+	}
+	err := requestLogDB(ctx).Model(&types.RequestLogLatencyBin{}).
+		Select("bin, SUM(request_count) as request_count").
+		Where("metric = ? AND bucket_start >= ? AND bucket_start < ?", metric, from, to).
+		Group("bin").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sketch := make(latencySketch, len(rows))
+	for _, row := range rows {
+		sketch[row.Bin] += row.RequestCount
+	}
+
+	return sketch, nil
+}
+
+// trimmedMean 近似计算去除 [0, lower) 与 [upper, 1] 分位区间后的平均值
+//
+// 与 calculateAvgFirstByteTimeWithPercentile 的截取规则一致，桶内取代表值。
+func (s latencySketch) trimmedMean(lowerPercentile, upperPercentile float64) float64 {
+	var total int64
+	bins := make([]int, 0, len(s))
+	for bin, count := range s {
+		if count <= 0 {
+			continue
+		}
+		bins = append(bins, bin)
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Ints(bins)
+
+	lowerIndex := int64(float64(total) * lowerPercentile)
+	upperIndex := int64(float64(total) * upperPercentile)
+	if lowerIndex >= upperIndex {
+		upperIndex = lowerIndex + 1
+	}
+	if upperIndex > total {
+		upperIndex = total
+	}
+
+	var (
+		pos   int64
+		sum   float64
+		count int64
+	)
+	for _, bin := range bins {
+		binStart, binEnd := pos, pos+s[bin]
+		pos = binEnd
+
+		lo := max(binStart, lowerIndex)
+		hi := min(binEnd, upperIndex)
+		if lo >= hi {
+			continue
+		}
+		sum += latencyBinValue(bin) * float64(hi-lo)
+		count += hi - lo
+	}
+	if count == 0 {
+		return 0
+	}
+
+	return sum / float64(count)
+}
+
+// latencyBinIndex 返回微秒延迟所属的对数刻度桶序号
+func latencyBinIndex(us int64) int {
+	if us <= 1 {
+		return 0
+	}
+	return int(math.Floor(math.Log2(float64(us)) * latencyBinsPerOctave))
+}
+
+// latencyBinValue 返回桶的代表值（桶上下界的几何中点，微秒）
+func latencyBinValue(bin int) float64 {
+	return math.Exp2((float64(bin) + 0.5) / latencyBinsPerOctave)
+}
+
+// hourBucket 返回时间所属的小时桶起始时间，统一为 UTC 以保证汇总键与服务器时区无关
+func hourBucket(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
+
+// requestLogDB 返回不携带 gen 查询状态的 GORM 会话，用于跨表的原始 SQL 操作
+func requestLogDB(ctx context.Context) *gorm.DB {
+	return database.CleanSession(ctx, query.Q.RequestLog.WithContext(ctx).UnderlyingDB())
+}
+
+// quantile 近似计算分位数（最近秩法），返回桶代表值（微秒）
+func (s latencySketch) quantile(q float64) float64 {
+	var total int64
+	bins := make([]int, 0, len(s))
+	for bin, count := range s {
+		if count <= 0 {
+			continue
+		}
+		bins = append(bins, bin)
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Ints(bins)
+
+	rank := int64(math.Ceil(q * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var cumulative int64
+	for _, bin := range bins {
+		cumulative += s[bin]
+		if cumulative >= rank {
+			return latencyBinValue(bin)
+		}
+	}
+
+	return latencyBinValue(bins[len(bins)-1])
+}
+
+// percentiles 计算常用延迟分位数
+func (s latencySketch) percentiles() LatencyPercentiles {
+	var count int64
+	for _, c := range s {
+		count += c
+	}
+	if count == 0 {
+		return LatencyPercentiles{}
+	}
+
+	return LatencyPercentiles{
+		Count: count,
+		P50:   s.quantile(0.50),
+		P90:   s.quantile(0.90),
+		P95:   s.quantile(0.95),
+		P99:   s.quantile(0.99),
+	}
+}
+
+// latencyBinRow 定义按模型、平台、指标与桶汇总的延迟分布行
+type latencyBinRow struct {
+	ModelName    string `gorm:"column:model_name"`
+	PlatformID   uint   `gorm:"column:platform_id"`
+	Metric       string `gorm:"column:metric"`
+	Bin          int    `gorm:"column:bin"`
+	RequestCount int64  `gorm:"column:request_count"`
+}
+
+// loadLatencyBins 读取 [from, to) 内按模型、平台、指标与桶汇总的延迟分布；modelName 非空时按原始模型名称过滤
+func loadLatencyBins(ctx context.Context, from, to time.Time, modelName *string) ([]latencyBinRow, error) {
+	db := requestLogDB(ctx).Model(&types.RequestLogLatencyBin{}).
+		Select("model_name, platform_id, metric, bin, SUM(request_count) as request_count").
+		Where("bucket_start >= ? AND bucket_start < ?", from, to)
+	if modelName != nil && *modelName != "" {
+		db = db.Where("model_name = ?", *modelName)
+	}
+
+	var rows []latencyBinRow
+	if err := db.Group("model_name, platform_id, metric, bin").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// latencyStatsSet 为按维度键聚合的延迟分布
+type latencyStatsSet[K comparable] map[K]map[string]latencySketch
+
+func (l latencyStatsSet[K]) add(key K, row latencyBinRow) {
+	metrics, ok := l[key]
+	if !ok {
+		metrics = make(map[string]latencySketch, 2)
+		l[key] = metrics
+	}
+	sketch, ok := metrics[row.Metric]
+	if !ok {
+		sketch = make(latencySketch)
+		metrics[row.Metric] = sketch
+	}
+	sketch[row.Bin] += row.RequestCount
+}
+
+func (l latencyStatsSet[K]) stats(key K) LatencyStats {
+	metrics := l[key]
+	return LatencyStats{
+		Duration:  metrics[types.LatencyMetricDuration].percentiles(),
+		FirstByte: metrics[types.LatencyMetricFirstByte].percentiles(),
+	}
+}
@@ -0,0 +1,284 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
+)
+
+func TestRecordRequestLogRollup_增量累加并供仪表盘读取(t *testing.T) {
+	svc, db := newRetentionTestService(t, 0)
+	ctx := context.Background()
+
+	now := time.Now()
+	firstByte := int64(2000)
+	logs := []*types.RequestLog{
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, IsStream: true, Duration: 4000, FirstByteTime: &firstByte, PromptTokens: intPtr(10), CompletionTokens: intPtr(5)},
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 1, Success: false, Duration: 1000},
+		{Timestamp: now, OriginalModelName: "", PlatformID: 2, Success: true, Duration: 3000, TotalTokens: intPtr(8)},
+	}
+	for _, log := range logs {
+		if err := db.Create(log).Error; err != nil {
+			t.Fatalf("写入请求日志失败: %v", err)
+		}
+		if err := RecordRequestLogRollup(ctx, db, log); err != nil {
+			t.Fatalf("累加小时汇总失败: %v", err)
+		}
+	}
+
+	var stats []types.RequestLogHourlyStat
+	if err := db.Order("platform_id ASC").Find(&stats).Error; err != nil {
+		t.Fatalf("查询小时汇总失败: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("小时汇总数量 = %d, 期望 2", len(stats))
+	}
+	if stats[0].RequestCount != 2 || stats[0].SuccessCount != 1 || stats[0].TotalTokens != 15 {
+		t.Fatalf("gpt-4o 小时汇总不符: %+v", stats[0])
+	}
+
+	dashboard, err := svc.GetDashboard(ctx, TrendRange24h)
+	if err != nil {
+		t.Fatalf("获取仪表盘失败: %v", err)
+	}
+	if dashboard.Overview.TotalRequests != 3 || dashboard.Overview.TotalTokens != 23 {
+		t.Fatalf("仪表盘概览不符: %+v", dashboard.Overview)
+	}
+	if dashboard.Overview.AvgFirstByteTime != 2000 {
+		t.Fatalf("平均首字时间 = %v, 期望 2000", dashboard.Overview.AvgFirstByteTime)
+	}
+	if dashboard.Overview.ActiveModels != 2 || dashboard.Overview.ActivePlatforms != 2 {
+		t.Fatalf("活跃模型或平台数不符: %+v", dashboard.Overview)
+	}
+	if last := dashboard.Trend.DataPoints[len(dashboard.Trend.DataPoints)-1]; last.RequestCount != 3 {
+		t.Fatalf("最新趋势点请求数 = %d, 期望 3", last.RequestCount)
+	}
+
+	modelName := "gpt-4o"
+	status, err := svc.GetModelStatus(ctx, StatusOptions{Range: TrendRange24h, ModelName: &modelName})
+	if err != nil {
+		t.Fatalf("获取模型状态失败: %v", err)
+	}
+	if len(status.Models) != 1 || status.Models[0].TotalRequests != 2 || status.Models[0].SuccessCount != 1 {
+		t.Fatalf("模型状态不符: %+v", status.Models)
+	}
+
+	rank, err := svc.GetModelUsageRank(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("获取模型用量排名失败: %v", err)
+	}
+	if rank.TotalTokens != 23 || len(rank.Models) != 2 || rank.Models[0].ModelName != "gpt-4o" {
+		t.Fatalf("模型用量排名不符: %+v", rank)
+	}
+}
+
+func TestBackfillRequestLogRollup_补建未汇总的历史日志(t *testing.T) {
+	svc, db := newRetentionTestService(t, 0)
+	ctx := context.Background()
+
+	earlier := time.Now().Add(-3 * time.Hour)
+	logs := []*types.RequestLog{
+		{Timestamp: earlier, OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, Duration: 1000},
+		{Timestamp: earlier.Add(time.Hour), OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, Duration: 1000},
+	}
+	if err := db.Create(&logs).Error; err != nil {
+		t.Fatalf("写入请求日志失败: %v", err)
+	}
+
+	written, err := svc.BackfillRequestLogRollup(ctx)
+	if err != nil {
+		t.Fatalf("补建小时汇总失败: %v", err)
+	}
+	if written != 2 {
+		t.Fatalf("补建数量 = %d, 期望 2", written)
+	}
+
+	// 已有汇总覆盖全部日志时不再重复补建
+	again, err := svc.BackfillRequestLogRollup(ctx)
+	if err != nil {
+		t.Fatalf("重复补建失败: %v", err)
+	}
+	if again != 0 {
+		t.Fatalf("重复补建数量 = %d, 期望 0", again)
+	}
+
+	var total int64
+	db.Model(&types.RequestLogHourlyStat{}).Select("SUM(request_count)").Scan(&total)
+	if total != 2 {
+		t.Fatalf("汇总请求数 = %d, 期望 2", total)
+	}
+
+	// 增量累加已写入的小时再次整点重算时覆盖为原始日志的汇总，不重复计数
+	live := &types.RequestLog{Timestamp: time.Now(), OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, Duration: 1000}
+	if err := db.Create(live).Error; err != nil {
+		t.Fatalf("写入请求日志失败: %v", err)
+	}
+	if err := RecordRequestLogRollup(ctx, db, live); err != nil {
+		t.Fatalf("累加小时汇总失败: %v", err)
+	}
+	from := hourBucket(earlier)
+	for i := 0; i < 2; i++ {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			_, err := rebuildRollupRange(tx, from, ceilToHour(time.Now()))
+			return err
+		}); err != nil {
+			t.Fatalf("重算小时汇总失败: %v", err)
+		}
+	}
+	db.Model(&types.RequestLogHourlyStat{}).Select("SUM(request_count)").Scan(&total)
+	if total != 3 {
+		t.Fatalf("重算后汇总请求数 = %d, 期望 3", total)
+	}
+}
+
+func TestLatencySketch_近似百分位过滤平均值(t *testing.T) {
+	sketch := make(latencySketch)
+	values := make([]uint64, 0, 100)
+	for i := 1; i <= 100; i++ {
+		us := int64(i * 1000)
+		sketch[latencyBinIndex(us)]++
+		values = append(values, uint64(us))
+	}
+
+	exact := calculateAverage(values[10:90])
+	approx := sketch.trimmedMean(percentileLower, percentileUpper)
+	if math.Abs(approx-exact)/exact > 0.1 {
+		t.Fatalf("近似平均值 = %v, 精确值 = %v, 误差超过 10%%", approx, exact)
+	}
+}
+
+func TestLatencySketch_近似分位数(t *testing.T) {
+	sketch := make(latencySketch)
+	for i := 1; i <= 100; i++ {
+		sketch[latencyBinIndex(int64(i*1000))]++
+	}
+
+	p := sketch.percentiles()
+	if p.Count != 100 {
+		t.Fatalf("样本数 = %d, 期望 100", p.Count)
+	}
+	for _, tc := range []struct {
+		name   string
+		got    float64
+		expect float64
+	}{
+		{"p50", p.P50, 50000},
+		{"p90", p.P90, 90000},
+		{"p99", p.P99, 99000},
+	} {
+		if math.Abs(tc.got-tc.expect)/tc.expect > 0.1 {
+			t.Fatalf("%s = %v, 期望约 %v", tc.name, tc.got, tc.expect)
+		}
+	}
+}
+
+func TestGetPlatformStatus_按模型对比平台延迟(t *testing.T) {
+	svc, db := newRetentionTestService(t, 0)
+	ctx := context.Background()
+
+	if err := db.Create(&types.Platform{ID: 1, Name: "openai"}).Error; err != nil {
+		t.Fatalf("写入平台失败: %v", err)
+	}
+
+	now := time.Now()
+	logs := []*types.RequestLog{
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, Duration: 1000},
+		{Timestamp: now, OriginalModelName: "gpt-4o", PlatformID: 2, Success: true, Duration: 8000},
+		{Timestamp: now, OriginalModelName: "claude", PlatformID: 2, Success: false, Duration: 2000},
+	}
+	for _, log := range logs {
+		if err := RecordRequestLogRollup(ctx, db, log); err != nil {
+			t.Fatalf("累加小时汇总失败: %v", err)
+		}
+	}
+
+	modelName := "gpt-4o"
+	status, err := svc.GetPlatformStatus(ctx, StatusOptions{Range: TrendRange24h, ModelName: &modelName})
+	if err != nil {
+		t.Fatalf("获取平台状态失败: %v", err)
+	}
+	if len(status.Platforms) != 2 {
+		t.Fatalf("平台数量 = %d, 期望 2", len(status.Platforms))
+	}
+
+	byID := make(map[uint]PlatformStatusItem)
+	for _, item := range status.Platforms {
+		byID[item.PlatformID] = item
+	}
+	if byID[1].PlatformName != "openai" || byID[2].PlatformName != "平台#2" {
+		t.Fatalf("平台名称不符: %+v", status.Platforms)
+	}
+	if byID[2].TotalRequests != 1 || byID[2].Latency.Duration.Count != 1 {
+		t.Fatalf("按模型过滤后平台 2 统计不符: %+v", byID[2])
+	}
+	if byID[1].Latency.Duration.P50 >= byID[2].Latency.Duration.P50 {
+		t.Fatalf("平台延迟对比不符: %v >= %v", byID[1].Latency.Duration.P50, byID[2].Latency.Duration.P50)
+	}
+
+	modelStatus, err := svc.GetModelStatus(ctx, StatusOptions{Range: TrendRange24h})
+	if err != nil {
+		t.Fatalf("获取模型状态失败: %v", err)
+	}
+	for _, item := range modelStatus.Models {
+		if item.Latency.Duration.Count != item.TotalRequests {
+			t.Fatalf("模型 %s 延迟样本数 = %d, 期望 %d", item.ModelName, item.Latency.Duration.Count, item.TotalRequests)
+		}
+	}
+}
+
+func TestGetPlatformStatus_按起止时间查询(t *testing.T) {
+	svc, db := newRetentionTestService(t, 0)
+	ctx := context.Background()
+
+	now := time.Now()
+	for _, ts := range []time.Time{now.Add(-40 * 24 * time.Hour), now.Add(-10 * 24 * time.Hour), now} {
+		if err := RecordRequestLogRollup(ctx, db, &types.RequestLog{Timestamp: ts, OriginalModelName: "gpt-4o", PlatformID: 1, Success: true, Duration: 1000}); err != nil {
+			t.Fatalf("累加小时汇总失败: %v", err)
+		}
+	}
+
+	// 起点早于最早的汇总时收窄到最早的汇总，跨度超过 48 小时按天聚合
+	from, to := now.Add(-60*24*time.Hour), now.Add(time.Hour)
+	status, err := svc.GetPlatformStatus(ctx, StatusOptions{StartTime: &from, EndTime: &to})
+	if err != nil {
+		t.Fatalf("获取平台状态失败: %v", err)
+	}
+	if status.Range != "custom" || status.Granularity != "1d" {
+		t.Fatalf("时间范围或颗粒度不符: %s %s", status.Range, status.Granularity)
+	}
+	if !status.WindowStart.Equal(hourBucket(now.Add(-40*24*time.Hour))) || !status.WindowEnd.Equal(ceilToHour(now)) {
+		t.Fatalf("时间窗口不符: %v - %v", status.WindowStart, status.WindowEnd)
+	}
+	if len(status.Platforms) != 1 || status.Platforms[0].TotalRequests != 3 || len(status.Platforms[0].Points) != 41 {
+		t.Fatalf("平台状态不符: %+v", status.Platforms)
+	}
+	var pointTotal int64
+	for _, point := range status.Platforms[0].Points {
+		pointTotal += point.RequestCount
+	}
+	if pointTotal != 3 {
+		t.Fatalf("数据点请求数合计 = %d, 期望 3", pointTotal)
+	}
+
+	// 跨度不超过 48 小时按小时聚合，仅统计窗口内的汇总
+	from = now.Add(-11 * 24 * time.Hour)
+	to = from.Add(36 * time.Hour)
+	modelStatus, err := svc.GetModelStatus(ctx, StatusOptions{StartTime: &from, EndTime: &to})
+	if err != nil {
+		t.Fatalf("获取模型状态失败: %v", err)
+	}
+	if modelStatus.Granularity != "1h" || len(modelStatus.Models) != 1 || modelStatus.Models[0].TotalRequests != 1 || modelStatus.Models[0].Latency.Duration.Count != 1 {
+		t.Fatalf("模型状态不符: %s %+v", modelStatus.Granularity, modelStatus.Models)
+	}
+
+	// 结束时间早于最早的汇总时参数无效
+	to = now.Add(-50 * 24 * time.Hour)
+	if _, err := svc.GetModelStatus(ctx, StatusOptions{EndTime: &to}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("结束时间早于汇总数据时应返回参数错误: %v", err)
+	}
+}
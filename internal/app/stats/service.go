@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
 )
 
 // New 创建一个新的统计服务实例
@@ -26,20 +27,43 @@ func New(logger *slog.Logger) Service {
 // 参数：
 //   - logger: 日志记录器，用于记录服务运行状态和关键操作
 //   - collector: 实时数据采集器；为 nil 时实时统计能力不可用
+//   - opts: 可选配置，如请求日志保留策略
 //
 // 返回值：
 //   - Service: 统计服务实例
-func NewWithCollector(logger *slog.Logger, collector *Collector) Service {
+func NewWithCollector(logger *slog.Logger, collector *Collector, opts ...Option) Service {
 	if collector == nil {
 		logger.Warn("未显式提供采集器，实时统计能力不可用")
 	}
 
-	logger.Info("统计服务初始化完成")
-
-	return &service{
+	s := &service{
 		logger:    logger,
 		collector: collector,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	logger.Info("统计服务初始化完成", "raw_log_retention_days", s.retention.RawLogDays)
+
+	return s
+}
+
+// Option 定义统计服务的可选配置函数
+type Option func(*service)
+
+// WithRetention 设置请求日志保留策略
+func WithRetention(cfg RetentionConfig) Option {
+	return func(s *service) {
+		s.retention = cfg
+	}
+}
+
+// WithAuditRecorder 设置模型单价变更的审计记录器
+func WithAuditRecorder(recorder audit.Recorder) Option {
+	return func(s *service) {
+		s.auditRecorder = recorder
+	}
 }
 
 // Service 定义统计服务接口
@@ -47,8 +71,14 @@ type Service interface {
 	// GetDashboard 获取仪表盘所有数据（单次查询优化版本）
 	GetDashboard(ctx context.Context, trendRange TrendRange) (*DashboardResponse, error)
 
-	// GetModelStatus 获取模型状态监控数据
-	GetModelStatus(ctx context.Context, trendRange TrendRange, modelName *string) (*ModelStatusResponse, error)
+	// GetModelStatus 获取模型状态监控数据，支持预设时间范围或起止时间
+	GetModelStatus(ctx context.Context, opts StatusOptions) (*ModelStatusResponse, error)
+
+	// GetPlatformStatus 获取平台状态监控数据，可按模型过滤以对比同一模型在不同平台的表现
+	GetPlatformStatus(ctx context.Context, opts StatusOptions) (*PlatformStatusResponse, error)
+
+	// GetErrorAnalytics 获取错误分析数据，失败请求按结构化错误字段分组
+	GetErrorAnalytics(ctx context.Context, opts ErrorAnalyticsOptions) (*ErrorAnalyticsResponse, error)
 
 	// GetOverview 获取全局概览数据
 	//
@@ -58,9 +88,42 @@ type Service interface {
 	// GetRealtime 获取实时数据
 	GetRealtime(ctx context.Context) (*StatsRealtimeResponse, error)
 
+	// SubscribeLive 订阅实时请求事件（请求开始与完成）
+	SubscribeLive(ctx context.Context, filter LiveFilter) (*LiveSubscription, error)
+
 	// ListRequestLogs 获取请求状态列表
 	ListRequestLogs(ctx context.Context, opts ListRequestLogsOptions) ([]*types.RequestLog, int64, error)
 
+	// GetRequestLog 按请求 ID 或请求日志 ID 获取单条请求日志详情（包含路由详情）
+	GetRequestLog(ctx context.Context, ref string) (*RequestLogDetail, error)
+
+	// RunRequestLogRetention 将超过保留期的原始请求日志汇总为小时统计后清理
+	RunRequestLogRetention(ctx context.Context, taskID uint) (*RequestLogRetentionResult, error)
+
+	// BackfillRequestLogRollup 为尚未汇总的原始请求日志补建小时汇总，可与请求日志写入并发执行
+	BackfillRequestLogRollup(ctx context.Context) (int64, error)
+
+	// GetRequestLogRetention 获取请求日志保留策略的当前状态
+	GetRequestLogRetention(ctx context.Context) (*RequestLogRetentionStatus, error)
+
+	// GetAPIKeyUsage 获取按上游密钥汇总的用量与费用报表
+	GetAPIKeyUsage(ctx context.Context, opts APIKeyUsageOptions) (*APIKeyUsageResponse, error)
+
+	// ListModelPrices 获取模型单价列表
+	ListModelPrices(ctx context.Context, opts ListModelPricesOptions) ([]types.ModelPrice, error)
+
+	// CreateModelPrice 创建模型单价，新单价对之后写入的请求日志生效
+	CreateModelPrice(ctx context.Context, req ModelPriceRequest) (*types.ModelPrice, error)
+
+	// UpdateModelPrice 更新模型单价
+	UpdateModelPrice(ctx context.Context, id uint, req ModelPriceRequest) (*types.ModelPrice, error)
+
+	// DeleteModelPrice 删除模型单价
+	DeleteModelPrice(ctx context.Context, id uint) error
+
+	// RecomputeRequestLogCost 按当前单价重算时间范围内原始请求日志的费用并修正用量汇总
+	RecomputeRequestLogCost(ctx context.Context, taskID uint, req CostRecomputeRequest) (*CostRecomputeResult, error)
+
 	// GetModelCallRank 获取模型调用排名前 5
 	//
 	// Deprecated: 请改用 GetDashboard 获取统一仪表盘数据。
@@ -1,7 +1,13 @@
 package stats
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"gorm.io/gorm"
 )
 
 // trendRangeConfig 定义趋势范围配置
@@ -29,11 +35,138 @@ var trendRangeConfigs = map[TrendRange]trendRangeConfig{
 	},
 }
 
-// ceilToHour 将时间向上取整到整点（若已是整点则保持不变）
+// ceilToHour 将时间向上取整到整点（若已是整点则保持不变），结果统一为 UTC
 func ceilToHour(t time.Time) time.Time {
-	u := t
+	u := t.UTC()
 	if u.Minute() == 0 && u.Second() == 0 && u.Nanosecond() == 0 {
 		return u
 	}
 	return u.Truncate(time.Hour).Add(time.Hour)
 }
+
+// customRangeLabel 为按起止时间查询时响应中的时间范围标识
+const customRangeLabel = "custom"
+
+// customRangeGranularities 为按起止时间查询时可选的颗粒度，按跨度由小到大选择
+var customRangeGranularities = []struct {
+	MaxSpan     time.Duration
+	Granularity time.Duration
+	Label       string
+}{
+	{MaxSpan: 48 * time.Hour, Granularity: time.Hour, Label: "1h"},
+	{MaxSpan: 90 * 24 * time.Hour, Granularity: 24 * time.Hour, Label: "1d"},
+	{MaxSpan: 0, Granularity: 7 * 24 * time.Hour, Label: "7d"},
+}
+
+// statusWindow 为状态监控的时间窗口与颗粒度
+type statusWindow struct {
+	Range string
+	Start time.Time // 首个数据点的起始时间（含）
+	End   time.Time // 窗口结束时间（不含）
+	trendRangeConfig
+}
+
+// resolveStatusWindow 解析状态监控的时间窗口。
+//
+// 指定起止时间时，起点不早于最早的小时汇总、终点不晚于当前整点，颗粒度按跨度选择；
+// 否则按预设时间范围取截至当前的滑动窗口。
+func resolveStatusWindow(ctx context.Context, opts StatusOptions) (statusWindow, error) {
+	now := time.Now()
+	if opts.StartTime == nil && opts.EndTime == nil {
+		trendRange := opts.Range
+		if trendRange == "" {
+			trendRange = TrendRange24h
+		}
+		cfg, ok := trendRangeConfigs[trendRange]
+		if !ok {
+			return statusWindow{}, fmt.Errorf("无效的时间范围参数，可选值：24h, 7d, 30d：%w", ErrInvalidArgument)
+		}
+		end := ceilToHour(now)
+		return statusWindow{
+			Range:            string(trendRange),
+			Start:            end.Add(-cfg.Granularity * time.Duration(cfg.Points)),
+			End:              end,
+			trendRangeConfig: cfg,
+		}, nil
+	}
+
+	end := ceilToHour(now)
+	if opts.EndTime != nil && ceilToHour(*opts.EndTime).Before(end) {
+		end = ceilToHour(*opts.EndTime)
+	}
+
+	earliest, err := earliestHourlyStatBucket(ctx)
+	if err != nil {
+		return statusWindow{}, fmt.Errorf("查询最早的小时汇总失败：%w", err)
+	}
+	var start time.Time
+	switch {
+	case opts.StartTime != nil:
+		start = hourBucket(*opts.StartTime)
+	case earliest != nil:
+		start = *earliest
+	default:
+		start = end.Add(-24 * time.Hour)
+	}
+	if earliest != nil && start.Before(*earliest) {
+		start = *earliest
+	}
+	if !end.After(start) {
+		return statusWindow{}, fmt.Errorf("结束时间须晚于开始时间且不早于最早的汇总数据：%w", ErrInvalidArgument)
+	}
+
+	span := end.Sub(start)
+	choice := customRangeGranularities[len(customRangeGranularities)-1]
+	for _, candidate := range customRangeGranularities {
+		if candidate.MaxSpan > 0 && span <= candidate.MaxSpan {
+			choice = candidate
+			break
+		}
+	}
+	points := int((span + choice.Granularity - 1) / choice.Granularity)
+
+	return statusWindow{
+		Range: customRangeLabel,
+		Start: start,
+		End:   end,
+		trendRangeConfig: trendRangeConfig{
+			Granularity: choice.Granularity,
+			Points:      points,
+			Label:       choice.Label,
+		},
+	}, nil
+}
+
+// points 返回窗口内按颗粒度拆分的空数据点，时间戳为各数据点的结束时间
+func (w statusWindow) points() []ModelStatusPoint {
+	points := make([]ModelStatusPoint, w.Points)
+	for i := range points {
+		points[i] = ModelStatusPoint{
+			Timestamp: w.Start.Add(w.Granularity * time.Duration(i+1)),
+		}
+	}
+	return points
+}
+
+// pointIndex 返回小时汇总所属数据点的下标，超出窗口时返回 -1
+func (w statusWindow) pointIndex(bucketStart time.Time) int {
+	idx := int(bucketStart.Sub(w.Start) / w.Granularity)
+	if bucketStart.Before(w.Start) || idx >= w.Points {
+		return -1
+	}
+	return idx
+}
+
+// earliestHourlyStatBucket 返回最早的小时汇总时间，无汇总数据时返回 nil
+func earliestHourlyStatBucket(ctx context.Context) (*time.Time, error) {
+	var stat types.RequestLogHourlyStat
+	err := requestLogDB(ctx).Select("bucket_start").Order("bucket_start ASC").First(&stat).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	earliest := stat.BucketStart.UTC()
+	return &earliest, nil
+}
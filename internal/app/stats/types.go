@@ -3,12 +3,18 @@ package stats
 import (
 	"log/slog"
 	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/audit"
 )
 
 // service 是 ServiceInterface 接口的具体实现
 type service struct {
 	logger    *slog.Logger
 	collector *Collector
+	retention RetentionConfig
+
+	auditRecorder audit.Recorder // 模型单价变更审计记录器，为空时不记录
 }
 
 // StatsOverviewResponse 定义了全局概览数据的响应结构
@@ -25,6 +31,25 @@ type StatsOverviewResponse struct {
 type StatsRealtimeResponse struct {
 	RPM               int64 `json:"rpm"`                // 每分钟请求数
 	ActiveConnections int64 `json:"active_connections"` // 当前活动连接数
+	RealtimeBreakdown
+}
+
+// RealtimeBreakdown 定义按维度拆分的过去 1 分钟实时数据
+type RealtimeBreakdown struct {
+	Models    []RealtimeBreakdownItem `json:"models"`    // 按原始模型名称拆分
+	Platforms []RealtimeBreakdownItem `json:"platforms"` // 按平台拆分（请求完成后计入）
+	Clients   []RealtimeBreakdownItem `json:"clients"`   // 按客户端 IP 拆分（不含 TPM 与错误率）
+}
+
+// RealtimeBreakdownItem 定义单个维度取值的过去 1 分钟实时数据
+type RealtimeBreakdownItem struct {
+	Name       string  `json:"name"`                  // 模型名称、平台名称或客户端 IP
+	PlatformID uint    `json:"platform_id,omitempty"` // 平台 ID（仅平台维度）
+	RPM        int64   `json:"rpm"`                   // 每分钟请求数
+	TPM        int64   `json:"tpm"`                   // 每分钟 Token 数
+	InFlight   int64   `json:"in_flight"`             // 进行中的请求数
+	Finished   int64   `json:"finished"`              // 过去 1 分钟完成的请求数
+	ErrorRate  float64 `json:"error_rate"`            // 过去 1 分钟完成请求的错误率
 }
 
 // ModelCallRankItem 定义了模型调用排名项
@@ -45,20 +70,22 @@ type PlatformCallRankItem struct {
 
 // ModelUsageRankItem 定义了模型用量排名项
 type ModelUsageRankItem struct {
-	ModelName        string  `json:"model_name"`        // 模型名称
-	TotalTokens      int64   `json:"total_tokens"`      // 总 Token 数
-	PromptTokens     int64   `json:"prompt_tokens"`     // 输入 Token 数
-	CompletionTokens int64   `json:"completion_tokens"` // 输出 Token 数
-	Percentage       float64 `json:"percentage"`        // 占比
+	ModelName        string       `json:"model_name"`        // 模型名称
+	TotalTokens      int64        `json:"total_tokens"`      // 总 Token 数
+	PromptTokens     int64        `json:"prompt_tokens"`     // 输入 Token 数
+	CompletionTokens int64        `json:"completion_tokens"` // 输出 Token 数
+	Percentage       float64      `json:"percentage"`        // 占比
+	Costs            []CostAmount `json:"costs,omitempty"`   // 按币种的费用
 }
 
 // PlatformUsageRankItem 定义了平台用量排名项
 type PlatformUsageRankItem struct {
-	PlatformName     string  `json:"platform_name"`     // 平台名称
-	TotalTokens      int64   `json:"total_tokens"`      // 总 Token 数
-	PromptTokens     int64   `json:"prompt_tokens"`     // 输入 Token 数
-	CompletionTokens int64   `json:"completion_tokens"` // 输出 Token 数
-	Percentage       float64 `json:"percentage"`        // 占比
+	PlatformName     string       `json:"platform_name"`     // 平台名称
+	TotalTokens      int64        `json:"total_tokens"`      // 总 Token 数
+	PromptTokens     int64        `json:"prompt_tokens"`     // 输入 Token 数
+	CompletionTokens int64        `json:"completion_tokens"` // 输出 Token 数
+	Percentage       float64      `json:"percentage"`        // 占比
+	Costs            []CostAmount `json:"costs,omitempty"`   // 按币种的费用
 }
 
 // ModelCallRankResponse 定义了模型调用排名响应结构
@@ -120,6 +147,17 @@ type TrendResponse struct {
 	Summary     TrendSummary     `json:"summary"`     // 汇总统计
 }
 
+// StatusOptions 定义模型与平台状态监控的查询选项
+//
+// 指定 StartTime 或 EndTime 时按起止时间查询并忽略 Range，起止时间按整点对齐，
+// 起点不早于最早的小时汇总、终点不晚于当前时间，颗粒度按跨度自动选择（不超过 48 小时为 1h，不超过 90 天为 1d，否则为 7d）。
+type StatusOptions struct {
+	Range     TrendRange // 时间范围：24h/7d/30d
+	StartTime *time.Time // 开始时间
+	EndTime   *time.Time // 结束时间
+	ModelName *string    // 原始模型名称
+}
+
 // ModelStatusPoint 定义模型状态监控中的单个时间点
 type ModelStatusPoint struct {
 	Timestamp    time.Time `json:"timestamp"`     // 数据点时间戳
@@ -127,23 +165,117 @@ type ModelStatusPoint struct {
 	SuccessCount int64     `json:"success_count"` // 成功数
 }
 
+// LatencyPercentiles 定义延迟分位数（微秒，基于对数分桶近似，相对误差约 ±9%）
+type LatencyPercentiles struct {
+	Count int64   `json:"count"` // 样本数
+	P50   float64 `json:"p50"`   // 50 分位
+	P90   float64 `json:"p90"`   // 90 分位
+	P95   float64 `json:"p95"`   // 95 分位
+	P99   float64 `json:"p99"`   // 99 分位
+}
+
+// LatencyStats 定义总用时与首字用时的延迟分位数
+type LatencyStats struct {
+	Duration  LatencyPercentiles `json:"duration"`   // 总用时
+	FirstByte LatencyPercentiles `json:"first_byte"` // 首字用时（仅流式）
+}
+
 // ModelStatusItem 定义单个模型的状态统计
 type ModelStatusItem struct {
 	ModelName     string             `json:"model_name"`     // 模型名称
 	TotalRequests int64              `json:"total_requests"` // 总请求数
 	SuccessCount  int64              `json:"success_count"`  // 总成功数
+	Latency       LatencyStats       `json:"latency"`        // 延迟分位数
 	Points        []ModelStatusPoint `json:"points"`         // 按颗粒度拆分的数据点
 }
 
 // ModelStatusResponse 定义模型状态监控响应
 type ModelStatusResponse struct {
-	Range       string            `json:"range"`        // 时间范围
+	Range       string            `json:"range"`        // 时间范围，按起止时间查询时为 custom
 	Granularity string            `json:"granularity"`  // 颗粒度
 	WindowStart time.Time         `json:"window_start"` // 窗口开始时间
 	WindowEnd   time.Time         `json:"window_end"`   // 窗口结束时间
 	Models      []ModelStatusItem `json:"models"`       // 模型状态列表
 }
 
+// PlatformStatusItem 定义单个平台的状态统计
+type PlatformStatusItem struct {
+	PlatformID    uint               `json:"platform_id"`    // 平台 ID
+	PlatformName  string             `json:"platform_name"`  // 平台名称
+	TotalRequests int64              `json:"total_requests"` // 总请求数
+	SuccessCount  int64              `json:"success_count"`  // 总成功数
+	Latency       LatencyStats       `json:"latency"`        // 延迟分位数
+	Points        []ModelStatusPoint `json:"points"`         // 按颗粒度拆分的数据点
+}
+
+// PlatformStatusResponse 定义平台状态监控响应
+type PlatformStatusResponse struct {
+	Range       string               `json:"range"`                // 时间范围，按起止时间查询时为 custom
+	Granularity string               `json:"granularity"`          // 颗粒度
+	ModelName   string               `json:"model_name,omitempty"` // 模型过滤条件
+	WindowStart time.Time            `json:"window_start"`         // 窗口开始时间
+	WindowEnd   time.Time            `json:"window_end"`           // 窗口结束时间
+	Platforms   []PlatformStatusItem `json:"platforms"`            // 平台状态列表
+}
+
+// ErrorAnalyticsOptions 定义错误分析的筛选选项
+type ErrorAnalyticsOptions struct {
+	Range      TrendRange `json:"range"`                 // 时间范围：24h/7d/30d
+	ModelName  *string    `json:"model_name,omitempty"`  // 原始模型名称
+	PlatformID *uint      `json:"platform_id,omitempty"` // 平台 ID
+	APIKeyID   *uint      `json:"api_key_id,omitempty"`  // 密钥 ID
+}
+
+// ErrorDimensionItem 定义错误分组在单个维度（模型/平台/密钥）上的计数
+type ErrorDimensionItem struct {
+	ID    uint   `json:"id,omitempty"` // 平台或密钥 ID（模型维度为空）
+	Name  string `json:"name"`         // 名称
+	Count int64  `json:"count"`        // 失败次数
+}
+
+// ErrorSampleMessage 定义错误分组内的示例消息
+type ErrorSampleMessage struct {
+	Message   string `json:"message"`     // 错误消息（优先取上游错误消息）
+	Count     int64  `json:"count"`       // 出现次数
+	LastLogID uint   `json:"last_log_id"` // 最近一次出现的请求日志 ID
+}
+
+// ErrorTrendPoint 定义错误趋势数据点
+type ErrorTrendPoint struct {
+	Timestamp time.Time `json:"timestamp"` // 桶结束时间
+	Count     int64     `json:"count"`     // 失败次数
+}
+
+// ErrorGroupItem 定义按结构化错误字段聚合的错误分组
+type ErrorGroupItem struct {
+	ErrorCode         string               `json:"error_code"`          // 结构化错误码
+	ErrorFrom         string               `json:"error_from"`          // 错误来源
+	HTTPStatus        *int                 `json:"http_status"`         // 上游 HTTP 状态码
+	UpstreamErrorType string               `json:"upstream_error_type"` // 上游错误类型
+	UpstreamErrorCode string               `json:"upstream_error_code"` // 上游错误码
+	Count             int64                `json:"count"`               // 失败次数
+	Percentage        float64              `json:"percentage"`          // 占全部失败的百分比
+	FirstSeen         time.Time            `json:"first_seen"`          // 窗口内首次出现时间
+	LastSeen          time.Time            `json:"last_seen"`           // 窗口内最近出现时间
+	Models            []ErrorDimensionItem `json:"models"`              // 按模型拆分
+	Platforms         []ErrorDimensionItem `json:"platforms"`           // 按平台拆分
+	APIKeys           []ErrorDimensionItem `json:"api_keys"`            // 按密钥拆分
+	Points            []ErrorTrendPoint    `json:"points"`              // 按颗粒度拆分的趋势
+	Samples           []ErrorSampleMessage `json:"samples"`             // 出现最多的错误消息
+}
+
+// ErrorAnalyticsResponse 定义错误分析响应
+type ErrorAnalyticsResponse struct {
+	Range         string           `json:"range"`          // 时间范围
+	Granularity   string           `json:"granularity"`    // 颗粒度
+	WindowStart   time.Time        `json:"window_start"`   // 窗口开始时间
+	WindowEnd     time.Time        `json:"window_end"`     // 窗口结束时间
+	TotalRequests int64            `json:"total_requests"` // 窗口内请求总数（同筛选条件）
+	TotalErrors   int64            `json:"total_errors"`   // 窗口内失败总数
+	ErrorRate     float64          `json:"error_rate"`     // 失败率
+	Groups        []ErrorGroupItem `json:"groups"`         // 错误分组，按失败次数降序
+}
+
 // DashboardRequest 仪表盘数据请求参数
 type DashboardRequest struct {
 	Range TrendRange `json:"range"` // 时间范围：24h/7d/30d
@@ -159,14 +291,15 @@ type DashboardResponse struct {
 
 // DashboardOverview 仪表盘概览数据
 type DashboardOverview struct {
-	TotalRequests         int64   `json:"total_requests"`          // 总请求量
-	SuccessRate           float64 `json:"success_rate"`            // 成功率
-	AvgFirstByteTime      float64 `json:"avg_first_byte"`          // 平均首字时间（微秒）
-	ActiveModels          int     `json:"active_models"`           // 活跃模型数（时间范围内有请求的去重模型）
-	ActivePlatforms       int     `json:"active_platforms"`        // 活跃平台数（时间范围内有请求的去重平台）
-	TotalPromptTokens     int64   `json:"total_prompt_tokens"`     // 总输入 Token
-	TotalCompletionTokens int64   `json:"total_completion_tokens"` // 总输出 Token
-	TotalTokens           int64   `json:"total_tokens"`            // 总 Token
+	TotalRequests         int64        `json:"total_requests"`          // 总请求量
+	SuccessRate           float64      `json:"success_rate"`            // 成功率
+	AvgFirstByteTime      float64      `json:"avg_first_byte"`          // 平均首字时间（微秒）
+	ActiveModels          int          `json:"active_models"`           // 活跃模型数（时间范围内有请求的去重模型）
+	ActivePlatforms       int          `json:"active_platforms"`        // 活跃平台数（时间范围内有请求的去重平台）
+	TotalPromptTokens     int64        `json:"total_prompt_tokens"`     // 总输入 Token
+	TotalCompletionTokens int64        `json:"total_completion_tokens"` // 总输出 Token
+	TotalTokens           int64        `json:"total_tokens"`            // 总 Token
+	TotalCosts            []CostAmount `json:"total_costs"`             // 按币种的费用合计
 }
 
 // DashboardRanks 仪表盘排名数据
@@ -190,3 +323,121 @@ type ListRequestLogsOptions struct {
 	Page       int        `json:"page"`
 	PageSize   int        `json:"page_size"`
 }
+
+// RequestLogDetail 定义了单条请求日志的详情响应结构
+type RequestLogDetail struct {
+	*types.RequestLog
+	Routing RequestLogRouting `json:"routing"` // 路由详情
+}
+
+// RequestLogRouting 定义了请求日志关联的路由详情
+//
+// 关联资源可能已被删除，此时对应字段为空。
+type RequestLogRouting struct {
+	PlatformName    string `json:"platform_name,omitempty"`     // 平台名称
+	PlatformBaseURL string `json:"platform_base_url,omitempty"` // 平台基础 URL
+	ModelAlias      string `json:"model_alias,omitempty"`       // 模型别名
+	APIKeyExists    bool   `json:"api_key_exists"`              // 使用的密钥是否仍存在
+}
+
+// RetentionConfig 定义请求日志保留策略配置
+type RetentionConfig struct {
+	RawLogDays int // 原始请求日志保留天数，小于等于 0 表示永久保留
+}
+
+// RequestLogRetentionResult 定义单次请求日志保留任务的执行结果
+type RequestLogRetentionResult struct {
+	Cutoff         time.Time `json:"cutoff"`           // 截止时间，早于该时间的原始日志被清理
+	HourlyStatRows int64     `json:"hourly_stat_rows"` // 写入的小时汇总记录数
+	PurgedLogs     int64     `json:"purged_logs"`      // 清理的原始日志数
+}
+
+// RequestLogRollupBackfillResult 定义小时汇总补建任务的执行结果
+type RequestLogRollupBackfillResult struct {
+	HourlyStatRows int64 `json:"hourly_stat_rows"` // 写入的小时汇总记录数
+}
+
+// RequestLogRetentionRun 定义最近一次请求日志保留任务的运行记录
+type RequestLogRetentionRun struct {
+	TaskID     uint                       `json:"task_id"`          // 任务 ID
+	StartedAt  time.Time                  `json:"started_at"`       // 开始时间
+	FinishedAt time.Time                  `json:"finished_at"`      // 结束时间
+	Result     *RequestLogRetentionResult `json:"result,omitempty"` // 执行结果
+	Error      string                     `json:"error,omitempty"`  // 失败原因
+}
+
+// RequestLogRetentionStatus 定义请求日志保留策略的状态响应
+type RequestLogRetentionStatus struct {
+	Enabled        bool                    `json:"enabled"`                     // 是否启用
+	RawLogDays     int                     `json:"raw_log_days"`                // 原始日志保留天数
+	Cutoff         *time.Time              `json:"cutoff,omitempty"`            // 当前截止时间
+	OldestRawLogAt *time.Time              `json:"oldest_raw_log_at,omitempty"` // 最早的原始日志时间
+	HourlyStatRows int64                   `json:"hourly_stat_rows"`            // 小时汇总记录数
+	LastRun        *RequestLogRetentionRun `json:"last_run,omitempty"`          // 最近一次结束的保留任务（来自任务表）
+}
+
+// CostAmount 定义单一币种的费用合计
+type CostAmount struct {
+	Currency string  `json:"currency"` // 币种
+	Amount   float64 `json:"amount"`   // 金额
+}
+
+// ModelPriceRequest 定义创建或更新模型单价的请求体，单价以每百万 Token 计
+type ModelPriceRequest struct {
+	PlatformID    uint       `json:"platform_id"`              // 平台 ID，0 表示全部平台
+	ModelName     string     `json:"model_name"`               // 模型名称
+	Currency      string     `json:"currency"`                 // 币种，默认 USD
+	InputPrice    float64    `json:"input_price"`              // 输入单价
+	OutputPrice   float64    `json:"output_price"`             // 输出单价
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"` // 生效时间，未指定时自始生效
+}
+
+// ListModelPricesOptions 定义模型单价列表的筛选选项
+type ListModelPricesOptions struct {
+	PlatformID *uint   // 平台 ID
+	ModelName  *string // 模型名称
+}
+
+// CostRecomputeRequest 定义重算请求费用的时间范围
+type CostRecomputeRequest struct {
+	StartTime time.Time `json:"start_time"` // 起始时间
+	EndTime   time.Time `json:"end_time"`   // 结束时间
+}
+
+// CostRecomputeResult 定义请求费用重算任务的执行结果
+type CostRecomputeResult struct {
+	From        time.Time `json:"from"`         // 实际重算起点（不早于最早的原始日志）
+	To          time.Time `json:"to"`           // 实际重算终点
+	ScannedLogs int64     `json:"scanned_logs"` // 扫描的原始日志数
+	UpdatedLogs int64     `json:"updated_logs"` // 费用发生变化的日志数
+	PricedLogs  int64     `json:"priced_logs"`  // 重算后有费用的日志数
+}
+
+// APIKeyUsageOptions 定义按密钥用量报表的筛选选项
+type APIKeyUsageOptions struct {
+	Range      TrendRange // 时间范围：24h/7d/30d
+	PlatformID *uint      // 平台 ID
+	ModelName  *string    // 原始模型名称
+}
+
+// APIKeyUsageItem 定义单个密钥的用量与费用
+type APIKeyUsageItem struct {
+	APIKeyID         uint         `json:"api_key_id"`        // 密钥 ID
+	PlatformID       uint         `json:"platform_id"`       // 平台 ID
+	PlatformName     string       `json:"platform_name"`     // 平台名称
+	RequestCount     int64        `json:"request_count"`     // 请求数
+	UnpricedRequests int64        `json:"unpriced_requests"` // 未计费的请求数（无匹配单价或无 Token 统计）
+	PromptTokens     int64        `json:"prompt_tokens"`     // 输入 Token 数
+	CompletionTokens int64        `json:"completion_tokens"` // 输出 Token 数
+	TotalTokens      int64        `json:"total_tokens"`      // 总 Token 数
+	Costs            []CostAmount `json:"costs"`             // 按币种的费用
+}
+
+// APIKeyUsageResponse 定义按密钥用量报表的响应结构
+type APIKeyUsageResponse struct {
+	Range      string            `json:"range"`       // 时间范围
+	StartTime  time.Time         `json:"start_time"`  // 统计起始时间
+	EndTime    time.Time         `json:"end_time"`    // 统计结束时间
+	TotalCosts []CostAmount      `json:"total_costs"` // 按币种的费用合计
+	Items      []APIKeyUsageItem `json:"items"`       // 按请求数降序的密钥用量
+}
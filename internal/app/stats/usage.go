@@ -0,0 +1,198 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/types"
+)
+
+// usageCostRow 定义按模型、平台与币种汇总的费用行
+type usageCostRow struct {
+	ModelName  string  `gorm:"column:model_name"`
+	PlatformID uint    `gorm:"column:platform_id"`
+	Currency   string  `gorm:"column:currency"`
+	Cost       float64 `gorm:"column:cost"`
+}
+
+// loadUsageCosts 读取 [from, to) 内按模型、平台与币种汇总的费用；to 为零值时不限制结束时间
+func loadUsageCosts(ctx context.Context, from, to time.Time) ([]usageCostRow, error) {
+	db := requestLogDB(ctx).Model(&types.RequestLogHourlyUsage{}).
+		Select("model_name, platform_id, currency, SUM(cost) AS cost").
+		Where("bucket_start >= ? AND currency <> ''", from)
+	if !to.IsZero() {
+		db = db.Where("bucket_start < ?", to)
+	}
+
+	var rows []usageCostRow
+	if err := db.Group("model_name, platform_id, currency").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// usageCostIndex 为按模型与平台索引的分币种费用
+type usageCostIndex struct {
+	total     map[string]float64
+	models    map[string]map[string]float64
+	platforms map[uint]map[string]float64
+}
+
+// indexUsageCosts 按模型（空名称归为 unknown）与平台汇总费用
+func indexUsageCosts(rows []usageCostRow) usageCostIndex {
+	index := usageCostIndex{
+		total:     make(map[string]float64),
+		models:    make(map[string]map[string]float64),
+		platforms: make(map[uint]map[string]float64),
+	}
+
+	for _, row := range rows {
+		modelName := row.ModelName
+		if modelName == "" {
+			modelName = "unknown"
+		}
+
+		index.total[row.Currency] += row.Cost
+
+		if index.models[modelName] == nil {
+			index.models[modelName] = make(map[string]float64)
+		}
+		index.models[modelName][row.Currency] += row.Cost
+
+		if index.platforms[row.PlatformID] == nil {
+			index.platforms[row.PlatformID] = make(map[string]float64)
+		}
+		index.platforms[row.PlatformID][row.Currency] += row.Cost
+	}
+
+	return index
+}
+
+// apiKeyUsageRow 定义按密钥、平台与币种汇总的用量行
+type apiKeyUsageRow struct {
+	APIKeyID         uint    `gorm:"column:api_key_id"`
+	PlatformID       uint    `gorm:"column:platform_id"`
+	Currency         string  `gorm:"column:currency"`
+	RequestCount     int64   `gorm:"column:request_count"`
+	PromptTokens     int64   `gorm:"column:prompt_tokens"`
+	CompletionTokens int64   `gorm:"column:completion_tokens"`
+	TotalTokens      int64   `gorm:"column:total_tokens"`
+	Cost             float64 `gorm:"column:cost"`
+}
+
+type apiKeyUsageKey struct {
+	APIKeyID   uint
+	PlatformID uint
+}
+
+// GetAPIKeyUsage 获取按上游密钥汇总的用量与费用报表。
+//
+// 数据读取自小时用量汇总，不扫描原始请求日志。
+func (s *service) GetAPIKeyUsage(ctx context.Context, opts APIKeyUsageOptions) (*APIKeyUsageResponse, error) {
+	start := time.Now()
+	logger := s.logger.With("operation", "get_api_key_usage")
+
+	trendRange := opts.Range
+	if trendRange == "" {
+		trendRange = TrendRange24h
+	}
+
+	cfg, ok := trendRangeConfigs[trendRange]
+	if !ok {
+		return nil, fmt.Errorf("无效的时间范围参数，可选值：24h, 7d, 30d")
+	}
+
+	bucketEnd := ceilToHour(time.Now())
+	bucketStart := bucketEnd.Add(-cfg.Granularity * time.Duration(cfg.Points))
+
+	db := requestLogDB(ctx).Model(&types.RequestLogHourlyUsage{}).
+		Select("api_key_id, platform_id, currency, SUM(request_count) AS request_count, SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, SUM(total_tokens) AS total_tokens, SUM(cost) AS cost").
+		Where("bucket_start >= ? AND bucket_start < ?", bucketStart, bucketEnd)
+	if opts.PlatformID != nil {
+		db = db.Where("platform_id = ?", *opts.PlatformID)
+	}
+	if opts.ModelName != nil && *opts.ModelName != "" {
+		db = db.Where("model_name = ?", *opts.ModelName)
+	}
+
+	var rows []apiKeyUsageRow
+	if err := db.Group("api_key_id, platform_id, currency").Scan(&rows).Error; err != nil {
+		logger.ErrorContext(ctx, "查询密钥用量汇总失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("查询密钥用量失败：%w", err)
+	}
+
+	platformNameMap, err := s.loadPlatformNameMap(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "加载平台名称映射失败",
+			"error", err,
+			"error_type", "database_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return nil, fmt.Errorf("加载平台名称失败：%w", err)
+	}
+
+	items := make(map[apiKeyUsageKey]*APIKeyUsageItem)
+	itemCosts := make(map[apiKeyUsageKey]map[string]float64)
+	totalCosts := make(map[string]float64)
+	for _, row := range rows {
+		key := apiKeyUsageKey{APIKeyID: row.APIKeyID, PlatformID: row.PlatformID}
+		item, exists := items[key]
+		if !exists {
+			platformName := platformNameMap[row.PlatformID]
+			if platformName == "" {
+				platformName = fmt.Sprintf("平台#%d", row.PlatformID)
+			}
+			item = &APIKeyUsageItem{
+				APIKeyID:     row.APIKeyID,
+				PlatformID:   row.PlatformID,
+				PlatformName: platformName,
+			}
+			items[key] = item
+			itemCosts[key] = make(map[string]float64)
+		}
+
+		item.RequestCount += row.RequestCount
+		item.PromptTokens += row.PromptTokens
+		item.CompletionTokens += row.CompletionTokens
+		item.TotalTokens += row.TotalTokens
+		if row.Currency == "" {
+			item.UnpricedRequests += row.RequestCount
+			continue
+		}
+		itemCosts[key][row.Currency] += row.Cost
+		totalCosts[row.Currency] += row.Cost
+	}
+
+	resp := &APIKeyUsageResponse{
+		Range:      string(trendRange),
+		StartTime:  bucketStart,
+		EndTime:    bucketEnd,
+		TotalCosts: costsFromMap(totalCosts),
+		Items:      make([]APIKeyUsageItem, 0, len(items)),
+	}
+	for key, item := range items {
+		item.Costs = costsFromMap(itemCosts[key])
+		resp.Items = append(resp.Items, *item)
+	}
+	sort.Slice(resp.Items, func(i, j int) bool {
+		if resp.Items[i].RequestCount == resp.Items[j].RequestCount {
+			return resp.Items[i].APIKeyID < resp.Items[j].APIKeyID
+		}
+		return resp.Items[i].RequestCount > resp.Items[j].RequestCount
+	})
+
+	logger.DebugContext(ctx, "成功聚合密钥用量",
+		"range", trendRange,
+		"key_count", len(resp.Items),
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	return resp, nil
+}
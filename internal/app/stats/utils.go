@@ -47,7 +47,7 @@ func (s *service) calculateAvgFirstByteTimeWithPercentile(ctx context.Context, l
 	err := r.WithContext(ctx).
 		Select(r.FirstByteTime).
 		Where(r.FirstByteTime.IsNotNull()).
-		Where(r.Timestamp.Gte(time.Now().Add(-24 * time.Hour))).
+		Where(r.Timestamp.Gte(time.Now().UTC().Add(-24 * time.Hour))).
 		Scan(&firstByteTimes)
 	if err != nil {
 		s.logger.ErrorContext(ctx, "获取首字时间数据失败", "error", err)
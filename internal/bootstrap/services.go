@@ -2,10 +2,18 @@ package bootstrap
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/alert"
+	"github.com/MeowSalty/pinai/internal/app/audit"
 	"github.com/MeowSalty/pinai/internal/app/gateway"
 	"github.com/MeowSalty/pinai/internal/app/health"
+	"github.com/MeowSalty/pinai/internal/app/probe"
 	"github.com/MeowSalty/pinai/internal/app/provider"
 	"github.com/MeowSalty/pinai/internal/app/stats"
 	"github.com/MeowSalty/pinai/internal/infra/portal"
@@ -13,6 +21,8 @@ import (
 
 // Services 持有启动阶段装配得到的服务实例。
 type Services struct {
+	AlertService    alert.Service
+	AuditService    audit.Service
 	HealthService   health.Service
 	GatewayService  gateway.Service
 	ProviderService provider.Service
@@ -20,22 +30,71 @@ type Services struct {
 	StatsCollector  *stats.Collector
 }
 
+// Options 定义服务装配所需的配置。
+type Options struct {
+	ModelMapping            string // 模型映射规则
+	RequestLogRetentionDays int    // 原始请求日志保留天数，0 表示永久保留
+
+	HealthProbeInterval        time.Duration // 主动健康探测周期，0 表示不自动探测
+	HealthProbeModels          bool          // 主动探测是否包含模型补全请求
+	HealthSyncInterval         time.Duration // 多实例健康状态同步周期，0 表示不同步
+	HealthHistoryRetentionDays int           // 健康状态变化历史保留天数，0 表示永久保留
+
+	AlertDeliveryRetentionDays int // 告警投递记录保留天数，0 表示永久保留
+
+	ProvidersFile     string        // 声明式平台配置文件路径，为空表示不启用
+	ModelSyncInterval time.Duration // 上游模型列表自动同步周期，0 表示不同步
+
+	TaskConcurrency   int // 同时执行的异步任务数量
+	TaskRetentionDays int // 已结束异步任务的保留天数，0 表示永久保留
+}
+
+// requestLogRetentionInterval 为请求日志保留任务的执行周期。
+const requestLogRetentionInterval = time.Hour
+
+// healthHistoryRetentionInterval 为健康状态变化历史清理任务的执行周期。
+const healthHistoryRetentionInterval = 24 * time.Hour
+
+// alertDeliveryRetentionInterval 为告警投递记录清理任务的执行周期。
+const alertDeliveryRetentionInterval = 24 * time.Hour
+
+// taskRetentionInterval 为已结束异步任务清理任务的执行周期。
+const taskRetentionInterval = 24 * time.Hour
+
 // NewServices 初始化应用所需服务并返回聚合结果。
-func NewServices(ctx context.Context, logger *slog.Logger, modelMapping string) (*Services, error) {
+func NewServices(ctx context.Context, logger *slog.Logger, opts Options) (*Services, error) {
 	// 初始化共享健康存储
 	healthStorage, err := health.NewStorage(ctx, logger.WithGroup("health_storage"))
 	if err != nil {
 		return nil, err
 	}
 
+	// 多副本部署时轮询其他实例的健康变更，使各实例缓存在数秒内收敛
+	if err := healthStorage.StartSync(ctx, opts.HealthSyncInterval); err != nil {
+		return nil, err
+	}
+
+	// 初始化审计服务，控制面变更持久化到审计表
+	auditService := audit.New(logger.WithGroup("audit"))
+
 	// 基于共享存储初始化健康服务
-	healthService, err := health.NewService(healthStorage, logger.WithGroup("health"))
+	healthService, err := health.NewService(healthStorage, logger.WithGroup("health"), health.WithAuditRecorder(auditService))
 	if err != nil {
 		return nil, err
 	}
 
-	// 使用共享的 Storage 创建 Portal 服务
-	portalService, err := portal.New(ctx, logger.WithGroup("portal"), modelMapping, healthStorage)
+	// 创建统计采集器，同时作为请求日志观察者推送实时事件
+	statsLogger := logger.WithGroup("stats")
+	statsCollector := stats.NewCollector(statsLogger.WithGroup("collector"))
+
+	// 告警服务观察健康状态变化与请求日志
+	alertService := alert.New(logger.WithGroup("alert"), alert.WithAuditRecorder(auditService))
+	healthStorage.AddObserver(alertService)
+	healthStorage.AddKeyDisabledObserver(alertService)
+
+	// 使用共享的 Storage 创建 Portal 服务，健康存储据请求日志中的上游错误永久禁用密钥
+	logObservers := requestLogObservers{statsCollector, alertService, healthStorage}
+	portalService, err := portal.New(ctx, logger.WithGroup("portal"), opts.ModelMapping, healthStorage, logObservers)
 	if err != nil {
 		return nil, err
 	}
@@ -43,18 +102,132 @@ func NewServices(ctx context.Context, logger *slog.Logger, modelMapping string)
 	// 初始化网关应用服务
 	gatewayService := gateway.New(portalService, logger.WithGroup("gateway_app"))
 
+	// 初始化统计服务（主路径：装配阶段显式创建并注入采集器）
+	statsService := stats.NewWithCollector(statsLogger, statsCollector, stats.WithRetention(stats.RetentionConfig{
+		RawLogDays: opts.RequestLogRetentionDays,
+	}), stats.WithAuditRecorder(auditService))
+
+	// 主动探测服务：周期探测健康状态，并为密钥创建与测试提供上游校验
+	probeService := probe.New(probe.Config{
+		Interval:    opts.HealthProbeInterval,
+		ProbeModels: opts.HealthProbeModels,
+	}, healthStorage, logger.WithGroup("probe"))
+
 	// 初始化供应商服务
-	providerService := provider.New(logger.WithGroup("provider"), healthStorage)
+	providerService := provider.New(logger.WithGroup("provider"), healthStorage,
+		provider.WithControlAuditLogger(controlAuditRecorder{auditService: auditService}),
+		provider.WithKeyValidator(keyValidator{probeService: probeService}),
+		provider.WithModelLister(probeService),
+		provider.WithTaskConcurrency(opts.TaskConcurrency))
+
+	// 声明式配置文件中的平台在启动时同步，文件变更后自动重新同步
+	if err := providerService.StartProvidersFileSync(ctx, opts.ProvidersFile); err != nil {
+		return nil, err
+	}
+
+	// 补建历史请求日志的小时汇总：以异步任务执行，任务租约保证多实例同一时间只有一个实例补建
+	if err := providerService.RegisterTaskHandler(types.TaskTypeRequestLogRollupBackfill, func(ctx context.Context, _ *types.ModelBatchTask) (any, error) {
+		rows, err := statsService.BackfillRequestLogRollup(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &stats.RequestLogRollupBackfillResult{HourlyStatRows: rows}, nil
+	}, provider.WithTaskMaxAttempts(3)); err != nil {
+		return nil, err
+	}
+	if _, err := providerService.EnqueueTask(ctx, types.TaskTypeRequestLogRollupBackfill, nil); err != nil {
+		statsLogger.Error("提交请求日志小时汇总补建任务失败，仪表盘历史数据可能不完整", "error", err)
+	}
+
+	// 请求日志保留任务复用模型批量任务运行时，分段覆盖写入可安全重试
+	if opts.RequestLogRetentionDays > 0 {
+		if err := providerService.RegisterTaskHandler(types.TaskTypeRequestLogRetention, func(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+			return statsService.RunRequestLogRetention(ctx, task.ID)
+		}, provider.WithTaskMaxAttempts(3)); err != nil {
+			return nil, err
+		}
+		if err := providerService.SchedulePeriodicTask(types.TaskTypeRequestLogRetention, requestLogRetentionInterval, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// 请求费用重算任务按需提交，修改模型单价后用于重算历史日志
+	if err := providerService.RegisterTaskHandler(types.TaskTypeRequestLogCostRecompute, func(ctx context.Context, task *types.ModelBatchTask) (any, error) {
+		var req stats.CostRecomputeRequest
+		if err := json.Unmarshal([]byte(task.Payload), &req); err != nil {
+			return nil, fmt.Errorf("解析费用重算任务载荷失败：%w", err)
+		}
+		return statsService.RecomputeRequestLogCost(ctx, task.ID, req)
+	}, provider.WithTaskMaxAttempts(3)); err != nil {
+		return nil, err
+	}
+
+	// 主动健康探测任务直接请求上游，不写入请求日志与统计
+	if err := providerService.RegisterTaskHandler(types.TaskTypeHealthProbe, func(ctx context.Context, _ *types.ModelBatchTask) (any, error) {
+		return probeService.RunOnce(ctx)
+	}); err != nil {
+		return nil, err
+	}
+	if opts.HealthProbeInterval > 0 {
+		if err := providerService.SchedulePeriodicTask(types.TaskTypeHealthProbe, opts.HealthProbeInterval, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// 健康状态变化历史按保留天数清理，删除幂等可安全重试
+	if opts.HealthHistoryRetentionDays > 0 {
+		if err := providerService.RegisterTaskHandler(types.TaskTypeHealthHistoryRetention, func(ctx context.Context, _ *types.ModelBatchTask) (any, error) {
+			return healthService.PruneHistory(ctx, time.Now().AddDate(0, 0, -opts.HealthHistoryRetentionDays))
+		}, provider.WithTaskMaxAttempts(3)); err != nil {
+			return nil, err
+		}
+		if err := providerService.SchedulePeriodicTask(types.TaskTypeHealthHistoryRetention, healthHistoryRetentionInterval, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// 告警投递记录按保留天数清理，删除幂等可安全重试
+	if opts.AlertDeliveryRetentionDays > 0 {
+		if err := providerService.RegisterTaskHandler(types.TaskTypeAlertDeliveryRetention, func(ctx context.Context, _ *types.ModelBatchTask) (any, error) {
+			return alertService.PruneDeliveries(ctx, time.Now().AddDate(0, 0, -opts.AlertDeliveryRetentionDays))
+		}, provider.WithTaskMaxAttempts(3)); err != nil {
+			return nil, err
+		}
+		if err := providerService.SchedulePeriodicTask(types.TaskTypeAlertDeliveryRetention, alertDeliveryRetentionInterval, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// 已结束的异步任务按保留天数清理，周期任务产生的记录不会无限增长
+	if opts.TaskRetentionDays > 0 {
+		if err := providerService.RegisterTaskHandler(types.TaskTypeTaskRetention, func(ctx context.Context, _ *types.ModelBatchTask) (any, error) {
+			return providerService.PruneTasks(ctx, time.Now().AddDate(0, 0, -opts.TaskRetentionDays))
+		}, provider.WithTaskMaxAttempts(3)); err != nil {
+			return nil, err
+		}
+		if err := providerService.SchedulePeriodicTask(types.TaskTypeTaskRetention, taskRetentionInterval, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// 上游模型列表自动同步，仅同步开启自动同步的平台
+	if opts.ModelSyncInterval > 0 {
+		if err := providerService.SchedulePeriodicTask(types.ModelBatchTaskTypeSync, opts.ModelSyncInterval, nil); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := providerService.StartModelBatchTaskWorker(ctx); err != nil {
 		return nil, err
 	}
 
-	// 初始化统计服务（主路径：装配阶段显式创建并注入采集器）
-	statsLogger := logger.WithGroup("stats")
-	statsCollector := stats.NewCollector(statsLogger.WithGroup("collector"))
-	statsService := stats.NewWithCollector(statsLogger, statsCollector)
+	if err := alertService.Start(ctx); err != nil {
+		return nil, err
+	}
 
 	return &Services{
+		AlertService:    alertService,
+		AuditService:    auditService,
 		HealthService:   healthService,
 		GatewayService:  gatewayService,
 		ProviderService: providerService,
@@ -62,3 +235,63 @@ func NewServices(ctx context.Context, logger *slog.Logger, modelMapping string)
 		StatsCollector:  statsCollector,
 	}, nil
 }
+
+// requestLogObservers 将请求日志依次分发给多个观察者。
+type requestLogObservers []portal.RequestLogObserver
+
+// ObserveRequestLog 实现 portal.RequestLogObserver 接口。
+func (o requestLogObservers) ObserveRequestLog(log *types.RequestLog) {
+	for _, observer := range o {
+		observer.ObserveRequestLog(log)
+	}
+}
+
+// ObserveRequestRoute 将路由通知分发给实现了 portal.RequestRouteObserver 的观察者。
+func (o requestLogObservers) ObserveRequestRoute(info stats.RequestInfo) {
+	for _, observer := range o {
+		if routeObserver, ok := observer.(portal.RequestRouteObserver); ok {
+			routeObserver.ObserveRequestRoute(info)
+		}
+	}
+}
+
+// controlAuditRecorder 将供应商控制面审计事件写入审计服务。
+type controlAuditRecorder struct {
+	auditService audit.Service
+}
+
+// Log 实现 provider.ControlAuditLogger 接口。
+func (r controlAuditRecorder) Log(ctx context.Context, event provider.ControlAuditEvent) error {
+	resourceID := ""
+	if event.ResourceID != 0 {
+		resourceID = strconv.FormatUint(uint64(event.ResourceID), 10)
+	}
+
+	return r.auditService.Record(ctx, audit.Entry{
+		Action:     event.Action,
+		Resource:   event.Resource,
+		ResourceID: resourceID,
+		Result:     event.Result,
+		Detail:     event.Detail,
+		Before:     event.Before,
+		After:      event.After,
+	})
+}
+
+// keyValidator 使用主动探测服务向上游校验密钥。
+type keyValidator struct {
+	probeService probe.Service
+}
+
+// ValidateKey 实现 provider.KeyValidator 接口。
+func (v keyValidator) ValidateKey(ctx context.Context, platform *types.Platform, keyValue string) provider.KeyValidationResult {
+	result := v.probeService.CheckKey(ctx, platform, keyValue)
+	return provider.KeyValidationResult{
+		Success:      result.Success,
+		LatencyMs:    result.LatencyMs,
+		EndpointType: result.EndpointType,
+		HTTPStatus:   result.HTTPStatus,
+		ErrorCode:    result.ErrorCode,
+		ErrorMessage: result.ErrorMessage,
+	}
+}
@@ -0,0 +1,270 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/handlers/query"
+	"github.com/MeowSalty/pinai/internal/app/alert"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+)
+
+// Handler 告警处理器结构体
+type Handler struct {
+	alertService alert.Service
+	logger       *slog.Logger
+}
+
+// NewHandler 创建告警处理器实例
+func NewHandler(alertService alert.Service, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Handler{
+		alertService: alertService,
+		logger:       logger.With("component", "alert_handler"),
+	}
+}
+
+// ListRules godoc
+// @Summary      获取告警规则列表
+// @Tags         alerts
+// @Produce      json
+// @Success      200  {array}   types.AlertRule         "告警规则列表"
+// @Failure      500  {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/alerts/rules [get]
+func (h *Handler) ListRules(c *gin.Context) {
+	rules, err := h.alertService.ListRules(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "获取告警规则列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule godoc
+// @Summary      创建告警规则
+// @Description  支持资源不可用、密钥 401、模型错误率超阈值与额度耗尽四类规则，告警以 JSON 或 Slack/飞书/钉钉格式发送到 Webhook
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Param        request  body      alert.RuleRequest       true  "告警规则"
+// @Success      201      {object}  types.AlertRule         "创建的告警规则"
+// @Failure      400      {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      500      {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/alerts/rules [post]
+func (h *Handler) CreateRule(c *gin.Context) {
+	var req alert.RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	rule, err := h.alertService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		h.respondError(c, err, "创建告警规则失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetRule godoc
+// @Summary      获取告警规则
+// @Tags         alerts
+// @Produce      json
+// @Param        ruleId  path      int                     true  "规则 ID"
+// @Success      200     {object}  types.AlertRule         "告警规则"
+// @Failure      400     {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      404     {object}  response.ErrorResponse  "告警规则未找到"
+// @Failure      500     {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/alerts/rules/{ruleId} [get]
+func (h *Handler) GetRule(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.alertService.GetRule(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, err, "获取告警规则失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule godoc
+// @Summary      更新告警规则
+// @Description  整体替换规则内容，修改后该规则的冷却记录被清除
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Param        ruleId   path      int                     true  "规则 ID"
+// @Param        request  body      alert.RuleRequest       true  "告警规则"
+// @Success      200      {object}  types.AlertRule         "更新后的告警规则"
+// @Failure      400      {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      404      {object}  response.ErrorResponse  "告警规则未找到"
+// @Failure      500      {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/alerts/rules/{ruleId} [put]
+func (h *Handler) UpdateRule(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	var req alert.RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	rule, err := h.alertService.UpdateRule(c.Request.Context(), id, req)
+	if err != nil {
+		h.respondError(c, err, "更新告警规则失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule godoc
+// @Summary      删除告警规则
+// @Description  删除告警规则，已有投递记录保留
+// @Tags         alerts
+// @Param        ruleId  path  int  true  "规则 ID"
+// @Success      204  "删除成功"
+// @Failure      400  {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      404  {object}  response.ErrorResponse  "告警规则未找到"
+// @Failure      500  {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/alerts/rules/{ruleId} [delete]
+func (h *Handler) DeleteRule(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.alertService.DeleteRule(c.Request.Context(), id); err != nil {
+		h.respondError(c, err, "删除告警规则失败")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TestRule godoc
+// @Summary      发送测试告警
+// @Description  立即向规则的 Webhook 发送一条测试告警并返回投递记录，不受冷却时间限制
+// @Tags         alerts
+// @Produce      json
+// @Param        ruleId  path      int                     true  "规则 ID"
+// @Success      200     {object}  types.AlertDelivery     "投递记录"
+// @Failure      400     {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      404     {object}  response.ErrorResponse  "告警规则未找到"
+// @Failure      500     {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/alerts/rules/{ruleId}/test [post]
+func (h *Handler) TestRule(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.alertService.TestRule(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, err, "发送测试告警失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// ListDeliveries godoc
+// @Summary      获取告警投递记录
+// @Tags         alerts
+// @Produce      json
+// @Param        rule_id    query     int     false  "规则 ID"
+// @Param        status     query     string  false  "投递状态"  Enums(succeeded, failed)
+// @Param        page       query     int     false  "页码"  default(1)
+// @Param        page_size  query     int     false  "每页大小"  default(10)
+// @Success      200        {object}  alert.DeliveryListResponse
+// @Failure      400        {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      500        {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/alerts/deliveries [get]
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	logger := h.logger.With(
+		"operation", "list_deliveries",
+		"method", c.Request.Method,
+		"path", c.FullPath(),
+	)
+
+	page, pageSize, err := query.Pagination(c)
+	if err != nil {
+		logger.Warn("分页参数解析失败",
+			"page_raw", c.Query("page"),
+			"page_size_raw", c.Query("page_size"),
+			"error", err)
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	ruleID, err := query.OptionalUint(c, "rule_id")
+	if err != nil {
+		logger.Warn("规则 ID 参数解析失败", "error", err)
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", types.AlertDeliveryStatusSucceeded, types.AlertDeliveryStatusFailed:
+	default:
+		response.BadRequest(c, "无效的投递状态，可选值：succeeded, failed")
+		return
+	}
+
+	result, err := h.alertService.ListDeliveries(c.Request.Context(), alert.ListDeliveriesOptions{
+		RuleID:   ruleID,
+		Status:   status,
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		logger.Error("获取告警投递记录失败", "error", err)
+		response.InternalError(c, "获取告警投递记录失败")
+		return
+	}
+
+	logger.Debug("获取告警投递记录成功", "total", result.Total, "item_count", len(result.Items))
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseRuleID 解析路径中的规则 ID，失败时直接写入错误响应
+func parseRuleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("ruleId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的规则 ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, internalMessage string) {
+	switch {
+	case errors.Is(err, alert.ErrRuleNotFound):
+		response.NotFound(c, "告警规则未找到")
+	case errors.Is(err, alert.ErrInvalidArgument):
+		response.BadRequest(c, err.Error())
+	default:
+		h.logger.Error(internalMessage, "error", err, "path", c.FullPath())
+		response.InternalError(c, internalMessage)
+	}
+}
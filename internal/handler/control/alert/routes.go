@@ -0,0 +1,31 @@
+package alert
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/internal/app/alert"
+)
+
+// SetupAlertRoutes 配置告警规则与投递记录相关的路由
+func SetupAlertRoutes(router *gin.RouterGroup, alertService alert.Service, logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	handler := NewHandler(alertService, logger.WithGroup("alert_handler"))
+
+	alertGroup := router.Group("/alerts")
+
+	// 告警规则端点
+	alertGroup.GET("/rules", handler.ListRules)
+	alertGroup.POST("/rules", handler.CreateRule)
+	alertGroup.GET("/rules/:ruleId", handler.GetRule)
+	alertGroup.PUT("/rules/:ruleId", handler.UpdateRule)
+	alertGroup.DELETE("/rules/:ruleId", handler.DeleteRule)
+	alertGroup.POST("/rules/:ruleId/test", handler.TestRule)
+
+	// 投递记录端点
+	alertGroup.GET("/deliveries", handler.ListDeliveries)
+}
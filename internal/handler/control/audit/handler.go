@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/handlers/query"
+	"github.com/MeowSalty/pinai/internal/app/audit"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+)
+
+// Handler 审计处理器结构体
+type Handler struct {
+	auditService audit.Service
+	logger       *slog.Logger
+}
+
+// NewHandler 创建审计处理器实例
+func NewHandler(auditService audit.Service, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Handler{
+		auditService: auditService,
+		logger:       logger.With("component", "audit_handler"),
+	}
+}
+
+// ListAuditLogs godoc
+// @Summary      获取控制面审计记录
+// @Description  记录平台、模型、密钥、端点的增删改，健康状态启用/禁用与代理调用；快照中的密钥值等敏感字段已脱敏
+// @Tags         audit
+// @Produce      json
+// @Param        actor        query     string  false  "操作者标识"
+// @Param        action       query     string  false  "操作，如 platform.update"
+// @Param        resource     query     string  false  "资源类型，如 platform"
+// @Param        resource_id  query     string  false  "资源标识"
+// @Param        result       query     string  false  "结果"  Enums(success, failed)
+// @Param        start_time   query     string  false  "开始时间 (RFC3339 或 Unix 毫秒时间戳)"
+// @Param        end_time     query     string  false  "结束时间 (RFC3339 或 Unix 毫秒时间戳)"
+// @Param        page         query     int     false  "页码"  default(1)
+// @Param        page_size    query     int     false  "每页大小"  default(10)
+// @Success      200          {object}  audit.ListResponse
+// @Failure      400          {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      500          {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/audit [get]
+func (h *Handler) ListAuditLogs(c *gin.Context) {
+	logger := h.logger.With(
+		"operation", "list_audit_logs",
+		"method", c.Request.Method,
+		"path", c.FullPath(),
+	)
+
+	page, pageSize, err := query.Pagination(c)
+	if err != nil {
+		logger.Warn("分页参数解析失败",
+			"page_raw", c.Query("page"),
+			"page_size_raw", c.Query("page_size"),
+			"error", err)
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result := c.Query("result")
+	switch result {
+	case "", types.AuditResultSuccess, types.AuditResultFailed:
+	default:
+		response.BadRequest(c, "无效的审计结果，可选值：success, failed")
+		return
+	}
+
+	opts := audit.ListOptions{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		Resource:   c.Query("resource"),
+		ResourceID: c.Query("resource_id"),
+		Result:     result,
+		Page:       page,
+		PageSize:   pageSize,
+	}
+
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		startTime, err := parseTime(startTimeStr)
+		if err != nil {
+			logger.Warn("开始时间参数解析失败", "error", err)
+			response.BadRequest(c, "开始时间格式错误")
+			return
+		}
+		opts.StartTime = &startTime
+	}
+
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		endTime, err := parseTime(endTimeStr)
+		if err != nil {
+			logger.Warn("结束时间参数解析失败", "error", err)
+			response.BadRequest(c, "结束时间格式错误")
+			return
+		}
+		opts.EndTime = &endTime
+	}
+
+	resp, err := h.auditService.List(c.Request.Context(), opts)
+	if err != nil {
+		logger.Error("获取审计记录失败", "error", err)
+		response.InternalError(c, "获取审计记录失败")
+		return
+	}
+
+	logger.Debug("获取审计记录成功", "total", resp.Total, "item_count", len(resp.Items))
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseTime 解析时间字符串，支持 RFC3339 格式和 Unix 时间戳 (毫秒)
+func parseTime(timeStr string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, timeStr); err == nil {
+		return t, nil
+	}
+
+	ts, err := strconv.ParseInt(timeStr, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, ts*int64(time.Millisecond)), nil
+}
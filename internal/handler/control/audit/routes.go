@@ -0,0 +1,20 @@
+package audit
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/internal/app/audit"
+)
+
+// SetupAuditRoutes 配置控制面审计相关的路由
+func SetupAuditRoutes(router *gin.RouterGroup, auditService audit.Service, logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	handler := NewHandler(auditService, logger.WithGroup("audit_handler"))
+
+	router.GET("/audit", handler.ListAuditLogs)
+}
@@ -1,6 +1,7 @@
 package health
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -201,8 +202,46 @@ func (h *Handler) GetModelHealthList(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GetKeyModelHealthList 获取密钥-模型组合健康列表
+//
+// 返回值：
+//
+//	成功 - 组合健康列表数据
+//	失败 - 错误信息
+func (h *Handler) GetKeyModelHealthList(c *gin.Context) {
+	logger := h.logger.With(
+		"operation", "list_key_models",
+		"method", c.Request.Method,
+		"path", c.FullPath(),
+	)
+
+	page, pageSize, err := query.Pagination(c)
+	if err != nil {
+		logger.Warn("分页参数解析失败",
+			"page_raw", c.Query("page"),
+			"page_size_raw", c.Query("page_size"),
+			"error", err)
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.healthService.GetKeyModelHealthList(c.Request.Context(), page, pageSize)
+	if err != nil {
+		logger.Error("获取密钥-模型组合健康列表失败", "error", err)
+		response.InternalError(c, "获取密钥-模型组合健康列表失败："+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // GetIssues 获取异常资源列表
 //
+// 查询参数：
+//
+//	kind - 异常类别（backoff、manual、permanent），为空表示全部
+//	reason - 永久禁用原因（invalid_api_key、quota_exhausted、account_deactivated），为空表示全部
+//
 // 返回值：
 //
 //	成功 - 异常资源列表数据
@@ -214,8 +253,15 @@ func (h *Handler) GetIssues(c *gin.Context) {
 		"path", c.FullPath(),
 	)
 
-	result, err := h.healthService.GetIssues(c.Request.Context())
+	result, err := h.healthService.GetIssues(c.Request.Context(), health.IssuesOptions{
+		Kind:   c.Query("kind"),
+		Reason: c.Query("reason"),
+	})
 	if err != nil {
+		if errors.Is(err, health.ErrInvalidArgument) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Error("获取异常资源列表失败", "error", err)
 		response.InternalError(c, "获取异常资源列表失败："+err.Error())
 		return
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/handlers/query"
+	"github.com/MeowSalty/pinai/internal/app/health"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+)
+
+// GetHistory 返回指定资源类型的健康状态变化历史处理函数
+//
+// 路径参数：
+//
+//	resourceId - 资源 ID
+//
+// 查询参数：
+//
+//	range - 时间范围（24h、7d、30d），默认为 7d
+//	start_time - 开始时间（RFC3339 或 Unix 毫秒），指定起止时间之一时忽略 range
+//	end_time - 结束时间（RFC3339 或 Unix 毫秒），默认为当前时间
+//	page - 页码，默认为 1
+//	page_size - 每页大小，默认为 10，最大 100
+//
+// 返回值：
+//
+//	成功 - 状态变化记录（按时间倒序）与时间范围内的可用率
+//	失败 - 错误信息
+func (h *Handler) GetHistory(resourceType types.ResourceType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := h.logger.With(
+			"operation", "get_history",
+			"method", c.Request.Method,
+			"path", c.FullPath(),
+			"resource_type", resourceType,
+		)
+
+		resourceID, err := strconv.ParseUint(c.Param("resourceId"), 10, 64)
+		if err != nil || resourceID == 0 {
+			response.BadRequest(c, "无效的资源 ID")
+			return
+		}
+
+		page, pageSize, err := query.Pagination(c)
+		if err != nil {
+			logger.Warn("分页参数解析失败",
+				"page_raw", c.Query("page"),
+				"page_size_raw", c.Query("page_size"),
+				"error", err)
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		startTime, err := query.OptionalTime(c, "start_time")
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		endTime, err := query.OptionalTime(c, "end_time")
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		result, err := h.healthService.GetHistory(c.Request.Context(), resourceType, uint(resourceID), health.HistoryOptions{
+			Range:     c.Query("range"),
+			StartTime: startTime,
+			EndTime:   endTime,
+			Page:      page,
+			PageSize:  pageSize,
+		})
+		if err != nil {
+			if errors.Is(err, health.ErrInvalidArgument) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			logger.Error("获取健康状态变化历史失败", "error", err)
+			response.InternalError(c, "获取健康状态变化历史失败")
+			return
+		}
+
+		logger.Debug("获取健康状态变化历史成功", "resource_id", resourceID, "total", result.Total)
+
+		c.JSON(http.StatusOK, result)
+	}
+}
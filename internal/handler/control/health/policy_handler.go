@@ -0,0 +1,140 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/internal/app/health"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+)
+
+// ListPolicies godoc
+// @Summary      获取健康策略
+// @Description  返回全局退避与熔断策略及平台级覆盖
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  health.PolicyListResponse
+// @Failure      500  {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/health/policies [get]
+func (h *Handler) ListPolicies(c *gin.Context) {
+	result, err := h.healthService.ListPolicies(c.Request.Context())
+	if err != nil {
+		h.respondPolicyError(c, err, "获取健康策略失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateGlobalPolicy godoc
+// @Summary      更新全局健康策略
+// @Description  未提供的字段沿用当前全局策略
+// @Tags         health
+// @Accept       json
+// @Produce      json
+// @Param        request  body      health.PolicyRequest    true  "健康策略"
+// @Success      200      {object}  types.HealthPolicy      "更新后的全局策略"
+// @Failure      400      {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      500      {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/health/policies/global [put]
+func (h *Handler) UpdateGlobalPolicy(c *gin.Context) {
+	var req health.PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	policy, err := h.healthService.UpdateGlobalPolicy(c.Request.Context(), req)
+	if err != nil {
+		h.respondPolicyError(c, err, "更新全局健康策略失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdatePlatformPolicy godoc
+// @Summary      设置平台健康策略
+// @Description  覆盖平台及其密钥、模型的健康策略，未提供的字段沿用该平台当前策略或全局策略
+// @Tags         health
+// @Accept       json
+// @Produce      json
+// @Param        platformId  path      int                     true  "平台 ID"
+// @Param        request     body      health.PolicyRequest    true  "健康策略"
+// @Success      200         {object}  types.HealthPolicy      "平台策略"
+// @Failure      400         {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  response.ErrorResponse  "平台未找到"
+// @Failure      500         {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/health/policies/platforms/{platformId} [put]
+func (h *Handler) UpdatePlatformPolicy(c *gin.Context) {
+	platformID, ok := parsePlatformID(c)
+	if !ok {
+		return
+	}
+
+	var req health.PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	policy, err := h.healthService.UpdatePlatformPolicy(c.Request.Context(), platformID, req)
+	if err != nil {
+		h.respondPolicyError(c, err, "设置平台健康策略失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePlatformPolicy godoc
+// @Summary      删除平台健康策略
+// @Description  删除平台级覆盖，平台恢复使用全局策略
+// @Tags         health
+// @Param        platformId  path  int  true  "平台 ID"
+// @Success      204  "删除成功"
+// @Failure      400  {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      404  {object}  response.ErrorResponse  "平台未设置健康策略"
+// @Failure      500  {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/health/policies/platforms/{platformId} [delete]
+func (h *Handler) DeletePlatformPolicy(c *gin.Context) {
+	platformID, ok := parsePlatformID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.healthService.DeletePlatformPolicy(c.Request.Context(), platformID); err != nil {
+		h.respondPolicyError(c, err, "删除平台健康策略失败")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parsePlatformID 解析路径中的平台 ID，失败时直接写入错误响应
+func parsePlatformID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("platformId"), 10, 64)
+	if err != nil || id == 0 {
+		response.BadRequest(c, "无效的平台 ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *Handler) respondPolicyError(c *gin.Context, err error, internalMessage string) {
+	switch {
+	case errors.Is(err, health.ErrPolicyNotFound):
+		response.NotFound(c, "平台未设置健康策略")
+	case errors.Is(err, health.ErrPolicyPlatformAbsent):
+		response.NotFound(c, "平台未找到")
+	case errors.Is(err, health.ErrInvalidPolicy):
+		response.BadRequest(c, err.Error())
+	default:
+		h.logger.Error(internalMessage, "error", err, "path", c.FullPath())
+		response.InternalError(c, internalMessage)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/MeowSalty/pinai/database/types"
 	"github.com/MeowSalty/pinai/internal/app/health"
 )
 
@@ -30,4 +31,19 @@ func SetupHealthRoutes(router *gin.RouterGroup, healthService health.Service, lo
 
 	// 模型健康端点
 	healthGroup.GET("/models", handler.GetModelHealthList)
+
+	// 密钥-模型组合健康端点
+	healthGroup.GET("/key-models", handler.GetKeyModelHealthList)
+	healthGroup.GET("/key-models/:resourceId/history", handler.GetHistory(types.ResourceTypeKeyModel))
+
+	// 状态变化历史与可用率端点
+	healthGroup.GET("/platforms/:resourceId/history", handler.GetHistory(types.ResourceTypePlatform))
+	healthGroup.GET("/keys/:resourceId/history", handler.GetHistory(types.ResourceTypeAPIKey))
+	healthGroup.GET("/models/:resourceId/history", handler.GetHistory(types.ResourceTypeModel))
+
+	// 退避与熔断策略端点
+	healthGroup.GET("/policies", handler.ListPolicies)
+	healthGroup.PUT("/policies/global", handler.UpdateGlobalPolicy)
+	healthGroup.PUT("/policies/platforms/:platformId", handler.UpdatePlatformPolicy)
+	healthGroup.DELETE("/policies/platforms/:platformId", handler.DeletePlatformPolicy)
 }
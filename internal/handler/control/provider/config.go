@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MeowSalty/pinai/handlers/query"
+	serviceprovider "github.com/MeowSalty/pinai/internal/app/provider"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportConfig godoc
+// @Summary      导出配置
+// @Description  导出全部平台及其端点、模型、别名与密钥关联；默认不包含密钥
+// @Tags         config
+// @Produce      json
+// @Produce      application/yaml
+// @Param        format           query     string  false  "导出格式：json（默认）或 yaml"
+// @Param        include_secrets  query     bool    false  "是否包含密钥值及密钥与模型的关联"
+// @Success      200              {object}  serviceprovider.ConfigDocument  "配置文档"
+// @Failure      400              {object}  response.ErrorResponse          "请求参数错误"
+// @Failure      500              {object}  response.ErrorResponse          "服务器内部错误"
+// @Router       /api/config/export [get]
+func (h *Handler) ExportConfig(c *gin.Context) {
+	includeSecrets, err := query.OptionalBool(c, "include_secrets")
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "yaml" {
+		response.BadRequest(c, "format 仅支持 json 或 yaml")
+		return
+	}
+
+	doc, err := h.service.ExportConfig(c.Request.Context(), includeSecrets != nil && *includeSecrets)
+	if err != nil {
+		respondProviderServiceError(c, err, "配置未找到", "导出配置失败")
+		return
+	}
+
+	if format == "yaml" {
+		c.YAML(http.StatusOK, doc)
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// ImportConfig godoc
+// @Summary      导入配置
+// @Description  按配置文档同步平台配置，文档中不存在的资源会被删除；dry_run=true 时仅返回变更列表
+// @Tags         config
+// @Accept       json
+// @Accept       application/yaml
+// @Produce      json
+// @Param        dry_run  query     bool                             false  "仅计算变更，不写入"
+// @Param        request  body      serviceprovider.ConfigDocument   true   "配置文档（JSON 或 YAML）"
+// @Success      200      {object}  serviceprovider.ConfigImportResult  "变更列表与统计"
+// @Failure      400      {object}  response.ErrorResponse             "请求参数错误"
+// @Failure      500      {object}  response.ErrorResponse             "服务器内部错误"
+// @Router       /api/config/import [post]
+func (h *Handler) ImportConfig(c *gin.Context) {
+	dryRun, err := query.OptionalBool(c, "dry_run")
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var doc serviceprovider.ConfigDocument
+	if strings.Contains(c.ContentType(), "yaml") {
+		err = c.ShouldBindYAML(&doc)
+	} else {
+		err = c.ShouldBindJSON(&doc)
+	}
+	if err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	result, err := h.service.ImportConfig(c.Request.Context(), doc, dryRun != nil && *dryRun)
+	if err != nil {
+		respondProviderServiceError(c, err, "配置未找到", "导入配置失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"errors"
+	"net/http"
 
 	serviceprovider "github.com/MeowSalty/pinai/internal/app/provider"
 	"github.com/MeowSalty/pinai/internal/handler/response"
@@ -9,6 +10,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// codeManagedResource 为修改声明式配置文件管理资源的错误码
+	codeManagedResource = "managed_resource"
+	// codeTaskStateConflict 为任务当前状态不支持取消或重试的错误码
+	codeTaskStateConflict = "task_state_conflict"
+)
+
 func respondProviderServiceError(c *gin.Context, err error, notFoundMessage, internalMessage string) {
 	if errors.Is(err, serviceprovider.ErrResourceNotFound) {
 		response.NotFound(c, notFoundMessage)
@@ -20,6 +28,16 @@ func respondProviderServiceError(c *gin.Context, err error, notFoundMessage, int
 		return
 	}
 
+	if errors.Is(err, serviceprovider.ErrManagedResource) {
+		response.Error(c, http.StatusConflict, codeManagedResource, err.Error())
+		return
+	}
+
+	if errors.Is(err, serviceprovider.ErrTaskStateConflict) {
+		response.Error(c, http.StatusConflict, codeTaskStateConflict, err.Error())
+		return
+	}
+
 	if errors.Is(err, serviceprovider.ErrResourceNotBelong) ||
 		errors.Is(err, serviceprovider.ErrInvalidArgument) ||
 		errors.Is(err, serviceprovider.ErrDefaultConflict) {
@@ -6,6 +6,8 @@ import (
 	"strconv"
 
 	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/handlers/query"
+	serviceprovider "github.com/MeowSalty/pinai/internal/app/provider"
 	"github.com/MeowSalty/pinai/internal/handler/response"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +19,15 @@ type KeyWithHealth struct {
 	HealthStatus *types.HealthStatus `json:"health_status,omitempty"`
 }
 
+// KeyWithValidation 带校验结果的密钥响应
+type KeyWithValidation struct {
+	*types.APIKey
+	Validation *serviceprovider.KeyValidationResult `json:"validation,omitempty"`
+}
+
+// codeKeyValidationFailed 为密钥校验未通过的错误码
+const codeKeyValidationFailed = "key_validation_failed"
+
 // AddKeyToPlatform godoc
 // @Summary      为指定平台添加新密钥
 // @Description  为指定平台添加新密钥
@@ -24,10 +35,12 @@ type KeyWithHealth struct {
 // @Accept       json
 // @Produce      json
 // @Param        platformId  path      int                             true  "平台 ID"
+// @Param        validate    query     bool                            false "保存前向上游校验密钥，未通过时返回 422 且不保存"
 // @Param        request     body      types.APIKey                    true  "创建密钥的请求体"
-// @Success      201         {object}  types.APIKey                      "创建成功的密钥信息 (不包含 value)"
+// @Success      201         {object}  KeyWithValidation                 "创建成功的密钥信息 (不包含 value)"
 // @Failure      400         {object}  response.ErrorResponse            "请求参数错误"
 // @Failure      404         {object}  response.ErrorResponse            "平台未找到"
+// @Failure      422         {object}  response.ErrorResponse            "密钥校验未通过，details 为校验结果"
 // @Failure      500         {object}  response.ErrorResponse            "服务器内部错误"
 // @Router       /api/platforms/{platformId}/keys [post]
 func (h *Handler) AddKeyToPlatform(c *gin.Context) {
@@ -43,7 +56,26 @@ func (h *Handler) AddKeyToPlatform(c *gin.Context) {
 		return
 	}
 
+	validate, err := query.OptionalBool(c, "validate")
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
 	ctx := c.Request.Context()
+	var validation *serviceprovider.KeyValidationResult
+	if validate != nil && *validate {
+		validation, err = h.service.ValidateKey(ctx, uint(platformId), key.Value)
+		if err != nil {
+			respondProviderServiceError(c, err, "平台未找到", "校验密钥失败")
+			return
+		}
+		if !validation.Success {
+			respondKeyValidationFailed(c, validation)
+			return
+		}
+	}
+
 	createdKey, err := h.service.AddKeyToPlatform(ctx, uint(platformId), key)
 	if err != nil {
 		respondProviderServiceError(c, err, "平台未找到", "为平台添加密钥失败")
@@ -52,7 +84,7 @@ func (h *Handler) AddKeyToPlatform(c *gin.Context) {
 
 	// 出于安全考虑，不返回密钥值
 	createdKey.Value = ""
-	c.JSON(http.StatusCreated, createdKey)
+	c.JSON(http.StatusCreated, KeyWithValidation{APIKey: createdKey, Validation: validation})
 }
 
 // GetKeysByPlatform godoc
@@ -135,10 +167,12 @@ func (h *Handler) DeleteKey(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        keyId       path      int                             true  "密钥 ID"
+// @Param        validate    query     bool                            false "保存前向上游校验密钥（未提供新值时校验当前值），未通过时返回 422 且不保存"
 // @Param        request     body      types.APIKey                    true  "更新密钥的请求体"
-// @Success      200         {object}  types.APIKey                      "更新后的密钥信息 (不包含 value)"
+// @Success      200         {object}  KeyWithValidation                 "更新后的密钥信息 (不包含 value)"
 // @Failure      400         {object}  response.ErrorResponse            "请求参数错误"
 // @Failure      404         {object}  response.ErrorResponse            "密钥未找到"
+// @Failure      422         {object}  response.ErrorResponse            "密钥校验未通过，details 为校验结果"
 // @Failure      500         {object}  response.ErrorResponse            "服务器内部错误"
 // @Router       /api/keys/{keyId} [put]
 func (h *Handler) UpdateKey(c *gin.Context) {
@@ -154,7 +188,35 @@ func (h *Handler) UpdateKey(c *gin.Context) {
 		return
 	}
 
+	validate, err := query.OptionalBool(c, "validate")
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
 	ctx := c.Request.Context()
+	var validation *serviceprovider.KeyValidationResult
+	if validate != nil && *validate {
+		if key.Value == "" {
+			validation, err = h.service.TestKey(ctx, uint(keyId))
+		} else {
+			existingKey, getErr := h.service.GetKey(ctx, uint(keyId))
+			if getErr != nil {
+				respondProviderServiceError(c, getErr, "密钥未找到", "校验密钥失败")
+				return
+			}
+			validation, err = h.service.ValidateKey(ctx, existingKey.PlatformID, key.Value)
+		}
+		if err != nil {
+			respondProviderServiceError(c, err, "密钥未找到", "校验密钥失败")
+			return
+		}
+		if !validation.Success {
+			respondKeyValidationFailed(c, validation)
+			return
+		}
+	}
+
 	updatedKey, err := h.service.UpdateKey(ctx, uint(keyId), key)
 	if err != nil {
 		respondProviderServiceError(c, err, "密钥未找到", "更新密钥失败")
@@ -163,7 +225,40 @@ func (h *Handler) UpdateKey(c *gin.Context) {
 
 	// 出于安全考虑，不返回密钥值
 	updatedKey.Value = ""
-	c.JSON(http.StatusOK, updatedKey)
+	c.JSON(http.StatusOK, KeyWithValidation{APIKey: updatedKey, Validation: validation})
+}
+
+// TestKey godoc
+// @Summary      测试指定密钥
+// @Description  使用平台默认端点向上游发送一次列出模型请求，返回是否成功、耗时与映射后的错误；结果不影响健康状态
+// @Tags         keys
+// @Produce      json
+// @Param        keyId  path      int                                   true  "密钥 ID"
+// @Success      200    {object}  serviceprovider.KeyValidationResult   "校验结果"
+// @Failure      400    {object}  response.ErrorResponse                "请求参数错误"
+// @Failure      404    {object}  response.ErrorResponse                "密钥未找到"
+// @Failure      500    {object}  response.ErrorResponse                "服务器内部错误"
+// @Router       /api/keys/{keyId}/test [post]
+func (h *Handler) TestKey(c *gin.Context) {
+	keyId, err := strconv.ParseUint(c.Param("keyId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的密钥 ID")
+		return
+	}
+
+	result, err := h.service.TestKey(c.Request.Context(), uint(keyId))
+	if err != nil {
+		respondProviderServiceError(c, err, "密钥未找到", "测试密钥失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// respondKeyValidationFailed 输出密钥校验未通过响应
+func respondKeyValidationFailed(c *gin.Context, result *serviceprovider.KeyValidationResult) {
+	response.ErrorWithDetails(c, http.StatusUnprocessableEntity, codeKeyValidationFailed,
+		"密钥校验未通过："+result.ErrorMessage, result)
 }
 
 // UpdateKeyHealth godoc
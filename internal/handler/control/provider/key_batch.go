@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	serviceprovider "github.com/MeowSalty/pinai/internal/app/provider"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxKeyImportBodySize 为文本方式导入密钥的请求体上限
+const maxKeyImportBodySize = 4 << 20
+
+// BatchAddKeysToPlatform godoc
+// @Summary      批量导入密钥
+// @Description  提交批量导入密钥异步任务，返回任务 ID；支持 JSON 请求体，或以 text/plain、text/csv 提交逐行密钥列表（取每行第一列），重复及已存在的密钥会被跳过
+// @Tags         keys
+// @Accept       json
+// @Accept       plain
+// @Accept       text/csv
+// @Produce      json
+// @Param        platformId  path      int                                            true  "平台 ID"
+// @Param        request     body      serviceprovider.BatchCreateKeysRequest         true  "批量导入密钥的请求体"
+// @Success      202         {object}  serviceprovider.BatchTaskAcceptedResponse      "任务提交成功"
+// @Failure      400         {object}  response.ErrorResponse                          "请求参数错误"
+// @Failure      404         {object}  response.ErrorResponse                          "平台未找到"
+// @Failure      409         {object}  response.ErrorResponse                          "平台由声明式配置文件管理"
+// @Failure      500         {object}  response.ErrorResponse                          "服务器内部错误"
+// @Router       /api/platforms/{platformId}/keys/batch [post]
+func (h *Handler) BatchAddKeysToPlatform(c *gin.Context) {
+	platformId, err := strconv.ParseUint(c.Param("platformId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的平台 ID")
+		return
+	}
+
+	var req serviceprovider.BatchCreateKeysRequest
+	if contentType := c.ContentType(); strings.HasPrefix(contentType, "text/") {
+		body, readErr := io.ReadAll(io.LimitReader(c.Request.Body, maxKeyImportBodySize))
+		if readErr != nil {
+			response.BadRequest(c, fmt.Sprintf("无法读取请求体: %v", readErr))
+			return
+		}
+		req.Text = string(body)
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	accepted, err := h.service.EnqueueBatchAddKeysTask(c.Request.Context(), uint(platformId), req)
+	if err != nil {
+		respondProviderServiceError(c, err, "平台未找到", "提交批量导入密钥任务失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// BatchDeleteKeys godoc
+// @Summary      批量删除密钥
+// @Description  提交批量删除密钥异步任务，返回任务 ID；原先关联这些密钥且失去全部密钥的模型会被一并删除
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Param        platformId  path      int                                          true  "平台 ID"
+// @Param        request     body      serviceprovider.BatchDeleteKeysRequest       true  "批量删除密钥的请求体"
+// @Success      202         {object}  serviceprovider.BatchTaskAcceptedResponse    "任务提交成功"
+// @Failure      400         {object}  response.ErrorResponse                        "请求参数错误"
+// @Failure      404         {object}  response.ErrorResponse                        "平台未找到"
+// @Failure      409         {object}  response.ErrorResponse                        "平台由声明式配置文件管理"
+// @Failure      500         {object}  response.ErrorResponse                        "服务器内部错误"
+// @Router       /api/platforms/{platformId}/keys/batch [delete]
+func (h *Handler) BatchDeleteKeys(c *gin.Context) {
+	platformId, err := strconv.ParseUint(c.Param("platformId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的平台 ID")
+		return
+	}
+
+	var req serviceprovider.BatchDeleteKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	accepted, err := h.service.EnqueueBatchDeleteKeysTask(c.Request.Context(), uint(platformId), req.KeyIDs)
+	if err != nil {
+		respondProviderServiceError(c, err, "平台未找到", "提交批量删除密钥任务失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// BatchUpdateKeyModels godoc
+// @Summary      批量更新密钥与模型关联
+// @Description  提交批量更新密钥与模型关联异步任务，mode 为 add（追加）、remove（移除）或 replace（替换）；失去全部密钥的模型予以保留
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Param        platformId  path      int                                             true  "平台 ID"
+// @Param        request     body      serviceprovider.BatchUpdateKeyModelsRequest     true  "批量更新关联的请求体"
+// @Success      202         {object}  serviceprovider.BatchTaskAcceptedResponse       "任务提交成功"
+// @Failure      400         {object}  response.ErrorResponse                           "请求参数错误"
+// @Failure      404         {object}  response.ErrorResponse                           "平台未找到"
+// @Failure      409         {object}  response.ErrorResponse                           "平台由声明式配置文件管理"
+// @Failure      500         {object}  response.ErrorResponse                           "服务器内部错误"
+// @Router       /api/platforms/{platformId}/keys/batch/models [put]
+func (h *Handler) BatchUpdateKeyModels(c *gin.Context) {
+	platformId, err := strconv.ParseUint(c.Param("platformId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的平台 ID")
+		return
+	}
+
+	var req serviceprovider.BatchUpdateKeyModelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	accepted, err := h.service.EnqueueBatchUpdateKeyModelsTask(c.Request.Context(), uint(platformId), req)
+	if err != nil {
+		respondProviderServiceError(c, err, "平台未找到", "提交批量更新密钥关联任务失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
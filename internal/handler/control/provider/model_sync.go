@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/MeowSalty/pinai/handlers/query"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModelAutoSyncRequest 模型自动同步设置请求
+type ModelAutoSyncRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"` // true=参与定时同步；false=不参与
+}
+
+// SyncPlatformModels godoc
+// @Summary      同步上游模型列表
+// @Description  使用平台密钥请求上游模型列表并与现有模型对比；存在差异时提交模型同步任务，dry_run=true 时仅返回差异
+// @Tags         models
+// @Produce      json
+// @Param        platformId  path      int                                  true   "平台 ID"
+// @Param        dry_run     query     bool                                 false  "仅返回差异，不提交任务"
+// @Success      200         {object}  provider.ModelSyncResponse           "差异预览（无变更或 dry_run）"
+// @Success      202         {object}  provider.ModelSyncResponse           "差异预览与已提交的任务"
+// @Failure      400         {object}  response.ErrorResponse               "请求参数错误"
+// @Failure      404         {object}  response.ErrorResponse               "平台未找到"
+// @Failure      409         {object}  response.ErrorResponse               "平台由声明式配置文件管理"
+// @Failure      500         {object}  response.ErrorResponse               "服务器内部错误"
+// @Router       /api/platforms/{platformId}/models/sync [post]
+func (h *Handler) SyncPlatformModels(c *gin.Context) {
+	platformId, err := strconv.ParseUint(c.Param("platformId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的平台 ID")
+		return
+	}
+
+	dryRun, err := query.OptionalBool(c, "dry_run")
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.service.SyncPlatformModels(c.Request.Context(), uint(platformId), dryRun != nil && *dryRun)
+	if err != nil {
+		respondProviderServiceError(c, err, "平台未找到", "同步上游模型列表失败")
+		return
+	}
+
+	if result.Task != nil {
+		c.JSON(http.StatusAccepted, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdatePlatformModelAutoSync godoc
+// @Summary      设置模型自动同步
+// @Description  设置平台是否参与上游模型列表定时同步（需配置 MODEL_SYNC_INTERVAL）
+// @Tags         models
+// @Accept       json
+// @Produce      json
+// @Param        platformId  path      int                   true  "平台 ID"
+// @Param        request     body      ModelAutoSyncRequest  true  "自动同步设置"
+// @Success      200         {object}  types.Platform          "更新后的平台"
+// @Failure      400         {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  response.ErrorResponse  "平台未找到"
+// @Failure      409         {object}  response.ErrorResponse  "平台由声明式配置文件管理"
+// @Failure      500         {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/platforms/{platformId}/models/sync [patch]
+func (h *Handler) UpdatePlatformModelAutoSync(c *gin.Context) {
+	platformId, err := strconv.ParseUint(c.Param("platformId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的平台 ID")
+		return
+	}
+
+	var req ModelAutoSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	platform, err := h.service.UpdatePlatformModelAutoSync(c.Request.Context(), uint(platformId), *req.Enabled)
+	if err != nil {
+		respondProviderServiceError(c, err, "平台未找到", "更新模型自动同步失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, platform)
+}
@@ -31,16 +31,23 @@ type PlatformWithHealth struct {
 
 // CreatePlatform godoc
 // @Summary      创建一个新的平台
-// @Description  创建一个新的平台
+// @Description  创建一个新的平台；指定 preset 时按预设创建平台及其端点与建议模型，请求体为 provider.CreatePlatformFromPresetRequest（可省略），响应附带创建的模型
 // @Tags         platforms
 // @Accept       json
 // @Produce      json
-// @Param        request  body      types.Platform  true  "创建平台的请求体"
+// @Param        preset   query     string          false  "平台预设 ID，见 /api/platform-presets"
+// @Param        request  body      types.Platform  false  "创建平台的请求体"
 // @Success      201      {object}  types.Platform                    "创建成功的平台信息"
 // @Failure      400      {object}  response.ErrorResponse            "请求参数错误"
+// @Failure      404      {object}  response.ErrorResponse            "平台预设未找到"
 // @Failure      500      {object}  response.ErrorResponse            "服务器内部错误"
 // @Router       /api/platforms [post]
 func (h *Handler) CreatePlatform(c *gin.Context) {
+	if presetID := c.Query("preset"); presetID != "" {
+		h.createPlatformFromPreset(c, presetID)
+		return
+	}
+
 	var platform types.Platform
 	if err := c.ShouldBindJSON(&platform); err != nil {
 		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
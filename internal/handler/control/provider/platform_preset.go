@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	serviceprovider "github.com/MeowSalty/pinai/internal/app/provider"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPlatformPresets godoc
+// @Summary      获取平台预设列表
+// @Description  获取内置的常用供应方预设，包含基础 URL、端点类型与变体及建议模型列表
+// @Tags         platforms
+// @Produce      json
+// @Success      200  {array}   serviceprovider.PlatformPreset  "平台预设列表"
+// @Router       /api/platform-presets [get]
+func (h *Handler) ListPlatformPresets(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ListPlatformPresets())
+}
+
+// createPlatformFromPreset 处理 POST /api/platforms?preset=...，请求体可省略
+func (h *Handler) createPlatformFromPreset(c *gin.Context, presetID string) {
+	var req serviceprovider.CreatePlatformFromPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	result, err := h.service.CreatePlatformFromPreset(c.Request.Context(), presetID, req)
+	if err != nil {
+		respondProviderServiceError(c, err, "平台预设未找到", "从预设创建平台失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
@@ -16,6 +16,9 @@ func SetupProviderRoutes(router *gin.RouterGroup, llmService provider.Service) {
 	platforms.POST("", handler.CreatePlatform)
 	platforms.GET("", handler.GetPlatforms)
 
+	// 平台预设路由
+	router.GET("/platform-presets", handler.ListPlatformPresets)
+
 	platform := platforms.Group("/:platformId")
 	platform.GET("", handler.GetPlatform)
 	platform.PUT("", handler.UpdatePlatform)
@@ -31,6 +34,8 @@ func SetupProviderRoutes(router *gin.RouterGroup, llmService provider.Service) {
 	models.GET("", handler.GetModelsByPlatform)
 	models.PUT("/batch", handler.BatchUpdateModels)
 	models.DELETE("/batch", handler.BatchDeleteModels)
+	models.POST("/sync", handler.SyncPlatformModels)
+	models.PATCH("/sync", handler.UpdatePlatformModelAutoSync)
 
 	// 模型 (Models) 单资源操作路由
 	modelRoutes := router.Group("/models")
@@ -38,20 +43,31 @@ func SetupProviderRoutes(router *gin.RouterGroup, llmService provider.Service) {
 	modelRoutes.DELETE("/:modelId", handler.DeleteModel)
 	modelRoutes.PATCH("/:modelId/health", handler.UpdateModelHealth)
 
-	// 模型批量任务路由
+	// 批量任务路由（保留兼容，等同于 /tasks/:taskId）
 	modelTaskRoutes := router.Group("/model-tasks")
 	modelTaskRoutes.GET("/:taskId", handler.GetModelBatchTask)
 
+	// 异步任务路由
+	taskRoutes := router.Group("/tasks")
+	taskRoutes.GET("", handler.ListTasks)
+	taskRoutes.GET("/:taskId", handler.GetModelBatchTask)
+	taskRoutes.POST("/:taskId/cancel", handler.CancelTask)
+	taskRoutes.POST("/:taskId/retry", handler.RetryTask)
+
 	// 密钥 (Keys) 相关路由 (嵌套在平台下)
 	keys := platform.Group("/keys")
 	keys.POST("", handler.AddKeyToPlatform)
 	keys.GET("", handler.GetKeysByPlatform)
+	keys.POST("/batch", handler.BatchAddKeysToPlatform)
+	keys.DELETE("/batch", handler.BatchDeleteKeys)
+	keys.PUT("/batch/models", handler.BatchUpdateKeyModels)
 
 	// 密钥 (Keys) 单资源操作路由
 	keyRoutes := router.Group("/keys")
 	keyRoutes.PUT("/:keyId", handler.UpdateKey)
 	keyRoutes.DELETE("/:keyId", handler.DeleteKey)
 	keyRoutes.PATCH("/:keyId/health", handler.UpdateKeyHealth)
+	keyRoutes.POST("/:keyId/test", handler.TestKey)
 
 	// 端点 (Endpoints) 相关路由 (嵌套在平台下)
 	endpoints := platform.Group("/endpoints")
@@ -65,4 +81,9 @@ func SetupProviderRoutes(router *gin.RouterGroup, llmService provider.Service) {
 	endpointRoutes.GET("/:endpointId", handler.GetEndpoint)
 	endpointRoutes.PUT("/:endpointId", handler.UpdateEndpoint)
 	endpointRoutes.DELETE("/:endpointId", handler.DeleteEndpoint)
+
+	// 配置导入导出路由
+	configRoutes := router.Group("/config")
+	configRoutes.GET("/export", handler.ExportConfig)
+	configRoutes.POST("/import", handler.ImportConfig)
 }
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/handlers/query"
+	serviceprovider "github.com/MeowSalty/pinai/internal/app/provider"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTasks godoc
+// @Summary      获取异步任务列表
+// @Description  按任务类型、状态与平台筛选异步任务，按 ID 倒序分页返回
+// @Tags         tasks
+// @Produce      json
+// @Param        type         query     string  false  "任务类型，如 model.batch_add、key.batch_add、model.sync、request_log.retention"
+// @Param        status       query     string  false  "任务状态"  Enums(pending, running, succeeded, failed, canceled)
+// @Param        platform_id  query     int     false  "平台 ID"
+// @Param        page         query     int     false  "页码"  default(1)
+// @Param        page_size    query     int     false  "每页大小"  default(10)
+// @Success      200          {object}  serviceprovider.TaskListResponse  "任务列表"
+// @Failure      400          {object}  response.ErrorResponse            "请求参数错误"
+// @Failure      500          {object}  response.ErrorResponse            "服务器内部错误"
+// @Router       /api/tasks [get]
+func (h *Handler) ListTasks(c *gin.Context) {
+	page, pageSize, err := query.Pagination(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	platformID, err := query.OptionalUint(c, "platform_id")
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", types.ModelBatchTaskStatusPending, types.ModelBatchTaskStatusRunning, types.ModelBatchTaskStatusSucceeded,
+		types.ModelBatchTaskStatusFailed, types.ModelBatchTaskStatusCanceled:
+	default:
+		response.BadRequest(c, "无效的任务状态，可选值：pending, running, succeeded, failed, canceled")
+		return
+	}
+
+	result, err := h.service.ListTasks(c.Request.Context(), serviceprovider.ListTasksOptions{
+		Type:       c.Query("type"),
+		Status:     status,
+		PlatformID: platformID,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		respondProviderServiceError(c, err, "任务未找到", "查询任务列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CancelTask godoc
+// @Summary      取消异步任务
+// @Description  pending 任务立即取消；running 任务中断执行后状态变为 canceled，返回中的 cancel_requested 为 true
+// @Tags         tasks
+// @Produce      json
+// @Param        taskId  path      int                                      true  "任务 ID"
+// @Success      200     {object}  serviceprovider.ModelBatchTaskSummary   "任务详情"
+// @Failure      400     {object}  response.ErrorResponse                   "请求参数错误"
+// @Failure      404     {object}  response.ErrorResponse                   "任务未找到"
+// @Failure      409     {object}  response.ErrorResponse                   "任务已结束"
+// @Failure      500     {object}  response.ErrorResponse                   "服务器内部错误"
+// @Router       /api/tasks/{taskId}/cancel [post]
+func (h *Handler) CancelTask(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的任务 ID")
+		return
+	}
+
+	task, err := h.service.CancelTask(c.Request.Context(), uint(taskID))
+	if err != nil {
+		respondProviderServiceError(c, err, "任务未找到", "取消任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// RetryTask godoc
+// @Summary      重试异步任务
+// @Description  将失败或已取消的任务重置为 pending 并重新执行，执行次数重新计算
+// @Tags         tasks
+// @Produce      json
+// @Param        taskId  path      int                                      true  "任务 ID"
+// @Success      202     {object}  serviceprovider.ModelBatchTaskSummary   "任务已重新提交"
+// @Failure      400     {object}  response.ErrorResponse                   "请求参数错误"
+// @Failure      404     {object}  response.ErrorResponse                   "任务未找到"
+// @Failure      409     {object}  response.ErrorResponse                   "任务状态不支持重试或平台由声明式配置文件管理"
+// @Failure      500     {object}  response.ErrorResponse                   "服务器内部错误"
+// @Router       /api/tasks/{taskId}/retry [post]
+func (h *Handler) RetryTask(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的任务 ID")
+		return
+	}
+
+	task, err := h.service.RetryTask(c.Request.Context(), uint(taskID))
+	if err != nil {
+		respondProviderServiceError(c, err, "任务未找到", "重试任务失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, task)
+}
@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/internal/app/audit"
 )
 
 const (
@@ -32,9 +34,15 @@ var hopByHopHeaders = map[string]struct{}{
 	"keep-alive":          {},
 }
 
+// AuditRecorder 定义代理请求审计记录所需的最小能力。
+type AuditRecorder interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
 // Handler 负责处理代理请求。
 type Handler struct {
 	userAgent string
+	recorder  AuditRecorder
 	logger    *slog.Logger
 }
 
@@ -47,10 +55,11 @@ type ProxyRequest struct {
 	TimeoutMS int               `json:"timeout_ms"`
 }
 
-// New 创建代理处理器实例。
-func New(userAgent string, logger *slog.Logger) *Handler {
+// New 创建代理处理器实例，recorder 为空时仅输出审计日志。
+func New(userAgent string, recorder AuditRecorder, logger *slog.Logger) *Handler {
 	return &Handler{
 		userAgent: userAgent,
+		recorder:  recorder,
 		logger:    logger,
 	}
 }
@@ -62,6 +71,8 @@ func (h *Handler) Proxy(c *gin.Context) {
 
 	auditTargetID := ""
 	writeAuditLog := func(result string, statusCode int, errorType string, method string) {
+		// 所有代理调用均持久化审计记录，只读方法不再输出审计日志
+		h.recordAudit(c, auditTargetID, result, statusCode, errorType, method)
 		if method == http.MethodGet || method == http.MethodHead {
 			return
 		}
@@ -250,6 +261,28 @@ func (h *Handler) Proxy(c *gin.Context) {
 	c.Data(upstreamResp.StatusCode, contentType, bodyBytes)
 }
 
+// recordAudit 持久化一次代理调用的审计记录，仅记录目标地址的协议与主机，避免写入查询参数中的凭据。
+func (h *Handler) recordAudit(c *gin.Context, targetID, result string, statusCode int, errorType, method string) {
+	if h.recorder == nil {
+		return
+	}
+
+	detail := fmt.Sprintf("代理 %s 请求，状态码 %d", method, statusCode)
+	if errorType != "" {
+		detail += "，错误类型 " + errorType
+	}
+
+	if err := h.recorder.Record(c.Request.Context(), audit.Entry{
+		Action:     "proxy.request",
+		Resource:   "proxy",
+		ResourceID: targetID,
+		Result:     result,
+		Detail:     detail,
+	}); err != nil {
+		h.logger.Warn("写入代理审计记录失败", "error", err)
+	}
+}
+
 func (h *Handler) newRequestLogger(c *gin.Context) *slog.Logger {
 	if c == nil || c.Request == nil {
 		return h.logger.With(
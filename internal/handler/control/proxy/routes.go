@@ -7,12 +7,12 @@ import (
 )
 
 // SetupProxyRoutes 配置代理相关路由。
-func SetupProxyRoutes(router *gin.RouterGroup, apiToken string, userAgent string, logger *slog.Logger) {
+func SetupProxyRoutes(router *gin.RouterGroup, apiToken string, userAgent string, recorder AuditRecorder, logger *slog.Logger) {
 	_ = apiToken
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	handler := New(userAgent, logger.WithGroup("handlers").With("component", "proxy_handler"))
+	handler := New(userAgent, recorder, logger.WithGroup("handlers").With("component", "proxy_handler"))
 	router.POST("", handler.Proxy)
 }
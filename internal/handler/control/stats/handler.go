@@ -1,9 +1,11 @@
 package stats
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -121,14 +123,100 @@ func (h *StatsHandler) GetRealtime(c *gin.Context) {
 	c.JSON(http.StatusOK, realtime)
 }
 
+// liveHeartbeatInterval 为实时事件流的心跳间隔，避免代理因空闲断开连接
+const liveHeartbeatInterval = 15 * time.Second
+
+// GetLive 推送实时请求事件流，路径为 GET /api/stats/live。
+// 以 SSE 推送每个请求的开始（request.start）与完成（request.finish）事件；
+// 订阅者消费过慢时丢弃事件，并通过 dropped 事件告知丢弃数量。
+//
+// @Summary      订阅实时请求事件
+// @Description  以 SSE 推送请求开始与完成事件，支持按模型与平台过滤；按平台过滤时仅推送完成事件
+// @Tags         统计
+// @Produce      text/event-stream
+// @Param        model        query     string  false  "原始模型名称"
+// @Param        platform_id  query     int     false  "平台 ID"
+// @Success      200          {object}  stats.LiveEvent
+// @Failure      400          {object}  response.ErrorResponse  "参数格式错误"
+// @Failure      500          {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/stats/live [get]
+func (h *StatsHandler) GetLive(c *gin.Context) {
+	start := time.Now()
+	logger := h.newRequestLogger(c, "get_live")
+
+	filter := stats.LiveFilter{Model: c.Query("model")}
+	if platformIDStr := c.Query("platform_id"); platformIDStr != "" {
+		platformID, err := strconv.ParseUint(platformIDStr, 10, 32)
+		if err != nil {
+			logger.Warn("请求参数校验失败",
+				"error_type", "validation_error",
+				"error", err,
+				"platform_id", platformIDStr,
+			)
+			response.BadRequest(c, "平台 ID 格式错误")
+			return
+		}
+		filter.PlatformID = uint(platformID)
+	}
+
+	ctx := c.Request.Context()
+	sub, err := h.StatsService.SubscribeLive(ctx, filter)
+	if err != nil {
+		logger.Error("订阅实时事件失败",
+			"error", err,
+			"error_type", "service_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		response.InternalError(c, "订阅实时事件失败")
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	logger.Info("实时事件流已建立", "model", filter.Model, "platform_id", filter.PlatformID)
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("实时事件流已关闭", "latency_ms", time.Since(start).Milliseconds())
+			return
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if dropped := sub.TakeDropped(); dropped > 0 {
+				c.SSEvent("dropped", gin.H{"dropped": dropped})
+			}
+			c.SSEvent(event.Type, event)
+			c.Writer.Flush()
+		}
+	}
+}
+
 // GetModelStatus 获取模型状态监控数据。
 //
 // @Summary      获取模型状态监控数据
-// @Description  获取滑动窗口内模型请求总量、成功数、成功率，以及按颗粒度拆分的时间序列数据
+// @Description  获取滑动窗口内模型请求总量、成功数、总用时与首字用时分位数，以及按颗粒度拆分的时间序列数据
 // @Tags         统计
 // @Accept       json
 // @Produce      json
 // @Param        range       query     string  false  "时间范围"  Enums(24h, 7d, 30d)  default(24h)
+// @Param        start_time  query     string  false  "开始时间 (RFC3339 或 Unix 毫秒时间戳)，指定起止时间时忽略 range"
+// @Param        end_time    query     string  false  "结束时间 (RFC3339 或 Unix 毫秒时间戳)"
 // @Param        model_name  query     string  false  "模型名称（按请求原始模型名过滤）"
 // @Success      200         {object}  stats.ModelStatusResponse
 // @Failure      400         {object}  response.ErrorResponse  "无效的时间范围参数"
@@ -138,10 +226,116 @@ func (h *StatsHandler) GetModelStatus(c *gin.Context) {
 	start := time.Now()
 	logger := h.newRequestLogger(c, "get_model_status")
 
+	opts, ok := parseStatusOptions(c, logger)
+	if !ok {
+		return
+	}
+
+	logger = logger.With("range", opts.Range)
+	if opts.ModelName != nil {
+		logger = logger.With("model_name", *opts.ModelName)
+	}
+
+	result, err := h.StatsService.GetModelStatus(c.Request.Context(), opts)
+	if err != nil {
+		if errors.Is(err, stats.ErrInvalidArgument) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logger.Error("获取模型状态监控数据失败",
+			"error", err,
+			"error_type", "service_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		response.InternalError(c, "获取模型状态监控数据失败")
+		return
+	}
+
+	logger.Debug("获取模型状态监控数据成功",
+		"status_code", http.StatusOK,
+		"latency_ms", time.Since(start).Milliseconds(),
+		"model_count", len(result.Models),
+	)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPlatformStatus 获取平台状态监控数据。
+//
+// @Summary      获取平台状态监控数据
+// @Description  获取滑动窗口内各平台请求总量、成功数、总用时与首字用时分位数，以及按颗粒度拆分的时间序列数据；可按模型过滤以对比同一模型在不同平台的表现
+// @Tags         统计
+// @Accept       json
+// @Produce      json
+// @Param        range       query     string  false  "时间范围"  Enums(24h, 7d, 30d)  default(24h)
+// @Param        start_time  query     string  false  "开始时间 (RFC3339 或 Unix 毫秒时间戳)，指定起止时间时忽略 range"
+// @Param        end_time    query     string  false  "结束时间 (RFC3339 或 Unix 毫秒时间戳)"
+// @Param        model_name  query     string  false  "模型名称（按请求原始模型名过滤）"
+// @Success      200         {object}  stats.PlatformStatusResponse
+// @Failure      400         {object}  response.ErrorResponse  "无效的时间范围参数"
+// @Failure      500         {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/stats/platform-status [get]
+func (h *StatsHandler) GetPlatformStatus(c *gin.Context) {
+	start := time.Now()
+	logger := h.newRequestLogger(c, "get_platform_status")
+
+	opts, ok := parseStatusOptions(c, logger)
+	if !ok {
+		return
+	}
+
+	logger = logger.With("range", opts.Range)
+	if opts.ModelName != nil {
+		logger = logger.With("model_name", *opts.ModelName)
+	}
+
+	result, err := h.StatsService.GetPlatformStatus(c.Request.Context(), opts)
+	if err != nil {
+		if errors.Is(err, stats.ErrInvalidArgument) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logger.Error("获取平台状态监控数据失败",
+			"error", err,
+			"error_type", "service_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		response.InternalError(c, "获取平台状态监控数据失败")
+		return
+	}
+
+	logger.Debug("获取平台状态监控数据成功",
+		"status_code", http.StatusOK,
+		"latency_ms", time.Since(start).Milliseconds(),
+		"platform_count", len(result.Platforms),
+	)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetErrors 获取错误分析数据。
+//
+// @Summary      获取错误分析数据
+// @Description  将时间窗口内的失败请求按错误码、错误来源、HTTP 状态码、上游错误类型与上游错误码分组，返回各组按模型、平台与密钥的拆分、趋势与示例错误消息
+// @Tags         统计
+// @Accept       json
+// @Produce      json
+// @Param        range        query     string  false  "时间范围"  Enums(24h, 7d, 30d)  default(24h)
+// @Param        model_name   query     string  false  "模型名称（按请求原始模型名过滤）"
+// @Param        platform_id  query     int     false  "平台 ID"
+// @Param        api_key_id   query     int     false  "密钥 ID"
+// @Success      200          {object}  stats.ErrorAnalyticsResponse
+// @Failure      400          {object}  response.ErrorResponse  "参数错误"
+// @Failure      500          {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/stats/errors [get]
+func (h *StatsHandler) GetErrors(c *gin.Context) {
+	start := time.Now()
+	logger := h.newRequestLogger(c, "get_error_analytics")
+
 	rangeStr := c.DefaultQuery("range", string(stats.TrendRange24h))
-	trendRange := stats.TrendRange(rangeStr)
+	opts := stats.ErrorAnalyticsOptions{Range: stats.TrendRange(rangeStr)}
 
-	switch trendRange {
+	switch opts.Range {
 	case stats.TrendRange24h, stats.TrendRange7d, stats.TrendRange30d:
 		// 参数有效
 	default:
@@ -155,31 +349,120 @@ func (h *StatsHandler) GetModelStatus(c *gin.Context) {
 		return
 	}
 
-	var modelName *string
 	if v := c.Query("model_name"); v != "" {
-		modelName = &v
+		opts.ModelName = &v
+	}
+
+	for _, field := range []struct {
+		name   string
+		target **uint
+	}{
+		{"platform_id", &opts.PlatformID},
+		{"api_key_id", &opts.APIKeyID},
+	} {
+		value, err := query.OptionalUint(c, field.name)
+		if err != nil {
+			logger.Warn("请求参数校验失败",
+				"error", err,
+				"error_type", "validation_error",
+				"field", field.name,
+				"client_ip", c.ClientIP(),
+			)
+			response.BadRequest(c, err.Error())
+			return
+		}
+		*field.target = value
 	}
 
 	logger = logger.With("range", rangeStr)
-	if modelName != nil {
-		logger = logger.With("model_name", *modelName)
+
+	result, err := h.StatsService.GetErrorAnalytics(c.Request.Context(), opts)
+	if err != nil {
+		logger.Error("获取错误分析数据失败",
+			"error", err,
+			"error_type", "service_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		response.InternalError(c, "获取错误分析数据失败")
+		return
+	}
+
+	logger.Debug("获取错误分析数据成功",
+		"status_code", http.StatusOK,
+		"latency_ms", time.Since(start).Milliseconds(),
+		"group_count", len(result.Groups),
+	)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetAPIKeyUsage 处理按密钥用量报表请求，路径为 GET /api/stats/usage/keys。
+//
+// @Summary      获取按密钥的用量与费用报表
+// @Description  按上游密钥汇总时间窗口内的请求数、Token 用量与分币种费用，数据读取自小时用量汇总
+// @Tags         统计
+// @Accept       json
+// @Produce      json
+// @Param        range        query     string  false  "时间范围"  Enums(24h, 7d, 30d)  default(24h)
+// @Param        platform_id  query     int     false  "平台 ID"
+// @Param        model_name   query     string  false  "模型名称（按请求原始模型名过滤）"
+// @Success      200          {object}  stats.APIKeyUsageResponse
+// @Failure      400          {object}  response.ErrorResponse  "参数错误"
+// @Failure      500          {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/stats/usage/keys [get]
+func (h *StatsHandler) GetAPIKeyUsage(c *gin.Context) {
+	start := time.Now()
+	logger := h.newRequestLogger(c, "get_api_key_usage")
+
+	rangeStr := c.DefaultQuery("range", string(stats.TrendRange24h))
+	opts := stats.APIKeyUsageOptions{Range: stats.TrendRange(rangeStr)}
+
+	switch opts.Range {
+	case stats.TrendRange24h, stats.TrendRange7d, stats.TrendRange30d:
+		// 参数有效
+	default:
+		logger.Warn("请求参数校验失败",
+			"error_type", "validation_error",
+			"error", "无效的时间范围参数",
+			"range", rangeStr,
+			"client_ip", c.ClientIP(),
+		)
+		response.BadRequest(c, "无效的时间范围参数，可选值：24h, 7d, 30d")
+		return
 	}
 
-	result, err := h.StatsService.GetModelStatus(c.Request.Context(), trendRange, modelName)
+	platformID, err := query.OptionalUint(c, "platform_id")
 	if err != nil {
-		logger.Error("获取模型状态监控数据失败",
+		logger.Warn("请求参数校验失败",
+			"error", err,
+			"error_type", "validation_error",
+			"field", "platform_id",
+			"client_ip", c.ClientIP(),
+		)
+		response.BadRequest(c, err.Error())
+		return
+	}
+	opts.PlatformID = platformID
+
+	if v := c.Query("model_name"); v != "" {
+		opts.ModelName = &v
+	}
+
+	result, err := h.StatsService.GetAPIKeyUsage(c.Request.Context(), opts)
+	if err != nil {
+		logger.Error("获取密钥用量报表失败",
 			"error", err,
 			"error_type", "service_error",
 			"latency_ms", time.Since(start).Milliseconds(),
 		)
-		response.InternalError(c, "获取模型状态监控数据失败")
+		response.InternalError(c, "获取密钥用量报表失败")
 		return
 	}
 
-	logger.Debug("获取模型状态监控数据成功",
+	logger.Debug("获取密钥用量报表成功",
 		"status_code", http.StatusOK,
 		"latency_ms", time.Since(start).Milliseconds(),
-		"model_count", len(result.Models),
+		"key_count", len(result.Items),
 	)
 
 	c.JSON(http.StatusOK, result)
@@ -375,6 +658,107 @@ func (h *StatsHandler) ListRequestLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetRequestLog 获取单条请求日志详情
+//
+// 路径参数：
+//   - id: 数据面请求 ID（X-Request-ID）或请求日志 ID
+//
+// 返回值：
+//   - 成功：请求日志完整记录及路由详情
+//   - 失败：错误信息
+//
+// @Summary      获取请求日志详情
+// @Description  根据数据面请求 ID（X-Request-ID）获取最新一条请求日志，未匹配时按请求日志 ID 查找；返回完整记录，包括上游请求 ID、结构化错误字段与路由详情
+// @Tags         统计
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "请求 ID 或请求日志 ID"
+// @Success      200  {object}  stats.RequestLogDetail
+// @Failure      400  {object}  response.ErrorResponse  "参数错误"
+// @Failure      404  {object}  response.ErrorResponse  "请求日志未找到"
+// @Failure      500  {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/stats/requests/{id} [get]
+func (h *StatsHandler) GetRequestLog(c *gin.Context) {
+	start := time.Now()
+	logger := h.newRequestLogger(c, "get_request_log")
+
+	ref := strings.TrimSpace(c.Param("id"))
+	if ref == "" {
+		logger.Warn("请求参数校验失败",
+			"error_type", "validation_error",
+			"field", "id",
+			"client_ip", c.ClientIP(),
+		)
+		response.BadRequest(c, "无效的请求 ID")
+		return
+	}
+
+	logger = logger.With("request_log_ref", ref)
+
+	detail, err := h.StatsService.GetRequestLog(c.Request.Context(), ref)
+	if err != nil {
+		if errors.Is(err, stats.ErrResourceNotFound) {
+			logger.Debug("请求日志未找到",
+				"status_code", http.StatusNotFound,
+				"latency_ms", time.Since(start).Milliseconds(),
+			)
+			response.NotFound(c, "请求日志未找到")
+			return
+		}
+		logger.Error("获取请求日志详情失败",
+			"error", err,
+			"error_type", "service_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		response.InternalError(c, "获取请求日志详情失败")
+		return
+	}
+
+	logger.Debug("获取请求日志详情成功",
+		"status_code", http.StatusOK,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// GetRequestLogRetention 获取请求日志保留策略状态
+//
+// 返回值：
+//   - 成功：保留策略配置、最早原始日志时间、小时汇总记录数及最近一次运行记录
+//   - 失败：错误信息
+//
+// @Summary      获取请求日志保留策略状态
+// @Description  获取原始请求日志保留天数、当前截止时间与最近一次汇总清理任务的运行记录；任务详情可通过 /api/tasks/{taskId} 查询
+// @Tags         统计
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  stats.RequestLogRetentionStatus
+// @Failure      500  {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/stats/retention [get]
+func (h *StatsHandler) GetRequestLogRetention(c *gin.Context) {
+	start := time.Now()
+	logger := h.newRequestLogger(c, "get_request_log_retention")
+
+	status, err := h.StatsService.GetRequestLogRetention(c.Request.Context())
+	if err != nil {
+		logger.Error("获取请求日志保留策略状态失败",
+			"error", err,
+			"error_type", "service_error",
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		response.InternalError(c, "获取请求日志保留策略状态失败")
+		return
+	}
+
+	logger.Debug("获取请求日志保留策略状态成功",
+		"status_code", http.StatusOK,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	c.JSON(http.StatusOK, status)
+}
+
 func (h *StatsHandler) newRequestLogger(c *gin.Context, operation string) *slog.Logger {
 	return h.logger.With(
 		"operation", operation,
@@ -398,6 +782,58 @@ func requestIDFromContext(c *gin.Context) string {
 	return c.GetHeader("X-Request-Id")
 }
 
+// parseStatusOptions 解析模型与平台状态监控的查询参数，校验失败时写入错误响应并返回 false
+func parseStatusOptions(c *gin.Context, logger *slog.Logger) (stats.StatusOptions, bool) {
+	rangeStr := c.DefaultQuery("range", string(stats.TrendRange24h))
+	opts := stats.StatusOptions{Range: stats.TrendRange(rangeStr)}
+
+	switch opts.Range {
+	case stats.TrendRange24h, stats.TrendRange7d, stats.TrendRange30d:
+		// 参数有效
+	default:
+		logger.Warn("请求参数校验失败",
+			"error_type", "validation_error",
+			"error", "无效的时间范围参数",
+			"range", rangeStr,
+			"client_ip", c.ClientIP(),
+		)
+		response.BadRequest(c, "无效的时间范围参数，可选值：24h, 7d, 30d")
+		return opts, false
+	}
+
+	for _, field := range []struct {
+		name    string
+		message string
+		target  **time.Time
+	}{
+		{"start_time", "开始时间格式错误", &opts.StartTime},
+		{"end_time", "结束时间格式错误", &opts.EndTime},
+	} {
+		value := c.Query(field.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseTime(value)
+		if err != nil {
+			logger.Warn("请求参数校验失败",
+				"error", err,
+				"error_type", "validation_error",
+				"field", field.name,
+				"client_ip", c.ClientIP(),
+			)
+			response.BadRequest(c, field.message)
+			return opts, false
+		}
+		*field.target = &parsed
+	}
+
+	if v := c.Query("model_name"); v != "" {
+		opts.ModelName = &v
+	}
+
+	return opts, true
+}
+
 // parseTime 解析时间字符串，支持 RFC3339 格式和 Unix 时间戳 (毫秒)
 //
 // 参数：
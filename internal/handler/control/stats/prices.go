@@ -0,0 +1,217 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/handlers/query"
+	"github.com/MeowSalty/pinai/internal/app/provider"
+	"github.com/MeowSalty/pinai/internal/app/stats"
+	"github.com/MeowSalty/pinai/internal/handler/response"
+)
+
+// TaskEnqueuer 定义提交异步任务的能力，由供应商服务的任务运行时实现
+type TaskEnqueuer interface {
+	EnqueueTask(ctx context.Context, taskType string, payload any) (*provider.BatchTaskAcceptedResponse, error)
+}
+
+// PriceHandler 模型单价处理器结构体
+type PriceHandler struct {
+	statsService stats.Service
+	taskEnqueuer TaskEnqueuer
+	logger       *slog.Logger
+}
+
+// NewPriceHandler 创建模型单价处理器实例
+func NewPriceHandler(statsService stats.Service, taskEnqueuer TaskEnqueuer, logger *slog.Logger) *PriceHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &PriceHandler{
+		statsService: statsService,
+		taskEnqueuer: taskEnqueuer,
+		logger:       logger.With("component", "price_handler"),
+	}
+}
+
+// ListPrices godoc
+// @Summary      获取模型单价列表
+// @Tags         prices
+// @Produce      json
+// @Param        platform_id  query     int     false  "平台 ID，0 表示全局单价"
+// @Param        model_name   query     string  false  "模型名称"
+// @Success      200          {array}   types.ModelPrice        "模型单价列表"
+// @Failure      400          {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      500          {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/prices [get]
+func (h *PriceHandler) ListPrices(c *gin.Context) {
+	platformID, err := query.OptionalUint(c, "platform_id")
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	opts := stats.ListModelPricesOptions{PlatformID: platformID}
+	if v := c.Query("model_name"); v != "" {
+		opts.ModelName = &v
+	}
+
+	prices, err := h.statsService.ListModelPrices(c.Request.Context(), opts)
+	if err != nil {
+		h.respondError(c, err, "获取模型单价列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// CreatePrice godoc
+// @Summary      创建模型单价
+// @Description  单价以每百万 Token 计；platform_id 为 0 时对所有平台生效，effective_from 未指定时自始生效。新单价只影响之后写入的请求日志，历史日志需调用重算接口
+// @Tags         prices
+// @Accept       json
+// @Produce      json
+// @Param        request  body      stats.ModelPriceRequest  true  "模型单价"
+// @Success      201      {object}  types.ModelPrice         "创建的模型单价"
+// @Failure      400      {object}  response.ErrorResponse   "请求参数错误"
+// @Failure      500      {object}  response.ErrorResponse   "服务器内部错误"
+// @Router       /api/prices [post]
+func (h *PriceHandler) CreatePrice(c *gin.Context) {
+	var req stats.ModelPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	price, err := h.statsService.CreateModelPrice(c.Request.Context(), req)
+	if err != nil {
+		h.respondError(c, err, "创建模型单价失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, price)
+}
+
+// UpdatePrice godoc
+// @Summary      更新模型单价
+// @Tags         prices
+// @Accept       json
+// @Produce      json
+// @Param        priceId  path      int                      true  "单价 ID"
+// @Param        request  body      stats.ModelPriceRequest  true  "模型单价"
+// @Success      200      {object}  types.ModelPrice         "更新后的模型单价"
+// @Failure      400      {object}  response.ErrorResponse   "请求参数错误"
+// @Failure      404      {object}  response.ErrorResponse   "模型单价未找到"
+// @Failure      500      {object}  response.ErrorResponse   "服务器内部错误"
+// @Router       /api/prices/{priceId} [put]
+func (h *PriceHandler) UpdatePrice(c *gin.Context) {
+	id, ok := parsePriceID(c)
+	if !ok {
+		return
+	}
+
+	var req stats.ModelPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+
+	price, err := h.statsService.UpdateModelPrice(c.Request.Context(), id, req)
+	if err != nil {
+		h.respondError(c, err, "更新模型单价失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+// DeletePrice godoc
+// @Summary      删除模型单价
+// @Tags         prices
+// @Param        priceId  path  int  true  "单价 ID"
+// @Success      204  "删除成功"
+// @Failure      400  {object}  response.ErrorResponse  "请求参数错误"
+// @Failure      404  {object}  response.ErrorResponse  "模型单价未找到"
+// @Failure      500  {object}  response.ErrorResponse  "服务器内部错误"
+// @Router       /api/prices/{priceId} [delete]
+func (h *PriceHandler) DeletePrice(c *gin.Context) {
+	id, ok := parsePriceID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.statsService.DeleteModelPrice(c.Request.Context(), id); err != nil {
+		h.respondError(c, err, "删除模型单价失败")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RecomputeCosts godoc
+// @Summary      重算历史请求费用
+// @Description  按当前单价异步重算时间范围内原始请求日志的费用并修正用量汇总；已被保留策略清理的日志无法重算。任务进度可通过 /api/tasks/{taskId} 查询
+// @Tags         prices
+// @Accept       json
+// @Produce      json
+// @Param        request  body      stats.CostRecomputeRequest        true  "重算时间范围"
+// @Success      202      {object}  provider.BatchTaskAcceptedResponse  "任务已接受"
+// @Failure      400      {object}  response.ErrorResponse            "请求参数错误"
+// @Failure      500      {object}  response.ErrorResponse            "服务器内部错误"
+// @Router       /api/prices/recompute [post]
+func (h *PriceHandler) RecomputeCosts(c *gin.Context) {
+	var req stats.CostRecomputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, fmt.Sprintf("无法解析请求体: %v", err))
+		return
+	}
+	if req.StartTime.IsZero() || req.EndTime.IsZero() || !req.EndTime.After(req.StartTime) {
+		response.BadRequest(c, "重算时间范围无效，start_time 与 end_time 必填且结束时间须晚于起始时间")
+		return
+	}
+
+	accepted, err := h.taskEnqueuer.EnqueueTask(c.Request.Context(), types.TaskTypeRequestLogCostRecompute, req)
+	if err != nil {
+		h.logger.Error("提交费用重算任务失败", "error", err, "path", c.FullPath())
+		response.InternalError(c, "提交费用重算任务失败")
+		return
+	}
+
+	h.logger.Info("费用重算任务已提交",
+		"task_id", accepted.TaskID,
+		"start_time", req.StartTime,
+		"end_time", req.EndTime,
+	)
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// parsePriceID 解析路径中的单价 ID，失败时直接写入错误响应
+func parsePriceID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("priceId"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "无效的单价 ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *PriceHandler) respondError(c *gin.Context, err error, internalMessage string) {
+	switch {
+	case errors.Is(err, stats.ErrResourceNotFound):
+		response.NotFound(c, "模型单价未找到")
+	case errors.Is(err, stats.ErrInvalidArgument):
+		response.BadRequest(c, err.Error())
+	default:
+		h.logger.Error(internalMessage, "error", err, "path", c.FullPath())
+		response.InternalError(c, internalMessage)
+	}
+}
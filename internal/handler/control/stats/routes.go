@@ -8,8 +8,8 @@ import (
 	"github.com/MeowSalty/pinai/internal/app/stats"
 )
 
-// SetupStatsRoutes 配置统计相关的路由
-func SetupStatsRoutes(router *gin.RouterGroup, statsService stats.Service, logger *slog.Logger) {
+// SetupStatsRoutes 配置统计与模型单价相关的路由
+func SetupStatsRoutes(router *gin.RouterGroup, statsService stats.Service, taskEnqueuer TaskEnqueuer, logger *slog.Logger) {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -19,6 +19,21 @@ func SetupStatsRoutes(router *gin.RouterGroup, statsService stats.Service, logge
 	statsGroup := router.Group("/stats")
 	statsGroup.GET("/dashboard", handler.GetDashboard)
 	statsGroup.GET("/model-status", handler.GetModelStatus)
+	statsGroup.GET("/platform-status", handler.GetPlatformStatus)
+	statsGroup.GET("/errors", handler.GetErrors)
 	statsGroup.GET("/requests", handler.ListRequestLogs)
+	statsGroup.GET("/requests/:id", handler.GetRequestLog)
 	statsGroup.GET("/realtime", handler.GetRealtime)
+	statsGroup.GET("/live", handler.GetLive)
+	statsGroup.GET("/retention", handler.GetRequestLogRetention)
+	statsGroup.GET("/usage/keys", handler.GetAPIKeyUsage)
+
+	priceHandler := NewPriceHandler(statsService, taskEnqueuer, logger.WithGroup("handlers"))
+
+	priceGroup := router.Group("/prices")
+	priceGroup.GET("", priceHandler.ListPrices)
+	priceGroup.POST("", priceHandler.CreatePrice)
+	priceGroup.PUT("/:priceId", priceHandler.UpdatePrice)
+	priceGroup.DELETE("/:priceId", priceHandler.DeletePrice)
+	priceGroup.POST("/recompute", priceHandler.RecomputeCosts)
 }
@@ -1,8 +1,11 @@
 package common
 
 import (
+	"math"
+	"strconv"
 	"strings"
 
+	"github.com/MeowSalty/pinai/internal/app/gateway"
 	"github.com/gin-gonic/gin"
 )
 
@@ -58,3 +61,11 @@ func SetBaseSSEHeaders(c *gin.Context) {
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
 }
+
+// SetRetryAfterHeader 在错误带有建议等待时长时设置 Retry-After 响应头（秒，向上取整）。
+func SetRetryAfterHeader(c *gin.Context, mappedErr *gateway.DataPlaneError) {
+	if mappedErr == nil || mappedErr.RetryAfter <= 0 {
+		return
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(mappedErr.RetryAfter.Seconds()))))
+}
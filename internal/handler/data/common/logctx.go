@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/MeowSalty/pinai/internal/app/gateway"
+	"github.com/MeowSalty/pinai/internal/app/stats"
 	"github.com/gin-gonic/gin"
 )
 
@@ -37,6 +38,9 @@ type RequestLogContext struct {
 	ClientIP    string
 	UserAgent   string
 	Extra       map[string]string
+
+	// requestKey 为进程内唯一的请求标识（见 stats.NewRequestKey），副本之间共享
+	requestKey uint64
 }
 
 type contextKey struct{}
@@ -63,6 +67,7 @@ func NewRequestLogContext(c *gin.Context, provider, apiStyle, requestName string
 	lc.Provider = provider
 	lc.APIStyle = apiStyle
 	lc.RequestName = requestName
+	lc.requestKey = stats.NewRequestKey()
 
 	return lc
 }
@@ -95,8 +100,10 @@ func (lc RequestLogContext) WithExtra(kvs map[string]string) RequestLogContext {
 // WithContext 将 RequestLogContext 写入 context.Context。
 //
 // Handler 层调用此方法将日志上下文附加到请求的 context 中，
-// 后续 Gateway 层可通过 FromContext 读取。
+// 后续 Gateway 层可通过 FromContext 读取；同时写入统计请求信息，
+// 供请求日志落库时关联请求 ID 与客户端信息。
 func (lc RequestLogContext) WithContext(ctx context.Context) context.Context {
+	ctx = stats.ContextWithRequestInfo(ctx, lc.RequestInfo())
 	return context.WithValue(ctx, logCtxKey, lc)
 }
 
@@ -211,3 +218,18 @@ func init() {
 		return nil
 	})
 }
+
+// RequestInfo 转换为统计采集器使用的请求信息。
+//
+// 流式场景通过 Extra 中的 flow=stream 标记识别。
+func (lc RequestLogContext) RequestInfo() stats.RequestInfo {
+	return stats.RequestInfo{
+		Key:       lc.requestKey,
+		RequestID: lc.RequestID,
+		Model:     lc.Model,
+		Provider:  lc.Provider,
+		APIStyle:  lc.APIStyle,
+		ClientIP:  lc.ClientIP,
+		Stream:    lc.Extra["flow"] == "stream",
+	}
+}
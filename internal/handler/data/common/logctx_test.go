@@ -348,3 +348,42 @@ func attrsToMap(attrs []any) map[string]string {
 	}
 	return m
 }
+
+// --- EnsureRequestID 测试 ---
+
+func TestEnsureRequestID_沿用入站请求ID(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/multi/v1/chat/completions", nil)
+	c.Request.Header.Set("X-Correlation-ID", "corr-456")
+
+	requestID := EnsureRequestID(c)
+
+	if requestID != "corr-456" {
+		t.Errorf("RequestID = %q, 期望 %q", requestID, "corr-456")
+	}
+	if got := w.Header().Get(RequestIDHeader); got != "corr-456" {
+		t.Errorf("响应头 %s = %q, 期望 %q", RequestIDHeader, got, "corr-456")
+	}
+	if lc := NewRequestLogContext(c, "openai", "compat", "chat_completions"); lc.RequestID != "corr-456" {
+		t.Errorf("日志上下文 RequestID = %q, 期望 %q", lc.RequestID, "corr-456")
+	}
+}
+
+func TestEnsureRequestID_缺失时生成(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/multi/v1/chat/completions", nil)
+
+	requestID := EnsureRequestID(c)
+
+	if len(requestID) != 32 {
+		t.Fatalf("生成的 RequestID 长度 = %d, 期望 32", len(requestID))
+	}
+	if got := w.Header().Get(RequestIDHeader); got != requestID {
+		t.Errorf("响应头 %s = %q, 期望 %q", RequestIDHeader, got, requestID)
+	}
+	if again := EnsureRequestID(c); again != requestID {
+		t.Errorf("重复调用应返回同一 ID，实际 = %q, 期望 %q", again, requestID)
+	}
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	sloggin "github.com/samber/slog-gin"
+)
+
+// RequestIDHeader 是数据面回显请求 ID 的响应头。
+const RequestIDHeader = "X-Request-ID"
+
+// EnsureRequestID 确保当前请求拥有请求 ID，并将其写入 gin.Context 与响应头。
+//
+// 优先沿用入站请求 ID（与 requestIDFromGinContext 的读取顺序一致），
+// 其次沿用访问日志中间件生成的 ID，均缺失时生成新的 ID。
+// 响应头在处理器写出响应前设置，因此对流式响应同样生效。
+func EnsureRequestID(c *gin.Context) string {
+	requestID := requestIDFromGinContext(c)
+	if requestID == "" {
+		requestID = sloggin.GetRequestID(c)
+	}
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+
+	c.Set("request_id", requestID)
+	c.Header(RequestIDHeader, requestID)
+
+	return requestID
+}
+
+// newRequestID 生成 32 位十六进制的随机请求 ID。
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -58,8 +58,8 @@ func (h *Handler) Messages(c *gin.Context) {
 	}
 
 	if h.collector != nil {
-		h.collector.IncrementConnection()
-		defer h.collector.DecrementConnection()
+		h.collector.IncrementConnection(logCtx.RequestInfo())
+		defer h.collector.DecrementConnection(logCtx.RequestInfo())
 	}
 
 	// 非流式响应
@@ -67,6 +67,7 @@ func (h *Handler) Messages(c *gin.Context) {
 	resp, err := h.gatewayService.AnthropicCompatMessages(ctx, &req)
 	if err != nil {
 		mappedErr := h.gatewayService.MapDataPlaneError(err, "处理请求时出错")
+		common.SetRetryAfterHeader(c, &mappedErr)
 		c.JSON(mappedErr.StatusCode, common.NewAnthropicErrorResponse(mappedErr.Message, mappedErr.StatusCode, err, &mappedErr))
 		return
 	}
@@ -92,12 +93,12 @@ func (h *Handler) handleAnthropicStreamResponse(c *gin.Context, req *anthropicTy
 		}
 		connectionReleased = true
 		if h.collector != nil {
-			h.collector.DecrementConnection()
+			h.collector.DecrementConnection(streamLogCtx.RequestInfo())
 		}
 	}
 
 	if h.collector != nil {
-		h.collector.IncrementConnection()
+		h.collector.IncrementConnection(streamLogCtx.RequestInfo())
 	}
 	defer releaseConnection()
 
@@ -63,14 +63,15 @@ func (h *Handler) GeminiGenerateContent(c *gin.Context) {
 	common.ApplyHTTPHeaders(req.Headers, h.userAgent, h.passthroughHeaders, c)
 
 	if h.collector != nil {
-		h.collector.IncrementConnection()
-		defer h.collector.DecrementConnection()
+		h.collector.IncrementConnection(logCtx.RequestInfo())
+		defer h.collector.DecrementConnection(logCtx.RequestInfo())
 	}
 
 	ctx := logCtx.WithContext(c.Request.Context())
 	resp, err := h.gatewayService.GeminiCompatGenerateContent(ctx, &req)
 	if err != nil {
 		mappedErr := h.gatewayService.MapDataPlaneError(err, "处理请求时出错")
+		common.SetRetryAfterHeader(c, &mappedErr)
 		common.WriteGeminiJSONError(c, mappedErr.StatusCode, mappedErr.Message, err, &mappedErr)
 		return
 	}
@@ -142,12 +143,12 @@ func (h *Handler) handleGeminiStreamResponse(c *gin.Context, req *geminiTypes.Re
 	connectionCounted := false
 	releaseConnection := func() {
 		if h.collector != nil && connectionCounted {
-			h.collector.DecrementConnection()
+			h.collector.DecrementConnection(streamLogCtx.RequestInfo())
 			connectionCounted = false
 		}
 	}
 	if h.collector != nil {
-		h.collector.IncrementConnection()
+		h.collector.IncrementConnection(streamLogCtx.RequestInfo())
 		connectionCounted = true
 	}
 	defer releaseConnection()
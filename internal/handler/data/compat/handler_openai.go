@@ -63,14 +63,15 @@ func (h *Handler) ChatCompletions(c *gin.Context) {
 
 	// 非流式响应
 	if h.collector != nil {
-		h.collector.IncrementConnection()
-		defer h.collector.DecrementConnection()
+		h.collector.IncrementConnection(logCtx.RequestInfo())
+		defer h.collector.DecrementConnection(logCtx.RequestInfo())
 	}
 
 	ctx := logCtx.WithContext(c.Request.Context())
 	resp, err := h.gatewayService.OpenAICompatChatCompletion(ctx, &req)
 	if err != nil {
 		mappedErr := h.gatewayService.MapDataPlaneError(err, "处理请求时出错")
+		common.SetRetryAfterHeader(c, &mappedErr)
 		c.JSON(
 			mappedErr.StatusCode,
 			common.NewOpenAIHTTPErrorResponse(mappedErr.Message, mappedErr.StatusCode, err, &mappedErr),
@@ -127,14 +128,15 @@ func (h *Handler) Responses(c *gin.Context) {
 	}
 
 	if h.collector != nil {
-		h.collector.IncrementConnection()
-		defer h.collector.DecrementConnection()
+		h.collector.IncrementConnection(logCtx.RequestInfo())
+		defer h.collector.DecrementConnection(logCtx.RequestInfo())
 	}
 
 	ctx := logCtx.WithContext(c.Request.Context())
 	resp, err := h.gatewayService.OpenAICompatResponses(ctx, &req)
 	if err != nil {
 		mappedErr := h.gatewayService.MapDataPlaneError(err, "处理请求时出错")
+		common.SetRetryAfterHeader(c, &mappedErr)
 		c.JSON(
 			mappedErr.StatusCode,
 			common.NewOpenAIHTTPErrorResponse(mappedErr.Message, mappedErr.StatusCode, err, &mappedErr),
@@ -155,12 +157,12 @@ func (h *Handler) streamOpenAIChat(c *gin.Context, req *openaiChatTypes.Request,
 	connectionCounted := false
 	releaseConnection := func() {
 		if h.collector != nil && connectionCounted {
-			h.collector.DecrementConnection()
+			h.collector.DecrementConnection(streamLogCtx.RequestInfo())
 			connectionCounted = false
 		}
 	}
 	if h.collector != nil {
-		h.collector.IncrementConnection()
+		h.collector.IncrementConnection(streamLogCtx.RequestInfo())
 		connectionCounted = true
 	}
 	defer releaseConnection()
@@ -313,12 +315,12 @@ func (h *Handler) streamOpenAIResponses(c *gin.Context, req *openaiResponsesType
 	connectionCounted := false
 	releaseConnection := func() {
 		if h.collector != nil && connectionCounted {
-			h.collector.DecrementConnection()
+			h.collector.DecrementConnection(streamLogCtx.RequestInfo())
 			connectionCounted = false
 		}
 	}
 	if h.collector != nil {
-		h.collector.IncrementConnection()
+		h.collector.IncrementConnection(streamLogCtx.RequestInfo())
 		connectionCounted = true
 	}
 	defer releaseConnection()
@@ -55,14 +55,15 @@ func (h *Handler) AnthropicMessages(c *gin.Context) {
 	}
 
 	if h.collector != nil {
-		h.collector.IncrementConnection()
-		defer h.collector.DecrementConnection()
+		h.collector.IncrementConnection(logCtx.RequestInfo())
+		defer h.collector.DecrementConnection(logCtx.RequestInfo())
 	}
 
 	ctx := logCtx.WithContext(c.Request.Context())
 	resp, err := h.gatewayService.AnthropicNativeMessages(ctx, &req)
 	if err != nil {
 		mappedErr := h.gatewayService.MapDataPlaneError(err, "请求失败")
+		common.SetRetryAfterHeader(c, &mappedErr)
 		c.JSON(mappedErr.StatusCode, common.NewAnthropicErrorResponse(mappedErr.Message, mappedErr.StatusCode, err, &mappedErr))
 		return
 	}
@@ -82,11 +83,11 @@ func (h *Handler) streamAnthropic(c *gin.Context, req *anthropicTypes.Request, l
 		if h.collector == nil || connectionReleased {
 			return
 		}
-		h.collector.DecrementConnection()
+		h.collector.DecrementConnection(streamLogCtx.RequestInfo())
 		connectionReleased = true
 	}
 	if h.collector != nil {
-		h.collector.IncrementConnection()
+		h.collector.IncrementConnection(streamLogCtx.RequestInfo())
 	}
 	defer releaseConnection()
 
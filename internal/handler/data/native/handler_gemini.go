@@ -63,14 +63,15 @@ func (h *Handler) GeminiGenerateContent(c *gin.Context) {
 	logCtx = logCtx.WithModel(req.Model)
 
 	if h.collector != nil {
-		h.collector.IncrementConnection()
-		defer h.collector.DecrementConnection()
+		h.collector.IncrementConnection(logCtx.RequestInfo())
+		defer h.collector.DecrementConnection(logCtx.RequestInfo())
 	}
 
 	ctx := logCtx.WithContext(c.Request.Context())
 	resp, err := h.gatewayService.GeminiNativeGenerateContent(ctx, &req)
 	if err != nil {
 		mappedErr := h.gatewayService.MapDataPlaneError(err, "请求失败")
+		common.SetRetryAfterHeader(c, &mappedErr)
 		common.WriteGeminiJSONError(c, mappedErr.StatusCode, mappedErr.Message, err, &mappedErr)
 		return
 	}
@@ -140,11 +141,11 @@ func (h *Handler) streamGemini(c *gin.Context, req *geminiTypes.Request, logCtx
 		if h.collector == nil || connectionReleased {
 			return
 		}
-		h.collector.DecrementConnection()
+		h.collector.DecrementConnection(streamLogCtx.RequestInfo())
 		connectionReleased = true
 	}
 	if h.collector != nil {
-		h.collector.IncrementConnection()
+		h.collector.IncrementConnection(streamLogCtx.RequestInfo())
 	}
 	defer releaseConnection()
 
@@ -58,10 +58,16 @@ func (h *Handler) OpenAIChatCompletions(c *gin.Context) {
 		return
 	}
 
+	if h.collector != nil {
+		h.collector.IncrementConnection(logCtx.RequestInfo())
+		defer h.collector.DecrementConnection(logCtx.RequestInfo())
+	}
+
 	ctx := logCtx.WithContext(c.Request.Context())
 	resp, err := h.gatewayService.OpenAINativeChatCompletion(ctx, &req)
 	if err != nil {
 		mappedErr := h.gatewayService.MapDataPlaneError(err, "请求失败")
+		common.SetRetryAfterHeader(c, &mappedErr)
 		c.JSON(
 			mappedErr.StatusCode,
 			common.NewOpenAIHTTPErrorResponse(mappedErr.Message, mappedErr.StatusCode, err, &mappedErr),
@@ -118,14 +124,15 @@ func (h *Handler) OpenAIResponses(c *gin.Context) {
 	}
 
 	if h.collector != nil {
-		h.collector.IncrementConnection()
-		defer h.collector.DecrementConnection()
+		h.collector.IncrementConnection(logCtx.RequestInfo())
+		defer h.collector.DecrementConnection(logCtx.RequestInfo())
 	}
 
 	ctx := logCtx.WithContext(c.Request.Context())
 	resp, err := h.gatewayService.OpenAINativeResponses(ctx, &req)
 	if err != nil {
 		mappedErr := h.gatewayService.MapDataPlaneError(err, "请求失败")
+		common.SetRetryAfterHeader(c, &mappedErr)
 		c.JSON(
 			mappedErr.StatusCode,
 			common.NewOpenAIHTTPErrorResponse(mappedErr.Message, mappedErr.StatusCode, err, &mappedErr),
@@ -148,11 +155,11 @@ func (h *Handler) streamOpenAIChat(c *gin.Context, req *openaiChatTypes.Request,
 		if h.collector == nil || connectionReleased {
 			return
 		}
-		h.collector.DecrementConnection()
+		h.collector.DecrementConnection(streamLogCtx.RequestInfo())
 		connectionReleased = true
 	}
 	if h.collector != nil {
-		h.collector.IncrementConnection()
+		h.collector.IncrementConnection(streamLogCtx.RequestInfo())
 	}
 	defer releaseConnection()
 
@@ -314,11 +321,11 @@ func (h *Handler) streamOpenAIResponses(c *gin.Context, req *openaiResponsesType
 		if h.collector == nil || connectionReleased {
 			return
 		}
-		h.collector.DecrementConnection()
+		h.collector.DecrementConnection(streamLogCtx.RequestInfo())
 		connectionReleased = true
 	}
 	if h.collector != nil {
-		h.collector.IncrementConnection()
+		h.collector.IncrementConnection(streamLogCtx.RequestInfo())
 	}
 	defer releaseConnection()
 
@@ -3,6 +3,7 @@ package portal
 import (
 	"context"
 
+	"github.com/MeowSalty/pinai/internal/infra/portal/repository"
 	portalTypes "github.com/MeowSalty/portal"
 	anthropicTypes "github.com/MeowSalty/portal/request/adapter/anthropic/types"
 )
@@ -19,6 +20,9 @@ func (s *facadeService) NativeAnthropicMessages(ctx context.Context, req *anthro
 		req.Model = mappedModel
 	}
 
+	release := s.tracker.Track(ctx, req.Model, false)
+	defer release()
+
 	return s.runtime.NativeAnthropicMessages(ctx, req, opts...)
 }
 
@@ -35,7 +39,8 @@ func (s *facadeService) NativeAnthropicMessagesStream(ctx context.Context, req *
 		req.Model = mappedModel
 	}
 
-	stream := s.runtime.NativeAnthropicMessagesStream(ctx, req, opts...)
+	release := s.tracker.Track(ctx, req.Model, true)
+	stream := repository.TrackStream(ctx, release, s.runtime.NativeAnthropicMessagesStream(ctx, req, opts...))
 	streamLogger.Info("Anthropic 原生流启动成功", "model", req.Model, "original_model", originalModel)
 	return stream
 }
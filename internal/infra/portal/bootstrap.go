@@ -16,12 +16,14 @@ import (
 type portalFacadeDependencies struct {
 	Runtime          gatewayRuntime
 	ModelMappingRule map[string]string
+	RequestTracker   *repository.RequestTracker
 }
 
 // assemblePortalFacadeDependencies 负责收口 Portal facade 的依赖装配。
-func assemblePortalFacadeDependencies(logger *slog.Logger, modelMappingStr string, healthStorage HealthStorage) (*portalFacadeDependencies, error) {
-	repo := repository.New(logger)
+func assemblePortalFacadeDependencies(logger *slog.Logger, modelMappingStr string, healthStorage HealthStorage, logObserver RequestLogObserver) (*portalFacadeDependencies, error) {
 	health := healthadapter.New(healthStorage)
+	tracker := repository.NewRequestTracker()
+	repo := repository.New(logger, logObserver, health, tracker)
 
 	runtime, err := newGatewayRuntime(logger, repo, health)
 	if err != nil {
@@ -36,6 +38,7 @@ func assemblePortalFacadeDependencies(logger *slog.Logger, modelMappingStr strin
 	return &portalFacadeDependencies{
 		Runtime:          runtime,
 		ModelMappingRule: modelMappingRule,
+		RequestTracker:   tracker,
 	}, nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/infra/portal/repository"
 	adapterTypes "github.com/MeowSalty/portal/request/adapter/types"
 )
 
@@ -14,8 +15,20 @@ type HealthStorage interface {
 	Get(resourceType types.ResourceType, resourceID uint) (*types.Health, error)
 	Set(status *types.Health) error
 	Delete(resourceType types.ResourceType, resourceID uint) error
+	ApplyPolicy(previous, next *types.Health)
+	IsKeyModelAvailable(keyID, modelID uint) bool
+	IsKeyNearlyExhausted(keyID uint) bool
+	ChannelAvailableAt(platformID, modelID, keyID uint, now time.Time) (time.Time, bool)
 }
 
+// RequestLogObserver 定义请求日志写入后的观察者最小契约，如实时统计采集器；落库失败时同样会被通知。
+type RequestLogObserver interface {
+	ObserveRequestLog(log *types.RequestLog)
+}
+
+// RequestRouteObserver 为请求日志观察者的可选扩展，在路由候选通道确定唯一平台时接收通知。
+type RequestRouteObserver = repository.RequestRouteObserver
+
 // ChatCompletion 处理聊天完成请求
 //
 // 提供统一的聊天完成处理入口，包含日志记录和错误处理
@@ -32,6 +45,9 @@ func (s *facadeService) ChatCompletion(ctx context.Context, req *adapterTypes.Re
 		req.Model = mappedModel
 	}
 
+	release := s.tracker.Track(ctx, req.Model, false)
+	defer release()
+
 	startTime := time.Now()
 
 	resp, err := s.runtime.ChatCompletion(ctx, req)
@@ -71,7 +87,8 @@ func (s *facadeService) ChatCompletionStream(ctx context.Context, req *adapterTy
 	}
 
 	streamLogger.Debug("正在启动流式处理")
-	stream := s.runtime.ChatCompletionStream(ctx, req)
+	release := s.tracker.Track(ctx, req.Model, true)
+	stream := repository.TrackStream(ctx, release, s.runtime.ChatCompletionStream(ctx, req))
 
 	streamLogger.Info("聊天完成流启动成功", "model", req.Model)
 	return stream, nil
@@ -17,6 +17,7 @@ import (
 type AssembledDependencies struct {
 	Runtime          runtimepkg.Runtime
 	ModelMappingRule map[string]string
+	RequestTracker   *repository.RequestTracker
 }
 
 // BuildServiceDependencies 构建 Portal 服务所需依赖。
@@ -24,10 +25,12 @@ func BuildServiceDependencies(
 	logger *slog.Logger,
 	modelMappingStr string,
 	healthStorage healthadapter.HealthStorage,
+	logObserver repository.RequestLogObserver,
 	parseModelMapping func(string) (map[string]string, error),
 ) (*AssembledDependencies, error) {
-	repo := repository.New(logger)
 	health := healthadapter.New(healthStorage)
+	tracker := repository.NewRequestTracker()
+	repo := repository.New(logger, logObserver, health, tracker)
 
 	runtime, err := newPortalRuntime(logger, repo, health)
 	if err != nil {
@@ -42,6 +45,7 @@ func BuildServiceDependencies(
 	return &AssembledDependencies{
 		Runtime:          runtime,
 		ModelMappingRule: modelMappingRule,
+		RequestTracker:   tracker,
 	}, nil
 }
 
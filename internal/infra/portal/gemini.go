@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/MeowSalty/pinai/internal/infra/portal/repository"
 	portalTypes "github.com/MeowSalty/portal"
 	geminiTypes "github.com/MeowSalty/portal/request/adapter/gemini/types"
 )
@@ -22,6 +23,9 @@ func (s *facadeService) NativeGeminiGenerateContent(ctx context.Context, req *ge
 		req.Model = mappedModel
 	}
 
+	release := s.tracker.Track(ctx, req.Model, false)
+	defer release()
+
 	startTime := time.Now()
 	resp, err := s.runtime.NativeGeminiGenerateContent(ctx, req, opts...)
 	duration := time.Since(startTime)
@@ -56,7 +60,8 @@ func (s *facadeService) NativeGeminiStreamGenerateContent(ctx context.Context, r
 		req.Model = mappedModel
 	}
 
-	stream := s.runtime.NativeGeminiStreamGenerateContent(ctx, req, opts...)
+	release := s.tracker.Track(ctx, req.Model, true)
+	stream := repository.TrackStream(ctx, release, s.runtime.NativeGeminiStreamGenerateContent(ctx, req, opts...))
 	streamLogger.Info("Gemini 原生流启动成功", "model", req.Model, "original_model", originalModel)
 	return stream
 }
@@ -1,7 +1,11 @@
 package healthadapter
 
 import (
+	"errors"
+	"time"
+
 	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/health"
 	coreHealth "github.com/MeowSalty/portal/routing/health"
 )
 
@@ -10,6 +14,10 @@ type HealthStorage interface {
 	Get(resourceType types.ResourceType, resourceID uint) (*types.Health, error)
 	Set(status *types.Health) error
 	Delete(resourceType types.ResourceType, resourceID uint) error
+	ApplyPolicy(previous, next *types.Health)
+	IsKeyModelAvailable(keyID, modelID uint) bool
+	IsKeyNearlyExhausted(keyID uint) bool
+	ChannelAvailableAt(platformID, modelID, keyID uint, now time.Time) (time.Time, bool)
 }
 
 // Adapter 适配器，将内部 health.Storage 转换为 portal 需要的 health.Storage 接口。
@@ -80,7 +88,21 @@ func (a *Adapter) Set(status *coreHealth.Health) error {
 		UpdatedAt:               status.UpdatedAt,
 	}
 
-	return a.storage.Set(internalStatus)
+	// 按 pinai 健康策略修正 portal 库计算的退避与状态
+	previous, err := a.storage.Get(internalStatus.ResourceType, internalStatus.ResourceID)
+	if err != nil {
+		return err
+	}
+	a.storage.ApplyPolicy(previous, internalStatus)
+
+	if err := a.storage.Set(internalStatus); err != nil {
+		// portal 基于过期记录计算的结果不再写入：其他实例的变更已刷新到缓存，以其为准
+		if errors.Is(err, health.ErrHealthConflict) {
+			return nil
+		}
+		return err
+	}
+	return nil
 }
 
 // Delete 实现 portal health.Storage 接口的 Delete 方法。
@@ -90,6 +112,21 @@ func (a *Adapter) Delete(resourceType coreHealth.ResourceType, resourceID uint)
 	return a.storage.Delete(internalResourceType, resourceID)
 }
 
+// IsKeyModelAvailable 判断密钥-模型组合是否可参与路由，供仓储在构建通道前过滤密钥。
+func (a *Adapter) IsKeyModelAvailable(keyID, modelID uint) bool {
+	return a.storage.IsKeyModelAvailable(keyID, modelID)
+}
+
+// IsKeyNearlyExhausted 判断密钥剩余额度是否即将耗尽，供仓储降低其路由优先级。
+func (a *Adapter) IsKeyNearlyExhausted(keyID uint) bool {
+	return a.storage.IsKeyNearlyExhausted(keyID)
+}
+
+// ChannelAvailableAt 返回通道最早可参与路由的时间，供网关计算 Retry-After。
+func (a *Adapter) ChannelAvailableAt(platformID, modelID, keyID uint, now time.Time) (time.Time, bool) {
+	return a.storage.ChannelAvailableAt(platformID, modelID, keyID, now)
+}
+
 // convertResourceTypeToInternal 将 portal 库的 ResourceType 转换为内部 health 包的 ResourceType。
 func convertResourceTypeToInternal(portalType coreHealth.ResourceType) types.ResourceType {
 	// 直接类型转换，因为它们应该有相同的值定义
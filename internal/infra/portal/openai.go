@@ -3,6 +3,7 @@ package portal
 import (
 	"context"
 
+	"github.com/MeowSalty/pinai/internal/infra/portal/repository"
 	portalTypes "github.com/MeowSalty/portal"
 	openaiChatTypes "github.com/MeowSalty/portal/request/adapter/openai/types/chat"
 	openaiResponsesTypes "github.com/MeowSalty/portal/request/adapter/openai/types/responses"
@@ -18,6 +19,9 @@ func (s *facadeService) NativeOpenAIChatCompletion(ctx context.Context, req *ope
 			"mapped_model", mappedModel)
 	}
 
+	release := s.tracker.Track(ctx, req.Model, false)
+	defer release()
+
 	return s.runtime.NativeOpenAIChatCompletion(ctx, req, opts...)
 }
 
@@ -34,7 +38,8 @@ func (s *facadeService) NativeOpenAIChatCompletionStream(ctx context.Context, re
 		req.Model = mappedModel
 	}
 
-	stream := s.runtime.NativeOpenAIChatCompletionStream(ctx, req, opts...)
+	release := s.tracker.Track(ctx, req.Model, true)
+	stream := repository.TrackStream(ctx, release, s.runtime.NativeOpenAIChatCompletionStream(ctx, req, opts...))
 	streamLogger.Info("OpenAI Chat 原生流启动成功", "model", req.Model, "original_model", originalModel)
 	return stream
 }
@@ -57,6 +62,9 @@ func (s *facadeService) NativeOpenAIResponses(ctx context.Context, req *openaiRe
 		}
 	}
 
+	release := s.tracker.Track(ctx, modelName, false)
+	defer release()
+
 	return s.runtime.NativeOpenAIResponses(ctx, req, opts...)
 }
 
@@ -81,7 +89,8 @@ func (s *facadeService) NativeOpenAIResponsesStream(ctx context.Context, req *op
 		}
 	}
 
-	stream := s.runtime.NativeOpenAIResponsesStream(ctx, req, opts...)
+	release := s.tracker.Track(ctx, modelName, true)
+	stream := repository.TrackStream(ctx, release, s.runtime.NativeOpenAIResponsesStream(ctx, req, opts...))
 	streamLogger.Info("OpenAI Responses 原生流启动成功", "model", modelName, "original_model", originalModel)
 	return stream
 }
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/MeowSalty/pinai/database/query"
 	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/gateway"
+	"github.com/MeowSalty/pinai/internal/app/stats"
 	"github.com/MeowSalty/portal/request"
 	"github.com/MeowSalty/portal/routing"
 )
@@ -15,12 +18,74 @@ import (
 //
 // 仅实现 portal runtime 装配所需的数据查询与日志落库能力。
 type Repository struct {
-	logger *slog.Logger
+	logger        *slog.Logger
+	observer      RequestLogObserver
+	channelHealth ChannelHealth
+	tracker       *RequestTracker
 }
 
-// New 创建仓储适配器。
-func New(logger *slog.Logger) *Repository {
-	return &Repository{logger: logger.WithGroup("database_repository")}
+// maxRequestIDLength 为请求日志中请求 ID 的最大长度
+const maxRequestIDLength = 128
+
+// RequestLogObserver 定义请求日志写入后的观察者最小契约，落库失败时同样会被通知。
+type RequestLogObserver interface {
+	ObserveRequestLog(log *types.RequestLog)
+}
+
+// RequestRouteObserver 为请求日志观察者的可选扩展，在路由候选通道确定唯一平台时接收通知。
+//
+// info 为 ctx 携带的数据面请求信息，PlatformID 为候选通道所属的平台。
+type RequestRouteObserver interface {
+	ObserveRequestRoute(info stats.RequestInfo)
+}
+
+// ChannelHealth 定义构建通道时所需的健康状态查询最小契约。
+type ChannelHealth interface {
+	IsKeyModelAvailable(keyID, modelID uint) bool
+	IsKeyNearlyExhausted(keyID uint) bool
+	ChannelAvailableAt(platformID, modelID, keyID uint, now time.Time) (time.Time, bool)
+}
+
+// New 创建仓储适配器；observer、channelHealth 与 tracker 可为 nil。
+func New(logger *slog.Logger, observer RequestLogObserver, channelHealth ChannelHealth, tracker *RequestTracker) *Repository {
+	return &Repository{logger: logger.WithGroup("database_repository"), observer: observer, channelHealth: channelHealth, tracker: tracker}
+}
+
+// convertModelKeys 转换模型关联的密钥，并排除该模型上处于退避中的密钥。
+//
+// 上游报告剩余额度即将耗尽的密钥仅在没有其他密钥时参与路由；
+// 同时向网关上报候选通道中最早恢复可用的时间，用于所有通道冷却时返回 Retry-After。
+func (r *Repository) convertModelKeys(ctx context.Context, platformID, modelID uint, dbKeys []types.APIKey) []routing.APIKey {
+	apiKeys := make([]routing.APIKey, 0, len(dbKeys))
+	lowQuotaKeys := make([]routing.APIKey, 0)
+	now := time.Now()
+	for _, dbKey := range dbKeys {
+		key := routing.APIKey{
+			ID:    dbKey.ID,
+			Value: dbKey.Value,
+		}
+		if r.channelHealth == nil {
+			apiKeys = append(apiKeys, key)
+			continue
+		}
+		if availableAt, ok := r.channelHealth.ChannelAvailableAt(platformID, modelID, dbKey.ID, now); ok {
+			gateway.ObserveChannelCooldown(ctx, availableAt)
+		}
+		if !r.channelHealth.IsKeyModelAvailable(dbKey.ID, modelID) {
+			r.logger.Debug("密钥在该模型上不可用，跳过", "api_key_id", dbKey.ID, "model_id", modelID)
+			continue
+		}
+		if r.channelHealth.IsKeyNearlyExhausted(dbKey.ID) {
+			r.logger.Debug("密钥剩余额度即将耗尽，降低优先级", "api_key_id", dbKey.ID, "model_id", modelID)
+			lowQuotaKeys = append(lowQuotaKeys, key)
+			continue
+		}
+		apiKeys = append(apiKeys, key)
+	}
+	if len(apiKeys) == 0 {
+		return lowQuotaKeys
+	}
+	return apiKeys
 }
 
 // GetModelByID 根据 ID 获取模型信息
@@ -38,13 +103,7 @@ func (r *Repository) GetModelByID(ctx context.Context, id uint) (routing.Model,
 	}
 
 	// 转换 APIKeys
-	apiKeys := make([]routing.APIKey, len(dbModel.APIKeys))
-	for i, dbKey := range dbModel.APIKeys {
-		apiKeys[i] = routing.APIKey{
-			ID:    dbKey.ID,
-			Value: dbKey.Value,
-		}
-	}
+	apiKeys := r.convertModelKeys(ctx, dbModel.PlatformID, dbModel.ID, dbModel.APIKeys)
 
 	// 转换为 routing.Model 类型
 	model := routing.Model{
@@ -107,13 +166,7 @@ func (r *Repository) FindModelsWithDefaultEndpoint(ctx context.Context, name str
 	modelsWithEndpoint := make([]routing.ModelWithEndpoint, 0, len(dbModels))
 	for _, model := range dbModels {
 		// 转换 APIKeys
-		apiKeys := make([]routing.APIKey, len(model.APIKeys))
-		for j, dbKey := range model.APIKeys {
-			apiKeys[j] = routing.APIKey{
-				ID:    dbKey.ID,
-				Value: dbKey.Value,
-			}
-		}
+		apiKeys := r.convertModelKeys(ctx, model.PlatformID, model.ID, model.APIKeys)
 
 		// 转换 CustomHeaders
 		endpointCustomHeaders := copyStringMap(model.Platform.Endpoints[0].CustomHeaders)
@@ -147,6 +200,7 @@ func (r *Repository) FindModelsWithDefaultEndpoint(ctx context.Context, name str
 	}
 
 	repoLogger.Debug("模型查询成功", "name", name, "found_count", len(modelsWithEndpoint))
+	r.observeRoute(ctx, modelsWithEndpoint)
 	return modelsWithEndpoint, nil
 }
 
@@ -201,13 +255,7 @@ func (r *Repository) FindModelsWithEndpoint(ctx context.Context, name, endpointT
 	modelsWithEndpoint := make([]routing.ModelWithEndpoint, 0, len(dbModels))
 	for _, model := range dbModels {
 		// 转换 APIKeys
-		apiKeys := make([]routing.APIKey, len(model.APIKeys))
-		for j, dbKey := range model.APIKeys {
-			apiKeys[j] = routing.APIKey{
-				ID:    dbKey.ID,
-				Value: dbKey.Value,
-			}
-		}
+		apiKeys := r.convertModelKeys(ctx, model.PlatformID, model.ID, model.APIKeys)
 
 		// 转换 CustomHeaders
 		endpointCustomHeaders := copyStringMap(model.Platform.Endpoints[0].CustomHeaders)
@@ -241,9 +289,44 @@ func (r *Repository) FindModelsWithEndpoint(ctx context.Context, name, endpointT
 	}
 
 	repoLogger.Debug("模型查询成功", "name", name, "endpoint_type", endpointType, "endpoint_variant", endpointVariant, "found_count", len(modelsWithEndpoint))
+	r.observeRoute(ctx, modelsWithEndpoint)
 	return modelsWithEndpoint, nil
 }
 
+// observeRoute 为请求登记表记录本次上游尝试的候选通道，并在可用候选通道均属于同一平台时通知观察者本次上游尝试所属的平台。
+//
+// 候选通道分属多个平台时由 portal 选择通道，此处无法得知结果，不通知；
+// 该尝试由观察者在请求日志写入时按实际通道计入平台。
+func (r *Repository) observeRoute(ctx context.Context, models []routing.ModelWithEndpoint) {
+	r.tracker.Route(ctx, models)
+
+	observer, ok := r.observer.(RequestRouteObserver)
+	if !ok {
+		return
+	}
+	info, ok := stats.RequestInfoFromContext(ctx)
+	if !ok || info.Key == 0 {
+		return
+	}
+
+	var platformID uint
+	for _, model := range models {
+		if len(model.Model.APIKeys) == 0 {
+			continue
+		}
+		if platformID != 0 && platformID != model.Platform.ID {
+			return
+		}
+		platformID = model.Platform.ID
+	}
+	if platformID == 0 {
+		return
+	}
+
+	info.PlatformID = platformID
+	observer.ObserveRequestRoute(info)
+}
+
 // GetPlatformByID 根据 ID 获取平台信息
 //
 // 参数：
@@ -326,7 +409,7 @@ func (r *Repository) GetAllAPIKeysByPlatformID(ctx context.Context, platformID u
 
 // CreateRequestLog 创建请求日志
 //
-// 保存请求日志到数据库
+// 保存请求日志到数据库，并在同一事务内增量累加小时汇总
 //
 // 参数：
 //   - ctx: 上下文
@@ -383,10 +466,30 @@ func (r *Repository) CreateRequestLog(ctx context.Context, log *request.RequestL
 		firstByteTime := log.FirstByteTime.Microseconds()
 		dbLog.FirstByteTime = &firstByteTime
 	}
+	if info, ok := r.tracker.Match(log); ok {
+		dbLog.RequestID = truncateRequestID(info.RequestID)
+		dbLog.RequestKey = info.Key
+	}
+
+	// 计算请求费用，失败时仅记录日志，不影响请求日志写入
+	if err := stats.PriceRequestLog(ctx, dbLog); err != nil {
+		repoLogger.Warn("计算请求费用失败", "error", err)
+	}
 
 	// 保存到数据库
 	repoLogger.Debug("保存请求日志到数据库")
-	err := query.Q.WithContext(ctx).RequestLog.Create(dbLog)
+	err := query.Q.Transaction(func(tx *query.Query) error {
+		if err := tx.RequestLog.WithContext(ctx).Create(dbLog); err != nil {
+			return err
+		}
+		return stats.RecordRequestLogRollup(ctx, tx.RequestLog.WithContext(ctx).UnderlyingDB(), dbLog)
+	})
+
+	// 落库失败时同样通知观察者，保证实时统计与健康状态反映该请求；此时日志 ID 为 0
+	if r.observer != nil {
+		r.observer.ObserveRequestLog(dbLog)
+	}
+
 	if err != nil {
 		repoLogger.Error("保存请求日志失败",
 			"error", err,
@@ -399,6 +502,14 @@ func (r *Repository) CreateRequestLog(ctx context.Context, log *request.RequestL
 	return nil
 }
 
+// truncateRequestID 截断过长的入站请求 ID，使其符合请求日志列宽
+func truncateRequestID(requestID string) string {
+	if len(requestID) > maxRequestIDLength {
+		return requestID[:maxRequestIDLength]
+	}
+	return requestID
+}
+
 func copyStringMap(src map[string]string) map[string]string {
 	if len(src) == 0 {
 		return nil
@@ -0,0 +1,122 @@
+package repository_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/stats"
+	"github.com/MeowSalty/pinai/internal/infra/portal/repository"
+	"github.com/MeowSalty/portal/request"
+	"github.com/MeowSalty/portal/routing"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type recordingObserver struct {
+	logs []*types.RequestLog
+}
+
+func (o *recordingObserver) ObserveRequestLog(log *types.RequestLog) {
+	o.logs = append(o.logs, log)
+}
+
+func TestCreateRequestLog_落库失败仍通知观察者(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	// 未迁移请求日志表，写入必然失败
+	if err := db.AutoMigrate(&types.ModelPrice{}); err != nil {
+		t.Fatalf("迁移数据表失败: %v", err)
+	}
+	query.SetDefault(db)
+
+	observer := &recordingObserver{}
+	tracker := repository.NewRequestTracker()
+	repo := repository.New(slog.Default(), observer, nil, tracker)
+
+	ctx := stats.ContextWithRequestInfo(context.Background(), stats.RequestInfo{Key: 1, RequestID: "req-failed"})
+	release := tracker.Track(ctx, "gpt-4o", true)
+	defer release()
+	tracker.Route(ctx, []routing.ModelWithEndpoint{{
+		Model:    routing.Model{ID: 1, PlatformID: 1, APIKeys: []routing.APIKey{{ID: 1}}},
+		Platform: routing.Platform{ID: 1},
+	}})
+
+	err = repo.CreateRequestLog(context.Background(), &request.RequestLog{
+		Timestamp:         time.Now(),
+		OriginalModelName: "gpt-4o",
+		IsStream:          true,
+		PlatformID:        1,
+		ModelID:           1,
+		APIKeyID:          1,
+	})
+	if err == nil {
+		t.Fatal("请求日志表不存在时应返回错误")
+	}
+	if len(observer.logs) != 1 || observer.logs[0].RequestID != "req-failed" || observer.logs[0].ID != 0 {
+		t.Fatalf("落库失败时观察者应收到带请求 ID 的日志: %+v", observer.logs)
+	}
+}
+
+type routeRecordingObserver struct {
+	recordingObserver
+	routes []stats.RequestInfo
+}
+
+func (o *routeRecordingObserver) ObserveRequestRoute(info stats.RequestInfo) {
+	o.routes = append(o.routes, info)
+}
+
+func TestFindModelsWithDefaultEndpoint_候选通道属于同一平台时通知路由(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&types.Platform{}, &types.Endpoint{}, &types.Model{}, &types.APIKey{}); err != nil {
+		t.Fatalf("迁移数据表失败: %v", err)
+	}
+	query.SetDefault(db)
+
+	for _, platform := range []types.Platform{
+		{ID: 1, Name: "openai", Endpoints: []types.Endpoint{{EndpointType: "openai", IsDefault: true}}},
+		{ID: 2, Name: "backup", Endpoints: []types.Endpoint{{EndpointType: "openai", IsDefault: true}}},
+	} {
+		if err := db.Create(&platform).Error; err != nil {
+			t.Fatalf("创建平台失败: %v", err)
+		}
+	}
+	// 平台 2 的模型没有密钥，不会被路由选中
+	if err := db.Create(&types.Model{ID: 1, PlatformID: 1, Name: "gpt-4o", APIKeys: []types.APIKey{{PlatformID: 1, Value: "sk-1"}}}).Error; err != nil {
+		t.Fatalf("创建模型失败: %v", err)
+	}
+	if err := db.Create(&types.Model{ID: 2, PlatformID: 2, Name: "gpt-4o"}).Error; err != nil {
+		t.Fatalf("创建模型失败: %v", err)
+	}
+
+	observer := &routeRecordingObserver{}
+	repo := repository.New(slog.Default(), observer, nil, nil)
+	ctx := stats.ContextWithRequestInfo(context.Background(), stats.RequestInfo{Key: 1, RequestID: "req-route", Model: "gpt-4o"})
+
+	if _, err := repo.FindModelsWithDefaultEndpoint(ctx, "gpt-4o"); err != nil {
+		t.Fatalf("查询模型失败: %v", err)
+	}
+	if len(observer.routes) != 1 || observer.routes[0].RequestID != "req-route" || observer.routes[0].PlatformID != 1 {
+		t.Fatalf("路由通知不符: %+v", observer.routes)
+	}
+
+	// 候选通道分属多个平台时无法确定平台，不通知
+	if err := db.Create(&types.APIKey{PlatformID: 2, Value: "sk-2", Models: []types.Model{{ID: 2}}}).Error; err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	if _, err := repo.FindModelsWithDefaultEndpoint(ctx, "gpt-4o"); err != nil {
+		t.Fatalf("查询模型失败: %v", err)
+	}
+	if len(observer.routes) != 1 {
+		t.Fatalf("多平台候选时不应通知路由: %+v", observer.routes)
+	}
+}
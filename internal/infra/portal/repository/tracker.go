@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MeowSalty/pinai/internal/app/stats"
+	"github.com/MeowSalty/portal/request"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// RequestTracker 关联进行中的数据面请求与 portal 写入的请求日志。
+//
+// portal 落库请求日志时不传递请求上下文，门面在调用 portal 期间登记请求；
+// portal 每次上游尝试前以请求上下文查询候选通道，仓储据此为登记项记录本次尝试的候选通道。
+// 写入日志时按日志中的实际通道（平台、模型、密钥）查找尚在等待日志的尝试，
+// 仅当恰好一个请求的尝试包含该通道时关联；同一模型的并发请求候选通道相同而无法区分时，
+// 日志不关联请求，不按时间猜测。
+type RequestTracker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	entries map[uint64]*trackedRequest
+}
+
+// trackedRequest 表示一个进行中的数据面请求
+type trackedRequest struct {
+	info     stats.RequestInfo
+	model    string
+	stream   bool
+	attempts []map[channelKey]struct{} // 尚未写入日志的上游尝试的候选通道，按路由先后排列
+}
+
+// channelKey 标识 portal 选中的通道
+type channelKey struct {
+	platformID uint
+	modelID    uint
+	apiKeyID   uint
+}
+
+// NewRequestTracker 创建请求登记表
+func NewRequestTracker() *RequestTracker {
+	return &RequestTracker{entries: make(map[uint64]*trackedRequest)}
+}
+
+// Track 登记 ctx 携带的数据面请求，model 为模型映射后传给 portal 的模型名称。
+//
+// 返回的 release 在 portal 调用结束（流式请求为事件通道关闭）后调用；
+// ctx 未携带请求信息时不登记，release 为空操作。
+func (t *RequestTracker) Track(ctx context.Context, model string, stream bool) (release func()) {
+	info, ok := stats.RequestInfoFromContext(ctx)
+	if t == nil || !ok || info.Key == 0 {
+		return func() {}
+	}
+
+	t.mu.Lock()
+	t.nextID++
+	id := t.nextID
+	t.entries[id] = &trackedRequest{info: info, model: model, stream: stream}
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			delete(t.entries, id)
+			t.mu.Unlock()
+		})
+	}
+}
+
+// Route 为 ctx 对应的登记项记录一次上游尝试的候选通道，models 为仓储返回给 portal 的候选
+func (t *RequestTracker) Route(ctx context.Context, models []routing.ModelWithEndpoint) {
+	info, ok := stats.RequestInfoFromContext(ctx)
+	if t == nil || !ok || info.Key == 0 {
+		return
+	}
+
+	candidates := make(map[channelKey]struct{})
+	for _, model := range models {
+		for _, key := range model.Model.APIKeys {
+			candidates[channelKey{platformID: model.Platform.ID, modelID: model.Model.ID, apiKeyID: key.ID}] = struct{}{}
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, entry := range t.entries {
+		if entry.info.Key == info.Key {
+			entry.attempts = append(entry.attempts, candidates)
+		}
+	}
+}
+
+// Match 返回与请求日志对应的数据面请求信息，无法唯一确定时返回 false
+func (t *RequestTracker) Match(log *request.RequestLog) (stats.RequestInfo, bool) {
+	if t == nil || log == nil {
+		return stats.RequestInfo{}, false
+	}
+	channel := channelKey{platformID: log.PlatformID, modelID: log.ModelID, apiKeyID: log.APIKeyID}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var (
+		matched *trackedRequest
+		attempt int
+	)
+	for _, entry := range t.entries {
+		if entry.model != log.OriginalModelName || entry.stream != log.IsStream {
+			continue
+		}
+		i := entry.attemptIndex(channel)
+		if i < 0 {
+			continue
+		}
+		if matched != nil {
+			return stats.RequestInfo{}, false
+		}
+		matched, attempt = entry, i
+	}
+	if matched == nil {
+		return stats.RequestInfo{}, false
+	}
+
+	matched.attempts = append(matched.attempts[:attempt], matched.attempts[attempt+1:]...)
+	return matched.info, true
+}
+
+// attemptIndex 返回最早一次候选通道包含 channel 的尝试下标，不存在时返回 -1
+func (r *trackedRequest) attemptIndex(channel channelKey) int {
+	for i, candidates := range r.attempts {
+		if _, ok := candidates[channel]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// TrackStream 转发 portal 事件通道，并在上游通道关闭（portal 已写入请求日志）后调用 release。
+//
+// 调用方取消 ctx 后停止转发并继续排空上游通道，避免 portal 阻塞在发送上。
+func TrackStream[T any](ctx context.Context, release func(), in <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		defer release()
+		for event := range in {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				for range in {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,169 @@
+package repository_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/pinai/database/query"
+	"github.com/MeowSalty/pinai/database/types"
+	"github.com/MeowSalty/pinai/internal/app/stats"
+	"github.com/MeowSalty/pinai/internal/handler/data/common"
+	"github.com/MeowSalty/pinai/internal/infra/portal/repository"
+	"github.com/MeowSalty/portal/request"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCreateRequestLog_按请求ID回查请求日志(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&types.RequestLog{}, &types.RequestLogHourlyStat{}, &types.RequestLogLatencyBin{}, &types.RequestLogHourlyUsage{}, &types.RequestLogHourlyError{}, &types.ModelPrice{}, &types.Platform{}, &types.Endpoint{}, &types.Model{}, &types.APIKey{}); err != nil {
+		t.Fatalf("迁移数据表失败: %v", err)
+	}
+	query.SetDefault(db)
+	seedTrackerChannels(t, db)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/openai/v1/chat/completions", nil)
+	c.Request.Header.Set(common.RequestIDHeader, "req-known-0001")
+	common.EnsureRequestID(c)
+	ctx := common.NewRequestLogContext(c, "openai", "compat", "chat_completions").WithModel("gpt-4o").WithContext(c.Request.Context())
+
+	tracker := repository.NewRequestTracker()
+	repo := repository.New(slog.Default(), nil, nil, tracker)
+
+	// 并发的其他模型请求不应被关联
+	otherCtx := stats.ContextWithRequestInfo(context.Background(), stats.RequestInfo{Key: stats.NewRequestKey(), RequestID: "req-other"})
+	releaseOther := tracker.Track(otherCtx, "claude", false)
+	defer releaseOther()
+
+	release := tracker.Track(ctx, "gpt-4o", false)
+	if _, err := repo.FindModelsWithDefaultEndpoint(ctx, "gpt-4o"); err != nil {
+		t.Fatalf("查询模型失败: %v", err)
+	}
+	if err := repo.CreateRequestLog(context.Background(), &request.RequestLog{
+		Timestamp:         time.Now(),
+		OriginalModelName: "gpt-4o",
+		ModelName:         "gpt-4o",
+		PlatformID:        1,
+		ModelID:           1,
+		APIKeyID:          1,
+		Success:           true,
+	}); err != nil {
+		t.Fatalf("写入请求日志失败: %v", err)
+	}
+	release()
+
+	// 请求结束后写入的日志不再关联请求 ID
+	if err := repo.CreateRequestLog(context.Background(), &request.RequestLog{Timestamp: time.Now(), OriginalModelName: "gpt-4o", PlatformID: 1, ModelID: 1, APIKeyID: 1}); err != nil {
+		t.Fatalf("写入请求日志失败: %v", err)
+	}
+
+	svc := stats.New(slog.Default())
+	detail, err := svc.GetRequestLog(context.Background(), "req-known-0001")
+	if err != nil {
+		t.Fatalf("按请求 ID 获取请求日志失败: %v", err)
+	}
+	if detail.RequestID != "req-known-0001" || detail.OriginalModelName != "gpt-4o" || !detail.Success {
+		t.Fatalf("请求日志不符: %+v", detail.RequestLog)
+	}
+
+	byID, err := svc.GetRequestLog(context.Background(), "2")
+	if err != nil {
+		t.Fatalf("按请求日志 ID 获取请求日志失败: %v", err)
+	}
+	if byID.ID != 2 || byID.RequestID != "" {
+		t.Fatalf("未关联请求的日志不应带请求 ID: %+v", byID.RequestLog)
+	}
+}
+
+// unavailableKeys 将指定密钥视为在所有模型上不可用
+type unavailableKeys map[uint]bool
+
+func (k unavailableKeys) IsKeyModelAvailable(keyID, _ uint) bool { return !k[keyID] }
+
+func (k unavailableKeys) IsKeyNearlyExhausted(uint) bool { return false }
+
+func (k unavailableKeys) ChannelAvailableAt(_, _, _ uint, _ time.Time) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// seedTrackerChannels 创建平台 1 上带两个密钥的 gpt-4o 模型
+func seedTrackerChannels(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	platform := types.Platform{ID: 1, Name: "openai", Endpoints: []types.Endpoint{{EndpointType: "openai", IsDefault: true}}}
+	if err := db.Create(&platform).Error; err != nil {
+		t.Fatalf("创建平台失败: %v", err)
+	}
+	model := types.Model{ID: 1, PlatformID: 1, Name: "gpt-4o", APIKeys: []types.APIKey{{ID: 1, PlatformID: 1, Value: "sk-1"}, {ID: 2, PlatformID: 1, Value: "sk-2"}}}
+	if err := db.Create(&model).Error; err != nil {
+		t.Fatalf("创建模型失败: %v", err)
+	}
+}
+
+func TestRequestTracker_同一模型的并发请求按实际通道关联(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&types.Platform{}, &types.Endpoint{}, &types.Model{}, &types.APIKey{}); err != nil {
+		t.Fatalf("迁移数据表失败: %v", err)
+	}
+	query.SetDefault(db)
+	seedTrackerChannels(t, db)
+
+	tracker := repository.NewRequestTracker()
+	health := unavailableKeys{}
+	repo := repository.New(slog.Default(), nil, health, tracker)
+
+	newRequest := func(requestID string) context.Context {
+		return stats.ContextWithRequestInfo(context.Background(), stats.RequestInfo{Key: stats.NewRequestKey(), RequestID: requestID})
+	}
+	route := func(ctx context.Context) {
+		if _, err := repo.FindModelsWithDefaultEndpoint(ctx, "gpt-4o"); err != nil {
+			t.Fatalf("查询模型失败: %v", err)
+		}
+	}
+	match := func(keyID uint) string {
+		info, ok := tracker.Match(&request.RequestLog{OriginalModelName: "gpt-4o", PlatformID: 1, ModelID: 1, APIKeyID: keyID})
+		if !ok {
+			return ""
+		}
+		return info.RequestID
+	}
+
+	// 先开始的请求可使用两个密钥，密钥 1 退避后开始的请求只能使用密钥 2
+	first, second := newRequest("req-first"), newRequest("req-second")
+	releaseFirst := tracker.Track(first, "gpt-4o", false)
+	defer releaseFirst()
+	releaseSecond := tracker.Track(second, "gpt-4o", false)
+	defer releaseSecond()
+	route(first)
+	health[1] = true
+	route(second)
+
+	// 密钥 1 的日志只可能属于先开始的请求，不按开始时间关联到后开始的请求
+	if got := match(1); got != "req-first" {
+		t.Fatalf("密钥 1 的日志应关联先开始的请求: %q", got)
+	}
+	// 先开始的请求的尝试已写入日志，密钥 2 的日志只可能属于后开始的请求
+	if got := match(2); got != "req-second" {
+		t.Fatalf("密钥 2 的日志应关联后开始的请求: %q", got)
+	}
+
+	// 两个请求的候选通道相同时无法区分，日志不关联请求而不是交换请求 ID
+	health[1] = false
+	route(first)
+	route(second)
+	if got := match(1); got != "" {
+		t.Fatalf("无法区分的日志不应关联请求: %q", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 
 	"github.com/MeowSalty/pinai/internal/app/gateway"
+	"github.com/MeowSalty/pinai/internal/infra/portal/repository"
 )
 
 var _ gateway.GatewayPort = (*facadeService)(nil)
@@ -15,6 +16,7 @@ var _ gateway.GatewayPort = (*facadeService)(nil)
 type facadeService struct {
 	runtime          gatewayRuntime
 	modelMappingRule map[string]string
+	tracker          *repository.RequestTracker
 	logger           *slog.Logger
 }
 
@@ -22,6 +24,7 @@ func newFacadeService(logger *slog.Logger, deps *portalFacadeDependencies) Servi
 	return &facadeService{
 		runtime:          deps.Runtime,
 		modelMappingRule: deps.ModelMappingRule,
+		tracker:          deps.RequestTracker,
 		logger:           logger,
 	}
 }
@@ -35,15 +38,16 @@ func newFacadeService(logger *slog.Logger, deps *portalFacadeDependencies) Servi
 //   - logger: 日志记录器实例，用于记录处理过程中的日志信息
 //   - modelMappingStr: 模型映射规则字符串，格式为 "key1:value1,key2:value2"
 //   - healthStorage: 健康状态存储实例（最小依赖契约）
+//   - logObserver: 请求日志落库后的观察者，可为 nil
 //
 // 返回值：
 //   - Service: 初始化后的 Portal 服务实例
 //   - error: 初始化过程中可能出现的错误
-func New(ctx context.Context, logger *slog.Logger, modelMappingStr string, healthStorage HealthStorage) (Service, error) {
+func New(ctx context.Context, logger *slog.Logger, modelMappingStr string, healthStorage HealthStorage, logObserver RequestLogObserver) (Service, error) {
 	logger.Info("开始初始化 Portal 服务", "model_mapping", modelMappingStr)
 	_ = ctx
 
-	deps, err := assemblePortalFacadeDependencies(logger, modelMappingStr, healthStorage)
+	deps, err := assemblePortalFacadeDependencies(logger, modelMappingStr, healthStorage, logObserver)
 	if err != nil {
 		return nil, err
 	}
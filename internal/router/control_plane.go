@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	appbootstrap "github.com/MeowSalty/pinai/internal/bootstrap"
+	"github.com/MeowSalty/pinai/internal/handler/control/alert"
+	"github.com/MeowSalty/pinai/internal/handler/control/audit"
 	"github.com/MeowSalty/pinai/internal/handler/control/health"
 	"github.com/MeowSalty/pinai/internal/handler/control/provider"
 	"github.com/MeowSalty/pinai/internal/handler/control/proxy"
@@ -25,7 +27,7 @@ func SetupControlPlaneRoutes(webAPI *gin.RouterGroup, svcs *appbootstrap.Service
 	// 条件注册代理路由（需 ProxyEnabled=true 且 AdminToken 非空）
 	if config.ProxyEnabled && config.AdminToken != "" {
 		proxyAPI := webAPI.Group("/proxy")
-		proxy.SetupProxyRoutes(proxyAPI, config.ApiToken, config.UserAgent, logger)
+		proxy.SetupProxyRoutes(proxyAPI, config.ApiToken, config.UserAgent, svcs.AuditService, logger)
 	}
 
 	webAPI.GET("/ping", func(c *gin.Context) {
@@ -35,6 +37,8 @@ func SetupControlPlaneRoutes(webAPI *gin.RouterGroup, svcs *appbootstrap.Service
 	})
 
 	provider.SetupProviderRoutes(webAPI, svcs.ProviderService)
-	stats.SetupStatsRoutes(webAPI, svcs.StatsService, logger)
+	stats.SetupStatsRoutes(webAPI, svcs.StatsService, svcs.ProviderService, logger)
 	health.SetupHealthRoutes(webAPI, svcs.HealthService, logger)
+	alert.SetupAlertRoutes(webAPI, svcs.AlertService, logger)
+	audit.SetupAuditRoutes(webAPI, svcs.AuditService, logger)
 }
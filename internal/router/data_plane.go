@@ -3,9 +3,10 @@ package router
 import (
 	"log/slog"
 
+	"github.com/MeowSalty/pinai/internal/app/stats"
 	appbootstrap "github.com/MeowSalty/pinai/internal/bootstrap"
+	"github.com/MeowSalty/pinai/internal/handler/data/common"
 	multi "github.com/MeowSalty/pinai/internal/handler/data/compat"
-	"github.com/MeowSalty/pinai/internal/app/stats"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,6 +21,9 @@ type DataPlaneConfig struct {
 func SetupDataPlaneRoutes(web *gin.Engine, svcs *appbootstrap.Services, config DataPlaneConfig, logger *slog.Logger) {
 	multiAPI := web.Group("/multi")
 
+	// 为数据面响应回显请求 ID，便于客户端反馈问题时定位请求
+	multiAPI.Use(createRequestIDMiddleware())
+
 	// 为业务 API 添加统计采集中间件
 	multiAPI.Use(createStatsCollectorMiddleware(svcs.StatsCollector))
 
@@ -40,3 +44,13 @@ func createStatsCollectorMiddleware(collector *stats.Collector) gin.HandlerFunc
 		c.Next()
 	}
 }
+
+// createRequestIDMiddleware 创建请求 ID 中间件。
+//
+// 沿用入站请求 ID 或生成新 ID，并在所有数据面响应（含流式）中通过 X-Request-ID 回显。
+func createRequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		common.EnsureRequestID(c)
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MeowSalty/pinai/internal/app/audit"
+)
+
+// createAuditActorMiddleware 创建审计操作者中间件
+//
+// 将管理令牌指纹、客户端 IP 与请求 ID 写入请求上下文，供控制面审计记录读取。
+func createAuditActorMiddleware(adminToken string) gin.HandlerFunc {
+	identity := audit.TokenIdentity(adminToken)
+
+	return func(c *gin.Context) {
+		actor := audit.Actor{
+			Identity:  identity,
+			ClientIP:  c.ClientIP(),
+			RequestID: strings.TrimSpace(c.GetHeader("X-Request-ID")),
+		}
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), actor))
+
+		c.Next()
+	}
+}
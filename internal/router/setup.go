@@ -27,6 +27,9 @@ func setupAPIRootGroup(web *gin.Engine, config Config) *gin.RouterGroup {
 		webAPI.Use(createOpenAIAuthMiddleware(config.AdminToken))
 	}
 
+	// 鉴权通过后记录操作者身份，供控制面审计使用。
+	webAPI.Use(createAuditActorMiddleware(config.AdminToken))
+
 	return webAPI
 }
 
@@ -55,6 +58,7 @@ func createAllowAllCORSConfig() cors.Config {
 		ExposeHeaders: []string{
 			"Content-Length",
 			"Content-Type",
+			"X-Request-ID",
 		},
 		AllowCredentials: false,
 		MaxAge:           24 * time.Hour,
@@ -96,7 +96,19 @@ func newBootstrapRuntime(cfg *config.Config) *bootstrapRuntime {
 
 	// 初始化服务
 	appContext := context.Background()
-	svcs, err := appbootstrap.NewServices(appContext, appLogger.WithGroup("services"), cfg.ModelMapping)
+	svcs, err := appbootstrap.NewServices(appContext, appLogger.WithGroup("services"), appbootstrap.Options{
+		ModelMapping:               cfg.ModelMapping,
+		RequestLogRetentionDays:    cfg.RequestLogRetentionDays,
+		HealthProbeInterval:        time.Duration(cfg.HealthProbeInterval) * time.Second,
+		HealthProbeModels:          cfg.HealthProbeModels,
+		HealthSyncInterval:         time.Duration(cfg.HealthSyncInterval) * time.Second,
+		HealthHistoryRetentionDays: cfg.HealthHistoryRetentionDays,
+		AlertDeliveryRetentionDays: cfg.AlertDeliveryRetentionDays,
+		ProvidersFile:              cfg.ProvidersFile,
+		ModelSyncInterval:          time.Duration(cfg.ModelSyncInterval) * time.Second,
+		TaskConcurrency:            cfg.TaskConcurrency,
+		TaskRetentionDays:          cfg.TaskRetentionDays,
+	})
 	if err != nil {
 		appLogger.Error("服务初始化失败", "error", err)
 		if closeErr := db.Close(); closeErr != nil {